@@ -3,6 +3,9 @@ package alias
 import (
 	"context"
 	"errors"
+	"fmt"
+	"os"
+	"regexp"
 	"strings"
 
 	"github.com/pingme998/rclone/fs"
@@ -20,7 +23,7 @@ func init() {
 		NewFs:       NewFs,
 		Options: []fs.Option{{
 			Name:     "remote",
-			Help:     "Remote or path to alias.\nCan be \"myremote:path/to/dir\", \"myremote:bucket\", \"myremote:\" or \"/local/path\".",
+			Help:     "Remote or path to alias.\nCan be \"myremote:path/to/dir\", \"myremote:bucket\", \"myremote:\" or \"/local/path\".\nMay contain parameters like ${user} - see the docs.",
 			Required: true,
 		}},
 	}
@@ -48,5 +51,45 @@ func NewFs(ctx context.Context, name, root string, m configmap.Mapper) (fs.Fs, e
 	if strings.HasPrefix(opt.Remote, name+":") {
 		return nil, errors.New("can't point alias remote at itself - check the value of the remote setting")
 	}
-	return cache.Get(ctx, fspath.JoinRootPath(opt.Remote, root))
+	remote, err := expandParams(opt.Remote, m)
+	if err != nil {
+		return nil, err
+	}
+	return cache.Get(ctx, fspath.JoinRootPath(remote, root))
+}
+
+// matches a parameter in the remote, eg ${user}
+var paramRe = regexp.MustCompile(`\$\{([a-zA-Z0-9_]+)\}`)
+
+// expandParams replaces the parameters like ${user} in remote.
+//
+// The value of ${user} is read from the config parameter "user", which
+// may be set in the connection string, or from the environment
+// variable RCLONE_ALIAS_USER.
+//
+// The values must be a single path segment so they can't be used to
+// reach outside the intended directory.
+func expandParams(remote string, m configmap.Getter) (string, error) {
+	var err error
+	remote = paramRe.ReplaceAllStringFunc(remote, func(param string) string {
+		key := paramRe.FindStringSubmatch(param)[1]
+		value, ok := m.Get(key)
+		if !ok {
+			value, ok = os.LookupEnv("RCLONE_ALIAS_" + strings.ToUpper(key))
+		}
+		if !ok {
+			if err == nil {
+				err = fmt.Errorf("alias: no value for %s - set it in the connection string, eg \"remote,%s=value:\" or with $RCLONE_ALIAS_%s", param, key, strings.ToUpper(key))
+			}
+			return param
+		}
+		if value == "" || value == "." || value == ".." || strings.ContainsAny(value, "/\\") {
+			if err == nil {
+				err = fmt.Errorf("alias: invalid value %q for %s", value, param)
+			}
+			return param
+		}
+		return value
+	})
+	return remote, err
 }
@@ -3,6 +3,7 @@ package alias
 import (
 	"context"
 	"fmt"
+	"os"
 	"path"
 	"path/filepath"
 	"sort"
@@ -12,7 +13,7 @@ import (
 	"github.com/pingme998/rclone/fs"
 	"github.com/pingme998/rclone/fs/config"
 	"github.com/pingme998/rclone/fs/config/configfile"
-	"github.com/pingme998/testify/require"
+	"github.com/stretchr/testify/require"
 )
 
 var (
@@ -105,3 +106,51 @@ func TestNewFSInvalidRemote(t *testing.T) {
 	require.Error(t, err)
 	require.Nil(t, f)
 }
+
+func TestNewFSParams(t *testing.T) {
+	ctx := context.Background()
+	root, err := filepath.Abs(filepath.FromSlash("test/files"))
+	require.NoError(t, err)
+	prepare(t, root+"/${dir}")
+
+	listNames := func(f fs.Fs) (names []string) {
+		entries, err := f.List(ctx, "")
+		require.NoError(t, err)
+		sort.Sort(entries)
+		for _, entry := range entries {
+			names = append(names, entry.Remote())
+		}
+		return names
+	}
+
+	// No value for the parameter
+	_, err = fs.NewFs(ctx, remoteName+":")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "no value for ${dir}")
+
+	// Value from the connection string
+	f, err := fs.NewFs(ctx, remoteName+",dir=four:")
+	require.NoError(t, err)
+	require.Equal(t, []string{"five", "under four.txt"}, listNames(f))
+
+	// Value from the environment
+	require.NoError(t, os.Setenv("RCLONE_ALIAS_DIR", "three"))
+	defer func() {
+		require.NoError(t, os.Unsetenv("RCLONE_ALIAS_DIR"))
+	}()
+	f, err = fs.NewFs(ctx, remoteName+":")
+	require.NoError(t, err)
+	require.Equal(t, []string{"underthree.txt"}, listNames(f))
+
+	// The connection string takes precedence over the environment
+	f, err = fs.NewFs(ctx, remoteName+",dir=four:")
+	require.NoError(t, err)
+	require.Equal(t, []string{"five", "under four.txt"}, listNames(f))
+
+	// Values which could escape the directory are rejected
+	for _, dir := range []string{"..", "four/five", `four\five`} {
+		_, err = fs.NewFs(ctx, remoteName+",dir='"+dir+"':")
+		require.Error(t, err, dir)
+		require.Contains(t, err.Error(), "invalid value", dir)
+	}
+}
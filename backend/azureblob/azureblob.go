@@ -30,12 +30,14 @@ import (
 	"github.com/pingme998/rclone/fs/fserrors"
 	"github.com/pingme998/rclone/fs/fshttp"
 	"github.com/pingme998/rclone/fs/hash"
+	"github.com/pingme998/rclone/fs/operations"
 	"github.com/pingme998/rclone/fs/walk"
 	"github.com/pingme998/rclone/lib/bucket"
 	"github.com/pingme998/rclone/lib/encoder"
 	"github.com/pingme998/rclone/lib/env"
 	"github.com/pingme998/rclone/lib/pacer"
 	"github.com/pingme998/rclone/lib/pool"
+	"github.com/pingme998/rclone/lib/version"
 )
 
 const (
@@ -59,10 +61,13 @@ const (
 	emulatorBlobEndpoint = "http://127.0.0.1:10000/devstoreaccount1"
 	memoryPoolFlushTime  = fs.Duration(time.Minute) // flush the cached buffers after this long
 	memoryPoolUseMmap    = false
+	defaultRehydratePoll = fs.Duration(5 * time.Minute) // how often to check rehydrating blobs
 )
 
 var (
 	errCantUpdateArchiveTierBlobs = fserrors.NoRetryError(errors.New("can't update archive tier blob without --azureblob-archive-tier-delete"))
+	errNotWithVersions            = errors.New("can't modify or delete files in --azureblob-versions mode")
+	errSoftDeleted                = errors.New("blob is soft deleted - use the undelete backend command to restore it first")
 )
 
 // Register with Fs
@@ -71,6 +76,7 @@ func init() {
 		Name:        "azureblob",
 		Description: "Microsoft Azure Blob Storage",
 		NewFs:       NewFs,
+		CommandHelp: commandHelp,
 		Options: []fs.Option{{
 			Name: "account",
 			Help: "Storage Account Name (leave blank to use SAS URL or Emulator)",
@@ -185,6 +191,60 @@ replacement.  This has the potential for data loss if the upload fails
 archive tier blobs early may be chargable.
 `, errCantUpdateArchiveTierBlobs),
 			Advanced: true,
+		}, {
+			Name: "rehydrate_tier",
+			Help: `Rehydrate archive tier blobs to this tier when reading them.
+
+Archive tier blobs can't be read until they have been rehydrated to
+the hot or cool tier, which can take many hours. Normally rclone
+gives an error if asked to read one.
+
+If this is set to hot or cool then when rclone needs to read an
+archive tier blob it asks for it to be rehydrated to this tier and
+waits until it can be read, checking every
+--azureblob-rehydrate-poll-interval. This means an archive can be
+restored with a plain "rclone copy".
+
+To rehydrate many blobs it is quicker to start them all off first with
+the "rehydrate" backend command.`,
+			Default:  "",
+			Advanced: true,
+			Examples: []fs.OptionExample{{
+				Value: "",
+				Help:  "Don't rehydrate archive tier blobs",
+			}, {
+				Value: string(azblob.AccessTierHot),
+				Help:  "Rehydrate to the hot tier",
+			}, {
+				Value: string(azblob.AccessTierCool),
+				Help:  "Rehydrate to the cool tier",
+			}},
+		}, {
+			Name:     "rehydrate_poll_interval",
+			Help:     `How often to check whether rehydrating blobs can be read.`,
+			Default:  defaultRehydratePoll,
+			Advanced: true,
+		}, {
+			Name:    "versions",
+			Default: false,
+			Help: `Include old versions in directory listings.
+
+This needs blob versioning to be enabled on the storage account. Old
+versions are shown with their version time added to the file name,
+e.g. "file-v2021-06-10-101213-123.txt", and can be read or copied.
+
+Note that when using this no file write operations are permitted,
+so you can't upload files or delete them.`,
+			Advanced: true,
+		}, {
+			Name:    "show_deleted",
+			Default: false,
+			Help: `Include soft deleted blobs in directory listings.
+
+This needs soft delete to be enabled on the storage account. Soft
+deleted blobs can't be read until they have been restored with the
+undelete backend command.`,
+			Advanced: true,
 		}, {
 			Name: "disable_checksum",
 			Help: `Don't store MD5 checksum with object metadata.
@@ -253,6 +313,10 @@ type Options struct {
 	ListChunkSize        uint                 `config:"list_chunk"`
 	AccessTier           string               `config:"access_tier"`
 	ArchiveTierDelete    bool                 `config:"archive_tier_delete"`
+	RehydrateTier        string               `config:"rehydrate_tier"`
+	RehydratePoll        fs.Duration          `config:"rehydrate_poll_interval"`
+	Versions             bool                 `config:"versions"`
+	ShowDeleted          bool                 `config:"show_deleted"`
 	UseEmulator          bool                 `config:"use_emulator"`
 	DisableCheckSum      bool                 `config:"disable_checksum"`
 	MemoryPoolFlushTime  fs.Duration          `config:"memory_pool_flush_time"`
@@ -285,14 +349,17 @@ type Fs struct {
 
 // Object describes an azure object
 type Object struct {
-	fs         *Fs                   // what this object is part of
-	remote     string                // The remote path
-	modTime    time.Time             // The modified time of the object if known
-	md5        string                // MD5 hash if known
-	size       int64                 // Size of the object
-	mimeType   string                // Content-Type of the object
-	accessTier azblob.AccessTierType // Blob Access Tier
-	meta       map[string]string     // blob metadata
+	fs            *Fs                   // what this object is part of
+	remote        string                // The remote path
+	modTime       time.Time             // The modified time of the object if known
+	md5           string                // MD5 hash if known
+	size          int64                 // Size of the object
+	mimeType      string                // Content-Type of the object
+	accessTier    azblob.AccessTierType // Blob Access Tier
+	archiveStatus string                // rehydration status of an archive tier blob
+	meta          map[string]string     // blob metadata
+	versionID     string                // version of the blob if an old version
+	deleted       bool                  // set if the blob is soft deleted
 }
 
 // ------------------------------------------------------------
@@ -534,6 +601,15 @@ func NewFs(ctx context.Context, name, root string, m configmap.Mapper) (fs.Fs, e
 			string(azblob.AccessTierHot), string(azblob.AccessTierCool), string(azblob.AccessTierArchive))
 	}
 
+	if opt.RehydrateTier != "" {
+		tier, err := parseAccessTier(opt.RehydrateTier)
+		if err != nil || tier == azblob.AccessTierArchive {
+			return nil, errors.Errorf("Azure Blob: Supported rehydrate tiers are %s and %s",
+				string(azblob.AccessTierHot), string(azblob.AccessTierCool))
+		}
+		opt.RehydrateTier = string(tier)
+	}
+
 	if !validatePublicAccess((opt.PublicAccess)) {
 		return nil, errors.Errorf("Azure Blob: Supported public access level are %s and %s",
 			string(azblob.PublicAccessBlob), string(azblob.PublicAccessContainer))
@@ -834,7 +910,8 @@ func (f *Fs) list(ctx context.Context, container, directory, prefix string, addC
 			Metadata:         true,
 			Snapshots:        false,
 			UncommittedBlobs: false,
-			Deleted:          false,
+			Deleted:          f.opt.ShowDeleted,
+			Versions:         f.opt.Versions,
 		},
 		Prefix:     directory,
 		MaxResults: int32(maxResults),
@@ -871,6 +948,14 @@ func (f *Fs) list(ctx context.Context, container, directory, prefix string, addC
 			if isDirectoryMarker(*file.Properties.ContentLength, file.Metadata, remote) {
 				continue // skip directory marker
 			}
+			if isOldVersion(file) {
+				versionTime, err := parseVersionID(*file.VersionID)
+				if err != nil {
+					fs.Debugf(f, "Skipping %q: %v", remote, err)
+					continue
+				}
+				remote = version.Add(remote, versionTime)
+			}
 			if addContainer {
 				remote = path.Join(container, remote)
 			}
@@ -1094,6 +1179,9 @@ func (f *Fs) listContainersToFn(fn listContainerFn) error {
 //
 // The new object may have been created if an error is returned
 func (f *Fs) Put(ctx context.Context, in io.Reader, src fs.ObjectInfo, options ...fs.OpenOption) (fs.Object, error) {
+	if f.opt.Versions {
+		return nil, errNotWithVersions
+	}
 	// Temporary Object under construction
 	fs := &Object{
 		fs:     f,
@@ -1109,6 +1197,9 @@ func (f *Fs) PutStream(ctx context.Context, in io.Reader, src fs.ObjectInfo, opt
 
 // Mkdir creates the container if it doesn't exist
 func (f *Fs) Mkdir(ctx context.Context, dir string) error {
+	if f.opt.Versions {
+		return errNotWithVersions
+	}
 	container, _ := f.split(dir)
 	return f.makeContainer(ctx, container)
 }
@@ -1189,6 +1280,9 @@ func (f *Fs) deleteContainer(ctx context.Context, container string) error {
 //
 // Returns an error if it isn't empty
 func (f *Fs) Rmdir(ctx context.Context, dir string) error {
+	if f.opt.Versions {
+		return errNotWithVersions
+	}
 	container, directory := f.split(dir)
 	if container == "" || directory != "" {
 		return nil
@@ -1212,6 +1306,9 @@ func (f *Fs) Hashes() hash.Set {
 
 // Purge deletes all the files and directories including the old versions.
 func (f *Fs) Purge(ctx context.Context, dir string) error {
+	if f.opt.Versions {
+		return errNotWithVersions
+	}
 	container, directory := f.split(dir)
 	if container == "" || directory != "" {
 		// Delegate to caller if not root of a container
@@ -1230,6 +1327,9 @@ func (f *Fs) Purge(ctx context.Context, dir string) error {
 //
 // If it isn't possible then return fs.ErrorCantCopy
 func (f *Fs) Copy(ctx context.Context, src fs.Object, remote string) (fs.Object, error) {
+	if f.opt.Versions {
+		return nil, errNotWithVersions
+	}
 	dstContainer, dstPath := f.split(remote)
 	err := f.makeContainer(ctx, dstContainer)
 	if err != nil {
@@ -1242,10 +1342,19 @@ func (f *Fs) Copy(ctx context.Context, src fs.Object, remote string) (fs.Object,
 	}
 	dstBlobURL := f.getBlobReference(dstContainer, dstPath)
 	srcBlobURL := srcObj.getBlobReference()
+	err = f.copyBlob(ctx, dstBlobURL, srcBlobURL)
+	if err != nil {
+		return nil, err
+	}
+	return f.NewObject(ctx, remote)
+}
 
+// copyBlob copies srcBlobURL to dstBlobURL server-side, waiting for
+// the copy to finish
+func (f *Fs) copyBlob(ctx context.Context, dstBlobURL, srcBlobURL azblob.BlobURL) (err error) {
 	source, err := url.Parse(srcBlobURL.String())
 	if err != nil {
-		return nil, err
+		return err
 	}
 
 	options := azblob.BlobAccessConditions{}
@@ -1256,7 +1365,7 @@ func (f *Fs) Copy(ctx context.Context, src fs.Object, remote string) (fs.Object,
 		return f.shouldRetry(ctx, err)
 	})
 	if err != nil {
-		return nil, err
+		return err
 	}
 
 	copyStatus := startCopy.CopyStatus()
@@ -1264,12 +1373,336 @@ func (f *Fs) Copy(ctx context.Context, src fs.Object, remote string) (fs.Object,
 		time.Sleep(1 * time.Second)
 		getMetadata, err := dstBlobURL.GetProperties(ctx, options, azblob.ClientProvidedKeyOptions{})
 		if err != nil {
-			return nil, err
+			return err
 		}
 		copyStatus = getMetadata.CopyStatus()
 	}
+	return nil
+}
 
-	return f.NewObject(ctx, remote)
+// isOldVersion returns true if the list item is a version of a blob
+// other than the current one
+func isOldVersion(info *azblob.BlobItemInternal) bool {
+	return info.VersionID != nil && *info.VersionID != "" && (info.IsCurrentVersion == nil || !*info.IsCurrentVersion)
+}
+
+// parseVersionID parses a blob version ID which is the time the
+// version was created
+func parseVersionID(versionID string) (time.Time, error) {
+	t, err := time.Parse(time.RFC3339Nano, versionID)
+	if err != nil {
+		return t, errors.Wrapf(err, "failed to parse version ID %q", versionID)
+	}
+	return t, nil
+}
+
+// listAllFn is called from listAll to handle each blob
+type listAllFn func(containerPath string, file *azblob.BlobItemInternal) error
+
+// listAll lists every blob starting with prefix in container
+// including old versions and soft deleted blobs.
+//
+// containerPath is the decoded path of the blob in the container
+func (f *Fs) listAll(ctx context.Context, container, prefix string, fn listAllFn) error {
+	options := azblob.ListBlobsSegmentOptions{
+		Details: azblob.BlobListingDetails{
+			Metadata: true,
+			Deleted:  true,
+			Versions: true,
+		},
+		Prefix:     f.opt.Enc.FromStandardPath(prefix),
+		MaxResults: int32(f.opt.ListChunkSize),
+	}
+	for marker := (azblob.Marker{}); marker.NotDone(); {
+		var response *azblob.ListBlobsFlatSegmentResponse
+		err := f.pacer.Call(func() (bool, error) {
+			var err error
+			response, err = f.cntURL(container).ListBlobsFlatSegment(ctx, marker, options)
+			return f.shouldRetry(ctx, err)
+		})
+		if err != nil {
+			return err
+		}
+		marker = response.NextMarker
+		for i := range response.Segment.BlobItems {
+			file := &response.Segment.BlobItems[i]
+			err = fn(f.opt.Enc.ToStandardPath(file.Name), file)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// findVersion finds the old version of a blob from containerPath
+// which has a version suffix as shown in --azureblob-versions
+// listings.
+func (f *Fs) findVersion(ctx context.Context, container, containerPath string) (info *azblob.BlobItemInternal, err error) {
+	_, blobPath := version.Remove(containerPath)
+	err = f.listAll(ctx, container, blobPath, func(name string, file *azblob.BlobItemInternal) error {
+		if name != blobPath || !isOldVersion(file) {
+			return nil
+		}
+		versionTime, err := parseVersionID(*file.VersionID)
+		if err != nil {
+			return nil
+		}
+		if version.Add(blobPath, versionTime) == containerPath {
+			info = file
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if info == nil {
+		return nil, fs.ErrorObjectNotFound
+	}
+	return info, nil
+}
+
+var commandHelp = []fs.CommandHelp{{
+	Name:  "undelete",
+	Short: "Restore soft deleted blobs",
+	Long: `This command restores soft deleted blobs, which need soft delete to
+be enabled on the storage account.
+
+Usage Examples:
+
+    rclone backend undelete azureblob:container/path/to/blob
+    rclone backend undelete azureblob:container/path/to/directory
+    rclone backend undelete azureblob:container
+
+Soft deleted blobs can be seen with the --azureblob-show-deleted flag.
+
+Note that if blob versioning is enabled then deleting a blob doesn't
+soft delete it, instead the current version becomes an old version. Use
+the restore-version command to restore those.
+
+It returns a list of status dictionaries with Remote and Status
+keys. The Status will be OK if it was successful or an error message
+if not.
+
+    [
+        {
+            "Status": "OK",
+            "Remote": "test.txt"
+        }
+    ]
+`,
+}, {
+	Name:  "restore-version",
+	Short: "Make an old version of a blob the current version",
+	Long: `This command copies an old version of a blob over the current
+version, which needs blob versioning to be enabled on the storage
+account.
+
+Pass the names of the old versions as shown by listing with the
+--azureblob-versions flag
+
+    rclone backend restore-version azureblob:container/path/file-v2021-06-10-101213-123.txt
+
+Or pass the name of the blob and the exact version ID
+
+    rclone backend restore-version azureblob:container/path/file.txt -o version-id=2021-06-10T10:12:13.1234567Z
+
+The old version is kept. It returns a list of status dictionaries as
+the undelete command does.
+`,
+	Opts: map[string]string{
+		"version-id": "Version ID of the version to restore",
+	},
+}, {
+	Name:  "settier",
+	Short: "Change the access tier of blobs",
+	Long: `This command changes the access tier of a blob or of all the blobs
+in a directory.
+
+Usage Examples:
+
+    rclone backend settier azureblob:container/path/to/blob -o tier=Cool
+    rclone backend settier azureblob:container/path/to/directory -o tier=Archive
+    rclone backend settier azureblob:container -o tier=Hot --include "*.jpg"
+
+Filters apply to directories. Setting the tier of an archive tier blob
+to hot or cool starts rehydrating it - see the rehydrate command.
+
+It returns a list of status dictionaries as the undelete command does.
+`,
+	Opts: map[string]string{
+		"tier": "Tier to set - Hot, Cool or Archive",
+	},
+}, {
+	Name:  "rehydrate",
+	Short: "Rehydrate archive tier blobs",
+	Long: `This command starts rehydrating archive tier blobs so they can be
+read. It works on a blob or on all the blobs in a directory, ignoring
+blobs which aren't in the archive tier.
+
+Usage Examples:
+
+    rclone backend rehydrate azureblob:container/path/to/directory
+    rclone backend rehydrate azureblob:container/path -o tier=Cool -o wait
+
+Rehydration can take many hours. Without the wait option the command
+returns once rehydration has been requested, so the blobs can be
+copied later, eg with --azureblob-rehydrate-tier set. With the wait
+option it checks the blobs every --azureblob-rehydrate-poll-interval
+and returns when they can all be read.
+
+It returns a list of status dictionaries as the undelete command does.
+`,
+	Opts: map[string]string{
+		"tier": "Tier to rehydrate to - Hot (default) or Cool",
+		"wait": "Wait for the blobs to be rehydrated",
+	},
+}}
+
+// commandStatus is returned for each blob by the backend commands
+type commandStatus struct {
+	Status string
+	Remote string
+}
+
+// Command the backend to run a named command
+//
+// The command run is name
+// args may be used to read arguments from
+// opts may be used to read optional arguments from
+//
+// The result should be capable of being JSON encoded
+// If it is a string or a []string it will be shown to the user
+// otherwise it will be JSON encoded and shown to the user like that
+func (f *Fs) Command(ctx context.Context, name string, arg []string, opt map[string]string) (out interface{}, err error) {
+	switch name {
+	case "undelete":
+		if len(arg) == 0 {
+			arg = []string{""}
+		}
+		out := []commandStatus{}
+		for _, remote := range arg {
+			status, err := f.undelete(ctx, remote)
+			out = append(out, status...)
+			if err != nil {
+				return out, err
+			}
+		}
+		return out, nil
+	case "restore-version":
+		if len(arg) == 0 {
+			return nil, errors.New("need at least one blob to restore")
+		}
+		out := []commandStatus{}
+		for _, remote := range arg {
+			st := commandStatus{Status: "OK", Remote: remote}
+			err := f.restoreVersion(ctx, remote, opt["version-id"])
+			if err != nil {
+				st.Status = err.Error()
+			}
+			out = append(out, st)
+		}
+		return out, nil
+	case "settier":
+		tier, err := parseAccessTier(opt["tier"])
+		if err != nil {
+			return nil, err
+		}
+		if len(arg) == 0 {
+			arg = []string{""}
+		}
+		return f.setTier(ctx, arg, tier)
+	case "rehydrate":
+		tier := azblob.AccessTierHot
+		if opt["tier"] != "" {
+			tier, err = parseAccessTier(opt["tier"])
+			if err != nil {
+				return nil, err
+			}
+			if tier == azblob.AccessTierArchive {
+				return nil, errors.New("can't rehydrate to the archive tier")
+			}
+		}
+		_, wait := opt["wait"]
+		if len(arg) == 0 {
+			arg = []string{""}
+		}
+		return f.rehydrateCommand(ctx, arg, tier, wait)
+	default:
+		return nil, fs.ErrorCommandNotFound
+	}
+}
+
+// undelete restores the soft deleted blobs at or under remote
+func (f *Fs) undelete(ctx context.Context, remote string) (out []commandStatus, err error) {
+	container, containerPath := f.split(remote)
+	if container == "" {
+		return nil, errors.New("need a container to undelete blobs in")
+	}
+	seen := map[string]struct{}{}
+	err = f.listAll(ctx, container, containerPath, func(name string, file *azblob.BlobItemInternal) error {
+		if !file.Deleted {
+			return nil
+		}
+		if containerPath != "" && name != containerPath && !strings.HasPrefix(name, containerPath+"/") {
+			return nil
+		}
+		if _, ok := seen[name]; ok {
+			return nil
+		}
+		seen[name] = struct{}{}
+		// remote relative to the root of f
+		blobRemote := name
+		if f.rootDirectory != "" {
+			blobRemote = strings.TrimPrefix(strings.TrimPrefix(name, f.rootDirectory), "/")
+		}
+		if f.rootContainer == "" {
+			blobRemote = path.Join(container, blobRemote)
+		}
+		st := commandStatus{Status: "OK", Remote: blobRemote}
+		defer func() {
+			out = append(out, st)
+		}()
+		if operations.SkipDestructive(ctx, blobRemote, "undelete") {
+			return nil
+		}
+		blob := f.getBlobReference(container, name)
+		err := f.pacer.Call(func() (bool, error) {
+			_, err := blob.Undelete(ctx)
+			return f.shouldRetry(ctx, err)
+		})
+		if err != nil {
+			st.Status = err.Error()
+		}
+		return nil
+	})
+	return out, err
+}
+
+// restoreVersion copies the old version of the blob at remote over
+// the current version.
+//
+// If versionID is empty then remote should have a version suffix.
+func (f *Fs) restoreVersion(ctx context.Context, remote, versionID string) error {
+	container, containerPath := f.split(remote)
+	if container == "" {
+		return errors.New("need a container to restore blobs in")
+	}
+	blobPath := containerPath
+	if versionID == "" {
+		info, err := f.findVersion(ctx, container, containerPath)
+		if err != nil {
+			return err
+		}
+		versionID = *info.VersionID
+		_, blobPath = version.Remove(containerPath)
+	}
+	if operations.SkipDestructive(ctx, remote, "restore version") {
+		return nil
+	}
+	dstBlobURL := f.getBlobReference(container, blobPath)
+	srcBlobURL := dstBlobURL.WithVersionID(versionID)
+	return f.copyBlob(ctx, dstBlobURL, srcBlobURL)
 }
 
 func (f *Fs) getMemoryPool(size int64) *pool.Pool {
@@ -1362,6 +1795,7 @@ func (o *Object) decodeMetaDataFromPropertiesResponse(info *azblob.BlobGetProper
 	o.size = size
 	o.modTime = info.LastModified()
 	o.accessTier = azblob.AccessTierType(info.AccessTier())
+	o.archiveStatus = info.ArchiveStatus()
 	o.setMetadata(metadata)
 
 	return nil
@@ -1380,13 +1814,25 @@ func (o *Object) decodeMetaDataFromBlob(info *azblob.BlobItemInternal) (err erro
 	o.size = size
 	o.modTime = info.Properties.LastModified
 	o.accessTier = info.Properties.AccessTier
+	o.archiveStatus = string(info.Properties.ArchiveStatus)
+	o.deleted = info.Deleted
+	if isOldVersion(info) {
+		o.versionID = *info.VersionID
+	}
 	o.setMetadata(metadata)
 	return nil
 }
 
 // getBlobReference creates an empty blob reference with no metadata
+//
+// If the object is an old version then the reference is to that
+// version.
 func (o *Object) getBlobReference() azblob.BlobURL {
 	container, directory := o.split()
+	if o.versionID != "" {
+		_, directory = version.Remove(directory)
+		return o.fs.getBlobReference(container, directory).WithVersionID(o.versionID)
+	}
 	return o.fs.getBlobReference(container, directory)
 }
 
@@ -1406,6 +1852,16 @@ func (o *Object) readMetaData() (err error) {
 	if !o.modTime.IsZero() {
 		return nil
 	}
+	// If using versions and have a version suffix, need to list
+	// the versions to find the correct one
+	if o.fs.opt.Versions && o.versionID == "" && version.Match(o.remote) {
+		container, containerPath := o.split()
+		info, err := o.fs.findVersion(context.Background(), container, containerPath)
+		if err != nil {
+			return err
+		}
+		return o.decodeMetaDataFromBlob(info)
+	}
 	blob := o.getBlobReference()
 
 	// Read metadata (this includes metadata)
@@ -1439,6 +1895,9 @@ func (o *Object) ModTime(ctx context.Context) (result time.Time) {
 
 // SetModTime sets the modification time of the local fs object
 func (o *Object) SetModTime(ctx context.Context, modTime time.Time) error {
+	if o.fs.opt.Versions {
+		return errNotWithVersions
+	}
 	// Make sure o.meta is not nil
 	if o.meta == nil {
 		o.meta = make(map[string]string, 1)
@@ -1469,7 +1928,16 @@ func (o *Object) Open(ctx context.Context, options ...fs.OpenOption) (in io.Read
 	var offset int64
 	var count int64
 	if o.AccessTier() == azblob.AccessTierArchive {
-		return nil, errors.Errorf("Blob in archive tier, you need to set tier to hot or cool first")
+		if o.fs.opt.RehydrateTier == "" {
+			return nil, errors.Errorf("Blob in archive tier, you need to set tier to hot or cool first")
+		}
+		err = o.fs.rehydrate(ctx, []*Object{o}, azblob.AccessTierType(o.fs.opt.RehydrateTier), true)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if o.deleted {
+		return nil, errSoftDeleted
 	}
 	fs.FixRangeOption(options, o.size)
 	for _, option := range options {
@@ -1580,6 +2048,9 @@ func (pw *poolWrapper) Close() {
 //
 // The new object may have been created if an error is returned
 func (o *Object) Update(ctx context.Context, in io.Reader, src fs.ObjectInfo, options ...fs.OpenOption) (err error) {
+	if o.fs.opt.Versions {
+		return errNotWithVersions
+	}
 	if o.accessTier == azblob.AccessTierArchive {
 		if o.fs.opt.ArchiveTierDelete {
 			fs.Debugf(o, "deleting archive tier blob before updating")
@@ -1656,6 +2127,9 @@ func (o *Object) Update(ctx context.Context, in io.Reader, src fs.ObjectInfo, op
 
 // Remove an object
 func (o *Object) Remove(ctx context.Context) error {
+	if o.fs.opt.Versions {
+		return errNotWithVersions
+	}
 	blob := o.getBlobReference()
 	snapShotOptions := azblob.DeleteSnapshotsOptionNone
 	ac := azblob.BlobAccessConditions{}
@@ -1697,6 +2171,14 @@ func (o *Object) SetTier(tier string) error {
 		return errors.Wrap(err, "Failed to set Blob Tier")
 	}
 
+	// Archive tier blobs stay in the archive tier until they
+	// have been rehydrated
+	if o.accessTier == azblob.AccessTierArchive {
+		o.archiveStatus = "rehydrate-pending-to-" + strings.ToLower(tier)
+		fs.Debugf(o, "Successfully started rehydration to %s", tier)
+		return nil
+	}
+
 	// Set access tier on local object also, this typically
 	// gets updated on get blob properties
 	o.accessTier = desiredAccessTier
@@ -1714,6 +2196,7 @@ func (o *Object) GetTier() string {
 var (
 	_ fs.Fs          = &Fs{}
 	_ fs.Copier      = &Fs{}
+	_ fs.Commander   = &Fs{}
 	_ fs.PutStreamer = &Fs{}
 	_ fs.Purger      = &Fs{}
 	_ fs.ListRer     = &Fs{}
@@ -3,9 +3,26 @@
 package azureblob
 
 import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/Azure/azure-storage-blob-go/azblob"
+	"github.com/pingme998/rclone/fs"
+	"github.com/pingme998/rclone/fs/config/configmap"
+	"github.com/pingme998/rclone/fs/object"
+	"github.com/pingme998/rclone/lib/version"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func (f *Fs) InternalTest(t *testing.T) {
@@ -33,3 +50,331 @@ func TestIncrement(t *testing.T) {
 		assert.Equal(t, test.want, test.in)
 	}
 }
+
+func TestIsOldVersion(t *testing.T) {
+	versionID := "2021-06-10T10:12:13.1234567Z"
+	empty := ""
+	yes, no := true, false
+	for _, test := range []struct {
+		versionID *string
+		current   *bool
+		want      bool
+	}{
+		{nil, nil, false},
+		{&empty, nil, false},
+		{&versionID, &yes, false},
+		{&versionID, &no, true},
+		{&versionID, nil, true},
+	} {
+		info := &azblob.BlobItemInternal{VersionID: test.versionID, IsCurrentVersion: test.current}
+		assert.Equal(t, test.want, isOldVersion(info))
+	}
+}
+
+func TestParseVersionID(t *testing.T) {
+	got, err := parseVersionID("2021-06-10T10:12:13.1234567Z")
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2021, 6, 10, 10, 12, 13, 123456700, time.UTC), got)
+	assert.Equal(t, "dir/file-v2021-06-10-101213-123.txt", version.Add("dir/file.txt", got))
+
+	_, err = parseVersionID("potato")
+	assert.Error(t, err)
+}
+
+func TestNotWithVersions(t *testing.T) {
+	ctx := context.Background()
+	f := &Fs{opt: Options{Versions: true}}
+	o := &Object{fs: f, remote: "container/file.txt"}
+	src := object.NewStaticObjectInfo("container/file.txt", time.Now(), 0, true, nil, nil)
+
+	obj, err := f.Put(ctx, bytes.NewReader(nil), src)
+	assert.Equal(t, errNotWithVersions, err)
+	assert.Nil(t, obj)
+	obj, err = f.PutStream(ctx, bytes.NewReader(nil), src)
+	assert.Equal(t, errNotWithVersions, err)
+	assert.Nil(t, obj)
+	obj, err = f.Copy(ctx, o, "container/file2.txt")
+	assert.Equal(t, errNotWithVersions, err)
+	assert.Nil(t, obj)
+	assert.Equal(t, errNotWithVersions, f.Mkdir(ctx, "container"))
+	assert.Equal(t, errNotWithVersions, f.Rmdir(ctx, "container"))
+	assert.Equal(t, errNotWithVersions, f.Purge(ctx, "container"))
+	assert.Equal(t, errNotWithVersions, o.Update(ctx, bytes.NewReader(nil), src))
+	assert.Equal(t, errNotWithVersions, o.SetModTime(ctx, time.Now()))
+	assert.Equal(t, errNotWithVersions, o.Remove(ctx))
+}
+
+func TestParseAccessTier(t *testing.T) {
+	for _, test := range []struct {
+		in      string
+		want    azblob.AccessTierType
+		wantErr bool
+	}{
+		{"Hot", azblob.AccessTierHot, false},
+		{"cool", azblob.AccessTierCool, false},
+		{"ARCHIVE", azblob.AccessTierArchive, false},
+		{"", azblob.AccessTierNone, true},
+		{"potato", azblob.AccessTierNone, true},
+	} {
+		got, err := parseAccessTier(test.in)
+		assert.Equal(t, test.want, got, test.in)
+		assert.Equal(t, test.wantErr, err != nil, test.in)
+	}
+}
+
+// fakeBlob is a blob stored in a fakeAzure
+type fakeBlob struct {
+	tier          azblob.AccessTierType // access tier of the blob
+	archiveStatus string                // rehydration status if rehydrating
+	rehydrateTo   azblob.AccessTierType // tier being rehydrated to
+	checks        int                   // number of HEADs left until rehydrated
+}
+
+// fakeAzure is an in memory blob server for the container "container"
+// which only implements enough of the API to test changing tiers
+//
+// Archive tier blobs are rehydrated after being read with HEAD twice.
+type fakeAzure struct {
+	mu       sync.Mutex
+	blobs    map[string]*fakeBlob
+	setTiers []string // "name=tier" for each successful set tier call
+}
+
+func (s *fakeAzure) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	query := r.URL.Query()
+	if r.URL.Path == "/container" && query.Get("comp") == "list" {
+		s.list(w, query.Get("prefix"))
+		return
+	}
+	name := strings.TrimPrefix(r.URL.Path, "/container/")
+	blob, ok := s.blobs[name]
+	if !ok {
+		w.Header().Set("x-ms-error-code", string(azblob.ServiceCodeBlobNotFound))
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	switch {
+	case r.Method == "PUT" && query.Get("comp") == "tier":
+		tier := azblob.AccessTierType(r.Header.Get("x-ms-access-tier"))
+		switch {
+		case blob.archiveStatus != "":
+			w.Header().Set("x-ms-error-code", string(azblob.ServiceCodeBlobBeingRehydrated))
+			w.WriteHeader(http.StatusConflict)
+			return
+		case blob.tier == azblob.AccessTierArchive && tier != azblob.AccessTierArchive:
+			blob.archiveStatus = "rehydrate-pending-to-" + strings.ToLower(string(tier))
+			blob.rehydrateTo = tier
+			blob.checks = 2
+			w.WriteHeader(http.StatusAccepted)
+		default:
+			blob.tier = tier
+			w.WriteHeader(http.StatusOK)
+		}
+		s.setTiers = append(s.setTiers, name+"="+string(tier))
+	case r.Method == "HEAD":
+		if blob.archiveStatus != "" {
+			blob.checks--
+			if blob.checks <= 0 {
+				blob.tier = blob.rehydrateTo
+				blob.archiveStatus = ""
+			}
+		}
+		w.Header().Set("Content-Length", fmt.Sprint(len(name)))
+		w.Header().Set("Content-Type", "text/plain")
+		w.Header().Set("Last-Modified", "Mon, 02 Jan 2006 15:04:05 GMT")
+		w.Header().Set("x-ms-blob-type", "BlockBlob")
+		w.Header().Set("x-ms-access-tier", string(blob.tier))
+		if blob.archiveStatus != "" {
+			w.Header().Set("x-ms-archive-status", blob.archiveStatus)
+		}
+	case r.Method == "GET":
+		if blob.tier == azblob.AccessTierArchive {
+			w.Header().Set("x-ms-error-code", "BlobArchived")
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		w.Header().Set("Content-Length", fmt.Sprint(len(name)))
+		_, _ = w.Write([]byte(name))
+	default:
+		w.WriteHeader(http.StatusNotImplemented)
+	}
+}
+
+// list writes a recursive listing of the blobs under prefix
+func (s *fakeAzure) list(w http.ResponseWriter, prefix string) {
+	type properties struct {
+		LastModified  string `xml:"Last-Modified"`
+		ContentLength int    `xml:"Content-Length"`
+		ContentType   string `xml:"Content-Type"`
+		BlobType      string `xml:"BlobType"`
+		AccessTier    string `xml:"AccessTier"`
+		ArchiveStatus string `xml:"ArchiveStatus,omitempty"`
+	}
+	type blob struct {
+		Name       string     `xml:"Name"`
+		Properties properties `xml:"Properties"`
+	}
+	var resp struct {
+		XMLName    xml.Name `xml:"EnumerationResults"`
+		Blobs      []blob   `xml:"Blobs>Blob"`
+		NextMarker string   `xml:"NextMarker"` // empty as there is only one page
+	}
+	names := make([]string, 0, len(s.blobs))
+	for name := range s.blobs {
+		if strings.HasPrefix(name, prefix) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		resp.Blobs = append(resp.Blobs, blob{
+			Name: name,
+			Properties: properties{
+				LastModified:  "Mon, 02 Jan 2006 15:04:05 GMT",
+				ContentLength: len(name),
+				ContentType:   "text/plain",
+				BlobType:      "BlockBlob",
+				AccessTier:    string(s.blobs[name].tier),
+				ArchiveStatus: s.blobs[name].archiveStatus,
+			},
+		})
+	}
+	_ = xml.NewEncoder(w).Encode(&resp)
+}
+
+// tiers returns the tier of each blob
+func (s *fakeAzure) tiers() map[string]azblob.AccessTierType {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tiers := make(map[string]azblob.AccessTierType, len(s.blobs))
+	for name, blob := range s.blobs {
+		tiers[name] = blob.tier
+	}
+	return tiers
+}
+
+// newFakeAzureFs makes an Fs on "container" of a fakeAzure with the
+// blobs and the extra config in m
+func newFakeAzureFs(t *testing.T, blobs map[string]*fakeBlob, m configmap.Simple) (f *Fs, s *fakeAzure, cleanup func()) {
+	s = &fakeAzure{blobs: blobs}
+	server := httptest.NewServer(s)
+	m["sas_url"] = server.URL + "/?sv=2019-12-12&sig=potato"
+	m["rehydrate_poll_interval"] = "1ms"
+	fsInfo, err := fs.Find("azureblob")
+	require.NoError(t, err)
+	fsys, err := NewFs(context.Background(), "TestAzureBlob", "container", fs.ConfigMap(fsInfo, "TestAzureBlob", m))
+	require.NoError(t, err)
+	return fsys.(*Fs), s, server.Close
+}
+
+func TestNewFsRehydrateTier(t *testing.T) {
+	f, _, cleanup := newFakeAzureFs(t, nil, configmap.Simple{"rehydrate_tier": "cool"})
+	defer cleanup()
+	assert.Equal(t, string(azblob.AccessTierCool), f.opt.RehydrateTier)
+
+	fsInfo, err := fs.Find("azureblob")
+	require.NoError(t, err)
+	m := configmap.Simple{"sas_url": "http://127.0.0.1/?sig=potato", "rehydrate_tier": "archive"}
+	_, err = NewFs(context.Background(), "TestAzureBlob", "container", fs.ConfigMap(fsInfo, "TestAzureBlob", m))
+	assert.Error(t, err)
+}
+
+func TestOpenRehydrates(t *testing.T) {
+	ctx := context.Background()
+	blobs := func() map[string]*fakeBlob {
+		return map[string]*fakeBlob{"file.txt": {tier: azblob.AccessTierArchive}}
+	}
+
+	f, s, cleanup := newFakeAzureFs(t, blobs(), configmap.Simple{})
+	defer cleanup()
+	o, err := f.NewObject(ctx, "file.txt")
+	require.NoError(t, err)
+	_, err = o.Open(ctx)
+	assert.Error(t, err)
+	assert.Empty(t, s.setTiers)
+
+	f, s, cleanup = newFakeAzureFs(t, blobs(), configmap.Simple{"rehydrate_tier": "cool"})
+	defer cleanup()
+	o, err = f.NewObject(ctx, "file.txt")
+	require.NoError(t, err)
+	in, err := o.Open(ctx)
+	require.NoError(t, err)
+	data, err := ioutil.ReadAll(in)
+	require.NoError(t, err)
+	require.NoError(t, in.Close())
+	assert.Equal(t, "file.txt", string(data))
+	assert.Equal(t, []string{"file.txt=Cool"}, s.setTiers)
+	assert.Equal(t, azblob.AccessTierCool, o.(*Object).AccessTier())
+}
+
+func TestSetTierCommand(t *testing.T) {
+	ctx := context.Background()
+	f, s, cleanup := newFakeAzureFs(t, map[string]*fakeBlob{
+		"dir/a.txt":     {tier: azblob.AccessTierHot},
+		"dir/b.txt":     {tier: azblob.AccessTierCool},
+		"other.txt":     {tier: azblob.AccessTierHot},
+		"dir/sub/c.txt": {tier: azblob.AccessTierHot},
+	}, configmap.Simple{})
+	defer cleanup()
+
+	_, err := f.Command(ctx, "settier", []string{"dir"}, map[string]string{"tier": "potato"})
+	assert.Error(t, err)
+
+	out, err := f.Command(ctx, "settier", []string{"dir", "other.txt"}, map[string]string{"tier": "cool"})
+	require.NoError(t, err)
+	assert.Equal(t, []commandStatus{
+		{Status: "OK", Remote: "dir/a.txt"},
+		{Status: "OK", Remote: "dir/b.txt"},
+		{Status: "OK", Remote: "dir/sub/c.txt"},
+		{Status: "OK", Remote: "other.txt"},
+	}, out)
+	assert.Equal(t, []string{"dir/a.txt=Cool", "dir/sub/c.txt=Cool", "other.txt=Cool"}, s.setTiers)
+	for name, tier := range s.tiers() {
+		assert.Equal(t, azblob.AccessTierCool, tier, name)
+	}
+}
+
+func TestRehydrateCommand(t *testing.T) {
+	ctx := context.Background()
+	blobs := func() map[string]*fakeBlob {
+		return map[string]*fakeBlob{
+			"dir/archive.txt": {tier: azblob.AccessTierArchive},
+			"dir/hot.txt":     {tier: azblob.AccessTierHot},
+			"dir/pending.txt": {
+				tier:          azblob.AccessTierArchive,
+				archiveStatus: "rehydrate-pending-to-hot",
+				rehydrateTo:   azblob.AccessTierHot,
+				checks:        3,
+			},
+		}
+	}
+	wantOut := []commandStatus{
+		{Status: "OK", Remote: "dir/archive.txt"},
+		{Status: "OK", Remote: "dir/pending.txt"},
+	}
+
+	_, err := (&Fs{}).Command(ctx, "rehydrate", nil, map[string]string{"tier": "archive"})
+	assert.Error(t, err)
+
+	f, s, cleanup := newFakeAzureFs(t, blobs(), configmap.Simple{})
+	defer cleanup()
+	out, err := f.Command(ctx, "rehydrate", []string{"dir"}, map[string]string{})
+	require.NoError(t, err)
+	assert.Equal(t, wantOut, out)
+	assert.Equal(t, []string{"dir/archive.txt=Hot"}, s.setTiers)
+	assert.Equal(t, azblob.AccessTierArchive, s.tiers()["dir/archive.txt"])
+
+	f, s, cleanup = newFakeAzureFs(t, blobs(), configmap.Simple{})
+	defer cleanup()
+	out, err = f.Command(ctx, "rehydrate", []string{"dir"}, map[string]string{"tier": "cool", "wait": ""})
+	require.NoError(t, err)
+	assert.Equal(t, wantOut, out)
+	assert.Equal(t, []string{"dir/archive.txt=Cool"}, s.setTiers)
+	assert.Equal(t, map[string]azblob.AccessTierType{
+		"dir/archive.txt": azblob.AccessTierCool,
+		"dir/hot.txt":     azblob.AccessTierHot,
+		"dir/pending.txt": azblob.AccessTierHot,
+	}, s.tiers())
+}
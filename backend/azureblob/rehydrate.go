@@ -0,0 +1,158 @@
+// +build !plan9,!solaris,!js,go1.14
+
+package azureblob
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-storage-blob-go/azblob"
+	"github.com/pkg/errors"
+	"github.com/pingme998/rclone/fs"
+	"github.com/pingme998/rclone/fs/walk"
+)
+
+// parseAccessTier turns a user supplied tier into an access tier
+// ignoring case
+func parseAccessTier(tier string) (azblob.AccessTierType, error) {
+	for _, accessTier := range []azblob.AccessTierType{azblob.AccessTierHot, azblob.AccessTierCool, azblob.AccessTierArchive} {
+		if strings.EqualFold(tier, string(accessTier)) {
+			return accessTier, nil
+		}
+	}
+	return azblob.AccessTierNone, errors.Errorf("unknown tier %q - must be %s, %s or %s", tier,
+		azblob.AccessTierHot, azblob.AccessTierCool, azblob.AccessTierArchive)
+}
+
+// forEachObject calls fn for the blob at remote or, if it isn't a
+// blob, for all the blobs in the directory remote which pass the
+// filters
+func (f *Fs) forEachObject(ctx context.Context, remote string, fn func(o *Object) error) error {
+	if remote != "" {
+		o, err := f.NewObject(ctx, remote)
+		if err == nil {
+			return fn(o.(*Object))
+		}
+		if err != fs.ErrorObjectNotFound && err != fs.ErrorNotAFile {
+			return err
+		}
+	}
+	return walk.ListR(ctx, f, remote, false, -1, walk.ListObjects, func(entries fs.DirEntries) error {
+		for _, entry := range entries {
+			if o, ok := entry.(*Object); ok {
+				err := fn(o)
+				if err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// setTier sets the tier of the blobs at or under remotes
+func (f *Fs) setTier(ctx context.Context, remotes []string, tier azblob.AccessTierType) (out []commandStatus, err error) {
+	out = []commandStatus{}
+	for _, remote := range remotes {
+		err = f.forEachObject(ctx, remote, func(o *Object) error {
+			st := commandStatus{Status: "OK", Remote: o.Remote()}
+			err := o.SetTier(string(tier))
+			if err != nil {
+				st.Status = err.Error()
+			}
+			out = append(out, st)
+			return nil
+		})
+		if err != nil {
+			return out, err
+		}
+	}
+	return out, nil
+}
+
+// rehydrateCommand starts rehydrating the archive tier blobs at or
+// under remotes to tier, waiting for them to finish if wait is set
+func (f *Fs) rehydrateCommand(ctx context.Context, remotes []string, tier azblob.AccessTierType, wait bool) (out []commandStatus, err error) {
+	out = []commandStatus{}
+	var objects []*Object
+	for _, remote := range remotes {
+		err = f.forEachObject(ctx, remote, func(o *Object) error {
+			if o.AccessTier() == azblob.AccessTierArchive {
+				objects = append(objects, o)
+			} else {
+				fs.Debugf(o, "Not rehydrating as tier is %q", o.AccessTier())
+			}
+			return nil
+		})
+		if err != nil {
+			return out, err
+		}
+	}
+	err = f.rehydrate(ctx, objects, tier, wait)
+	for _, o := range objects {
+		st := commandStatus{Status: "OK", Remote: o.Remote()}
+		if o.AccessTier() == azblob.AccessTierArchive && o.archiveStatus == "" {
+			st.Status = "rehydration not started"
+		}
+		out = append(out, st)
+	}
+	return out, err
+}
+
+// rehydrate asks for the archive tier objects to be rehydrated to
+// tier unless they are being rehydrated already.
+//
+// If wait is set it then waits until they have all been rehydrated,
+// checking every rehydrate_poll_interval.
+func (f *Fs) rehydrate(ctx context.Context, objects []*Object, tier azblob.AccessTierType, wait bool) error {
+	var pending []*Object
+	for _, o := range objects {
+		if o.AccessTier() != azblob.AccessTierArchive {
+			continue
+		}
+		pending = append(pending, o)
+		if o.archiveStatus != "" {
+			fs.Debugf(o, "Already rehydrating: %s", o.archiveStatus)
+			continue
+		}
+		fs.Infof(o, "Requesting rehydration to %s tier", tier)
+		err := o.SetTier(string(tier))
+		if storageErr, ok := errors.Cause(err).(azblob.StorageError); ok && storageErr.ServiceCode() == azblob.ServiceCodeBlobBeingRehydrated {
+			o.archiveStatus = "rehydrate-pending"
+			err = nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if !wait {
+		return nil
+	}
+	for len(pending) > 0 {
+		fs.Infof(f, "Waiting for %d blobs to be rehydrated - checking every %v", len(pending), f.opt.RehydratePoll)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Duration(f.opt.RehydratePoll)):
+		}
+		var stillPending []*Object
+		for _, o := range pending {
+			o.clearMetaData()
+			err := o.readMetaData()
+			if err != nil {
+				return err
+			}
+			if o.AccessTier() != azblob.AccessTierArchive {
+				fs.Infof(o, "Rehydrated to %s tier", o.AccessTier())
+				continue
+			}
+			if o.archiveStatus == "" {
+				return errors.Errorf("%v: rehydration stopped before the blob left the archive tier", o)
+			}
+			stillPending = append(stillPending, o)
+		}
+		pending = stillPending
+	}
+	return nil
+}
@@ -32,10 +32,18 @@ var _ fserrors.Fataler = (*Error)(nil)
 
 // Bucket describes a B2 bucket
 type Bucket struct {
-	ID        string `json:"bucketId"`
-	AccountID string `json:"accountId"`
-	Name      string `json:"bucketName"`
-	Type      string `json:"bucketType"`
+	ID             string          `json:"bucketId"`
+	AccountID      string          `json:"accountId"`
+	Name           string          `json:"bucketName"`
+	Type           string          `json:"bucketType"`
+	LifecycleRules []LifecycleRule `json:"lifecycleRules,omitempty"`
+}
+
+// LifecycleRule is a rule for hiding and deleting old files in a bucket
+type LifecycleRule struct {
+	DaysFromHidingToDeleting  *int   `json:"daysFromHidingToDeleting"`  // Delete hidden files this many days after they were hidden, or nil
+	DaysFromUploadingToHiding *int   `json:"daysFromUploadingToHiding"` // Hide files this many days after they were uploaded, or nil
+	FileNamePrefix            string `json:"fileNamePrefix"`            // The rule applies to files starting with this
 }
 
 // Timestamp is a UTC time when this file was uploaded. It is a base
@@ -210,6 +218,13 @@ type CreateBucketRequest struct {
 	Type      string `json:"bucketType"`
 }
 
+// UpdateBucketRequest is used to change the lifecycle rules of a bucket
+type UpdateBucketRequest struct {
+	ID             string          `json:"bucketId"`
+	AccountID      string          `json:"accountId"`
+	LifecycleRules []LifecycleRule `json:"lifecycleRules"` // replaces all the rules
+}
+
 // DeleteBucketRequest is used to create a bucket
 type DeleteBucketRequest struct {
 	ID        string `json:"bucketId"`
@@ -58,6 +58,7 @@ const (
 	defaultChunkSize    = 96 * fs.Mebi
 	defaultUploadCutoff = 200 * fs.Mebi
 	largeFileCopyCutoff = 4 * fs.Gibi              // 5E9 is the max
+	maxCopyPartSize     = 5 * 1000 * 1000 * 1000   // maximum size of a part in b2_copy_part
 	memoryPoolFlushTime = fs.Duration(time.Minute) // flush the cached buffers after this long
 	memoryPoolUseMmap   = false
 )
@@ -124,9 +125,13 @@ This value should be set no larger than 4.657 GiB (== 5 GB).`,
 			Help: `Cutoff for switching to multipart copy
 
 Any files larger than this that need to be server-side copied will be
-copied in chunks of this size.
+copied in chunks of this size using b2_copy_part. Smaller files are
+copied with a single b2_copy_file call.
 
-The minimum is 0 and the maximum is 4.6 GiB.`,
+If a file would need more than 10,000 parts then the chunk size is
+increased so it fits.
+
+The minimum is 5 MiB and the maximum is 4.6 GiB.`,
 			Default:  largeFileCopyCutoff,
 			Advanced: true,
 		}, {
@@ -384,6 +389,26 @@ func checkUploadCutoff(opt *Options, cs fs.SizeSuffix) error {
 	return nil
 }
 
+func checkCopyCutoff(cs fs.SizeSuffix) error {
+	if cs < minChunkSize {
+		return errors.Errorf("%s is less than %s", cs, minChunkSize)
+	}
+	if cs > maxCopyPartSize {
+		return errors.Errorf("%s is greater than %s", cs, fs.SizeSuffix(maxCopyPartSize))
+	}
+	return nil
+}
+
+// copyChunkSize returns the chunk size to use for a multipart copy
+// of a file of size so that it needs no more than maxParts parts
+func copyChunkSize(size int64, cutoff fs.SizeSuffix) fs.SizeSuffix {
+	chunkSize := cutoff
+	if size > int64(chunkSize)*maxParts {
+		chunkSize = fs.SizeSuffix((size + maxParts - 1) / maxParts)
+	}
+	return chunkSize
+}
+
 func (f *Fs) setUploadCutoff(cs fs.SizeSuffix) (old fs.SizeSuffix, err error) {
 	err = checkUploadCutoff(&f.opt, cs)
 	if err == nil {
@@ -418,6 +443,10 @@ func NewFs(ctx context.Context, name, root string, m configmap.Mapper) (fs.Fs, e
 	if err != nil {
 		return nil, errors.Wrap(err, "b2: chunk size")
 	}
+	err = checkCopyCutoff(opt.CopyCutoff)
+	if err != nil {
+		return nil, errors.Wrap(err, "b2: copy cutoff")
+	}
 	if opt.Account == "" {
 		return nil, errors.New("account not found")
 	}
@@ -1244,11 +1273,105 @@ func (f *Fs) CleanUp(ctx context.Context) error {
 	return f.purge(ctx, "", true)
 }
 
+// GetLifecycle reads the lifecycle rules of the bucket
+//
+// ExpireDays is the days from uploading to hiding a file and
+// NoncurrentExpireDays is the days from hiding to deleting it.
+func (f *Fs) GetLifecycle(ctx context.Context) (rules []fs.LifecycleRule, err error) {
+	if f.rootBucket == "" {
+		return nil, fs.ErrorListBucketRequired
+	}
+	found := false
+	rules = []fs.LifecycleRule{}
+	err = f.listBucketsToFn(ctx, func(bucket *api.Bucket) error {
+		if bucket.Name != f.rootBucket {
+			return nil
+		}
+		found = true
+		for _, b2Rule := range bucket.LifecycleRules {
+			rule := fs.LifecycleRule{
+				Prefix: f.opt.Enc.ToStandardPath(b2Rule.FileNamePrefix),
+			}
+			if b2Rule.DaysFromUploadingToHiding != nil {
+				rule.ExpireDays = *b2Rule.DaysFromUploadingToHiding
+			}
+			if b2Rule.DaysFromHidingToDeleting != nil {
+				rule.NoncurrentExpireDays = *b2Rule.DaysFromHidingToDeleting
+			}
+			rules = append(rules, rule)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read lifecycle rules")
+	}
+	if !found {
+		return nil, fs.ErrorDirNotFound
+	}
+	return rules, nil
+}
+
+// SetLifecycle replaces the lifecycle rules of the bucket
+//
+// Rules can't have an ID
+func (f *Fs) SetLifecycle(ctx context.Context, rules []fs.LifecycleRule) (err error) {
+	if f.rootBucket == "" {
+		return fs.ErrorListBucketRequired
+	}
+	bucketID, err := f.getBucketID(ctx, f.rootBucket)
+	if err != nil {
+		return err
+	}
+	var request = api.UpdateBucketRequest{
+		ID:             bucketID,
+		AccountID:      f.info.AccountID,
+		LifecycleRules: []api.LifecycleRule{},
+	}
+	for i := range rules {
+		rule := &rules[i]
+		if rule.ID != "" {
+			return errors.New("lifecycle rules can't have an id on this backend")
+		}
+		b2Rule := api.LifecycleRule{
+			FileNamePrefix: f.opt.Enc.FromStandardPath(rule.Prefix),
+		}
+		if rule.ExpireDays > 0 {
+			b2Rule.DaysFromUploadingToHiding = &rule.ExpireDays
+		}
+		if rule.NoncurrentExpireDays > 0 {
+			b2Rule.DaysFromHidingToDeleting = &rule.NoncurrentExpireDays
+		}
+		request.LifecycleRules = append(request.LifecycleRules, b2Rule)
+	}
+	opts := rest.Opts{
+		Method: "POST",
+		Path:   "/b2_update_bucket",
+	}
+	var response api.Bucket
+	err = f.pacer.Call(func() (bool, error) {
+		resp, err := f.srv.CallJSON(ctx, &opts, &request, &response)
+		return f.shouldRetry(ctx, resp, err)
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to set lifecycle rules")
+	}
+	return nil
+}
+
 // copy does a server-side copy from dstObj <- srcObj
 //
+// The source and destination may be in different buckets. Files
+// of copy_cutoff or larger are copied in parts with b2_copy_part.
+//
 // If newInfo is nil then the metadata will be copied otherwise it
 // will be replaced with newInfo
 func (f *Fs) copy(ctx context.Context, dstObj *Object, srcObj *Object, newInfo *api.File) (err error) {
+	dstBucket, dstPath := dstObj.split()
+	err = f.makeBucket(ctx, dstBucket)
+	if err != nil {
+		return err
+	}
+
 	if srcObj.size >= int64(f.opt.CopyCutoff) {
 		if newInfo == nil {
 			newInfo, err = srcObj.getMetaData(ctx)
@@ -1256,19 +1379,14 @@ func (f *Fs) copy(ctx context.Context, dstObj *Object, srcObj *Object, newInfo *
 				return err
 			}
 		}
-		up, err := f.newLargeUpload(ctx, dstObj, nil, srcObj, f.opt.CopyCutoff, true, newInfo)
+		chunkSize := copyChunkSize(srcObj.size, f.opt.CopyCutoff)
+		up, err := f.newLargeUpload(ctx, dstObj, nil, srcObj, chunkSize, true, newInfo)
 		if err != nil {
 			return err
 		}
 		return up.Upload(ctx)
 	}
 
-	dstBucket, dstPath := dstObj.split()
-	err = f.makeBucket(ctx, dstBucket)
-	if err != nil {
-		return err
-	}
-
 	destBucketID, err := f.getBucketID(ctx, dstBucket)
 	if err != nil {
 		return err
@@ -1998,6 +2116,7 @@ var (
 	_ fs.CleanUpper   = &Fs{}
 	_ fs.ListRer      = &Fs{}
 	_ fs.PublicLinker = &Fs{}
+	_ fs.Lifecycler   = &Fs{}
 	_ fs.Object       = &Object{}
 	_ fs.MimeTyper    = &Object{}
 	_ fs.IDer         = &Object{}
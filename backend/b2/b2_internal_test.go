@@ -4,6 +4,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/pingme998/rclone/fs"
 	"github.com/pingme998/rclone/fstest"
 )
 
@@ -168,3 +169,24 @@ func TestParseTimeString(t *testing.T) {
 	}
 
 }
+
+func TestCopyChunkSize(t *testing.T) {
+	for _, test := range []struct {
+		size   int64
+		cutoff fs.SizeSuffix
+		want   fs.SizeSuffix
+	}{
+		{10 * int64(fs.Gibi), largeFileCopyCutoff, largeFileCopyCutoff},
+		{maxParts * int64(minChunkSize), minChunkSize, minChunkSize},
+		{maxParts*int64(minChunkSize) + 1, minChunkSize, minChunkSize + 1},
+		{10 * int64(fs.Tebi), minChunkSize, 1099511628},
+	} {
+		got := copyChunkSize(test.size, test.cutoff)
+		if test.want != got {
+			t.Errorf("copyChunkSize(%d, %v): want %d got %d", test.size, test.cutoff, test.want, got)
+		}
+		if parts := (test.size + int64(got) - 1) / int64(got); parts > maxParts {
+			t.Errorf("copyChunkSize(%d, %v): too many parts %d", test.size, test.cutoff, parts)
+		}
+	}
+}
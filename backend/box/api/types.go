@@ -87,8 +87,9 @@ type Item struct {
 	ContentModifiedAt Time    `json:"content_modified_at"`
 	ItemStatus        string  `json:"item_status"` // active, trashed if the file has been moved to the trash, and deleted if the file has been permanently deleted
 	SharedLink        struct {
-		URL    string `json:"url,omitempty"`
-		Access string `json:"access,omitempty"`
+		URL        string `json:"url,omitempty"`
+		Access     string `json:"access,omitempty"`
+		UnsharedAt *Time  `json:"unshared_at,omitempty"` // when the link expires, nil for never
 	} `json:"shared_link"`
 }
 
@@ -189,6 +190,11 @@ type CreateSharedLink struct {
 	} `json:"shared_link"`
 }
 
+// RemoveSharedLink is the request to remove a Public Link
+type RemoveSharedLink struct {
+	SharedLink *struct{} `json:"shared_link"` // always null
+}
+
 // UploadSessionRequest is uses in Create Upload Session
 type UploadSessionRequest struct {
 	FolderID string `json:"folder_id,omitempty"` // don't pass for update
@@ -133,6 +133,25 @@ func init() {
 			Help:     "Max number of times to try committing a multipart file.",
 			Default:  100,
 			Advanced: true,
+		}, {
+			Name:    "api_compression",
+			Default: false,
+			Help: `Ask the API to compress its responses.
+
+This reduces the bandwidth used when listing large directories at
+the cost of a little CPU. File contents are not affected.`,
+			Advanced: true,
+		}, {
+			Name:    "api_gzip_requests",
+			Default: false,
+			Help: `Gzip the bodies of API requests.
+
+This reduces the bandwidth used by API calls with large request
+bodies at the cost of a little CPU. File contents are not affected.
+
+The requests are sent with "Content-Encoding: gzip". If API calls
+fail with this set then turn it off.`,
+			Advanced: true,
 		}, {
 			Name:     config.ConfigEncoding,
 			Help:     config.ConfigEncodingHelp,
@@ -242,11 +261,13 @@ func getDecryptedPrivateKey(boxConfig *api.ConfigJSON) (key *rsa.PrivateKey, err
 
 // Options defines the configuration for this backend
 type Options struct {
-	UploadCutoff  fs.SizeSuffix        `config:"upload_cutoff"`
-	CommitRetries int                  `config:"commit_retries"`
-	Enc           encoder.MultiEncoder `config:"encoding"`
-	RootFolderID  string               `config:"root_folder_id"`
-	AccessToken   string               `config:"access_token"`
+	UploadCutoff    fs.SizeSuffix        `config:"upload_cutoff"`
+	CommitRetries   int                  `config:"commit_retries"`
+	APICompression  bool                 `config:"api_compression"`
+	APIGzipRequests bool                 `config:"api_gzip_requests"`
+	Enc             encoder.MultiEncoder `config:"encoding"`
+	RootFolderID    string               `config:"root_folder_id"`
+	AccessToken     string               `config:"access_token"`
 }
 
 // Fs represents a remote box
@@ -403,7 +424,7 @@ func NewFs(ctx context.Context, name, root string, m configmap.Mapper) (fs.Fs, e
 		name:        name,
 		root:        root,
 		opt:         *opt,
-		srv:         rest.NewClient(client).SetRoot(rootURL),
+		srv:         rest.NewClient(client).SetRoot(rootURL).SetDecompress(opt.APICompression).SetGzipRequests(opt.APIGzipRequests),
 		pacer:       fs.NewPacer(ctx, pacer.NewDefault(pacer.MinSleep(minSleep), pacer.MaxSleep(maxSleep), pacer.DecayConstant(decayConstant))),
 		uploadToken: pacer.NewTokenDispenser(ci.Transfers),
 	}
@@ -1073,6 +1094,108 @@ func (f *Fs) PublicLink(ctx context.Context, remote string, expire fs.Duration,
 	return info.SharedLink.URL, err
 }
 
+// ListLinks returns the public links to dir and everything under it
+func (f *Fs) ListLinks(ctx context.Context, dir string) (links []fs.Link, err error) {
+	dirID, err := f.dirCache.FindDir(ctx, dir, false)
+	if err != nil {
+		return nil, err
+	}
+	addLink := func(remote string, info *api.Item) {
+		if info.SharedLink.URL == "" {
+			return
+		}
+		link := fs.Link{
+			Remote: remote,
+			URL:    info.SharedLink.URL,
+		}
+		if info.SharedLink.UnsharedAt != nil {
+			link.Expires = time.Time(*info.SharedLink.UnsharedAt)
+		}
+		links = append(links, link)
+	}
+	// Read the link to the directory itself
+	opts := rest.Opts{
+		Method:     "GET",
+		Path:       "/folders/" + dirID,
+		Parameters: fieldsValue(),
+	}
+	var info api.Item
+	var resp *http.Response
+	err = f.pacer.Call(func() (bool, error) {
+		resp, err = f.srv.CallJSON(ctx, &opts, nil, &info)
+		return shouldRetry(ctx, resp, err)
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read directory")
+	}
+	addLink(dir, &info)
+	// Then recurse through the links to everything under it
+	var listDir func(dir, dirID string) error
+	listDir = func(dir, dirID string) error {
+		var dirs []*api.Item
+		_, err := f.listAll(ctx, dirID, false, false, func(info *api.Item) bool {
+			remote := path.Join(dir, info.Name)
+			addLink(remote, info)
+			if info.Type == api.ItemTypeFolder {
+				dirs = append(dirs, info)
+			}
+			return false
+		})
+		if err != nil {
+			return err
+		}
+		for _, info := range dirs {
+			err = listDir(path.Join(dir, info.Name), info.ID)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	err = listDir(dir, dirID)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list links")
+	}
+	return links, nil
+}
+
+// RevokeLink removes the public link with the URL given
+func (f *Fs) RevokeLink(ctx context.Context, url string) (err error) {
+	// Find the item the link is to
+	opts := rest.Opts{
+		Method:     "GET",
+		Path:       "/shared_items",
+		Parameters: fieldsValue(),
+		ExtraHeaders: map[string]string{
+			"BoxApi": "shared_link=" + url,
+		},
+	}
+	var info api.Item
+	var resp *http.Response
+	err = f.pacer.Call(func() (bool, error) {
+		resp, err = f.srv.CallJSON(ctx, &opts, nil, &info)
+		return shouldRetry(ctx, resp, err)
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to find link")
+	}
+	// Then remove the link from it
+	opts = rest.Opts{
+		Method:     "PUT",
+		Path:       "/" + info.Type + "s/" + info.ID,
+		Parameters: fieldsValue(),
+	}
+	removeLink := api.RemoveSharedLink{}
+	err = f.pacer.Call(func() (bool, error) {
+		resp, err = f.srv.CallJSON(ctx, &opts, &removeLink, &info)
+		return shouldRetry(ctx, resp, err)
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to revoke link")
+	}
+	return nil
+}
+
 // deletePermanently permanently deletes a trashed file
 func (f *Fs) deletePermanently(ctx context.Context, itemType, id string) error {
 	opts := rest.Opts{
@@ -1377,6 +1500,7 @@ var (
 	_ fs.DirMover        = (*Fs)(nil)
 	_ fs.DirCacheFlusher = (*Fs)(nil)
 	_ fs.PublicLinker    = (*Fs)(nil)
+	_ fs.LinkManager     = (*Fs)(nil)
 	_ fs.CleanUpper      = (*Fs)(nil)
 	_ fs.Object          = (*Object)(nil)
 	_ fs.IDer            = (*Object)(nil)
@@ -0,0 +1,52 @@
+package box
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pingme998/rclone/fs"
+	"github.com/pingme998/rclone/lib/pacer"
+	"github.com/pingme998/rclone/lib/rest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRevokeLink(t *testing.T) {
+	ctx := context.Background()
+	const linkURL = "https://app.box.com/s/potato"
+	var (
+		gotBoxAPI string
+		gotPath   string
+		gotBody   string
+	)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.Method {
+		case "GET":
+			assert.Equal(t, "/shared_items", r.URL.Path)
+			gotBoxAPI = r.Header.Get("BoxApi")
+			_, _ = w.Write([]byte(`{"type":"file","id":"123","name":"potato.txt","item_status":"active","shared_link":{"url":"` + linkURL + `"}}`))
+		case "PUT":
+			gotPath = r.URL.Path
+			body, err := ioutil.ReadAll(r.Body)
+			require.NoError(t, err)
+			gotBody = string(body)
+			_, _ = w.Write([]byte(`{"type":"file","id":"123","name":"potato.txt","item_status":"active","shared_link":null}`))
+		default:
+			t.Errorf("unexpected method %q", r.Method)
+		}
+	}))
+	defer ts.Close()
+
+	f := &Fs{
+		srv:   rest.NewClient(http.DefaultClient).SetRoot(ts.URL),
+		pacer: fs.NewPacer(ctx, pacer.NewDefault()),
+	}
+	require.NoError(t, f.RevokeLink(ctx, linkURL))
+	assert.Equal(t, "shared_link="+linkURL, gotBoxAPI)
+	assert.Equal(t, "/files/123", gotPath)
+	assert.JSONEq(t, `{"shared_link":null}`, gotBody)
+}
@@ -1909,6 +1909,31 @@ var commandHelp = []fs.CommandHelp{
 	{
 		Name:  "stats",
 		Short: "Print stats on the cache backend in JSON format.",
+	}, {
+		Name:  "migrate",
+		Short: "Copy the cached file data into the VFS cache.",
+		Long: `The cache backend is deprecated. This command eases moving off it by
+copying the file data in its chunk store into the VFS cache of the
+wrapped remote, so a mount of the wrapped remote with
+--vfs-cache-mode full can use it without downloading it again.
+
+Usage Examples:
+
+    rclone backend migrate cache:
+    rclone backend migrate cache: path/to/dir path/to/file
+
+With no arguments all the cached files are copied, otherwise just the
+files at or under the paths given. The data is copied into the VFS
+cache in --cache-dir for the remote the cache backend wraps, so mount
+that remote with the same path and --cache-dir afterwards.
+
+Files whose cached data is out of date are skipped. The chunk store is
+left unchanged so it can be removed by hand once the VFS cache is in
+use.
+
+It returns the numbers of files, chunks and bytes copied and files
+skipped.
+`,
 	},
 }
 
@@ -1925,6 +1950,11 @@ func (f *Fs) Command(ctx context.Context, name string, arg []string, opt map[str
 	switch name {
 	case "stats":
 		return f.Stats()
+	case "migrate":
+		if len(arg) == 0 {
+			arg = []string{""}
+		}
+		return f.migrate(ctx, arg)
 	default:
 		return nil, fs.ErrorCommandNotFound
 	}
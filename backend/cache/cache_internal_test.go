@@ -7,6 +7,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/base64"
+	"encoding/json"
 	goflag "flag"
 	"fmt"
 	"io"
@@ -715,6 +716,73 @@ func TestInternalMaxChunkSizeRespected(t *testing.T) {
 	require.True(t, boltDb.HasChunk(co, chunkSize*5))
 }
 
+func TestInternalMigrate(t *testing.T) {
+	id := fmt.Sprintf("timig%v", time.Now().Unix())
+	rootFs, boltDb := runInstance.newCacheFs(t, remoteName, id, false, true, nil, map[string]string{"chunk_size": "1k"})
+	defer runInstance.cleanupFs(t, rootFs, boltDb)
+	if runInstance.rootIsCrypt {
+		t.Skip("test skipped with crypt remote")
+	}
+
+	cfs, err := runInstance.getCacheFs(rootFs)
+	require.NoError(t, err)
+	chunkSize := cfs.ChunkSize()
+	wrappedFs := cfs.UnWrap()
+	vfsRoot := filepath.Join(config.CacheDir, "vfs", wrappedFs.Name())
+	vfsMetaRoot := filepath.Join(config.CacheDir, "vfsMeta", wrappedFs.Name())
+	defer func() {
+		_ = os.RemoveAll(vfsRoot)
+		_ = os.RemoveAll(vfsMetaRoot)
+	}()
+
+	// read one fully so all its chunks are cached
+	one := randStringBytes(int(chunkSize*2 + chunkSize/2))
+	runInstance.writeRemoteBytes(t, rootFs, "dir/one", one)
+	_, err = runInstance.readDataFromRemote(t, rootFs, "dir/one", 0, int64(len(one)), false)
+	require.NoError(t, err)
+	co, err := cfs.NewObject(context.Background(), "dir/one")
+	require.NoError(t, err)
+	for i := int64(0); i < 3; i++ {
+		require.True(t, boltDb.HasChunk(co.(*cache.Object), chunkSize*i))
+	}
+
+	// never read two so it has no cached chunks
+	runInstance.writeRemoteBytes(t, rootFs, "dir/two", randStringBytes(int(chunkSize)))
+
+	// change stale in the wrapped remote after reading it so its
+	// cached chunks are out of date
+	stale := randStringBytes(int(chunkSize))
+	runInstance.writeRemoteBytes(t, rootFs, "stale", stale)
+	_, err = runInstance.readDataFromRemote(t, rootFs, "stale", 0, int64(len(stale)), false)
+	require.NoError(t, err)
+	runInstance.writeRemoteBytes(t, wrappedFs, "stale", randStringBytes(int(chunkSize/2)))
+
+	out, err := cfs.Command(context.Background(), "migrate", nil, nil)
+	require.NoError(t, err)
+	stats, err := json.Marshal(out)
+	require.NoError(t, err)
+	require.JSONEq(t, fmt.Sprintf(`{"files":1,"chunks":3,"bytes":%d,"skipped":1}`, len(one)), string(stats))
+
+	// the VFS cache of the wrapped remote has the data for one only
+	vfsDir := filepath.Join(vfsRoot, filepath.FromSlash(wrappedFs.Root()))
+	got, err := ioutil.ReadFile(filepath.Join(vfsDir, "dir", "one"))
+	require.NoError(t, err)
+	require.Equal(t, one, got)
+	_, err = os.Stat(filepath.Join(vfsMetaRoot, filepath.FromSlash(wrappedFs.Root()), "dir", "one"))
+	require.NoError(t, err)
+	for _, remote := range []string{"dir/two", "stale"} {
+		_, err = os.Stat(filepath.Join(vfsDir, filepath.FromSlash(remote)))
+		require.True(t, os.IsNotExist(err), remote)
+	}
+
+	// migrating just dir skips stale
+	out, err = cfs.Command(context.Background(), "migrate", []string{"dir"}, nil)
+	require.NoError(t, err)
+	stats, err = json.Marshal(out)
+	require.NoError(t, err)
+	require.JSONEq(t, fmt.Sprintf(`{"files":1,"chunks":3,"bytes":%d,"skipped":0}`, len(one)), string(stats))
+}
+
 func TestInternalExpiredEntriesRemoved(t *testing.T) {
 	id := fmt.Sprintf("tieer%v", time.Now().Unix())
 	vfsflags.Opt.DirCacheTime = time.Second * 4 // needs to be lower than the defined
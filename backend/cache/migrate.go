@@ -0,0 +1,151 @@
+// +build !plan9,!js
+
+package cache
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/pingme998/rclone/fs"
+	"github.com/pingme998/rclone/fs/walk"
+	"github.com/pingme998/rclone/vfs/vfscache"
+	"github.com/pingme998/rclone/vfs/vfscommon"
+)
+
+// migrateStats is returned by the migrate command
+type migrateStats struct {
+	Files   int   `json:"files"`   // files copied into the VFS cache
+	Chunks  int   `json:"chunks"`  // chunks copied into the VFS cache
+	Bytes   int64 `json:"bytes"`   // bytes copied into the VFS cache
+	Skipped int   `json:"skipped"` // cached files skipped as out of date or in error
+}
+
+// cachedChunk is a chunk of an object in the chunk store
+type cachedChunk struct {
+	offset int64
+	path   string
+}
+
+// cachedChunks returns the chunks of co which are in the chunk store
+// in order of offset
+func (f *Fs) cachedChunks(co *Object) (chunks []cachedChunk, err error) {
+	dir := path.Join(f.cache.dataPath, co.abs())
+	fis, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	for _, fi := range fis {
+		offset, err := strconv.ParseInt(fi.Name(), 10, 64)
+		if err != nil || fi.IsDir() {
+			continue
+		}
+		chunks = append(chunks, cachedChunk{offset: offset, path: path.Join(dir, fi.Name())})
+	}
+	// ReadDir sorts by name so sort by offset
+	sort.Slice(chunks, func(i, j int) bool {
+		return chunks[i].offset < chunks[j].offset
+	})
+	return chunks, nil
+}
+
+// migrateObject copies the chunks of co in the chunk store into the
+// VFS cache item for it
+func (f *Fs) migrateObject(ctx context.Context, c *vfscache.Cache, co *Object, stats *migrateStats) error {
+	chunks, err := f.cachedChunks(co)
+	if err != nil {
+		return err
+	}
+	if len(chunks) == 0 {
+		return nil
+	}
+	o, err := f.Fs.NewObject(ctx, co.Remote())
+	if err != nil {
+		return err
+	}
+	// Only use the chunks if they are for the current version of the file
+	dt := o.ModTime(ctx).Sub(time.Unix(0, co.CacheModTime))
+	if dt < 0 {
+		dt = -dt
+	}
+	if o.Size() != co.CacheSize || dt > f.Fs.Precision() {
+		return errors.New("cached chunks are out of date")
+	}
+	// Listings refresh co from the wrapped remote without expiring
+	// the chunks, so check they were stored after the file was
+	// last modified too
+	for _, chunk := range chunks {
+		ts, err := f.cache.GetChunkTs(co.abs(), chunk.offset)
+		if err != nil || ts.Before(o.ModTime(ctx)) {
+			return errors.New("cached chunks are out of date")
+		}
+	}
+	item := c.Item(co.Remote())
+	err = item.Open(o)
+	if err != nil {
+		return err
+	}
+	for _, chunk := range chunks {
+		if chunk.offset >= o.Size() {
+			continue
+		}
+		data, err := ioutil.ReadFile(chunk.path)
+		if err != nil {
+			_ = item.Close(nil)
+			return err
+		}
+		if end := o.Size() - chunk.offset; int64(len(data)) > end {
+			data = data[:end]
+		}
+		n, _, err := item.WriteAtNoOverwrite(data, chunk.offset)
+		if err != nil {
+			_ = item.Close(nil)
+			return err
+		}
+		stats.Chunks++
+		stats.Bytes += int64(n)
+	}
+	stats.Files++
+	return item.Close(nil)
+}
+
+// migrate copies the file data in the chunk store for the files at or
+// under remotes into the VFS cache of the wrapped remote
+func (f *Fs) migrate(ctx context.Context, remotes []string) (stats migrateStats, err error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	vfsOpt := vfscommon.DefaultOpt
+	vfsOpt.CacheMode = vfscommon.CacheModeFull
+	c, err := vfscache.New(ctx, f.Fs, &vfsOpt, nil)
+	if err != nil {
+		return stats, errors.Wrap(err, "failed to open VFS cache")
+	}
+	for _, remote := range remotes {
+		err = walk.ListR(ctx, f, remote, false, -1, walk.ListObjects, func(entries fs.DirEntries) error {
+			for _, entry := range entries {
+				co, ok := entry.(*Object)
+				if !ok {
+					continue
+				}
+				err := f.migrateObject(ctx, c, co, &stats)
+				if err != nil {
+					fs.Errorf(co, "Not migrating to VFS cache: %v", err)
+					stats.Skipped++
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return stats, err
+		}
+	}
+	fs.Infof(f, "Migrated %d chunks of %d files (%v) to the VFS cache", stats.Chunks, stats.Files, fs.SizeSuffix(stats.Bytes))
+	return stats, nil
+}
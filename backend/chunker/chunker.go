@@ -30,6 +30,8 @@ import (
 	"github.com/pingme998/rclone/fs/fspath"
 	"github.com/pingme998/rclone/fs/hash"
 	"github.com/pingme998/rclone/fs/operations"
+	"github.com/zeebo/blake3"
+	"github.com/zeebo/xxh3"
 )
 
 //
@@ -80,8 +82,16 @@ import (
 // they are currently ignored aka reserved.
 // In future they can be used to implement resumable uploads etc.
 //
+// The only control chunk written is the journal. Before a composite
+// file made of more than one chunk is committed chunker saves its new metadata in the journal,
+// with the transaction identifier of the temporary data chunks, and
+// removes it once the meta object has been updated. If the commit is
+// interrupted the journal is left behind so the fsck command can
+// complete or undo it. Older rclone versions ignore the journal.
+//
 const (
 	ctrlTypeRegStr   = `[a-z][a-z0-9]{2,6}`
+	ctrlTypeJournal  = "journal"
 	tempSuffixFormat = `_%04s`
 	tempSuffixRegStr = `_([0-9a-z]{4,9})`
 	tempSuffixRegOld = `\.\.tmp_([0-9]{10,13})`
@@ -146,6 +156,7 @@ func init() {
 		Name:        "chunker",
 		Description: "Transparently chunk/split large files",
 		NewFs:       NewFs,
+		CommandHelp: commandHelp,
 		Options: []fs.Option{{
 			Name:     "remote",
 			Required: true,
@@ -188,7 +199,7 @@ Metadata is a small JSON file named after the composite file.`,
 			}, {
 				Value: "simplejson",
 				Help: `Simple JSON supports hash sums and chunk validation.
-It has the following fields: ver, size, nchunks, md5, sha1.`,
+It has the following fields: ver, size, nchunks, md5, sha1, xxh3, blake3.`,
 			}},
 		}, {
 			Name:     "hash_type",
@@ -216,6 +227,18 @@ It has the following fields: ver, size, nchunks, md5, sha1.`,
 			}, {
 				Value: "sha1quick",
 				Help:  `Similar to "md5quick" but prefers SHA1 over MD5`,
+			}, {
+				Value: "xxh3",
+				Help:  `XXH3 for composite files`,
+			}, {
+				Value: "blake3",
+				Help:  `BLAKE3 for composite files`,
+			}, {
+				Value: "xxh3all",
+				Help:  `XXH3 for all files`,
+			}, {
+				Value: "blake3all",
+				Help:  `BLAKE3 for all files`,
 			}},
 		}, {
 			Name:     "fail_hard",
@@ -357,6 +380,8 @@ type Fs struct {
 	useMeta      bool           // false if metadata format is 'none'
 	useMD5       bool           // mutually exclusive with useSHA1
 	useSHA1      bool           // mutually exclusive with useMD5
+	useXXH3      bool           // mutually exclusive with the other hashes
+	useBLAKE3    bool           // mutually exclusive with the other hashes
 	hashFallback bool           // allows fallback from MD5 to SHA1 and vice versa
 	hashAll      bool           // hash all files, mutually exclusive with hashFallback
 	dataNameFmt  string         // name format of data chunks
@@ -413,6 +438,8 @@ func (f *Fs) setMetaFormat(metaFormat string) error {
 func (f *Fs) setHashType(hashType string) error {
 	f.useMD5 = false
 	f.useSHA1 = false
+	f.useXXH3 = false
+	f.useBLAKE3 = false
 	f.hashFallback = false
 	f.hashAll = false
 	requireMetaHash := true
@@ -436,6 +463,16 @@ func (f *Fs) setHashType(hashType string) error {
 	case "sha1all":
 		f.useSHA1 = true
 		f.hashAll = !f.base.Hashes().Contains(hash.SHA1)
+	case "xxh3":
+		f.useXXH3 = true
+	case "blake3":
+		f.useBLAKE3 = true
+	case "xxh3all":
+		f.useXXH3 = true
+		f.hashAll = !f.base.Hashes().Contains(hash.XXH3)
+	case "blake3all":
+		f.useBLAKE3 = true
+		f.hashAll = !f.base.Hashes().Contains(hash.BLAKE3)
 	default:
 		return fmt.Errorf("unsupported hash type '%s'", hashType)
 	}
@@ -953,6 +990,9 @@ func (f *Fs) scanObject(ctx context.Context, remote string, quickScan bool) (fs.
 				// temporary/control chunk calls for lazy metadata read
 				o.unsure = true
 			}
+			if ctrlType == ctrlTypeJournal && xactID == "" {
+				o.journal = true
+			}
 			continue
 		}
 		//fs.Debugf(f, "%q belongs to %q as chunk %d", entryRemote, mainRemote, chunkNo)
@@ -1059,10 +1099,15 @@ func (o *Object) readMetadata(ctx context.Context) error {
 			return errors.Wrap(err, "invalid metadata")
 		}
 		if o.size != metaInfo.Size() || len(o.chunks) != metaInfo.nChunks {
+			if o.journal {
+				return errors.New("metadata doesn't match file size, update was interrupted - run the fsck backend command")
+			}
 			return errors.New("metadata doesn't match file size")
 		}
 		o.md5 = metaInfo.md5
 		o.sha1 = metaInfo.sha1
+		o.xxh3 = metaInfo.xxh3
+		o.blake3 = metaInfo.blake3
 		o.xactID = metaInfo.xactID
 	}
 
@@ -1263,6 +1308,27 @@ func (f *Fs) put(
 		return nil, fmt.Errorf("Incorrect chunks size %d != %d", sizeTotal, c.readCount)
 	}
 
+	// Write the journal so an interrupted commit can be repaired.
+	// Files stored in a single chunk aren't journalled as it is only
+	// worth the extra transactions for a chunk set.
+	var journal fs.Object
+	if len(c.chunks) > 1 && f.useMeta && f.opt.MetaFormat == "simplejson" {
+		c.updateHashes()
+		metadata, err := marshalSimpleJSON(ctx, sizeTotal, len(c.chunks), c.md5, c.sha1, c.xxh3, c.blake3, xactID)
+		if err != nil {
+			return nil, err
+		}
+		journal, err = f.writeJournal(ctx, src, baseRemote, metadata)
+		if err != nil {
+			return nil, err
+		}
+		defer func() {
+			if err != nil {
+				silentlyRemove(ctx, journal)
+			}
+		}()
+	}
+
 	// If previous object was chunked, remove its chunks
 	f.removeOldChunks(ctx, baseRemote)
 
@@ -1296,7 +1362,7 @@ func (f *Fs) put(
 	switch f.opt.MetaFormat {
 	case "simplejson":
 		c.updateHashes()
-		metadata, err = marshalSimpleJSON(ctx, sizeTotal, len(c.chunks), c.md5, c.sha1, xactID)
+		metadata, err = marshalSimpleJSON(ctx, sizeTotal, len(c.chunks), c.md5, c.sha1, c.xxh3, c.blake3, xactID)
 	}
 	if err == nil {
 		metaInfo := f.wrapInfo(src, baseRemote, int64(len(metadata)))
@@ -1306,6 +1372,13 @@ func (f *Fs) put(
 		return nil, err
 	}
 
+	// The commit is complete
+	if journal != nil {
+		if err := journal.Remove(ctx); err != nil {
+			fs.Errorf(journal, "Failed to remove journal: %v", err)
+		}
+	}
+
 	o := f.newObject("", metaObject, c.chunks)
 	o.size = sizeTotal
 	o.xactID = xactID
@@ -1331,6 +1404,8 @@ type chunkingReader struct {
 	hasher       gohash.Hash
 	md5          string
 	sha1         string
+	xxh3         string
+	blake3       string
 }
 
 func (f *Fs) newChunkingReader(src fs.ObjectInfo) *chunkingReader {
@@ -1377,6 +1452,26 @@ func (c *chunkingReader) wrapStream(ctx context.Context, in io.Reader, src fs.Ob
 				c.hasher = sha1.New()
 			}
 		}
+	case c.fs.useXXH3:
+		srcObj := fs.UnWrapObjectInfo(src)
+		if srcObj != nil && srcObj.Fs().Features().SlowHash {
+			fs.Debugf(src, "skip slow XXH3 on source file, hashing in-transit")
+			c.hasher = xxh3.New()
+			break
+		}
+		if c.xxh3, _ = src.Hash(ctx, hash.XXH3); c.xxh3 == "" {
+			c.hasher = xxh3.New()
+		}
+	case c.fs.useBLAKE3:
+		srcObj := fs.UnWrapObjectInfo(src)
+		if srcObj != nil && srcObj.Fs().Features().SlowHash {
+			fs.Debugf(src, "skip slow BLAKE3 on source file, hashing in-transit")
+			c.hasher = blake3.New()
+			break
+		}
+		if c.blake3, _ = src.Hash(ctx, hash.BLAKE3); c.blake3 == "" {
+			c.hasher = blake3.New()
+		}
 	}
 
 	if c.hasher != nil {
@@ -1395,6 +1490,10 @@ func (c *chunkingReader) updateHashes() {
 		c.md5 = hex.EncodeToString(c.hasher.Sum(nil))
 	case c.fs.useSHA1:
 		c.sha1 = hex.EncodeToString(c.hasher.Sum(nil))
+	case c.fs.useXXH3:
+		c.xxh3 = hex.EncodeToString(c.hasher.Sum(nil))
+	case c.fs.useBLAKE3:
+		c.blake3 = hex.EncodeToString(c.hasher.Sum(nil))
 	}
 }
 
@@ -1475,6 +1574,18 @@ func (c *chunkingReader) rollback(ctx context.Context, metaObject fs.Object) {
 	}
 }
 
+// writeJournal saves the metadata of the composite file at remote in
+// its journal before the file is committed
+func (f *Fs) writeJournal(ctx context.Context, src fs.ObjectInfo, remote string, metadata []byte) (fs.Object, error) {
+	journalRemote := f.makeChunkName(remote, -1, ctrlTypeJournal, "")
+	info := f.wrapInfo(src, journalRemote, int64(len(metadata)))
+	journal, err := f.base.Put(ctx, bytes.NewReader(metadata), info)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to write journal")
+	}
+	return journal, nil
+}
+
 func (f *Fs) removeOldChunks(ctx context.Context, remote string) {
 	oldFsObject, err := f.NewObject(ctx, remote)
 	if err == nil {
@@ -1545,6 +1656,12 @@ func (f *Fs) Hashes() hash.Set {
 	if f.useSHA1 && !f.hashFallback && (f.hashAll || f.base.Hashes().Contains(hash.SHA1)) {
 		return hash.NewHashSet(hash.SHA1)
 	}
+	if f.useXXH3 && (f.hashAll || f.base.Hashes().Contains(hash.XXH3)) {
+		return hash.NewHashSet(hash.XXH3)
+	}
+	if f.useBLAKE3 && (f.hashAll || f.base.Hashes().Contains(hash.BLAKE3)) {
+		return hash.NewHashSet(hash.BLAKE3)
+	}
 	return hash.NewHashSet() // can't provide strong guarantees
 }
 
@@ -1643,12 +1760,13 @@ func (o *Object) Remove(ctx context.Context) (err error) {
 		}
 	}
 
-	// There are no known control chunks to remove atm.
+	// The journal is left alone as it could belong to a commit
+	// running in parallel, fsck removes stale ones.
 	return err
 }
 
 // copyOrMove implements copy or move
-func (f *Fs) copyOrMove(ctx context.Context, o *Object, remote string, do copyMoveFn, md5, sha1, opName string) (fs.Object, error) {
+func (f *Fs) copyOrMove(ctx context.Context, o *Object, remote string, do copyMoveFn, md5, sha1, xxh3, blake3, opName string) (fs.Object, error) {
 	if err := f.forbidChunk(o, remote); err != nil {
 		return nil, errors.Wrapf(err, "can't %s", opName)
 	}
@@ -1712,7 +1830,7 @@ func (f *Fs) copyOrMove(ctx context.Context, o *Object, remote string, do copyMo
 	var metadata []byte
 	switch f.opt.MetaFormat {
 	case "simplejson":
-		metadata, err = marshalSimpleJSON(ctx, newObj.size, len(newChunks), md5, sha1, o.xactID)
+		metadata, err = marshalSimpleJSON(ctx, newObj.size, len(newChunks), md5, sha1, xxh3, blake3, o.xactID)
 		if err == nil {
 			metaInfo := f.wrapInfo(metaObject, "", int64(len(metadata)))
 			err = newObj.main.Update(ctx, bytes.NewReader(metadata), metaInfo)
@@ -1733,7 +1851,7 @@ func (f *Fs) copyOrMove(ctx context.Context, o *Object, remote string, do copyMo
 
 type copyMoveFn func(context.Context, fs.Object, string) (fs.Object, error)
 
-func (f *Fs) okForServerSide(ctx context.Context, src fs.Object, opName string) (obj *Object, md5, sha1 string, ok bool) {
+func (f *Fs) okForServerSide(ctx context.Context, src fs.Object, opName string) (obj *Object, md5, sha1, xxh3, blake3 string, ok bool) {
 	var diff string
 	obj, ok = src.(*Object)
 
@@ -1782,6 +1900,12 @@ func (f *Fs) okForServerSide(ctx context.Context, src fs.Object, opName string)
 			md5, _ = obj.Hash(ctx, hash.MD5)
 			ok = md5 != ""
 		}
+	case f.useXXH3:
+		xxh3, _ = obj.Hash(ctx, hash.XXH3)
+		ok = xxh3 != ""
+	case f.useBLAKE3:
+		blake3, _ = obj.Hash(ctx, hash.BLAKE3)
+		ok = blake3 != ""
 	default:
 		ok = false
 	}
@@ -1805,11 +1929,11 @@ func (f *Fs) Copy(ctx context.Context, src fs.Object, remote string) (fs.Object,
 	if baseCopy == nil {
 		return nil, fs.ErrorCantCopy
 	}
-	obj, md5, sha1, ok := f.okForServerSide(ctx, src, "copy")
+	obj, md5, sha1, xxh3, blake3, ok := f.okForServerSide(ctx, src, "copy")
 	if !ok {
 		return nil, fs.ErrorCantCopy
 	}
-	return f.copyOrMove(ctx, obj, remote, baseCopy, md5, sha1, "copy")
+	return f.copyOrMove(ctx, obj, remote, baseCopy, md5, sha1, xxh3, blake3, "copy")
 }
 
 // Move src to this remote using server-side move operations.
@@ -1825,11 +1949,11 @@ func (f *Fs) Move(ctx context.Context, src fs.Object, remote string) (fs.Object,
 	baseMove := func(ctx context.Context, src fs.Object, remote string) (fs.Object, error) {
 		return f.baseMove(ctx, src, remote, delNever)
 	}
-	obj, md5, sha1, ok := f.okForServerSide(ctx, src, "move")
+	obj, md5, sha1, xxh3, blake3, ok := f.okForServerSide(ctx, src, "move")
 	if !ok {
 		return nil, fs.ErrorCantMove
 	}
-	return f.copyOrMove(ctx, obj, remote, baseMove, md5, sha1, "move")
+	return f.copyOrMove(ctx, obj, remote, baseMove, md5, sha1, xxh3, blake3, "move")
 }
 
 // baseMove chains to the wrapped Move or simulates it by Copy+Delete
@@ -1962,9 +2086,12 @@ type Object struct {
 	isFull    bool        // true if metadata has been read
 	xIDCached bool        // true if xactID has been read
 	unsure    bool        // true if need to read metadata to detect object type
+	journal   bool        // true if the journal of an unfinished commit was found
 	xactID    string      // transaction ID for "norename" or empty string for "renamed" chunks
 	md5       string
 	sha1      string
+	xxh3      string
+	blake3    string
 	f         *Fs
 }
 
@@ -2138,6 +2265,16 @@ func (o *Object) Hash(ctx context.Context, hashType hash.Type) (string, error) {
 			return "", nil
 		}
 		return o.sha1, nil
+	case hash.XXH3:
+		if o.xxh3 == "" {
+			return "", nil
+		}
+		return o.xxh3, nil
+	case hash.BLAKE3:
+		if o.blake3 == "" {
+			return "", nil
+		}
+		return o.blake3, nil
 	default:
 		return "", hash.ErrUnsupported
 	}
@@ -2272,7 +2409,9 @@ func (r *linearReader) Read(p []byte) (n int, err error) {
 	if err == nil || err == io.EOF {
 		r.count -= int64(n)
 		r.limit -= int64(n)
-		if r.limit > 0 {
+		if err == io.EOF && r.count > 0 {
+			err = io.ErrUnexpectedEOF // chunk is shorter than listed
+		} else if r.limit > 0 {
 			err = nil // more data to read
 		}
 	}
@@ -2298,6 +2437,8 @@ type ObjectInfo struct {
 	remote  string // overrides remote name
 	md5     string // overrides MD5 checksum
 	sha1    string // overrides SHA1 checksum
+	xxh3    string // overrides XXH3 checksum
+	blake3  string // overrides BLAKE3 checksum
 }
 
 func (f *Fs) wrapInfo(src fs.ObjectInfo, newRemote string, totalSize int64) *ObjectInfo {
@@ -2362,6 +2503,14 @@ func (oi *ObjectInfo) Hash(ctx context.Context, hashType hash.Type) (string, err
 		if oi.sha1 != "" {
 			return oi.sha1, nil
 		}
+	case hash.XXH3:
+		if oi.xxh3 != "" {
+			return oi.xxh3, nil
+		}
+	case hash.BLAKE3:
+		if oi.blake3 != "" {
+			return oi.blake3, nil
+		}
 	default:
 		errUnsupported = hash.ErrUnsupported
 	}
@@ -2394,6 +2543,8 @@ type metaSimpleJSON struct {
 	// optional extra fields
 	MD5    string `json:"md5,omitempty"`
 	SHA1   string `json:"sha1,omitempty"`
+	XXH3   string `json:"xxh3,omitempty"`
+	BLAKE3 string `json:"blake3,omitempty"`
 	XactID string `json:"txn,omitempty"` // transaction ID for norename transactions
 }
 
@@ -2404,7 +2555,7 @@ type metaSimpleJSON struct {
 // - if file contents can be mistaken as meta object
 // - if consistent hashing is On but wrapped remote can't provide given hash
 //
-func marshalSimpleJSON(ctx context.Context, size int64, nChunks int, md5, sha1, xactID string) ([]byte, error) {
+func marshalSimpleJSON(ctx context.Context, size int64, nChunks int, md5, sha1, xxh3, blake3, xactID string) ([]byte, error) {
 	version := metadataVersion
 	if xactID == "" && version == 2 {
 		version = 1
@@ -2417,6 +2568,8 @@ func marshalSimpleJSON(ctx context.Context, size int64, nChunks int, md5, sha1,
 		// optional extra fields
 		MD5:    md5,
 		SHA1:   sha1,
+		XXH3:   xxh3,
+		BLAKE3: blake3,
 		XactID: xactID,
 	}
 	data, err := json.Marshal(&metadata)
@@ -2481,6 +2634,18 @@ func unmarshalSimpleJSON(ctx context.Context, metaObject fs.Object, data []byte)
 			return nil, false, errors.New("wrong sha1 hash")
 		}
 	}
+	if metadata.XXH3 != "" {
+		_, err = hex.DecodeString(metadata.XXH3)
+		if len(metadata.XXH3) != 16 || err != nil {
+			return nil, false, errors.New("wrong xxh3 hash")
+		}
+	}
+	if metadata.BLAKE3 != "" {
+		_, err = hex.DecodeString(metadata.BLAKE3)
+		if len(metadata.BLAKE3) != 64 || err != nil {
+			return nil, false, errors.New("wrong blake3 hash")
+		}
+	}
 	// ChunkNum is allowed to be 0 in future versions
 	if *metadata.ChunkNum < 1 && *metadata.Version <= metadataVersion {
 		return nil, false, errors.New("wrong number of chunks")
@@ -2495,6 +2660,8 @@ func unmarshalSimpleJSON(ctx context.Context, metaObject fs.Object, data []byte)
 	info.nChunks = *metadata.ChunkNum
 	info.md5 = metadata.MD5
 	info.sha1 = metadata.SHA1
+	info.xxh3 = metadata.XXH3
+	info.blake3 = metadata.BLAKE3
 	info.xactID = metadata.XactID
 	return info, true, nil
 }
@@ -2533,6 +2700,64 @@ func (f *Fs) CanQuickRename() bool {
 	return f.base.Features().Move != nil
 }
 
+var commandHelp = []fs.CommandHelp{{
+	Name:  "fsck",
+	Short: "Check and repair composite files",
+	Long: `This command checks the composite files at or under the paths given,
+or the whole remote if none are given, for problems left behind by
+interrupted transfers.
+
+Usage Examples:
+
+    rclone backend fsck chunker:
+    rclone backend fsck chunker: path/to/dir path/to/other/dir
+    rclone backend fsck chunker: -o repair
+
+It finds
+
+- updates interrupted while being committed - these are completed if
+  all the new data chunks were uploaded, otherwise undone
+- stale journals left by updates which had finished
+- orphaned temporary chunks left by interrupted uploads
+- orphaned data chunks which don't belong to a file
+- partial chunk sets with chunks missing - these can't be repaired
+
+Without the repair option it only reports the problems. With it the
+problems are repaired where possible. Don't repair a remote while
+other rclone processes are uploading to it as their temporary chunks
+look orphaned.
+
+It returns a list of the problems found and the action taken.
+`,
+	Opts: map[string]string{
+		"repair": "Repair the problems found",
+	},
+}}
+
+// Command the backend to run a named command
+//
+// The command run is name
+// args may be used to read arguments from
+// opts may be used to read optional arguments from
+//
+// The result should be capable of being JSON encoded
+// If it is a string or a []string it will be shown to the user
+// otherwise it will be JSON encoded and shown to the user like that
+func (f *Fs) Command(ctx context.Context, name string, arg []string, opt map[string]string) (out interface{}, err error) {
+	switch name {
+	case "fsck":
+		_, repair := opt["repair"]
+		c := &fsck{f: f, repair: repair, results: []fsckResult{}}
+		if len(arg) == 0 {
+			arg = []string{""}
+		}
+		err = c.run(ctx, arg)
+		return c.results, err
+	default:
+		return nil, fs.ErrorCommandNotFound
+	}
+}
+
 // Check the interfaces are satisfied
 var (
 	_ fs.Fs              = (*Fs)(nil)
@@ -2549,6 +2774,7 @@ var (
 	_ fs.Wrapper         = (*Fs)(nil)
 	_ fs.ChangeNotifier  = (*Fs)(nil)
 	_ fs.Shutdowner      = (*Fs)(nil)
+	_ fs.Commander       = (*Fs)(nil)
 	_ fs.ObjectInfo      = (*ObjectInfo)(nil)
 	_ fs.Object          = (*Object)(nil)
 	_ fs.ObjectUnWrapper = (*Object)(nil)
@@ -5,6 +5,7 @@ import (
 	"context"
 	"flag"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"path"
 	"regexp"
@@ -402,6 +403,10 @@ func testSmallFileInternals(t *testing.T, f *Fs) {
 			ht = hash.MD5
 		case f.useSHA1:
 			ht = hash.SHA1
+		case f.useXXH3:
+			ht = hash.XXH3
+		case f.useBLAKE3:
+			ht = hash.BLAKE3
 		default:
 			return
 		}
@@ -662,7 +667,7 @@ func testMetadataInput(t *testing.T, f *Fs) {
 		}
 	}
 
-	metaData, err := marshalSimpleJSON(ctx, 3, 1, "", "", "")
+	metaData, err := marshalSimpleJSON(ctx, 3, 1, "", "", "", "", "")
 	require.NoError(t, err)
 	todaysMeta := string(metaData)
 	runSubtest(todaysMeta, "today")
@@ -676,6 +681,35 @@ func testMetadataInput(t *testing.T, f *Fs) {
 	runSubtest(futureMeta, "future")
 }
 
+// Test that hashes survive a round trip through metadata
+func testMetadataHashes(t *testing.T, f *Fs) {
+	ctx := context.Background()
+	const (
+		md5    = "d41d8cd98f00b204e9800998ecf8427e"
+		sha1   = "da39a3ee5e6b4b0d3255bfef95601890afd80709"
+		xxh3   = "2d06800538d394c2"
+		blake3 = "af1349b9f5f9a1a6a0404dea36dcc9499bcb25c9adc112b7cc9a93cae41f3262"
+	)
+	metaData, err := marshalSimpleJSON(ctx, 3, 1, md5, sha1, xxh3, blake3, "")
+	require.NoError(t, err)
+	info, madeByChunker, err := unmarshalSimpleJSON(ctx, nil, metaData)
+	require.NoError(t, err)
+	assert.True(t, madeByChunker)
+	assert.Equal(t, md5, info.md5)
+	assert.Equal(t, sha1, info.sha1)
+	assert.Equal(t, xxh3, info.xxh3)
+	assert.Equal(t, blake3, info.blake3)
+
+	for _, bad := range []string{"2d06", "potato"} {
+		badMeta := strings.Replace(string(metaData), xxh3, bad, 1)
+		_, _, err = unmarshalSimpleJSON(ctx, nil, []byte(badMeta))
+		assert.Error(t, err, "xxh3 "+bad)
+		badMeta = strings.Replace(string(metaData), blake3, bad, 1)
+		_, _, err = unmarshalSimpleJSON(ctx, nil, []byte(badMeta))
+		assert.Error(t, err, "blake3 "+bad)
+	}
+}
+
 // Test that chunker refuses to change on objects with future/unknown metadata
 func testFutureProof(t *testing.T, f *Fs) {
 	if f.opt.MetaFormat == "none" {
@@ -844,6 +878,129 @@ func testChunkerServerSideMove(t *testing.T, f *Fs) {
 	_ = operations.Purge(ctx, f.base, dir)
 }
 
+// putRecorder records the remotes uploaded to the base remote
+type putRecorder struct {
+	fs.Fs
+	remotes []string
+}
+
+// Put records the remote then uploads it
+func (r *putRecorder) Put(ctx context.Context, in io.Reader, src fs.ObjectInfo, options ...fs.OpenOption) (fs.Object, error) {
+	r.remotes = append(r.remotes, src.Remote())
+	return r.Fs.Put(ctx, in, src, options...)
+}
+
+// test that the journal is only written for chunk sets
+func testJournal(t *testing.T, f *Fs) {
+	if f.opt.MetaFormat != "simplejson" {
+		t.Skip("this test requires simplejson metadata")
+	}
+
+	ctx := context.Background()
+	const dir = "journal"
+	saveOpt := f.opt
+	saveBase := f.base
+	saveHashAll := f.hashAll
+	defer func() {
+		f.opt = saveOpt
+		f.base = saveBase
+		f.hashAll = saveHashAll
+		_ = operations.Purge(ctx, f.base, dir)
+	}()
+	f.opt.ChunkSize = fs.SizeSuffix(10)
+	// force metadata for single chunk files
+	f.hashAll = true
+	recorder := &putRecorder{Fs: f.base}
+	f.base = recorder
+
+	modTime := fstest.Time("2001-02-03T04:05:06.499999999Z")
+	put := func(name, contents string) []string {
+		recorder.remotes = nil
+		item := fstest.Item{Path: path.Join(dir, name), ModTime: modTime}
+		_, obj := fstests.PutTestContents(ctx, t, f, &item, contents, true)
+		require.NotNil(t, obj)
+		_, err := saveBase.NewObject(ctx, f.makeChunkName(item.Path, -1, ctrlTypeJournal, ""))
+		assert.Equal(t, fs.ErrorObjectNotFound, err, "journal should be removed")
+		return recorder.remotes
+	}
+
+	journal := f.makeChunkName(path.Join(dir, "single"), -1, ctrlTypeJournal, "")
+	assert.NotContains(t, put("single", "abc"), journal)
+
+	journal = f.makeChunkName(path.Join(dir, "chunked"), -1, ctrlTypeJournal, "")
+	assert.Contains(t, put("chunked", random.String(25)), journal)
+}
+
+// test that fsck finds and repairs interrupted commits and orphans
+func testFsck(t *testing.T, f *Fs) {
+	if f.opt.MetaFormat != "simplejson" {
+		t.Skip("this test requires simplejson metadata")
+	}
+
+	ctx := context.Background()
+	const dir = "fsck"
+	defer func() {
+		_ = operations.Purge(ctx, f.base, dir)
+	}()
+
+	modTime := fstest.Time("2001-02-03T04:05:06.499999999Z")
+	putBase := func(name, data string) {
+		item := fstest.Item{Path: name, ModTime: modTime}
+		_, obj := fstests.PutTestContents(ctx, t, f.base, &item, data, true)
+		require.NotNil(t, obj)
+	}
+	fsck := func(repair bool) []fsckResult {
+		opt := map[string]string{}
+		if repair {
+			opt["repair"] = ""
+		}
+		out, err := f.Command(ctx, "fsck", []string{dir}, opt)
+		require.NoError(t, err)
+		return out.([]fsckResult)
+	}
+
+	// simulate a commit interrupted while renaming the new chunks
+	const file = dir + "/interrupted"
+	journal, err := marshalSimpleJSON(ctx, 6, 2, "", "", "", "", "abcd")
+	require.NoError(t, err)
+	putBase(file, `{"ver":1,"size":9,"nchunks":3}`)
+	putBase(f.makeChunkName(file, -1, ctrlTypeJournal, ""), string(journal))
+	putBase(f.makeChunkName(file, 0, "", ""), "abc")
+	putBase(f.makeChunkName(file, 1, "", "abcd"), "def")
+	putBase(f.makeChunkName(file, 2, "", ""), "ghi")
+
+	// simulate orphaned chunks
+	putBase(f.makeChunkName(dir+"/temp", 0, "", "wxyz"), "tmp")
+	putBase(f.makeChunkName(dir+"/nometa", 0, "", ""), "orphan")
+
+	results := fsck(false)
+	require.Len(t, results, 3)
+	assert.Equal(t, fsckResult{Remote: file, Problem: "interrupted update"}, results[0])
+	assert.Equal(t, dir+"/nometa", results[1].Remote)
+	assert.Equal(t, dir+"/temp", results[2].Remote)
+	for _, result := range results {
+		assert.Equal(t, "", result.Action)
+	}
+
+	results = fsck(true)
+	require.Len(t, results, 3)
+	assert.Equal(t, "completed", results[0].Action)
+	assert.Equal(t, "removed", results[1].Action)
+	assert.Equal(t, "removed", results[2].Action)
+
+	obj, err := f.NewObject(ctx, file)
+	require.NoError(t, err)
+	assert.Equal(t, int64(6), obj.Size())
+	r, err := obj.Open(ctx)
+	require.NoError(t, err)
+	data, err := ioutil.ReadAll(r)
+	assert.NoError(t, err)
+	assert.Equal(t, "abcdef", string(data))
+	_ = r.Close()
+
+	assert.Len(t, fsck(false), 0)
+}
+
 // InternalTest dispatches all internal tests
 func (f *Fs) InternalTest(t *testing.T) {
 	t.Run("PutLarge", func(t *testing.T) {
@@ -867,6 +1024,9 @@ func (f *Fs) InternalTest(t *testing.T) {
 	t.Run("MetadataInput", func(t *testing.T) {
 		testMetadataInput(t, f)
 	})
+	t.Run("MetadataHashes", func(t *testing.T) {
+		testMetadataHashes(t, f)
+	})
 	t.Run("FutureProof", func(t *testing.T) {
 		testFutureProof(t, f)
 	})
@@ -876,6 +1036,12 @@ func (f *Fs) InternalTest(t *testing.T) {
 	t.Run("ChunkerServerSideMove", func(t *testing.T) {
 		testChunkerServerSideMove(t, f)
 	})
+	t.Run("Journal", func(t *testing.T) {
+		testJournal(t, f)
+	})
+	t.Run("Fsck", func(t *testing.T) {
+		testFsck(t, f)
+	})
 }
 
 var _ fstests.InternalTester = (*Fs)(nil)
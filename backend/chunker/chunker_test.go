@@ -38,6 +38,8 @@ func TestIntegration(t *testing.T) {
 		},
 		UnimplementableFsMethods: []string{
 			"PublicLink",
+			"ListLinks",
+			"RevokeLink",
 			"OpenWriterAt",
 			"MergeDirs",
 			"DirCacheFlush",
@@ -0,0 +1,329 @@
+package chunker
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"sort"
+
+	"github.com/pkg/errors"
+	"github.com/pingme998/rclone/fs"
+	"github.com/pingme998/rclone/fs/object"
+	"github.com/pingme998/rclone/fs/walk"
+)
+
+// fsckResult is returned by the fsck command for each problem found
+type fsckResult struct {
+	Remote  string `json:"remote"`
+	Problem string `json:"problem"`
+	Action  string `json:"action,omitempty"`
+}
+
+// chunkSet is the set of files in the wrapped remote belonging to a
+// composite file
+type chunkSet struct {
+	remote  string                       // remote of the composite file
+	main    fs.Object                    // meta object or non-chunked file, if any
+	journal fs.Object                    // journal, if any
+	active  map[int]fs.Object            // active data chunks by number
+	temp    map[string]map[int]fs.Object // temporary data chunks by xactID and number
+}
+
+// fsck checks the composite files at or under each of dirs
+type fsck struct {
+	f       *Fs
+	repair  bool
+	results []fsckResult
+}
+
+// report records a problem with remote and the action taken
+func (c *fsck) report(remote, problem, action string) {
+	if action == "" {
+		fs.Errorf(remote, "fsck: %s", problem)
+	} else {
+		fs.Logf(remote, "fsck: %s: %s", problem, action)
+	}
+	c.results = append(c.results, fsckResult{Remote: remote, Problem: problem, Action: action})
+}
+
+// remove removes the objects returning the action taken
+func (c *fsck) remove(ctx context.Context, objects []fs.Object) string {
+	if !c.repair {
+		return ""
+	}
+	for _, o := range objects {
+		if err := o.Remove(ctx); err != nil {
+			return fmt.Sprintf("failed to remove %q: %v", o.Remote(), err)
+		}
+	}
+	return "removed"
+}
+
+// scan finds the chunk sets under dir in the wrapped remote
+func (c *fsck) scan(ctx context.Context, dir string) (sets map[string]*chunkSet, err error) {
+	f := c.f
+	sets = make(map[string]*chunkSet)
+	get := func(remote string) *chunkSet {
+		set := sets[remote]
+		if set == nil {
+			set = &chunkSet{
+				remote: remote,
+				active: make(map[int]fs.Object),
+				temp:   make(map[string]map[int]fs.Object),
+			}
+			sets[remote] = set
+		}
+		return set
+	}
+	err = walk.ListR(ctx, f.base, dir, true, -1, walk.ListObjects, func(entries fs.DirEntries) error {
+		for _, entry := range entries {
+			o, ok := entry.(fs.Object)
+			if !ok {
+				continue
+			}
+			mainRemote, chunkNo, ctrlType, xactID := f.parseChunkName(o.Remote())
+			switch {
+			case mainRemote == "":
+				get(o.Remote()).main = o
+			case ctrlType == ctrlTypeJournal && xactID == "":
+				get(mainRemote).journal = o
+			case ctrlType != "":
+				// other control chunks are reserved
+			case xactID == "":
+				get(mainRemote).active[chunkNo] = o
+			default:
+				set := get(mainRemote)
+				if set.temp[xactID] == nil {
+					set.temp[xactID] = make(map[int]fs.Object)
+				}
+				set.temp[xactID][chunkNo] = o
+			}
+		}
+		return nil
+	})
+	return sets, err
+}
+
+// readInfo reads and parses the metadata in o returning nil if it
+// isn't chunker metadata
+func (c *fsck) readInfo(ctx context.Context, o fs.Object) (info *ObjectInfo, err error) {
+	if o == nil || o.Size() > maxMetadataSize || c.f.opt.MetaFormat != "simplejson" {
+		return nil, nil
+	}
+	reader, err := o.Open(ctx)
+	if err != nil {
+		return nil, err
+	}
+	data, err := ioutil.ReadAll(reader)
+	_ = reader.Close() // ensure file handle is freed on windows
+	if err != nil {
+		return nil, err
+	}
+	info, madeByChunker, err := unmarshalSimpleJSON(ctx, o, data)
+	if !madeByChunker {
+		return nil, nil
+	}
+	return info, err
+}
+
+// chunkObjects returns the objects in chunks sorted by chunk number
+func chunkObjects(chunks map[int]fs.Object) (objects []fs.Object) {
+	var nums []int
+	for chunkNo := range chunks {
+		nums = append(nums, chunkNo)
+	}
+	sort.Ints(nums)
+	for _, chunkNo := range nums {
+		objects = append(objects, chunks[chunkNo])
+	}
+	return objects
+}
+
+// sameInfo returns true if the metadata in a and b describes the same
+// file
+func sameInfo(a, b *ObjectInfo) bool {
+	return a.size == b.size && a.nChunks == b.nChunks &&
+		a.md5 == b.md5 && a.sha1 == b.sha1 && a.xxh3 == b.xxh3 && a.blake3 == b.blake3
+}
+
+// checkJournal deals with an interrupted commit of set
+//
+// It returns true if there is nothing more to check, which is the
+// case once the commit has been completed.
+func (c *fsck) checkJournal(ctx context.Context, set *chunkSet, info *ObjectInfo) (done bool, err error) {
+	const problem = "interrupted update"
+	journalInfo, err := c.readInfo(ctx, set.journal)
+	if err != nil || journalInfo == nil {
+		c.report(set.remote, "invalid journal", c.remove(ctx, []fs.Object{set.journal}))
+		return false, nil
+	}
+	xactID := journalInfo.xactID
+	temp := set.temp[xactID]
+
+	// The commit finished but the journal wasn't removed
+	if info != nil && sameInfo(info, journalInfo) && (info.xactID == xactID || len(temp) == 0) {
+		c.report(set.remote, "stale journal", c.remove(ctx, []fs.Object{set.journal}))
+		return false, nil
+	}
+
+	// Work out the new chunks - all the temporary chunks were
+	// uploaded before the journal was written, so a missing one
+	// has already been renamed to its active name
+	chunks := make([]fs.Object, journalInfo.nChunks)
+	var size int64
+	renamed := false
+	for chunkNo := range chunks {
+		chunk := temp[chunkNo]
+		if chunk == nil {
+			chunk = set.active[chunkNo]
+			renamed = true
+		}
+		if chunk == nil {
+			size = -1
+			break
+		}
+		chunks[chunkNo] = chunk
+		size += chunk.Size()
+	}
+
+	// Undo the commit if the new chunks aren't all there
+	if size != journalInfo.size {
+		action := c.remove(ctx, append(chunkObjects(temp), set.journal))
+		if action == "removed" {
+			action = "rolled back"
+			delete(set.temp, xactID)
+		}
+		c.report(set.remote, problem+": new chunks incomplete", action)
+		return false, nil
+	}
+	if !c.repair {
+		c.report(set.remote, problem, "")
+		return true, nil
+	}
+
+	// Otherwise complete the commit
+	f := c.f
+	var obsolete []fs.Object
+	for chunkNo, chunk := range set.active {
+		if chunkNo >= len(chunks) || chunks[chunkNo] != chunk {
+			obsolete = append(obsolete, chunk)
+		}
+	}
+	if action := c.remove(ctx, obsolete); action != "removed" {
+		c.report(set.remote, problem, action)
+		return true, nil
+	}
+	if renamed || !f.useNoRename {
+		for chunkNo, chunk := range chunks {
+			if chunk != temp[chunkNo] {
+				continue
+			}
+			chunk, err = f.baseMove(ctx, chunk, f.makeChunkName(set.remote, chunkNo, "", ""), delFailed)
+			if err != nil {
+				return true, errors.Wrap(err, "failed to rename chunk")
+			}
+			chunks[chunkNo] = chunk
+		}
+		xactID = ""
+	}
+	metadata, err := marshalSimpleJSON(ctx, journalInfo.size, len(chunks), journalInfo.md5, journalInfo.sha1, journalInfo.xxh3, journalInfo.blake3, xactID)
+	if err != nil {
+		return true, err
+	}
+	metaInfo := object.NewStaticObjectInfo(set.remote, chunks[0].ModTime(ctx), int64(len(metadata)), true, nil, f.base)
+	_, err = f.base.Put(ctx, bytes.NewReader(metadata), metaInfo)
+	if err != nil {
+		return true, errors.Wrap(err, "failed to write metadata")
+	}
+	c.report(set.remote, problem, "completed")
+	return true, set.journal.Remove(ctx)
+}
+
+// check checks a chunk set and repairs it if required
+func (c *fsck) check(ctx context.Context, set *chunkSet) error {
+	f := c.f
+	info, err := c.readInfo(ctx, set.main)
+	if err == ErrMetaUnknown {
+		c.report(set.remote, "unsupported metadata, please upgrade rclone", "")
+		return nil
+	} else if err != nil {
+		c.report(set.remote, fmt.Sprintf("invalid metadata: %v", err), "")
+		return nil
+	}
+
+	if set.journal != nil {
+		done, err := c.checkJournal(ctx, set, info)
+		if done || err != nil {
+			return err
+		}
+	}
+
+	// Temporary chunks left by interrupted uploads
+	xactID := ""
+	if info != nil {
+		xactID = info.xactID
+	}
+	for tempID, temp := range set.temp {
+		if tempID == xactID {
+			continue
+		}
+		c.report(set.remote, fmt.Sprintf("orphaned temporary chunks of transaction %q", tempID), c.remove(ctx, chunkObjects(temp)))
+	}
+
+	// Data chunks without metadata
+	if info == nil {
+		if f.useMeta && len(set.active) > 0 {
+			c.report(set.remote, "orphaned data chunks without metadata", c.remove(ctx, chunkObjects(set.active)))
+		}
+		return nil
+	}
+
+	// Chunks which don't match the metadata
+	chunks := set.active
+	if xactID != "" {
+		chunks = set.temp[xactID]
+		if len(set.active) > 0 {
+			c.report(set.remote, "orphaned data chunks", c.remove(ctx, chunkObjects(set.active)))
+		}
+	}
+	var size int64
+	var extra []fs.Object
+	for chunkNo, chunk := range chunks {
+		if chunkNo >= info.nChunks {
+			extra = append(extra, chunk)
+		} else {
+			size += chunk.Size()
+		}
+	}
+	if len(chunks)-len(extra) != info.nChunks || size != info.size {
+		c.report(set.remote, fmt.Sprintf("partial chunk set: found %d of %d chunks with %d of %d bytes", len(chunks)-len(extra), info.nChunks, size, info.size), "")
+		return nil
+	}
+	if len(extra) > 0 {
+		c.report(set.remote, "orphaned data chunks", c.remove(ctx, extra))
+	}
+	return nil
+}
+
+// run checks the composite files under each of dirs
+func (c *fsck) run(ctx context.Context, dirs []string) error {
+	for _, dir := range dirs {
+		sets, err := c.scan(ctx, dir)
+		if err != nil {
+			return err
+		}
+		var remotes []string
+		for remote := range sets {
+			remotes = append(remotes, remote)
+		}
+		sort.Strings(remotes)
+		for _, remote := range remotes {
+			err = c.check(ctx, sets[remote])
+			if err != nil {
+				return errors.Wrapf(err, "fsck of %q failed", remote)
+			}
+		}
+	}
+	return nil
+}
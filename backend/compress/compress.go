@@ -5,10 +5,8 @@ import (
 	"bufio"
 	"bytes"
 	"context"
-	"crypto/md5"
 	"encoding/base64"
 	"encoding/binary"
-	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -55,6 +53,9 @@ const (
 
 var nameRegexp = regexp.MustCompile("^(.+?)\\.([A-Za-z0-9-_]{11})$")
 
+// Hashes of the uncompressed data stored in the metadata
+var metaHashes = hash.NewHashSet(hash.MD5, hash.XXH3, hash.BLAKE3)
+
 // Register with Fs
 func init() {
 	// Build compression mode options.
@@ -483,7 +484,10 @@ func (f *Fs) putCompress(ctx context.Context, in io.Reader, src fs.ObjectInfo, o
 	in, wrap := accounting.UnWrap(in)
 
 	// Add the metadata hasher
-	metaHasher := md5.New()
+	metaHasher, err := hash.NewMultiHasherTypes(metaHashes)
+	if err != nil {
+		return nil, nil, err
+	}
 	in = io.TeeReader(in, metaHasher)
 
 	// Compress the file
@@ -518,7 +522,6 @@ func (f *Fs) putCompress(ctx context.Context, in io.Reader, src fs.ObjectInfo, o
 	// the compressed data.
 	ht := f.Fs.Hashes().GetOne()
 	var hasher *hash.MultiHasher
-	var err error
 	if ht != hash.None {
 		// unwrap the accounting again
 		wrappedIn, wrap = accounting.UnWrap(wrappedIn)
@@ -557,7 +560,7 @@ func (f *Fs) putCompress(ctx context.Context, in io.Reader, src fs.ObjectInfo, o
 	}
 
 	// Generate metadata
-	meta := newMetadata(result.meta.Size, f.mode, result.meta, hex.EncodeToString(metaHasher.Sum(nil)), mimeType)
+	meta := newMetadata(result.meta.Size, f.mode, result.meta, metaHasher.Sums(), mimeType)
 
 	// Check the hashes of the compressed data if we were comparing them
 	if ht != hash.None && hasher != nil {
@@ -575,7 +578,7 @@ func (f *Fs) putUncompress(ctx context.Context, in io.Reader, src fs.ObjectInfo,
 	// Unwrap the accounting, add our metadata hasher, then wrap it back on
 	in, wrap := accounting.UnWrap(in)
 
-	hs := hash.NewHashSet(hash.MD5)
+	hs := metaHashes
 	ht := f.Fs.Hashes().GetOne()
 	if !hs.Contains(ht) {
 		hs.Add(ht)
@@ -607,11 +610,7 @@ func (f *Fs) putUncompress(ctx context.Context, in io.Reader, src fs.ObjectInfo,
 	}
 
 	// Return our object and metadata
-	sum, err := metaHasher.Sum(hash.MD5)
-	if err != nil {
-		return nil, nil, err
-	}
-	return o, newMetadata(o.Size(), Uncompressed, sgzip.GzipMetadata{}, hex.EncodeToString(sum), mimeType), nil
+	return o, newMetadata(o.Size(), Uncompressed, sgzip.GzipMetadata{}, metaHasher.Sums(), mimeType), nil
 }
 
 // This function will write a metadata struct to a metadata Object for an src. Returns a wrappable metadata object.
@@ -740,7 +739,7 @@ func (f *Fs) PutStream(ctx context.Context, in io.Reader, src fs.ObjectInfo, opt
 
 // Hashes returns the supported hash sets.
 func (f *Fs) Hashes() hash.Set {
-	return hash.Set(hash.MD5)
+	return metaHashes
 }
 
 // Mkdir makes the directory (container, bucket)
@@ -1002,6 +1001,8 @@ type ObjectMetadata struct {
 	Mode                int    // Compression mode of the file.
 	Size                int64  // Size of the object.
 	MD5                 string // MD5 hash of the file.
+	XXH3                string `json:",omitempty"` // XXH3 hash of the file.
+	BLAKE3              string `json:",omitempty"` // BLAKE3 hash of the file.
 	MimeType            string // Mime type of the file
 	CompressionMetadata sgzip.GzipMetadata
 }
@@ -1017,12 +1018,14 @@ type Object struct {
 }
 
 // This function generates a metadata object
-func newMetadata(size int64, mode int, cmeta sgzip.GzipMetadata, md5 string, mimeType string) *ObjectMetadata {
+func newMetadata(size int64, mode int, cmeta sgzip.GzipMetadata, sums map[hash.Type]string, mimeType string) *ObjectMetadata {
 	meta := new(ObjectMetadata)
 	meta.Size = size
 	meta.Mode = mode
 	meta.CompressionMetadata = cmeta
-	meta.MD5 = md5
+	meta.MD5 = sums[hash.MD5]
+	meta.XXH3 = sums[hash.XXH3]
+	meta.BLAKE3 = sums[hash.BLAKE3]
 	meta.MimeType = mimeType
 	return meta
 }
@@ -1211,13 +1214,19 @@ func (o *Object) MimeType(ctx context.Context) string {
 // Hash returns the selected checksum of the file
 // If no checksum is available it returns ""
 func (o *Object) Hash(ctx context.Context, ht hash.Type) (string, error) {
-	if ht != hash.MD5 {
+	if !metaHashes.Contains(ht) {
 		return "", hash.ErrUnsupported
 	}
 	err := o.loadMetadataIfNotLoaded(ctx)
 	if err != nil {
 		return "", err
 	}
+	switch ht {
+	case hash.XXH3:
+		return o.meta.XXH3, nil
+	case hash.BLAKE3:
+		return o.meta.BLAKE3, nil
+	}
 	return o.meta.MD5, nil
 }
 
@@ -1244,6 +1253,24 @@ func (o *Object) GetTier() string {
 	return do.GetTier()
 }
 
+// Metadata returns the backend specific metadata of the underlying Object
+func (o *Object) Metadata(ctx context.Context) (map[string]string, error) {
+	do, ok := o.Object.(fs.Metadataer)
+	if !ok {
+		return nil, nil
+	}
+	return do.Metadata(ctx)
+}
+
+// Tags returns the user defined tags of the underlying Object
+func (o *Object) Tags(ctx context.Context) (map[string]string, error) {
+	do, ok := o.Object.(fs.Tagger)
+	if !ok {
+		return nil, nil
+	}
+	return do.Tags(ctx)
+}
+
 // UnWrap returns the wrapped Object
 func (o *Object) UnWrap() fs.Object {
 	return o.Object
@@ -1413,4 +1440,6 @@ var (
 	_ fs.ObjectUnWrapper = (*Object)(nil)
 	_ fs.IDer            = (*Object)(nil)
 	_ fs.MimeTyper       = (*Object)(nil)
+	_ fs.Metadataer      = (*Object)(nil)
+	_ fs.Tagger          = (*Object)(nil)
 )
@@ -27,6 +27,8 @@ func TestIntegration(t *testing.T) {
 			"PutStream",
 			"UserInfo",
 			"Disconnect",
+			"ListLinks",
+			"RevokeLink",
 		},
 		TiersToTest:                  []string{"STANDARD", "STANDARD_IA"},
 		UnimplementableObjectMethods: []string{}}
@@ -51,6 +53,8 @@ func TestRemoteGzip(t *testing.T) {
 			"PutStream",
 			"UserInfo",
 			"Disconnect",
+			"ListLinks",
+			"RevokeLink",
 		},
 		UnimplementableObjectMethods: []string{
 			"GetTier",
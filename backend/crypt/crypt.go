@@ -715,6 +715,42 @@ func (f *Fs) PublicLink(ctx context.Context, remote string, expire fs.Duration,
 	return do(ctx, o.(*Object).Object.Remote(), expire, unlink)
 }
 
+// ListLinks returns the public links to dir and everything under it
+func (f *Fs) ListLinks(ctx context.Context, dir string) ([]fs.Link, error) {
+	do := f.Fs.Features().ListLinks
+	if do == nil {
+		return nil, errors.New("ListLinks not supported")
+	}
+	links, err := do(ctx, f.cipher.EncryptDirName(dir))
+	if err != nil {
+		return nil, err
+	}
+	var decryptedLinks []fs.Link
+	for _, link := range links {
+		// the link could be to a file or a directory
+		remote, err := f.cipher.DecryptFileName(link.Remote)
+		if err != nil {
+			remote, err = f.cipher.DecryptDirName(link.Remote)
+		}
+		if err != nil {
+			fs.Debugf(link.Remote, "Skipping undecryptable link: %v", err)
+			continue
+		}
+		link.Remote = remote
+		decryptedLinks = append(decryptedLinks, link)
+	}
+	return decryptedLinks, nil
+}
+
+// RevokeLink removes the public link with the URL given
+func (f *Fs) RevokeLink(ctx context.Context, url string) error {
+	do := f.Fs.Features().RevokeLink
+	if do == nil {
+		return errors.New("RevokeLink not supported")
+	}
+	return do(ctx, url)
+}
+
 // ChangeNotify calls the passed function with a path
 // that has had changes. If the implementation
 // uses polling, it should adhere to the given interval.
@@ -1049,6 +1085,24 @@ func (o *Object) GetTier() string {
 	return do.GetTier()
 }
 
+// Metadata returns the backend specific metadata of the underlying Object
+func (o *Object) Metadata(ctx context.Context) (map[string]string, error) {
+	do, ok := o.Object.(fs.Metadataer)
+	if !ok {
+		return nil, nil
+	}
+	return do.Metadata(ctx)
+}
+
+// Tags returns the user defined tags of the underlying Object
+func (o *Object) Tags(ctx context.Context) (map[string]string, error) {
+	do, ok := o.Object.(fs.Tagger)
+	if !ok {
+		return nil, nil
+	}
+	return do.Tags(ctx)
+}
+
 // Check the interfaces are satisfied
 var (
 	_ fs.Fs              = (*Fs)(nil)
@@ -1070,6 +1124,7 @@ var (
 	_ fs.PublicLinker    = (*Fs)(nil)
 	_ fs.UserInfoer      = (*Fs)(nil)
 	_ fs.Disconnecter    = (*Fs)(nil)
+	_ fs.LinkManager     = (*Fs)(nil)
 	_ fs.Shutdowner      = (*Fs)(nil)
 	_ fs.ObjectInfo      = (*ObjectInfo)(nil)
 	_ fs.Object          = (*Object)(nil)
@@ -1077,4 +1132,6 @@ var (
 	_ fs.IDer            = (*Object)(nil)
 	_ fs.SetTierer       = (*Object)(nil)
 	_ fs.GetTierer       = (*Object)(nil)
+	_ fs.Metadataer      = (*Object)(nil)
+	_ fs.Tagger          = (*Object)(nil)
 )
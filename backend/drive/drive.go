@@ -532,6 +532,30 @@ Google don't document so it may break in the future.
 Normally rclone dereferences shortcut files making them appear as if
 they are the original file (see [the shortcuts section](#shortcuts)).
 If this flag is set then rclone will ignore shortcut files completely.
+`,
+			Advanced: true,
+			Default:  false,
+		}, {
+			Name: "skip_dangling_shortcuts",
+			Help: `If set skip dangling shortcut files
+
+Shortcuts whose target has been deleted, or can't be read, are
+normally shown as empty files so they can be found and deleted, but
+they give errors if they are downloaded. If this flag is set then
+rclone will ignore them instead.
+
+See the "fix-shortcuts" backend command for a way of finding and
+removing them.
+`,
+			Advanced: true,
+			Default:  false,
+		}, {
+			Name: "copy_shortcut_content",
+			Help: `If set server-side copies of shortcuts copy the target
+
+Normally when a shortcut is copied server-side a new shortcut pointing
+to the same target is made. If this flag is set then the target of the
+shortcut is copied instead, so the copy is independent of the original.
 `,
 			Advanced: true,
 			Default:  false,
@@ -594,6 +618,8 @@ type Options struct {
 	StopOnUploadLimit         bool                 `config:"stop_on_upload_limit"`
 	StopOnDownloadLimit       bool                 `config:"stop_on_download_limit"`
 	SkipShortcuts             bool                 `config:"skip_shortcuts"`
+	SkipDanglingShortcuts     bool                 `config:"skip_dangling_shortcuts"`
+	CopyShortcutContent       bool                 `config:"copy_shortcut_content"`
 	Enc                       encoder.MultiEncoder `config:"encoding"`
 }
 
@@ -1351,6 +1377,9 @@ func (f *Fs) newObjectWithExportInfo(
 		// and not from a listing. This is unlikely.
 		fs.Debugf(remote, "Ignoring shortcut as skip shortcuts is set")
 		return nil, fs.ErrorObjectNotFound
+	case info.MimeType == shortcutMimeTypeDangling && f.opt.SkipDanglingShortcuts:
+		fs.Debugf(remote, "Ignoring dangling shortcut as skip dangling shortcuts is set")
+		return nil, fs.ErrorObjectNotFound
 	case info.MimeType == shortcutMimeTypeDangling:
 		// Pretend a dangling shortcut is a regular object
 		// It will error if used, but appear in listings so it can be deleted
@@ -2341,8 +2370,12 @@ func (f *Fs) Copy(ctx context.Context, src fs.Object, remote string) (fs.Object,
 		createInfo.Description = ""
 	}
 
-	// get the ID of the thing to copy - this is the shortcut if available
+	// get the ID of the thing to copy - this is the shortcut if
+	// available unless we are copying the contents of shortcuts
 	id := shortcutID(srcObj.id)
+	if f.opt.CopyShortcutContent {
+		id = actualID(srcObj.id)
+	}
 
 	var info *drive.File
 	err = f.pacer.Call(func() (bool, error) {
@@ -3074,6 +3107,81 @@ func (f *Fs) copyID(ctx context.Context, id, dest string) (err error) {
 	return nil
 }
 
+// danglingShortcut describes a shortcut whose target can't be found
+type danglingShortcut struct {
+	Path     string
+	ID       string
+	TargetID string
+	Deleted  bool
+}
+
+type fixShortcutsResult struct {
+	Dangling []danglingShortcut
+	Deleted  int
+	Errors   int
+}
+
+func (r fixShortcutsResult) Error() string {
+	return fmt.Sprintf("%d errors while fixing shortcuts - see log", r.Errors)
+}
+
+// Find the dangling shortcuts in dir, directoryID recursing into
+// subdirectories and deleting them if del is set
+func (f *Fs) fixShortcuts(ctx context.Context, dir string, directoryID string, del bool, r *fixShortcutsResult) {
+	directoryID = actualID(directoryID)
+	fs.Debugf(dir, "finding dangling shortcuts in directory %q", directoryID)
+	_, err := f.list(ctx, []string{directoryID}, "", false, false, f.opt.TrashedOnly, false, func(item *drive.File) bool {
+		remote := path.Join(dir, item.Name)
+		switch {
+		case item.MimeType == shortcutMimeTypeDangling:
+			fs.Infof(remote, "dangling shortcut to %q", item.ShortcutDetails.TargetId)
+			shortcut := danglingShortcut{
+				Path:     remote,
+				ID:       item.Id,
+				TargetID: item.ShortcutDetails.TargetId,
+			}
+			if del && !operations.SkipDestructive(ctx, remote, "delete dangling shortcut") {
+				err := f.delete(ctx, item.Id, f.opt.UseTrash)
+				if err != nil {
+					err = errors.Wrap(err, "failed to delete dangling shortcut")
+					r.Errors++
+					fs.Errorf(remote, "%v", err)
+				} else {
+					shortcut.Deleted = true
+					r.Deleted++
+				}
+			}
+			r.Dangling = append(r.Dangling, shortcut)
+		case item.MimeType == driveFolderType && !isShortcutID(item.Id):
+			f.fixShortcuts(ctx, remote, item.Id, del, r)
+		}
+		return false
+	})
+	if err != nil {
+		err = errors.Wrap(err, "failed to list directory")
+		r.Errors++
+		fs.Errorf(dir, "%v", err)
+	}
+}
+
+// Find and optionally delete the dangling shortcuts in dir
+func (f *Fs) fixShortcutsDir(ctx context.Context, dir string, del bool) (r fixShortcutsResult, err error) {
+	if f.opt.SkipShortcuts {
+		return r, errors.New("can't find dangling shortcuts with skip_shortcuts set")
+	}
+	directoryID, err := f.dirCache.FindDir(ctx, dir, false)
+	if err != nil {
+		r.Errors++
+		return r, err
+	}
+	r.Dangling = []danglingShortcut{}
+	f.fixShortcuts(ctx, dir, directoryID, del, &r)
+	if r.Errors != 0 {
+		return r, r
+	}
+	return r, nil
+}
+
 var commandHelp = []fs.CommandHelp{{
 	Name:  "get",
 	Short: "Get command for fetching the drive config parameters",
@@ -3197,6 +3305,40 @@ attempted if possible.
 
 Use the -i flag to see what would be copied before copying.
 `,
+}, {
+	Name:  "fix-shortcuts",
+	Short: "Find and delete dangling shortcuts",
+	Long: `This command finds the shortcuts in the directory passed in and the
+directories under it whose target has been deleted or can't be read.
+
+Usage:
+
+    rclone backend fix-shortcuts drive:directory
+    rclone backend fix-shortcuts drive:directory -o delete
+    rclone backend -i fix-shortcuts drive:directory -o delete
+
+The first example reports the dangling shortcuts. The second deletes
+them too, using the trash if --drive-use-trash is set. Use the -i flag
+to see what would be deleted before deleting it.
+
+Result:
+
+    {
+        "Dangling": [
+            {
+                "Path": "dir/shortcut.txt",
+                "ID": "1ABCDEF-01234567890",
+                "TargetID": "1ABCDEFabcdefghijkl",
+                "Deleted": true
+            }
+        ],
+        "Deleted": 1,
+        "Errors": 0
+    }
+`,
+	Opts: map[string]string{
+		"delete": "delete the dangling shortcuts found",
+	},
 }}
 
 // Command the backend to run a named command
@@ -3281,6 +3423,13 @@ func (f *Fs) Command(ctx context.Context, name string, arg []string, opt map[str
 			}
 		}
 		return nil, nil
+	case "fix-shortcuts":
+		dir := ""
+		if len(arg) > 0 {
+			dir = arg[0]
+		}
+		_, del := opt["delete"]
+		return f.fixShortcutsDir(ctx, dir, del)
 	default:
 		return nil, fs.ErrorCommandNotFound
 	}
@@ -3742,6 +3891,25 @@ func (o *baseObject) ParentID() string {
 	return ""
 }
 
+// Metadata returns the properties of the Object, with the private
+// app properties prefixed with "app:"
+//
+// These aren't read when listing so this needs an API call.
+func (o *baseObject) Metadata(ctx context.Context) (map[string]string, error) {
+	info, err := o.fs.getFile(ctx, actualID(o.id), "properties,appProperties")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read properties")
+	}
+	metadata := make(map[string]string, len(info.Properties)+len(info.AppProperties))
+	for key, value := range info.Properties {
+		metadata[key] = value
+	}
+	for key, value := range info.AppProperties {
+		metadata["app:"+key] = value
+	}
+	return metadata, nil
+}
+
 func (o *documentObject) ext() string {
 	return o.baseObject.remote[len(o.baseObject.remote)-o.extLen:]
 }
@@ -3803,12 +3971,15 @@ var (
 	_ fs.MimeTyper       = (*Object)(nil)
 	_ fs.IDer            = (*Object)(nil)
 	_ fs.ParentIDer      = (*Object)(nil)
+	_ fs.Metadataer      = (*Object)(nil)
 	_ fs.Object          = (*documentObject)(nil)
 	_ fs.MimeTyper       = (*documentObject)(nil)
 	_ fs.IDer            = (*documentObject)(nil)
 	_ fs.ParentIDer      = (*documentObject)(nil)
+	_ fs.Metadataer      = (*documentObject)(nil)
 	_ fs.Object          = (*linkObject)(nil)
 	_ fs.MimeTyper       = (*linkObject)(nil)
 	_ fs.IDer            = (*linkObject)(nil)
 	_ fs.ParentIDer      = (*linkObject)(nil)
+	_ fs.Metadataer      = (*linkObject)(nil)
 )
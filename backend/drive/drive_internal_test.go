@@ -366,6 +366,45 @@ func (f *Fs) InternalTestShortcuts(t *testing.T) {
 		assert.Equal(t, srcHash, dstHash)
 		require.NoError(t, dstObj.Remove(ctx))
 	})
+	t.Run("Dangling", func(t *testing.T) {
+		// Make a shortcut then delete its target permanently
+		contents := random.String(100)
+		item := fstest.NewItem("danglingDir/target.txt", contents, time.Now())
+		_, obj := fstests.PutTestContents(ctx, t, f, &item, contents, false)
+		_, err := f.makeShortcut(ctx, "danglingDir/target.txt", f, "danglingDir/sub/shortcut.txt")
+		require.NoError(t, err)
+		require.NoError(t, f.delete(ctx, obj.(*Object).id, false))
+
+		// Find the shortcut without deleting it
+		outI, err := f.Command(ctx, "fix-shortcuts", []string{"danglingDir"}, nil)
+		require.NoError(t, err)
+		out := outI.(fixShortcutsResult)
+		require.Equal(t, 1, len(out.Dangling))
+		assert.Equal(t, "danglingDir/sub/shortcut.txt", out.Dangling[0].Path)
+		assert.False(t, out.Dangling[0].Deleted)
+		assert.Equal(t, 0, out.Deleted)
+
+		// Check it can be skipped
+		f.opt.SkipDanglingShortcuts = true
+		_, err = f.NewObject(ctx, "danglingDir/sub/shortcut.txt")
+		f.opt.SkipDanglingShortcuts = false
+		assert.Equal(t, fs.ErrorObjectNotFound, err)
+
+		// Now delete it
+		outI, err = f.Command(ctx, "fix-shortcuts", []string{"danglingDir"}, map[string]string{
+			"delete": "",
+		})
+		require.NoError(t, err)
+		out = outI.(fixShortcutsResult)
+		require.Equal(t, 1, len(out.Dangling))
+		assert.True(t, out.Dangling[0].Deleted)
+		assert.Equal(t, 1, out.Deleted)
+
+		fstest.CheckListingWithRoot(t, f, "danglingDir", []fstest.Item{}, []string{
+			"danglingDir/sub",
+		}, f.Precision())
+		require.NoError(t, f.Purge(ctx, "danglingDir"))
+	})
 }
 
 // TestIntegration/FsMkdir/FsPutFiles/Internal/UnTrash
@@ -1210,6 +1210,71 @@ func (f *Fs) PublicLink(ctx context.Context, remote string, expire fs.Duration,
 	return
 }
 
+// ListLinks returns the public links to dir and everything under it
+//
+// Dropbox only returns the paths of the links in lower case
+func (f *Fs) ListLinks(ctx context.Context, dir string) (links []fs.Link, err error) {
+	rootLower := strings.ToLower(f.opt.Enc.FromStandardPath(f.slashRootSlash))
+	dirLower := strings.ToLower(f.opt.Enc.FromStandardPath(path.Join(f.slashRoot, dir)))
+	dirLowerSlash := strings.TrimSuffix(dirLower, "/") + "/"
+	listArg := sharing.ListSharedLinksArg{}
+	for {
+		var listRes *sharing.ListSharedLinksResult
+		err = f.pacer.Call(func() (bool, error) {
+			listRes, err = f.sharing.ListSharedLinks(&listArg)
+			return shouldRetry(ctx, err)
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to list links")
+		}
+		for _, linkRes := range listRes.Links {
+			var metadata *sharing.SharedLinkMetadata
+			switch res := linkRes.(type) {
+			case *sharing.FileLinkMetadata:
+				metadata = &res.SharedLinkMetadata
+			case *sharing.FolderLinkMetadata:
+				metadata = &res.SharedLinkMetadata
+			default:
+				continue
+			}
+			pathLower := metadata.PathLower
+			if pathLower != dirLower && !strings.HasPrefix(pathLower, dirLowerSlash) {
+				// not under dir or not visible to us
+				continue
+			}
+			remote := strings.TrimPrefix(pathLower, rootLower)
+			if pathLower+"/" == rootLower {
+				remote = ""
+			}
+			links = append(links, fs.Link{
+				Remote:  f.opt.Enc.ToStandardPath(remote),
+				URL:     metadata.Url,
+				Expires: metadata.Expires,
+			})
+		}
+		if !listRes.HasMore {
+			break
+		}
+		listArg.Cursor = listRes.Cursor
+	}
+	return links, nil
+}
+
+// RevokeLink removes the public link with the URL given
+func (f *Fs) RevokeLink(ctx context.Context, url string) (err error) {
+	revokeArg := sharing.RevokeSharedLinkArg{
+		Url: url,
+	}
+	err = f.pacer.Call(func() (bool, error) {
+		err = f.sharing.RevokeSharedLink(&revokeArg)
+		return shouldRetry(ctx, err)
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to revoke link")
+	}
+	return nil
+}
+
 // DirMove moves src, srcRemote to this remote at dstRemote
 // using server-side move operations.
 //
@@ -1795,6 +1860,7 @@ var (
 	_ fs.PutStreamer  = (*Fs)(nil)
 	_ fs.Mover        = (*Fs)(nil)
 	_ fs.PublicLinker = (*Fs)(nil)
+	_ fs.LinkManager  = (*Fs)(nil)
 	_ fs.DirMover     = (*Fs)(nil)
 	_ fs.Abouter      = (*Fs)(nil)
 	_ fs.Shutdowner   = &Fs{}
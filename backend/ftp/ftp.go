@@ -165,6 +165,8 @@ type Fs struct {
 	tokens   *pacer.TokenDispenser
 	tlsConf  *tls.Config
 	pacer    *fs.Pacer // pacer for FTP connections
+	fSetTime bool      // true if server supports setting the modification time with MFMT
+	fLstTime bool      // true if MLSD is used so listings have precise times
 }
 
 // Object describes an FTP file
@@ -455,6 +457,8 @@ func NewFs(ctx context.Context, name, root string, m configmap.Mapper) (ff fs.Fs
 	if err != nil {
 		return nil, errors.Wrap(err, "NewFs")
 	}
+	f.fSetTime = c.IsSetTimeSupported()
+	f.fLstTime = c.IsTimePreciseInList()
 	f.putFtpConnection(&c, nil)
 	if root != "" {
 		// Check to see if the root actually an existing file
@@ -687,8 +691,14 @@ func (f *Fs) Hashes() hash.Set {
 	return 0
 }
 
-// Precision shows Modified Time not supported
+// Precision returns the precision of the modification times
+//
+// These are only supported if the server lists precise times with
+// MLSD and can set them with MFMT.
 func (f *Fs) Precision() time.Duration {
+	if f.fLstTime && f.fSetTime {
+		return time.Second
+	}
 	return fs.ModTimeNotSupported
 }
 
@@ -930,6 +940,21 @@ func (o *Object) ModTime(ctx context.Context) time.Time {
 
 // SetModTime sets the modification time of the object
 func (o *Object) SetModTime(ctx context.Context, modTime time.Time) error {
+	if !o.fs.fSetTime {
+		return nil
+	}
+	c, err := o.fs.getFtpConnection(ctx)
+	if err != nil {
+		return errors.Wrap(err, "SetModTime")
+	}
+	modTime = modTime.In(time.UTC)
+	path := path.Join(o.fs.root, o.remote)
+	err = c.SetTime(o.fs.opt.Enc.FromStandardPath(path), modTime)
+	o.fs.putFtpConnection(&c, err)
+	if err != nil {
+		return errors.Wrap(err, "SetModTime")
+	}
+	o.info.ModTime = modTime
 	return nil
 }
 
@@ -1056,6 +1081,13 @@ func (o *Object) Update(ctx context.Context, in io.Reader, src fs.ObjectInfo, op
 		o.fs.putFtpConnection(nil, err)
 		return errors.Wrap(err, "update stor")
 	}
+	if o.fs.fSetTime {
+		err = c.SetTime(o.fs.opt.Enc.FromStandardPath(path), src.ModTime(ctx).In(time.UTC))
+		if err != nil {
+			o.fs.putFtpConnection(&c, err)
+			return errors.Wrap(err, "update set modtime")
+		}
+	}
 	o.fs.putFtpConnection(&c, nil)
 	o.info, err = o.fs.getInfo(ctx, path)
 	if err != nil {
@@ -0,0 +1,161 @@
+package ftp
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pingme998/rclone/fs"
+	"github.com/pingme998/rclone/fs/config/configmap"
+	"github.com/pingme998/rclone/fs/config/obscure"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeServer is an FTP server which only speaks enough of the
+// control connection to log in and set modification times
+type fakeServer struct {
+	features []string // features returned by FEAT
+	listener net.Listener
+
+	mu   sync.Mutex
+	mfmt []string // arguments of the MFMT commands received
+}
+
+// newFakeServer starts a fakeServer advertising features
+func newFakeServer(t *testing.T, features ...string) *fakeServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	s := &fakeServer{
+		features: features,
+		listener: listener,
+	}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+// serve a single control connection
+func (s *fakeServer) serve(conn net.Conn) {
+	defer func() { _ = conn.Close() }()
+	r := bufio.NewReader(conn)
+	reply := func(msg string) {
+		_, _ = conn.Write([]byte(msg + "\r\n"))
+	}
+	reply("220 fake server ready")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		args := strings.SplitN(strings.TrimSpace(line), " ", 2)
+		switch strings.ToUpper(args[0]) {
+		case "USER":
+			reply("331 password please")
+		case "PASS":
+			reply("230 logged in")
+		case "FEAT":
+			feat := "211-Features:\r\n"
+			for _, feature := range s.features {
+				feat += " " + feature + "\r\n"
+			}
+			reply(feat + "211 End")
+		case "TYPE", "OPTS":
+			reply("200 ok")
+		case "MFMT":
+			s.mu.Lock()
+			s.mfmt = append(s.mfmt, args[1])
+			s.mu.Unlock()
+			reply("213 " + args[1])
+		case "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("502 not implemented")
+		}
+	}
+}
+
+// received returns the arguments of the MFMT commands received
+func (s *fakeServer) received() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.mfmt...)
+}
+
+// newFs makes an Fs talking to the server with the extra config in m
+func (s *fakeServer) newFs(t *testing.T, m configmap.Simple) *Fs {
+	host, port, err := net.SplitHostPort(s.listener.Addr().String())
+	require.NoError(t, err)
+	m["host"] = host
+	m["port"] = port
+	m["user"] = "rclone"
+	m["pass"] = obscure.MustObscure("potato")
+	f, err := NewFs(context.Background(), "TestFTP", "", m)
+	require.NoError(t, err)
+	return f.(*Fs)
+}
+
+// close the server and the connections of f
+func (s *fakeServer) close(t *testing.T, f *Fs) {
+	require.NoError(t, f.Shutdown(context.Background()))
+	require.NoError(t, s.listener.Close())
+}
+
+func TestPrecision(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		features []string
+		config   configmap.Simple
+		want     time.Duration
+	}{
+		{"MLSD and MFMT", []string{"MLST type*;size*;modify*;", "MFMT"}, configmap.Simple{}, time.Second},
+		{"MFMT only", []string{"MFMT"}, configmap.Simple{}, fs.ModTimeNotSupported},
+		{"MLSD only", []string{"MLST type*;size*;modify*;"}, configmap.Simple{}, fs.ModTimeNotSupported},
+		{"MLSD disabled", []string{"MLST type*;size*;modify*;", "MFMT"}, configmap.Simple{"disable_mlsd": "true"}, fs.ModTimeNotSupported},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			s := newFakeServer(t, test.features...)
+			f := s.newFs(t, test.config)
+			defer s.close(t, f)
+			assert.Equal(t, test.want, f.Precision())
+		})
+	}
+}
+
+func TestSetModTime(t *testing.T) {
+	ctx := context.Background()
+	modTime := time.Date(2021, 2, 3, 4, 5, 6, 0, time.FixedZone("CET", 3600))
+	oldTime := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("Supported", func(t *testing.T) {
+		s := newFakeServer(t, "MFMT")
+		f := s.newFs(t, configmap.Simple{})
+		defer s.close(t, f)
+		o := &Object{fs: f, remote: "dir/file.txt", info: &FileInfo{ModTime: oldTime}}
+		require.NoError(t, o.SetModTime(ctx, modTime))
+		assert.Equal(t, []string{"20210203030506 dir/file.txt"}, s.received())
+		assert.True(t, modTime.Equal(o.ModTime(ctx)))
+	})
+
+	t.Run("Unsupported", func(t *testing.T) {
+		s := newFakeServer(t)
+		f := s.newFs(t, configmap.Simple{})
+		defer s.close(t, f)
+		o := &Object{fs: f, remote: "dir/file.txt", info: &FileInfo{ModTime: oldTime}}
+		require.NoError(t, o.SetModTime(ctx, modTime))
+		assert.Empty(t, s.received())
+		assert.Equal(t, oldTime, o.ModTime(ctx))
+	})
+}
@@ -14,6 +14,7 @@ FIXME Patch/Delete/Get isn't working with files with spaces in - giving 404 erro
 
 import (
 	"context"
+	"crypto/sha256"
 	"encoding/base64"
 	"encoding/hex"
 	"fmt"
@@ -21,6 +22,7 @@ import (
 	"io/ioutil"
 	"net/http"
 	"path"
+	"reflect"
 	"strings"
 	"time"
 
@@ -246,6 +248,34 @@ Docs: https://cloud.google.com/storage/docs/bucket-policy-only
 				Value: "DURABLE_REDUCED_AVAILABILITY",
 				Help:  "Durable reduced availability storage class",
 			}},
+		}, {
+			Name: "encryption_key",
+			Help: `Customer-supplied encryption key (CSEK) to encrypt and decrypt objects with.
+
+This should be an AES-256 key, base64 encoded. The SHA256 hash of the
+key which Google Cloud Storage needs is calculated from it.
+
+If this is set then all objects uploaded are encrypted with it and it
+must be set to read them again. It can't be used with kms_key_name.
+
+Docs: https://cloud.google.com/storage/docs/encryption/customer-supplied-keys
+`,
+			Advanced: true,
+		}, {
+			Name: "kms_key_name",
+			Help: `Cloud KMS key (CMEK) to encrypt objects uploaded with.
+
+This is the resource name of the key, eg
+
+    projects/PROJECT/locations/LOCATION/keyRings/RING/cryptoKeys/KEY
+
+If this isn't set then the default KMS key of the bucket (if any) is
+used. Objects encrypted with KMS keys are decrypted automatically so
+this isn't needed for reading. It can't be used with encryption_key.
+
+Docs: https://cloud.google.com/storage/docs/encryption/customer-managed-keys
+`,
+			Advanced: true,
 		}, {
 			Name:     config.ConfigEncoding,
 			Help:     config.ConfigEncodingHelp,
@@ -268,6 +298,8 @@ type Options struct {
 	BucketPolicyOnly          bool                 `config:"bucket_policy_only"`
 	Location                  string               `config:"location"`
 	StorageClass              string               `config:"storage_class"`
+	EncryptionKey             string               `config:"encryption_key"`
+	KMSKeyName                string               `config:"kms_key_name"`
 	Enc                       encoder.MultiEncoder `config:"encoding"`
 }
 
@@ -283,6 +315,7 @@ type Fs struct {
 	rootDirectory string           // directory part of root (if any)
 	cache         *bucket.Cache    // cache of bucket status
 	pacer         *fs.Pacer        // To pace the API calls
+	keySHA256     string           // base64 SHA256 of opt.EncryptionKey if set
 }
 
 // Object describes a storage object
@@ -402,6 +435,10 @@ func NewFs(ctx context.Context, name, root string, m configmap.Mapper) (fs.Fs, e
 	if opt.BucketACL == "" {
 		opt.BucketACL = "private"
 	}
+	keySHA256, err := checkEncryptionKey(opt)
+	if err != nil {
+		return nil, err
+	}
 
 	// try loading service account credentials from env variable, then from a file
 	if opt.ServiceAccountCredentials == "" && opt.ServiceAccountFile != "" {
@@ -430,11 +467,12 @@ func NewFs(ctx context.Context, name, root string, m configmap.Mapper) (fs.Fs, e
 	}
 
 	f := &Fs{
-		name:  name,
-		root:  root,
-		opt:   *opt,
-		pacer: fs.NewPacer(ctx, pacer.NewGoogleDrive(pacer.MinSleep(minSleep))),
-		cache: bucket.NewCache(),
+		name:      name,
+		root:      root,
+		opt:       *opt,
+		pacer:     fs.NewPacer(ctx, pacer.NewGoogleDrive(pacer.MinSleep(minSleep))),
+		cache:     bucket.NewCache(),
+		keySHA256: keySHA256,
 	}
 	f.setRoot(root)
 	f.features = (&fs.Features{
@@ -455,7 +493,9 @@ func NewFs(ctx context.Context, name, root string, m configmap.Mapper) (fs.Fs, e
 		// Check to see if the object exists
 		encodedDirectory := f.opt.Enc.FromStandardPath(f.rootDirectory)
 		err = f.pacer.Call(func() (bool, error) {
-			_, err = f.svc.Objects.Get(f.rootBucket, encodedDirectory).Context(ctx).Do()
+			getObject := f.svc.Objects.Get(f.rootBucket, encodedDirectory)
+			f.setEncryptionHeaders(getObject.Header())
+			_, err = getObject.Context(ctx).Do()
 			return shouldRetry(ctx, err)
 		})
 		if err == nil {
@@ -471,6 +511,48 @@ func NewFs(ctx context.Context, name, root string, m configmap.Mapper) (fs.Fs, e
 	return f, nil
 }
 
+// checkEncryptionKey checks the encryption options returning the
+// base64 encoded SHA256 of the encryption key if set
+func checkEncryptionKey(opt *Options) (keySHA256 string, err error) {
+	if opt.EncryptionKey == "" {
+		return "", nil
+	}
+	if opt.KMSKeyName != "" {
+		return "", errors.New("can't use encryption_key and kms_key_name together")
+	}
+	key, err := base64.StdEncoding.DecodeString(opt.EncryptionKey)
+	if err != nil {
+		return "", errors.Wrap(err, "encryption_key must be base64 encoded")
+	}
+	if len(key) != 32 {
+		return "", errors.Errorf("encryption_key must be an AES-256 key of 32 bytes but is %d bytes", len(key))
+	}
+	sum := sha256.Sum256(key)
+	return base64.StdEncoding.EncodeToString(sum[:]), nil
+}
+
+// setEncryptionHeaders adds the headers for the customer-supplied
+// encryption key to h if set
+func (f *Fs) setEncryptionHeaders(h http.Header) {
+	if f.opt.EncryptionKey == "" {
+		return
+	}
+	h.Set("x-goog-encryption-algorithm", "AES256")
+	h.Set("x-goog-encryption-key", f.opt.EncryptionKey)
+	h.Set("x-goog-encryption-key-sha256", f.keySHA256)
+}
+
+// setCopySourceEncryptionHeaders adds the headers for the
+// customer-supplied encryption key of the source of a copy to h if set
+func (f *Fs) setCopySourceEncryptionHeaders(h http.Header) {
+	if f.opt.EncryptionKey == "" {
+		return
+	}
+	h.Set("x-goog-copy-source-encryption-algorithm", "AES256")
+	h.Set("x-goog-copy-source-encryption-key", f.opt.EncryptionKey)
+	h.Set("x-goog-copy-source-encryption-key-sha256", f.keySHA256)
+}
+
 // Return an Object from a path
 //
 // If it can't be found it returns the error fs.ErrorObjectNotFound.
@@ -845,6 +927,11 @@ func (f *Fs) Copy(ctx context.Context, src fs.Object, remote string) (fs.Object,
 	if !f.opt.BucketPolicyOnly {
 		rewriteRequest.DestinationPredefinedAcl(f.opt.ObjectACL)
 	}
+	if f.opt.KMSKeyName != "" {
+		rewriteRequest.DestinationKmsKeyName(f.opt.KMSKeyName)
+	}
+	srcObj.fs.setCopySourceEncryptionHeaders(rewriteRequest.Header())
+	f.setEncryptionHeaders(rewriteRequest.Header())
 	var rewriteResponse *storage.RewriteResponse
 	for {
 		err = f.pacer.Call(func() (bool, error) {
@@ -860,8 +947,22 @@ func (f *Fs) Copy(ctx context.Context, src fs.Object, remote string) (fs.Object,
 		rewriteRequest.RewriteToken(rewriteResponse.RewriteToken)
 		fs.Debugf(dstObj, "Continuing rewrite %d bytes done", rewriteResponse.TotalBytesRewritten)
 	}
+	newObject := rewriteResponse.Resource
+	// The rewrite copies the custom metadata so add any tags to it
+	if tags := fs.GetConfig(ctx).Tags; len(tags) != 0 {
+		patch := storage.Object{
+			Metadata: tagsMetadata(tags),
+		}
+		err = f.pacer.Call(func() (bool, error) {
+			newObject, err = f.svc.Objects.Patch(dstBucket, dstPath, &patch).Context(ctx).Do()
+			return shouldRetry(ctx, err)
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to set tags")
+		}
+	}
 	// Set the metadata for the new object while we have it
-	dstObj.setMetaData(rewriteResponse.Resource)
+	dstObj.setMetaData(newObject)
 	return dstObj, nil
 }
 
@@ -870,6 +971,104 @@ func (f *Fs) Hashes() hash.Set {
 	return hash.Set(hash.MD5)
 }
 
+// getLifecycle reads the lifecycle config of the bucket
+func (f *Fs) getLifecycle(ctx context.Context) (lifecycle *storage.BucketLifecycle, err error) {
+	if f.rootBucket == "" {
+		return nil, fs.ErrorListBucketRequired
+	}
+	var bucket *storage.Bucket
+	err = f.pacer.Call(func() (bool, error) {
+		bucket, err = f.svc.Buckets.Get(f.rootBucket).Fields("lifecycle").Context(ctx).Do()
+		return shouldRetry(ctx, err)
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read lifecycle rules")
+	}
+	if bucket.Lifecycle == nil {
+		return &storage.BucketLifecycle{}, nil
+	}
+	return bucket.Lifecycle, nil
+}
+
+// GetLifecycle reads the Delete rules of the bucket
+//
+// Only rules with just an age or a days since noncurrent time
+// condition are returned
+func (f *Fs) GetLifecycle(ctx context.Context) (rules []fs.LifecycleRule, err error) {
+	lifecycle, err := f.getLifecycle(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rules = []fs.LifecycleRule{}
+	for _, gcsRule := range lifecycle.Rule {
+		if gcsRule.Action == nil || gcsRule.Action.Type != "Delete" || gcsRule.Condition == nil {
+			continue
+		}
+		// skip rules with conditions we don't understand
+		condition := *gcsRule.Condition
+		age, noncurrentDays, isLive := condition.Age, condition.DaysSinceNoncurrentTime, condition.IsLive
+		condition.Age, condition.DaysSinceNoncurrentTime, condition.IsLive = 0, 0, nil
+		if !reflect.DeepEqual(condition, storage.BucketLifecycleRuleCondition{}) {
+			continue
+		}
+		switch {
+		case age > 0 && noncurrentDays == 0 && (isLive == nil || *isLive):
+			rules = append(rules, fs.LifecycleRule{ExpireDays: int(age)})
+		case age == 0 && noncurrentDays > 0:
+			rules = append(rules, fs.LifecycleRule{NoncurrentExpireDays: int(noncurrentDays)})
+		}
+	}
+	return rules, nil
+}
+
+// SetLifecycle replaces the Delete rules of the bucket
+//
+// Other rules, eg SetStorageClass, are kept. Rules can't have an ID
+// or a prefix.
+func (f *Fs) SetLifecycle(ctx context.Context, rules []fs.LifecycleRule) (err error) {
+	lifecycle, err := f.getLifecycle(ctx)
+	if err != nil {
+		return err
+	}
+	newLifecycle := storage.BucketLifecycle{
+		Rule:            []*storage.BucketLifecycleRule{},
+		ForceSendFields: []string{"Rule"},
+	}
+	for _, gcsRule := range lifecycle.Rule {
+		if gcsRule.Action != nil && gcsRule.Action.Type != "Delete" {
+			newLifecycle.Rule = append(newLifecycle.Rule, gcsRule)
+		}
+	}
+	for _, rule := range rules {
+		if rule.ID != "" || rule.Prefix != "" {
+			return errors.New("lifecycle rules can't have an id or prefix on this backend")
+		}
+		if rule.ExpireDays > 0 {
+			newLifecycle.Rule = append(newLifecycle.Rule, &storage.BucketLifecycleRule{
+				Action:    &storage.BucketLifecycleRuleAction{Type: "Delete"},
+				Condition: &storage.BucketLifecycleRuleCondition{Age: int64(rule.ExpireDays)},
+			})
+		}
+		if rule.NoncurrentExpireDays > 0 {
+			newLifecycle.Rule = append(newLifecycle.Rule, &storage.BucketLifecycleRule{
+				Action:    &storage.BucketLifecycleRuleAction{Type: "Delete"},
+				Condition: &storage.BucketLifecycleRuleCondition{DaysSinceNoncurrentTime: int64(rule.NoncurrentExpireDays)},
+			})
+		}
+	}
+	bucket := storage.Bucket{
+		Lifecycle: &newLifecycle,
+	}
+	err = f.pacer.Call(func() (bool, error) {
+		_, err = f.svc.Buckets.Patch(f.rootBucket, &bucket).Fields("lifecycle").Context(ctx).Do()
+		return shouldRetry(ctx, err)
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to set lifecycle rules")
+	}
+	return nil
+}
+
 // ------------------------------------------------------------
 
 // Fs returns the parent Fs
@@ -941,7 +1140,9 @@ func (o *Object) setMetaData(info *storage.Object) {
 func (o *Object) readObjectInfo(ctx context.Context) (object *storage.Object, err error) {
 	bucket, bucketPath := o.split()
 	err = o.fs.pacer.Call(func() (bool, error) {
-		object, err = o.fs.svc.Objects.Get(bucket, bucketPath).Context(ctx).Do()
+		getObject := o.fs.svc.Objects.Get(bucket, bucketPath)
+		o.fs.setEncryptionHeaders(getObject.Header())
+		object, err = getObject.Context(ctx).Do()
 		return shouldRetry(ctx, err)
 	})
 	if err != nil {
@@ -984,6 +1185,20 @@ func (o *Object) ModTime(ctx context.Context) time.Time {
 }
 
 // Returns metadata for an object
+// tagsMetadata returns the tags to be stored in the custom metadata
+// of an object
+//
+// Tags which would overwrite the modification time are ignored
+func tagsMetadata(tags map[string]string) map[string]string {
+	metadata := make(map[string]string, len(tags))
+	for key, value := range tags {
+		if key != metaMtime {
+			metadata[key] = value
+		}
+	}
+	return metadata
+}
+
 func metadataFromModTime(modTime time.Time) map[string]string {
 	metadata := make(map[string]string, 1)
 	metadata[metaMtime] = modTime.Format(timeFormatOut)
@@ -1012,6 +1227,11 @@ func (o *Object) SetModTime(ctx context.Context, modTime time.Time) (err error)
 		if !o.fs.opt.BucketPolicyOnly {
 			copyObject.DestinationPredefinedAcl(o.fs.opt.ObjectACL)
 		}
+		if o.fs.opt.KMSKeyName != "" {
+			copyObject.DestinationKmsKeyName(o.fs.opt.KMSKeyName)
+		}
+		o.fs.setCopySourceEncryptionHeaders(copyObject.Header())
+		o.fs.setEncryptionHeaders(copyObject.Header())
 		newObject, err = copyObject.Context(ctx).Do()
 		return shouldRetry(ctx, err)
 	})
@@ -1035,6 +1255,7 @@ func (o *Object) Open(ctx context.Context, options ...fs.OpenOption) (in io.Read
 	}
 	fs.FixRangeOption(options, o.bytes)
 	fs.OpenOptionAddHTTPHeaders(req.Header, options)
+	o.fs.setEncryptionHeaders(req.Header)
 	var res *http.Response
 	err = o.fs.pacer.Call(func() (bool, error) {
 		res, err = o.fs.client.Do(req)
@@ -1074,6 +1295,9 @@ func (o *Object) Update(ctx context.Context, in io.Reader, src fs.ObjectInfo, op
 		ContentType: fs.MimeType(ctx, src),
 		Metadata:    metadataFromModTime(modTime),
 	}
+	for key, value := range tagsMetadata(fs.GetConfig(ctx).Tags) {
+		object.Metadata[key] = value
+	}
 	// Apply upload options
 	for _, option := range options {
 		key, value := option.Header()
@@ -1109,6 +1333,10 @@ func (o *Object) Update(ctx context.Context, in io.Reader, src fs.ObjectInfo, op
 		if !o.fs.opt.BucketPolicyOnly {
 			insertObject.PredefinedAcl(o.fs.opt.ObjectACL)
 		}
+		if o.fs.opt.KMSKeyName != "" {
+			insertObject.KmsKeyName(o.fs.opt.KMSKeyName)
+		}
+		o.fs.setEncryptionHeaders(insertObject.Header())
 		newObject, err = insertObject.Context(ctx).Do()
 		return shouldRetry(ctx, err)
 	})
@@ -1135,12 +1363,28 @@ func (o *Object) MimeType(ctx context.Context) string {
 	return o.mimeType
 }
 
+// Tags returns the custom metadata of the Object which is where tags
+// are stored as GCS objects don't have labels
+func (o *Object) Tags(ctx context.Context) (map[string]string, error) {
+	object, err := o.readObjectInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tags := tagsMetadata(object.Metadata)
+	if len(tags) == 0 {
+		return nil, nil
+	}
+	return tags, nil
+}
+
 // Check the interfaces are satisfied
 var (
 	_ fs.Fs          = &Fs{}
 	_ fs.Copier      = &Fs{}
 	_ fs.PutStreamer = &Fs{}
 	_ fs.ListRer     = &Fs{}
+	_ fs.Lifecycler  = &Fs{}
 	_ fs.Object      = &Object{}
 	_ fs.MimeTyper   = &Object{}
+	_ fs.Tagger      = &Object{}
 )
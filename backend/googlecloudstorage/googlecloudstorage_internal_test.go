@@ -0,0 +1,48 @@
+package googlecloudstorage
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckEncryptionKey(t *testing.T) {
+	const (
+		key       = "AAECAwQFBgcICQoLDA0ODxAREhMUFRYXGBkaGxwdHh8="
+		keySHA256 = "Yw3NKWbEM2aRElRIu7JbT/QSpJxzLbLIq8G4WBvXEN0="
+	)
+
+	got, err := checkEncryptionKey(&Options{})
+	require.NoError(t, err)
+	assert.Equal(t, "", got)
+
+	got, err = checkEncryptionKey(&Options{EncryptionKey: key})
+	require.NoError(t, err)
+	assert.Equal(t, keySHA256, got)
+
+	_, err = checkEncryptionKey(&Options{EncryptionKey: "not base64!"})
+	assert.Error(t, err)
+
+	_, err = checkEncryptionKey(&Options{EncryptionKey: "AAECAw=="})
+	assert.Error(t, err)
+
+	_, err = checkEncryptionKey(&Options{EncryptionKey: key, KMSKeyName: "projects/p/locations/l/keyRings/r/cryptoKeys/k"})
+	assert.Error(t, err)
+
+	f := &Fs{opt: Options{EncryptionKey: key}, keySHA256: keySHA256}
+	h := http.Header{}
+	f.setEncryptionHeaders(h)
+	f.setCopySourceEncryptionHeaders(h)
+	assert.Equal(t, "AES256", h.Get("x-goog-encryption-algorithm"))
+	assert.Equal(t, key, h.Get("x-goog-encryption-key"))
+	assert.Equal(t, keySHA256, h.Get("x-goog-encryption-key-sha256"))
+	assert.Equal(t, "AES256", h.Get("x-goog-copy-source-encryption-algorithm"))
+	assert.Equal(t, key, h.Get("x-goog-copy-source-encryption-key"))
+	assert.Equal(t, keySHA256, h.Get("x-goog-copy-source-encryption-key-sha256"))
+
+	h = http.Header{}
+	(&Fs{}).setEncryptionHeaders(h)
+	assert.Equal(t, 0, len(h))
+}
@@ -173,6 +173,7 @@ func (f *Fs) NewObject(ctx context.Context, remote string) (fs.Object, error) {
 		remote:  remote,
 		size:    info.Size(),
 		modTime: info.ModTime(),
+		meta:    hdfsMetadata(info),
 	}, nil
 }
 
@@ -200,7 +201,8 @@ func (f *Fs) List(ctx context.Context, dir string) (entries fs.DirEntries, err e
 				fs:      f,
 				remote:  remote,
 				size:    x.Size(),
-				modTime: x.ModTime()})
+				modTime: x.ModTime(),
+				meta:    hdfsMetadata(x)})
 		}
 	}
 	return entries, nil
@@ -4,10 +4,14 @@ package hdfs
 
 import (
 	"context"
+	"fmt"
 	"io"
+	"os"
 	"path"
+	"strconv"
 	"time"
 
+	"github.com/colinmarc/hdfs/v2"
 	"github.com/pingme998/rclone/fs"
 	"github.com/pingme998/rclone/fs/hash"
 	"github.com/pingme998/rclone/lib/readers"
@@ -19,6 +23,7 @@ type Object struct {
 	remote  string
 	size    int64
 	modTime time.Time
+	meta    map[string]string // HDFS specific metadata
 }
 
 // Fs returns the parent Fs
@@ -156,6 +161,7 @@ func (o *Object) Update(ctx context.Context, in io.Reader, src fs.ObjectInfo, op
 		return err
 	}
 	o.size = info.Size()
+	o.meta = hdfsMetadata(info)
 
 	return nil
 }
@@ -171,7 +177,34 @@ func (o *Object) realpath() string {
 	return o.fs.opt.Enc.FromStandardPath(xPath(o.Fs().Root(), o.remote))
 }
 
+// Metadata returns the replication, block size, owner, group and
+// permissions of the file
+func (o *Object) Metadata(ctx context.Context) (map[string]string, error) {
+	return o.meta, nil
+}
+
+// hdfsMetadata reads the HDFS specific metadata from info
+func hdfsMetadata(info os.FileInfo) map[string]string {
+	meta := map[string]string{
+		"permissions": fmt.Sprintf("%04o", info.Mode().Perm()),
+	}
+	if hi, ok := info.(*hdfs.FileInfo); ok {
+		meta["owner"] = hi.Owner()
+		meta["group"] = hi.OwnerGroup()
+	}
+	// The status is an internal type of the hdfs library so use
+	// interfaces to read it
+	if status, ok := info.Sys().(interface{ GetBlockReplication() uint32 }); ok {
+		meta["replication"] = strconv.FormatUint(uint64(status.GetBlockReplication()), 10)
+	}
+	if status, ok := info.Sys().(interface{ GetBlocksize() uint64 }); ok {
+		meta["blocksize"] = strconv.FormatUint(status.GetBlocksize(), 10)
+	}
+	return meta
+}
+
 // Check the interfaces are satisfied
 var (
-	_ fs.Object = (*Object)(nil)
+	_ fs.Object     = (*Object)(nil)
+	_ fs.Metadataer = (*Object)(nil)
 )
@@ -98,6 +98,64 @@ If you set this option, rclone will not do the HEAD request.  This will mean
 `,
 			Default:  false,
 			Advanced: true,
+		}, {
+			Name: "head_concurrency",
+			Help: `Number of HEAD requests to do in parallel when listing
+
+Rclone does a HEAD request for each file in an HTML directory listing
+unless --http-no-head is set. This sets how many of these are done at
+once. If it is 0 then the value of --checkers is used.
+
+Increasing this can speed up listing large directories on sites which
+can cope with the extra load.`,
+			Default:  0,
+			Advanced: true,
+		}, {
+			Name: "head_cache_time",
+			Help: `Time to cache the results of HEAD requests for
+
+The size, modification time and type found by a HEAD request are
+remembered for this long so they don't need to be fetched again, for
+example when a file found in a listing is then copied.
+
+Set to 0 to disable the cache.`,
+			Default:  fs.Duration(5 * time.Minute),
+			Advanced: true,
+		}, {
+			Name: "index_file",
+			Help: `Read the directory listings from this index file
+
+Instead of scraping the HTML pages served by the site for links, read
+the paths of all the files from a single index file. This is much
+quicker for large sites which publish one, such as mirror sites.
+
+This is the path or URL of the index, relative to the url. The paths
+in the index are relative to the url too, or can be absolute URLs.
+The index is read once when it is first needed.
+
+See index_format for the formats which can be read.`,
+			Advanced: true,
+		}, {
+			Name: "index_format",
+			Help: `Format of the index file
+
+Set this if the format of the index_file can't be detected from its
+name or Content-Type.`,
+			Default: indexAuto,
+			Examples: []fs.OptionExample{{
+				Value: indexAuto,
+				Help:  "Detect the format from the name and Content-Type of the index",
+			}, {
+				Value: indexJSON,
+				Help:  "A JSON list of objects with Path and optional Size, ModTime and IsDir as output by rclone lsjson",
+			}, {
+				Value: indexSitemap,
+				Help:  "An XML sitemap using the loc and optional lastmod of each url",
+			}, {
+				Value: indexCSV,
+				Help:  "CSV lines of path with optional size and modification time",
+			}},
+			Advanced: true,
 		}},
 	}
 	fs.Register(fsi)
@@ -105,10 +163,14 @@ If you set this option, rclone will not do the HEAD request.  This will mean
 
 // Options defines the configuration for this backend
 type Options struct {
-	Endpoint string          `config:"url"`
-	NoSlash  bool            `config:"no_slash"`
-	NoHead   bool            `config:"no_head"`
-	Headers  fs.CommaSepList `config:"headers"`
+	Endpoint        string          `config:"url"`
+	NoSlash         bool            `config:"no_slash"`
+	NoHead          bool            `config:"no_head"`
+	Headers         fs.CommaSepList `config:"headers"`
+	HeadConcurrency int             `config:"head_concurrency"`
+	HeadCacheTime   fs.Duration     `config:"head_cache_time"`
+	IndexFile       string          `config:"index_file"`
+	IndexFormat     string          `config:"index_format"`
 }
 
 // Fs stores the interface to the remote HTTP files
@@ -119,8 +181,12 @@ type Fs struct {
 	opt         Options        // options for this backend
 	ci          *fs.ConfigInfo // global config
 	endpoint    *url.URL
-	endpointURL string // endpoint as a string
+	endpointURL string   // endpoint as a string
+	baseURL     *url.URL // the configured url without the root
 	httpClient  *http.Client
+	headCache   *headCache // cached results of HEAD requests
+	indexMu     sync.Mutex // protects index
+	index       *index     // parsed index_file if set and read
 }
 
 // Object is a remote object that has been stat'd (so it exists, but is not necessarily open for reading)
@@ -158,6 +224,12 @@ func NewFs(ctx context.Context, name, root string, m configmap.Mapper) (fs.Fs, e
 		return nil, errors.New("odd number of headers supplied")
 	}
 
+	switch opt.IndexFormat {
+	case "", indexAuto, indexJSON, indexSitemap, indexCSV:
+	default:
+		return nil, errors.Errorf("unknown index_format %q", opt.IndexFormat)
+	}
+
 	if !strings.HasSuffix(opt.Endpoint, "/") {
 		opt.Endpoint += "/"
 	}
@@ -218,6 +290,8 @@ func NewFs(ctx context.Context, name, root string, m configmap.Mapper) (fs.Fs, e
 		httpClient:  client,
 		endpoint:    u,
 		endpointURL: u.String(),
+		baseURL:     base,
+		headCache:   newHeadCache(time.Duration(opt.HeadCacheTime)),
 	}
 	f.features = (&fs.Features{
 		CanHaveEmptyDirectories: true,
@@ -258,6 +332,16 @@ func (f *Fs) Precision() time.Duration {
 
 // NewObject creates a new remote http file object
 func (f *Fs) NewObject(ctx context.Context, remote string) (fs.Object, error) {
+	if f.opt.IndexFile != "" {
+		idx, err := f.getIndex(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if o, found := idx.files[remote]; found {
+			return o, nil
+		}
+		// not in the index so check the site
+	}
 	o := &Object{
 		fs:     f,
 		remote: remote,
@@ -430,6 +514,9 @@ func (f *Fs) readDir(ctx context.Context, dir string) (names []string, err error
 // This should return ErrDirNotFound if the directory isn't
 // found.
 func (f *Fs) List(ctx context.Context, dir string) (entries fs.DirEntries, err error) {
+	if f.opt.IndexFile != "" {
+		return f.listIndex(ctx, dir)
+	}
 	if !strings.HasSuffix(dir, "/") && dir != "" {
 		dir += "/"
 	}
@@ -437,10 +524,13 @@ func (f *Fs) List(ctx context.Context, dir string) (entries fs.DirEntries, err e
 	if err != nil {
 		return nil, errors.Wrapf(err, "error listing %q", dir)
 	}
+	checkers := f.opt.HeadConcurrency
+	if checkers <= 0 {
+		checkers = f.ci.Checkers
+	}
 	var (
 		entriesMu sync.Mutex // to protect entries
 		wg        sync.WaitGroup
-		checkers  = f.ci.Checkers
 		in        = make(chan string, checkers)
 	)
 	add := func(entry fs.DirEntry) {
@@ -544,6 +634,15 @@ func (o *Object) stat(ctx context.Context) error {
 		o.contentType = fs.MimeType(ctx, o)
 		return nil
 	}
+	if cached, ok := o.fs.headCache.get(o.remote); ok {
+		o.size = cached.size
+		o.modTime = cached.modTime
+		o.contentType = cached.contentType
+		if cached.isDir {
+			return fs.ErrorNotAFile
+		}
+		return nil
+	}
 	url := o.url()
 	req, err := http.NewRequestWithContext(ctx, "HEAD", url, nil)
 	if err != nil {
@@ -565,6 +664,11 @@ func (o *Object) stat(ctx context.Context) error {
 	o.size = parseInt64(res.Header.Get("Content-Length"), -1)
 	o.modTime = t
 	o.contentType = res.Header.Get("Content-Type")
+	cached := headCacheEntry{
+		size:        o.size,
+		modTime:     o.modTime,
+		contentType: o.contentType,
+	}
 	// If NoSlash is set then check ContentType to see if it is a directory
 	if o.fs.opt.NoSlash {
 		mediaType, _, err := mime.ParseMediaType(o.contentType)
@@ -572,9 +676,12 @@ func (o *Object) stat(ctx context.Context) error {
 			return errors.Wrapf(err, "failed to parse Content-Type: %q", o.contentType)
 		}
 		if mediaType == "text/html" {
+			cached.isDir = true
+			o.fs.headCache.put(o.remote, cached)
 			return fs.ErrorNotAFile
 		}
 	}
+	o.fs.headCache.put(o.remote, cached)
 	return nil
 }
 
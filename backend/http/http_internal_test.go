@@ -353,3 +353,153 @@ func TestParseCaddy(t *testing.T) {
 		"v1.36-22-g06ea13a-ssh-agentβ/",
 	})
 }
+
+func TestParseIndex(t *testing.T) {
+	size := int64(6)
+	modTime := time.Date(2021, 4, 5, 6, 7, 8, 0, time.UTC)
+	for _, test := range []struct {
+		format string
+		in     string
+		want   []indexEntry
+	}{
+		{indexJSON, `[
+{"Path":"one.txt","Name":"one.txt","Size":6,"ModTime":"2021-04-05T06:07:08Z","IsDir":false},
+{"Path":"dir","Name":"dir","Size":-1,"ModTime":"2021-04-05T06:07:08Z","IsDir":true},
+{"Path":"dir/two.txt"}
+]`, []indexEntry{
+			{Path: "one.txt", Size: &size, ModTime: modTime},
+			{Path: "dir", ModTime: modTime, IsDir: true},
+			{Path: "dir/two.txt"},
+		}},
+		{indexSitemap, `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url><loc>http://example.com/one.txt</loc><lastmod>2021-04-05T06:07:08Z</lastmod></url>
+  <url><loc>http://example.com/dir/</loc></url>
+  <url><loc> http://example.com/dir/two.txt </loc><lastmod>2021-04-05</lastmod></url>
+</urlset>`, []indexEntry{
+			{Path: "http://example.com/one.txt", ModTime: modTime},
+			{Path: "http://example.com/dir/", IsDir: true},
+			{Path: "http://example.com/dir/two.txt", ModTime: time.Date(2021, 4, 5, 0, 0, 0, 0, time.UTC)},
+		}},
+		{indexCSV, `path,size,modtime
+one.txt,6,2021-04-05T06:07:08Z
+# a comment
+dir/
+"dir/two.txt"
+`, []indexEntry{
+			{Path: "one.txt", Size: &size, ModTime: modTime},
+			{Path: "dir/", IsDir: true},
+			{Path: "dir/two.txt"},
+		}},
+	} {
+		got, err := parseIndex(test.format, strings.NewReader(test.in))
+		require.NoError(t, err, test.format)
+		assert.Equal(t, test.want, got, test.format)
+	}
+
+	_, err := parseIndex(indexCSV, strings.NewReader("one.txt,potato\n"))
+	assert.Error(t, err)
+	_, err = parseIndex("potato", strings.NewReader(""))
+	assert.Error(t, err)
+}
+
+func TestDetectIndexFormat(t *testing.T) {
+	for _, test := range []struct {
+		name        string
+		contentType string
+		want        string
+	}{
+		{"index", "application/json", indexJSON},
+		{"index", "application/xml; charset=utf-8", indexSitemap},
+		{"index", "text/xml", indexSitemap},
+		{"index", "text/csv", indexCSV},
+		{"index.json", "text/plain", indexJSON},
+		{"sitemap.xml", "", indexSitemap},
+		{"files.CSV", "application/octet-stream", indexCSV},
+		{"index", "text/plain", ""},
+	} {
+		got, err := detectIndexFormat(test.name, test.contentType)
+		assert.Equal(t, test.want, got, test.name)
+		assert.Equal(t, test.want == "", err != nil, test.name)
+	}
+}
+
+func TestListIndex(t *testing.T) {
+	m, tidy := prepareServer(t)
+	defer tidy()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/index.csv", r.URL.Path)
+		_, _ = w.Write([]byte(`one%.txt,6
+three/underthree.txt,9,2021-04-05T06:07:08Z
+four/
+elsewhere/file.txt,1
+/absolute/file.txt,1
+`))
+	}))
+	defer ts.Close()
+	m.Set("index_file", ts.URL+"/index.csv")
+	ctx := context.Background()
+
+	f, err := NewFs(ctx, remoteName, "", m)
+	require.NoError(t, err)
+	entries, err := f.List(ctx, "")
+	require.NoError(t, err)
+	sort.Sort(entries)
+	var got []string
+	for _, entry := range entries {
+		got = append(got, fmt.Sprintf("%s %d", entry.Remote(), entry.Size()))
+	}
+	assert.Equal(t, []string{"absolute -1", "elsewhere -1", "four -1", "one%.txt 6", "three -1"}, got)
+
+	entries, err = f.List(ctx, "three")
+	require.NoError(t, err)
+	require.Equal(t, 1, len(entries))
+	assert.Equal(t, "three/underthree.txt", entries[0].Remote())
+	assert.Equal(t, time.Date(2021, 4, 5, 6, 7, 8, 0, time.UTC), entries[0].ModTime(ctx))
+
+	entries, err = f.List(ctx, "four")
+	require.NoError(t, err)
+	assert.Equal(t, 0, len(entries))
+
+	_, err = f.List(ctx, "potato")
+	assert.Equal(t, fs.ErrorDirNotFound, err)
+
+	// Objects in the index are found without a HEAD request
+	o, err := f.NewObject(ctx, "three/underthree.txt")
+	require.NoError(t, err)
+	assert.Equal(t, int64(9), o.Size())
+
+	// Objects not in the index are looked up on the site
+	o, err = f.NewObject(ctx, "four/under four.txt")
+	require.NoError(t, err)
+	assert.Equal(t, int64(9), o.Size())
+
+	// With a root only the entries under it are listed
+	f, err = NewFs(ctx, remoteName, "three", m)
+	require.NoError(t, err)
+	entries, err = f.List(ctx, "")
+	require.NoError(t, err)
+	require.Equal(t, 1, len(entries))
+	assert.Equal(t, "underthree.txt", entries[0].Remote())
+}
+
+func TestHeadCache(t *testing.T) {
+	c := newHeadCache(time.Minute)
+	_, ok := c.get("potato")
+	assert.False(t, ok)
+	c.put("potato", headCacheEntry{size: 6})
+	got, ok := c.get("potato")
+	assert.True(t, ok)
+	assert.Equal(t, int64(6), got.size)
+
+	// Expired entries aren't returned
+	c.entries["potato"] = headCacheEntry{when: time.Now().Add(-2 * time.Minute)}
+	_, ok = c.get("potato")
+	assert.False(t, ok)
+
+	// Disabled cache
+	c = newHeadCache(0)
+	c.put("potato", headCacheEntry{size: 6})
+	_, ok = c.get("potato")
+	assert.False(t, ok)
+}
@@ -0,0 +1,378 @@
+package http
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/pingme998/rclone/fs"
+	"github.com/pingme998/rclone/lib/rest"
+)
+
+// Formats of the index file
+const (
+	indexAuto    = "auto"
+	indexJSON    = "json"
+	indexSitemap = "sitemap"
+	indexCSV     = "csv"
+)
+
+// indexEntry is a file or directory read from the index file
+//
+// The JSON field names match the output of rclone lsjson
+type indexEntry struct {
+	Path    string    // path or URL as found in the index
+	Size    *int64    // size if known
+	ModTime time.Time // modification time or zero if not known
+	IsDir   bool      // set if this is a directory
+}
+
+// index is the parsed index file
+type index struct {
+	dirs  map[string][]fs.DirEntry // entries by directory remote
+	files map[string]*Object       // objects by remote
+}
+
+// Layouts to try when parsing times from sitemaps and CSV files
+//
+// These are the W3C datetime formats used by sitemaps
+var indexTimeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02",
+}
+
+// parseIndexTime parses a time from the index
+func parseIndexTime(s string) (t time.Time, err error) {
+	s = strings.TrimSpace(s)
+	for _, layout := range indexTimeLayouts {
+		t, err = time.Parse(layout, s)
+		if err == nil {
+			return t, nil
+		}
+	}
+	return t, errors.Errorf("can't parse time %q", s)
+}
+
+// detectIndexFormat works out the format of the index from its name
+// and the Content-Type it was served with
+func detectIndexFormat(name, contentType string) (string, error) {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch {
+	case strings.HasSuffix(mediaType, "json"):
+		return indexJSON, nil
+	case strings.HasSuffix(mediaType, "xml"):
+		return indexSitemap, nil
+	case mediaType == "text/csv":
+		return indexCSV, nil
+	}
+	switch strings.ToLower(path.Ext(name)) {
+	case ".json":
+		return indexJSON, nil
+	case ".xml":
+		return indexSitemap, nil
+	case ".csv":
+		return indexCSV, nil
+	}
+	return "", errors.Errorf("can't detect format of index %q with Content-Type %q - set index_format", name, contentType)
+}
+
+// parseIndexJSON reads a JSON array of objects with Path, Size,
+// ModTime and IsDir fields as written by rclone lsjson
+func parseIndexJSON(in io.Reader) (entries []indexEntry, err error) {
+	err = json.NewDecoder(in).Decode(&entries)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse JSON index")
+	}
+	for i := range entries {
+		if entries[i].IsDir {
+			entries[i].Size = nil
+		}
+	}
+	return entries, nil
+}
+
+// parseIndexSitemap reads the URLs in a sitemap
+func parseIndexSitemap(in io.Reader) (entries []indexEntry, err error) {
+	var sitemap struct {
+		URLs []struct {
+			Loc     string `xml:"loc"`
+			LastMod string `xml:"lastmod"`
+		} `xml:"url"`
+	}
+	err = xml.NewDecoder(in).Decode(&sitemap)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse sitemap index")
+	}
+	for _, u := range sitemap.URLs {
+		entry := indexEntry{
+			Path:  strings.TrimSpace(u.Loc),
+			IsDir: strings.HasSuffix(strings.TrimSpace(u.Loc), "/"),
+		}
+		if u.LastMod != "" {
+			entry.ModTime, err = parseIndexTime(u.LastMod)
+			if err != nil {
+				fs.Debugf(nil, "Ignoring lastmod for %q in sitemap: %v", entry.Path, err)
+			}
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// parseIndexCSV reads lines of path,size,modtime from a CSV file
+//
+// The size and modtime are optional and a header line starting with
+// "path" is ignored.
+func parseIndexCSV(in io.Reader) (entries []indexEntry, err error) {
+	r := csv.NewReader(in)
+	r.FieldsPerRecord = -1
+	r.Comment = '#'
+	r.TrimLeadingSpace = true
+	for line := 1; ; line++ {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, errors.Wrap(err, "failed to parse CSV index")
+		}
+		if line == 1 && strings.EqualFold(record[0], "path") {
+			continue
+		}
+		entry := indexEntry{
+			Path:  record[0],
+			IsDir: strings.HasSuffix(record[0], "/"),
+		}
+		if len(record) > 1 && record[1] != "" && !entry.IsDir {
+			size, err := strconv.ParseInt(record[1], 10, 64)
+			if err != nil {
+				return nil, errors.Wrapf(err, "bad size on line %d of CSV index", line)
+			}
+			entry.Size = &size
+		}
+		if len(record) > 2 && record[2] != "" {
+			entry.ModTime, err = parseIndexTime(record[2])
+			if err != nil {
+				return nil, errors.Wrapf(err, "bad time on line %d of CSV index", line)
+			}
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// parseIndex reads the entries from an index in format
+func parseIndex(format string, in io.Reader) ([]indexEntry, error) {
+	switch format {
+	case indexJSON:
+		return parseIndexJSON(in)
+	case indexSitemap:
+		return parseIndexSitemap(in)
+	case indexCSV:
+		return parseIndexCSV(in)
+	}
+	return nil, errors.Errorf("unknown index format %q", format)
+}
+
+// indexRemote turns a path from the index into a remote under the
+// root or returns false if it isn't under the root
+//
+// Paths are relative to the url the remote was configured with
+func (f *Fs) indexRemote(name string) (remote string, ok bool) {
+	u, err := rest.URLJoin(f.baseURL, rest.URLPathEscape(strings.TrimLeft(name, "/")))
+	if strings.Contains(name, "://") {
+		u, err = url.Parse(name)
+	}
+	if err != nil || u.Host != f.endpoint.Host || u.Scheme != f.endpoint.Scheme {
+		return "", false
+	}
+	if !strings.HasPrefix(u.Path, f.endpoint.Path) {
+		return "", false
+	}
+	remote = strings.Trim(u.Path[len(f.endpoint.Path):], "/")
+	if remote == "" {
+		return "", false
+	}
+	return remote, true
+}
+
+// newIndex makes an index of the entries under the root
+func (f *Fs) newIndex(ctx context.Context, entries []indexEntry) *index {
+	idx := &index{
+		dirs: map[string][]fs.DirEntry{
+			"": nil,
+		},
+		files: make(map[string]*Object),
+	}
+	// addDir adds the directory and any parents which aren't in the index
+	var addDir func(remote string, modTime time.Time)
+	addDir = func(remote string, modTime time.Time) {
+		if _, found := idx.dirs[remote]; found {
+			return
+		}
+		idx.dirs[remote] = nil
+		parent := path.Dir(remote)
+		if parent == "." {
+			parent = ""
+		}
+		addDir(parent, timeUnset)
+		idx.dirs[parent] = append(idx.dirs[parent], fs.NewDir(remote, modTime))
+	}
+	for _, entry := range entries {
+		remote, ok := f.indexRemote(entry.Path)
+		if !ok {
+			continue
+		}
+		modTime := entry.ModTime
+		if modTime.IsZero() {
+			modTime = timeUnset
+		}
+		if entry.IsDir {
+			addDir(remote, modTime)
+			continue
+		}
+		if _, found := idx.files[remote]; found {
+			continue
+		}
+		parent := path.Dir(remote)
+		if parent == "." {
+			parent = ""
+		}
+		addDir(parent, timeUnset)
+		o := &Object{
+			fs:      f,
+			remote:  remote,
+			size:    -1,
+			modTime: modTime,
+		}
+		if entry.Size != nil {
+			o.size = *entry.Size
+		}
+		o.contentType = fs.MimeType(ctx, o)
+		idx.files[remote] = o
+		idx.dirs[parent] = append(idx.dirs[parent], o)
+	}
+	return idx
+}
+
+// readIndex fetches and parses the index file
+func (f *Fs) readIndex(ctx context.Context) (*index, error) {
+	u, err := rest.URLJoin(f.baseURL, f.opt.IndexFile)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to make index URL")
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", u.String(), nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read index")
+	}
+	f.addHeaders(req)
+	res, err := f.httpClient.Do(req)
+	err = statusError(res, err)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read index")
+	}
+	defer fs.CheckClose(res.Body, &err)
+	format := f.opt.IndexFormat
+	if format == indexAuto || format == "" {
+		format, err = detectIndexFormat(u.Path, res.Header.Get("Content-Type"))
+		if err != nil {
+			return nil, err
+		}
+	}
+	entries, err := parseIndex(format, res.Body)
+	if err != nil {
+		return nil, err
+	}
+	fs.Debugf(f, "Read %d entries from %s index %q", len(entries), format, u.String())
+	return f.newIndex(ctx, entries), nil
+}
+
+// getIndex returns the index, reading it if necessary
+//
+// The index is only read once for the life of the Fs.
+func (f *Fs) getIndex(ctx context.Context) (*index, error) {
+	f.indexMu.Lock()
+	defer f.indexMu.Unlock()
+	if f.index == nil {
+		idx, err := f.readIndex(ctx)
+		if err != nil {
+			return nil, err
+		}
+		f.index = idx
+	}
+	return f.index, nil
+}
+
+// listIndex lists dir from the index
+func (f *Fs) listIndex(ctx context.Context, dir string) (entries fs.DirEntries, err error) {
+	idx, err := f.getIndex(ctx)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error listing %q", dir)
+	}
+	dirEntries, found := idx.dirs[strings.Trim(dir, "/")]
+	if !found {
+		return nil, fs.ErrorDirNotFound
+	}
+	return append(entries, dirEntries...), nil
+}
+
+// headCacheEntry is the result of a HEAD request
+type headCacheEntry struct {
+	when        time.Time // when the request was made
+	size        int64
+	modTime     time.Time
+	contentType string
+	isDir       bool
+}
+
+// headCache remembers the results of HEAD requests
+type headCache struct {
+	mu      sync.Mutex
+	maxAge  time.Duration
+	entries map[string]headCacheEntry
+}
+
+// newHeadCache makes a headCache keeping entries for maxAge
+func newHeadCache(maxAge time.Duration) *headCache {
+	return &headCache{
+		maxAge:  maxAge,
+		entries: make(map[string]headCacheEntry),
+	}
+}
+
+// get returns the cached result for remote if there is one
+func (c *headCache) get(remote string) (entry headCacheEntry, ok bool) {
+	if c.maxAge <= 0 {
+		return entry, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok = c.entries[remote]
+	if ok && time.Since(entry.when) > c.maxAge {
+		delete(c.entries, remote)
+		return entry, false
+	}
+	return entry, ok
+}
+
+// put stores the result for remote
+func (c *headCache) put(remote string, entry headCacheEntry) {
+	if c.maxAge <= 0 {
+		return
+	}
+	entry.when = time.Now()
+	c.mu.Lock()
+	c.entries[remote] = entry
+	c.mu.Unlock()
+}
@@ -0,0 +1,309 @@
+// +build linux darwin freebsd netbsd openbsd dragonfly windows
+
+package local
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/pingme998/rclone/fs"
+	"github.com/pingme998/rclone/lib/errors"
+)
+
+// changeWatcher watches the directories under the root of the Fs for
+// changes and collects them until they are passed on
+type changeWatcher struct {
+	f       *Fs
+	watcher *fsnotify.Watcher
+	dirs    map[string]struct{}     // OS paths of the directories being watched
+	changes map[string]fs.EntryType // remotes which have changed since the last flush
+	limited bool                    // set if the OS has run out of watches
+}
+
+// newChangeWatcher starts watching the root of f and all the
+// directories under it
+func newChangeWatcher(f *Fs) (*changeWatcher, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	w := &changeWatcher{
+		f:       f,
+		watcher: watcher,
+		dirs:    make(map[string]struct{}),
+		changes: make(map[string]fs.EntryType),
+	}
+	err = w.addDir(f.root, false)
+	if err != nil {
+		_ = watcher.Close()
+		return nil, err
+	}
+	return w, nil
+}
+
+// isWatchLimit returns true if err is the OS running out of watches -
+// ENOSPC from inotify or EMFILE from kqueue which needs an open file
+// for each watch
+func isWatchLimit(err error) (isLimit bool) {
+	errors.Walk(err, func(c error) bool {
+		isLimit = c == syscall.ENOSPC || c == syscall.EMFILE
+		return isLimit
+	})
+	return isLimit
+}
+
+// addDir adds watches for dir and all the directories under it
+//
+// If record is set then everything found under dir is recorded as
+// changed, as it may have been created before the watches were added.
+//
+// Errors on the subdirectories are logged but otherwise ignored so
+// the rest of the tree can still be watched. If the OS runs out of
+// watches then this is logged once and no more are added.
+func (w *changeWatcher) addDir(dir string, record bool) error {
+	return filepath.Walk(dir, func(osPath string, info os.FileInfo, err error) error {
+		if err != nil {
+			if osPath == dir {
+				return err
+			}
+			fs.Debugf(w.f, "Change notify: failed to read %q: %v", osPath, err)
+			return nil
+		}
+		if !info.IsDir() {
+			if record {
+				w.record(osPath, info)
+			}
+			return nil
+		}
+		if osPath != w.f.root && w.f.dev != readDevice(info, w.f.opt.OneFileSystem) {
+			return filepath.SkipDir
+		}
+		if w.limited && osPath != dir {
+			return filepath.SkipDir
+		}
+		err = w.watcher.Add(osPath)
+		if err != nil {
+			if osPath == dir {
+				return err
+			}
+			if !isWatchLimit(err) {
+				fs.Errorf(w.f, "Change notify: failed to watch %q: %v", osPath, err)
+			} else if !w.limited {
+				w.limited = true
+				fs.Errorf(w.f, "Change notify: out of watches at %q so some changes won't be seen - increase fs.inotify.max_user_watches on Linux or the open file limit: %v", osPath, err)
+			}
+			return filepath.SkipDir
+		}
+		w.dirs[osPath] = struct{}{}
+		if record && osPath != dir {
+			w.record(osPath, info)
+		}
+		return nil
+	})
+}
+
+// removeDir stops watching dir and all the directories under it
+func (w *changeWatcher) removeDir(dir string) {
+	prefix := dir + string(os.PathSeparator)
+	for osPath := range w.dirs {
+		if osPath == dir || strings.HasPrefix(osPath, prefix) {
+			// the watch may already have gone with the directory
+			_ = w.watcher.Remove(osPath)
+			delete(w.dirs, osPath)
+		}
+	}
+}
+
+// remote converts an OS path under the root into a remote
+//
+// It returns false if osPath isn't under the root.
+func (w *changeWatcher) remote(osPath string) (remote string, ok bool) {
+	rel, err := filepath.Rel(w.f.root, osPath)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(os.PathSeparator)) {
+		return "", false
+	}
+	for _, name := range strings.Split(rel, string(os.PathSeparator)) {
+		remote = w.f.cleanRemote(remote, name)
+	}
+	return remote, true
+}
+
+// record marks the existing file or directory at osPath as changed
+func (w *changeWatcher) record(osPath string, info os.FileInfo) {
+	remote, ok := w.remote(osPath)
+	if !ok {
+		return
+	}
+	if info.IsDir() {
+		w.changes[remote] = fs.EntryDirectory
+	} else if w.f.opt.TranslateSymlinks && info.Mode()&os.ModeSymlink != 0 {
+		w.changes[remote+linkSuffix] = fs.EntryObject
+	} else {
+		w.changes[remote] = fs.EntryObject
+	}
+}
+
+// handleEvent records the change in event
+func (w *changeWatcher) handleEvent(event fsnotify.Event) {
+	info, err := w.f.lstat(event.Name)
+	if err == nil {
+		w.record(event.Name, info)
+		if _, found := w.dirs[event.Name]; info.IsDir() && !found {
+			// Watch new directories and anything
+			// already created inside them
+			err = w.addDir(event.Name, true)
+			if err != nil {
+				fs.Errorf(w.f, "Change notify: failed to watch %q: %v", event.Name, err)
+			}
+		}
+		return
+	}
+	remote, ok := w.remote(event.Name)
+	if !ok {
+		return
+	}
+	if _, found := w.dirs[event.Name]; found {
+		// The directory has been removed or renamed
+		w.changes[remote] = fs.EntryDirectory
+		w.removeDir(event.Name)
+	} else {
+		w.changes[remote] = fs.EntryObject
+	}
+}
+
+// flush passes the collected changes to notifyFunc
+func (w *changeWatcher) flush(notifyFunc func(string, fs.EntryType)) {
+	for remote, entryType := range w.changes {
+		notifyFunc(remote, entryType)
+	}
+	w.changes = make(map[string]fs.EntryType)
+}
+
+// close stops watching for changes
+func (w *changeWatcher) close() {
+	err := w.watcher.Close()
+	if err != nil {
+		fs.Debugf(w.f, "Change notify: failed to close watcher: %v", err)
+	}
+}
+
+// ChangeNotify calls the passed function with a path that has had changes.
+//
+// The changes are read from the operating system (inotify on Linux,
+// kqueue on macOS and the BSDs and ReadDirectoryChangesW on Windows)
+// as they happen and are passed on once every poll interval.
+//
+// Adding the watches for a large directory tree takes a while so it
+// is done in the background.
+//
+// Close the returned channel to stop being notified.
+func (f *Fs) ChangeNotify(ctx context.Context, notifyFunc func(string, fs.EntryType), pollIntervalChan <-chan time.Duration) {
+	go func() {
+		var (
+			w        *changeWatcher
+			wanted   bool                        // set if the watcher should be running
+			starting bool                        // set while a watcher is being started
+			startedC = make(chan *changeWatcher) // receives the started watcher or nil
+			ticker   *time.Ticker
+			tickerC  <-chan time.Time
+			eventsC  <-chan fsnotify.Event
+			errorsC  <-chan error
+		)
+		startWatcher := func() {
+			wanted = true
+			if w != nil || starting {
+				return
+			}
+			starting = true
+			go func() {
+				w, err := newChangeWatcher(f)
+				if err != nil {
+					fs.Infof(f, "Failed to start change notify: %v", err)
+				}
+				startedC <- w
+			}()
+		}
+		stopWatcher := func() {
+			wanted = false
+			if w != nil {
+				w.close()
+				w = nil
+			}
+			eventsC, errorsC = nil, nil
+		}
+		// start watching early so all changes from now on get processed
+		startWatcher()
+		for {
+			select {
+			case newW := <-startedC:
+				starting = false
+				if newW == nil {
+					continue
+				}
+				if !wanted {
+					// stopped while it was starting
+					newW.close()
+					continue
+				}
+				w = newW
+				eventsC, errorsC = w.watcher.Events, w.watcher.Errors
+			case pollInterval, ok := <-pollIntervalChan:
+				if !ok {
+					if ticker != nil {
+						ticker.Stop()
+					}
+					stopWatcher()
+					if starting {
+						// close the watcher being started when it arrives
+						go func() {
+							if w := <-startedC; w != nil {
+								w.close()
+							}
+						}()
+					}
+					return
+				}
+				if ticker != nil {
+					ticker.Stop()
+					ticker, tickerC = nil, nil
+				}
+				if pollInterval != 0 {
+					ticker = time.NewTicker(pollInterval)
+					tickerC = ticker.C
+					startWatcher()
+				} else {
+					stopWatcher()
+				}
+			case event, ok := <-eventsC:
+				if !ok {
+					eventsC = nil
+					continue
+				}
+				w.handleEvent(event)
+			case err, ok := <-errorsC:
+				if !ok {
+					errorsC = nil
+					continue
+				}
+				fs.Infof(f, "Change notify listener failure: %s", err)
+			case <-tickerC:
+				if w == nil {
+					// the root may not have existed before
+					startWatcher()
+					continue
+				}
+				w.flush(notifyFunc)
+			}
+		}
+	}()
+}
+
+// Check the interfaces are satisfied
+var (
+	_ fs.ChangeNotifier = &Fs{}
+)
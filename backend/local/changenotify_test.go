@@ -0,0 +1,102 @@
+// +build linux darwin freebsd netbsd openbsd dragonfly windows
+
+package local
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/pingme998/rclone/fs"
+	"github.com/pingme998/rclone/fs/config/configmap"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newChangeNotifyFs makes a temporary directory and an Fs on it with
+// change_notify set to changeNotify
+func newChangeNotifyFs(t *testing.T, changeNotify string) (f *Fs, cleanup func()) {
+	dir, err := ioutil.TempDir("", "rclone-change-notify-test")
+	require.NoError(t, err)
+	m := configmap.Simple{
+		"change_notify": changeNotify,
+	}
+	fsys, err := NewFs(context.Background(), "local", dir, m)
+	require.NoError(t, err)
+	return fsys.(*Fs), func() {
+		require.NoError(t, os.RemoveAll(dir))
+	}
+}
+
+func TestChangeNotifyOptIn(t *testing.T) {
+	f, cleanup := newChangeNotifyFs(t, "false")
+	defer cleanup()
+	assert.Nil(t, f.Features().ChangeNotify)
+
+	f, cleanup = newChangeNotifyFs(t, "true")
+	defer cleanup()
+	assert.NotNil(t, f.Features().ChangeNotify)
+}
+
+func TestChangeNotify(t *testing.T) {
+	f, cleanup := newChangeNotifyFs(t, "true")
+	defer cleanup()
+	require.NoError(t, os.Mkdir(filepath.Join(f.root, "dir"), 0777))
+
+	changes := make(chan string, 100)
+	pollInterval := make(chan time.Duration)
+	f.ChangeNotify(context.Background(), func(remote string, entryType fs.EntryType) {
+		changes <- remote
+	}, pollInterval)
+	defer close(pollInterval)
+	pollInterval <- 10 * time.Millisecond
+
+	// The watches are added in the background so keep writing
+	// until the change is seen
+	timeout := time.After(10 * time.Second)
+	for i := 0; ; i++ {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(f.root, "dir", "file.txt"), []byte{byte(i)}, 0666))
+		select {
+		case remote := <-changes:
+			if remote == "dir/file.txt" {
+				return
+			}
+		case <-time.After(100 * time.Millisecond):
+		case <-timeout:
+			t.Fatal("timed out waiting for change")
+		}
+	}
+}
+
+func TestIsWatchLimit(t *testing.T) {
+	assert.True(t, isWatchLimit(syscall.ENOSPC))
+	assert.True(t, isWatchLimit(errors.Wrap(syscall.EMFILE, "open")))
+	assert.True(t, isWatchLimit(&os.PathError{Op: "open", Path: "dir", Err: syscall.EMFILE}))
+	assert.False(t, isWatchLimit(syscall.ENOENT))
+	assert.False(t, isWatchLimit(errors.New("potato")))
+}
+
+func TestChangeWatcherLimited(t *testing.T) {
+	f, cleanup := newChangeNotifyFs(t, "true")
+	defer cleanup()
+	require.NoError(t, os.MkdirAll(filepath.Join(f.root, "dir", "sub"), 0777))
+
+	w, err := newChangeWatcher(f)
+	require.NoError(t, err)
+	defer w.close()
+	assert.Len(t, w.dirs, 3)
+
+	// Once out of watches no more are added for new directories
+	w.limited = true
+	newDir := filepath.Join(f.root, "new")
+	require.NoError(t, os.MkdirAll(filepath.Join(newDir, "sub"), 0777))
+	require.NoError(t, w.addDir(newDir, true))
+	assert.Len(t, w.dirs, 4)
+	assert.Contains(t, w.dirs, newDir)
+	assert.NotContains(t, w.dirs, filepath.Join(newDir, "sub"))
+}
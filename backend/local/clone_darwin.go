@@ -0,0 +1,40 @@
+//+build darwin
+
+package local
+
+import (
+	"context"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// cloneFile makes dst a copy of src using clonefile so the files
+// share their data.
+//
+// If followLinks is set and src is a symlink then the file it points
+// to is cloned rather than the link.
+//
+// It returns errCloneUnsupported if the file system can't clone files.
+func cloneFile(ctx context.Context, src, dst string, followLinks bool) error {
+	// clonefile won't overwrite an existing file so clone to a
+	// temporary name then rename it
+	tmp := cloneTempName(dst)
+	flags := 0
+	if !followLinks {
+		flags = unix.CLONE_NOFOLLOW
+	}
+	err := unix.Clonefile(src, tmp, flags)
+	switch err {
+	case nil:
+	case unix.ENOTSUP, unix.EXDEV:
+		return errCloneUnsupported
+	default:
+		return &os.PathError{Op: "clonefile", Path: src, Err: err}
+	}
+	err = os.Rename(tmp, dst)
+	if err != nil {
+		_ = os.Remove(tmp)
+	}
+	return err
+}
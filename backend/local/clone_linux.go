@@ -0,0 +1,92 @@
+//+build linux
+
+package local
+
+import (
+	"context"
+	"os"
+
+	"github.com/pingme998/rclone/fs"
+	"golang.org/x/sys/unix"
+)
+
+// cloneChunkSize is the most copy_file_range is asked to copy at once
+// so ctx is checked regularly while copying big files
+const cloneChunkSize = 64 * 1024 * 1024
+
+// cloneFile makes dst a copy of src.
+//
+// It uses a reflink if the file system supports it, so the files share
+// their data, otherwise copy_file_range so the data is copied by the
+// kernel without passing through rclone. This is done in chunks of
+// cloneChunkSize, stopping if ctx is cancelled.
+//
+// The copy is made under a temporary name and renamed over dst when it
+// is complete so an existing dst is left alone if it fails. Symlinks
+// are always followed so followLinks is ignored.
+//
+// It returns errCloneUnsupported if neither can be used.
+func cloneFile(ctx context.Context, src, dst string, followLinks bool) (err error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer fs.CheckClose(in, &err)
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+	tmp := cloneTempName(dst)
+	out, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0666)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		closeErr := out.Close()
+		if err == nil {
+			err = closeErr
+		}
+		if err == nil {
+			err = os.Rename(tmp, dst)
+		}
+		if err != nil {
+			_ = os.Remove(tmp)
+		}
+	}()
+
+	err = unix.IoctlFileClone(int(out.Fd()), int(in.Fd()))
+	if err == nil {
+		return nil
+	}
+
+	remaining := info.Size()
+	for remaining > 0 {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		chunk := remaining
+		if chunk > cloneChunkSize {
+			chunk = cloneChunkSize
+		}
+		n, err := unix.CopyFileRange(int(in.Fd()), nil, int(out.Fd()), nil, int(chunk), 0)
+		if err != nil {
+			if remaining == info.Size() {
+				switch err {
+				case unix.ENOSYS, unix.EXDEV, unix.EOPNOTSUPP, unix.EINVAL:
+					return errCloneUnsupported
+				}
+			}
+			return &os.PathError{Op: "copy_file_range", Path: src, Err: err}
+		}
+		if n == 0 {
+			if remaining == info.Size() {
+				// some file systems return 0 rather than an error
+				return errCloneUnsupported
+			}
+			// the source was truncated while copying
+			break
+		}
+		remaining -= int64(n)
+	}
+	return nil
+}
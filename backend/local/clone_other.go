@@ -0,0 +1,12 @@
+//+build !linux,!darwin
+
+package local
+
+import (
+	"context"
+)
+
+// cloneFile isn't supported on this OS
+func cloneFile(ctx context.Context, src, dst string, followLinks bool) error {
+	return errCloneUnsupported
+}
@@ -0,0 +1,14 @@
+//+build linux darwin
+
+package local
+
+import (
+	"github.com/pingme998/rclone/lib/random"
+)
+
+// cloneTempName returns a name in the same directory as dst to make
+// the clone under before renaming it over dst, so an existing dst is
+// left alone if the clone fails
+func cloneTempName(dst string) string {
+	return dst + "." + random.String(8) + ".rclone-clone"
+}
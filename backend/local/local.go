@@ -191,6 +191,39 @@ when copying to a CIFS mount owned by another user. If this option is
 enabled, rclone will no longer update the modtime after copying a file.`,
 			Default:  false,
 			Advanced: true,
+		}, {
+			Name: "no_clone",
+			Help: `Disable server-side copies using file clones
+
+When copying files within the local file system rclone will clone them
+if it can. On Linux this uses a reflink on file systems which support
+it, such as btrfs and xfs, or copy_file_range to copy the data in the
+kernel, and on macOS clonefile on APFS. Cloned files share their data
+until one of them is changed so the copy is instant and takes no extra
+space, but this means they aren't a physically separate copy.
+
+Files aren't cloned when --bwlimit, --bwlimit-file or --max-transfer
+is in use, as the data doesn't pass through rclone so can't be limited
+or counted.
+
+If this option is enabled, rclone will always copy the data itself.`,
+			Default:  false,
+			Advanced: true,
+		}, {
+			Name: "change_notify",
+			Help: `Watch the file system for changes
+
+If this is set then the changes made to the local file system outside
+of rclone are read from the operating system and passed on to
+rclone mount and the other users of the VFS every --poll-interval.
+
+This needs a watch for every directory under the root, so it isn't
+enabled by default. Starting to watch a large directory tree can take
+a while and may exceed the operating system's limit on watches.
+
+This is only supported on Linux, macOS, the BSDs and Windows.`,
+			Default:  false,
+			Advanced: true,
 		}, {
 			Name:     config.ConfigEncoding,
 			Help:     config.ConfigEncodingHelp,
@@ -215,6 +248,8 @@ type Options struct {
 	NoPreAllocate     bool                 `config:"no_preallocate"`
 	NoSparse          bool                 `config:"no_sparse"`
 	NoSetModTime      bool                 `config:"no_set_modtime"`
+	NoClone           bool                 `config:"no_clone"`
+	ChangeNotify      bool                 `config:"change_notify"`
 	Enc               encoder.MultiEncoder `config:"encoding"`
 }
 
@@ -253,6 +288,9 @@ type Object struct {
 
 var errLinksAndCopyLinks = errors.New("can't use -l/--links with -L/--copy-links")
 
+// returned by cloneFile if the file system can't clone files
+var errCloneUnsupported = errors.New("file cloning not supported")
+
 // NewFs constructs an Fs from the path
 func NewFs(ctx context.Context, name, root string, m configmap.Mapper) (fs.Fs, error) {
 	// Parse config into Options struct
@@ -279,6 +317,9 @@ func NewFs(ctx context.Context, name, root string, m configmap.Mapper) (fs.Fs, e
 		IsLocal:                 true,
 		SlowHash:                true,
 	}).Fill(ctx, f)
+	if !opt.ChangeNotify {
+		f.features.ChangeNotify = nil
+	}
 	if opt.FollowSymlinks {
 		f.lstat = os.Stat
 	}
@@ -736,6 +777,81 @@ func (f *Fs) Move(ctx context.Context, src fs.Object, remote string) (fs.Object,
 	return dstObj, nil
 }
 
+// Copy src to this remote using server-side copy operations.
+//
+// This is stored with the remote path given
+//
+// It returns the destination Object and a possible error
+//
+// Will only be called if src.Fs().Name() == f.Name()
+//
+// If it isn't possible then return fs.ErrorCantCopy
+func (f *Fs) Copy(ctx context.Context, src fs.Object, remote string) (fs.Object, error) {
+	if f.opt.NoClone {
+		return nil, fs.ErrorCantCopy
+	}
+	// The data doesn't pass through rclone so can't be limited or
+	// counted towards --max-transfer
+	ci := fs.GetConfig(ctx)
+	if ci.MaxTransfer >= 0 || accounting.IsBwLimited(ctx) {
+		fs.Debugf(src, "Can't copy - --bwlimit or --max-transfer is set")
+		return nil, fs.ErrorCantCopy
+	}
+	srcObj, ok := src.(*Object)
+	if !ok {
+		fs.Debugf(src, "Can't copy - not same remote type")
+		return nil, fs.ErrorCantCopy
+	}
+	if srcObj.translatedLink {
+		fs.Debugf(src, "Can't copy - is a translated link")
+		return nil, fs.ErrorCantCopy
+	}
+
+	// Temporary Object under construction
+	dstObj := f.newObject(remote)
+	dstObj.fs.objectMetaMu.RLock()
+	dstObjMode := dstObj.mode
+	dstObj.fs.objectMetaMu.RUnlock()
+
+	// Check it is a file if it exists
+	err := dstObj.lstat()
+	if os.IsNotExist(err) {
+		// OK
+	} else if err != nil {
+		return nil, err
+	} else if !dstObj.fs.isRegular(dstObjMode) {
+		// It isn't a file
+		return nil, errors.New("can't copy file onto non-file")
+	}
+
+	// Create destination
+	err = dstObj.mkdirAll()
+	if err != nil {
+		return nil, err
+	}
+
+	// Do the copy
+	err = cloneFile(ctx, srcObj.path, dstObj.path, f.opt.FollowSymlinks)
+	if err == errCloneUnsupported {
+		fs.Debugf(src, "Can't copy: file system doesn't support cloning")
+		return nil, fs.ErrorCantCopy
+	} else if err != nil {
+		return nil, err
+	}
+
+	// Set the modification time and update the info
+	err = dstObj.SetModTime(ctx, srcObj.ModTime(ctx))
+	if err != nil {
+		return nil, err
+	}
+	err = dstObj.lstat()
+	if err != nil {
+		return nil, err
+	}
+
+	return dstObj, nil
+}
+
 // DirMove moves src, srcRemote to this remote at dstRemote
 // using server-side move operations.
 //
@@ -1340,6 +1456,7 @@ var (
 	_ fs.Purger         = &Fs{}
 	_ fs.PutStreamer    = &Fs{}
 	_ fs.Mover          = &Fs{}
+	_ fs.Copier         = &Fs{}
 	_ fs.DirMover       = &Fs{}
 	_ fs.Commander      = &Fs{}
 	_ fs.OpenWriterAter = &Fs{}
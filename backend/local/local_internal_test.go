@@ -166,3 +166,139 @@ func TestSymlinkError(t *testing.T) {
 	_, err := NewFs(context.Background(), "local", "/", m)
 	assert.Equal(t, errLinksAndCopyLinks, err)
 }
+
+func TestCopy(t *testing.T) {
+	ctx := context.Background()
+	r := fstest.NewRun(t)
+	defer r.Finalise()
+	f := r.Flocal.(*Fs)
+
+	modTime1 := fstest.Time("2001-02-03T04:05:10.123123123Z")
+	file1 := r.WriteFile("file.txt", "hello world", modTime1)
+	src, err := f.NewObject(ctx, file1.Path)
+	require.NoError(t, err)
+
+	// Copy into a new directory
+	dst, err := f.Copy(ctx, src, "dir/copy.txt")
+	if err == fs.ErrorCantCopy {
+		t.Skip("file system doesn't support cloning")
+	}
+	require.NoError(t, err)
+	file2 := fstest.NewItem("dir/copy.txt", "hello world", modTime1)
+	fstest.CheckItems(t, r.Flocal, file1, file2)
+
+	// Copy over an existing longer file
+	r.WriteFile("dir/copy.txt", "a much longer file than the source", modTime1)
+	dst, err = f.Copy(ctx, src, "dir/copy.txt")
+	require.NoError(t, err)
+	assert.Equal(t, int64(11), dst.Size())
+	fstest.CheckItems(t, r.Flocal, file1, file2)
+
+	// Can't copy onto a directory
+	_, err = f.Copy(ctx, src, "dir")
+	assert.Error(t, err)
+
+	// Disabled with --local-no-clone
+	f.opt.NoClone = true
+	defer func() {
+		f.opt.NoClone = false
+	}()
+	_, err = f.Copy(ctx, src, "dir/copy2.txt")
+	assert.Equal(t, fs.ErrorCantCopy, err)
+	f.opt.NoClone = false
+
+	// Disabled with --max-transfer
+	ctx2, ci := fs.AddConfig(ctx)
+	ci.MaxTransfer = 1024
+	_, err = f.Copy(ctx2, src, "dir/copy2.txt")
+	assert.Equal(t, fs.ErrorCantCopy, err)
+
+	// Disabled with --bwlimit-file
+	ctx2, ci = fs.AddConfig(ctx)
+	require.NoError(t, ci.BwLimitFile.Set("1M"))
+	_, err = f.Copy(ctx2, src, "dir/copy2.txt")
+	assert.Equal(t, fs.ErrorCantCopy, err)
+}
+
+func TestCloneFileFailure(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rclone-clone-test")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(dir))
+	}()
+	dst := filepath.Join(dir, "dst.txt")
+	require.NoError(t, ioutil.WriteFile(dst, []byte("existing"), 0600))
+
+	// A directory can't be cloned so this fails after making the
+	// temporary file
+	err = cloneFile(context.Background(), dir, dst, false)
+	require.Error(t, err)
+
+	// Check dst is untouched and the temporary file is gone
+	contents, err := ioutil.ReadFile(dst)
+	require.NoError(t, err)
+	assert.Equal(t, "existing", string(contents))
+	names, err := ioutil.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, names, 1)
+	assert.Equal(t, "dst.txt", names[0].Name())
+}
+
+func TestCloneFileCancel(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rclone-clone-test")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(dir))
+	}()
+	src := filepath.Join(dir, "src.txt")
+	require.NoError(t, ioutil.WriteFile(src, []byte("hello world"), 0600))
+	dst := filepath.Join(dir, "dst.txt")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = cloneFile(ctx, src, dst, false)
+	if err == nil || err == errCloneUnsupported {
+		t.Skip("file system doesn't copy the data when cloning")
+	}
+	assert.Equal(t, context.Canceled, err)
+
+	// Check dst wasn't made and the temporary file is gone
+	_, err = os.Stat(dst)
+	assert.True(t, os.IsNotExist(err))
+	names, err := ioutil.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, names, 1)
+}
+
+func TestCopySymlinkCopyLinks(t *testing.T) {
+	ctx := context.Background()
+	dir, err := ioutil.TempDir("", "rclone-clone-test")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(dir))
+	}()
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "target.txt"), []byte("hello world"), 0600))
+	require.NoError(t, os.Symlink("target.txt", filepath.Join(dir, "link.txt")))
+
+	m := configmap.Simple{
+		"copy_links": "true",
+	}
+	f, err := NewFs(ctx, "local", dir, m)
+	require.NoError(t, err)
+	src, err := f.NewObject(ctx, "link.txt")
+	require.NoError(t, err)
+
+	// The copy is of the file the link points to, not the link
+	dst, err := f.(*Fs).Copy(ctx, src, "copy.txt")
+	if err == fs.ErrorCantCopy {
+		t.Skip("file system doesn't support cloning")
+	}
+	require.NoError(t, err)
+	assert.Equal(t, int64(11), dst.Size())
+	fi, err := os.Lstat(filepath.Join(dir, "copy.txt"))
+	require.NoError(t, err)
+	assert.True(t, fi.Mode().IsRegular())
+	contents, err := ioutil.ReadFile(filepath.Join(dir, "copy.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "hello world", string(contents))
+}
@@ -0,0 +1,467 @@
+package onedrive
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/pingme998/rclone/backend/onedrive/api"
+	"github.com/pingme998/rclone/fs"
+	"github.com/pingme998/rclone/fs/config"
+	"github.com/pingme998/rclone/fs/walk"
+	"github.com/pingme998/rclone/lib/rest"
+)
+
+// errDeltaExpired is returned when the delta link can no longer be
+// used and the changes must be read from the start again
+var errDeltaExpired = errors.New("delta link expired")
+
+// readDelta reads the changes from link, or all the items in the drive
+// if link is empty, calling fn with each page of changed items.
+//
+// It returns the link to read the next changes from.
+func (f *Fs) readDelta(ctx context.Context, link string, fn func(items []api.Item) error) (deltaLink string, err error) {
+	opts := rest.Opts{
+		Method: "GET",
+		Path:   "/root/delta",
+	}
+	if link != "" {
+		opts.Path = ""
+		opts.RootURL = link
+	}
+	for {
+		var result api.ViewDeltaResponse
+		var resp *http.Response
+		err = f.pacer.Call(func() (bool, error) {
+			resp, err = f.srv.CallJSON(ctx, &opts, nil, &result)
+			return shouldRetry(ctx, resp, err)
+		})
+		if err != nil {
+			if resp != nil && resp.StatusCode == http.StatusGone {
+				return "", errDeltaExpired
+			}
+			return "", errors.Wrap(err, "couldn't read changes")
+		}
+		for i := range result.Value {
+			item := &result.Value[i]
+			item.Name = f.opt.Enc.ToStandardName(item.GetName())
+		}
+		err = fn(result.Value)
+		if err != nil {
+			return "", err
+		}
+		if result.DeltaLink != "" {
+			return result.DeltaLink, nil
+		}
+		if result.NextLink == "" {
+			return "", errors.New("no link to the next changes")
+		}
+		opts.Path = ""
+		opts.RootURL = result.NextLink
+	}
+}
+
+// latestDeltaLink returns a delta link to read changes made from now on
+func (f *Fs) latestDeltaLink(ctx context.Context) (deltaLink string, err error) {
+	opts := rest.Opts{
+		Method: "GET",
+		Path:   "/root/delta?token=latest",
+	}
+	var result api.ViewDeltaResponse
+	var resp *http.Response
+	err = f.pacer.Call(func() (bool, error) {
+		resp, err = f.srv.CallJSON(ctx, &opts, nil, &result)
+		return shouldRetry(ctx, resp, err)
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "couldn't read latest delta link")
+	}
+	if result.DeltaLink == "" {
+		return "", errors.New("no delta link returned")
+	}
+	return result.DeltaLink, nil
+}
+
+// itemKey returns the ID of the item within this drive
+//
+// This is the ID of the item itself, even if it is a remote item.
+func (f *Fs) itemKey(item *api.Item) string {
+	if strings.Contains(item.ID, "#") {
+		return item.ID
+	}
+	driveID := f.driveID
+	if item.ParentReference != nil && item.ParentReference.DriveID != "" {
+		driveID = item.ParentReference.DriveID
+	}
+	return driveID + "#" + item.ID
+}
+
+// parentKey returns the ID of the parent of item or "" if it is the
+// root of the drive
+func (f *Fs) parentKey(item *api.Item) string {
+	parent := item.ParentReference
+	if parent == nil || parent.ID == "" {
+		return ""
+	}
+	if strings.Contains(parent.ID, "#") {
+		return parent.ID
+	}
+	driveID := f.driveID
+	if parent.DriveID != "" {
+		driveID = parent.DriveID
+	}
+	return driveID + "#" + parent.ID
+}
+
+// ChangeNotify calls the passed function with a path that has had changes.
+// If the implementation uses polling, it should adhere to the given interval.
+//
+// Automatically restarts itself in case of unexpected behavior of the remote.
+//
+// Close the returned channel to stop being notified.
+func (f *Fs) ChangeNotify(ctx context.Context, notifyFunc func(string, fs.EntryType), pollIntervalChan <-chan time.Duration) {
+	go func() {
+		// get the delta link early so all changes from now on get processed
+		deltaLink, err := f.latestDeltaLink(ctx)
+		if err != nil {
+			fs.Infof(f, "Failed to get delta link: %s", err)
+		}
+		var ticker *time.Ticker
+		var tickerC <-chan time.Time
+		for {
+			select {
+			case pollInterval, ok := <-pollIntervalChan:
+				if !ok {
+					if ticker != nil {
+						ticker.Stop()
+					}
+					return
+				}
+				if ticker != nil {
+					ticker.Stop()
+					ticker, tickerC = nil, nil
+				}
+				if pollInterval != 0 {
+					ticker = time.NewTicker(pollInterval)
+					tickerC = ticker.C
+				}
+			case <-tickerC:
+				if deltaLink == "" {
+					deltaLink, err = f.latestDeltaLink(ctx)
+					if err != nil {
+						fs.Infof(f, "Failed to get delta link: %s", err)
+						continue
+					}
+				}
+				fs.Debugf(f, "Checking for changes on remote")
+				deltaLink, err = f.changeNotifyRunner(ctx, notifyFunc, deltaLink)
+				if err != nil {
+					fs.Infof(f, "Change notify listener failure: %s", err)
+				}
+			}
+		}
+	}()
+}
+
+// changeNotifyRunner reads the changes from deltaLink and passes the
+// paths of the changed items which can be found in the directory
+// cache to notifyFunc
+func (f *Fs) changeNotifyRunner(ctx context.Context, notifyFunc func(string, fs.EntryType), deltaLink string) (newDeltaLink string, err error) {
+	type entryType struct {
+		path      string
+		entryType fs.EntryType
+	}
+	return f.readDelta(ctx, deltaLink, func(items []api.Item) error {
+		var pathsToClear []entryType
+		for i := range items {
+			item := &items[i]
+			changeType := fs.EntryObject
+			if item.GetFolder() != nil {
+				changeType = fs.EntryDirectory
+			}
+
+			// find the previous path - only directories are in the cache
+			if path, ok := f.dirCache.GetInv(item.GetID()); ok {
+				pathsToClear = append(pathsToClear, entryType{path: path, entryType: fs.EntryDirectory})
+			}
+
+			// find the new path by translating the parent directory
+			if parentPath, ok := f.dirCache.GetInv(f.parentKey(item)); ok && item.Name != "" {
+				newPath := path.Join(parentPath, item.Name)
+				pathsToClear = append(pathsToClear, entryType{path: newPath, entryType: changeType})
+			}
+		}
+
+		visitedPaths := make(map[string]struct{})
+		for _, entry := range pathsToClear {
+			if _, ok := visitedPaths[entry.path]; ok {
+				continue
+			}
+			visitedPaths[entry.path] = struct{}{}
+			notifyFunc(entry.path, entry.entryType)
+		}
+		return nil
+	})
+}
+
+// deltaCache is a copy of the metadata of the items in the drive which
+// is brought up to date by reading the changes since it was last used
+// from the delta API.
+//
+// It is saved in the cache directory between runs.
+type deltaCache struct {
+	path      string               // file the cache is saved in
+	DeltaLink string               // link to read the next changes from
+	Items     map[string]*api.Item // items in the drive by itemKey
+}
+
+// deltaState holds the deltaCache while it is in use
+type deltaState struct {
+	mu    sync.Mutex
+	cache *deltaCache // nil if not loaded yet
+}
+
+// newDeltaCache makes an empty deltaCache which will be saved in path
+func newDeltaCache(path string) *deltaCache {
+	return &deltaCache{
+		path:  path,
+		Items: make(map[string]*api.Item),
+	}
+}
+
+// deltaCachePath returns the file the deltaCache for f is saved in
+func (f *Fs) deltaCachePath() string {
+	h := md5.Sum([]byte(f.name + "\x00" + f.driveID))
+	return filepath.Join(config.CacheDir, "onedrive-delta", hex.EncodeToString(h[:])+".json")
+}
+
+// loadDeltaCache reads the deltaCache from path returning an empty
+// one if it doesn't exist or can't be read
+func loadDeltaCache(path string) *deltaCache {
+	c := newDeltaCache(path)
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			fs.Debugf(nil, "Failed to read delta cache: %v", err)
+		}
+		return c
+	}
+	err = json.Unmarshal(data, c)
+	if err != nil || c.Items == nil {
+		fs.Debugf(nil, "Ignoring corrupted delta cache %q: %v", path, err)
+		return newDeltaCache(path)
+	}
+	return c
+}
+
+// save writes the deltaCache to its file
+func (c *deltaCache) save() error {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return errors.Wrap(err, "failed to encode delta cache")
+	}
+	err = os.MkdirAll(filepath.Dir(c.path), 0700)
+	if err != nil {
+		return errors.Wrap(err, "failed to make delta cache directory")
+	}
+	tmp := c.path + ".tmp"
+	err = ioutil.WriteFile(tmp, data, 0600)
+	if err != nil {
+		return errors.Wrap(err, "failed to write delta cache")
+	}
+	return os.Rename(tmp, c.path)
+}
+
+// apply the changed items to the cache
+func (c *deltaCache) apply(f *Fs, items []api.Item) {
+	for i := range items {
+		item := items[i]
+		key := f.itemKey(&item)
+		if item.Deleted != nil {
+			delete(c.Items, key)
+			continue
+		}
+		// drop the fields we don't use to keep the cache small
+		item.CreatedBy = api.IdentitySet{}
+		item.LastModifiedBy = api.IdentitySet{}
+		item.WebURL = ""
+		item.Description = ""
+		c.Items[key] = &item
+	}
+}
+
+// children returns the items in the cache indexed by their parent
+func (c *deltaCache) children(f *Fs) map[string][]*api.Item {
+	children := make(map[string][]*api.Item, len(c.Items))
+	for _, item := range c.Items {
+		parent := f.parentKey(item)
+		if parent != "" {
+			children[parent] = append(children[parent], item)
+		}
+	}
+	return children
+}
+
+// updateDeltaCache brings the deltaCache up to date with the changes
+// on the remote and saves it. Call with f.delta.mu held.
+func (f *Fs) updateDeltaCache(ctx context.Context) (err error) {
+	if f.delta.cache == nil {
+		f.delta.cache = loadDeltaCache(f.deltaCachePath())
+	}
+	c := f.delta.cache
+	if c.DeltaLink == "" {
+		fs.Infof(f, "Reading all items in the drive to start the delta cache")
+	}
+	deltaLink, err := f.readDelta(ctx, c.DeltaLink, func(items []api.Item) error {
+		c.apply(f, items)
+		return nil
+	})
+	if err == errDeltaExpired {
+		fs.Infof(f, "Delta link expired - reading all items in the drive again")
+		c = newDeltaCache(c.path)
+		f.delta.cache = c
+		deltaLink, err = f.readDelta(ctx, "", func(items []api.Item) error {
+			c.apply(f, items)
+			return nil
+		})
+	}
+	if err != nil {
+		// the changes read so far may be incomplete so start again
+		f.delta.cache = nil
+		return err
+	}
+	c.DeltaLink = deltaLink
+	err = c.save()
+	if err != nil {
+		fs.Errorf(f, "Failed to save delta cache: %v", err)
+	}
+	return nil
+}
+
+// itemToDirEntry converts the item found in dir into a DirEntry
+//
+// It returns nil if the item shouldn't be listed.
+func (f *Fs) itemToDirEntry(ctx context.Context, dir string, info *api.Item) (entry fs.DirEntry, err error) {
+	if !f.opt.ExposeOneNoteFiles && info.GetPackageType() == api.PackageTypeOneNote {
+		fs.Debugf(info.Name, "OneNote file not shown in directory listing")
+		return nil, nil
+	}
+	remote := path.Join(dir, info.GetName())
+	folder := info.GetFolder()
+	if folder != nil {
+		// cache the directory ID for later lookups
+		id := info.GetID()
+		f.dirCache.Put(remote, id)
+		d := fs.NewDir(remote, time.Time(info.GetLastModifiedDateTime())).SetID(id)
+		d.SetItems(folder.ChildCount)
+		return d, nil
+	}
+	return f.newObjectWithInfo(ctx, remote, info)
+}
+
+// listRecursive lists dir and all the directories under it without
+// using the delta cache
+func (f *Fs) listRecursive(ctx context.Context, dir string, list *walk.ListRHelper) error {
+	entries, err := f.List(ctx, dir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		err = list.Add(entry)
+		if err != nil {
+			return err
+		}
+		if d, ok := entry.(fs.Directory); ok {
+			err = f.listRecursive(ctx, d.Remote(), list)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// ListR lists the objects and directories of the Fs starting
+// from dir recursively into out.
+//
+// dir should be "" to start from the root, and should not
+// have trailing slashes.
+//
+// This should return ErrDirNotFound if the directory isn't
+// found.
+//
+// It should call callback for each tranche of entries read.
+// These need not be returned in any particular order.  If
+// callback returns an error then the listing will stop
+// immediately.
+//
+// This is only enabled with the delta option. It reads the changes
+// since the last listing and lists the items from the delta cache.
+func (f *Fs) ListR(ctx context.Context, dir string, callback fs.ListRCallback) (err error) {
+	directoryID, err := f.dirCache.FindDir(ctx, dir, false)
+	if err != nil {
+		return err
+	}
+	f.delta.mu.Lock()
+	defer f.delta.mu.Unlock()
+	err = f.updateDeltaCache(ctx)
+	if err != nil {
+		return err
+	}
+	list := walk.NewListRHelper(callback)
+	if _, found := f.delta.cache.Items[directoryID]; !found {
+		// Directories shared with us aren't in the delta so
+		// list them the normal way
+		fs.Debugf(f, "Directory %q not in delta cache - listing normally", dir)
+		err = f.listRecursive(ctx, dir, list)
+		if err != nil {
+			return err
+		}
+		return list.Flush()
+	}
+	children := f.delta.cache.children(f)
+	var walkDir func(dir, directoryID string) error
+	walkDir = func(dir, directoryID string) error {
+		for _, item := range children[directoryID] {
+			entry, err := f.itemToDirEntry(ctx, dir, item)
+			if err != nil {
+				return err
+			}
+			if entry == nil {
+				continue
+			}
+			err = list.Add(entry)
+			if err != nil {
+				return err
+			}
+			if _, ok := entry.(fs.Directory); !ok {
+				continue
+			}
+			if item.IsRemote() {
+				err = f.listRecursive(ctx, entry.Remote(), list)
+			} else {
+				err = walkDir(entry.Remote(), f.itemKey(item))
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	err = walkDir(dir, directoryID)
+	if err != nil {
+		return err
+	}
+	return list.Flush()
+}
+
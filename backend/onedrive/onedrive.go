@@ -179,6 +179,39 @@ modification time and removes all but the last version.
 this flag there.
 `,
 			Advanced: true,
+		}, {
+			Name:    "api_compression",
+			Default: false,
+			Help: `Ask the API to compress its responses.
+
+This reduces the bandwidth used when listing large directories at
+the cost of a little CPU. File contents are not affected.`,
+			Advanced: true,
+		}, {
+			Name:    "api_gzip_requests",
+			Default: false,
+			Help: `Gzip the bodies of API requests.
+
+This reduces the bandwidth used by API calls with large request
+bodies at the cost of a little CPU. File contents are not affected.
+
+The requests are sent with "Content-Encoding: gzip". If API calls
+fail with this set then turn it off.`,
+			Advanced: true,
+		}, {
+			Name:    "delta",
+			Default: false,
+			Help: `Use the delta API for recursive listings.
+
+If this is set then recursive listings (as used by --fast-list) read
+only the changes made since the last listing from the delta API and
+merge them into a copy of the drive's metadata kept in the cache
+directory. This makes repeated syncs of large drives much quicker.
+
+The first listing reads the metadata of every item in the drive,
+which may take a long time and the copy may use a lot of disk space
+for very large drives.`,
+			Advanced: true,
 		}, {
 			Name:     "link_scope",
 			Default:  "anonymous",
@@ -531,6 +564,9 @@ type Options struct {
 	ServerSideAcrossConfigs bool                 `config:"server_side_across_configs"`
 	ListChunk               int64                `config:"list_chunk"`
 	NoVersions              bool                 `config:"no_versions"`
+	APICompression          bool                 `config:"api_compression"`
+	APIGzipRequests         bool                 `config:"api_gzip_requests"`
+	Delta                   bool                 `config:"delta"`
 	LinkScope               string               `config:"link_scope"`
 	LinkType                string               `config:"link_type"`
 	LinkPassword            string               `config:"link_password"`
@@ -550,6 +586,7 @@ type Fs struct {
 	tokenRenewer *oauthutil.Renew   // renew the token on expiry
 	driveID      string             // ID to use for querying Microsoft Graph
 	driveType    string             // https://developer.microsoft.com/en-us/graph/docs/api-reference/v1.0/resources/drive
+	delta        *deltaState        // metadata of the drive kept up to date with the delta API
 }
 
 // Object describes a one drive object
@@ -770,6 +807,12 @@ func (f *Fs) setUploadChunkSize(cs fs.SizeSuffix) (old fs.SizeSuffix, err error)
 	return
 }
 
+// newSrv makes the client for the API at rootURL with the
+// compression options in opt
+func newSrv(client *http.Client, rootURL string, opt *Options) *rest.Client {
+	return rest.NewClient(client).SetRoot(rootURL).SetDecompress(opt.APICompression).SetGzipRequests(opt.APIGzipRequests)
+}
+
 // NewFs constructs an Fs from the path, container:path
 func NewFs(ctx context.Context, name, root string, m configmap.Mapper) (fs.Fs, error) {
 	// Parse config into Options struct
@@ -808,8 +851,9 @@ func NewFs(ctx context.Context, name, root string, m configmap.Mapper) (fs.Fs, e
 		ci:        ci,
 		driveID:   opt.DriveID,
 		driveType: opt.DriveType,
-		srv:       rest.NewClient(oAuthClient).SetRoot(rootURL),
+		srv:       newSrv(oAuthClient, rootURL, opt),
 		pacer:     fs.NewPacer(ctx, pacer.NewDefault(pacer.MinSleep(minSleep), pacer.MaxSleep(maxSleep), pacer.DecayConstant(decayConstant))),
+		delta:     &deltaState{},
 	}
 	f.features = (&fs.Features{
 		CaseInsensitive:         true,
@@ -817,6 +861,9 @@ func NewFs(ctx context.Context, name, root string, m configmap.Mapper) (fs.Fs, e
 		CanHaveEmptyDirectories: true,
 		ServerSideAcrossConfigs: opt.ServerSideAcrossConfigs,
 	}).Fill(ctx, f)
+	if !opt.Delta {
+		f.features.ListR = nil
+	}
 	f.srv.SetErrorHandler(errorHandler)
 
 	// Renew the token in the background
@@ -1022,27 +1069,13 @@ func (f *Fs) List(ctx context.Context, dir string) (entries fs.DirEntries, err e
 	}
 	var iErr error
 	_, err = f.listAll(ctx, directoryID, false, false, func(info *api.Item) bool {
-		if !f.opt.ExposeOneNoteFiles && info.GetPackageType() == api.PackageTypeOneNote {
-			fs.Debugf(info.Name, "OneNote file not shown in directory listing")
-			return false
+		entry, err := f.itemToDirEntry(ctx, dir, info)
+		if err != nil {
+			iErr = err
+			return true
 		}
-
-		remote := path.Join(dir, info.GetName())
-		folder := info.GetFolder()
-		if folder != nil {
-			// cache the directory ID for later lookups
-			id := info.GetID()
-			f.dirCache.Put(remote, id)
-			d := fs.NewDir(remote, time.Time(info.GetLastModifiedDateTime())).SetID(id)
-			d.SetItems(folder.ChildCount)
-			entries = append(entries, d)
-		} else {
-			o, err := f.newObjectWithInfo(ctx, remote, info)
-			if err != nil {
-				iErr = err
-				return true
-			}
-			entries = append(entries, o)
+		if entry != nil {
+			entries = append(entries, entry)
 		}
 		return false
 	})
@@ -2216,6 +2249,8 @@ var (
 	_ fs.Abouter         = (*Fs)(nil)
 	_ fs.PublicLinker    = (*Fs)(nil)
 	_ fs.CleanUpper      = (*Fs)(nil)
+	_ fs.ChangeNotifier  = (*Fs)(nil)
+	_ fs.ListRer         = (*Fs)(nil)
 	_ fs.Object          = (*Object)(nil)
 	_ fs.MimeTyper       = &Object{}
 	_ fs.IDer            = &Object{}
@@ -0,0 +1,121 @@
+package onedrive
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pingme998/rclone/backend/onedrive/api"
+	"github.com/pingme998/rclone/fs"
+	"github.com/pingme998/rclone/lib/pacer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeltaCache(t *testing.T) {
+	f := &Fs{driveID: "drive"}
+	item := func(id, parentID, name string) api.Item {
+		return api.Item{
+			ID:   id,
+			Name: name,
+			ParentReference: &api.ItemReference{
+				DriveID: "drive",
+				ID:      parentID,
+			},
+		}
+	}
+	root := item("root", "", "root")
+	dir := item("dir", "root", "dir")
+	file1 := item("file1", "dir", "file1.txt")
+	file2 := item("file2", "root", "file2.txt")
+
+	tempDir, err := ioutil.TempDir("", "rclone-onedrive-delta")
+	require.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(tempDir)
+	}()
+	c := newDeltaCache(filepath.Join(tempDir, "sub", "cache.json"))
+
+	c.apply(f, []api.Item{root, dir, file1, file2})
+	assert.Len(t, c.Items, 4)
+	assert.Equal(t, "drive#root", f.itemKey(&root))
+	assert.Equal(t, "", f.parentKey(&root))
+	assert.Equal(t, "drive#dir", f.parentKey(&file1))
+
+	// Delete a file and rename another
+	deleted := item("file2", "", "")
+	deleted.ParentReference = nil
+	deleted.Deleted = &api.DeletedFacet{}
+	renamed := file1
+	renamed.Name = "renamed.txt"
+	c.apply(f, []api.Item{deleted, renamed})
+	assert.Len(t, c.Items, 3)
+	assert.Equal(t, "renamed.txt", c.Items["drive#file1"].Name)
+
+	children := c.children(f)
+	require.Len(t, children["drive#root"], 1)
+	assert.Equal(t, "dir", children["drive#root"][0].Name)
+	require.Len(t, children["drive#dir"], 1)
+	assert.Equal(t, "renamed.txt", children["drive#dir"][0].Name)
+
+	// Check it saves and loads
+	c.DeltaLink = "https://example.com/delta?token=123"
+	require.NoError(t, c.save())
+	loaded := loadDeltaCache(c.path)
+	assert.Equal(t, c.DeltaLink, loaded.DeltaLink)
+	assert.Equal(t, len(c.Items), len(loaded.Items))
+	assert.Equal(t, "renamed.txt", loaded.Items["drive#file1"].Name)
+
+	// Check a missing cache is empty
+	loaded = loadDeltaCache(filepath.Join(tempDir, "notfound.json"))
+	assert.Equal(t, "", loaded.DeltaLink)
+	assert.Len(t, loaded.Items, 0)
+}
+
+func TestAPICompression(t *testing.T) {
+	ctx := context.Background()
+	var (
+		gotPath           string
+		gotEncoding       string
+		gotAcceptEncoding string
+		gotRequest        api.CreateItemRequest
+	)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		gotEncoding = r.Header.Get("Content-Encoding")
+		gotAcceptEncoding = r.Header.Get("Accept-Encoding")
+		zr, err := gzip.NewReader(r.Body)
+		require.NoError(t, err)
+		require.NoError(t, json.NewDecoder(zr).Decode(&gotRequest))
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Encoding", "gzip")
+		zw := gzip.NewWriter(w)
+		_, _ = zw.Write([]byte(`{"id":"newid","name":"potato"}`))
+		_ = zw.Close()
+	}))
+	defer ts.Close()
+
+	opt := &Options{
+		APICompression:  true,
+		APIGzipRequests: true,
+	}
+	f := &Fs{
+		opt:   *opt,
+		srv:   newSrv(http.DefaultClient, ts.URL, opt),
+		pacer: fs.NewPacer(ctx, pacer.NewDefault()),
+	}
+	newID, err := f.CreateDir(ctx, "dirid", "potato")
+	require.NoError(t, err)
+	assert.Equal(t, "newid", newID)
+	assert.Equal(t, "/items/dirid/children", gotPath)
+	assert.Equal(t, "gzip", gotEncoding)
+	assert.Contains(t, gotAcceptEncoding, "gzip")
+	assert.Equal(t, "potato", gotRequest.Name)
+	assert.Equal(t, "fail", gotRequest.ConflictBehavior)
+}
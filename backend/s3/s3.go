@@ -910,6 +910,20 @@ isn't set then "acl" is used instead.`,
 				Value: "arn:aws:kms:us-east-1:*",
 				Help:  "arn:aws:kms:*",
 			}},
+		}, {
+			Name: "sse_kms_key_map",
+			Help: `A comma separated list of prefix=key mappings to choose the KMS key per path.
+
+Each prefix is matched against the "bucket/path" of the object being
+uploaded or server-side copied and the KMS key of the longest matching
+prefix is used with "aws:kms" server-side encryption. Objects which
+don't match any prefix use sse_kms_key_id as normal.
+
+Eg "secure/finance=arn:aws:kms:us-east-1:111122223333:key/1234,secure=alias/secure"
+
+This can't be used with sse_customer_key.`,
+			Provider: "AWS,Ceph,Minio",
+			Advanced: true,
 		}, {
 			Name:     "sse_customer_key",
 			Help:     "If using SSE-C you must provide the secret encryption key used to encrypt/decrypt your data.",
@@ -1225,6 +1239,23 @@ very small even with this flag.
 			Help:     `If set, don't HEAD objects`,
 			Default:  false,
 			Advanced: true,
+		}, {
+			Name: "directory_markers",
+			Help: `Use zero length directory marker objects
+
+S3 has no real directories so normally empty directories are lost
+when they are copied to S3.
+
+If this is set then rclone will make a zero length object with a
+trailing "/" on its name, eg "dir/", when making a directory and
+will show these directory markers as directories when listing. This
+means empty directories survive a sync to S3 and back.
+
+Directory markers are removed when the directory is removed and when
+the directory is purged.
+`,
+			Default:  false,
+			Advanced: true,
 		}, {
 			Name:     config.ConfigEncoding,
 			Help:     config.ConfigEncodingHelp,
@@ -1287,6 +1318,9 @@ const (
 	memoryPoolFlushTime = fs.Duration(time.Minute) // flush the cached buffers after this long
 	memoryPoolUseMmap   = false
 	maxExpireDuration   = fs.Duration(7 * 24 * time.Hour) // max expiry is 1 week
+	maxDeleteObjects    = 1000                            // maximum number of keys in a DeleteObjects request
+
+	directoryMarkerMimeType = "application/x-directory" // Content-Type of directory markers
 )
 
 // Options defines the configuration for this backend
@@ -1303,6 +1337,7 @@ type Options struct {
 	RequesterPays         bool                 `config:"requester_pays"`
 	ServerSideEncryption  string               `config:"server_side_encryption"`
 	SSEKMSKeyID           string               `config:"sse_kms_key_id"`
+	SSEKMSKeyMap          fs.CommaSepList      `config:"sse_kms_key_map"`
 	SSECustomerAlgorithm  string               `config:"sse_customer_algorithm"`
 	SSECustomerKey        string               `config:"sse_customer_key"`
 	SSECustomerKeyMD5     string               `config:"sse_customer_key_md5"`
@@ -1324,6 +1359,7 @@ type Options struct {
 	NoCheckBucket         bool                 `config:"no_check_bucket"`
 	NoHead                bool                 `config:"no_head"`
 	NoHeadObject          bool                 `config:"no_head_object"`
+	DirectoryMarkers      bool                 `config:"directory_markers"`
 	Enc                   encoder.MultiEncoder `config:"encoding"`
 	MemoryPoolFlushTime   fs.Duration          `config:"memory_pool_flush_time"`
 	MemoryPoolUseMmap     bool                 `config:"memory_pool_use_mmap"`
@@ -1347,6 +1383,13 @@ type Fs struct {
 	srv           *http.Client     // a plain http client
 	pool          *pool.Pool       // memory pool
 	etagIsNotMD5  bool             // if set ETags are not MD5s
+	kmsKeyMap     []kmsKeyMapping  // KMS keys to use per path, longest prefix first
+}
+
+// kmsKeyMapping is a parsed entry from sse_kms_key_map
+type kmsKeyMapping struct {
+	prefix string // bucket/path prefix
+	keyID  string // KMS key to use for the prefix
 }
 
 // Object describes a s3 object
@@ -1649,6 +1692,13 @@ func NewFs(ctx context.Context, name, root string, m configmap.Mapper) (fs.Fs, e
 		md5sumBinary := md5.Sum([]byte(opt.SSECustomerKey))
 		opt.SSECustomerKeyMD5 = base64.StdEncoding.EncodeToString(md5sumBinary[:])
 	}
+	kmsKeyMap, err := parseKMSKeyMap(opt.SSEKMSKeyMap)
+	if err != nil {
+		return nil, err
+	}
+	if len(kmsKeyMap) > 0 && opt.SSECustomerKey != "" {
+		return nil, errors.New("sse_kms_key_map can't be used with sse_customer_key")
+	}
 	srv := getClient(ctx, opt)
 	c, ses, err := s3Connection(ctx, opt, srv)
 	if err != nil {
@@ -1673,7 +1723,8 @@ func NewFs(ctx context.Context, name, root string, m configmap.Mapper) (fs.Fs, e
 			opt.MemoryPoolUseMmap,
 		),
 	}
-	if opt.ServerSideEncryption == "aws:kms" || opt.SSECustomerAlgorithm != "" {
+	f.kmsKeyMap = kmsKeyMap
+	if opt.ServerSideEncryption == "aws:kms" || opt.SSECustomerAlgorithm != "" || len(kmsKeyMap) > 0 {
 		// From: https://docs.aws.amazon.com/AmazonS3/latest/API/RESTCommonResponseHeaders.html
 		//
 		// Objects encrypted by SSE-S3 or plaintext have ETags that are an MD5
@@ -1693,6 +1744,11 @@ func NewFs(ctx context.Context, name, root string, m configmap.Mapper) (fs.Fs, e
 		GetTier:           true,
 		SlowModTime:       true,
 	}).Fill(ctx, f)
+	if opt.DirectoryMarkers {
+		f.features.CanHaveEmptyDirectories = true
+	} else {
+		f.features.Purge = nil
+	}
 	if f.rootBucket != "" && f.rootDirectory != "" && !opt.NoHeadObject && !strings.HasSuffix(root, "/") {
 		// Check to see if the (bucket,directory) is actually an existing file
 		oldRoot := f.root
@@ -1921,6 +1977,7 @@ func (f *Fs) list(ctx context.Context, bucket, directory, prefix string, addBuck
 					continue
 				}
 			}
+			key := remote
 			remote = f.opt.Enc.ToStandardPath(remote)
 			if !strings.HasPrefix(remote, prefix) {
 				fs.Logf(f, "Odd name received %q", remote)
@@ -1933,7 +1990,16 @@ func (f *Fs) list(ctx context.Context, bucket, directory, prefix string, addBuck
 			}
 			// is this a directory marker?
 			if isDirectory && object.Size != nil && *object.Size == 0 {
-				continue // skip directory marker
+				if !f.opt.DirectoryMarkers || key == directory || strings.Trim(remote, "/") == "" {
+					continue // skip directory marker
+				}
+				// show the directory marker as a directory
+				remote = strings.TrimSuffix(remote, "/")
+				err = fn(remote, &s3.Object{Key: &remote}, true)
+				if err != nil {
+					return err
+				}
+				continue
 			}
 			err = fn(remote, object, false)
 			if err != nil {
@@ -2135,9 +2201,81 @@ func (f *Fs) bucketExists(ctx context.Context, bucket string) (bool, error) {
 }
 
 // Mkdir creates the bucket if it doesn't exist
+//
+// If directory markers are in use it also creates the marker for the
+// directory.
 func (f *Fs) Mkdir(ctx context.Context, dir string) error {
-	bucket, _ := f.split(dir)
-	return f.makeBucket(ctx, bucket)
+	bucket, directory := f.split(dir)
+	err := f.makeBucket(ctx, bucket)
+	if err != nil || !f.opt.DirectoryMarkers || bucket == "" || directory == "" {
+		return err
+	}
+	return f.putDirectoryMarker(ctx, bucket, directory)
+}
+
+// putDirectoryMarker makes the zero length directory marker object
+// for directory in bucket
+func (f *Fs) putDirectoryMarker(ctx context.Context, bucket, directory string) error {
+	key := directory + "/"
+	req := s3.PutObjectInput{
+		Bucket:        &bucket,
+		ACL:           &f.opt.ACL,
+		Key:           &key,
+		Body:          bytes.NewReader(nil),
+		ContentLength: aws.Int64(0),
+		ContentType:   aws.String(directoryMarkerMimeType),
+	}
+	if f.opt.RequesterPays {
+		req.RequestPayer = aws.String(s3.RequestPayerRequester)
+	}
+	req.ServerSideEncryption, req.SSEKMSKeyId = f.sseKMS(bucket, key)
+	if f.opt.SSECustomerAlgorithm != "" {
+		req.SSECustomerAlgorithm = &f.opt.SSECustomerAlgorithm
+	}
+	if f.opt.SSECustomerKey != "" {
+		req.SSECustomerKey = &f.opt.SSECustomerKey
+	}
+	if f.opt.SSECustomerKeyMD5 != "" {
+		req.SSECustomerKeyMD5 = &f.opt.SSECustomerKeyMD5
+	}
+	if f.opt.StorageClass != "" {
+		req.StorageClass = &f.opt.StorageClass
+	}
+	err := f.pacer.Call(func() (bool, error) {
+		_, err := f.c.PutObjectWithContext(ctx, &req)
+		return f.shouldRetry(ctx, err)
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to make directory marker %q", key)
+	}
+	return nil
+}
+
+// removeDirectoryMarker removes the directory marker for directory
+// in bucket returning an error if the directory isn't empty
+func (f *Fs) removeDirectoryMarker(ctx context.Context, bucket, directory string) error {
+	errFound := errors.New("found entry")
+	err := f.list(ctx, bucket, directory, "", false, false, func(remote string, object *s3.Object, isDirectory bool) error {
+		return errFound
+	})
+	if err == errFound {
+		return fs.ErrorDirectoryNotEmpty
+	}
+	if err != nil {
+		return err
+	}
+	key := directory + "/"
+	req := s3.DeleteObjectInput{
+		Bucket: &bucket,
+		Key:    &key,
+	}
+	if f.opt.RequesterPays {
+		req.RequestPayer = aws.String(s3.RequestPayerRequester)
+	}
+	return f.pacer.Call(func() (bool, error) {
+		_, err := f.c.DeleteObjectWithContext(ctx, &req)
+		return f.shouldRetry(ctx, err)
+	})
 }
 
 // makeBucket creates the bucket if it doesn't exist
@@ -2175,12 +2313,21 @@ func (f *Fs) makeBucket(ctx context.Context, bucket string) error {
 
 // Rmdir deletes the bucket if the fs is at the root
 //
+// If directory markers are in use it deletes the marker for the
+// directory otherwise.
+//
 // Returns an error if it isn't empty
 func (f *Fs) Rmdir(ctx context.Context, dir string) error {
 	bucket, directory := f.split(dir)
-	if bucket == "" || directory != "" {
+	if bucket == "" {
 		return nil
 	}
+	if directory != "" {
+		if !f.opt.DirectoryMarkers {
+			return nil
+		}
+		return f.removeDirectoryMarker(ctx, bucket, directory)
+	}
 	return f.cache.Remove(bucket, func() error {
 		req := s3.DeleteBucketInput{
 			Bucket: &bucket,
@@ -2196,6 +2343,84 @@ func (f *Fs) Rmdir(ctx context.Context, dir string) error {
 	})
 }
 
+// Purge deletes all the files and directories in dir along with any
+// directory markers
+//
+// This is only enabled if directory markers are in use.
+func (f *Fs) Purge(ctx context.Context, dir string) error {
+	bucket, directory := f.split(dir)
+	if bucket == "" {
+		return errors.New("can't purge from root")
+	}
+	var keys []*s3.ObjectIdentifier
+	flush := func() error {
+		if len(keys) == 0 {
+			return nil
+		}
+		err := f.deleteObjects(ctx, bucket, keys)
+		keys = keys[:0]
+		return err
+	}
+	add := func(key string) error {
+		keys = append(keys, &s3.ObjectIdentifier{Key: aws.String(key)})
+		if len(keys) >= maxDeleteObjects {
+			return flush()
+		}
+		return nil
+	}
+	// List with no prefix so the remotes returned are the keys
+	err := f.list(ctx, bucket, directory, "", false, true, func(remote string, object *s3.Object, isDirectory bool) error {
+		key := f.opt.Enc.FromStandardPath(remote)
+		if isDirectory {
+			key += "/"
+		}
+		return add(key)
+	})
+	if err == nil && directory != "" {
+		err = add(directory + "/")
+	}
+	if err == nil {
+		err = flush()
+	}
+	if err != nil {
+		return errors.Wrap(err, "purge failed")
+	}
+	if directory != "" {
+		return nil
+	}
+	return f.Rmdir(ctx, dir)
+}
+
+// deleteObjects deletes the keys from bucket in a single request
+func (f *Fs) deleteObjects(ctx context.Context, bucket string, keys []*s3.ObjectIdentifier) error {
+	req := s3.DeleteObjectsInput{
+		Bucket: &bucket,
+		Delete: &s3.Delete{
+			Objects: keys,
+			Quiet:   aws.Bool(true),
+		},
+	}
+	if f.opt.RequesterPays {
+		req.RequestPayer = aws.String(s3.RequestPayerRequester)
+	}
+	var resp *s3.DeleteObjectsOutput
+	err := f.pacer.Call(func() (bool, error) {
+		var err error
+		resp, err = f.c.DeleteObjectsWithContext(ctx, &req)
+		return f.shouldRetry(ctx, err)
+	})
+	if err != nil {
+		return err
+	}
+	for _, deleteErr := range resp.Errors {
+		fs.Errorf(f, "Failed to delete %q: %s: %s", aws.StringValue(deleteErr.Key), aws.StringValue(deleteErr.Code), aws.StringValue(deleteErr.Message))
+	}
+	if len(resp.Errors) != 0 {
+		return errors.Errorf("failed to delete %d objects", len(resp.Errors))
+	}
+	return nil
+}
+
 // Precision of the remote
 func (f *Fs) Precision() time.Duration {
 	return time.Nanosecond
@@ -2207,6 +2432,46 @@ func pathEscape(s string) string {
 	return strings.Replace(rest.URLPathEscape(s), "+", "%2B", -1)
 }
 
+// parseKMSKeyMap parses the sse_kms_key_map option returning the
+// mappings sorted longest prefix first
+func parseKMSKeyMap(list fs.CommaSepList) (kmsKeyMap []kmsKeyMapping, err error) {
+	for _, item := range list {
+		equals := strings.IndexRune(item, '=')
+		if equals < 0 {
+			return nil, errors.Errorf("sse_kms_key_map: bad entry %q - expecting prefix=key", item)
+		}
+		prefix := strings.Trim(strings.TrimSpace(item[:equals]), "/")
+		keyID := strings.TrimSpace(item[equals+1:])
+		if keyID == "" {
+			return nil, errors.Errorf("sse_kms_key_map: empty key for prefix %q", prefix)
+		}
+		kmsKeyMap = append(kmsKeyMap, kmsKeyMapping{prefix: prefix, keyID: keyID})
+	}
+	sort.SliceStable(kmsKeyMap, func(i, j int) bool {
+		return len(kmsKeyMap[i].prefix) > len(kmsKeyMap[j].prefix)
+	})
+	return kmsKeyMap, nil
+}
+
+// sseKMS returns the server-side encryption and KMS key to use when
+// writing bucket/bucketPath or nil if not set
+func (f *Fs) sseKMS(bucket, bucketPath string) (sse, keyID *string) {
+	if f.opt.ServerSideEncryption != "" {
+		sse = &f.opt.ServerSideEncryption
+	}
+	if f.opt.SSEKMSKeyID != "" {
+		keyID = &f.opt.SSEKMSKeyID
+	}
+	fullPath := path.Join(bucket, bucketPath)
+	for i := range f.kmsKeyMap {
+		mapping := &f.kmsKeyMap[i]
+		if mapping.prefix == "" || fullPath == mapping.prefix || strings.HasPrefix(fullPath, mapping.prefix+"/") {
+			return aws.String(s3.ServerSideEncryptionAwsKms), &mapping.keyID
+		}
+	}
+	return sse, keyID
+}
+
 // copy does a server-side copy
 //
 // It adds the boiler plate to the req passed in and calls the s3
@@ -2220,29 +2485,47 @@ func (f *Fs) copy(ctx context.Context, req *s3.CopyObjectInput, dstBucket, dstPa
 	if f.opt.RequesterPays {
 		req.RequestPayer = aws.String(s3.RequestPayerRequester)
 	}
-	if f.opt.ServerSideEncryption != "" {
-		req.ServerSideEncryption = &f.opt.ServerSideEncryption
-	}
+	req.ServerSideEncryption, req.SSEKMSKeyId = f.sseKMS(dstBucket, dstPath)
 	if f.opt.SSECustomerAlgorithm != "" {
 		req.SSECustomerAlgorithm = &f.opt.SSECustomerAlgorithm
-		req.CopySourceSSECustomerAlgorithm = &f.opt.SSECustomerAlgorithm
 	}
 	if f.opt.SSECustomerKey != "" {
 		req.SSECustomerKey = &f.opt.SSECustomerKey
-		req.CopySourceSSECustomerKey = &f.opt.SSECustomerKey
 	}
 	if f.opt.SSECustomerKeyMD5 != "" {
 		req.SSECustomerKeyMD5 = &f.opt.SSECustomerKeyMD5
-		req.CopySourceSSECustomerKeyMD5 = &f.opt.SSECustomerKeyMD5
 	}
-	if f.opt.SSEKMSKeyID != "" {
-		req.SSEKMSKeyId = &f.opt.SSEKMSKeyID
+	// The source may be encrypted with a different SSE-C key
+	srcOpt := &src.fs.opt
+	if srcOpt.SSECustomerAlgorithm != "" {
+		req.CopySourceSSECustomerAlgorithm = &srcOpt.SSECustomerAlgorithm
+	}
+	if srcOpt.SSECustomerKey != "" {
+		req.CopySourceSSECustomerKey = &srcOpt.SSECustomerKey
+	}
+	if srcOpt.SSECustomerKeyMD5 != "" {
+		req.CopySourceSSECustomerKeyMD5 = &srcOpt.SSECustomerKeyMD5
 	}
 	if req.StorageClass == nil && f.opt.StorageClass != "" {
 		req.StorageClass = &f.opt.StorageClass
 	}
 
-	if src.bytes >= int64(f.opt.CopyCutoff) {
+	// CopyObject copies the tags of the source unless they are
+	// replaced, but a multipart copy has to set them
+	multipart := src.bytes >= int64(f.opt.CopyCutoff)
+	if extraTags := fs.GetConfig(ctx).Tags; multipart || len(extraTags) != 0 {
+		tags, err := src.Tags(ctx)
+		if err != nil {
+			fs.Debugf(src, "Failed to read tags to copy: %v", err)
+		}
+		tags = mergeTags(tags, extraTags)
+		req.TaggingDirective = aws.String(s3.TaggingDirectiveReplace)
+		if len(tags) != 0 {
+			req.Tagging = aws.String(encodeTags(tags))
+		}
+	}
+
+	if multipart {
 		return f.copyMultipart(ctx, req, dstBucket, dstPath, srcBucket, srcPath, src)
 	}
 	return f.pacer.Call(func() (bool, error) {
@@ -2275,6 +2558,13 @@ func (f *Fs) copyMultipart(ctx context.Context, copyReq *s3.CopyObjectInput, dst
 	// Fill in the request from the head info
 	structs.SetFrom(req, info)
 
+	// Don't copy the encryption of the source - the destination
+	// encryption comes from the copyReq
+	req.ServerSideEncryption = nil
+	req.SSEKMSKeyId = nil
+	req.SSECustomerAlgorithm = nil
+	req.SSECustomerKeyMD5 = nil
+
 	// If copy metadata was set then set the Metadata to that read
 	// from the head request
 	if aws.StringValue(copyReq.MetadataDirective) == s3.MetadataDirectiveCopy {
@@ -2724,6 +3014,114 @@ func (f *Fs) CleanUp(ctx context.Context) (err error) {
 	return f.cleanUp(ctx, 24*time.Hour)
 }
 
+// GetLifecycle reads the enabled expiry rules of the bucket
+func (f *Fs) GetLifecycle(ctx context.Context) (rules []fs.LifecycleRule, err error) {
+	if f.rootBucket == "" {
+		return nil, fs.ErrorListBucketRequired
+	}
+	req := s3.GetBucketLifecycleConfigurationInput{
+		Bucket: &f.rootBucket,
+	}
+	var resp *s3.GetBucketLifecycleConfigurationOutput
+	err = f.pacer.Call(func() (bool, error) {
+		resp, err = f.c.GetBucketLifecycleConfigurationWithContext(ctx, &req)
+		return f.shouldRetry(ctx, err)
+	})
+	if awsErr, ok := err.(awserr.Error); ok && awsErr.Code() == "NoSuchLifecycleConfiguration" {
+		return []fs.LifecycleRule{}, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read lifecycle rules")
+	}
+	rules = []fs.LifecycleRule{}
+	for _, s3Rule := range resp.Rules {
+		if aws.StringValue(s3Rule.Status) != s3.ExpirationStatusEnabled {
+			continue
+		}
+		rule := fs.LifecycleRule{
+			ID:     aws.StringValue(s3Rule.ID),
+			Prefix: aws.StringValue(s3Rule.Prefix),
+		}
+		if filter := s3Rule.Filter; filter != nil {
+			if filter.Prefix != nil {
+				rule.Prefix = *filter.Prefix
+			} else if filter.And != nil {
+				rule.Prefix = aws.StringValue(filter.And.Prefix)
+			}
+		}
+		if s3Rule.Expiration != nil {
+			rule.ExpireDays = int(aws.Int64Value(s3Rule.Expiration.Days))
+		}
+		if s3Rule.NoncurrentVersionExpiration != nil {
+			rule.NoncurrentExpireDays = int(aws.Int64Value(s3Rule.NoncurrentVersionExpiration.NoncurrentDays))
+		}
+		if rule.ExpireDays == 0 && rule.NoncurrentExpireDays == 0 {
+			// not an expiry rule, eg a transition
+			continue
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// SetLifecycle replaces the expiry rules of the bucket
+//
+// This replaces all the rules of the bucket, including any which
+// GetLifecycle doesn't show
+func (f *Fs) SetLifecycle(ctx context.Context, rules []fs.LifecycleRule) (err error) {
+	if f.rootBucket == "" {
+		return fs.ErrorListBucketRequired
+	}
+	if len(rules) == 0 {
+		req := s3.DeleteBucketLifecycleInput{
+			Bucket: &f.rootBucket,
+		}
+		err = f.pacer.Call(func() (bool, error) {
+			_, err = f.c.DeleteBucketLifecycleWithContext(ctx, &req)
+			return f.shouldRetry(ctx, err)
+		})
+		if err != nil {
+			return errors.Wrap(err, "failed to delete lifecycle rules")
+		}
+		return nil
+	}
+	req := s3.PutBucketLifecycleConfigurationInput{
+		Bucket:                 &f.rootBucket,
+		LifecycleConfiguration: &s3.BucketLifecycleConfiguration{},
+	}
+	for i := range rules {
+		rule := &rules[i]
+		s3Rule := &s3.LifecycleRule{
+			Filter: &s3.LifecycleRuleFilter{
+				Prefix: aws.String(rule.Prefix),
+			},
+			Status: aws.String(s3.ExpirationStatusEnabled),
+		}
+		if rule.ID != "" {
+			s3Rule.ID = aws.String(rule.ID)
+		}
+		if rule.ExpireDays > 0 {
+			s3Rule.Expiration = &s3.LifecycleExpiration{
+				Days: aws.Int64(int64(rule.ExpireDays)),
+			}
+		}
+		if rule.NoncurrentExpireDays > 0 {
+			s3Rule.NoncurrentVersionExpiration = &s3.NoncurrentVersionExpiration{
+				NoncurrentDays: aws.Int64(int64(rule.NoncurrentExpireDays)),
+			}
+		}
+		req.LifecycleConfiguration.Rules = append(req.LifecycleConfiguration.Rules, s3Rule)
+	}
+	err = f.pacer.Call(func() (bool, error) {
+		_, err = f.c.PutBucketLifecycleConfigurationWithContext(ctx, &req)
+		return f.shouldRetry(ctx, err)
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to set lifecycle rules")
+	}
+	return nil
+}
+
 // ------------------------------------------------------------
 
 // Fs returns the parent Fs
@@ -3246,9 +3644,7 @@ func (o *Object) Update(ctx context.Context, in io.Reader, src fs.ObjectInfo, op
 	if o.fs.opt.RequesterPays {
 		req.RequestPayer = aws.String(s3.RequestPayerRequester)
 	}
-	if o.fs.opt.ServerSideEncryption != "" {
-		req.ServerSideEncryption = &o.fs.opt.ServerSideEncryption
-	}
+	req.ServerSideEncryption, req.SSEKMSKeyId = o.fs.sseKMS(bucket, bucketPath)
 	if o.fs.opt.SSECustomerAlgorithm != "" {
 		req.SSECustomerAlgorithm = &o.fs.opt.SSECustomerAlgorithm
 	}
@@ -3258,12 +3654,12 @@ func (o *Object) Update(ctx context.Context, in io.Reader, src fs.ObjectInfo, op
 	if o.fs.opt.SSECustomerKeyMD5 != "" {
 		req.SSECustomerKeyMD5 = &o.fs.opt.SSECustomerKeyMD5
 	}
-	if o.fs.opt.SSEKMSKeyID != "" {
-		req.SSEKMSKeyId = &o.fs.opt.SSEKMSKeyID
-	}
 	if o.fs.opt.StorageClass != "" {
 		req.StorageClass = &o.fs.opt.StorageClass
 	}
+	if tags := fs.GetConfig(ctx).Tags; len(tags) != 0 {
+		req.Tagging = aws.String(encodeTags(tags))
+	}
 	// Apply upload options
 	for _, option := range options {
 		key, value := option.Header()
@@ -3432,16 +3828,109 @@ func (o *Object) GetTier() string {
 	return o.storageClass
 }
 
+// Metadata returns the user metadata of the object with the keys in
+// lower case.
+//
+// This includes the metadata rclone uses to store the modification
+// time and MD5 sum. This may need a HEAD request to read it.
+func (o *Object) Metadata(ctx context.Context) (map[string]string, error) {
+	err := o.readMetaData(ctx)
+	if err != nil {
+		return nil, err
+	}
+	metadata := make(map[string]string, len(o.meta))
+	for key, value := range o.meta {
+		if value != nil {
+			metadata[strings.ToLower(key)] = *value
+		}
+	}
+	return metadata, nil
+}
+
+// Tags returns the object tags of the Object
+func (o *Object) Tags(ctx context.Context) (map[string]string, error) {
+	bucket, bucketPath := o.split()
+	req := s3.GetObjectTaggingInput{
+		Bucket: &bucket,
+		Key:    &bucketPath,
+	}
+	if o.fs.opt.RequesterPays {
+		req.RequestPayer = aws.String(s3.RequestPayerRequester)
+	}
+	var resp *s3.GetObjectTaggingOutput
+	err := o.fs.pacer.Call(func() (bool, error) {
+		var err error
+		resp, err = o.fs.c.GetObjectTaggingWithContext(ctx, &req)
+		return o.fs.shouldRetry(ctx, err)
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read tags")
+	}
+	if len(resp.TagSet) == 0 {
+		return nil, nil
+	}
+	tags := make(map[string]string, len(resp.TagSet))
+	for _, tag := range resp.TagSet {
+		tags[aws.StringValue(tag.Key)] = aws.StringValue(tag.Value)
+	}
+	return tags, nil
+}
+
+// mergeTags returns tags with extraTags added, replacing any with
+// the same key
+func mergeTags(tags, extraTags map[string]string) map[string]string {
+	if len(extraTags) == 0 {
+		return tags
+	}
+	merged := make(map[string]string, len(tags)+len(extraTags))
+	for key, value := range tags {
+		merged[key] = value
+	}
+	for key, value := range extraTags {
+		merged[key] = value
+	}
+	return merged
+}
+
+// encodeTags encodes tags as URL query parameters for the
+// x-amz-tagging header
+func encodeTags(tags map[string]string) string {
+	keys := make([]string, 0, len(tags))
+	for key := range tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var out strings.Builder
+	for i, key := range keys {
+		if i > 0 {
+			out.WriteByte('&')
+		}
+		out.WriteString(tagEscape(key))
+		out.WriteByte('=')
+		out.WriteString(tagEscape(tags[key]))
+	}
+	return out.String()
+}
+
+// tagEscape escapes s for use in the x-amz-tagging header
+func tagEscape(s string) string {
+	return strings.Replace(url.QueryEscape(s), "+", "%20", -1)
+}
+
 // Check the interfaces are satisfied
 var (
 	_ fs.Fs          = &Fs{}
 	_ fs.Copier      = &Fs{}
 	_ fs.PutStreamer = &Fs{}
 	_ fs.ListRer     = &Fs{}
+	_ fs.Purger      = &Fs{}
 	_ fs.Commander   = &Fs{}
 	_ fs.CleanUpper  = &Fs{}
+	_ fs.Lifecycler  = &Fs{}
 	_ fs.Object      = &Object{}
 	_ fs.MimeTyper   = &Object{}
 	_ fs.GetTierer   = &Object{}
 	_ fs.SetTierer   = &Object{}
+	_ fs.Metadataer  = &Object{}
+	_ fs.Tagger      = &Object{}
 )
@@ -0,0 +1,288 @@
+package s3
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/pingme998/rclone/fs"
+	"github.com/pingme998/rclone/fs/config/configmap"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseKMSKeyMap(t *testing.T) {
+	kmsKeyMap, err := parseKMSKeyMap(nil)
+	require.NoError(t, err)
+	assert.Nil(t, kmsKeyMap)
+
+	kmsKeyMap, err = parseKMSKeyMap(fs.CommaSepList{"secure=alias/secure", " /secure/finance/ = arn:finance"})
+	require.NoError(t, err)
+	assert.Equal(t, []kmsKeyMapping{
+		{prefix: "secure/finance", keyID: "arn:finance"},
+		{prefix: "secure", keyID: "alias/secure"},
+	}, kmsKeyMap)
+
+	_, err = parseKMSKeyMap(fs.CommaSepList{"secure"})
+	assert.Error(t, err)
+	_, err = parseKMSKeyMap(fs.CommaSepList{"secure="})
+	assert.Error(t, err)
+}
+
+func TestSSEKMS(t *testing.T) {
+	f := &Fs{}
+	sse, keyID := f.sseKMS("bucket", "file.txt")
+	assert.Nil(t, sse)
+	assert.Nil(t, keyID)
+
+	f.opt.ServerSideEncryption = "AES256"
+	var err error
+	f.kmsKeyMap, err = parseKMSKeyMap(fs.CommaSepList{"secure=alias/secure", "secure/finance=arn:finance"})
+	require.NoError(t, err)
+	for _, test := range []struct {
+		bucket     string
+		bucketPath string
+		wantSSE    string
+		wantKeyID  string
+	}{
+		{"bucket", "file.txt", "AES256", ""},
+		{"secure", "file.txt", "aws:kms", "alias/secure"},
+		{"secure", "finance", "aws:kms", "arn:finance"},
+		{"secure", "finance/2021/file.txt", "aws:kms", "arn:finance"},
+		{"secure", "financedept/file.txt", "aws:kms", "alias/secure"},
+		{"secured", "file.txt", "AES256", ""},
+	} {
+		sse, keyID := f.sseKMS(test.bucket, test.bucketPath)
+		what := test.bucket + "/" + test.bucketPath
+		assert.Equal(t, test.wantSSE, aws.StringValue(sse), what)
+		assert.Equal(t, test.wantKeyID, aws.StringValue(keyID), what)
+	}
+}
+
+func TestEncodeTags(t *testing.T) {
+	assert.Equal(t, "", encodeTags(nil))
+	assert.Equal(t, "a=1%262&b=x%20y", encodeTags(map[string]string{"b": "x y", "a": "1&2"}))
+	assert.Equal(t, "key%3D=", encodeTags(map[string]string{"key=": ""}))
+}
+
+func TestMergeTags(t *testing.T) {
+	tags := map[string]string{"a": "1", "b": "2"}
+	assert.Equal(t, tags, mergeTags(tags, nil))
+	assert.Equal(t, map[string]string{"a": "1", "b": "3", "c": "4"}, mergeTags(tags, map[string]string{"b": "3", "c": "4"}))
+	assert.Equal(t, map[string]string{"a": "1", "b": "2"}, tags)
+}
+
+// fakeS3 is an in memory S3 server which only implements enough of
+// the API to test directory markers in the bucket "bucket"
+type fakeS3 struct {
+	mu                 sync.Mutex
+	objects            map[string]string // object key to Content-Type
+	deleteObjectCalls  int               // number of DeleteObject calls
+	deleteObjectsCalls int               // number of DeleteObjects calls
+}
+
+func (s *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := strings.TrimPrefix(r.URL.Path, "/bucket/")
+	query := r.URL.Query()
+	switch {
+	case r.Method == "PUT":
+		_, _ = io.Copy(ioutil.Discard, r.Body)
+		s.objects[key] = r.Header.Get("Content-Type")
+		w.Header().Set("ETag", `"d41d8cd98f00b204e9800998ecf8427e"`)
+	case r.Method == "DELETE":
+		s.deleteObjectCalls++
+		delete(s.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == "POST" && query["delete"] != nil:
+		var req struct {
+			Objects []struct {
+				Key string
+			} `xml:"Object"`
+		}
+		if err := xml.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		s.deleteObjectsCalls++
+		for _, object := range req.Objects {
+			delete(s.objects, object.Key)
+		}
+		_, _ = w.Write([]byte(`<DeleteResult></DeleteResult>`))
+	case r.Method == "GET" && r.URL.Path == "/bucket":
+		s.list(w, query.Get("prefix"), query.Get("delimiter"))
+	default:
+		http.Error(w, "not implemented", http.StatusNotImplemented)
+	}
+}
+
+// list writes a ListObjects response for the objects under prefix
+func (s *fakeS3) list(w http.ResponseWriter, prefix, delimiter string) {
+	type content struct {
+		Key          string
+		Size         int64
+		LastModified string
+	}
+	type commonPrefix struct {
+		Prefix string
+	}
+	var resp struct {
+		XMLName        xml.Name       `xml:"ListBucketResult"`
+		IsTruncated    bool           `xml:"IsTruncated"`
+		Contents       []content      `xml:"Contents"`
+		CommonPrefixes []commonPrefix `xml:"CommonPrefixes"`
+	}
+	keys := make([]string, 0, len(s.objects))
+	for key := range s.objects {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	seen := map[string]bool{}
+	for _, key := range keys {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		if delimiter != "" {
+			if i := strings.Index(key[len(prefix):], delimiter); i >= 0 {
+				dir := key[:len(prefix)+i+len(delimiter)]
+				if !seen[dir] {
+					seen[dir] = true
+					resp.CommonPrefixes = append(resp.CommonPrefixes, commonPrefix{Prefix: dir})
+				}
+				continue
+			}
+		}
+		resp.Contents = append(resp.Contents, content{
+			Key:          key,
+			LastModified: "2021-01-01T00:00:00.000Z",
+		})
+	}
+	_ = xml.NewEncoder(w).Encode(&resp)
+}
+
+// newFakeS3Fs makes an Fs on "bucket" of a fakeS3 containing keys with
+// directory_markers set to directoryMarkers
+func newFakeS3Fs(t *testing.T, directoryMarkers bool, keys ...string) (f *Fs, s *fakeS3, cleanup func()) {
+	s = &fakeS3{objects: map[string]string{}}
+	for _, key := range keys {
+		s.objects[key] = ""
+	}
+	server := httptest.NewServer(s)
+	m := configmap.Simple{
+		"provider":          "Other",
+		"access_key_id":     "AKIA",
+		"secret_access_key": "secret",
+		"endpoint":          server.URL,
+		"no_check_bucket":   "true",
+		"directory_markers": fmt.Sprint(directoryMarkers),
+	}
+	fsInfo, err := fs.Find("s3")
+	require.NoError(t, err)
+	fsys, err := NewFs(context.Background(), "TestS3", "bucket", fs.ConfigMap(fsInfo, "TestS3", m))
+	require.NoError(t, err)
+	return fsys.(*Fs), s, server.Close
+}
+
+// dirMarkerEntries returns the remotes of the entries with a trailing
+// "/" on the directories
+func dirMarkerEntries(entries fs.DirEntries) (remotes []string) {
+	for _, entry := range entries {
+		remote := entry.Remote()
+		if _, ok := entry.(fs.Directory); ok {
+			remote += "/"
+		}
+		remotes = append(remotes, remote)
+	}
+	sort.Strings(remotes)
+	return remotes
+}
+
+func TestDirectoryMarkersMkdir(t *testing.T) {
+	ctx := context.Background()
+
+	f, s, cleanup := newFakeS3Fs(t, false)
+	defer cleanup()
+	require.NoError(t, f.Mkdir(ctx, "dir"))
+	assert.Empty(t, s.objects)
+
+	f, s, cleanup = newFakeS3Fs(t, true)
+	defer cleanup()
+	assert.True(t, f.Features().CanHaveEmptyDirectories)
+	require.NoError(t, f.Mkdir(ctx, ""))
+	assert.Empty(t, s.objects)
+	require.NoError(t, f.Mkdir(ctx, "dir/sub"))
+	assert.Equal(t, map[string]string{"dir/sub/": directoryMarkerMimeType}, s.objects)
+}
+
+func TestDirectoryMarkersList(t *testing.T) {
+	ctx := context.Background()
+	keys := []string{"dir/", "dir/sub/", "dir/file.txt"}
+
+	f, _, cleanup := newFakeS3Fs(t, false, keys...)
+	defer cleanup()
+	entries, err := f.List(ctx, "dir")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"dir/file.txt", "dir/sub/"}, dirMarkerEntries(entries))
+	var listed fs.DirEntries
+	require.NoError(t, f.ListR(ctx, "dir", func(entries fs.DirEntries) error {
+		listed = append(listed, entries...)
+		return nil
+	}))
+	assert.Equal(t, []string{"dir/file.txt"}, dirMarkerEntries(listed))
+
+	f, _, cleanup = newFakeS3Fs(t, true, keys...)
+	defer cleanup()
+	entries, err = f.List(ctx, "dir")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"dir/file.txt", "dir/sub/"}, dirMarkerEntries(entries))
+	listed = nil
+	require.NoError(t, f.ListR(ctx, "dir", func(entries fs.DirEntries) error {
+		listed = append(listed, entries...)
+		return nil
+	}))
+	assert.Equal(t, []string{"dir/file.txt", "dir/sub/"}, dirMarkerEntries(listed))
+}
+
+func TestDirectoryMarkersRmdir(t *testing.T) {
+	ctx := context.Background()
+
+	f, s, cleanup := newFakeS3Fs(t, false, "dir/")
+	defer cleanup()
+	require.NoError(t, f.Rmdir(ctx, "dir"))
+	assert.Contains(t, s.objects, "dir/")
+
+	f, s, cleanup = newFakeS3Fs(t, true, "dir/", "dir/sub/", "dir/sub/file.txt")
+	defer cleanup()
+	assert.Equal(t, fs.ErrorDirectoryNotEmpty, f.Rmdir(ctx, "dir/sub"))
+	assert.Equal(t, fs.ErrorDirectoryNotEmpty, f.Rmdir(ctx, "dir"))
+	delete(s.objects, "dir/sub/file.txt")
+	require.NoError(t, f.Rmdir(ctx, "dir/sub"))
+	require.NoError(t, f.Rmdir(ctx, "dir"))
+	assert.Empty(t, s.objects)
+}
+
+func TestDirectoryMarkersPurge(t *testing.T) {
+	ctx := context.Background()
+
+	f, s, cleanup := newFakeS3Fs(t, false)
+	defer cleanup()
+	assert.Nil(t, f.Features().Purge)
+
+	f, s, cleanup = newFakeS3Fs(t, true, "dir/", "dir/file.txt", "dir/sub/", "dir/sub/file.txt", "other/", "other.txt")
+	defer cleanup()
+	require.NotNil(t, f.Features().Purge)
+	require.NoError(t, f.Purge(ctx, "dir"))
+	assert.Equal(t, map[string]string{"other/": "", "other.txt": ""}, s.objects)
+	assert.Equal(t, 1, s.deleteObjectsCalls)
+	assert.Equal(t, 0, s.deleteObjectCalls)
+}
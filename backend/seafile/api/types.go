@@ -139,8 +139,13 @@ type ShareLinkRequest struct {
 
 // SharedLink contains the information returned by a call to shared link creation
 type SharedLink struct {
-	Link      string `json:"link"`
-	IsExpired bool   `json:"is_expired"`
+	Link       string `json:"link"`
+	IsExpired  bool   `json:"is_expired"`
+	Token      string `json:"token,omitempty"`
+	Path       string `json:"path,omitempty"`
+	IsDir      bool   `json:"is_dir,omitempty"`
+	ExpireDate string `json:"expire_date,omitempty"`
+	ViewCount  int    `json:"view_cnt,omitempty"`
 }
 
 // BatchSourceDestRequest contains JSON parameters for sending a batch copy or move operation
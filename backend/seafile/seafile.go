@@ -23,6 +23,7 @@ import (
 	"github.com/pingme998/rclone/fs/fserrors"
 	"github.com/pingme998/rclone/fs/fshttp"
 	"github.com/pingme998/rclone/fs/hash"
+	"github.com/pingme998/rclone/fs/operations"
 	"github.com/pingme998/rclone/lib/bucket"
 	"github.com/pingme998/rclone/lib/cache"
 	"github.com/pingme998/rclone/lib/encoder"
@@ -58,6 +59,7 @@ func init() {
 		Description: "seafile",
 		NewFs:       NewFs,
 		Config:      Config,
+		CommandHelp: commandHelp,
 		Options: []fs.Option{{
 			Name:     configURL,
 			Help:     "URL of seafile host to connect to",
@@ -998,6 +1000,181 @@ func (f *Fs) PublicLink(ctx context.Context, remote string, expire fs.Duration,
 	return shareLink.Link, nil
 }
 
+// ==================== Optional Interface fs.Commander ====================
+
+var commandHelp = []fs.CommandHelp{{
+	Name:  "libraries",
+	Short: "List the libraries available to this account",
+	Long: `This lists the libraries available to the user with their ID,
+owner, size and whether they are encrypted.
+
+Usage Example:
+
+    rclone backend libraries seafile:
+    rclone rc backend/command command=libraries fs=seafile:
+`,
+}, {
+	Name:  "mklib",
+	Short: "Create a library",
+	Long: `This creates a library with the name given. If a password is given
+with "-o password" the library will be encrypted with it.
+
+It returns an error if the library already exists.
+
+Usage Examples:
+
+    rclone backend mklib seafile: library
+    rclone backend mklib seafile: library -o password=secret
+    rclone rc backend/command command=mklib fs=seafile: library -o password=secret
+`,
+	Opts: map[string]string{
+		"password": "encrypt the library with this password",
+	},
+}, {
+	Name:  "rmlib",
+	Short: "Delete a library and all its contents",
+	Long: `This deletes the library with the name given along with all of its
+contents. Depending on the server setup the library may be recoverable
+from the trash for a while.
+
+Usage Example:
+
+    rclone backend rmlib seafile: library
+    rclone rc backend/command command=rmlib fs=seafile: library
+`,
+}, {
+	Name:  "passwd",
+	Short: "Change the password of an encrypted library",
+	Long: `This changes the password of the encrypted library given. If no
+library is given then the library configured in the remote is used.
+
+Usage Examples:
+
+    rclone backend passwd seafile: library -o old=secret -o new=newsecret
+    rclone backend passwd seafile-library: -o old=secret -o new=newsecret
+    rclone rc backend/command command=passwd fs=seafile: library -o old=secret -o new=newsecret
+
+Note that if the password is stored in the config of the remote as
+"library_key" it will need to be updated there too.
+`,
+	Opts: map[string]string{
+		"old": "the current password of the library",
+		"new": "the new password for the library",
+	},
+}, {
+	Name:  "links",
+	Short: "List the share links of a file or directory",
+	Long: `This lists the share links of the paths given which should be
+relative to the remote. If no paths are given then the share links of
+the root of the remote are listed.
+
+Usage Examples:
+
+    rclone backend links seafile: library/path/to/file
+    rclone backend links seafile-library: path/to/dir path/to/file
+    rclone rc backend/command command=links fs=seafile: library/path
+`,
+}}
+
+// Command the backend to run a named command
+//
+// The command run is name
+// args may be used to read arguments from
+// opts may be used to read optional arguments from
+//
+// The result should be capable of being JSON encoded
+// If it is a string or a []string it will be shown to the user
+// otherwise it will be JSON encoded and shown to the user like that
+func (f *Fs) Command(ctx context.Context, name string, arg []string, opt map[string]string) (out interface{}, err error) {
+	switch name {
+	case "libraries":
+		return f.getLibraries(ctx)
+	case "mklib":
+		if len(arg) != 1 {
+			return nil, errors.New("need exactly 1 argument: the library name")
+		}
+		exists, err := f.libraryExists(ctx, arg[0])
+		if err != nil {
+			return nil, err
+		}
+		if exists {
+			return nil, errors.Errorf("library %q already exists", arg[0])
+		}
+		if operations.SkipDestructive(ctx, arg[0], "create library") {
+			return nil, nil
+		}
+		return nil, f.mkLibrary(ctx, arg[0], opt["password"])
+	case "rmlib":
+		if len(arg) != 1 {
+			return nil, errors.New("need exactly 1 argument: the library name")
+		}
+		libraryID, err := f.getLibraryID(ctx, arg[0])
+		if err != nil {
+			return nil, err
+		}
+		if operations.SkipDestructive(ctx, arg[0], "delete library") {
+			return nil, nil
+		}
+		err = f.deleteLibrary(ctx, libraryID)
+		if err != nil {
+			return nil, err
+		}
+		f.librariesMutex.Lock()
+		f.libraries.Delete(librariesCacheKey)
+		f.librariesMutex.Unlock()
+		return nil, nil
+	case "passwd":
+		libraryName := f.libraryName
+		if len(arg) > 1 {
+			return nil, errors.New("need at most 1 argument: the library name")
+		} else if len(arg) == 1 {
+			libraryName = arg[0]
+		}
+		if libraryName == "" {
+			return nil, errors.New("need a library name")
+		}
+		oldPassword, ok := opt["old"]
+		if !ok {
+			return nil, errors.New("need the current password as -o old=password")
+		}
+		newPassword, ok := opt["new"]
+		if !ok || newPassword == "" {
+			return nil, errors.New("need the new password as -o new=password")
+		}
+		libraryID, err := f.getLibraryID(ctx, libraryName)
+		if err != nil {
+			return nil, err
+		}
+		if operations.SkipDestructive(ctx, libraryName, "change library password") {
+			return nil, nil
+		}
+		return nil, f.changeLibraryPassword(ctx, libraryID, oldPassword, newPassword)
+	case "links":
+		if len(arg) == 0 {
+			arg = []string{""}
+		}
+		links := make(map[string][]api.SharedLink, len(arg))
+		for _, remote := range arg {
+			libraryName, filePath := f.splitPath(remote)
+			if libraryName == "" {
+				return nil, errors.New("need a path inside a library")
+			}
+			libraryID, err := f.getLibraryID(ctx, libraryName)
+			if err != nil {
+				return nil, err
+			}
+			shareLinks, err := f.listShareLinks(ctx, libraryID, filePath)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to list share links of %q", remote)
+			}
+			links[remote] = shareLinks
+		}
+		return links, nil
+	default:
+		return nil, fs.ErrorCommandNotFound
+	}
+}
+
 func (f *Fs) listLibraries(ctx context.Context) (entries fs.DirEntries, err error) {
 	libraries, err := f.getCachedLibraries(ctx)
 	if err != nil {
@@ -1318,6 +1495,7 @@ var (
 	_ fs.Fs           = &Fs{}
 	_ fs.Abouter      = &Fs{}
 	_ fs.CleanUpper   = &Fs{}
+	_ fs.Commander    = &Fs{}
 	_ fs.Copier       = &Fs{}
 	_ fs.Mover        = &Fs{}
 	_ fs.DirMover     = &Fs{}
@@ -1,10 +1,21 @@
 package seafile
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"path"
+	"strings"
+	"sync"
 	"testing"
 
+	"github.com/pingme998/rclone/backend/seafile/api"
+	"github.com/pingme998/rclone/fs"
+	"github.com/pingme998/rclone/fs/config/configmap"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 type pathData struct {
@@ -121,3 +132,215 @@ func TestSplitPathIntoSlice(t *testing.T) {
 		assert.Equal(t, expected, output)
 	}
 }
+
+// fakeSeafile is a Seafile server which only knows enough of the API
+// to manage libraries
+type fakeSeafile struct {
+	mu        sync.Mutex
+	libraries []api.Library
+	passwords map[string]string // passwords of the encrypted libraries by ID
+	changes   []string          // method and path of the requests changing libraries
+	status    int               // if set returned for the requests changing libraries
+}
+
+// newFakeSeafile starts a fakeSeafile with the libraries given and
+// returns an Fs using it
+func newFakeSeafile(t *testing.T, libraries ...api.Library) (*fakeSeafile, *Fs) {
+	s := &fakeSeafile{
+		libraries: libraries,
+		passwords: map[string]string{},
+	}
+	for _, library := range libraries {
+		if library.Encrypted {
+			s.passwords[library.ID] = "secret"
+		}
+	}
+	server := httptest.NewServer(s)
+	t.Cleanup(server.Close)
+	f, err := NewFs(context.Background(), "TestSeafile", "", configmap.Simple{
+		"url":        server.URL,
+		"auth_token": "token",
+	})
+	require.NoError(t, err)
+	return s, f.(*Fs)
+}
+
+// ServeHTTP answers the requests for the server info and libraries
+func (s *fakeSeafile) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	reply := func(v interface{}) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(v)
+	}
+	if r.URL.Path == "/api2/server-info/" {
+		reply(api.ServerInfo{Version: "7.0.0"})
+		return
+	}
+	if r.Header.Get("Authorization") != "Token token" {
+		http.Error(w, "bad token", http.StatusUnauthorized)
+		return
+	}
+	if r.Method != "GET" {
+		s.changes = append(s.changes, r.Method+" "+r.URL.Path)
+		if s.status != 0 {
+			http.Error(w, "injected error", s.status)
+			return
+		}
+	}
+	switch {
+	case r.Method == "GET" && r.URL.Path == "/api2/repos/":
+		reply(s.libraries)
+	case r.Method == "POST" && r.URL.Path == "/api2/repos/":
+		var request api.CreateLibraryRequest
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		library := api.Library{
+			ID:        fmt.Sprintf("id%d", len(s.libraries)+1),
+			Name:      request.Name,
+			Encrypted: request.Password != "",
+		}
+		if library.Encrypted {
+			s.passwords[library.ID] = request.Password
+		}
+		s.libraries = append(s.libraries, library)
+		reply(api.CreateLibrary{ID: library.ID, Name: library.Name})
+	case r.Method == "DELETE" && strings.HasPrefix(r.URL.Path, "/api2/repos/"):
+		id := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api2/repos/"), "/")
+		for i, library := range s.libraries {
+			if library.ID == id {
+				s.libraries = append(s.libraries[:i], s.libraries[i+1:]...)
+				reply("success")
+				return
+			}
+		}
+		http.NotFound(w, r)
+	case r.Method == "PUT" && strings.HasSuffix(r.URL.Path, "/set-password/"):
+		id := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/v2.1/repos/"), "/set-password/")
+		password, encrypted := s.passwords[id]
+		if !encrypted || r.FormValue("old_password") != password {
+			http.Error(w, "bad password", http.StatusBadRequest)
+			return
+		}
+		s.passwords[id] = r.FormValue("new_password")
+		reply(map[string]bool{"success": true})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// names returns the names of the libraries on the server
+func (s *fakeSeafile) names() (names []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, library := range s.libraries {
+		names = append(names, library.Name)
+	}
+	return names
+}
+
+// dryRun returns a context with --dry-run set
+func dryRun() context.Context {
+	ctx, ci := fs.AddConfig(context.Background())
+	ci.DryRun = true
+	return ctx
+}
+
+func TestCommandMkLib(t *testing.T) {
+	ctx := context.Background()
+	s, f := newFakeSeafile(t, api.Library{ID: "id1", Name: "existing"})
+
+	_, err := f.Command(ctx, "mklib", nil, nil)
+	assert.EqualError(t, err, "need exactly 1 argument: the library name")
+	_, err = f.Command(ctx, "mklib", []string{"existing"}, nil)
+	assert.EqualError(t, err, `library "existing" already exists`)
+
+	_, err = f.Command(dryRun(), "mklib", []string{"new"}, nil)
+	require.NoError(t, err)
+	assert.Empty(t, s.changes)
+
+	_, err = f.Command(ctx, "mklib", []string{"new"}, map[string]string{"password": "secret"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"POST /api2/repos/"}, s.changes)
+	assert.Equal(t, []string{"existing", "new"}, s.names())
+	assert.Equal(t, "secret", s.passwords["id2"])
+
+	s.status = http.StatusForbidden
+	_, err = f.Command(ctx, "mklib", []string{"other"}, nil)
+	assert.Equal(t, fs.ErrorPermissionDenied, err)
+}
+
+func TestCommandRmLib(t *testing.T) {
+	ctx := context.Background()
+	s, f := newFakeSeafile(t, api.Library{ID: "id1", Name: "one"}, api.Library{ID: "id2", Name: "two"})
+
+	_, err := f.Command(ctx, "rmlib", nil, nil)
+	assert.EqualError(t, err, "need exactly 1 argument: the library name")
+	_, err = f.Command(ctx, "rmlib", []string{"missing"}, nil)
+	assert.EqualError(t, err, "cannot find library 'missing'")
+
+	_, err = f.Command(dryRun(), "rmlib", []string{"one"}, nil)
+	require.NoError(t, err)
+	assert.Empty(t, s.changes)
+	assert.Equal(t, []string{"one", "two"}, s.names())
+
+	_, err = f.Command(ctx, "rmlib", []string{"one"}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"DELETE /api2/repos/id1/"}, s.changes)
+	assert.Equal(t, []string{"two"}, s.names())
+
+	// The cached libraries are refreshed
+	exists, err := f.libraryExists(ctx, "one")
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	s.status = http.StatusForbidden
+	_, err = f.Command(ctx, "rmlib", []string{"two"}, nil)
+	assert.Equal(t, fs.ErrorPermissionDenied, err)
+	assert.Equal(t, []string{"two"}, s.names())
+}
+
+func TestCommandPasswd(t *testing.T) {
+	ctx := context.Background()
+	s, f := newFakeSeafile(t, api.Library{ID: "id1", Name: "plain"}, api.Library{ID: "id2", Name: "secure", Encrypted: true})
+	passwords := map[string]string{"old": "secret", "new": "newsecret"}
+
+	_, err := f.Command(ctx, "passwd", nil, passwords)
+	assert.EqualError(t, err, "need a library name")
+	_, err = f.Command(ctx, "passwd", []string{"one", "two"}, passwords)
+	assert.EqualError(t, err, "need at most 1 argument: the library name")
+	_, err = f.Command(ctx, "passwd", []string{"secure"}, map[string]string{"new": "newsecret"})
+	assert.EqualError(t, err, "need the current password as -o old=password")
+	_, err = f.Command(ctx, "passwd", []string{"secure"}, map[string]string{"old": "secret"})
+	assert.EqualError(t, err, "need the new password as -o new=password")
+	_, err = f.Command(ctx, "passwd", []string{"missing"}, passwords)
+	assert.EqualError(t, err, "cannot find library 'missing'")
+
+	_, err = f.Command(dryRun(), "passwd", []string{"secure"}, passwords)
+	require.NoError(t, err)
+	assert.Empty(t, s.changes)
+	assert.Equal(t, "secret", s.passwords["id2"])
+
+	_, err = f.Command(ctx, "passwd", []string{"secure"}, passwords)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"PUT /api/v2.1/repos/id2/set-password/"}, s.changes)
+	assert.Equal(t, "newsecret", s.passwords["id2"])
+
+	// The old password is now wrong
+	_, err = f.Command(ctx, "passwd", []string{"secure"}, passwords)
+	assert.EqualError(t, err, "incorrect password or library not encrypted")
+	_, err = f.Command(ctx, "passwd", []string{"plain"}, passwords)
+	assert.EqualError(t, err, "incorrect password or library not encrypted")
+
+	// The library in the config is used if none is given
+	f.libraryName = "secure"
+	_, err = f.Command(ctx, "passwd", nil, map[string]string{"old": "newsecret", "new": "secret"})
+	require.NoError(t, err)
+	assert.Equal(t, "secret", s.passwords["id2"])
+
+	s.status = http.StatusForbidden
+	_, err = f.Command(ctx, "passwd", []string{"secure"}, passwords)
+	assert.Equal(t, fs.ErrorPermissionDenied, err)
+}
@@ -245,6 +245,44 @@ func (f *Fs) decryptLibrary(ctx context.Context, libraryID, password string) err
 	return nil
 }
 
+func (f *Fs) changeLibraryPassword(ctx context.Context, libraryID, oldPassword, newPassword string) error {
+	// API Documentation
+	// https://download.seafile.com/published/web-api/v2.1/library-encryption.md#user-content-Change%20Library%20Password
+	if libraryID == "" {
+		return errors.New("cannot change the password without a library")
+	}
+	// This is another call that cannot accept a JSON input so we have to build it manually
+	params := url.Values{
+		"old_password": {oldPassword},
+		"new_password": {newPassword},
+	}
+	opts := rest.Opts{
+		Method:      "PUT",
+		Path:        "api/v2.1/repos/" + libraryID + "/set-password/",
+		ContentType: "application/x-www-form-urlencoded",
+		Body:        bytes.NewBuffer([]byte(params.Encode())),
+		NoResponse:  true,
+	}
+	var resp *http.Response
+	var err error
+	err = f.pacer.Call(func() (bool, error) {
+		resp, err = f.srv.Call(ctx, &opts)
+		return f.shouldRetry(ctx, resp, err)
+	})
+	if err != nil {
+		if resp != nil {
+			if resp.StatusCode == 401 || resp.StatusCode == 403 {
+				return fs.ErrorPermissionDenied
+			}
+			if resp.StatusCode == 400 {
+				return errors.New("incorrect password or library not encrypted")
+			}
+		}
+		return errors.Wrap(err, "failed to change library password")
+	}
+	return nil
+}
+
 func (f *Fs) getDirectoryEntriesAPIv21(ctx context.Context, libraryID, dirPath string, recursive bool) ([]api.DirEntry, error) {
 	// API Documentation
 	// https://download.seafile.com/published/web-api/v2.1/directories.md#user-content-List%20Items%20in%20Directory
@@ -7,6 +7,7 @@ package sftp
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -34,6 +35,7 @@ import (
 	"github.com/pingme998/rclone/lib/readers"
 	sshagent "github.com/xanzy/ssh-agent"
 	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
 	"golang.org/x/crypto/ssh/knownhosts"
 )
 
@@ -48,6 +50,21 @@ var (
 	currentUser = env.CurrentUser()
 )
 
+type agentContextKeyType struct{}
+
+// Context key for the ssh-agent
+var agentContextKey = agentContextKeyType{}
+
+// ContextWithAgent returns a copy of ctx which makes the sftp
+// backends created with it use ag instead of the ssh-agent found from
+// SSH_AUTH_SOCK.
+//
+// This is used by rclone serve sftp to authenticate to the upstream
+// server with the agent forwarded by the client.
+func ContextWithAgent(ctx context.Context, ag agent.Agent) context.Context {
+	return context.WithValue(ctx, agentContextKey, ag)
+}
+
 func init() {
 	fsi := &fs.RegInfo{
 		Name:        "sftp",
@@ -246,6 +263,35 @@ If no connections have been returned to the connection pool in the time
 given, rclone will empty the connection pool.
 
 Set to 0 to keep connections indefinitely.
+`,
+			Advanced: true,
+		}, {
+			Name:    "max_sessions_per_connection",
+			Default: 8,
+			Help: `Max number of SFTP sessions to share one SSH connection
+
+Rclone shares SSH connections between the SFTP sessions it makes to
+the same host as the same user, so parallel transfers don't each need
+their own TCP connection and SSH handshake. A new SSH connection is
+opened when all the existing ones have this many sessions.
+
+Most SSH servers limit the sessions on a connection (MaxSessions in
+sshd_config which defaults to 10) and rclone needs a spare one to run
+commands for checksums, so don't set this above that limit minus one.
+
+Set to 1 to use a separate SSH connection for every session.
+`,
+			Advanced: true,
+		}, {
+			Name:    "keepalive",
+			Default: fs.Duration(60 * time.Second),
+			Help: `Interval between SSH keepalives
+
+Rclone sends keepalive requests on its SSH connections at this
+interval. If one isn't answered within the interval the connection is
+closed and rclone will open a new one when it is next needed.
+
+Set to 0 to disable keepalives.
 `,
 			Advanced: true,
 		}},
@@ -279,6 +325,8 @@ type Options struct {
 	DisableConcurrentReads  bool        `config:"disable_concurrent_reads"`
 	DisableConcurrentWrites bool        `config:"disable_concurrent_writes"`
 	IdleTimeout             fs.Duration `config:"idle_timeout"`
+	MaxSessions             int         `config:"max_sessions_per_connection"`
+	Keepalive               fs.Duration `config:"keepalive"`
 }
 
 // Fs stores the interface to the remote SFTP files
@@ -299,7 +347,8 @@ type Fs struct {
 	drain        *time.Timer // used to drain the pool when we stop using the connections
 	pacer        *fs.Pacer   // pacer for operations
 	savedpswd    string
-	transfers    int32 // count in use references
+	transfers    int32  // count in use references
+	sshPoolKey   string // key to share SSH connections under - "" if not shared
 }
 
 // Object is a remote SFTP file that has been stat'd (so it exists, but is not necessarily open for reading)
@@ -331,21 +380,17 @@ func (f *Fs) dial(ctx context.Context, network, addr string, sshConfig *ssh.Clie
 }
 
 // conn encapsulates an ssh client and corresponding sftp client
+//
+// The ssh client may be shared with other conns
 type conn struct {
-	sshClient  *ssh.Client
+	sshClient  *sshClient
 	sftpClient *sftp.Client
-	err        chan error
-}
-
-// Wait for connection to close
-func (c *conn) wait() {
-	c.err <- c.sshClient.Conn.Wait()
 }
 
 // Closes the connection
 func (c *conn) close() error {
 	sftpErr := c.sftpClient.Close()
-	sshErr := c.sshClient.Close()
+	sshErr := c.sshClient.release()
 	if sftpErr != nil {
 		return sftpErr
 	}
@@ -354,12 +399,7 @@ func (c *conn) close() error {
 
 // Returns an error if closed
 func (c *conn) closed() error {
-	select {
-	case err := <-c.err:
-		return err
-	default:
-	}
-	return nil
+	return c.sshClient.closed()
 }
 
 // Show that we are doing an upload or download
@@ -382,19 +422,21 @@ func (f *Fs) getTransfers() int32 {
 // Open a new connection to the SFTP server.
 func (f *Fs) sftpConnection(ctx context.Context) (c *conn, err error) {
 	// Rate limit rate of new connections
-	c = &conn{
-		err: make(chan error, 1),
-	}
-	c.sshClient, err = f.dial(ctx, "tcp", f.opt.Host+":"+f.opt.Port, f.config)
+	c = &conn{}
+	c.sshClient, err = sharedSSHPool.get(f.sshPoolKey, f.opt.MaxSessions, time.Duration(f.opt.Keepalive), func() (*ssh.Client, error) {
+		return f.dial(ctx, "tcp", f.opt.Host+":"+f.opt.Port, f.config)
+	})
 	if err != nil {
 		return nil, errors.Wrap(err, "couldn't connect SSH")
 	}
-	c.sftpClient, err = f.newSftpClient(c.sshClient)
+	c.sftpClient, err = f.newSftpClient(c.sshClient.Client)
 	if err != nil {
-		_ = c.sshClient.Close()
+		// the server may not allow any more sessions on
+		// this connection so don't try to open them
+		c.sshClient.markBroken()
+		_ = c.sshClient.release()
 		return nil, errors.Wrap(err, "couldn't initialise SFTP")
 	}
-	go c.wait()
 	return c, nil
 }
 
@@ -448,6 +490,7 @@ func (f *Fs) getSftpConnection(ctx context.Context) (c *conn, err error) {
 			break
 		}
 		fs.Errorf(f, "Discarding closed SSH connection: %v", err)
+		_ = c.close()
 		c = nil
 	}
 	f.poolMu.Unlock()
@@ -491,6 +534,7 @@ func (f *Fs) putSftpConnection(pc **conn, err error) {
 			_, nopErr := c.sftpClient.Getwd()
 			if nopErr != nil {
 				fs.Debugf(f, "Connection failed, closing: %v", nopErr)
+				c.sshClient.markBroken()
 				_ = c.close()
 				return
 			}
@@ -528,6 +572,8 @@ func (f *Fs) drainPool(ctx context.Context) (err error) {
 			if cErr != nil {
 				err = cErr
 			}
+		} else {
+			_ = c.close()
 		}
 		f.pool[i] = nil
 	}
@@ -584,9 +630,12 @@ func NewFs(ctx context.Context, name, root string, m configmap.Mapper) (fs.Fs, e
 	//keyPem := env.ShellExpand(opt.KeyPem)
 	// Add ssh agent-auth if no password or file or key PEM specified
 	if (opt.Pass == "" && keyFile == "" && !opt.AskPassword && opt.KeyPem == "") || opt.KeyUseAgent {
-		sshAgentClient, _, err := sshagent.New()
-		if err != nil {
-			return nil, errors.Wrap(err, "couldn't connect to ssh-agent")
+		sshAgentClient, ok := ctx.Value(agentContextKey).(agent.Agent)
+		if !ok {
+			sshAgentClient, _, err = sshagent.New()
+			if err != nil {
+				return nil, errors.Wrap(err, "couldn't connect to ssh-agent")
+			}
 		}
 		signers, err := sshAgentClient.Signers()
 		if err != nil {
@@ -710,9 +759,37 @@ func NewFs(ctx context.Context, name, root string, m configmap.Mapper) (fs.Fs, e
 		)
 	}
 
+	// Don't share SSH connections made with an agent from the
+	// context as it belongs to the client of rclone serve sftp
+	if _, ok := ctx.Value(agentContextKey).(agent.Agent); !ok {
+		f.sshPoolKey = sshPoolKey(opt)
+	}
+
 	return NewFsWithConnection(ctx, f, name, root, m, opt, sshConfig)
 }
 
+// sshPoolKey returns the key to share SSH connections under.
+//
+// Connections are only shared between remotes which connect to the
+// same server as the same user and authenticate the same way.
+func sshPoolKey(opt *Options) string {
+	auth := sha256.New()
+	for _, item := range []string{
+		opt.Pass,
+		opt.KeyPem,
+		opt.KeyFile,
+		opt.KeyFilePass,
+		opt.PubKeyFile,
+		opt.KnownHostsFile,
+		strconv.FormatBool(opt.KeyUseAgent),
+		strconv.FormatBool(opt.UseInsecureCipher),
+		strconv.FormatBool(opt.AskPassword),
+	} {
+		_, _ = fmt.Fprintf(auth, "%q\n", item)
+	}
+	return fmt.Sprintf("%s@%s:%s/%x", opt.User, opt.Host, opt.Port, auth.Sum(nil))
+}
+
 // Do the keyboard interactive challenge
 //
 // Just send the password back for all questions
@@ -4,6 +4,7 @@ package sftp
 
 import (
 	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -49,3 +50,33 @@ func TestParseUsage(t *testing.T) {
 		assert.Equal(t, test.usage, [3]int64{gotSpaceTotal, gotSpaceUsed, gotSpaceAvail}, fmt.Sprintf("Test %d sshOutput = %q", i, test.sshOutput))
 	}
 }
+
+func TestSSHPoolKey(t *testing.T) {
+	opt := &Options{
+		User:    "user",
+		Host:    "example.com",
+		Port:    "22",
+		KeyFile: "~/.ssh/id_rsa",
+	}
+	key := sshPoolKey(opt)
+	assert.True(t, strings.HasPrefix(key, "user@example.com:22/"), key)
+
+	// Same login so should be shared
+	same := *opt
+	same.Subsystem = "other"
+	assert.Equal(t, key, sshPoolKey(&same))
+
+	// Different logins shouldn't be shared
+	for _, change := range []func(o *Options){
+		func(o *Options) { o.User = "other" },
+		func(o *Options) { o.Host = "other.example.com" },
+		func(o *Options) { o.Port = "2222" },
+		func(o *Options) { o.KeyFile = "" },
+		func(o *Options) { o.Pass = "pass" },
+		func(o *Options) { o.KeyUseAgent = true },
+	} {
+		different := *opt
+		change(&different)
+		assert.NotEqual(t, key, sshPoolKey(&different))
+	}
+}
@@ -0,0 +1,195 @@
+// +build !plan9
+
+package sftp
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/pingme998/rclone/fs"
+	"golang.org/x/crypto/ssh"
+)
+
+// sshPool shares SSH connections between the SFTP sessions made to
+// the same server so parallel transfers don't each need their own
+// TCP connection and SSH handshake.
+type sshPool struct {
+	mu      sync.Mutex
+	clients map[string][]*sshClient // open connections by pool key
+}
+
+// sshClient is an SSH connection which may be shared by several SFTP
+// sessions
+type sshClient struct {
+	*ssh.Client
+	pool     *sshPool
+	key      string        // pool key - "" if not shared
+	sessions int           // sessions using this connection - protected by pool.mu
+	broken   bool          // set if no new sessions should be opened - protected by pool.mu
+	done     chan struct{} // closed when the connection has shut down
+	err      error         // why the connection shut down - read after done is closed
+}
+
+// connection pool shared by all the sftp remotes
+var sharedSSHPool = newSSHPool()
+
+// newSSHPool makes a new empty sshPool
+func newSSHPool() *sshPool {
+	return &sshPool{
+		clients: make(map[string][]*sshClient),
+	}
+}
+
+// get returns a connection for key which has room for another
+// session, calling dial to open a new connection if there isn't one.
+//
+// A connection will be used for at most maxSessions sessions. If key
+// is "" then the connection won't be shared.
+//
+// If keepalive is set then keepalives are sent on new connections at
+// that interval and the connection is closed if they fail.
+//
+// Call release when the session is finished with.
+func (p *sshPool) get(key string, maxSessions int, keepalive time.Duration, dial func() (*ssh.Client, error)) (*sshClient, error) {
+	if key != "" {
+		p.mu.Lock()
+		for _, c := range p.clients[key] {
+			if !c.broken && c.sessions < maxSessions {
+				c.sessions++
+				p.mu.Unlock()
+				return c, nil
+			}
+		}
+		p.mu.Unlock()
+	}
+	client, err := dial()
+	if err != nil {
+		return nil, err
+	}
+	c := &sshClient{
+		Client:   client,
+		pool:     p,
+		key:      key,
+		sessions: 1,
+		done:     make(chan struct{}),
+	}
+	go c.wait()
+	if keepalive > 0 {
+		go c.keepalive(keepalive)
+	}
+	if key != "" {
+		p.mu.Lock()
+		p.clients[key] = append(p.clients[key], c)
+		p.mu.Unlock()
+	}
+	return c, nil
+}
+
+// remove c from the pool - call with p.mu held
+func (p *sshPool) remove(c *sshClient) {
+	clients := p.clients[c.key]
+	for i := range clients {
+		if clients[i] == c {
+			clients = append(clients[:i], clients[i+1:]...)
+			break
+		}
+	}
+	if len(clients) == 0 {
+		delete(p.clients, c.key)
+	} else {
+		p.clients[c.key] = clients
+	}
+}
+
+// Wait for the connection to shut down and stop it being used for new
+// sessions when it does
+func (c *sshClient) wait() {
+	c.err = c.Client.Wait()
+	close(c.done)
+	c.markBroken()
+}
+
+// keepalive sends keepalive requests on the connection every
+// interval, closing it if one fails or isn't answered in time.
+func (c *sshClient) keepalive(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-ticker.C:
+		}
+		errc := make(chan error, 1)
+		go func() {
+			// the reply doesn't matter, only that there is one
+			_, _, err := c.SendRequest("keepalive@openssh.com", true, nil)
+			errc <- err
+		}()
+		var err error
+		select {
+		case <-c.done:
+			return
+		case err = <-errc:
+		case <-time.After(interval):
+			err = errors.New("no reply")
+		}
+		if err != nil {
+			fs.Debugf(nil, "SSH keepalive to %s failed, closing connection: %v", c.RemoteAddr(), err)
+			c.markBroken()
+			_ = c.Client.Close()
+			return
+		}
+	}
+}
+
+// closed returns an error if the connection has shut down
+func (c *sshClient) closed() error {
+	select {
+	case <-c.done:
+		if c.err == nil {
+			return errors.New("SSH connection closed")
+		}
+		return c.err
+	default:
+	}
+	return nil
+}
+
+// markBroken stops new sessions being opened on the connection.
+//
+// It will be closed once the sessions using it are released and new
+// sessions will open a new connection.
+func (c *sshClient) markBroken() {
+	c.pool.mu.Lock()
+	defer c.pool.mu.Unlock()
+	if !c.broken {
+		c.broken = true
+		if c.key != "" {
+			c.pool.remove(c)
+		}
+	}
+}
+
+// release the session, closing the connection if it was the last one
+// using it
+func (c *sshClient) release() error {
+	c.pool.mu.Lock()
+	c.sessions--
+	last := c.sessions <= 0
+	if last && !c.broken {
+		c.broken = true
+		if c.key != "" {
+			c.pool.remove(c)
+		}
+	}
+	c.pool.mu.Unlock()
+	if !last {
+		return nil
+	}
+	if c.closed() != nil {
+		return nil
+	}
+	return c.Client.Close()
+}
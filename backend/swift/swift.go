@@ -5,6 +5,7 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/url"
@@ -38,6 +39,8 @@ const (
 	listChunks                 = 1000                    // chunk size to read directory listings
 	defaultChunkSize           = 5 * fs.Gibi
 	minSleep                   = 10 * time.Millisecond // In case of error, start at 10ms sleep.
+	largeObjectDLO             = "dlo"                 // dynamic large objects
+	largeObjectSLO             = "slo"                 // static large objects
 )
 
 // SharedOptions are shared between swift and hubic
@@ -63,6 +66,50 @@ Rclone will still chunk files bigger than chunk_size when doing normal
 copy operations.`,
 	Default:  false,
 	Advanced: true,
+}, {
+	Name: "large_object_type",
+	Help: `Type of large object to make when uploading chunked files.
+
+Dynamic Large Objects (DLO) find their segments by listing a prefix in
+the segments container. Static Large Objects (SLO) store an explicit
+list of their segments in the manifest so don't depend on the
+consistency of container listings, but not all clusters support them
+and they are limited in the number of segments they can have (1000 by
+default).
+
+This can be changed while rclone is running with the "set" backend
+command.`,
+	Default:  largeObjectDLO,
+	Advanced: true,
+	Examples: []fs.OptionExample{{
+		Value: largeObjectDLO,
+		Help:  "Dynamic Large Objects",
+	}, {
+		Value: largeObjectSLO,
+		Help:  "Static Large Objects",
+	}},
+}, {
+	Name: "segments_container",
+	Help: `Container to upload the segments of chunked files to.
+
+If this is empty the segments are uploaded to a container named after
+the destination container with "_segments" appended, eg "files" uses
+"files_segments".
+
+If this is set all the segments are uploaded to this container with
+the name of the destination container added to their paths.`,
+	Default:  "",
+	Advanced: true,
+}, {
+	Name: "verify_segment_cleanup",
+	Help: `Check the segments of large objects are gone after deleting them.
+
+When a large object is removed or overwritten rclone deletes its
+segments. If this is set rclone lists the segments container
+afterwards, tries again to delete any segments which are left and
+returns an error if any remain.`,
+	Default:  false,
+	Advanced: true,
 }, {
 	Name:     config.ConfigEncoding,
 	Help:     config.ConfigEncodingHelp,
@@ -77,6 +124,7 @@ func init() {
 		Name:        "swift",
 		Description: "OpenStack Swift (Rackspace Cloud Files, Memset Memstore, OVH)",
 		NewFs:       NewFs,
+		CommandHelp: commandHelp,
 		Options: append([]fs.Option{{
 			Name:    "env_auth",
 			Help:    "Get swift credentials from environment variables in standard OpenStack form.",
@@ -220,6 +268,9 @@ type Options struct {
 	EndpointType                string               `config:"endpoint_type"`
 	ChunkSize                   fs.SizeSuffix        `config:"chunk_size"`
 	NoChunk                     bool                 `config:"no_chunk"`
+	LargeObjectType             string               `config:"large_object_type"`
+	SegmentsContainer           string               `config:"segments_container"`
+	VerifySegmentCleanup        bool                 `config:"verify_segment_cleanup"`
 	Enc                         encoder.MultiEncoder `config:"encoding"`
 }
 
@@ -426,6 +477,14 @@ func checkUploadChunkSize(cs fs.SizeSuffix) error {
 	return nil
 }
 
+func checkLargeObjectType(largeObjectType string) error {
+	switch largeObjectType {
+	case largeObjectDLO, largeObjectSLO:
+		return nil
+	}
+	return errors.Errorf("unknown large object type %q - must be %q or %q", largeObjectType, largeObjectDLO, largeObjectSLO)
+}
+
 func (f *Fs) setUploadChunkSize(cs fs.SizeSuffix) (old fs.SizeSuffix, err error) {
 	err = checkUploadChunkSize(cs)
 	if err == nil {
@@ -499,6 +558,10 @@ func NewFs(ctx context.Context, name, root string, m configmap.Mapper) (fs.Fs, e
 	if err != nil {
 		return nil, errors.Wrap(err, "swift: chunk size")
 	}
+	err = checkLargeObjectType(opt.LargeObjectType)
+	if err != nil {
+		return nil, errors.Wrap(err, "swift: large object type")
+	}
 
 	c, err := swiftConnection(ctx, opt, name)
 	if err != nil {
@@ -928,7 +991,8 @@ func (f *Fs) Copy(ctx context.Context, src fs.Object, remote string) (fs.Object,
 }
 
 func copyLargeObject(ctx context.Context, f *Fs, src *Object, dstContainer string, dstPath string) error {
-	segmentsContainer := dstContainer + "_segments"
+	segmentsContainer := f.segmentsContainer(dstContainer)
+	segmentsPath := f.segmentsPath(dstContainer, dstPath)
 	err := f.makeContainer(ctx, segmentsContainer)
 	if err != nil {
 		return err
@@ -980,7 +1044,7 @@ func copyLargeObject(ctx context.Context, f *Fs, src *Object, dstContainer strin
 			} else {
 				lastIndex = lastIndex + 1
 			}
-			segmentName := dstPath + "/" + prefixSegment + "/" + s[lastIndex:]
+			segmentName := segmentsPath + "/" + prefixSegment + "/" + s[lastIndex:]
 			err = f.pacer.Call(func() (bool, error) {
 				var rxHeaders swift.Headers
 				rxHeaders, err = f.c.ObjectCopy(ctx, c, s, segmentsContainer, segmentName, nil)
@@ -994,7 +1058,7 @@ func copyLargeObject(ctx context.Context, f *Fs, src *Object, dstContainer strin
 	}
 	m := swift.Metadata{}
 	headers := m.ObjectHeaders()
-	headers["X-Object-Manifest"] = urlEncode(fmt.Sprintf("%s/%s/%s", segmentsContainer, dstPath, prefixSegment))
+	headers["X-Object-Manifest"] = urlEncode(fmt.Sprintf("%s/%s/%s", segmentsContainer, segmentsPath, prefixSegment))
 	headers["Content-Length"] = "0"
 	emptyReader := bytes.NewReader(nil)
 	err = f.pacer.Call(func() (bool, error) {
@@ -1030,6 +1094,103 @@ func (f *Fs) Hashes() hash.Set {
 	return hash.Set(hash.MD5)
 }
 
+var commandHelp = []fs.CommandHelp{{
+	Name:  "get",
+	Short: "Get command for fetching the large object config parameters",
+	Long: `This is a get command which will be used to fetch the large object
+config parameters
+
+Usage Examples:
+
+    rclone backend get swift: [-o large_object_type] [-o segments_container] [-o verify_segment_cleanup]
+    rclone rc backend/command command=get fs=swift: [-o large_object_type] [-o segments_container] [-o verify_segment_cleanup]
+`,
+	Opts: map[string]string{
+		"large_object_type":      "show the current large object type",
+		"segments_container":     "show the current segments container",
+		"verify_segment_cleanup": "show whether segment cleanup is verified",
+	},
+}, {
+	Name:  "set",
+	Short: "Set command for updating the large object config parameters",
+	Long: `This is a set command which will be used to update the large object
+config parameters for the uploads which follow it.
+
+This means the large object strategy can be chosen per upload when
+rclone is running as a daemon, eg with "rclone rcd" or "rclone mount".
+
+Usage Examples:
+
+    rclone backend set swift: [-o large_object_type=slo] [-o segments_container=segments] [-o verify_segment_cleanup=true]
+    rclone rc backend/command command=set fs=swift: [-o large_object_type=slo] [-o segments_container=segments] [-o verify_segment_cleanup=true]
+`,
+	Opts: map[string]string{
+		"large_object_type":      "update the large object type - dlo or slo",
+		"segments_container":     "update the segments container - empty for the default",
+		"verify_segment_cleanup": "update whether segment cleanup is verified - true or false",
+	},
+}}
+
+// Command the backend to run a named command
+//
+// The command run is name
+// args may be used to read arguments from
+// opts may be used to read optional arguments from
+//
+// The result should be capable of being JSON encoded
+// If it is a string or a []string it will be shown to the user
+// otherwise it will be JSON encoded and shown to the user like that
+func (f *Fs) Command(ctx context.Context, name string, arg []string, opt map[string]string) (out interface{}, err error) {
+	switch name {
+	case "get":
+		out := make(map[string]string)
+		if _, ok := opt["large_object_type"]; ok {
+			out["large_object_type"] = f.opt.LargeObjectType
+		}
+		if _, ok := opt["segments_container"]; ok {
+			out["segments_container"] = f.opt.SegmentsContainer
+		}
+		if _, ok := opt["verify_segment_cleanup"]; ok {
+			out["verify_segment_cleanup"] = strconv.FormatBool(f.opt.VerifySegmentCleanup)
+		}
+		return out, nil
+	case "set":
+		out := make(map[string]map[string]string)
+		if largeObjectType, ok := opt["large_object_type"]; ok {
+			largeObjectType = strings.ToLower(largeObjectType)
+			if err = checkLargeObjectType(largeObjectType); err != nil {
+				return out, err
+			}
+			out["large_object_type"] = map[string]string{
+				"previous": f.opt.LargeObjectType,
+				"current":  largeObjectType,
+			}
+			f.opt.LargeObjectType = largeObjectType
+		}
+		if segmentsContainer, ok := opt["segments_container"]; ok {
+			out["segments_container"] = map[string]string{
+				"previous": f.opt.SegmentsContainer,
+				"current":  segmentsContainer,
+			}
+			f.opt.SegmentsContainer = segmentsContainer
+		}
+		if verify, ok := opt["verify_segment_cleanup"]; ok {
+			verifySegmentCleanup, err := strconv.ParseBool(verify)
+			if err != nil {
+				return out, errors.Wrap(err, "bad verify_segment_cleanup")
+			}
+			out["verify_segment_cleanup"] = map[string]string{
+				"previous": strconv.FormatBool(f.opt.VerifySegmentCleanup),
+				"current":  strconv.FormatBool(verifySegmentCleanup),
+			}
+			f.opt.VerifySegmentCleanup = verifySegmentCleanup
+		}
+		return out, nil
+	default:
+		return nil, fs.ErrorCommandNotFound
+	}
+}
+
 // ------------------------------------------------------------
 
 // Fs returns the parent Fs
@@ -1275,6 +1436,69 @@ func (o *Object) removeSegmentsLargeObject(ctx context.Context, containerSegment
 			return err
 		}
 	}
+	if o.fs.opt.VerifySegmentCleanup {
+		return o.verifySegmentsRemoved(ctx, containerSegments)
+	}
+	return nil
+}
+
+// commonPrefix returns the longest prefix shared by all the names
+func commonPrefix(names []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+	prefix := names[0]
+	for _, name := range names[1:] {
+		i := 0
+		for i < len(prefix) && i < len(name) && prefix[i] == name[i] {
+			i++
+		}
+		prefix = prefix[:i]
+	}
+	return prefix
+}
+
+// verifySegmentsRemoved checks that the segments in containerSegments
+// have gone, trying again to delete any which are left
+func (o *Object) verifySegmentsRemoved(ctx context.Context, containerSegments map[string][]string) error {
+	remaining := 0
+	for container, segments := range containerSegments {
+		wanted := make(map[string]struct{}, len(segments))
+		for _, segment := range segments {
+			wanted[segment] = struct{}{}
+		}
+		var names []string
+		err := o.fs.pacer.Call(func() (bool, error) {
+			var err error
+			names, err = o.fs.c.ObjectNamesAll(ctx, container, &swift.ObjectsOpts{
+				Prefix: commonPrefix(segments),
+			})
+			return shouldRetry(ctx, err)
+		})
+		if err == swift.ContainerNotFound {
+			continue
+		}
+		if err != nil {
+			return errors.Wrapf(err, "failed to list segments container %q", container)
+		}
+		for _, name := range names {
+			if _, ok := wanted[name]; !ok {
+				continue
+			}
+			fs.Debugf(o, "Segment %q in %q wasn't removed - trying again", name, container)
+			err = o.fs.pacer.Call(func() (bool, error) {
+				err := o.fs.c.ObjectDelete(ctx, container, name)
+				return shouldRetry(ctx, err)
+			})
+			if err != nil && err != swift.ObjectNotFound {
+				fs.Errorf(o, "Failed to remove segment %q in %q: %v", name, container, err)
+				remaining++
+			}
+		}
+	}
+	if remaining > 0 {
+		return errors.Errorf("%d segments couldn't be removed", remaining)
+	}
 	return nil
 }
 
@@ -1312,11 +1536,71 @@ func urlEncode(str string) string {
 	return buf.String()
 }
 
+// segmentsContainer returns the name of the container to put the
+// segments of large objects in container into
+func (f *Fs) segmentsContainer(container string) string {
+	if f.opt.SegmentsContainer != "" {
+		return f.opt.SegmentsContainer
+	}
+	return container + "_segments"
+}
+
+// segmentsPath returns the path in the segments container to put the
+// segments of containerPath under
+//
+// If all the segments share a container then the container name is
+// included so objects with the same path in different containers
+// don't clash.
+func (f *Fs) segmentsPath(container, containerPath string) string {
+	if f.opt.SegmentsContainer != "" {
+		return path.Join(container, containerPath)
+	}
+	return containerPath
+}
+
+// sloSegment is an entry in a static large object manifest
+type sloSegment struct {
+	Path      string `json:"path"`
+	Etag      string `json:"etag"`
+	SizeBytes int64  `json:"size_bytes"`
+}
+
+// putSLOManifest uploads the manifest for a static large object made
+// from segments
+func (o *Object) putSLOManifest(ctx context.Context, segments []sloSegment, headers swift.Headers, contentType string) error {
+	container, containerPath := o.split()
+	manifest, err := json.Marshal(segments)
+	if err != nil {
+		return errors.Wrap(err, "failed to make static large object manifest")
+	}
+	headers["Content-Type"] = contentType
+	headers["Content-Length"] = strconv.Itoa(len(manifest))
+	return o.fs.pacer.Call(func() (bool, error) {
+		_, rxHeaders, err := o.fs.c.Call(ctx, o.fs.c.StorageUrl, swift.RequestOpts{
+			Container:  container,
+			ObjectName: containerPath,
+			Operation:  "PUT",
+			Parameters: url.Values{"multipart-manifest": {"put"}},
+			Headers:    headers,
+			Body:       bytes.NewReader(manifest),
+			NoResponse: true,
+			OnReAuth: func() (string, error) {
+				return o.fs.c.StorageUrl, nil
+			},
+		})
+		return shouldRetryHeaders(ctx, rxHeaders, err)
+	})
+}
+
 // updateChunks updates the existing object using chunks to a separate
 // container.  It returns a string which prefixes current segments.
+//
+// The manifest is a dynamic or static large object depending on the
+// large_object_type option.
 func (o *Object) updateChunks(ctx context.Context, in0 io.Reader, headers swift.Headers, size int64, contentType string) (string, error) {
 	container, containerPath := o.split()
-	segmentsContainer := container + "_segments"
+	segmentsContainer := o.fs.segmentsContainer(container)
+	static := o.fs.opt.LargeObjectType == largeObjectSLO
 	// Create the segmentsContainer if it doesn't exist
 	var err error
 	err = o.fs.pacer.Call(func() (bool, error) {
@@ -1341,9 +1625,10 @@ func (o *Object) updateChunks(ctx context.Context, in0 io.Reader, headers swift.
 	left := size
 	i := 0
 	uniquePrefix := fmt.Sprintf("%s/%d", swift.TimeToFloatString(time.Now()), size)
-	segmentsPath := path.Join(containerPath, uniquePrefix)
+	segmentsPath := path.Join(o.fs.segmentsPath(container, containerPath), uniquePrefix)
 	in := bufio.NewReader(in0)
 	segmentInfos := make([]string, 0, (size/int64(o.fs.opt.ChunkSize))+1)
+	var sloSegments []sloSegment
 	defer atexit.OnError(&err, func() {
 		if o.fs.opt.LeavePartsOnError {
 			return
@@ -1370,11 +1655,11 @@ func (o *Object) updateChunks(ctx context.Context, in0 io.Reader, headers swift.
 			headers["Content-Length"] = strconv.FormatInt(n, 10) // set Content-Length as we know it
 			left -= n
 		}
-		segmentReader := io.LimitReader(in, n)
+		segmentReader := readers.NewCountingReader(io.LimitReader(in, n))
 		segmentPath := fmt.Sprintf("%s/%08d", segmentsPath, i)
 		fs.Debugf(o, "Uploading segment file %q into %q", segmentPath, segmentsContainer)
+		var rxHeaders swift.Headers
 		err = o.fs.pacer.CallNoRetry(func() (bool, error) {
-			var rxHeaders swift.Headers
 			rxHeaders, err = o.fs.c.ObjectPut(ctx, segmentsContainer, segmentPath, segmentReader, true, "", "", headers)
 			if err == nil {
 				segmentInfos = append(segmentInfos, segmentPath)
@@ -1384,17 +1669,28 @@ func (o *Object) updateChunks(ctx context.Context, in0 io.Reader, headers swift.
 		if err != nil {
 			return "", err
 		}
+		if static {
+			sloSegments = append(sloSegments, sloSegment{
+				Path:      "/" + segmentsContainer + "/" + segmentPath,
+				Etag:      rxHeaders["Etag"],
+				SizeBytes: int64(segmentReader.BytesRead()),
+			})
+		}
 		i++
 	}
 	// Upload the manifest
-	headers["X-Object-Manifest"] = urlEncode(fmt.Sprintf("%s/%s", segmentsContainer, segmentsPath))
-	headers["Content-Length"] = "0" // set Content-Length as we know it
-	emptyReader := bytes.NewReader(nil)
-	err = o.fs.pacer.Call(func() (bool, error) {
-		var rxHeaders swift.Headers
-		rxHeaders, err = o.fs.c.ObjectPut(ctx, container, containerPath, emptyReader, true, "", contentType, headers)
-		return shouldRetryHeaders(ctx, rxHeaders, err)
-	})
+	if static {
+		err = o.putSLOManifest(ctx, sloSegments, headers, contentType)
+	} else {
+		headers["X-Object-Manifest"] = urlEncode(fmt.Sprintf("%s/%s", segmentsContainer, segmentsPath))
+		headers["Content-Length"] = "0" // set Content-Length as we know it
+		emptyReader := bytes.NewReader(nil)
+		err = o.fs.pacer.Call(func() (bool, error) {
+			var rxHeaders swift.Headers
+			rxHeaders, err = o.fs.c.ObjectPut(ctx, container, containerPath, emptyReader, true, "", contentType, headers)
+			return shouldRetryHeaders(ctx, rxHeaders, err)
+		})
+	}
 
 	if err == nil {
 		//reset data
@@ -1554,6 +1850,7 @@ var (
 	_ fs.PutStreamer = &Fs{}
 	_ fs.Copier      = &Fs{}
 	_ fs.ListRer     = &Fs{}
+	_ fs.Commander   = &Fs{}
 	_ fs.Object      = &Object{}
 	_ fs.MimeTyper   = &Object{}
 )
@@ -66,3 +66,18 @@ func TestInternalShouldRetryHeaders(t *testing.T) {
 	assert.True(t, dt >= time.Hour-time.Second && dt <= time.Hour+time.Second)
 
 }
+
+func TestInternalCommonPrefix(t *testing.T) {
+	for _, test := range []struct {
+		in   []string
+		want string
+	}{
+		{nil, ""},
+		{[]string{"a/b/00000000"}, "a/b/00000000"},
+		{[]string{"a/b/00000000", "a/b/00000001", "a/b/00000002"}, "a/b/0000000"},
+		{[]string{"a/b/00000009", "a/b/00000010"}, "a/b/000000"},
+		{[]string{"a/b/1", "c/d/1"}, ""},
+	} {
+		assert.Equal(t, test.want, commonPrefix(test.in), test.in)
+	}
+}
@@ -79,6 +79,7 @@ func (f *Fs) testNoChunk(t *testing.T) {
 func (f *Fs) InternalTest(t *testing.T) {
 	t.Run("NoChunk", f.testNoChunk)
 	t.Run("WithChunk", f.testWithChunk)
+	t.Run("WithChunkSLO", f.testWithChunkSLO)
 	t.Run("WithChunkFail", f.testWithChunkFail)
 	t.Run("CopyLargeObject", f.testCopyLargeObject)
 }
@@ -113,6 +114,60 @@ func (f *Fs) testWithChunk(t *testing.T) {
 	require.NotEmpty(t, obj)
 }
 
+func (f *Fs) testWithChunkSLO(t *testing.T) {
+	preConfChunkSize := f.opt.ChunkSize
+	preConfLargeObjectType := f.opt.LargeObjectType
+	preConfVerify := f.opt.VerifySegmentCleanup
+	f.opt.ChunkSize = 1024 * fs.SizeSuffixBase
+	f.opt.LargeObjectType = largeObjectSLO
+	f.opt.VerifySegmentCleanup = true
+	defer func() {
+		//restore old config after test
+		f.opt.ChunkSize = preConfChunkSize
+		f.opt.LargeObjectType = preConfLargeObjectType
+		f.opt.VerifySegmentCleanup = preConfVerify
+	}()
+
+	const contentSize = 2500
+	contents := random.String(contentSize)
+	file := fstest.Item{
+		ModTime: fstest.Time("2021-03-04T04:05:06.499999999Z"),
+		Path:    "slo chunk.txt",
+		Size:    contentSize,
+	}
+	obji := object.NewStaticObjectInfo(file.Path, file.ModTime, file.Size, true, nil, nil)
+	ctx := context.TODO()
+	obj, err := f.Put(ctx, bytes.NewBufferString(contents), obji)
+	require.NoError(t, err)
+
+	o := obj.(*Object)
+	isStatic, err := o.isStaticLargeObject(ctx)
+	require.NoError(t, err)
+	assert.True(t, isStatic)
+	assert.Equal(t, int64(contentSize), o.Size())
+	segments, err := o.getSegmentsLargeObject(ctx)
+	require.NoError(t, err)
+	for _, names := range segments {
+		assert.Len(t, names, 3)
+	}
+
+	in, err := o.Open(ctx)
+	require.NoError(t, err)
+	got, err := ioutil.ReadAll(in)
+	require.NoError(t, err)
+	require.NoError(t, in.Close())
+	assert.Equal(t, contents, string(got))
+
+	// Remove it and check the segments have gone
+	require.NoError(t, o.Remove(ctx))
+	for container, names := range segments {
+		for _, name := range names {
+			_, _, err = f.c.Object(ctx, container, name)
+			assert.Equal(t, swift.ObjectNotFound, err)
+		}
+	}
+}
+
 func (f *Fs) testWithChunkFail(t *testing.T) {
 	preConfChunkSize := f.opt.ChunkSize
 	preConfChunk := f.opt.NoChunk
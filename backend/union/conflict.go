@@ -0,0 +1,254 @@
+package union
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/pingme998/rclone/backend/union/upstream"
+	"github.com/pingme998/rclone/fs"
+	"github.com/pingme998/rclone/fs/hash"
+)
+
+// Conflict policies
+const (
+	conflictHide   = "hide"   // show only the copy chosen by the search policy
+	conflictSuffix = "suffix" // show the other copies with an upstream suffix
+)
+
+// matches the suffix added to conflicting copies, eg "file.txt.upstream2"
+var conflictRe = regexp.MustCompile(`^(.+)\.upstream(\d+)$`)
+
+// upstreamNumber returns the 1 based number of the upstream u used in
+// the names of conflicting copies or 0 if not found
+func (f *Fs) upstreamNumber(u *upstream.Fs) int {
+	for i := range f.upstreams {
+		if f.upstreams[i] == u {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// conflictName returns the name a conflicting copy of remote on the
+// upstream u is listed as
+func (f *Fs) conflictName(remote string, u *upstream.Fs) string {
+	return fmt.Sprintf("%s.upstream%d", remote, f.upstreamNumber(u))
+}
+
+// parseConflictName splits a name made by conflictName into the
+// remote and the upstream. It returns a nil upstream if remote isn't
+// the name of a conflicting copy.
+func (f *Fs) parseConflictName(remote string) (string, *upstream.Fs) {
+	match := conflictRe.FindStringSubmatch(remote)
+	if match == nil {
+		return "", nil
+	}
+	n, err := strconv.Atoi(match[2])
+	if err != nil || n < 1 || n > len(f.upstreams) {
+		return "", nil
+	}
+	return match[1], f.upstreams[n-1]
+}
+
+// differ returns true if the objects a and b on different upstreams
+// have different contents.
+//
+// The sizes are compared first, then a common hash if there is one
+// which is cheap to read, and otherwise the modification times.
+func differ(ctx context.Context, a, b *upstream.Object) bool {
+	if a.Size() != b.Size() {
+		return true
+	}
+	ua, ub := a.UpstreamFs(), b.UpstreamFs()
+	if !ua.Features().SlowHash && !ub.Features().SlowHash {
+		if ht := ua.Hashes().Overlap(ub.Hashes()).GetOne(); ht != hash.None {
+			hashA, errA := a.Hash(ctx, ht)
+			hashB, errB := b.Hash(ctx, ht)
+			if errA == nil && errB == nil && hashA != "" && hashB != "" {
+				return hashA != hashB
+			}
+		}
+	}
+	precision := ua.Precision()
+	if ub.Precision() > precision {
+		precision = ub.Precision()
+	}
+	if precision == fs.ModTimeNotSupported {
+		return false
+	}
+	dt := a.ModTime(ctx).Sub(b.ModTime(ctx))
+	if dt < 0 {
+		dt = -dt
+	}
+	return dt > precision
+}
+
+// splitConflicts splits the candidates for a path into those which
+// are the same as the one the search policy chooses and those which
+// conflict with it.
+//
+// Directories never conflict.
+func (f *Fs) splitConflicts(ctx context.Context, entries []upstream.Entry) (same, conflicts []upstream.Entry) {
+	if len(entries) < 2 {
+		return entries, nil
+	}
+	objs := make([]*upstream.Object, len(entries))
+	for i, e := range entries {
+		o, ok := e.(*upstream.Object)
+		if !ok {
+			return entries, nil
+		}
+		objs[i] = o
+	}
+	chosen, err := f.searchEntries(entries...)
+	if err != nil {
+		return entries, nil
+	}
+	chosenObj := chosen.(*upstream.Object)
+	for i, o := range objs {
+		if o == chosenObj || !differ(ctx, chosenObj, o) {
+			same = append(same, entries[i])
+		} else {
+			conflicts = append(conflicts, entries[i])
+		}
+	}
+	return same, conflicts
+}
+
+// wrapConflict wraps a conflicting copy in to a union Object listed
+// under its suffixed name
+func (f *Fs) wrapConflict(e upstream.Entry) *Object {
+	o := e.(*upstream.Object)
+	return &Object{
+		Object: o,
+		fs:     f,
+		co:     []upstream.Entry{e},
+		remote: f.conflictName(o.Remote(), o.UpstreamFs()),
+	}
+}
+
+// newConflictObject finds the conflicting copy listed as remote
+func (f *Fs) newConflictObject(ctx context.Context, remote string) (*Object, error) {
+	base, u := f.parseConflictName(remote)
+	if u == nil {
+		return nil, fs.ErrorObjectNotFound
+	}
+	entries, err := f.newObjectEntries(ctx, base)
+	if err != nil {
+		return nil, err
+	}
+	_, conflicts := f.splitConflicts(ctx, entries)
+	for _, e := range conflicts {
+		if e.UpstreamFs() == u {
+			return f.wrapConflict(e), nil
+		}
+	}
+	return nil, fs.ErrorObjectNotFound
+}
+
+// conflictCopy describes one copy of a conflicting file
+type conflictCopy struct {
+	Upstream string    // upstream the copy is on
+	Name     string    // name the copy is listed as
+	Size     int64     // size of the copy
+	ModTime  time.Time // modification time of the copy
+}
+
+// conflict describes a file with different contents on different
+// upstreams
+type conflict struct {
+	Path   string         // path of the file
+	Copies []conflictCopy // all the copies, the one the search policy chooses first
+}
+
+// conflicts returns all the files under dir with different contents
+// on different upstreams
+func (f *Fs) conflicts(ctx context.Context, dir string) ([]conflict, error) {
+	entriesList, err := f.listR(ctx, dir)
+	if err != nil {
+		return nil, err
+	}
+	out := []conflict{}
+	for _, entries := range f.groupEntries(entriesList) {
+		same, conflicts := f.splitConflicts(ctx, entries)
+		if len(conflicts) == 0 {
+			continue
+		}
+		chosen, err := f.searchEntries(same...)
+		if err != nil {
+			return nil, err
+		}
+		c := conflict{
+			Path: chosen.Remote(),
+		}
+		addCopy := func(e upstream.Entry, name string) {
+			c.Copies = append(c.Copies, conflictCopy{
+				Upstream: fs.ConfigString(e.UpstreamFs().RootFs),
+				Name:     name,
+				Size:     e.Size(),
+				ModTime:  e.ModTime(ctx),
+			})
+		}
+		addCopy(chosen, chosen.Remote())
+		for _, e := range same {
+			if e != chosen {
+				addCopy(e, chosen.Remote())
+			}
+		}
+		for _, e := range conflicts {
+			addCopy(e, f.conflictName(e.Remote(), e.UpstreamFs()))
+		}
+		out = append(out, c)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Path < out[j].Path
+	})
+	return out, nil
+}
+
+var commandHelp = []fs.CommandHelp{{
+	Name:  "conflicts",
+	Short: "List files with different contents on different upstreams",
+	Long: `This lists all the files under the directory given (or the root if
+none is given) which exist on more than one upstream with different
+contents, whatever the conflict_policy is.
+
+Files are considered different if their sizes differ, or their hashes
+differ if the upstreams share a hash type which is cheap to read, or
+otherwise if their modification times differ.
+
+For each file it returns the copies, the one chosen by the search
+policy first, with the name each copy is listed as.
+
+Usage Example:
+
+    rclone backend conflicts union: [dir]
+    rclone rc backend/command command=conflicts fs=union: [dir]
+`,
+}}
+
+// Command the backend to run a named command
+//
+// The command run is name
+// args may be used to read arguments from
+// opts may be used to read optional arguments from
+//
+// The result should be capable of being JSON encoded
+// If it is a string or a []string it will be shown to the user
+// otherwise it will be JSON encoded and shown to the user like that
+func (f *Fs) Command(ctx context.Context, name string, arg []string, opt map[string]string) (out interface{}, err error) {
+	switch name {
+	case "conflicts":
+		dir := ""
+		if len(arg) > 0 {
+			dir = arg[0]
+		}
+		return f.conflicts(ctx, dir)
+	default:
+		return nil, fs.ErrorCommandNotFound
+	}
+}
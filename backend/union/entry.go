@@ -16,8 +16,9 @@ import (
 // This is a wrapped object which returns the Union Fs as its parent
 type Object struct {
 	*upstream.Object
-	fs *Fs // what this object is part of
-	co []upstream.Entry
+	fs     *Fs // what this object is part of
+	co     []upstream.Entry
+	remote string // name a conflicting copy is listed as, if set
 }
 
 // Directory describes a union Directory
@@ -39,6 +40,23 @@ func (o *Object) UnWrap() *upstream.Object {
 	return o.Object
 }
 
+// Remote returns the remote path, which is suffixed for conflicting
+// copies
+func (o *Object) Remote() string {
+	if o.remote != "" {
+		return o.remote
+	}
+	return o.Object.Remote()
+}
+
+// String returns a description of the Object
+func (o *Object) String() string {
+	if o == nil {
+		return "<nil>"
+	}
+	return o.Remote()
+}
+
 // Fs returns the union Fs as the parent
 func (o *Object) Fs() fs.Info {
 	return o.fs
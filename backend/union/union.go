@@ -28,6 +28,7 @@ func init() {
 		Name:        "union",
 		Description: "Union merges the contents of several upstream fs",
 		NewFs:       NewFs,
+		CommandHelp: commandHelp,
 		Options: []fs.Option{{
 			Name:     "upstreams",
 			Help:     "List of space separated upstreams.\nCan be 'upstreama:test/dir upstreamb:', '\"upstreama:test/space:ro dir\" upstreamb:', etc.\n",
@@ -52,6 +53,24 @@ func init() {
 			Help:     "Cache time of usage and free space (in seconds). This option is only useful when a path preserving policy is used.",
 			Required: true,
 			Default:  120,
+		}, {
+			Name: "conflict_policy",
+			Help: `What to list when a file exists on several upstreams with different contents.
+
+Files are considered different if their sizes differ, or their hashes
+differ if the upstreams share a hash type which is cheap to read, or
+otherwise if their modification times differ.
+
+Use the "conflicts" backend command to list all the conflicts.`,
+			Default: conflictHide,
+			Examples: []fs.OptionExample{{
+				Value: conflictHide,
+				Help:  "Only list the copy chosen by the search policy.",
+			}, {
+				Value: conflictSuffix,
+				Help:  "Also list the other copies as file.txt.upstreamN where N is the number of the upstream.",
+			}},
+			Advanced: true,
 		}},
 	}
 	fs.Register(fsi)
@@ -59,12 +78,13 @@ func init() {
 
 // Options defines the configuration for this backend
 type Options struct {
-	Upstreams    fs.SpaceSepList `config:"upstreams"`
-	Remotes      fs.SpaceSepList `config:"remotes"` // Deprecated
-	ActionPolicy string          `config:"action_policy"`
-	CreatePolicy string          `config:"create_policy"`
-	SearchPolicy string          `config:"search_policy"`
-	CacheTime    int             `config:"cache_time"`
+	Upstreams      fs.SpaceSepList `config:"upstreams"`
+	Remotes        fs.SpaceSepList `config:"remotes"` // Deprecated
+	ActionPolicy   string          `config:"action_policy"`
+	CreatePolicy   string          `config:"create_policy"`
+	SearchPolicy   string          `config:"search_policy"`
+	CacheTime      int             `config:"cache_time"`
+	ConflictPolicy string          `config:"conflict_policy"`
 }
 
 // Fs represents a union of upstreams
@@ -599,7 +619,7 @@ func (f *Fs) List(ctx context.Context, dir string) (entries fs.DirEntries, err e
 		}
 		return nil, errs.Err()
 	}
-	return f.mergeDirEntries(entriesList)
+	return f.mergeDirEntries(ctx, entriesList)
 }
 
 // ListR lists the objects and directories of the Fs starting
@@ -619,7 +639,22 @@ func (f *Fs) List(ctx context.Context, dir string) (entries fs.DirEntries, err e
 // Don't implement this unless you have a more efficient way
 // of listing recursively that doing a directory traversal.
 func (f *Fs) ListR(ctx context.Context, dir string, callback fs.ListRCallback) (err error) {
-	var entriesList [][]upstream.Entry
+	entriesList, err := f.listR(ctx, dir)
+	if err != nil {
+		return err
+	}
+	entries, err := f.mergeDirEntries(ctx, entriesList)
+	if err != nil {
+		return err
+	}
+	return callback(entries)
+}
+
+// listR lists dir and all its subdirectories on all the upstreams
+func (f *Fs) listR(ctx context.Context, dir string) (entriesList [][]upstream.Entry, err error) {
+	// Keep the entries of each upstream together in upstream order
+	// so the search policy sees them in the same order as in List
+	entriesList = make([][]upstream.Entry, len(f.upstreams))
 	errs := Errors(make([]error, len(f.upstreams)))
 	var mutex sync.Mutex
 	multithread(len(f.upstreams), func(i int) {
@@ -631,7 +666,7 @@ func (f *Fs) ListR(ctx context.Context, dir string, callback fs.ListRCallback) (
 				uEntries[j], _ = u.WrapEntry(e)
 			}
 			mutex.Lock()
-			entriesList = append(entriesList, uEntries)
+			entriesList[i] = append(entriesList[i], uEntries...)
 			mutex.Unlock()
 			return nil
 		}
@@ -654,19 +689,37 @@ func (f *Fs) ListR(ctx context.Context, dir string, callback fs.ListRCallback) (
 			return e
 		})
 		if len(errs) == 0 {
-			return fs.ErrorDirNotFound
+			return nil, fs.ErrorDirNotFound
 		}
-		return errs.Err()
-	}
-	entries, err := f.mergeDirEntries(entriesList)
-	if err != nil {
-		return err
+		return nil, errs.Err()
 	}
-	return callback(entries)
+	return entriesList, nil
 }
 
 // NewObject creates a new remote union file object
 func (f *Fs) NewObject(ctx context.Context, remote string) (fs.Object, error) {
+	entries, err := f.newObjectEntries(ctx, remote)
+	if err == fs.ErrorObjectNotFound && f.opt.ConflictPolicy == conflictSuffix {
+		return f.newConflictObject(ctx, remote)
+	}
+	if len(entries) == 0 {
+		return nil, err
+	}
+	if f.opt.ConflictPolicy == conflictSuffix {
+		entries, _ = f.splitConflicts(ctx, entries)
+	}
+	e, wrapErr := f.wrapEntries(entries...)
+	if wrapErr != nil {
+		return nil, wrapErr
+	}
+	return e.(*Object), err
+}
+
+// newObjectEntries finds the candidates for the object at remote on
+// all the upstreams
+//
+// It may return candidates and an error if some upstreams failed
+func (f *Fs) newObjectEntries(ctx context.Context, remote string) ([]upstream.Entry, error) {
 	objs := make([]*upstream.Object, len(f.upstreams))
 	errs := Errors(make([]error, len(f.upstreams)))
 	multithread(len(f.upstreams), func(i int) {
@@ -687,11 +740,7 @@ func (f *Fs) NewObject(ctx context.Context, remote string) (fs.Object, error) {
 	if len(entries) == 0 {
 		return nil, fs.ErrorObjectNotFound
 	}
-	e, err := f.wrapEntries(entries...)
-	if err != nil {
-		return nil, err
-	}
-	return e.(*Object), errs.Err()
+	return entries, errs.Err()
 }
 
 // Precision is the greatest Precision of all upstreams
@@ -729,7 +778,8 @@ func (f *Fs) searchEntries(entries ...upstream.Entry) (upstream.Entry, error) {
 	return f.searchPolicy.SearchEntries(entries...)
 }
 
-func (f *Fs) mergeDirEntries(entriesList [][]upstream.Entry) (fs.DirEntries, error) {
+// groupEntries groups the entries from all the upstreams by path
+func (f *Fs) groupEntries(entriesList [][]upstream.Entry) map[string][]upstream.Entry {
 	entryMap := make(map[string]([]upstream.Entry))
 	for _, en := range entriesList {
 		if en == nil {
@@ -743,9 +793,20 @@ func (f *Fs) mergeDirEntries(entriesList [][]upstream.Entry) (fs.DirEntries, err
 			entryMap[remote] = append(entryMap[remote], entry)
 		}
 	}
+	return entryMap
+}
+
+func (f *Fs) mergeDirEntries(ctx context.Context, entriesList [][]upstream.Entry) (fs.DirEntries, error) {
 	var entries fs.DirEntries
-	for path := range entryMap {
-		e, err := f.wrapEntries(entryMap[path]...)
+	for _, candidates := range f.groupEntries(entriesList) {
+		if f.opt.ConflictPolicy == conflictSuffix {
+			var conflicts []upstream.Entry
+			candidates, conflicts = f.splitConflicts(ctx, candidates)
+			for _, c := range conflicts {
+				entries = append(entries, f.wrapConflict(c))
+			}
+		}
+		e, err := f.wrapEntries(candidates...)
 		if err != nil {
 			return nil, err
 		}
@@ -837,6 +898,11 @@ func NewFs(ctx context.Context, name, root string, m configmap.Mapper) (fs.Fs, e
 	if err != nil {
 		return nil, err
 	}
+	switch opt.ConflictPolicy {
+	case conflictHide, conflictSuffix:
+	default:
+		return nil, errors.Errorf("unknown conflict_policy %q - must be %q or %q", opt.ConflictPolicy, conflictHide, conflictSuffix)
+	}
 	fs.Debugf(f, "actionPolicy = %T, createPolicy = %T, searchPolicy = %T", f.actionPolicy, f.createPolicy, f.searchPolicy)
 	var features = (&fs.Features{
 		CaseInsensitive:         true,
@@ -911,4 +977,5 @@ var (
 	_ fs.Abouter         = (*Fs)(nil)
 	_ fs.ListRer         = (*Fs)(nil)
 	_ fs.Shutdowner      = (*Fs)(nil)
+	_ fs.Commander       = (*Fs)(nil)
 )
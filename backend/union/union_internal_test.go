@@ -3,9 +3,16 @@ package union
 import (
 	"bytes"
 	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
 	"testing"
 	"time"
 
+	_ "github.com/pingme998/rclone/backend/local"
+	"github.com/pingme998/rclone/fs"
+	"github.com/pingme998/rclone/fs/config/configmap"
 	"github.com/pingme998/rclone/fs/object"
 	"github.com/pingme998/rclone/fstest"
 	"github.com/pingme998/rclone/fstest/fstests"
@@ -65,3 +72,141 @@ func (f *Fs) InternalTest(t *testing.T) {
 }
 
 var _ fstests.InternalTester = (*Fs)(nil)
+
+// makeConflictFs makes a union of three local directories where
+// file.txt is the same on the first two and different on the third
+// and file2.txt has the same size but different contents on the first
+// two
+func makeConflictFs(t *testing.T, conflictPolicy string) (f *Fs, dirs []string, clean func()) {
+	for i := 0; i < 3; i++ {
+		dir, err := ioutil.TempDir("", "rclone-union-conflict")
+		require.NoError(t, err)
+		dirs = append(dirs, dir)
+	}
+	clean = func() {
+		for _, dir := range dirs {
+			assert.NoError(t, os.RemoveAll(dir))
+		}
+	}
+	t1 := time.Date(2001, 2, 3, 4, 5, 6, 0, time.UTC)
+	t2 := t1.Add(time.Hour)
+	write := func(dir, name, contents string, modTime time.Time) {
+		p := filepath.Join(dir, name)
+		require.NoError(t, ioutil.WriteFile(p, []byte(contents), 0600))
+		require.NoError(t, os.Chtimes(p, modTime, modTime))
+	}
+	write(dirs[0], "file.txt", "same", t1)
+	write(dirs[1], "file.txt", "same", t1)
+	write(dirs[2], "file.txt", "different", t1)
+	write(dirs[0], "file2.txt", "aaaa", t1)
+	write(dirs[1], "file2.txt", "bbbb", t2)
+	write(dirs[0], "only.txt", "only", t1)
+
+	m := configmap.Simple{
+		"upstreams":       dirs[0] + " " + dirs[1] + " " + dirs[2],
+		"action_policy":   "epall",
+		"create_policy":   "epmfs",
+		"search_policy":   "ff",
+		"cache_time":      "120",
+		"conflict_policy": conflictPolicy,
+	}
+	fsys, err := NewFs(context.Background(), "TestUnionConflict", "", m)
+	require.NoError(t, err)
+	return fsys.(*Fs), dirs, clean
+}
+
+func listNames(t *testing.T, f *Fs) (names []string) {
+	entries, err := f.List(context.Background(), "")
+	require.NoError(t, err)
+	for _, e := range entries {
+		names = append(names, e.Remote())
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestConflictSuffix(t *testing.T) {
+	ctx := context.Background()
+	f, dirs, clean := makeConflictFs(t, conflictSuffix)
+	defer clean()
+
+	assert.Equal(t, []string{"file.txt", "file.txt.upstream3", "file2.txt", "file2.txt.upstream2", "only.txt"}, listNames(t, f))
+
+	// The conflicting copy can be found and read under its suffixed name
+	o, err := f.NewObject(ctx, "file.txt.upstream3")
+	require.NoError(t, err)
+	assert.Equal(t, "file.txt.upstream3", o.Remote())
+	in, err := o.Open(ctx)
+	require.NoError(t, err)
+	contents, err := ioutil.ReadAll(in)
+	require.NoError(t, err)
+	require.NoError(t, in.Close())
+	assert.Equal(t, "different", string(contents))
+
+	// The identical copies are candidates of the plain name only
+	o, err = f.NewObject(ctx, "file.txt")
+	require.NoError(t, err)
+	assert.Len(t, o.(*Object).candidates(), 2)
+
+	for _, remote := range []string{"file.txt.upstream1", "file.txt.upstream4", "only.txt.upstream1"} {
+		_, err = f.NewObject(ctx, remote)
+		assert.Equal(t, fs.ErrorObjectNotFound, err, remote)
+	}
+
+	// Removing the conflicting copy only removes it from its upstream
+	o, err = f.NewObject(ctx, "file2.txt.upstream2")
+	require.NoError(t, err)
+	require.NoError(t, o.Remove(ctx))
+	_, err = os.Stat(filepath.Join(dirs[1], "file2.txt"))
+	assert.True(t, os.IsNotExist(err))
+	_, err = os.Stat(filepath.Join(dirs[0], "file2.txt"))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"file.txt", "file.txt.upstream3", "file2.txt", "only.txt"}, listNames(t, f))
+}
+
+func TestConflictHide(t *testing.T) {
+	ctx := context.Background()
+	f, _, clean := makeConflictFs(t, conflictHide)
+	defer clean()
+
+	assert.Equal(t, []string{"file.txt", "file2.txt", "only.txt"}, listNames(t, f))
+	_, err := f.NewObject(ctx, "file.txt.upstream3")
+	assert.Equal(t, fs.ErrorObjectNotFound, err)
+
+	// The conflicts are reported whatever the policy
+	out, err := f.Command(ctx, "conflicts", nil, nil)
+	require.NoError(t, err)
+	conflicts := out.([]conflict)
+	require.Len(t, conflicts, 2)
+
+	assert.Equal(t, "file.txt", conflicts[0].Path)
+	require.Len(t, conflicts[0].Copies, 3)
+	var names []string
+	for _, c := range conflicts[0].Copies {
+		names = append(names, c.Name)
+	}
+	assert.Equal(t, []string{"file.txt", "file.txt", "file.txt.upstream3"}, names)
+	assert.Equal(t, int64(9), conflicts[0].Copies[2].Size)
+
+	assert.Equal(t, "file2.txt", conflicts[1].Path)
+	require.Len(t, conflicts[1].Copies, 2)
+	assert.Equal(t, "file2.txt.upstream2", conflicts[1].Copies[1].Name)
+
+	_, err = f.Command(ctx, "potato", nil, nil)
+	assert.Equal(t, fs.ErrorCommandNotFound, err)
+}
+
+func TestConflictPolicyInvalid(t *testing.T) {
+	_, dirs, clean := makeConflictFs(t, conflictHide)
+	defer clean()
+	m := configmap.Simple{
+		"upstreams":       dirs[0] + " " + dirs[1],
+		"action_policy":   "epall",
+		"create_policy":   "epmfs",
+		"search_policy":   "ff",
+		"cache_time":      "120",
+		"conflict_policy": "potato",
+	}
+	_, err := NewFs(context.Background(), "TestUnionConflict", "", m)
+	assert.EqualError(t, err, `unknown conflict_policy "potato" - must be "hide" or "suffix"`)
+}
@@ -0,0 +1,237 @@
+package webdav
+
+// Nextcloud chunked upload API v2
+//
+// See https://docs.nextcloud.com/server/latest/developer_manual/client_apis/WebDAV/chunking.html
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/pingme998/rclone/backend/webdav/api"
+	"github.com/pingme998/rclone/fs"
+	"github.com/pingme998/rclone/lib/rest"
+)
+
+const maxChunks = 10000 // maximum number of chunks in a Nextcloud upload
+
+// getChunksUploadURL works out the URL of the Nextcloud chunked
+// uploads directory for the user from the endpoint
+//
+// The endpoint will look like either of these
+//
+//   https://example.com/remote.php/dav/files/USER/
+//   https://example.com/remote.php/webdav/
+//
+// and the uploads directory will be
+//
+//   https://example.com/remote.php/dav/uploads/USER/
+//
+// It returns "" if the URL couldn't be worked out.
+func (f *Fs) getChunksUploadURL() string {
+	const remotePHP = "/remote.php/"
+	u := *f.endpoint
+	i := strings.Index(u.Path, remotePHP)
+	if i < 0 {
+		fs.Debugf(f, "Chunked uploads disabled: can't find %q in URL", remotePHP)
+		return ""
+	}
+	user := f.opt.User
+	davPath := u.Path[i+len(remotePHP):]
+	if strings.HasPrefix(davPath, "dav/files/") {
+		user = strings.SplitN(davPath[len("dav/files/"):], "/", 2)[0]
+	}
+	if user == "" {
+		fs.Debugf(f, "Chunked uploads disabled: can't find user name")
+		return ""
+	}
+	u.Path = u.Path[:i] + remotePHP + "dav/uploads/" + user + "/"
+	u.RawPath = ""
+	return u.String()
+}
+
+// transferID returns an ID for the chunked upload of a file of size
+// and modTime to destination with chunkSize
+//
+// This is the same each time the same file is uploaded so an
+// interrupted upload can be resumed.
+func transferID(destination string, size, chunkSize int64, modTime time.Time) string {
+	h := md5.New()
+	_, _ = fmt.Fprintf(h, "%s\x00%d\x00%d\x00%d", destination, size, chunkSize, modTime.UnixNano())
+	return "rclone-" + hex.EncodeToString(h.Sum(nil))
+}
+
+// chunkName returns the name of chunk n (counting from 0)
+//
+// Nextcloud assembles the chunks in order of their names which must be
+// numbers between 1 and 10000.
+func chunkName(n int) string {
+	return fmt.Sprintf("%05d", n+1)
+}
+
+// mkChunksDir creates the directory for the chunks of the upload,
+// returning the sizes of the chunks already in it if it exists from a
+// previous attempt
+func (f *Fs) mkChunksDir(ctx context.Context, uploadDir, destination string) (uploaded map[string]int64, err error) {
+	opts := rest.Opts{
+		Method:     "MKCOL",
+		RootURL:    f.chunksUploadURL,
+		Path:       uploadDir,
+		NoResponse: true,
+		ExtraHeaders: map[string]string{
+			"Destination": destination,
+		},
+	}
+	var resp *http.Response
+	err = f.pacer.Call(func() (bool, error) {
+		resp, err = f.srv.Call(ctx, &opts)
+		return f.shouldRetry(ctx, resp, err)
+	})
+	if err == nil {
+		return nil, nil
+	}
+	if apiErr, ok := err.(*api.Error); !ok || apiErr.StatusCode != http.StatusMethodNotAllowed {
+		return nil, errors.Wrap(err, "failed to create chunked upload")
+	}
+	// The directory already exists so read what is in it
+	return f.listChunks(ctx, uploadDir)
+}
+
+// listChunks returns the sizes of the chunks in uploadDir by name
+func (f *Fs) listChunks(ctx context.Context, uploadDir string) (uploaded map[string]int64, err error) {
+	opts := rest.Opts{
+		Method:  "PROPFIND",
+		RootURL: f.chunksUploadURL,
+		Path:    uploadDir,
+		ExtraHeaders: map[string]string{
+			"Depth": "1",
+		},
+	}
+	var result api.Multistatus
+	var resp *http.Response
+	err = f.pacer.Call(func() (bool, error) {
+		resp, err = f.srv.CallXML(ctx, &opts, nil, &result)
+		return f.shouldRetry(ctx, resp, err)
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list chunked upload")
+	}
+	uploaded = make(map[string]int64, len(result.Responses))
+	for i := range result.Responses {
+		item := &result.Responses[i]
+		if itemIsDir(item) || !item.Props.StatusOK() {
+			continue
+		}
+		uploaded[path.Base(item.Href)] = item.Props.Size
+	}
+	return uploaded, nil
+}
+
+// uploadChunk uploads the chunk called name
+func (f *Fs) uploadChunk(ctx context.Context, uploadDir, name, destination string, size int64, chunk []byte) (err error) {
+	chunkSize := int64(len(chunk))
+	opts := rest.Opts{
+		Method:        "PUT",
+		RootURL:       f.chunksUploadURL,
+		Path:          uploadDir + name,
+		NoResponse:    true,
+		ContentLength: &chunkSize,
+		ExtraHeaders: map[string]string{
+			"Destination":     destination,
+			"OC-Total-Length": fmt.Sprintf("%d", size),
+		},
+	}
+	var resp *http.Response
+	return f.pacer.Call(func() (bool, error) {
+		opts.Body = bytes.NewReader(chunk)
+		resp, err = f.srv.Call(ctx, &opts)
+		return f.shouldRetry(ctx, resp, err)
+	})
+}
+
+// updateChunked uploads in to the object in chunks using the
+// Nextcloud chunked upload API
+//
+// If the upload fails the chunks uploaded so far are left on the
+// server so the next attempt can carry on from where this one
+// stopped. Nextcloud removes abandoned uploads after a day.
+func (o *Object) updateChunked(ctx context.Context, in io.Reader, src fs.ObjectInfo, size int64) (err error) {
+	f := o.fs
+	destinationURL, err := rest.URLJoin(f.endpoint, o.filePath())
+	if err != nil {
+		return errors.Wrap(err, "chunked upload couldn't join URL")
+	}
+	destination := destinationURL.String()
+
+	chunkSize := int64(f.opt.ChunkSize)
+	if size > chunkSize*maxChunks {
+		chunkSize = (size + maxChunks - 1) / maxChunks
+	}
+	uploadDir := transferID(destination, size, chunkSize, src.ModTime(ctx)) + "/"
+	uploaded, err := f.mkChunksDir(ctx, uploadDir, destination)
+	if err != nil {
+		return err
+	}
+
+	buf := make([]byte, chunkSize)
+	for n, remaining := 0, size; remaining > 0; n++ {
+		thisChunkSize := chunkSize
+		if remaining < thisChunkSize {
+			thisChunkSize = remaining
+		}
+		remaining -= thisChunkSize
+		name := chunkName(n)
+		if uploaded[name] == thisChunkSize {
+			fs.Debugf(o, "Skipping chunk %s already uploaded", name)
+			_, err = io.CopyN(ioutil.Discard, in, thisChunkSize)
+			if err != nil {
+				return errors.Wrap(err, "failed to read source")
+			}
+			continue
+		}
+		chunk := buf[:thisChunkSize]
+		_, err = io.ReadFull(in, chunk)
+		if err != nil {
+			return errors.Wrap(err, "failed to read source")
+		}
+		fs.Debugf(o, "Uploading chunk %s of size %d", name, thisChunkSize)
+		err = f.uploadChunk(ctx, uploadDir, name, destination, size, chunk)
+		if err != nil {
+			return errors.Wrapf(err, "failed to upload chunk %s", name)
+		}
+	}
+
+	// Assemble the chunks into the destination
+	opts := rest.Opts{
+		Method:       "MOVE",
+		RootURL:      f.chunksUploadURL,
+		Path:         uploadDir + ".file",
+		NoResponse:   true,
+		ExtraHeaders: f.uploadHeaders(ctx, src),
+	}
+	if opts.ExtraHeaders == nil {
+		opts.ExtraHeaders = map[string]string{}
+	}
+	opts.ExtraHeaders["Destination"] = destination
+	opts.ExtraHeaders["Overwrite"] = "T"
+	opts.ExtraHeaders["OC-Total-Length"] = fmt.Sprintf("%d", size)
+	var resp *http.Response
+	err = f.pacer.Call(func() (bool, error) {
+		resp, err = f.srv.Call(ctx, &opts)
+		return f.shouldRetry(ctx, resp, err)
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to assemble chunks")
+	}
+	return nil
+}
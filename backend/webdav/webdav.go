@@ -46,6 +46,8 @@ const (
 	maxSleep      = 2 * time.Second
 	decayConstant = 2   // bigger for slower decay, exponential
 	defaultDepth  = "1" // depth for PROPFIND
+
+	defaultChunkSize = 10 * fs.Mebi // default nextcloud_chunk_size
 )
 
 const defaultEncodingSharepointNTLM = (encoder.EncodeWin |
@@ -128,6 +130,20 @@ You can set multiple headers, e.g. '"Cookie","name=value","Authorization","xxx"'
 `,
 			Default:  fs.CommaSepList{},
 			Advanced: true,
+		}, {
+			Name: "nextcloud_chunk_size",
+			Help: `Nextcloud upload chunk size.
+
+Files larger than this are uploaded to Nextcloud in chunks of this
+size using its chunked upload API and assembled on the server. This
+means files larger than the limits of the server, or any proxy in
+front of it, can be uploaded and a failed upload can be resumed.
+
+Set to 0 to disable chunked uploads.
+
+This is only used with the nextcloud vendor.`,
+			Default:  defaultChunkSize,
+			Advanced: true,
 		}},
 	})
 }
@@ -142,6 +158,7 @@ type Options struct {
 	BearerTokenCommand string               `config:"bearer_token_command"`
 	Enc                encoder.MultiEncoder `config:"encoding"`
 	Headers            fs.CommaSepList      `config:"headers"`
+	ChunkSize          fs.SizeSuffix        `config:"nextcloud_chunk_size"`
 }
 
 // Fs represents a remote webdav
@@ -162,6 +179,7 @@ type Fs struct {
 	hasMD5             bool          // set if can use owncloud style checksums for MD5
 	hasSHA1            bool          // set if can use owncloud style checksums for SHA1
 	ntlmAuthMu         sync.Mutex    // mutex to serialize NTLM auth roundtrips
+	chunksUploadURL    string        // URL of the chunked uploads directory - "" if not supported
 }
 
 // Object describes a webdav object
@@ -547,6 +565,9 @@ func (f *Fs) setQuirks(ctx context.Context, vendor string) error {
 		f.precision = time.Second
 		f.useOCMtime = true
 		f.hasSHA1 = true
+		if f.opt.ChunkSize > 0 {
+			f.chunksUploadURL = f.getChunksUploadURL()
+		}
 	case "sharepoint":
 		// To mount sharepoint, two Cookies are required
 		// They have to be set instead of BasicAuth
@@ -1297,6 +1318,15 @@ func (o *Object) Update(ctx context.Context, in io.Reader, src fs.ObjectInfo, op
 	}
 
 	size := src.Size()
+	if o.fs.chunksUploadURL != "" && size > int64(o.fs.opt.ChunkSize) {
+		err = o.updateChunked(ctx, in, src, size)
+		if err != nil {
+			return err
+		}
+		// read metadata from remote
+		o.hasMetaData = false
+		return o.readMetaData(ctx)
+	}
 	var resp *http.Response
 	opts := rest.Opts{
 		Method:        "PUT",
@@ -1306,25 +1336,7 @@ func (o *Object) Update(ctx context.Context, in io.Reader, src fs.ObjectInfo, op
 		ContentLength: &size, // FIXME this isn't necessary with owncloud - See https://github.com/nextcloud/nextcloud-snap/issues/365
 		ContentType:   fs.MimeType(ctx, src),
 		Options:       options,
-	}
-	if o.fs.useOCMtime || o.fs.hasMD5 || o.fs.hasSHA1 {
-		opts.ExtraHeaders = map[string]string{}
-		if o.fs.useOCMtime {
-			opts.ExtraHeaders["X-OC-Mtime"] = fmt.Sprintf("%d", src.ModTime(ctx).Unix())
-		}
-		// Set one upload checksum
-		// Owncloud uses one checksum only to check the upload and stores its own SHA1 and MD5
-		// Nextcloud stores the checksum you supply (SHA1 or MD5) but only stores one
-		if o.fs.hasSHA1 {
-			if sha1, _ := src.Hash(ctx, hash.SHA1); sha1 != "" {
-				opts.ExtraHeaders["OC-Checksum"] = "SHA1:" + sha1
-			}
-		}
-		if o.fs.hasMD5 && opts.ExtraHeaders["OC-Checksum"] == "" {
-			if md5, _ := src.Hash(ctx, hash.MD5); md5 != "" {
-				opts.ExtraHeaders["OC-Checksum"] = "MD5:" + md5
-			}
-		}
+		ExtraHeaders:  o.fs.uploadHeaders(ctx, src),
 	}
 	err = o.fs.pacer.CallNoRetry(func() (bool, error) {
 		resp, err = o.fs.srv.Call(ctx, &opts)
@@ -1346,6 +1358,32 @@ func (o *Object) Update(ctx context.Context, in io.Reader, src fs.ObjectInfo, op
 	return o.readMetaData(ctx)
 }
 
+// uploadHeaders returns the extra headers to send with an upload of
+// src or nil if there aren't any
+func (f *Fs) uploadHeaders(ctx context.Context, src fs.ObjectInfo) (headers map[string]string) {
+	if !f.useOCMtime && !f.hasMD5 && !f.hasSHA1 {
+		return nil
+	}
+	headers = map[string]string{}
+	if f.useOCMtime {
+		headers["X-OC-Mtime"] = fmt.Sprintf("%d", src.ModTime(ctx).Unix())
+	}
+	// Set one upload checksum
+	// Owncloud uses one checksum only to check the upload and stores its own SHA1 and MD5
+	// Nextcloud stores the checksum you supply (SHA1 or MD5) but only stores one
+	if f.hasSHA1 {
+		if sha1, _ := src.Hash(ctx, hash.SHA1); sha1 != "" {
+			headers["OC-Checksum"] = "SHA1:" + sha1
+		}
+	}
+	if f.hasMD5 && headers["OC-Checksum"] == "" {
+		if md5, _ := src.Hash(ctx, hash.MD5); md5 != "" {
+			headers["OC-Checksum"] = "MD5:" + md5
+		}
+	}
+	return headers
+}
+
 // Remove an object
 func (o *Object) Remove(ctx context.Context) error {
 	opts := rest.Opts{
@@ -1,19 +1,28 @@
 package webdav_test
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"path"
+	"sort"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/pingme998/rclone/backend/webdav"
 	"github.com/pingme998/rclone/fs"
 	"github.com/pingme998/rclone/fs/config/configfile"
 	"github.com/pingme998/rclone/fs/config/configmap"
+	"github.com/pingme998/rclone/fs/object"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	xwebdav "golang.org/x/net/webdav"
 )
 
 var (
@@ -72,3 +81,122 @@ func TestHeaders(t *testing.T) {
 	_, err := f.Features().About(context.Background())
 	require.NoError(t, err)
 }
+
+// chunkServer is a minimal implementation of the Nextcloud chunked
+// upload API in front of a webdav server
+type chunkServer struct {
+	t       *testing.T
+	mu      sync.Mutex
+	files   http.Handler
+	uploads map[string]map[string][]byte // chunks by name by upload directory
+	puts    int                          // number of chunks uploaded
+	failPut string                       // fail the next PUT of this chunk
+}
+
+func (s *chunkServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	const uploadsPrefix = "/remote.php/dav/uploads/user/"
+	if !strings.HasPrefix(r.URL.Path, uploadsPrefix) {
+		s.files.ServeHTTP(w, r)
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	dir, name := path.Split(strings.TrimPrefix(r.URL.Path, uploadsPrefix))
+	if r.Method != "PROPFIND" {
+		assert.NotEqual(s.t, "", r.Header.Get("Destination"), r.Method+" Destination")
+	}
+	switch r.Method {
+	case "MKCOL":
+		if _, found := s.uploads[dir]; found {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		s.uploads[dir] = map[string][]byte{}
+		w.WriteHeader(http.StatusCreated)
+	case "PROPFIND":
+		_, _ = fmt.Fprintf(w, "<?xml version=\"1.0\"?>\n<d:multistatus xmlns:d=\"DAV:\">")
+		for name, chunk := range s.uploads[dir] {
+			_, _ = fmt.Fprintf(w, `<d:response><d:href>%s%s%s</d:href><d:propstat><d:prop><d:getcontentlength>%d</d:getcontentlength><d:resourcetype/></d:prop><d:status>HTTP/1.1 200 OK</d:status></d:propstat></d:response>`, uploadsPrefix, dir, name, len(chunk))
+		}
+		_, _ = fmt.Fprintf(w, "</d:multistatus>")
+	case "PUT":
+		if name == s.failPut {
+			s.failPut = ""
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		chunk, err := ioutil.ReadAll(r.Body)
+		require.NoError(s.t, err)
+		s.uploads[dir][name] = chunk
+		s.puts++
+		w.WriteHeader(http.StatusCreated)
+	case "MOVE":
+		assert.Equal(s.t, ".file", name)
+		var names []string
+		for name := range s.uploads[dir] {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		var buf bytes.Buffer
+		for _, name := range names {
+			buf.Write(s.uploads[dir][name])
+		}
+		assert.Equal(s.t, fmt.Sprint(buf.Len()), r.Header.Get("OC-Total-Length"))
+		delete(s.uploads, dir)
+		u, err := url.Parse(r.Header.Get("Destination"))
+		require.NoError(s.t, err)
+		req := httptest.NewRequest("PUT", u.Path, &buf)
+		s.files.ServeHTTP(w, req)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// TestChunkedUpload tests uploading in chunks to Nextcloud and
+// resuming a failed upload
+func TestChunkedUpload(t *testing.T) {
+	ctx := context.Background()
+	s := &chunkServer{
+		t: t,
+		files: &xwebdav.Handler{
+			Prefix:     "/remote.php/dav/files/user",
+			FileSystem: xwebdav.NewMemFS(),
+			LockSystem: xwebdav.NewMemLS(),
+		},
+		uploads: map[string]map[string][]byte{},
+		failPut: "00003",
+	}
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+
+	configfile.Install()
+	f, err := webdav.NewFs(ctx, remoteName, "", configmap.Simple{
+		"type":                 "webdav",
+		"url":                  ts.URL + "/remote.php/dav/files/user/",
+		"vendor":               "nextcloud",
+		"nextcloud_chunk_size": "16b",
+	})
+	require.NoError(t, err)
+
+	contents := strings.Repeat("0123456789", 10)
+	src := object.NewStaticObjectInfo("dir/file.txt", time.Unix(1600000000, 0), int64(len(contents)), true, nil, nil)
+
+	// The first upload fails on the third chunk
+	_, err = f.Put(ctx, strings.NewReader(contents), src)
+	require.Error(t, err)
+	assert.Equal(t, 2, s.puts)
+
+	// The second carries on from there
+	o, err := f.Put(ctx, strings.NewReader(contents), src)
+	require.NoError(t, err)
+	assert.Equal(t, 7, s.puts)
+	assert.Equal(t, int64(len(contents)), o.Size())
+	assert.Len(t, s.uploads, 0)
+
+	in, err := o.Open(ctx)
+	require.NoError(t, err)
+	got, err := ioutil.ReadAll(in)
+	require.NoError(t, err)
+	require.NoError(t, in.Close())
+	assert.Equal(t, contents, string(got))
+}
@@ -10,6 +10,7 @@ import (
 	_ "github.com/pingme998/rclone/cmd/cachestats"
 	_ "github.com/pingme998/rclone/cmd/cat"
 	_ "github.com/pingme998/rclone/cmd/check"
+	_ "github.com/pingme998/rclone/cmd/checksum"
 	_ "github.com/pingme998/rclone/cmd/cleanup"
 	_ "github.com/pingme998/rclone/cmd/cmount"
 	_ "github.com/pingme998/rclone/cmd/config"
@@ -22,9 +23,11 @@ import (
 	_ "github.com/pingme998/rclone/cmd/dedupe"
 	_ "github.com/pingme998/rclone/cmd/delete"
 	_ "github.com/pingme998/rclone/cmd/deletefile"
+	_ "github.com/pingme998/rclone/cmd/du"
 	_ "github.com/pingme998/rclone/cmd/genautocomplete"
 	_ "github.com/pingme998/rclone/cmd/gendocs"
 	_ "github.com/pingme998/rclone/cmd/hashsum"
+	_ "github.com/pingme998/rclone/cmd/jobs"
 	_ "github.com/pingme998/rclone/cmd/link"
 	_ "github.com/pingme998/rclone/cmd/listremotes"
 	_ "github.com/pingme998/rclone/cmd/ls"
@@ -44,6 +47,7 @@ import (
 	_ "github.com/pingme998/rclone/cmd/rc"
 	_ "github.com/pingme998/rclone/cmd/rcat"
 	_ "github.com/pingme998/rclone/cmd/rcd"
+	_ "github.com/pingme998/rclone/cmd/restoresuffix"
 	_ "github.com/pingme998/rclone/cmd/reveal"
 	_ "github.com/pingme998/rclone/cmd/rmdir"
 	_ "github.com/pingme998/rclone/cmd/rmdirs"
@@ -52,14 +56,17 @@ import (
 	_ "github.com/pingme998/rclone/cmd/settier"
 	_ "github.com/pingme998/rclone/cmd/sha1sum"
 	_ "github.com/pingme998/rclone/cmd/size"
+	_ "github.com/pingme998/rclone/cmd/stat"
 	_ "github.com/pingme998/rclone/cmd/sync"
 	_ "github.com/pingme998/rclone/cmd/test"
 	_ "github.com/pingme998/rclone/cmd/test/changenotify"
 	_ "github.com/pingme998/rclone/cmd/test/histogram"
 	_ "github.com/pingme998/rclone/cmd/test/info"
+	_ "github.com/pingme998/rclone/cmd/test/latency"
 	_ "github.com/pingme998/rclone/cmd/test/makefiles"
 	_ "github.com/pingme998/rclone/cmd/test/memory"
 	_ "github.com/pingme998/rclone/cmd/touch"
 	_ "github.com/pingme998/rclone/cmd/tree"
+	_ "github.com/pingme998/rclone/cmd/verify"
 	_ "github.com/pingme998/rclone/cmd/version"
 )
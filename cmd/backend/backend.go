@@ -33,8 +33,8 @@ var commandDefinition = &cobra.Command{
 	Short: `Run a backend specific command.`,
 	Long: `
 This runs a backend specific command. The commands themselves (except
-for "help" and "features") are defined by the backends and you should
-see the backend docs for definitions.
+for "help", "features" and "lifecycle") are defined by the backends and
+you should see the backend docs for definitions.
 
 You can discover what commands a backend implements by using
 
@@ -55,6 +55,23 @@ Pass arguments to the backend by placing them on the end of the line
 
     rclone backend cleanup remote:path file1 file2 file3
 
+Backends with native object expiry rules (s3, gcs and b2) can have
+them read or replaced with the "lifecycle" command
+
+    rclone backend lifecycle remote:bucket get
+    rclone backend lifecycle remote:bucket set -o prefix=logs/ -o expire-days=30 -o noncurrent-expire-days=7
+    rclone backend lifecycle remote:bucket set rules.json
+    rclone backend lifecycle remote:bucket set -o rules='[]'
+
+"get" shows the rules as a JSON list of objects with the fields "id",
+"prefix", "expireDays" and "noncurrentExpireDays". "set" replaces all
+the rules of the bucket with a JSON list read from a file or the
+"rules" option, or with a single rule made from the "id", "prefix",
+"expire-days" and "noncurrent-expire-days" options. An empty list
+removes all the rules. The prefix is relative to the bucket, not the
+path of the remote. Not all backends support every field - see the
+backend docs for details.
+
 Note to run these commands on a running backend then see
 [backend/command](/rc/#backend/command) in the rc docs.
 `,
@@ -85,6 +102,8 @@ Note to run these commands on a running backend then see
 				return showHelp(fsInfo)
 			case "features":
 				out = operations.GetFsInfo(f)
+			case "lifecycle":
+				out, err = operations.Lifecycle(context.Background(), f, args[2:], rc.ParseOptions(options))
 			default:
 				doCommand := f.Features().Command
 				if doCommand == nil {
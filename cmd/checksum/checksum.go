@@ -0,0 +1,160 @@
+package checksum
+
+import (
+	"context"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/pingme998/rclone/cmd"
+	"github.com/pingme998/rclone/fs"
+	"github.com/pingme998/rclone/fs/config/flags"
+	"github.com/pingme998/rclone/fs/hash"
+	"github.com/pingme998/rclone/fs/operations"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// Globals
+var (
+	hashType   = ""
+	sfv        = false
+	download   = false
+	outputFile = ""
+)
+
+func init() {
+	cmd.Root.AddCommand(Command)
+	Command.AddCommand(createCommand)
+	Command.AddCommand(verifyCommand)
+	addFlags(createCommand.Flags())
+	flags.StringVarP(createCommand.Flags(), &outputFile, "output-file", "", outputFile, "Write the manifest to this file rather than the terminal")
+	addFlags(verifyCommand.Flags())
+}
+
+// addFlags adds the flags common to the subcommands
+func addFlags(cmdFlags *pflag.FlagSet) {
+	flags.StringVarP(cmdFlags, &hashType, "hash", "", hashType, "Hash to use in the manifest, e.g. MD5, SHA-1 or CRC-32")
+	flags.BoolVarP(cmdFlags, &sfv, "sfv", "", sfv, "Use the SFV format with CRC-32 hashes")
+	flags.BoolVarP(cmdFlags, &download, "download", "", download, "Download the files and hash them locally rather than asking the remote for the hash")
+}
+
+// Command definition for cobra
+var Command = &cobra.Command{
+	Use:   "checksum <subcommand>",
+	Short: `Create or verify checksum manifests.`,
+	Long: strings.ReplaceAll(`
+Create a checksum manifest of a remote with |rclone checksum create|
+and later check the remote still matches it with |rclone checksum
+verify|.
+
+Manifests can be in the format used by |md5sum| and |sha1sum|, with
+any hash rclone supports, or in the SFV format which uses CRC-32
+hashes. They can be checked by the standard tools if the remote is
+mounted or downloaded.
+
+Both subcommands obey the filter rules.
+`, "|", "`"),
+}
+
+var createCommand = &cobra.Command{
+	Use:   "create remote:path",
+	Short: `Write a checksum manifest for the files in remote:path.`,
+	Long: strings.ReplaceAll(`
+Writes a checksum manifest for the files in remote:path sorted by
+path, to the terminal or to the file given with |--output-file|.
+
+By default the manifest is in the md5sum format with MD5 hashes. Use
+|--hash| to choose a different hash, e.g. |--hash SHA-1|, and |--sfv|
+to write an SFV file with CRC-32 hashes.
+
+The hashes are read from the remote unless |--download| is used, in
+which case the files are downloaded and hashed locally, so any hash can
+be used with any remote.
+
+    rclone checksum create --hash SHA-1 remote:path --output-file SHA1SUMS
+    rclone checksum create --sfv --download remote:path --output-file files.sfv
+`, "|", "`"),
+	Run: func(command *cobra.Command, args []string) {
+		cmd.CheckArgs(1, 1, command, args)
+		fsrc := cmd.NewFsSrc(args)
+		cmd.Run(false, false, command, func() error {
+			ht := hash.MD5
+			if sfv {
+				ht = hash.CRC32
+			}
+			if hashType != "" {
+				if err := ht.Set(hashType); err != nil {
+					return err
+				}
+			}
+			var out io.Writer = os.Stdout
+			if outputFile != "" {
+				f, err := os.Create(outputFile)
+				if err != nil {
+					return errors.Wrap(err, "failed to create manifest")
+				}
+				defer func() {
+					if err := f.Close(); err != nil {
+						fs.Errorf(nil, "Failed to close manifest %v: %v", outputFile, err)
+					}
+				}()
+				out = f
+			}
+			return operations.ChecksumCreate(context.Background(), fsrc, ht, sfv, download, out)
+		})
+	},
+}
+
+var verifyCommand = &cobra.Command{
+	Use:   "verify manifest remote:path",
+	Short: `Check the files in remote:path match a checksum manifest.`,
+	Long: strings.ReplaceAll(`
+Checks the files in remote:path match the hashes in the manifest,
+reporting files which differ, files missing from the remote and files
+not in the manifest. It doesn't alter the remote.
+
+The manifest is read as an SFV file if |--sfv| is used or its name
+ends in |.sfv|, otherwise in the md5sum format. The hash type is
+guessed from the length of the hashes unless |--hash| is used.
+
+The hashes are read from the remote where it supports them, otherwise
+and if |--download| is used the files are downloaded and hashed
+locally.
+
+The files which fail are logged at ERROR level and the command exits
+with a non-zero status if any were found. For a resumable audit with a
+report use |rclone verify --manifest|.
+`, "|", "`"),
+	Run: func(command *cobra.Command, args []string) {
+		cmd.CheckArgs(2, 2, command, args)
+		manifest := args[0]
+		fdst := cmd.NewFsSrc(args[1:])
+		cmd.Run(false, true, command, func() error {
+			ht := hash.None
+			if hashType != "" {
+				if err := ht.Set(hashType); err != nil {
+					return err
+				}
+			}
+			in, err := os.Open(manifest)
+			if err != nil {
+				return errors.Wrap(err, "failed to open manifest")
+			}
+			isSFV := sfv || strings.HasSuffix(strings.ToLower(manifest), ".sfv")
+			sums, ht, err := operations.ReadChecksumManifest(in, isSFV, ht)
+			_ = in.Close()
+			if err != nil {
+				return err
+			}
+			_, err = operations.Verify(context.Background(), &operations.VerifyOpt{
+				Fdst:          fdst,
+				Manifest:      sums,
+				HashType:      ht,
+				UseRemoteHash: !download,
+			})
+			return err
+		})
+	},
+}
@@ -31,12 +31,15 @@ import (
 	"github.com/pingme998/rclone/fs/filter"
 	"github.com/pingme998/rclone/fs/filter/filterflags"
 	"github.com/pingme998/rclone/fs/fserrors"
+	"github.com/pingme998/rclone/fs/fshttp"
 	"github.com/pingme998/rclone/fs/fspath"
 	fslog "github.com/pingme998/rclone/fs/log"
+	"github.com/pingme998/rclone/fs/operations"
 	"github.com/pingme998/rclone/fs/rc/rcflags"
 	"github.com/pingme998/rclone/fs/rc/rcserver"
 	"github.com/pingme998/rclone/lib/atexit"
 	"github.com/pingme998/rclone/lib/buildinfo"
+	"github.com/pingme998/rclone/lib/exitcode"
 	"github.com/pingme998/rclone/lib/random"
 	"github.com/pingme998/rclone/lib/terminal"
 	"github.com/spf13/cobra"
@@ -53,6 +56,7 @@ var (
 	version         bool
 	retries         = flags.IntP("retries", "", 3, "Retry operations this many times if they fail")
 	retriesInterval = flags.DurationP("retries-sleep", "", 0, "Interval between retrying operations if they fail, e.g 500ms, 60s, 5m. (0 to disable)")
+	summaryFile     = flags.StringP("summary-file", "", "", "Write a JSON summary of the run to this file on exit")
 	// Errors
 	errorCommandNotFound    = errors.New("command not found")
 	errorUncategorized      = errors.New("uncategorized error")
@@ -60,19 +64,6 @@ var (
 	errorTooManyArguments   = errors.New("too many arguments")
 )
 
-const (
-	exitCodeSuccess = iota
-	exitCodeUsageError
-	exitCodeUncategorizedError
-	exitCodeDirNotFound
-	exitCodeFileNotFound
-	exitCodeRetryError
-	exitCodeNoRetryError
-	exitCodeFatalError
-	exitCodeTransferExceeded
-	exitCodeNoFilesTransferred
-)
-
 // ShowVersion prints the version to stdout
 func ShowVersion() {
 	osVersion, osKernel := buildinfo.GetOSVersion()
@@ -120,12 +111,34 @@ func NewFsFile(remote string) (fs.Fs, string) {
 	return nil, ""
 }
 
+// remoteProfileLoaded is set once the default filter profile of the
+// first source remote has been looked for so the profile of a later
+// remote, eg the second one given to checksum, doesn't replace it
+var remoteProfileLoaded bool
+
 // newFsFileAddFilter creates an src Fs from a name
 //
 // This works the same as NewFsFile however it adds filters to the Fs
 // to limit it to a single file if the remote pointed to a file.
+//
+// If this is the first source remote and it has a default filter
+// profile then that is used for the command.
 func newFsFileAddFilter(remote string) (fs.Fs, string) {
-	fi := filter.GetConfig(context.Background())
+	ctx := context.Background()
+	fi := filter.GetConfig(ctx)
+	if !remoteProfileLoaded {
+		remoteProfileLoaded = true
+		profileCtx, err := filterflags.AddRemoteProfile(ctx, remote)
+		if err != nil {
+			err = fs.CountError(err)
+			log.Fatalf("Failed to load filter profile: %v", err)
+		}
+		// The commands run with the global filter so use the
+		// remote's filter for this command
+		if profileFi := filter.GetConfig(profileCtx); profileFi != fi {
+			*fi = *profileFi
+		}
+	}
 	f, fileName := NewFsFile(remote)
 	if fileName != "" {
 		if !fi.InActive() {
@@ -249,6 +262,61 @@ func ShowStats() bool {
 	return statsIntervalFlag != nil && statsIntervalFlag.Changed
 }
 
+// GracefulShutdown registers the functions to shut down gracefully
+// on exit signals if --shutdown-grace-period is set and returns a
+// function to unregister them.
+//
+// On an exit signal rclone waits up to the grace period for the
+// transfers in progress to finish then shuts down the backends and
+// closes the idle connections.
+func GracefulShutdown() (unregister func()) {
+	ci := fs.GetConfig(context.Background())
+	if ci.ShutdownGracePeriod <= 0 {
+		return func() {}
+	}
+	gracefulHandle := atexit.RegisterGraceful(waitForTransfers)
+	// Registered functions run after the graceful ones
+	shutdownHandle := atexit.Register(shutdownBackends)
+	return func() {
+		atexit.UnregisterGraceful(gracefulHandle)
+		atexit.Unregister(shutdownHandle)
+	}
+}
+
+// waitForTransfers waits until there are no transfers in progress
+// or ctx is cancelled
+func waitForTransfers(ctx context.Context) {
+	tick := time.NewTicker(100 * time.Millisecond)
+	defer tick.Stop()
+	logged := false
+	for {
+		n := accounting.GlobalStats().GetTransfersInProgress()
+		if n == 0 {
+			return
+		}
+		if !logged {
+			fs.Logf(nil, "Waiting for %d transfers to finish before exiting", n)
+			logged = true
+		}
+		select {
+		case <-tick.C:
+		case <-ctx.Done():
+			fs.Errorf(nil, "Exiting with %d transfers unfinished", n)
+			return
+		}
+	}
+}
+
+// shutdownBackends shuts down the backends in the fs cache and
+// closes idle connections if rclone is exiting because of a signal
+func shutdownBackends() {
+	if !atexit.Signalled() {
+		return
+	}
+	_ = cache.Shutdown(context.Background())
+	fshttp.CloseIdleConnections()
+}
+
 // Run the function with stats and retries if required
 func Run(Retry bool, showStats bool, cmd *cobra.Command, f func() error) {
 	ci := fs.GetConfig(context.Background())
@@ -263,6 +331,7 @@ func Run(Retry bool, showStats bool, cmd *cobra.Command, f func() error) {
 		stopStats = StartStats()
 	}
 	SigInfoHandler()
+	defer GracefulShutdown()()
 	for try := 1; try <= *retries; try++ {
 		cmdErr = f()
 		cmdErr = fs.CountError(cmdErr)
@@ -307,6 +376,16 @@ func Run(Retry bool, showStats bool, cmd *cobra.Command, f func() error) {
 	if showStats && (accounting.GlobalStats().Errored() || *statsInterval > 0) {
 		accounting.GlobalStats().Log()
 	}
+	if ci.DryRun && ci.DryRunOutput != "none" {
+		out := os.Stderr
+		if ci.DryRunOutput == "json" {
+			out = os.Stdout
+		}
+		err := operations.WriteDryRunSummary(out, ci.DryRunOutput)
+		if err != nil {
+			fs.Errorf(nil, "Failed to write --dry-run summary: %v", err)
+		}
+	}
 	fs.Debugf(nil, "%d go routines active\n", runtime.NumGoroutine())
 
 	if ci.Progress && ci.ProgressTerminalTitle {
@@ -388,6 +467,16 @@ func StartStats() func() {
 	}
 }
 
+// findCommand returns the command being run and its name without
+// the "rclone " prefix, eg "serve webdav"
+func findCommand() (*cobra.Command, string) {
+	command, _, err := Root.Find(os.Args[1:])
+	if err != nil || command == Root {
+		return Root, ""
+	}
+	return command, strings.TrimPrefix(command.CommandPath(), Root.Name()+" ")
+}
+
 // initConfig is run by cobra after initialising the flags
 func initConfig() {
 	ctx := context.Background()
@@ -402,6 +491,13 @@ func initConfig() {
 	// Load the config
 	configfile.Install()
 
+	// Set the options from --profile and the command defaults in the config
+	command, commandName := findCommand()
+	err := configflags.ApplyProfiles(ci, commandName, command.Flags(), pflag.CommandLine)
+	if err != nil {
+		log.Fatalf("Failed to load profile: %v", err)
+	}
+
 	// Start accounting
 	accounting.Start(ctx)
 
@@ -411,7 +507,7 @@ func initConfig() {
 	}
 
 	// Load filters
-	err := filterflags.Reload(ctx)
+	err = filterflags.Reload(ctx)
 	if err != nil {
 		log.Fatalf("Failed to load filters: %v", err)
 	}
@@ -479,36 +575,55 @@ func initConfig() {
 }
 
 func resolveExitCode(err error) {
-	ci := fs.GetConfig(context.Background())
 	atexit.Run()
+	code := exitCode(err)
+	writeSummary(code, err)
+	os.Exit(code)
+}
+
+// exitCode returns the exit code rclone should return for err
+func exitCode(err error) int {
+	ci := fs.GetConfig(context.Background())
 	if err == nil {
 		if ci.ErrorOnNoTransfer {
 			if accounting.GlobalStats().GetTransfers() == 0 {
-				os.Exit(exitCodeNoFilesTransferred)
+				return exitcode.NoFilesTransferred
 			}
 		}
-		os.Exit(exitCodeSuccess)
+		return exitcode.Success
 	}
 
 	_, unwrapped := fserrors.Cause(err)
 
+	// The causes which had exit codes before quota exceeded,
+	// permission denied and partial transfer were added are checked
+	// first so errors which are both keep their old exit code - see
+	// "rclone help exitcodes" for the list which scripts depend on
 	switch {
+	case unwrapped == errorCommandNotFound || unwrapped == errorNotEnoughArguments || unwrapped == errorTooManyArguments:
+		return exitcode.UsageError
 	case unwrapped == fs.ErrorDirNotFound:
-		os.Exit(exitCodeDirNotFound)
+		return exitcode.DirNotFound
 	case unwrapped == fs.ErrorObjectNotFound:
-		os.Exit(exitCodeFileNotFound)
+		return exitcode.FileNotFound
 	case unwrapped == errorUncategorized:
-		os.Exit(exitCodeUncategorizedError)
+		return exitcode.UncategorizedError
 	case unwrapped == accounting.ErrorMaxTransferLimitReached:
-		os.Exit(exitCodeTransferExceeded)
+		return exitcode.TransferExceeded
 	case fserrors.ShouldRetry(err):
-		os.Exit(exitCodeRetryError)
+		return exitcode.RetryError
 	case fserrors.IsNoRetryError(err):
-		os.Exit(exitCodeNoRetryError)
+		return exitcode.NoRetryError
 	case fserrors.IsFatalError(err):
-		os.Exit(exitCodeFatalError)
+		return exitcode.FatalError
+	case fserrors.IsQuotaError(err):
+		return exitcode.QuotaExceeded
+	case fserrors.IsPermissionError(err):
+		return exitcode.PermissionDenied
+	case accounting.GlobalStats().GetTransfers() > 0:
+		return exitcode.PartialTransfer
 	default:
-		os.Exit(exitCodeUsageError)
+		return exitcode.UsageError
 	}
 }
 
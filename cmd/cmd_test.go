@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"io"
+	"os"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/pingme998/rclone/fs"
+	"github.com/pingme998/rclone/fs/accounting"
+	"github.com/pingme998/rclone/fs/fserrors"
+	"github.com/pingme998/rclone/lib/exitcode"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExitCode(t *testing.T) {
+	accounting.GlobalStats().ResetCounters()
+	errQuota := errors.New("storageQuotaExceeded")
+	errPermission := &os.PathError{Op: "open", Path: "/file", Err: os.ErrPermission}
+	for _, test := range []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"Success", nil, exitcode.Success},
+		{"Usage", errorNotEnoughArguments, exitcode.UsageError},
+		{"DirNotFound", fs.ErrorDirNotFound, exitcode.DirNotFound},
+		{"FileNotFound", errors.Wrap(fs.ErrorObjectNotFound, "stat"), exitcode.FileNotFound},
+		{"Uncategorized", errorUncategorized, exitcode.UncategorizedError},
+		{"TransferExceeded", accounting.ErrorMaxTransferLimitReachedFatal, exitcode.TransferExceeded},
+		{"Quota", errQuota, exitcode.QuotaExceeded},
+		{"Permission", errPermission, exitcode.PermissionDenied},
+		{"Other", errors.New("potato"), exitcode.UsageError},
+
+		// Retry, no retry and fatal errors keep the codes they had
+		// before quota exceeded and permission denied were added
+		{"RetryQuota", errors.Wrap(io.ErrUnexpectedEOF, "storageQuotaExceeded"), exitcode.RetryError},
+		{"NoRetryQuota", fserrors.NoRetryError(errQuota), exitcode.NoRetryError},
+		{"NoRetryPermission", fserrors.NoRetryError(errPermission), exitcode.NoRetryError},
+		{"FatalQuota", fserrors.FatalError(errQuota), exitcode.FatalError},
+		{"FatalPermission", fserrors.FatalError(errPermission), exitcode.FatalError},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.want, exitCode(test.err))
+		})
+	}
+}
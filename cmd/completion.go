@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pingme998/rclone/fs"
+	"github.com/pingme998/rclone/fs/cache"
+	"github.com/pingme998/rclone/fs/config"
+	"github.com/spf13/cobra"
+)
+
+// completionTimeout is the maximum time spent listing a directory to
+// complete a remote path
+const completionTimeout = 10 * time.Second
+
+// addCompletion adds dynamic completion of remotes and remote paths
+// to command and its sub commands which take a remote:path argument
+// and don't have their own completion.
+func addCompletion(command *cobra.Command) {
+	if command.ValidArgsFunction == nil && len(command.ValidArgs) == 0 && strings.Contains(command.Use, ":") {
+		command.ValidArgsFunction = CompleteRemotePath
+	}
+	for _, subCommand := range command.Commands() {
+		addCompletion(subCommand)
+	}
+}
+
+// CompleteRemotePath is a cobra.ValidArgsFunction which completes
+// toComplete as a remote name from the config file or, if it has a
+// remote name, as a path on that remote by listing its parent
+// directory.
+//
+// Local paths are left to the shell to complete.
+func CompleteRemotePath(command *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	// Never prompt for the config password while completing
+	fs.GetConfig(context.Background()).AskPassword = false
+	colon := strings.Index(toComplete, ":")
+	if colon < 0 {
+		remotes := completeRemotes(toComplete)
+		if len(remotes) == 0 {
+			return nil, cobra.ShellCompDirectiveDefault
+		}
+		return remotes, cobra.ShellCompDirectiveNoSpace
+	}
+	if strings.ContainsAny(toComplete[:colon], `/\`) {
+		// a local path with a colon in
+		return nil, cobra.ShellCompDirectiveDefault
+	}
+	return completePath(toComplete[:colon+1], toComplete[colon+1:]), cobra.ShellCompDirectiveNoSpace | cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeRemotes returns the names of the remotes starting with
+// prefix with a ":" on the end
+func completeRemotes(prefix string) (remotes []string) {
+	for _, remote := range config.FileSections() {
+		if strings.HasPrefix(remote, prefix) {
+			remotes = append(remotes, remote+":")
+		}
+	}
+	sort.Strings(remotes)
+	return remotes
+}
+
+// completePath returns the entries of the directory on remote which
+// start with path. Directories have a "/" on the end.
+func completePath(remote, path string) (completions []string) {
+	dir, leaf := "", path
+	if i := strings.LastIndex(path, "/"); i >= 0 {
+		dir, leaf = path[:i+1], path[i+1:]
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), completionTimeout)
+	defer cancel()
+	f, err := cache.Get(ctx, remote+dir)
+	if err != nil {
+		fs.Debugf(nil, "Completion: failed to make remote %q: %v", remote+dir, err)
+		return nil
+	}
+	entries, err := f.List(ctx, "")
+	if err != nil {
+		fs.Debugf(f, "Completion: failed to list: %v", err)
+		return nil
+	}
+	for _, entry := range entries {
+		name := entry.Remote()
+		if !strings.HasPrefix(name, leaf) {
+			continue
+		}
+		completion := remote + dir + name
+		if _, isDir := entry.(fs.Directory); isDir {
+			completion += "/"
+		}
+		completions = append(completions, completion)
+	}
+	return completions
+}
@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	_ "github.com/pingme998/rclone/backend/local"
+	"github.com/pingme998/rclone/fs/config"
+	"github.com/pingme998/rclone/fs/config/configfile"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompleteRemotePath(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rclone-completion")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(dir))
+	}()
+
+	// Make a config file with some remotes in
+	configPath := filepath.Join(dir, "rclone.conf")
+	require.NoError(t, ioutil.WriteFile(configPath, []byte("[potato]\ntype = local\n\n[potato2]\ntype = local\n\n[other]\ntype = local\n"), 0600))
+	oldConfigPath := config.GetConfigPath()
+	oldConfigFile := config.Data()
+	defer func() {
+		assert.NoError(t, config.SetConfigPath(oldConfigPath))
+		config.SetData(oldConfigFile)
+	}()
+	require.NoError(t, config.SetConfigPath(configPath))
+	configfile.Install()
+
+	// Make some files to complete
+	data := filepath.ToSlash(filepath.Join(dir, "data"))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "data", "fdir"), 0777))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "data", "file"), []byte("potato"), 0666))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "data", "other"), []byte("potato"), 0666))
+
+	for _, test := range []struct {
+		toComplete string
+		want       []string
+		directive  cobra.ShellCompDirective
+	}{
+		{"pot", []string{"potato2:", "potato:"}, cobra.ShellCompDirectiveNoSpace},
+		{"", []string{"other:", "potato2:", "potato:"}, cobra.ShellCompDirectiveNoSpace},
+		{"nothing", nil, cobra.ShellCompDirectiveDefault},
+		{"./local:path", nil, cobra.ShellCompDirectiveDefault},
+		{"potato:" + data + "/f", []string{"potato:" + data + "/fdir/", "potato:" + data + "/file"}, cobra.ShellCompDirectiveNoSpace | cobra.ShellCompDirectiveNoFileComp},
+		{"potato:" + data + "/o", []string{"potato:" + data + "/other"}, cobra.ShellCompDirectiveNoSpace | cobra.ShellCompDirectiveNoFileComp},
+		{"potato:" + data + "/notfound/", nil, cobra.ShellCompDirectiveNoSpace | cobra.ShellCompDirectiveNoFileComp},
+	} {
+		got, directive := CompleteRemotePath(nil, nil, test.toComplete)
+		assert.ElementsMatch(t, test.want, got, test.toComplete)
+		assert.Equal(t, test.directive, directive, test.toComplete)
+	}
+}
+
+func TestAddCompletion(t *testing.T) {
+	root := &cobra.Command{Use: "root"}
+	remote := &cobra.Command{Use: "ls remote:path"}
+	local := &cobra.Command{Use: "version"}
+	own := &cobra.Command{Use: "own remote:path", ValidArgs: []string{"a", "b"}}
+	root.AddCommand(remote, local, own)
+	addCompletion(root)
+	assert.NotNil(t, remote.ValidArgsFunction)
+	assert.Nil(t, local.ValidArgsFunction)
+	assert.Nil(t, own.ValidArgsFunction)
+}
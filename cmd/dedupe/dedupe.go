@@ -19,7 +19,7 @@ var (
 func init() {
 	cmd.Root.AddCommand(commandDefinition)
 	cmdFlag := commandDefinition.Flags()
-	flags.FVarP(cmdFlag, &dedupeMode, "dedupe-mode", "", "Dedupe mode interactive|skip|first|newest|oldest|largest|smallest|rename.")
+	flags.FVarP(cmdFlag, &dedupeMode, "dedupe-mode", "", "Dedupe mode interactive|skip|first|newest|oldest|largest|smallest|rename|list|dirs.")
 	flags.BoolVarP(cmdFlag, &byHash, "by-hash", "", false, "Find indentical hashes rather than names")
 }
 
@@ -125,6 +125,21 @@ Dedupe can be run non interactively using the ` + "`" + `--dedupe-mode` + "`" +
   * ` + "`" + `--dedupe-mode smallest` + "`" + ` - removes identical files then keeps the smallest one.
   * ` + "`" + `--dedupe-mode rename` + "`" + ` - removes identical files then renames the rest to be different.
   * ` + "`" + `--dedupe-mode list` + "`" + ` - lists duplicate dirs and files only and changes nothing.
+  * ` + "`" + `--dedupe-mode dirs` + "`" + ` - merges directories whose names differ only in unicode normalization or trailing spaces.
+
+The ` + "`dirs`" + ` mode is for bucket based remotes like S3 and GCS
+where directories are just prefixes of the object names. After a
+migration from Windows or macOS these can end up with several
+"duplicate" directories, e.g. ` + "`docs`" + ` and ` + "`docs `" + `
+or the NFC and NFD forms of ` + "`café`" + `, which look the same but
+hold different files. Rclone reports each group of duplicate
+directories found, then moves the files into the directory of the
+group with the most files (preferring the normalized name if there is
+a tie) using server-side moves where possible. Files which are
+identical to one already there are deleted and files which differ are
+renamed to be different. Use ` + "`--dry-run`" + ` to see what it would do.
+
+    rclone dedupe dirs s3:bucket/path
 
 For example to rename all the identically named photos in your Google Photos directory, do
 
@@ -144,7 +159,7 @@ Or
 			args = args[1:]
 		}
 		fdst := cmd.NewFsSrc(args)
-		if !byHash && !fdst.Features().DuplicateFiles {
+		if !byHash && !fdst.Features().DuplicateFiles && dedupeMode != operations.DeduplicateDirs {
 			fs.Logf(fdst, "Can't have duplicate names here. Perhaps you wanted --by-hash ? Continuing anyway.")
 		}
 		cmd.Run(false, false, command, func() error {
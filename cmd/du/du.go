@@ -0,0 +1,244 @@
+// Package du provides the du command.
+package du
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+
+	"github.com/pkg/errors"
+	"github.com/pingme998/rclone/cmd"
+	"github.com/pingme998/rclone/fs"
+	"github.com/pingme998/rclone/fs/config/flags"
+	"github.com/pingme998/rclone/fs/walk"
+	"github.com/spf13/cobra"
+)
+
+var (
+	jsonOutput bool
+	sortBy     = "name"
+)
+
+func init() {
+	cmd.Root.AddCommand(commandDefinition)
+	cmdFlags := commandDefinition.Flags()
+	flags.BoolVarP(cmdFlags, &jsonOutput, "json", "", false, "Format output as JSON")
+	flags.StringVarP(cmdFlags, &sortBy, "sort", "", sortBy, "Sort directories by name, size or count")
+}
+
+var commandDefinition = &cobra.Command{
+	Use:   "du remote:path",
+	Short: `Prints the cumulative size and number of objects of each directory in remote:path.`,
+	Long: `
+rclone du lists the total size and number of objects in each directory
+of remote:path, including everything in the directories below it, in
+a similar way to the unix du command.
+
+For example
+
+    $ rclone du remote:path
+    9.277 KiByte        4 /
+    3.418 KiByte        2 dir
+        500 Byte        1 dir/subdir
+    4.883 KiByte        1 dir2
+          0 Byte        0 empty
+
+The columns are the size, the number of objects and the path of the
+directory relative to remote:path.
+
+The whole of remote:path is read in a single listing, which will use
+ListR if the remote supports it (see --fast-list), so the totals
+include everything below each directory.
+
+Use --max-depth to limit the depth of the directories shown. This
+doesn't limit the listing so the totals are still for everything
+below each directory. Eg --max-depth 1 shows just the total for
+remote:path and each directory in it.
+
+Use --sort to choose the order of the directories. Each directory is
+followed by its subdirectories sorted by
+
+- name - the name of the directory (the default)
+- size - the largest first
+- count - the most objects first
+
+Use --json to output the directories as a JSON array in the same
+order. Each directory has
+
+- path - the path of the directory relative to remote:path - "" is the root
+- depth - the depth of the directory - 0 is the root
+- bytes - the total size of the objects in it and below it
+- count - the total number of objects in it and below it
+- dirs - the total number of directories below it
+
+You can use any of the filtering options with the du command (e.g.
+--include and --exclude).
+`,
+	Run: func(command *cobra.Command, args []string) {
+		cmd.CheckArgs(1, 1, command, args)
+		fsrc := cmd.NewFsSrc(args)
+		cmd.Run(false, false, command, func() error {
+			ctx := context.Background()
+			maxDepth := fs.GetConfig(ctx).MaxDepth
+			root, err := Du(ctx, fsrc)
+			if err != nil {
+				return err
+			}
+			return Write(os.Stdout, root, maxDepth, sortBy, jsonOutput)
+		})
+	},
+}
+
+// Dir is the cumulative size and number of objects of a directory
+// and everything below it
+type Dir struct {
+	Path  string `json:"path"`  // path relative to the root - "" is the root
+	Depth int    `json:"depth"` // depth of the directory - 0 is the root
+	Bytes int64  `json:"bytes"` // total size of the objects
+	Count int64  `json:"count"` // total number of objects
+	Dirs  int64  `json:"dirs"`  // total number of directories below this one
+
+	children []*Dir
+}
+
+// Du reads the whole of f in a single listing and returns the root
+// directory with the totals.
+//
+// The listing isn't limited by --max-depth so the totals are always
+// for everything below each directory.
+func Du(ctx context.Context, f fs.Fs) (*Dir, error) {
+	root := &Dir{}
+	dirs := map[string]*Dir{"": root}
+
+	// find the Dir for dirPath creating it and its parents if necessary
+	var findDir func(dirPath string) *Dir
+	findDir = func(dirPath string) *Dir {
+		if dir, ok := dirs[dirPath]; ok {
+			return dir
+		}
+		parent := findDir(parentOf(dirPath))
+		dir := &Dir{
+			Path:  dirPath,
+			Depth: parent.Depth + 1,
+		}
+		parent.children = append(parent.children, dir)
+		dirs[dirPath] = dir
+		return dir
+	}
+
+	err := walk.ListR(ctx, f, "", false, -1, walk.ListAll, func(entries fs.DirEntries) error {
+		for _, entry := range entries {
+			switch x := entry.(type) {
+			case fs.Object:
+				size := x.Size()
+				if size < 0 {
+					size = 0
+				}
+				for dir := findDir(parentOf(x.Remote())); ; {
+					dir.Bytes += size
+					dir.Count++
+					if dir.Depth == 0 {
+						break
+					}
+					dir = dirs[parentOf(dir.Path)]
+				}
+			case fs.Directory:
+				findDir(x.Remote())
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	countDirs(root)
+	return root, nil
+}
+
+// parentOf returns the path of the directory remote is in
+func parentOf(remote string) string {
+	parentPath := path.Dir(remote)
+	if parentPath == "." {
+		return ""
+	}
+	return parentPath
+}
+
+// countDirs sets the Dirs total of dir and its children
+func countDirs(dir *Dir) int64 {
+	dir.Dirs = 0
+	for _, child := range dir.children {
+		dir.Dirs += 1 + countDirs(child)
+	}
+	return dir.Dirs
+}
+
+// Flatten returns root and the directories below it to maxDepth (< 0
+// for no limit) with each directory followed by its children sorted
+// by sortBy which should be "name", "size" or "count".
+func Flatten(root *Dir, maxDepth int, sortBy string) ([]*Dir, error) {
+	var less func(a, b *Dir) bool
+	switch sortBy {
+	case "name":
+		less = func(a, b *Dir) bool { return a.Path < b.Path }
+	case "size":
+		less = func(a, b *Dir) bool {
+			if a.Bytes != b.Bytes {
+				return a.Bytes > b.Bytes
+			}
+			return a.Path < b.Path
+		}
+	case "count":
+		less = func(a, b *Dir) bool {
+			if a.Count != b.Count {
+				return a.Count > b.Count
+			}
+			return a.Path < b.Path
+		}
+	default:
+		return nil, errors.Errorf("unknown --sort %q - must be name, size or count", sortBy)
+	}
+	out := []*Dir{}
+	var add func(dir *Dir)
+	add = func(dir *Dir) {
+		out = append(out, dir)
+		if maxDepth >= 0 && dir.Depth >= maxDepth {
+			return
+		}
+		sort.Slice(dir.children, func(i, j int) bool { return less(dir.children[i], dir.children[j]) })
+		for _, child := range dir.children {
+			add(child)
+		}
+	}
+	add(root)
+	return out, nil
+}
+
+// Write the directories from root to maxDepth sorted by sortBy to out
+// as text or JSON
+func Write(out io.Writer, root *Dir, maxDepth int, sortBy string, asJSON bool) error {
+	dirs, err := Flatten(root, maxDepth, sortBy)
+	if err != nil {
+		return err
+	}
+	if asJSON {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "\t")
+		return enc.Encode(dirs)
+	}
+	for _, dir := range dirs {
+		name := dir.Path
+		if name == "" {
+			name = "/"
+		}
+		_, err = fmt.Fprintf(out, "%12s %8d %s\n", fs.SizeSuffix(dir.Bytes).ByteUnit(), dir.Count, name)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
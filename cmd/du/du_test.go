@@ -0,0 +1,90 @@
+package du
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	_ "github.com/pingme998/rclone/backend/local"
+	"github.com/pingme998/rclone/fs"
+	"github.com/pingme998/rclone/fstest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDu(t *testing.T) {
+	fstest.Initialise()
+	ctx := context.Background()
+
+	dir, err := ioutil.TempDir("", "rclone-du")
+	require.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+	for _, file := range []struct {
+		path string
+		size int
+	}{
+		{"x", 1000},
+		{"a/y", 3000},
+		{"a/b/z", 500},
+		{"c/w", 5000},
+	} {
+		p := filepath.Join(dir, filepath.FromSlash(file.path))
+		require.NoError(t, os.MkdirAll(filepath.Dir(p), 0777))
+		require.NoError(t, ioutil.WriteFile(p, make([]byte, file.size), 0666))
+	}
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "e"), 0777))
+
+	f, err := fs.NewFs(ctx, dir)
+	require.NoError(t, err)
+	root, err := Du(ctx, f)
+	require.NoError(t, err)
+	assert.Equal(t, int64(9500), root.Bytes)
+	assert.Equal(t, int64(4), root.Count)
+	assert.Equal(t, int64(4), root.Dirs)
+
+	buf := new(bytes.Buffer)
+	require.NoError(t, Write(buf, root, -1, "name", false))
+	assert.Equal(t, `9.277 KiByte        4 /
+3.418 KiByte        2 a
+    500 Byte        1 a/b
+4.883 KiByte        1 c
+      0 Byte        0 e
+`, buf.String())
+
+	buf.Reset()
+	require.NoError(t, Write(buf, root, 1, "size", false))
+	assert.Equal(t, `9.277 KiByte        4 /
+4.883 KiByte        1 c
+3.418 KiByte        2 a
+      0 Byte        0 e
+`, buf.String())
+
+	dirs, err := Flatten(root, 0, "count")
+	require.NoError(t, err)
+	require.Equal(t, 1, len(dirs))
+	assert.Equal(t, "", dirs[0].Path)
+
+	dirs, err = Flatten(root, -1, "count")
+	require.NoError(t, err)
+	var paths []string
+	for _, dir := range dirs {
+		paths = append(paths, dir.Path)
+	}
+	assert.Equal(t, []string{"", "a", "a/b", "c", "e"}, paths)
+
+	buf.Reset()
+	require.NoError(t, Write(buf, root, 1, "name", true))
+	assert.Contains(t, buf.String(), `"path": "a",
+		"depth": 1,
+		"bytes": 3500,
+		"count": 2,
+		"dirs": 1`)
+
+	_, err = Flatten(root, -1, "potato")
+	assert.Error(t, err)
+}
@@ -15,5 +15,9 @@ var completionDefinition = &cobra.Command{
 	Long: `
 Generates a shell completion script for rclone.
 Run with --help to list the supported shells.
+
+As well as the commands and flags, the completion scripts complete
+remote names from the config file and, once a remote name has been
+typed, paths on the remote by calling rclone to list the directory.
 `,
 }
@@ -0,0 +1,57 @@
+package genautocomplete
+
+import (
+	"log"
+	"os"
+
+	"github.com/pingme998/rclone/cmd"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	completionDefinition.AddCommand(powershellCommandDefinition)
+}
+
+var powershellCommandDefinition = &cobra.Command{
+	Use:   "powershell [output_file]",
+	Short: `Output powershell completion script for rclone.`,
+	Long: `
+Generates a PowerShell autocompletion script for rclone.
+
+PowerShell doesn't have a standard place for completion scripts so
+this writes to stdout by default. To load the completions in the
+current session run
+
+    rclone genautocomplete powershell | Out-String | Invoke-Expression
+
+To load them in every session, write the script to a file and source
+it from your profile, e.g.
+
+    rclone genautocomplete powershell rclone.ps1
+    Add-Content $PROFILE ". $PWD\rclone.ps1"
+
+The script registers an argument completer with
+Register-ArgumentCompleter which calls rclone to complete commands,
+flags, the remote names from the config file (as shown by rclone
+listremotes) and the paths on remotes.
+
+If you supply a command line argument the script will be written
+there.
+
+If output_file is "-", then the output will be written to stdout.
+`,
+	Run: func(command *cobra.Command, args []string) {
+		cmd.CheckArgs(0, 1, command, args)
+		if len(args) == 0 || args[0] == "-" {
+			err := cmd.Root.GenPowerShellCompletion(os.Stdout)
+			if err != nil {
+				log.Fatal(err)
+			}
+			return
+		}
+		err := cmd.Root.GenPowerShellCompletionFile(args[0])
+		if err != nil {
+			log.Fatal(err)
+		}
+	},
+}
@@ -0,0 +1,185 @@
+package genautocomplete
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/pingme998/rclone/cmd"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	completionDefinition.AddCommand(sftpHelpersCommandDefinition)
+}
+
+var sftpHelpersCommandDefinition = &cobra.Command{
+	Use:   "sftp-helpers output_dir",
+	Short: `Output shell helpers for SFTP servers without md5sum, sha1sum or df.`,
+	Long: `
+Generates a bundle of small POSIX shell scripts which stand in for the
+md5sum, sha1sum and df commands which the rclone sftp backend runs on
+the server to read hashes and disk usage.
+
+Use this on servers which allow commands to be run over SSH but don't
+have these binaries, for example minimal containers or appliances.
+Copy the scripts to the server, make them executable and either put
+them in the PATH of the SSH user or point the sftp backend at them
+with the ` + "`md5sum_command`" + ` and ` + "`sha1sum_command`" + ` options.
+
+    rclone genautocomplete sftp-helpers helpers
+    rclone copy helpers sftpremote:bin
+
+The scripts calculate the hashes with whichever of openssl, md5/sha1,
+shasum or busybox they find on the server and produce output in the
+same format as the GNU tools and ` + "`rclone serve sftp`" + `.
+
+The scripts are written to output_dir which is created if necessary.
+If output_dir is "-" then the scripts are written to stdout.
+`,
+	Run: func(command *cobra.Command, args []string) {
+		cmd.CheckArgs(1, 1, command, args)
+		var err error
+		if args[0] == "-" {
+			err = writeSFTPHelpers(os.Stdout)
+		} else {
+			err = WriteSFTPHelpers(args[0])
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+// hashHelper is the template for the md5sum and sha1sum helpers.
+//
+// The parameters are the name of the command, the openssl digest, the
+// BSD command and any extra tools to try before busybox.
+const hashHelper = `#!/bin/sh
+# %[1]s helper generated by rclone
+#
+# Prints "<hash>  <file>" for each file given or "<hash>  -" for
+# standard input in the same format as GNU %[1]s.
+
+sum() {
+	if command -v openssl >/dev/null 2>&1; then
+		openssl dgst -%[2]s | sed 's/^.*= *//'
+	elif command -v %[3]s >/dev/null 2>&1; then
+		%[3]s -q
+%[4]s	elif command -v busybox >/dev/null 2>&1; then
+		busybox %[1]s | cut -d ' ' -f 1
+	else
+		echo "%[1]s: no way of calculating %[2]s found" >&2
+		return 1
+	fi
+}
+
+if [ $# -eq 0 ]; then
+	hash=$(sum) || exit 1
+	echo "$hash  -"
+	exit 0
+fi
+status=0
+for file in "$@"; do
+	if [ ! -f "$file" ]; then
+		echo "%[1]s: $file: No such file" >&2
+		status=1
+		continue
+	fi
+	hash=$(sum < "$file") || exit 1
+	echo "$hash  $file"
+done
+exit $status
+`
+
+// shasumHelper tries the shasum command found on macOS and with perl
+const shasumHelper = `	elif command -v shasum >/dev/null 2>&1; then
+		shasum -a 1 | cut -d ' ' -f 1
+`
+
+// dfHelper is the df helper
+const dfHelper = `#!/bin/sh
+# df helper generated by rclone
+#
+# Prints the disk usage of the last directory given in 1K blocks in
+# the same format as "df -k -P". Any options are ignored.
+
+dir=.
+for arg in "$@"; do
+	case "$arg" in
+	-*) ;;
+	*) dir=$arg ;;
+	esac
+done
+
+if command -v busybox >/dev/null 2>&1; then
+	exec busybox df -k -P "$dir"
+fi
+# block size, total blocks, free blocks, blocks available to users
+usage=$(stat -f -c '%S %b %f %a' "$dir" 2>/dev/null) || {
+	echo "df: no way of reading disk usage found" >&2
+	exit 1
+}
+set -- $usage
+total=$(($1 / 512 * $2 / 2))
+used=$(($1 / 512 * ($2 - $3) / 2))
+avail=$(($1 / 512 * $4 / 2))
+capacity=0
+if [ $total -gt 0 ]; then
+	capacity=$((100 * used / total))
+fi
+echo "Filesystem 1024-blocks Used Available Capacity Mounted on"
+echo "rclone $total $used $avail $capacity% $dir"
+`
+
+// SFTPHelpers returns the helper scripts for SFTP servers keyed by
+// the name of the command they stand in for
+func SFTPHelpers() map[string]string {
+	return map[string]string{
+		"md5sum":  fmt.Sprintf(hashHelper, "md5sum", "md5", "md5", ""),
+		"sha1sum": fmt.Sprintf(hashHelper, "sha1sum", "sha1", "sha1", shasumHelper),
+		"df":      dfHelper,
+	}
+}
+
+// sortedSFTPHelpers returns the names of the SFTP helpers in order
+func sortedSFTPHelpers(helpers map[string]string) (names []string) {
+	for name := range helpers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// WriteSFTPHelpers writes the SFTP helper scripts into dir as
+// executable files, creating it if necessary
+func WriteSFTPHelpers(dir string) error {
+	err := os.MkdirAll(dir, 0777)
+	if err != nil {
+		return err
+	}
+	helpers := SFTPHelpers()
+	for _, name := range sortedSFTPHelpers(helpers) {
+		err = ioutil.WriteFile(filepath.Join(dir, name), []byte(helpers[name]), 0755)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeSFTPHelpers writes the SFTP helper scripts to out one after
+// another, each preceded by a comment with its name
+func writeSFTPHelpers(out *os.File) error {
+	helpers := SFTPHelpers()
+	for _, name := range sortedSFTPHelpers(helpers) {
+		_, err := fmt.Fprintf(out, "### %s\n%s\n", name, helpers[name])
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
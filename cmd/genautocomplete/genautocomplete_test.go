@@ -1,11 +1,19 @@
 package genautocomplete
 
 import (
+	"crypto/md5"
+	"crypto/sha1"
+	"fmt"
 	"io/ioutil"
 	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestCompletionBash(t *testing.T) {
@@ -108,3 +116,103 @@ func TestCompletionFishStdout(t *testing.T) {
 	assert.NoError(t, err)
 	assert.NotEmpty(t, string(output))
 }
+
+func TestCompletionPowershell(t *testing.T) {
+	tempFile, err := ioutil.TempFile("", "completion_powershell")
+	assert.NoError(t, err)
+	defer func() {
+		_ = tempFile.Close()
+		_ = os.Remove(tempFile.Name())
+	}()
+
+	powershellCommandDefinition.Run(powershellCommandDefinition, []string{tempFile.Name()})
+
+	bs, err := ioutil.ReadFile(tempFile.Name())
+	assert.NoError(t, err)
+	assert.Contains(t, string(bs), "Register-ArgumentCompleter")
+}
+
+func TestCompletionPowershellStdout(t *testing.T) {
+	originalStdout := os.Stdout
+	tempFile, err := ioutil.TempFile("", "completion_powershell")
+	assert.NoError(t, err)
+	defer func() {
+		_ = tempFile.Close()
+		_ = os.Remove(tempFile.Name())
+	}()
+
+	os.Stdout = tempFile
+	defer func() { os.Stdout = originalStdout }()
+
+	powershellCommandDefinition.Run(powershellCommandDefinition, []string{"-"})
+
+	output, err := ioutil.ReadFile(tempFile.Name())
+	assert.NoError(t, err)
+	assert.NotEmpty(t, string(output))
+}
+
+// checkDfOutput checks out can be parsed by the sftp backend
+func checkDfOutput(t *testing.T, out string) {
+	lines := strings.Split(out, "\n")
+	require.True(t, len(lines) >= 2, out)
+	fields := strings.Fields(lines[1])
+	require.True(t, len(fields) >= 6, out)
+	for _, field := range fields[1:4] {
+		_, err := strconv.ParseInt(field, 10, 64)
+		assert.NoError(t, err, out)
+	}
+}
+
+// Check the helpers produce output in the same format as GNU
+// md5sum, sha1sum and df which is what rclone serve sftp emulates.
+func TestSFTPHelpers(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("no sh found")
+	}
+	dir, err := ioutil.TempDir("", "rclone-sftp-helpers")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(dir))
+	}()
+	helpers := filepath.Join(dir, "helpers")
+	require.NoError(t, WriteSFTPHelpers(helpers))
+	data := filepath.Join(dir, "data")
+	require.NoError(t, os.Mkdir(data, 0777))
+	contents := []byte("potato")
+	require.NoError(t, ioutil.WriteFile(filepath.Join(data, "file.txt"), contents, 0666))
+
+	// run runs the helper called name in the data directory
+	run := func(t *testing.T, name string, args ...string) string {
+		cmd := exec.Command("sh", append([]string{filepath.Join(helpers, name)}, args...)...)
+		cmd.Dir = data
+		out, err := cmd.CombinedOutput()
+		if err != nil && strings.Contains(string(out), "no way of") {
+			t.Skipf("%s helper not supported here: %s", name, out)
+		}
+		require.NoError(t, err, string(out))
+		return string(out)
+	}
+
+	for _, test := range []struct {
+		name  string
+		empty string
+		sum   string
+	}{
+		{"md5sum", fmt.Sprintf("%x", md5.Sum(nil)), fmt.Sprintf("%x", md5.Sum(contents))},
+		{"sha1sum", fmt.Sprintf("%x", sha1.Sum(nil)), fmt.Sprintf("%x", sha1.Sum(contents))},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.empty+"  -\n", run(t, test.name))
+			assert.Equal(t, test.sum+"  file.txt\n", run(t, test.name, "file.txt"))
+		})
+	}
+
+	t.Run("df", func(t *testing.T) {
+		cmd := exec.Command("sh", filepath.Join(helpers, "df"), "-k", data)
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Skipf("df helper not supported here: %v: %s", err, out)
+		}
+		checkDfOutput(t, string(out))
+	})
+}
@@ -14,6 +14,7 @@ import (
 	"github.com/pingme998/rclone/fs/log/logflags"
 	"github.com/pingme998/rclone/fs/rc/rcflags"
 	"github.com/pingme998/rclone/lib/atexit"
+	"github.com/pingme998/rclone/lib/exitcode"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 )
@@ -38,49 +39,25 @@ documentation, changelog and configuration walkthroughs.
 	DisableAutoGenTag:      true,
 }
 
+// bashCompletionFunc fixes up the bash completion generated by cobra
+// which splits words on ":" (as it is in COMP_WORDBREAKS) so remote
+// paths are sent to "rclone __complete" whole. The completions are
+// returned without the part of the word up to the last ":" as bash
+// only replaces that part.
 const (
 	bashCompletionFunc = `
-__rclone_custom_func() {
-    if [[ ${#COMPREPLY[@]} -eq 0 ]]; then
-        local cur cword prev words
-        if declare -F _init_completion > /dev/null; then
-            _init_completion -n : || return
-        else
-            __rclone_init_completion -n : || return
-        fi
-	local rclone=(command rclone --ask-password=false)
-        if [[ $cur != *:* ]]; then
-            local ifs=$IFS
-            IFS=$'\n'
-            local remotes=($("${rclone[@]}" listremotes 2> /dev/null))
-            IFS=$ifs
-            local remote
-            for remote in "${remotes[@]}"; do
-                [[ $remote != $cur* ]] || COMPREPLY+=("$remote")
-            done
-            if [[ ${COMPREPLY[@]} ]]; then
-                local paths=("$cur"*)
-                [[ ! -f ${paths[0]} ]] || COMPREPLY+=("${paths[@]}")
-            fi
-        else
-            local path=${cur#*:}
-            if [[ $path == */* ]]; then
-                local prefix=$(eval printf '%s' "${path%/*}")
-            else
-                local prefix=
-            fi
-            local ifs=$IFS
-            IFS=$'\n'
-            local lines=($("${rclone[@]}" lsf "${cur%%:*}:$prefix" 2> /dev/null))
-            IFS=$ifs
-            local line
-            for line in "${lines[@]}"; do
-                local reply=${prefix:+$prefix/}$line
-                [[ $reply != $path* ]] || COMPREPLY+=("$reply")
-            done
-	    [[ ! ${COMPREPLY[@]} || $(type -t compopt) != builtin ]] || compopt -o filenames
-        fi
-        [[ ! ${COMPREPLY[@]} || $(type -t compopt) != builtin ]] || compopt -o nospace
+eval "__rclone_handle_go_custom_completion_split() $(declare -f __rclone_handle_go_custom_completion | tail -n +2)"
+__rclone_handle_go_custom_completion() {
+    local cur cword prev words
+    if declare -F _init_completion > /dev/null; then
+        _init_completion -n : || return
+    else
+        __rclone_init_completion -n : || return
+    fi
+    __rclone_handle_go_custom_completion_split
+    if [[ $cur == *:* ]]; then
+        local prefix=${cur%"${cur##*:}"}
+        COMPREPLY=("${COMPREPLY[@]#"$prefix"}")
     fi
 }
 `
@@ -148,6 +125,44 @@ var helpBackend = &cobra.Command{
 	},
 }
 
+// Show the exit codes
+var helpExitCodes = &cobra.Command{
+	Use:   "exitcodes",
+	Short: "List the exit codes rclone returns",
+	Long: `
+List the exit codes rclone returns along with their names, which are
+written to the --summary-file, and meanings.
+
+These codes are stable so scripts can use them to decide what to do
+when rclone fails. When more than one could apply, e.g. a sync which
+transferred some files but ran out of quota, the first in this list
+is returned:
+
+- usage_error
+- dir_not_found, file_not_found
+- uncategorized_error
+- transfer_exceeded
+- retry_error, no_retry_error, fatal_error
+- quota_exceeded
+- permission_denied
+- partial_transfer
+
+Errors marked as retry, no retry or fatal errors keep the codes they
+had before quota_exceeded and permission_denied were added.
+`,
+	Run: func(command *cobra.Command, args []string) {
+		showExitCodes()
+	},
+}
+
+// showExitCodes shows the exit codes with their names and meanings
+func showExitCodes() {
+	fmt.Printf("%-4s %-22s %s\n", "Code", "Name", "Meaning")
+	for _, code := range exitcode.Codes() {
+		fmt.Printf("%-4d %-22s %s\n", code, exitcode.Name(code), exitcode.Meaning(code))
+	}
+}
+
 // runRoot implements the main rclone command with no subcommands
 func runRoot(cmd *cobra.Command, args []string) {
 	if version {
@@ -209,6 +224,9 @@ func setupRootCommand(rootCmd *cobra.Command) {
 	helpCommand.AddCommand(helpFlags)
 	helpCommand.AddCommand(helpBackends)
 	helpCommand.AddCommand(helpBackend)
+	helpCommand.AddCommand(helpExitCodes)
+
+	addCompletion(rootCmd)
 
 	cobra.OnInitialize(initConfig)
 
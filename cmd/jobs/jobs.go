@@ -0,0 +1,344 @@
+// Package jobs provides the jobs command.
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/pingme998/rclone/cmd"
+	rccmd "github.com/pingme998/rclone/cmd/rc"
+	"github.com/pingme998/rclone/fs"
+	"github.com/pingme998/rclone/fs/config/flags"
+	"github.com/pingme998/rclone/fs/rc"
+	"github.com/spf13/cobra"
+)
+
+var (
+	interval = time.Second
+	all      = false
+)
+
+func init() {
+	cmd.Root.AddCommand(commandDefinition)
+	rccmd.AddClientFlags(commandDefinition.PersistentFlags())
+	commandDefinition.AddCommand(listCommand)
+	commandDefinition.AddCommand(statusCommand)
+	commandDefinition.AddCommand(stopCommand)
+	commandDefinition.AddCommand(waitCommand)
+	flags.BoolVarP(listCommand.Flags(), &all, "all", "", all, "List all jobs, not just those started with _async.")
+	flags.DurationVarP(waitCommand.Flags(), &interval, "interval", "", interval, "Time between checks of the jobs.")
+}
+
+var commandDefinition = &cobra.Command{
+	Use:   "jobs",
+	Short: `Manage the jobs of a running rclone.`,
+	Long: `
+This lists, shows, stops and waits for the jobs started with "_async"
+on a running rclone, e.g. "rclone rcd", without having to use "rclone
+rc" or curl.
+
+Use the --url flag to specify an non default URL to connect on and
+--user and --pass for the username and password in the same way as
+"rclone rc". Use --loopback to manage the jobs of this rclone instead.
+
+Job progress is read from the stats group of each job.
+`,
+}
+
+var listCommand = &cobra.Command{
+	Use:   "list",
+	Short: `List the jobs in a table.`,
+	Long: `
+Lists the jobs known to the running rclone with their status and
+progress in a table, e.g.
+
+    ID  GROUP  STATUS   DURATION  BYTES          TRANSFERS  ERRORS
+    1   job/1  success  2.5s      10M / 10M      3 / 3      0
+    2   job/2  running  1m0s      1.2G / 4.0G    12 / 40    1
+
+Only the jobs started with "_async" are listed unless --all is used,
+as the running rclone makes a job for every call made to it.
+
+Finished jobs are kept for a while after they finish, controlled by
+--rc-job-expire-duration on the running rclone.
+`,
+	Run: func(command *cobra.Command, args []string) {
+		cmd.CheckArgs(0, 0, command, args)
+		cmd.Run(false, false, command, func() error {
+			ctx := context.Background()
+			rccmd.ParseFlags()
+			return list(ctx, os.Stdout, all)
+		})
+	},
+}
+
+var statusCommand = &cobra.Command{
+	Use:   "status jobid",
+	Short: `Show the status of a job.`,
+	Run: func(command *cobra.Command, args []string) {
+		cmd.CheckArgs(1, 1, command, args)
+		cmd.Run(false, false, command, func() error {
+			ctx := context.Background()
+			rccmd.ParseFlags()
+			jobID, err := parseJobID(args[0])
+			if err != nil {
+				return err
+			}
+			return status(ctx, os.Stdout, jobID)
+		})
+	},
+}
+
+var stopCommand = &cobra.Command{
+	Use:   "stop jobid [jobid ...]",
+	Short: `Stop running jobs.`,
+	Run: func(command *cobra.Command, args []string) {
+		cmd.CheckArgs(1, 1e9, command, args)
+		cmd.Run(false, false, command, func() error {
+			ctx := context.Background()
+			rccmd.ParseFlags()
+			for _, arg := range args {
+				jobID, err := parseJobID(arg)
+				if err != nil {
+					return err
+				}
+				_, err = rccmd.DoCall(ctx, "job/stop", rc.Params{"jobid": jobID})
+				if err != nil {
+					return errors.Wrapf(err, "failed to stop job %d", jobID)
+				}
+			}
+			return nil
+		})
+	},
+}
+
+var waitCommand = &cobra.Command{
+	Use:   "wait jobid [jobid ...]",
+	Short: `Wait for jobs to finish.`,
+	Long: `
+Waits for the jobs to finish, printing their progress every --interval.
+
+This returns an error if any of the jobs failed.
+`,
+	Run: func(command *cobra.Command, args []string) {
+		cmd.CheckArgs(1, 1e9, command, args)
+		cmd.Run(false, false, command, func() error {
+			ctx := context.Background()
+			rccmd.ParseFlags()
+			var jobIDs []int64
+			for _, arg := range args {
+				jobID, err := parseJobID(arg)
+				if err != nil {
+					return err
+				}
+				jobIDs = append(jobIDs, jobID)
+			}
+			return wait(ctx, os.Stdout, jobIDs, interval)
+		})
+	},
+}
+
+// parseJobID parses a job ID from the command line
+func parseJobID(arg string) (int64, error) {
+	jobID, err := strconv.ParseInt(arg, 10, 64)
+	if err != nil {
+		return 0, errors.Errorf("bad job ID %q", arg)
+	}
+	return jobID, nil
+}
+
+// job is the status of a job as returned by job/status
+type job struct {
+	ID        int64     `json:"id"`
+	Group     string    `json:"group"`
+	StartTime time.Time `json:"startTime"`
+	Error     string    `json:"error"`
+	Finished  bool      `json:"finished"`
+	Success   bool      `json:"success"`
+	Duration  float64   `json:"duration"`
+	Async     bool      `json:"async"`
+	stats     rc.Params // stats of the job's group
+}
+
+// getJob reads the status and the stats of the job
+func getJob(ctx context.Context, jobID int64) (*job, error) {
+	out, err := rccmd.DoCall(ctx, "job/status", rc.Params{"jobid": jobID})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read status of job %d", jobID)
+	}
+	j := new(job)
+	err = rc.Reshape(j, out)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to decode status of job %d", jobID)
+	}
+	j.stats, err = rccmd.DoCall(ctx, "core/stats", rc.Params{"group": j.Group})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read stats of job %d", jobID)
+	}
+	return j, nil
+}
+
+// state returns a word describing the state of the job
+func (j *job) state() string {
+	switch {
+	case !j.Finished:
+		return "running"
+	case j.Success:
+		return "success"
+	}
+	return "failed"
+}
+
+// duration returns how long the job has run for
+func (j *job) duration() time.Duration {
+	if !j.Finished {
+		return time.Since(j.StartTime).Truncate(time.Second)
+	}
+	return time.Duration(j.Duration * float64(time.Second)).Truncate(time.Millisecond)
+}
+
+// stat returns the stat called key or 0 if not found
+func (j *job) stat(key string) int64 {
+	value, err := j.stats.GetInt64(key)
+	if err != nil {
+		return 0
+	}
+	return value
+}
+
+// row returns the job as a row for the table
+func (j *job) row() []string {
+	return []string{
+		strconv.FormatInt(j.ID, 10),
+		j.Group,
+		j.state(),
+		j.duration().String(),
+		fmt.Sprintf("%v / %v", fs.SizeSuffix(j.stat("bytes")), fs.SizeSuffix(j.stat("totalBytes"))),
+		fmt.Sprintf("%d / %d", j.stat("transfers"), j.stat("totalTransfers")),
+		strconv.FormatInt(j.stat("errors"), 10),
+	}
+}
+
+// header is the header for the table of jobs
+var header = []string{"ID", "GROUP", "STATUS", "DURATION", "BYTES", "TRANSFERS", "ERRORS"}
+
+// printTable prints the rows with the columns lined up
+func printTable(out io.Writer, rows [][]string) {
+	var widths []int
+	for _, row := range rows {
+		for i, cell := range row {
+			if i >= len(widths) {
+				widths = append(widths, 0)
+			}
+			if len(cell) > widths[i] {
+				widths[i] = len(cell)
+			}
+		}
+	}
+	for _, row := range rows {
+		var line strings.Builder
+		for i, cell := range row {
+			if i == len(row)-1 {
+				line.WriteString(cell)
+			} else {
+				_, _ = fmt.Fprintf(&line, "%-*s  ", widths[i], cell)
+			}
+		}
+		_, _ = fmt.Fprintln(out, line.String())
+	}
+}
+
+// listJobIDs returns the IDs of the jobs
+func listJobIDs(ctx context.Context) (jobIDs []int64, err error) {
+	out, err := rccmd.DoCall(ctx, "job/list", nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list jobs")
+	}
+	var list struct {
+		JobIDs []int64 `json:"jobids"`
+	}
+	err = rc.Reshape(&list, out)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decode job list")
+	}
+	sort.Slice(list.JobIDs, func(i, j int) bool { return list.JobIDs[i] < list.JobIDs[j] })
+	return list.JobIDs, nil
+}
+
+// list the jobs in a table, only those started with _async unless
+// all is set
+func list(ctx context.Context, out io.Writer, all bool) error {
+	jobIDs, err := listJobIDs(ctx)
+	if err != nil {
+		return err
+	}
+	rows := [][]string{header}
+	for _, jobID := range jobIDs {
+		j, err := getJob(ctx, jobID)
+		if err != nil {
+			return err
+		}
+		if !j.Async && !all {
+			continue
+		}
+		rows = append(rows, j.row())
+	}
+	printTable(out, rows)
+	return nil
+}
+
+// status prints the status of a job
+func status(ctx context.Context, out io.Writer, jobID int64) error {
+	j, err := getJob(ctx, jobID)
+	if err != nil {
+		return err
+	}
+	row := j.row()
+	rows := [][]string{}
+	for i := range header {
+		rows = append(rows, []string{header[i] + ":", row[i]})
+	}
+	rows = append(rows, []string{"STARTED:", j.StartTime.Local().Format(time.RFC3339)})
+	if j.Error != "" {
+		rows = append(rows, []string{"ERROR:", j.Error})
+	}
+	printTable(out, rows)
+	return nil
+}
+
+// wait for the jobs to finish printing their progress every interval
+func wait(ctx context.Context, out io.Writer, jobIDs []int64, interval time.Duration) error {
+	for {
+		rows := [][]string{header}
+		finished := true
+		var failed []string
+		for _, jobID := range jobIDs {
+			j, err := getJob(ctx, jobID)
+			if err != nil {
+				return err
+			}
+			rows = append(rows, j.row())
+			if !j.Finished {
+				finished = false
+			} else if !j.Success {
+				failed = append(failed, fmt.Sprintf("job %d: %s", j.ID, j.Error))
+			}
+		}
+		printTable(out, rows)
+		if finished {
+			if len(failed) > 0 {
+				return errors.Errorf("%d job(s) failed: %s", len(failed), strings.Join(failed, ", "))
+			}
+			return nil
+		}
+		_, _ = fmt.Fprintln(out)
+		time.Sleep(interval)
+	}
+}
@@ -0,0 +1,74 @@
+package jobs
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/pingme998/rclone/fs/rc"
+	"github.com/pingme998/rclone/fs/rc/jobs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrintTable(t *testing.T) {
+	var out bytes.Buffer
+	printTable(&out, [][]string{
+		{"ID", "STATUS", "ERRORS"},
+		{"1", "success", "0"},
+		{"100", "failed", "10"},
+	})
+	assert.Equal(t, `ID   STATUS   ERRORS
+1    success  0
+100  failed   10
+`, out.String())
+}
+
+func TestJobsLoopback(t *testing.T) {
+	ctx := context.Background()
+	require.NoError(t, commandDefinition.PersistentFlags().Set("loopback", "true"))
+	defer func() {
+		require.NoError(t, commandDefinition.PersistentFlags().Set("loopback", "false"))
+	}()
+
+	// Start a job which succeeds and one which fails
+	okJob, _, err := jobs.NewJob(ctx, func(ctx context.Context, in rc.Params) (rc.Params, error) {
+		time.Sleep(50 * time.Millisecond)
+		return rc.Params{}, nil
+	}, rc.Params{"_async": true})
+	require.NoError(t, err)
+	failJob, _, err := jobs.NewJob(ctx, func(ctx context.Context, in rc.Params) (rc.Params, error) {
+		return nil, errors.New("potato")
+	}, rc.Params{"_async": true})
+	require.NoError(t, err)
+
+	var out bytes.Buffer
+	require.NoError(t, wait(ctx, &out, []int64{okJob.ID}, 10*time.Millisecond))
+	assert.Contains(t, out.String(), "success")
+
+	out.Reset()
+	err = wait(ctx, &out, []int64{okJob.ID, failJob.ID}, 10*time.Millisecond)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "potato")
+
+	out.Reset()
+	require.NoError(t, list(ctx, &out, false))
+	assert.Contains(t, out.String(), "STATUS")
+	assert.Contains(t, out.String(), "failed")
+	assert.NotContains(t, out.String(), "job/list")
+
+	// The loopback calls are jobs too
+	out.Reset()
+	require.NoError(t, list(ctx, &out, true))
+	assert.Greater(t, len(out.String()), 0)
+
+	out.Reset()
+	require.NoError(t, status(ctx, &out, failJob.ID))
+	assert.Contains(t, out.String(), "ERROR:")
+	assert.Contains(t, out.String(), "potato")
+
+	_, err = getJob(ctx, 1e9)
+	assert.Error(t, err)
+}
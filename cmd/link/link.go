@@ -3,7 +3,9 @@ package link
 import (
 	"context"
 	"fmt"
+	"time"
 
+	"github.com/pkg/errors"
 	"github.com/pingme998/rclone/cmd"
 	"github.com/pingme998/rclone/fs"
 	"github.com/pingme998/rclone/fs/config/flags"
@@ -14,6 +16,8 @@ import (
 var (
 	expire = fs.DurationOff
 	unlink = false
+	list   = false
+	revoke = ""
 )
 
 func init() {
@@ -21,6 +25,8 @@ func init() {
 	cmdFlags := commandDefinition.Flags()
 	flags.FVarP(cmdFlags, &expire, "expire", "", "The amount of time that the link will be valid")
 	flags.BoolVarP(cmdFlags, &unlink, "unlink", "", unlink, "Remove existing public link to file/folder")
+	flags.BoolVarP(cmdFlags, &list, "list", "", list, "List the public links to the folder and everything under it")
+	flags.StringVarP(cmdFlags, &revoke, "revoke", "", revoke, "Revoke the public link with this URL")
 }
 
 var commandDefinition = &cobra.Command{
@@ -47,9 +53,28 @@ If successful, the last line of the output will contain the
 link. Exact capabilities depend on the remote, but the link will
 always by default be created with the least constraints – e.g. no
 expiry, no password protection, accessible without account.
+
+Use the --list flag to show the public links to the folder and
+everything under it, one per line as the path, the link and the
+expiry time if set. Use the --revoke flag to remove a link listed
+there. This can find links which --unlink can't, e.g. to files which
+were moved or links made outside rclone.
+
+    rclone link --list remote:path/to/folder
+    rclone link --revoke https://example.com/link remote:
+
+**Note** only Box and Dropbox, and crypt remotes wrapping them,
+support --list and --revoke.
 `,
 	Run: func(command *cobra.Command, args []string) {
 		cmd.CheckArgs(1, 1, command, args)
+		if list || revoke != "" {
+			fdst := cmd.NewFsDir(args)
+			cmd.Run(false, false, command, func() error {
+				return manageLinks(context.Background(), fdst)
+			})
+			return
+		}
 		fsrc, remote := cmd.NewFsFile(args[0])
 		cmd.Run(false, false, command, func() error {
 			link, err := operations.PublicLink(context.Background(), fsrc, remote, expire, unlink)
@@ -63,3 +88,25 @@ expiry, no password protection, accessible without account.
 		})
 	},
 }
+
+// manageLinks lists or revokes the public links under f
+func manageLinks(ctx context.Context, f fs.Fs) error {
+	if list && revoke != "" {
+		return errors.New("can't use --list and --revoke together")
+	}
+	if revoke != "" {
+		return operations.RevokeLink(ctx, f, revoke)
+	}
+	links, err := operations.ListLinks(ctx, f, "")
+	if err != nil {
+		return err
+	}
+	for _, link := range links {
+		if link.Expires.IsZero() {
+			fmt.Printf("%s\t%s\n", link.Remote, link.URL)
+		} else {
+			fmt.Printf("%s\t%s\t%s\n", link.Remote, link.URL, link.Expires.Local().Format(time.RFC3339))
+		}
+	}
+	return nil
+}
@@ -1,10 +1,16 @@
 package lsf
 
 import (
+	"bytes"
 	"context"
+	encodingcsv "encoding/csv"
 	"fmt"
 	"io"
 	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
 
 	"github.com/pkg/errors"
 	"github.com/pingme998/rclone/cmd"
@@ -26,6 +32,8 @@ var (
 	dirsOnly  bool
 	csv       bool
 	absolute  bool
+	summarize bool
+	metaKeys  []string
 )
 
 func init() {
@@ -40,6 +48,8 @@ func init() {
 	flags.BoolVarP(cmdFlags, &csv, "csv", "", false, "Output in CSV format.")
 	flags.BoolVarP(cmdFlags, &absolute, "absolute", "", false, "Put a leading / in front of path names.")
 	flags.BoolVarP(cmdFlags, &recurse, "recursive", "R", false, "Recurse into the listing.")
+	flags.BoolVarP(cmdFlags, &summarize, "summarize", "", false, "Append rows with the number and size of files in each directory and in total.")
+	flags.StringArrayVarP(cmdFlags, &metaKeys, "metadata-key", "", nil, "Show this metadata key when `M` is used in the format (may be repeated).")
 }
 
 var commandDefinition = &cobra.Command{
@@ -73,6 +83,7 @@ output:
     m - MimeType of object if known
     e - encrypted name
     T - tier of storage if known, e.g. "Hot" or "Cool"
+    M - backend specific metadata if known
 
 So if you wanted the path, size and modification time, you would use
 --format "pst", or maybe --format "tsp" to put the path last.
@@ -108,6 +119,20 @@ Eg
 
 (Though "rclone md5sum ." is an easier way of typing this.)
 
+If you specify "M" in the format you will get the backend specific
+metadata of each object (see the lsjson command for which backends
+have any). Use the "--metadata-key" flag to choose which keys you
+want - "M" will then output a field for each key, in the order given,
+which is empty if the object doesn't have that key. Without
+"--metadata-key" "M" outputs all the metadata as "key=value" pairs
+separated by ",".
+
+Eg
+
+    $ rclone lsf --format "pM" --metadata-key owner --metadata-key replication hdfs:dir
+    file1;hadoop;3
+    file2;hadoop;1
+
 By default the separator is ";" this can be changed with the
 --separator flag.  Note that separators aren't escaped in the path so
 putting it last is a good strategy.
@@ -131,6 +156,26 @@ Eg
     test.sh,449
     "this file contains a comma, in the file name.txt",6
 
+Use the --summarize flag to append rows with the number of files and
+their total size after the listing, so you don't need to run "rclone
+size" on the same remote as well. Each row has the fields "dir", the
+directory path, the number of files and the total size of the files
+in that directory and its subdirectories. The directory rows are only
+output with --recursive. The last row has "total" in place of "dir" and
+an empty path and counts all the files listed. The rows use the same
+--separator and --csv quoting as the rest of the listing.
+
+Eg
+
+    $ rclone lsf --csv -R --summarize --format sp remote:path
+    321,file2
+    1234,file3
+    -1,subdir/
+    1,subdir/file2
+    111,subdir/file3
+    dir,subdir/,2,112
+    total,,4,1667
+
 Note that the --absolute parameter is useful for making lists of files
 to pass to an rclone copy with the --files-from-raw flag.
 
@@ -172,6 +217,11 @@ func Lsf(ctx context.Context, fsrc fs.Fs, out io.Writer) error {
 		FilesOnly:  filesOnly,
 		Recurse:    recurse,
 	}
+	// The files are needed to count them even if they aren't output
+	if summarize {
+		opt.DirsOnly = false
+	}
+	totals := newDirTotals()
 
 	for _, char := range format {
 		switch char {
@@ -199,13 +249,114 @@ func Lsf(ctx context.Context, fsrc fs.Fs, out io.Writer) error {
 			opt.ShowOrigIDs = true
 		case 'T':
 			list.AddTier()
+		case 'M':
+			list.AddMetadata(metaKeys)
+			opt.ShowMetadata = true
 		default:
 			return errors.Errorf("Unknown format character %q", char)
 		}
 	}
 
-	return operations.ListJSON(ctx, fsrc, "", &opt, func(item *operations.ListJSONItem) error {
+	err := operations.ListJSON(ctx, fsrc, "", &opt, func(item *operations.ListJSONItem) error {
+		if summarize {
+			totals.add(item)
+			if dirsOnly && !item.IsDir {
+				return nil
+			}
+		}
 		_, _ = fmt.Fprintln(out, list.Format(item))
 		return nil
 	})
+	if err != nil || !summarize {
+		return err
+	}
+	totals.write(out)
+	return nil
+}
+
+// dirTotal is the number and size of the files in a directory
+type dirTotal struct {
+	count int64
+	bytes int64
+}
+
+// dirTotals accumulates the totals for --summarize
+type dirTotals struct {
+	dirs  map[string]*dirTotal // totals for each directory including subdirectories
+	total dirTotal             // grand total
+}
+
+func newDirTotals() *dirTotals {
+	return &dirTotals{
+		dirs: make(map[string]*dirTotal),
+	}
+}
+
+// dir returns the total for dir making it if necessary
+func (t *dirTotals) dir(dir string) *dirTotal {
+	total := t.dirs[dir]
+	if total == nil {
+		total = new(dirTotal)
+		t.dirs[dir] = total
+	}
+	return total
+}
+
+// add item to the totals
+func (t *dirTotals) add(item *operations.ListJSONItem) {
+	if item.IsDir {
+		// make sure empty directories are shown
+		t.dir(item.Path)
+		return
+	}
+	size := item.Size
+	if size < 0 {
+		size = 0
+	}
+	t.total.count++
+	t.total.bytes += size
+	for dir := path.Dir(item.Path); dir != "." && dir != "/"; dir = path.Dir(dir) {
+		total := t.dir(dir)
+		total.count++
+		total.bytes += size
+	}
+}
+
+// row formats a row of the totals with the same separator and
+// quoting as the listing
+func row(fields ...string) string {
+	if !csv {
+		return strings.Join(fields, separator)
+	}
+	var buf bytes.Buffer
+	w := encodingcsv.NewWriter(&buf)
+	if separator != "" {
+		w.Comma = []rune(separator)[0]
+	}
+	_ = w.Write(fields) // can't fail writing to bytes.Buffer
+	w.Flush()
+	return strings.TrimRight(buf.String(), "\n")
+}
+
+// write the totals to out
+func (t *dirTotals) write(out io.Writer) {
+	if recurse {
+		dirs := make([]string, 0, len(t.dirs))
+		for dir := range t.dirs {
+			dirs = append(dirs, dir)
+		}
+		sort.Strings(dirs)
+		for _, dir := range dirs {
+			total := t.dirs[dir]
+			dirPath := dir
+			if absolute && !strings.HasPrefix(dirPath, "/") {
+				dirPath = "/" + dirPath
+			}
+			if dirSlash {
+				dirPath += "/"
+			}
+			_, _ = fmt.Fprintln(out, row("dir", dirPath, strconv.FormatInt(total.count, 10), strconv.FormatInt(total.bytes, 10)))
+		}
+	}
+	_, _ = fmt.Fprintln(out, row("total", "", strconv.FormatInt(t.total.count, 10), strconv.FormatInt(t.total.bytes, 10)))
 }
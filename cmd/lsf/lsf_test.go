@@ -223,3 +223,46 @@ subdir/file3_+_111_+_`+expectedOutput[6]+`
 	recurse = false
 	dirSlash = false
 }
+
+func TestSummarizeFlag(t *testing.T) {
+	fstest.Initialise()
+	f, err := fs.NewFs(context.Background(), "testfiles")
+	require.NoError(t, err)
+	format = "sp"
+	separator = ","
+	csv = true
+	recurse = true
+	dirSlash = true
+	summarize = true
+
+	buf := new(bytes.Buffer)
+	err = Lsf(context.Background(), f, buf)
+	require.NoError(t, err)
+	assert.Equal(t, `0,file1
+321,file2
+1234,file3
+-1,subdir/
+0,subdir/file1
+1,subdir/file2
+111,subdir/file3
+dir,subdir/,3,112
+total,,6,1667
+`, buf.String())
+
+	buf = new(bytes.Buffer)
+	recurse = false
+	dirsOnly = true
+	csv = false
+	separator = ";"
+	err = Lsf(context.Background(), f, buf)
+	require.NoError(t, err)
+	assert.Equal(t, `-1;subdir/
+total;;3;1555
+`, buf.String())
+
+	format = ""
+	separator = ""
+	dirsOnly = false
+	dirSlash = false
+	summarize = false
+}
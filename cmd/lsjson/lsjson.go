@@ -30,6 +30,8 @@ func init() {
 	flags.BoolVarP(cmdFlags, &opt.FilesOnly, "files-only", "", false, "Show only files in the listing.")
 	flags.BoolVarP(cmdFlags, &opt.DirsOnly, "dirs-only", "", false, "Show only directories in the listing.")
 	flags.StringArrayVarP(cmdFlags, &opt.HashTypes, "hash-type", "", nil, "Show only this hash type (may be repeated).")
+	flags.BoolVarP(cmdFlags, &opt.ShowMetadata, "metadata", "", false, "Include backend specific metadata in the output (may take longer).")
+	flags.BoolVarP(cmdFlags, &opt.ShowTags, "tags", "", false, "Include the user defined tags in the output (may take longer).")
 }
 
 var commandDefinition = &cobra.Command{
@@ -57,6 +59,9 @@ The output is an array of Items, where each Item looks like this
       "Path" : "full/path/goes/here/file.txt",
       "Size" : 6,
       "Tier" : "hot",
+      "Metadata" : {
+         "mtime" : "1496243757.034468261"
+      }
    }
 
 If --hash is not specified the Hashes property won't be emitted. The
@@ -73,6 +78,21 @@ request (e.g. s3, swift).
 
 If --encrypted is not specified the Encrypted won't be emitted.
 
+If --metadata is specified then the backend specific metadata of each
+object will be emitted as Metadata, a dictionary of strings. Only
+some backends have any, for example
+
+- s3 - the user metadata of the object (keys are in lower case)
+- drive - the properties of the file, with the app properties prefixed with "app:"
+- hdfs - the replication, blocksize, owner, group and permissions
+
+This will take an extra request per object on s3 and drive.
+
+If --tags is specified then the user defined tags of each object, as
+set with the --tag flag, will be emitted as Tags, a dictionary of
+strings. These are the object tags on s3 and the custom metadata on
+gcs. This will take an extra request per object.
+
 If --dirs-only is not specified files in addition to directories are
 returned
 
@@ -45,15 +45,18 @@ type Options struct {
 	DaemonTimeout      time.Duration // OSXFUSE only
 	AsyncRead          bool
 	NetworkMode        bool // Windows only
+	AutoRemount        bool
+	AutoRemountMaxWait time.Duration // longest wait between remount attempts
 }
 
 // DefaultOpt is the default values for creating the mount
 var DefaultOpt = Options{
-	MaxReadAhead:  128 * 1024,
-	AttrTimeout:   1 * time.Second, // how long the kernel caches attribute for
-	NoAppleDouble: true,            // use noappledouble by default
-	NoAppleXattr:  false,           // do not use noapplexattr by default
-	AsyncRead:     true,            // do async reads by default
+	MaxReadAhead:       128 * 1024,
+	AttrTimeout:        1 * time.Second, // how long the kernel caches attribute for
+	NoAppleDouble:      true,            // use noappledouble by default
+	NoAppleXattr:       false,           // do not use noapplexattr by default
+	AsyncRead:          true,            // do async reads by default
+	AutoRemountMaxWait: 5 * time.Minute, // back off remounts up to this
 }
 
 type (
@@ -95,6 +98,8 @@ func AddFlags(flagSet *pflag.FlagSet) {
 	flags.BoolVarP(flagSet, &Opt.AllowRoot, "allow-root", "", Opt.AllowRoot, "Allow access to root user. Not supported on Windows.")
 	flags.BoolVarP(flagSet, &Opt.AllowOther, "allow-other", "", Opt.AllowOther, "Allow access to other users. Not supported on Windows.")
 	flags.BoolVarP(flagSet, &Opt.AsyncRead, "async-read", "", Opt.AsyncRead, "Use asynchronous reads. Not supported on Windows.")
+	flags.BoolVarP(flagSet, &Opt.AutoRemount, "auto-remount", "", Opt.AutoRemount, "Remount if the FUSE session dies or the mount point stops responding. Not supported on Windows.")
+	flags.DurationVarP(flagSet, &Opt.AutoRemountMaxWait, "auto-remount-max-wait", "", Opt.AutoRemountMaxWait, "Longest time to wait between remount attempts with --auto-remount.")
 	flags.FVarP(flagSet, &Opt.MaxReadAhead, "max-read-ahead", "", "The number of bytes that can be prefetched for sequential reads. Not supported on Windows.")
 	flags.BoolVarP(flagSet, &Opt.WritebackCache, "write-back-cache", "", Opt.WritebackCache, "Makes kernel buffer writes before sending them to rclone. Without this, writethrough caching is used. Not supported on Windows.")
 	// Windows and OSX
@@ -444,6 +449,19 @@ after the mountpoint has been successfully set up.
 Units having the rclone @ service specified as a requirement
 will see all files and folders immediately in this mode.
 
+### Automatic remounting
+
+With |--auto-remount| rclone checks the mount point every 10 seconds.
+If the FUSE session dies, or the mount point fails to respond 3 times
+in a row, rclone unmounts it and mounts it again. If the remount
+fails it is retried, doubling the wait between tries up to
+|--auto-remount-max-wait| (default 5m). The VFS and its cache are kept
+across the remount so no cached or pending uploads are lost.
+
+The state of mounts made with the rc can be read with the
+|mount/listmounts| and |mount/status| rc calls, and |mount/status| can
+wait for the state to change.
+
 ### chunked reading
 
 |--vfs-read-chunk-size| will enable reading the source objects in parts.
@@ -541,6 +559,21 @@ When |--vfs-read-chunk-size-limit 500M| is specified, the result would be
 	return commandDefinition
 }
 
+// NodeAttrTimeout returns how long the kernel may cache the attributes
+// of node for.
+//
+// This is --attr-timeout unless the attributes of a file were read
+// with --vfs-readdirplus in which case they are valid for as long as
+// the directory cache if that is longer.
+func (opt *Options) NodeAttrTimeout(node vfs.Node) time.Duration {
+	if file, ok := node.(*vfs.File); ok && file.AttrCached() {
+		if dirCacheTime := file.VFS().Opt.DirCacheTime; dirCacheTime > opt.AttrTimeout {
+			return dirCacheTime
+		}
+	}
+	return opt.AttrTimeout
+}
+
 // ClipBlocks clips the blocks pointed to the OS max
 func ClipBlocks(b *uint64) {
 	var max uint64
@@ -573,7 +606,7 @@ func Mount(VFS *vfs.VFS, mountpoint string, mount MountFn, opt *Options) error {
 	}
 
 	// Mount it
-	errChan, unmount, err := mount(VFS, mountpoint, opt)
+	s, err := newSupervisor(VFS, mountpoint, mount, opt)
 	if err != nil {
 		return errors.Wrap(err, "failed to mount FUSE fs")
 	}
@@ -583,11 +616,13 @@ func Mount(VFS *vfs.VFS, mountpoint string, mount MountFn, opt *Options) error {
 	finalise := func() {
 		finaliseOnce.Do(func() {
 			_ = sysdnotify.Stopping()
-			_ = unmount()
+			_ = s.unmount()
 		})
 	}
 	fnHandle := atexit.Register(finalise)
 	defer atexit.Unregister(fnHandle)
+	defer cmd.GracefulShutdown()()
+	defer cmd.ReloadOnSIGHUP()()
 
 	// Notify systemd
 	if err := sysdnotify.Ready(); err != nil {
@@ -602,7 +637,7 @@ waitloop:
 	for {
 		select {
 		// umount triggered outside the app
-		case err = <-errChan:
+		case err = <-s.done:
 			break waitloop
 		// user sent SIGHUP to clear the cache
 		case <-sigHup:
@@ -3,6 +3,7 @@ package mountlib
 import (
 	"context"
 	"log"
+	"reflect"
 	"sort"
 	"sync"
 	"time"
@@ -17,12 +18,17 @@ import (
 
 // MountInfo defines the configuration for a mount
 type MountInfo struct {
-	unmountFn  UnmountFn
-	MountPoint string    `json:"MountPoint"`
-	MountedOn  time.Time `json:"MountedOn"`
-	Fs         string    `json:"Fs"`
-	MountOpt   *Options
-	VFSOpt     *vfscommon.Options
+	unmountFn    UnmountFn
+	supervisor   *supervisor
+	MountPoint   string    `json:"MountPoint"`
+	MountedOn    time.Time `json:"MountedOn"`
+	Fs           string    `json:"Fs"`
+	MountOpt     *Options
+	VFSOpt       *vfscommon.Options
+	State        string    `json:"State"`        // mounted, remounting or unmounted
+	StateChanged time.Time `json:"StateChanged"` // when State last changed
+	Remounts     int       `json:"Remounts"`     // number of times remounted with AutoRemount
+	LastError    string    `json:"LastError"`    // error which caused the last change of State
 }
 
 var (
@@ -117,7 +123,7 @@ func mountRc(ctx context.Context, in rc.Params) (out rc.Params, err error) {
 
 	if mountFns[mountType] != nil {
 		VFS := vfs.New(fdst, &vfsOpt)
-		_, unmountFn, err := mountFns[mountType](VFS, mountPoint, &mountOpt)
+		s, err := newSupervisor(VFS, mountPoint, mountFns[mountType], &mountOpt)
 
 		if err != nil {
 			log.Printf("mount FAILED: %v", err)
@@ -125,7 +131,8 @@ func mountRc(ctx context.Context, in rc.Params) (out rc.Params, err error) {
 		}
 		// Add mount to list if mount point was successfully created
 		liveMounts[mountPoint] = MountInfo{
-			unmountFn:  unmountFn,
+			unmountFn:  s.unmount,
+			supervisor: s,
 			MountedOn:  time.Now(),
 			Fs:         fdst.Name(),
 			MountPoint: mountPoint,
@@ -224,6 +231,10 @@ This takes no parameters and returns
 
 - mountPoints: list of current mount points
 
+Each mount point has a State which is "mounted", "remounting" while
+being remounted with --auto-remount or "unmounted" if it was unmounted
+outside rclone. Use mount/status to wait for the state to change.
+
 Eg
 
     rclone rc mount/listmounts
@@ -237,6 +248,9 @@ func listMountsRc(_ context.Context, in rc.Params) (out rc.Params, err error) {
 	mountMu.Lock()
 	defer mountMu.Unlock()
 	for _, a := range liveMounts {
+		if a.supervisor != nil {
+			a.supervisor.status(&a)
+		}
 		mountTypes = append(mountTypes, a)
 	}
 	return rc.Params{
@@ -244,6 +258,167 @@ func listMountsRc(_ context.Context, in rc.Params) (out rc.Params, err error) {
 	}, nil
 }
 
+func init() {
+	rc.Add(rc.Call{
+		Path:         "mount/status",
+		AuthRequired: true,
+		Fn:           mountStatusRc,
+		Title:        "Show the state of a mount point, waiting for it to change",
+		Help: `This shows the state of a mount point and can wait for it to
+change, which can be used to be notified when a mount dies and is
+remounted with --auto-remount.
+
+This takes the following parameters
+
+- mountPoint: valid path on the local machine where the mount was created (required)
+- state: if set wait until the state is different to this (optional)
+- timeout: longest time to wait for the state to change (default 1m)
+
+and returns the mount point as described in mount/listmounts with
+
+- State: "mounted", "remounting" or "unmounted"
+- StateChanged: time of the last change of state
+- Remounts: number of times the mount has been remounted
+- LastError: the error which caused the last change of state if any
+
+Eg
+
+    rclone rc mount/status mountPoint=/home/<user>/mountPoint
+    rclone rc mount/status mountPoint=/home/<user>/mountPoint state=mounted timeout=10m
+`,
+	})
+}
+
+// mountStatusRc returns the state of a mount, waiting for it to
+// change if required
+func mountStatusRc(ctx context.Context, in rc.Params) (out rc.Params, err error) {
+	mountPoint, err := in.GetString("mountPoint")
+	if err != nil {
+		return nil, err
+	}
+	state, err := in.GetString("state")
+	if err != nil && !rc.IsErrParamNotFound(err) {
+		return nil, err
+	}
+	timeout, err := in.GetDuration("timeout")
+	if rc.IsErrParamNotFound(err) {
+		timeout = time.Minute
+	} else if err != nil {
+		return nil, err
+	}
+	mountMu.Lock()
+	info, ok := liveMounts[mountPoint]
+	mountMu.Unlock()
+	if !ok || info.supervisor == nil {
+		return nil, errors.New("mount not found")
+	}
+	changed := info.supervisor.status(&info)
+	if state != "" && state == info.State {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		select {
+		case <-changed:
+		case <-timer.C:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		info.supervisor.status(&info)
+	}
+	out = make(rc.Params)
+	err = rc.Reshape(&out, info)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func init() {
+	rc.Add(rc.Call{
+		Path:         "mount/setopt",
+		AuthRequired: true,
+		Fn:           mountSetOptRc,
+		Title:        "Change the options of a mount point without remounting",
+		Help: `This changes some of the options of a live mount.
+
+This takes the following parameters
+
+- mountPoint: valid path on the local machine where the mount was created (required)
+- mountOpt: a JSON object with Mount options to change in.
+- vfsOpt: a JSON object with VFS options to change in.
+
+The options which can be changed are
+
+- mountOpt: AttrTimeout
+- vfsOpt: CacheMode, DirCacheTime, CacheMaxAge, CacheMaxSize, WriteBackBwLimit
+
+Changing any other option is an error. CacheMode can only be changed
+when no files are open and no files are waiting to be uploaded.
+Changes to CacheMaxAge and CacheMaxSize start the cache cleaner
+straight away and WriteBackBwLimit applies to uploads in progress
+which were started with a limit as well as new uploads.
+
+Durations are given in nanoseconds as in options/get.
+
+It returns the options of the mount as shown in mount/listmounts.
+
+Eg
+
+    rclone rc mount/setopt mountPoint=/mnt/tmp vfsOpt='{"CacheMode": "full", "DirCacheTime": 3600000000000}'
+    rclone rc mount/setopt mountPoint=/mnt/tmp vfsOpt='{"WriteBackBwLimit": "1M"}' mountOpt='{"AttrTimeout": 10000000000}'
+`,
+	})
+}
+
+// mountSetOptRc changes the options of a live mount
+func mountSetOptRc(ctx context.Context, in rc.Params) (out rc.Params, err error) {
+	mountPoint, err := in.GetString("mountPoint")
+	if err != nil {
+		return nil, err
+	}
+	mountMu.Lock()
+	defer mountMu.Unlock()
+	info, ok := liveMounts[mountPoint]
+	if !ok || info.supervisor == nil {
+		return nil, errors.New("mount not found")
+	}
+	VFS := info.supervisor.VFS
+
+	// Read the changes on top of the current options
+	mountOpt := *info.MountOpt
+	err = in.GetStructMissingOK("mountOpt", &mountOpt)
+	if err != nil {
+		return nil, err
+	}
+	check := mountOpt
+	check.AttrTimeout = info.MountOpt.AttrTimeout
+	if !reflect.DeepEqual(check, *info.MountOpt) {
+		return nil, errors.New("only AttrTimeout can be changed in mountOpt")
+	}
+	vfsOpt := VFS.Opt
+	err = in.GetStructMissingOK("vfsOpt", &vfsOpt)
+	if err != nil {
+		return nil, err
+	}
+
+	err = VFS.SetOptions(vfsOpt)
+	if err != nil {
+		return nil, err
+	}
+	info.MountOpt.AttrTimeout = mountOpt.AttrTimeout
+	newVFSOpt := VFS.Opt
+	info.VFSOpt = &newVFSOpt
+	liveMounts[mountPoint] = info
+	fs.Infof(nil, "Changed options of mount %q", mountPoint)
+
+	info.supervisor.status(&info)
+	out = make(rc.Params)
+	err = rc.Reshape(&out, info)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func init() {
 	rc.Add(rc.Call{
 		Path:         "mount/unmountall",
@@ -0,0 +1,85 @@
+package mountlib
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pingme998/rclone/fs"
+	"github.com/pingme998/rclone/fs/config"
+	"github.com/pingme998/rclone/fs/rc"
+	"github.com/pingme998/rclone/vfs/vfscommon"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMountSetOptRc(t *testing.T) {
+	ctx := context.Background()
+	setFast(t)
+	oldCacheDir := config.CacheDir
+	config.CacheDir = t.TempDir()
+	defer func() { config.CacheDir = oldCacheDir }()
+	m := newFakeMount()
+	mountPoint := t.TempDir()
+	s := newTestSupervisor(t, m, mountPoint, false)
+	vfsOpt := s.VFS.Opt
+	mountMu.Lock()
+	liveMounts[mountPoint] = MountInfo{
+		unmountFn:  s.unmount,
+		supervisor: s,
+		MountPoint: mountPoint,
+		MountOpt:   s.opt,
+		VFSOpt:     &vfsOpt,
+	}
+	mountMu.Unlock()
+	defer func() {
+		mountMu.Lock()
+		assert.NoError(t, performUnMount(mountPoint))
+		mountMu.Unlock()
+	}()
+	call := rc.Calls.Get("mount/setopt")
+	require.NotNil(t, call)
+
+	_, err := call.Fn(ctx, rc.Params{"mountPoint": "/notfound"})
+	assert.Error(t, err)
+
+	// can't change options which need a remount
+	_, err = call.Fn(ctx, rc.Params{
+		"mountPoint": mountPoint,
+		"mountOpt":   rc.Params{"AllowOther": true},
+	})
+	assert.Error(t, err)
+	_, err = call.Fn(ctx, rc.Params{
+		"mountPoint": mountPoint,
+		"vfsOpt":     rc.Params{"ReadOnly": true},
+	})
+	assert.Error(t, err)
+	assert.False(t, s.VFS.Opt.ReadOnly)
+
+	out, err := call.Fn(ctx, rc.Params{
+		"mountPoint": mountPoint,
+		"mountOpt":   rc.Params{"AttrTimeout": 10 * time.Second},
+		"vfsOpt": rc.Params{
+			"CacheMode":        "writes",
+			"DirCacheTime":     time.Hour,
+			"WriteBackBwLimit": "1M",
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 10*time.Second, s.opt.AttrTimeout)
+	assert.Equal(t, vfscommon.CacheModeWrites, s.VFS.Opt.CacheMode)
+	assert.Equal(t, time.Hour, s.VFS.Opt.DirCacheTime)
+	assert.Equal(t, fs.Mebi, s.VFS.Opt.WriteBackBwLimit)
+	assert.Equal(t, StateMounted, out["State"])
+
+	mountMu.Lock()
+	assert.Equal(t, s.VFS.Opt, *liveMounts[mountPoint].VFSOpt)
+	mountMu.Unlock()
+
+	_, err = call.Fn(ctx, rc.Params{
+		"mountPoint": mountPoint,
+		"vfsOpt":     rc.Params{"CacheMode": "off"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, vfscommon.CacheModeOff, s.VFS.Opt.CacheMode)
+}
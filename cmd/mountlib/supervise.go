@@ -0,0 +1,261 @@
+package mountlib
+
+import (
+	"os"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/pingme998/rclone/fs"
+	"github.com/pingme998/rclone/vfs"
+)
+
+// States of a mount as shown by the rc
+const (
+	StateMounted    = "mounted"
+	StateRemounting = "remounting"
+	StateUnmounted  = "unmounted"
+)
+
+// Health checks of the mount point with --auto-remount
+var (
+	healthCheckInterval = 10 * time.Second // time between checks
+	healthCheckTimeout  = 30 * time.Second // time a check may take before it fails
+	healthCheckFailures = 3                // checks which must fail in a row to remount
+	remountMinWait      = time.Second      // first wait between remount attempts
+)
+
+// supervisor looks after a mount until it is unmounted.
+//
+// With --auto-remount it checks the mount point is responding and
+// if it isn't, or the FUSE session dies, it unmounts it and remounts
+// it with exponential backoff. The same VFS is used for the new mount
+// so its cache is kept.
+type supervisor struct {
+	VFS        *vfs.VFS
+	mountpoint string
+	mount      MountFn
+	opt        *Options
+	stop       chan struct{} // closed when unmount is called
+	stopOnce   sync.Once
+	done       chan error    // receives the result when the mount finishes
+	checking   <-chan error  // result of a health check still running
+	mu         sync.Mutex    // protects the variables below
+	errChan    <-chan error  // from the current mount
+	unmountFn  UnmountFn     // for the current mount or nil
+	state      string        // one of the State constants
+	changed    time.Time     // when the state last changed
+	changedCh  chan struct{} // closed when the state changes
+	remounts   int           // number of successful remounts
+	lastErr    error         // error which caused the last state change
+}
+
+// newSupervisor mounts VFS on mountpoint and starts looking after the
+// mount.
+func newSupervisor(VFS *vfs.VFS, mountpoint string, mount MountFn, opt *Options) (*supervisor, error) {
+	errChan, unmountFn, err := mount(VFS, mountpoint, opt)
+	if err != nil {
+		return nil, err
+	}
+	s := &supervisor{
+		VFS:        VFS,
+		mountpoint: mountpoint,
+		mount:      mount,
+		opt:        opt,
+		stop:       make(chan struct{}),
+		done:       make(chan error, 1),
+		errChan:    errChan,
+		unmountFn:  unmountFn,
+		state:      StateMounted,
+		changed:    time.Now(),
+		changedCh:  make(chan struct{}),
+	}
+	go func() {
+		err := s.supervise()
+		s.mu.Lock()
+		s.setState(StateUnmounted, err)
+		s.mu.Unlock()
+		s.done <- err
+	}()
+	return s, nil
+}
+
+// setState changes the state - call with s.mu held
+func (s *supervisor) setState(state string, err error) {
+	if state != s.state {
+		fs.Logf(nil, "Mount %q is %s", s.mountpoint, state)
+	}
+	s.state = state
+	s.changed = time.Now()
+	s.lastErr = err
+	close(s.changedCh)
+	s.changedCh = make(chan struct{})
+}
+
+// status fills in the state of the mount in info and returns a
+// channel which is closed when the state changes
+func (s *supervisor) status(info *MountInfo) <-chan struct{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	info.State = s.state
+	info.StateChanged = s.changed
+	info.Remounts = s.remounts
+	info.LastError = ""
+	if s.lastErr != nil {
+		info.LastError = s.lastErr.Error()
+	}
+	return s.changedCh
+}
+
+// unmount stops supervising the mount and unmounts it
+func (s *supervisor) unmount() error {
+	s.stopOnce.Do(func() { close(s.stop) })
+	s.mu.Lock()
+	unmountFn := s.unmountFn
+	s.unmountFn = nil
+	s.mu.Unlock()
+	if unmountFn == nil {
+		return nil
+	}
+	return unmountFn()
+}
+
+// stopped returns true if unmount has been called
+func (s *supervisor) stopped() bool {
+	select {
+	case <-s.stop:
+		return true
+	default:
+		return false
+	}
+}
+
+// supervise waits for the mount to finish remounting it if required
+func (s *supervisor) supervise() error {
+	var tick <-chan time.Time
+	if s.opt.AutoRemount {
+		ticker := time.NewTicker(healthCheckInterval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	failures := 0
+	for {
+		s.mu.Lock()
+		errChan := s.errChan
+		s.mu.Unlock()
+		var reason error
+		select {
+		case <-s.stop:
+			return nil
+		case err := <-errChan:
+			// unmounted outside rclone or the FUSE session died
+			if s.stopped() {
+				return nil
+			}
+			if !s.opt.AutoRemount || err == nil {
+				return err
+			}
+			reason = errors.Wrap(err, "FUSE session ended")
+		case <-tick:
+			err := s.check()
+			if err == nil {
+				failures = 0
+				continue
+			}
+			failures++
+			fs.Errorf(nil, "Mount %q health check failed (%d/%d): %v", s.mountpoint, failures, healthCheckFailures, err)
+			if failures < healthCheckFailures && !errors.Is(err, syscall.ENOTCONN) {
+				continue
+			}
+			reason = err
+		}
+		failures = 0
+		if !s.remount(reason) {
+			return nil
+		}
+	}
+}
+
+// check the mount point is responding
+func (s *supervisor) check() error {
+	if s.checking == nil {
+		result := make(chan error, 1)
+		go func() {
+			_, err := os.Stat(s.mountpoint)
+			result <- err
+		}()
+		s.checking = result
+	}
+	timer := time.NewTimer(healthCheckTimeout)
+	defer timer.Stop()
+	select {
+	case err := <-s.checking:
+		s.checking = nil
+		return err
+	case <-timer.C:
+		// leave the check running - the next check waits for it
+		return errors.New("mount point not responding")
+	case <-s.stop:
+		return nil
+	}
+}
+
+// remount unmounts the dead mount and mounts it again, waiting longer
+// after each failure. It returns false if unmount was called first.
+func (s *supervisor) remount(reason error) bool {
+	fs.Errorf(nil, "Mount %q failed - remounting: %v", s.mountpoint, reason)
+	s.mu.Lock()
+	unmountFn := s.unmountFn
+	s.unmountFn = nil
+	s.setState(StateRemounting, reason)
+	s.mu.Unlock()
+	if unmountFn == nil {
+		// unmount was called while we were deciding to remount
+		return false
+	}
+
+	// Keep the VFS and its cache over the unmount
+	s.VFS.Reuse()
+	if err := unmountFn(); err != nil {
+		fs.Debugf(nil, "Unmount of %q failed - trying lazy unmount: %v", s.mountpoint, err)
+		if err := lazyUnmount(s.mountpoint); err != nil {
+			fs.Errorf(nil, "Lazy unmount of %q failed: %v", s.mountpoint, err)
+		}
+	}
+
+	wait := remountMinWait
+	for try := 1; ; try++ {
+		timer := time.NewTimer(wait)
+		select {
+		case <-s.stop:
+			timer.Stop()
+			s.VFS.Shutdown()
+			return false
+		case <-timer.C:
+		}
+		errChan, unmountFn, err := s.mount(s.VFS, s.mountpoint, s.opt)
+		if err != nil {
+			fs.Errorf(nil, "Remount of %q failed (try %d): %v", s.mountpoint, try, err)
+			s.mu.Lock()
+			s.lastErr = err
+			s.mu.Unlock()
+			wait *= 2
+			if maxWait := s.opt.AutoRemountMaxWait; maxWait > 0 && wait > maxWait {
+				wait = maxWait
+			}
+			continue
+		}
+		s.mu.Lock()
+		if s.stopped() {
+			s.mu.Unlock()
+			_ = unmountFn()
+			return false
+		}
+		s.errChan, s.unmountFn = errChan, unmountFn
+		s.remounts++
+		s.setState(StateMounted, nil)
+		s.mu.Unlock()
+		return true
+	}
+}
@@ -0,0 +1,188 @@
+package mountlib
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pingme998/rclone/fstest/mockfs"
+	"github.com/pingme998/rclone/vfs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeMount is a MountFn whose FUSE sessions can be killed
+type fakeMount struct {
+	mu        sync.Mutex
+	fail      int // fail this many mounts
+	mounts    int
+	unmounts  int
+	VFSes     []*vfs.VFS
+	errChan   chan error
+	mountedCh chan struct{} // receives a value on each successful mount
+}
+
+func newFakeMount() *fakeMount {
+	return &fakeMount{
+		mountedCh: make(chan struct{}, 100),
+	}
+}
+
+func (m *fakeMount) mount(VFS *vfs.VFS, mountpoint string, opt *Options) (<-chan error, func() error, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.fail > 0 {
+		m.fail--
+		return nil, nil, errors.New("mount failed")
+	}
+	m.mounts++
+	m.VFSes = append(m.VFSes, VFS)
+	errChan := make(chan error, 1)
+	m.errChan = errChan
+	m.mountedCh <- struct{}{}
+	unmount := func() error {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		m.unmounts++
+		VFS.Shutdown()
+		return nil
+	}
+	return errChan, unmount, nil
+}
+
+// kill the current FUSE session with err
+func (m *fakeMount) kill(err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.errChan <- err
+}
+
+// setFast makes the health checks and remounts quick for the test
+func setFast(t *testing.T) {
+	oldInterval, oldTimeout, oldWait := healthCheckInterval, healthCheckTimeout, remountMinWait
+	healthCheckInterval, healthCheckTimeout, remountMinWait = 10*time.Millisecond, time.Second, time.Millisecond
+	t.Cleanup(func() {
+		healthCheckInterval, healthCheckTimeout, remountMinWait = oldInterval, oldTimeout, oldWait
+	})
+}
+
+func newTestSupervisor(t *testing.T, m *fakeMount, mountpoint string, autoRemount bool) *supervisor {
+	f := mockfs.NewFs(context.Background(), "remote", "")
+	VFS := vfs.New(f, nil)
+	opt := DefaultOpt
+	opt.AutoRemount = autoRemount
+	opt.AutoRemountMaxWait = 4 * time.Millisecond
+	s, err := newSupervisor(VFS, mountpoint, m.mount, &opt)
+	require.NoError(t, err)
+	<-m.mountedCh
+	return s
+}
+
+// waitState waits for the supervisor to get to state
+func waitState(t *testing.T, s *supervisor, state string) MountInfo {
+	timeout := time.After(10 * time.Second)
+	for {
+		var info MountInfo
+		changed := s.status(&info)
+		if info.State == state {
+			return info
+		}
+		select {
+		case <-changed:
+		case <-timeout:
+			t.Fatalf("timed out waiting for state %q - still %q", state, info.State)
+		}
+	}
+}
+
+func TestSuperviseNoRemount(t *testing.T) {
+	setFast(t)
+	m := newFakeMount()
+	s := newTestSupervisor(t, m, t.TempDir(), false)
+
+	m.kill(errors.New("session died"))
+	err := <-s.done
+	assert.EqualError(t, err, "session died")
+	info := waitState(t, s, StateUnmounted)
+	assert.Equal(t, 0, info.Remounts)
+	assert.Equal(t, 1, m.mounts)
+}
+
+func TestSuperviseRemountOnSessionDeath(t *testing.T) {
+	setFast(t)
+	m := newFakeMount()
+	s := newTestSupervisor(t, m, t.TempDir(), true)
+
+	m.fail = 2
+	m.kill(errors.New("session died"))
+	<-m.mountedCh
+	info := waitState(t, s, StateMounted)
+	assert.Equal(t, 1, info.Remounts)
+	assert.Equal(t, "", info.LastError)
+
+	m.mu.Lock()
+	assert.Equal(t, 2, m.mounts)
+	assert.Equal(t, 1, m.unmounts)
+	assert.Equal(t, m.VFSes[0], m.VFSes[1], "VFS should be kept over a remount")
+	m.mu.Unlock()
+
+	require.NoError(t, s.unmount())
+	assert.NoError(t, <-s.done)
+	waitState(t, s, StateUnmounted)
+	assert.Equal(t, 2, m.unmounts)
+}
+
+func TestSuperviseUnmountedOutside(t *testing.T) {
+	setFast(t)
+	m := newFakeMount()
+	s := newTestSupervisor(t, m, t.TempDir(), true)
+
+	// a clean unmount outside rclone isn't remounted
+	m.kill(nil)
+	assert.NoError(t, <-s.done)
+	waitState(t, s, StateUnmounted)
+	assert.Equal(t, 1, m.mounts)
+}
+
+func TestSuperviseRemountOnHealthCheck(t *testing.T) {
+	setFast(t)
+	dir := t.TempDir()
+	mountpoint := filepath.Join(dir, "mnt")
+	require.NoError(t, os.Mkdir(mountpoint, 0777))
+	m := newFakeMount()
+	s := newTestSupervisor(t, m, mountpoint, true)
+
+	// make the health checks fail until remounted
+	require.NoError(t, os.Remove(mountpoint))
+	info := waitState(t, s, StateRemounting)
+	assert.Contains(t, info.LastError, "mnt")
+	require.NoError(t, ioutil.WriteFile(mountpoint, nil, 0666))
+	<-m.mountedCh
+	info = waitState(t, s, StateMounted)
+	assert.Equal(t, 1, info.Remounts)
+
+	require.NoError(t, s.unmount())
+	assert.NoError(t, <-s.done)
+}
+
+func TestSuperviseUnmountWhileRemounting(t *testing.T) {
+	setFast(t)
+	m := newFakeMount()
+	s := newTestSupervisor(t, m, t.TempDir(), true)
+
+	m.mu.Lock()
+	m.fail = 1 << 30
+	m.mu.Unlock()
+	m.kill(errors.New("session died"))
+	waitState(t, s, StateRemounting)
+
+	require.NoError(t, s.unmount())
+	assert.NoError(t, <-s.done)
+	waitState(t, s, StateUnmounted)
+	assert.Equal(t, 1, m.mounts)
+}
@@ -0,0 +1,25 @@
+// Lazy unmount for Linux only
+
+// +build linux
+
+package mountlib
+
+import (
+	"os/exec"
+
+	"github.com/pkg/errors"
+)
+
+// lazyUnmount detaches mountpoint even if it is busy or its FUSE
+// session has died
+func lazyUnmount(mountpoint string) (err error) {
+	for _, fusermount := range []string{"fusermount3", "fusermount"} {
+		var out []byte
+		out, err = exec.Command(fusermount, "-u", "-z", mountpoint).CombinedOutput()
+		if err == nil {
+			return nil
+		}
+		err = errors.Wrapf(err, "%s: %s", fusermount, out)
+	}
+	return err
+}
@@ -0,0 +1,16 @@
+// Lazy unmount for non-Linux variants
+
+// +build !linux
+
+package mountlib
+
+import (
+	"runtime"
+
+	"github.com/pkg/errors"
+)
+
+// lazyUnmount isn't supported on this platform
+func lazyUnmount(mountpoint string) error {
+	return errors.Errorf("lazy unmount not supported on %s", runtime.GOOS)
+}
@@ -19,12 +19,17 @@ import (
 	"github.com/pingme998/rclone/cmd"
 	"github.com/pingme998/rclone/cmd/ncdu/scan"
 	"github.com/pingme998/rclone/fs"
+	"github.com/pingme998/rclone/fs/config/flags"
 	"github.com/pingme998/rclone/fs/operations"
 	"github.com/spf13/cobra"
 )
 
+var exportFile = "rclone-ncdu.json"
+
 func init() {
 	cmd.Root.AddCommand(commandDefinition)
+	cmdFlags := commandDefinition.Flags()
+	flags.StringVarP(cmdFlags, &exportFile, "export", "", exportFile, "File to export the scanned tree to as JSON with the x key")
 }
 
 var commandDefinition = &cobra.Command{
@@ -52,12 +57,27 @@ but is useful as it stands.
 
 Note that it might take some time to delete big files/folders. The
 UI won't respond in the meantime since the deletion is done synchronously.
+
+Instead of deleting files and directories straight away with d you can
+mark them for deletion with m. They are shown with a * and M shows
+what is marked. When you quit, rclone shows a summary of everything
+marked and asks for confirmation before deleting it.
+
+Press x to export the tree scanned so far as JSON to the file set
+with --export ("rclone-ncdu.json" in the current directory by
+default). Each entry has its "name" and "size", and directories also
+have "isDir", the "count" of files in them and their "entries".
 `,
 	Run: func(command *cobra.Command, args []string) {
 		cmd.CheckArgs(1, 1, command, args)
 		fsrc := cmd.NewFsSrc(args)
 		cmd.Run(false, false, command, func() error {
-			return NewUI(fsrc).Show()
+			ui := NewUI(fsrc)
+			err := ui.Show()
+			if err != nil {
+				return err
+			}
+			return ui.deleteQueue(context.Background())
 		})
 	},
 }
@@ -74,6 +94,9 @@ func helpText() (tr []string) {
 		" a toggle average size in directory",
 		" n,s,C,A sort by name,size,count,average size",
 		" d delete file/directory",
+		" m mark/unmark file/directory for deletion on exit",
+		" M show files/directories marked for deletion",
+		" x export scanned tree to JSON",
 	}
 	if !clipboard.Unsupported {
 		tr = append(tr, " y copy current path to clipboard")
@@ -111,7 +134,9 @@ type UI struct {
 	sortBySize         int8
 	sortByCount        int8
 	sortByAverageSize  int8
-	dirPosMap          map[string]dirPos // store for directory positions
+	dirPosMap          map[string]dirPos     // store for directory positions
+	queue              map[string]*queueItem // files and directories marked for deletion
+	exportFile         string                // file to export the tree to
 }
 
 // Where we have got to in the directory listing
@@ -396,6 +421,9 @@ func (u *UI) Draw() error {
 					fileFlag = 'e'
 				}
 			}
+			if u.isMarked(entry) {
+				fileFlag = '*'
+			}
 			if u.showGraph {
 				bars := (size + perBar/2 - 1) / perBar
 				// clip if necessary - only happens during startup
@@ -419,6 +447,9 @@ func (u *UI) Draw() error {
 		if u.listing {
 			message = " [listing in progress]"
 		}
+		if len(u.queue) > 0 {
+			message += fmt.Sprintf(" [%d marked for deletion]", len(u.queue))
+		}
 		size, count := u.d.Attr()
 		Linef(0, h-1, w, termbox.ColorBlack, termbox.ColorWhite, ' ', "Total usage: %v, Objects: %d%s", fs.SizeSuffix(size), count, message)
 	}
@@ -502,6 +533,7 @@ func (u *UI) delete() {
 			if err != nil {
 				return "", err
 			}
+			delete(u.queue, obj.Remote())
 			u.removeEntry(dirPos)
 			if cursorPos.entry >= len(u.entries) {
 				u.move(-1) // move back onto a valid entry
@@ -520,6 +552,7 @@ func (u *UI) delete() {
 			if err != nil {
 				return "", err
 			}
+			delete(u.queue, dirEntry.Remote())
 			u.removeEntry(dirPos)
 			if cursorPos.entry >= len(u.entries) {
 				u.move(-1) // move back onto a valid entry
@@ -731,6 +764,8 @@ func NewUI(f fs.Fs) *UI {
 		sortBySize:         1,
 		sortByCount:        0,
 		dirPosMap:          make(map[string]dirPos),
+		queue:              make(map[string]*queueItem),
+		exportFile:         exportFile,
 	}
 }
 
@@ -834,6 +869,12 @@ outer:
 					u.displayPath()
 				case 'd':
 					u.delete()
+				case 'm':
+					u.toggleMark()
+				case 'M':
+					u.showQueue()
+				case 'x':
+					u.export()
 				case '?':
 					u.togglePopupBox(helpText())
 
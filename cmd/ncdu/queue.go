@@ -0,0 +1,173 @@
+//+build !plan9,!solaris,!js
+
+package ncdu
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/pingme998/rclone/fs"
+	"github.com/pingme998/rclone/fs/config"
+	"github.com/pingme998/rclone/fs/operations"
+)
+
+// queueItem is a file or directory marked for deletion
+type queueItem struct {
+	entry fs.DirEntry
+	isDir bool
+	size  int64
+	count int64 // number of files in the directory
+}
+
+// toggleMark marks or unmarks the current entry for deletion and
+// moves onto the next one
+func (u *UI) toggleMark() {
+	if u.d == nil || len(u.entries) == 0 {
+		return
+	}
+	dirPos := u.dirPosMap[u.path]
+	i := u.sortPerm[dirPos.entry]
+	entry := u.entries[i]
+	remote := entry.Remote()
+	if _, found := u.queue[remote]; found {
+		delete(u.queue, remote)
+	} else {
+		size, count, isDir, _, _, _ := u.d.AttrI(i)
+		u.queue[remote] = &queueItem{
+			entry: entry,
+			isDir: isDir,
+			size:  size,
+			count: count,
+		}
+	}
+	u.move(1)
+}
+
+// isMarked returns whether the entry is marked for deletion
+func (u *UI) isMarked(entry fs.DirEntry) bool {
+	_, found := u.queue[entry.Remote()]
+	return found
+}
+
+// queueItems returns the items to delete sorted by path leaving out
+// any inside a directory which will be deleted
+func (u *UI) queueItems() (items []*queueItem) {
+	remotes := make([]string, 0, len(u.queue))
+	for remote := range u.queue {
+		remotes = append(remotes, remote)
+	}
+	sort.Strings(remotes)
+	var dirs []string
+outer:
+	for _, remote := range remotes {
+		for _, dir := range dirs {
+			if strings.HasPrefix(remote, dir+"/") {
+				continue outer
+			}
+		}
+		item := u.queue[remote]
+		if item.isDir {
+			dirs = append(dirs, remote)
+		}
+		items = append(items, item)
+	}
+	return items
+}
+
+// queueSummary returns lines describing what is marked for deletion
+func (u *UI) queueSummary() (lines []string, total string) {
+	var files, dirs, size int64
+	for _, item := range u.queueItems() {
+		if item.isDir {
+			dirs++
+			files += item.count
+			lines = append(lines, fmt.Sprintf("%9v %s/ (%d files)", fs.SizeSuffix(item.size), item.entry.Remote(), item.count))
+		} else {
+			files++
+			lines = append(lines, fmt.Sprintf("%9v %s", fs.SizeSuffix(item.size), item.entry.Remote()))
+		}
+		size += item.size
+	}
+	total = fmt.Sprintf("%d directories and %d files, total size %v", dirs, files, fs.SizeSuffix(size))
+	return lines, total
+}
+
+// showQueue shows a box with the files and directories marked for
+// deletion
+func (u *UI) showQueue() {
+	if len(u.queue) == 0 {
+		u.togglePopupBox([]string{"Nothing marked for deletion", "Press m to mark a file or directory"})
+		return
+	}
+	lines, total := u.queueSummary()
+	const maxLines = 20
+	if len(lines) > maxLines {
+		lines = append(lines[:maxLines], fmt.Sprintf("... and %d more", len(lines)-maxLines))
+	}
+	u.togglePopupBox(append([]string{"Marked for deletion on exit", total}, lines...))
+}
+
+// deleteQueue asks for confirmation then deletes the files and
+// directories marked for deletion.
+//
+// This should be called after the user interface has been closed.
+func (u *UI) deleteQueue(ctx context.Context) error {
+	if len(u.queue) == 0 {
+		return nil
+	}
+	lines, total := u.queueSummary()
+	fmt.Printf("The following are marked for deletion from %s\n\n", u.fsName)
+	for _, line := range lines {
+		fmt.Printf("  %s\n", line)
+	}
+	fmt.Printf("\nDelete %s?\n", total)
+	if !config.Confirm(false) {
+		fmt.Printf("Nothing deleted\n")
+		return nil
+	}
+	var errCount int
+	for _, item := range u.queueItems() {
+		var err error
+		if o, ok := item.entry.(fs.Object); ok {
+			err = operations.DeleteFile(ctx, o)
+		} else {
+			err = operations.Purge(ctx, u.f, item.entry.Remote())
+		}
+		if err != nil {
+			fs.Errorf(item.entry, "Failed to delete: %v", err)
+			errCount++
+		}
+	}
+	if errCount > 0 {
+		return errors.Errorf("failed to delete %d of the marked files and directories", errCount)
+	}
+	return nil
+}
+
+// export writes the scanned tree as JSON to u.exportFile
+func (u *UI) export() {
+	if u.root == nil {
+		u.popupBox([]string{"Nothing to export yet", "Waiting for root directory..."})
+		return
+	}
+	e := u.root.Export()
+	e.Name = u.fsName
+	data, err := json.MarshalIndent(e, "", "\t")
+	if err == nil {
+		err = ioutil.WriteFile(u.exportFile, data, 0666)
+	}
+	if err != nil {
+		u.popupBox([]string{"error:", fmt.Sprintf("failed to export: %v", err)})
+		return
+	}
+	msg := "Exported scanned tree to"
+	if u.listing {
+		msg = "Exported partially scanned tree to"
+	}
+	u.popupBox([]string{"Finished:", msg, u.exportFile})
+}
@@ -0,0 +1,40 @@
+//+build !plan9,!solaris,!js
+
+package ncdu
+
+import (
+	"testing"
+
+	"github.com/pingme998/rclone/fstest/mockdir"
+	"github.com/pingme998/rclone/fstest/mockobject"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueue(t *testing.T) {
+	file := mockobject.New("file").WithContent(make([]byte, 100), mockobject.SeekModeNone)
+	dirFile := mockobject.New("dir/file").WithContent(make([]byte, 10), mockobject.SeekModeNone)
+	u := &UI{
+		queue: map[string]*queueItem{
+			"file":     {entry: file, size: 100},
+			"dir":      {entry: mockdir.New("dir"), isDir: true, size: 2048, count: 3},
+			"dir/file": {entry: dirFile, size: 10},
+		},
+	}
+
+	assert.True(t, u.isMarked(file))
+	assert.False(t, u.isMarked(mockdir.New("other")))
+
+	// Items inside a directory to be deleted are left out
+	items := u.queueItems()
+	require.Len(t, items, 2)
+	assert.Equal(t, "dir", items[0].entry.Remote())
+	assert.Equal(t, "file", items[1].entry.Remote())
+
+	lines, total := u.queueSummary()
+	assert.Equal(t, []string{
+		"      2Ki dir/ (3 files)",
+		"      100 file",
+	}, lines)
+	assert.Equal(t, "1 directories and 4 files, total size 2.098Ki", total)
+}
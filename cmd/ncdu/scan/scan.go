@@ -208,3 +208,56 @@ func Scan(ctx context.Context, f fs.Fs) (chan *Dir, chan error, chan struct{}) {
 	}()
 	return root, errChan, updated
 }
+
+// Export is a directory or file in the tree returned by Dir.Export
+type Export struct {
+	Name    string    `json:"name"`              // leaf name - the path for the root
+	Size    int64     `json:"size"`              // size of the file or total size of the directory
+	Count   int64     `json:"count,omitempty"`   // total number of files in the directory
+	IsDir   bool      `json:"isDir,omitempty"`   // set if this is a directory
+	Error   string    `json:"error,omitempty"`   // error reading the directory
+	Entries []*Export `json:"entries,omitempty"` // contents of the directory
+}
+
+// Export returns the directory and everything below it which has
+// been scanned so far in a form suitable for encoding to JSON.
+func (d *Dir) Export() *Export {
+	d.mu.Lock()
+	e := &Export{
+		Name:  path.Base(d.path),
+		Size:  d.size,
+		Count: d.count,
+		IsDir: true,
+	}
+	if d.parent == nil {
+		e.Name = d.path
+	}
+	if d.readError != nil {
+		e.Error = d.readError.Error()
+	}
+	entries := d.Entries()
+	subDirs := make([]*Dir, len(entries))
+	for i := range entries {
+		subDirs[i], _ = d.getDir(i)
+	}
+	d.mu.Unlock()
+	for i, entry := range entries {
+		if _, isDir := entry.(fs.Directory); isDir {
+			if subDirs[i] != nil {
+				e.Entries = append(e.Entries, subDirs[i].Export())
+			} else {
+				e.Entries = append(e.Entries, &Export{
+					Name:  path.Base(entry.Remote()),
+					IsDir: true,
+					Error: "not read yet",
+				})
+			}
+			continue
+		}
+		e.Entries = append(e.Entries, &Export{
+			Name: path.Base(entry.Remote()),
+			Size: entry.Size(),
+		})
+	}
+	return e
+}
@@ -0,0 +1,52 @@
+package scan
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/pingme998/rclone/fs"
+	"github.com/pingme998/rclone/fstest/mockdir"
+	"github.com/pingme998/rclone/fstest/mockobject"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newFile makes a file at remote with size bytes in it
+func newFile(remote string, size int) fs.Object {
+	return mockobject.New(remote).WithContent(make([]byte, size), mockobject.SeekModeNone)
+}
+
+func TestExport(t *testing.T) {
+	root := newDir(nil, "", fs.DirEntries{
+		newFile("file1", 1),
+		mockdir.New("dir"),
+		mockdir.New("unread"),
+	}, nil)
+	newDir(root, "dir", fs.DirEntries{
+		newFile("dir/file2", 2),
+		newFile("dir/file3", 3),
+	}, errors.New("boom"))
+
+	e := root.Export()
+	assert.Equal(t, "", e.Name)
+	assert.Equal(t, int64(6), e.Size)
+	assert.Equal(t, int64(3), e.Count)
+	assert.True(t, e.IsDir)
+	assert.Equal(t, "", e.Error)
+	require.Len(t, e.Entries, 3)
+
+	assert.Equal(t, &Export{Name: "file1", Size: 1}, e.Entries[0])
+
+	dir := e.Entries[1]
+	assert.Equal(t, "dir", dir.Name)
+	assert.Equal(t, int64(5), dir.Size)
+	assert.Equal(t, int64(2), dir.Count)
+	assert.True(t, dir.IsDir)
+	assert.Equal(t, "boom", dir.Error)
+	assert.Equal(t, []*Export{
+		{Name: "file2", Size: 2},
+		{Name: "file3", Size: 3},
+	}, dir.Entries)
+
+	assert.Equal(t, &Export{Name: "unread", IsDir: true, Error: "not read yet"}, e.Entries[2])
+}
@@ -5,6 +5,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"os"
@@ -36,13 +37,19 @@ func init() {
 	cmd.Root.AddCommand(commandDefinition)
 	cmdFlags := commandDefinition.Flags()
 	flags.BoolVarP(cmdFlags, &noOutput, "no-output", "", noOutput, "If set, don't output the JSON result.")
-	flags.StringVarP(cmdFlags, &url, "url", "", url, "URL to connect to rclone remote control.")
 	flags.StringVarP(cmdFlags, &jsonInput, "json", "", jsonInput, "Input JSON - use instead of key=value args.")
+	AddClientFlags(cmdFlags)
+	flags.StringArrayVarP(cmdFlags, &options, "opt", "o", options, "Option in the form name=value or name placed in the \"opt\" array.")
+	flags.StringArrayVarP(cmdFlags, &arguments, "arg", "a", arguments, "Argument placed in the \"arg\" array.")
+}
+
+// AddClientFlags adds the flags used to connect to a running rclone
+// to cmdFlags
+func AddClientFlags(cmdFlags *pflag.FlagSet) {
+	flags.StringVarP(cmdFlags, &url, "url", "", url, "URL to connect to rclone remote control.")
 	flags.StringVarP(cmdFlags, &authUser, "user", "", "", "Username to use to rclone remote control.")
 	flags.StringVarP(cmdFlags, &authPass, "pass", "", "", "Password to use to connect to rclone remote control.")
 	flags.BoolVarP(cmdFlags, &loopback, "loopback", "", false, "If set connect to this rclone instance not via HTTP.")
-	flags.StringArrayVarP(cmdFlags, &options, "opt", "o", options, "Option in the form name=value or name placed in the \"opt\" array.")
-	flags.StringArrayVarP(cmdFlags, &arguments, "arg", "a", arguments, "Argument placed in the \"arg\" array.")
 }
 
 var commandDefinition = &cobra.Command{
@@ -102,7 +109,7 @@ Use "rclone rc" to see a list of all possible commands.`,
 		cmd.CheckArgs(0, 1e9, command, args)
 		cmd.Run(false, false, command, func() error {
 			ctx := context.Background()
-			parseFlags()
+			ParseFlags()
 			if len(args) == 0 {
 				return list(ctx)
 			}
@@ -111,8 +118,9 @@ Use "rclone rc" to see a list of all possible commands.`,
 	},
 }
 
-// Parse the flags
-func parseFlags() {
+// ParseFlags parses the flags added by AddClientFlags. It should be
+// called before DoCall.
+func ParseFlags() {
 	// set alternates from alternate flags
 	setAlternateFlag("rc-addr", &url)
 	setAlternateFlag("rc-user", &authUser)
@@ -155,10 +163,11 @@ func setAlternateFlag(flagName string, output *string) {
 	}
 }
 
-// do a call from (path, in) to (out, err).
+// DoCall does a call from (path, in) to (out, err) on the rclone
+// set by the flags added by AddClientFlags.
 //
 // if err is set, out may be a valid error return or it may be nil
-func doCall(ctx context.Context, path string, in rc.Params) (out rc.Params, err error) {
+func DoCall(ctx context.Context, path string, in rc.Params) (out rc.Params, err error) {
 	// If loopback set, short circuit HTTP request
 	if loopback {
 		call := rc.Calls.Get(path)
@@ -179,13 +188,12 @@ func doCall(ctx context.Context, path string, in rc.Params) (out rc.Params, err
 
 	// Do HTTP request
 	client := fshttp.NewClient(ctx)
-	url += path
 	data, err := json.Marshal(in)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to encode JSON")
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(data))
+	req, err := http.NewRequestWithContext(ctx, "POST", url+path, bytes.NewBuffer(data))
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to make request")
 	}
@@ -214,6 +222,15 @@ func doCall(ctx context.Context, path string, in rc.Params) (out rc.Params, err
 		return nil, errors.Errorf("Failed to read rc response: %s: %s", resp.Status, bodyString)
 	}
 
+	// Write attachments, e.g. profiles from debug/pprof, to stdout
+	if strings.HasPrefix(resp.Header.Get("Content-Disposition"), "attachment") {
+		_, err = io.Copy(os.Stdout, resp.Body)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to write attachment")
+		}
+		return nil, nil
+	}
+
 	// Parse output
 	out = make(rc.Params)
 	err = json.NewDecoder(resp.Body).Decode(&out)
@@ -262,7 +279,7 @@ func run(ctx context.Context, args []string) (err error) {
 	}
 
 	// Do the call
-	out, callErr := doCall(ctx, path, in)
+	out, callErr := DoCall(ctx, path, in)
 
 	// Write the JSON blob to stdout if required
 	if out != nil && !noOutput {
@@ -277,7 +294,7 @@ func run(ctx context.Context, args []string) (err error) {
 
 // List the available commands to stdout
 func list(ctx context.Context) error {
-	list, err := doCall(ctx, "rc/list", nil)
+	list, err := DoCall(ctx, "rc/list", nil)
 	if err != nil {
 		return errors.Wrap(err, "failed to list")
 	}
@@ -9,6 +9,7 @@ import (
 	"github.com/pingme998/rclone/cmd"
 	"github.com/pingme998/rclone/fs/rc/rcflags"
 	"github.com/pingme998/rclone/fs/rc/rcserver"
+	"github.com/pingme998/rclone/fs/rc/scheduler"
 	"github.com/pingme998/rclone/lib/atexit"
 	"github.com/spf13/cobra"
 )
@@ -29,6 +30,10 @@ If you pass in a path to a directory, rclone will serve that directory
 for GET requests on the URL passed in.  It will also open the URL in
 the browser when rclone is run.
 
+Tasks defined in the config file are run on their schedules. See the
+[scheduled tasks](/rc/#scheduled-tasks) section of the rc documentation
+for how to define them.
+
 See the [rc documentation](/rc/) for more info on the rc flags.
 `,
 	Run: func(command *cobra.Command, args []string) {
@@ -39,6 +44,7 @@ See the [rc documentation](/rc/) for more info on the rc flags.
 
 		// Start the rc
 		rcflags.Opt.Enabled = true
+		rcflags.Opt.HTTPOptions.SocketActivation = true
 		if len(args) > 0 {
 			rcflags.Opt.Files = args[0]
 		}
@@ -51,6 +57,13 @@ See the [rc documentation](/rc/) for more info on the rc flags.
 			log.Fatal("rc server not configured")
 		}
 
+		// Run the scheduled tasks
+		sched, err := scheduler.Start(context.Background())
+		if err != nil {
+			log.Fatalf("Failed to start task scheduler: %v", err)
+		}
+		defer sched.Stop()
+
 		// Notify stopping on exit
 		var finaliseOnce sync.Once
 		finalise := func() {
@@ -60,6 +73,8 @@ See the [rc documentation](/rc/) for more info on the rc flags.
 		}
 		fnHandle := atexit.Register(finalise)
 		defer atexit.Unregister(fnHandle)
+		defer cmd.GracefulShutdown()()
+		defer cmd.ReloadOnSIGHUP()()
 
 		// Notify ready to systemd
 		if err := sysdnotify.Ready(); err != nil {
@@ -0,0 +1,176 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/pkg/errors"
+	"github.com/pingme998/rclone/fs"
+	"github.com/pingme998/rclone/fs/accounting"
+	"github.com/pingme998/rclone/fs/cache"
+	"github.com/pingme998/rclone/fs/config"
+	"github.com/pingme998/rclone/fs/config/configflags"
+	"github.com/pingme998/rclone/fs/filter/filterflags"
+	"github.com/pingme998/rclone/fs/rc"
+	"github.com/spf13/pflag"
+)
+
+var (
+	reloadMu      sync.Mutex                   // stops reloads running concurrently
+	loadedRemotes map[string]map[string]string // config of the remotes when last loaded
+)
+
+// remoteConfigs returns the key/value pairs of each remote in the
+// config file
+func remoteConfigs() map[string]map[string]string {
+	data := config.LoadedData()
+	remotes := map[string]map[string]string{}
+	for _, section := range data.GetSectionList() {
+		if !config.IsRemoteSection(section) {
+			continue
+		}
+		values := map[string]string{}
+		for _, key := range data.GetKeyList(section) {
+			values[key], _ = data.GetValue(section, key)
+		}
+		remotes[section] = values
+	}
+	return remotes
+}
+
+// changedRemotes returns the names of the remotes which were in
+// oldRemotes but have changed or been removed in newRemotes.
+//
+// Remotes which refer to a changed remote, eg a crypt remote wrapping
+// it, are counted as changed too.
+func changedRemotes(oldRemotes, newRemotes map[string]map[string]string) (changed []string) {
+	isChanged := map[string]bool{}
+	for name, values := range oldRemotes {
+		if !reflect.DeepEqual(values, newRemotes[name]) {
+			isChanged[name] = true
+		}
+	}
+	for {
+		found := false
+		for name, values := range oldRemotes {
+			if isChanged[name] {
+				continue
+			}
+			for _, value := range values {
+				for changedName := range isChanged {
+					if strings.Contains(value, changedName+":") {
+						isChanged[name] = true
+						found = true
+					}
+				}
+			}
+		}
+		if !found {
+			break
+		}
+	}
+	for name := range isChanged {
+		changed = append(changed, name)
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// Reload re-reads the config file, the options from the profiles and
+// command defaults, the filters and the bandwidth limit.
+//
+// Remotes whose config has changed are removed from the Fs cache so
+// they are re-created with the new config the next time they are
+// used. Remotes which are already in use, eg the remote being served
+// or mounted, carry on with the config they were created with.
+func Reload(ctx context.Context) error {
+	reloadMu.Lock()
+	defer reloadMu.Unlock()
+	fs.Logf(nil, "Reloading config")
+
+	err := config.LoadedData().Load()
+	if err != nil && err != config.ErrorConfigFileNotFound {
+		return errors.Wrap(err, "failed to reload config file")
+	}
+	newRemotes := remoteConfigs()
+	for _, name := range changedRemotes(loadedRemotes, newRemotes) {
+		deleted := cache.ClearConfig(name)
+		fs.Infof(nil, "Config for remote %q changed - removed %d entries from the Fs cache", name, deleted)
+	}
+	loadedRemotes = newRemotes
+
+	ci := fs.GetConfig(ctx)
+	command, commandName := findCommand()
+	err = configflags.ApplyProfiles(ci, commandName, command.Flags(), pflag.CommandLine)
+	if err != nil {
+		return errors.Wrap(err, "failed to reload profile")
+	}
+	err = filterflags.Reload(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to reload filters")
+	}
+	accounting.TokenBucket.Reload(ctx)
+	return nil
+}
+
+// ReloadOnSIGHUP calls Reload whenever rclone receives a SIGHUP until
+// the returned function is called.
+//
+// It is used by the long running commands.
+func ReloadOnSIGHUP() (stop func()) {
+	// The config file is re-read whenever it changes so remember
+	// the remotes now to find out which have changed on reload.
+	reloadMu.Lock()
+	if loadedRemotes == nil {
+		loadedRemotes = remoteConfigs()
+	}
+	reloadMu.Unlock()
+
+	sigHup := make(chan os.Signal, 1)
+	signal.Notify(sigHup, syscall.SIGHUP)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-sigHup:
+				if err := Reload(context.Background()); err != nil {
+					fs.Errorf(nil, "Failed to reload config: %v", err)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+	return func() {
+		signal.Stop(sigHup)
+		close(done)
+	}
+}
+
+func init() {
+	rc.Add(rc.Call{
+		Path:  "core/reload",
+		Fn:    rcReload,
+		Title: "Reload the config file, filters and bandwidth limit.",
+		Help: `
+This re-reads the config file, the options set from the --profile and
+command default sections in it, the filter rules and the bandwidth
+limit timetable, as happens when a long running command is sent a
+SIGHUP.
+
+Remotes whose config has changed are removed from the Fs cache so
+they are re-created with the new config the next time they are used.
+`,
+	})
+}
+
+// Reload the config
+func rcReload(ctx context.Context, in rc.Params) (out rc.Params, err error) {
+	return nil, Reload(ctx)
+}
@@ -0,0 +1,81 @@
+package restoresuffix
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+
+	"github.com/pkg/errors"
+	"github.com/pingme998/rclone/cmd"
+	"github.com/pingme998/rclone/fs/config/flags"
+	"github.com/pingme998/rclone/fs/operations"
+	"github.com/spf13/cobra"
+)
+
+var (
+	list = false
+)
+
+func init() {
+	cmd.Root.AddCommand(commandDefinition)
+	cmdFlags := commandDefinition.Flags()
+	flags.BoolVarP(cmdFlags, &list, "list", "", list, "List the versions of the file instead of restoring one.")
+}
+
+var commandDefinition = &cobra.Command{
+	Use:   "restore-suffix remote:path/file [version]",
+	Short: `Restore a numbered version of a file made with --suffix.`,
+	Long: `
+If ` + "`--suffix`" + ` contains ` + "`{n}`" + ` then each time ` + "`sync`, `copy` or `move`" + `
+would overwrite or delete a file it is backed up to a new numbered
+version instead, eg with ` + "`--suffix .~{n}~`" + ` the versions of
+` + "`file.txt`" + ` will be ` + "`file.txt.~1~`, `file.txt.~2~`" + ` etc.
+
+This command puts a version of a file back. Pass the same ` + "`--suffix`" + `,
+` + "`--suffix-keep-extension`" + ` and ` + "`--backup-dir`" + ` flags that were used to make
+the versions. If the version number isn't given then the latest
+version is restored.
+
+If the file exists it is backed up as a new version before it is
+replaced so the restore can itself be undone.
+
+Use ` + "`--list`" + ` to see the versions available.
+
+    rclone restore-suffix --suffix .~{n}~ --list remote:path/file.txt
+    rclone restore-suffix --suffix .~{n}~ remote:path/file.txt 2
+`,
+	Run: func(command *cobra.Command, args []string) {
+		cmd.CheckArgs(1, 2, command, args)
+		f, fileName := cmd.NewFsDstFile(args)
+		version := 0
+		if len(args) > 1 {
+			var err error
+			version, err = strconv.Atoi(args[1])
+			if err != nil || version <= 0 {
+				log.Fatalf("Invalid version %q", args[1])
+			}
+		}
+		cmd.Run(!list, false, command, func() error {
+			ctx := context.Background()
+			if !operations.SuffixNumbered(ctx) {
+				return errors.New("need --suffix containing {n} to restore a version")
+			}
+			if !list {
+				return operations.RestoreSuffix(ctx, f, fileName, version)
+			}
+			backupDir, err := operations.BackupDir(ctx, f, f, fileName)
+			if err != nil {
+				return err
+			}
+			versions, err := operations.SuffixVersions(ctx, backupDir, fileName)
+			if err != nil {
+				return err
+			}
+			for _, v := range versions {
+				fmt.Printf("%6d %12d %s %s\n", v.N, v.Obj.Size(), v.Obj.ModTime(ctx).Local().Format("2006-01-02 15:04:05"), v.Obj.Remote())
+			}
+			return nil
+		})
+	},
+}
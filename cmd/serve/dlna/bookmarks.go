@@ -0,0 +1,154 @@
+package dlna
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/pingme998/rclone/fs"
+	"github.com/pingme998/rclone/fs/config"
+)
+
+// bookmark is the playback position of an item saved by a client
+// with X_SetBookmark
+type bookmark struct {
+	Position      int64     `json:"position"`                 // seconds from the start
+	PlaybackCount int       `json:"playback_count,omitempty"` // number of times played to the end
+	Updated       time.Time `json:"updated"`                  // when the bookmark was last set
+}
+
+// bookmarks holds the bookmarks of all the clients, persisted in a
+// file in the cache directory so they survive restarts
+type bookmarks struct {
+	mu     sync.Mutex
+	path   string               // file the bookmarks are saved in
+	loaded bool                 // set once the file has been read
+	Items  map[string]*bookmark `json:"items"` // by bookmarkKey
+}
+
+// newBookmarks makes an empty bookmarks which will be saved in path
+func newBookmarks(path string) *bookmarks {
+	return &bookmarks{
+		path:  path,
+		Items: make(map[string]*bookmark),
+	}
+}
+
+// bookmarksPath returns the file the bookmarks for f are saved in
+func bookmarksPath(f fs.Fs) string {
+	h := md5.Sum([]byte(fs.ConfigString(f)))
+	return filepath.Join(config.CacheDir, "dlna-bookmarks", hex.EncodeToString(h[:])+".json")
+}
+
+// bookmarkKey returns the key for the bookmark of client for the
+// item at objectPath
+func bookmarkKey(client, objectPath string) string {
+	return client + "\x00" + objectPath
+}
+
+// bookmarkClient identifies the client making r by its IP address
+// as players use a different port for each request
+func bookmarkClient(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// _load reads the bookmarks from the file if they haven't been read
+// already, starting afresh if it doesn't exist or can't be read
+//
+// call with mu held
+func (b *bookmarks) _load() {
+	if b.loaded {
+		return
+	}
+	b.loaded = true
+	data, err := ioutil.ReadFile(b.path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			fs.Debugf(nil, "Failed to read DLNA bookmarks: %v", err)
+		}
+		return
+	}
+	var saved bookmarks
+	err = json.Unmarshal(data, &saved)
+	if err != nil || saved.Items == nil {
+		fs.Debugf(nil, "Ignoring corrupted DLNA bookmarks %q: %v", b.path, err)
+		return
+	}
+	b.Items = saved.Items
+}
+
+// _save writes the bookmarks to the file
+//
+// call with mu held
+func (b *bookmarks) _save() error {
+	data, err := json.Marshal(b)
+	if err != nil {
+		return errors.Wrap(err, "failed to encode DLNA bookmarks")
+	}
+	err = os.MkdirAll(filepath.Dir(b.path), 0700)
+	if err != nil {
+		return errors.Wrap(err, "failed to make DLNA bookmarks directory")
+	}
+	tmp := b.path + ".tmp"
+	err = ioutil.WriteFile(tmp, data, 0600)
+	if err != nil {
+		return errors.Wrap(err, "failed to write DLNA bookmarks")
+	}
+	return os.Rename(tmp, b.path)
+}
+
+// get returns the bookmark of client for the item at objectPath
+func (b *bookmarks) get(client, objectPath string) (bm bookmark, found bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b._load()
+	if p := b.Items[bookmarkKey(client, objectPath)]; p != nil {
+		return *p, true
+	}
+	return bm, false
+}
+
+// set saves position as the bookmark of client for the item at
+// objectPath.
+//
+// A position set back to 0 after a later one is taken to mean the
+// item was played to the end, so is counted as a playback.
+func (b *bookmarks) set(client, objectPath string, position int64) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b._load()
+	key := bookmarkKey(client, objectPath)
+	bm := b.Items[key]
+	if bm == nil {
+		bm = &bookmark{}
+		b.Items[key] = bm
+	}
+	if position <= 0 && bm.Position > 0 {
+		bm.PlaybackCount++
+	}
+	if position < 0 {
+		position = 0
+	}
+	bm.Position = position
+	bm.Updated = time.Now()
+	return b._save()
+}
+
+// formatPlaybackPosition formats seconds as H+:MM:SS as used in
+// upnp:lastPlaybackPosition
+func formatPlaybackPosition(seconds int64) string {
+	return fmt.Sprintf("%d:%02d:%02d", seconds/3600, seconds/60%60, seconds%60)
+}
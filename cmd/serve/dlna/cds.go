@@ -14,7 +14,6 @@ import (
 	"regexp"
 	"strings"
 
-	"github.com/anacrolix/dms/dlna"
 	"github.com/anacrolix/dms/upnp"
 	"github.com/pkg/errors"
 	"github.com/pingme998/rclone/cmd/serve/dlna/upnpav"
@@ -67,9 +66,10 @@ func (cds *contentDirectoryService) updateIDString() string {
 
 var mediaMimeTypeRegexp = regexp.MustCompile("^(video|audio|image)/")
 
-// Turns the given entry and DMS host into a UPnP object. A nil object is
-// returned if the entry is not of interest.
-func (cds *contentDirectoryService) cdsObjectToUpnpavObject(cdsObject object, fileInfo vfs.Node, resources vfs.Nodes, host string) (ret interface{}, err error) {
+// Turns the given entry and DMS host into a UPnP object for client
+// using profile. A nil object is returned if the entry is not of
+// interest.
+func (cds *contentDirectoryService) cdsObjectToUpnpavObject(cdsObject object, fileInfo vfs.Node, resources vfs.Nodes, host, client string, profile *deviceProfile) (ret interface{}, err error) {
 	obj := upnpav.Object{
 		ID:         cdsObject.ID(),
 		Restricted: 1,
@@ -90,15 +90,7 @@ func (cds *contentDirectoryService) cdsObjectToUpnpavObject(cdsObject object, fi
 		return
 	}
 
-	// Read the mime type from the fs.Object if possible,
-	// otherwise fall back to working out what it is from the file path.
-	var mimeType string
-	if o, ok := fileInfo.DirEntry().(fs.Object); ok {
-		mimeType = fs.MimeType(context.TODO(), o)
-	} else {
-		mimeType = fs.MimeTypeFromName(fileInfo.Name())
-	}
-
+	mimeType := nodeMimeType(fileInfo)
 	mediaType := mediaMimeTypeRegexp.FindStringSubmatch(mimeType)
 	if mediaType == nil {
 		return
@@ -112,6 +104,10 @@ func (cds *contentDirectoryService) cdsObjectToUpnpavObject(cdsObject object, fi
 		Object: obj,
 		Res:    make([]upnpav.Resource, 0, 1),
 	}
+	if bm, found := cds.bookmarks.get(client, cdsObject.Path); found {
+		item.LastPlaybackPosition = formatPlaybackPosition(bm.Position)
+		item.PlaybackCount = bm.PlaybackCount
+	}
 
 	item.Res = append(item.Res, upnpav.Resource{
 		URL: (&url.URL{
@@ -119,12 +115,13 @@ func (cds *contentDirectoryService) cdsObjectToUpnpavObject(cdsObject object, fi
 			Host:   host,
 			Path:   path.Join(resPath, cdsObject.Path),
 		}).String(),
-		ProtocolInfo: fmt.Sprintf("http-get:*:%s:%s", mimeType, dlna.ContentFeatures{
-			SupportRange: true,
-		}.String()),
-		Size: uint64(fileInfo.Size()),
+		ProtocolInfo: fmt.Sprintf("http-get:*:%s:%s", profile.mimeType(mimeType), cds.contentFeatures(fileInfo.(*vfs.File), mimeType)),
+		Size:         uint64(fileInfo.Size()),
 	})
 
+	if profile.NoSubtitles {
+		resources = nil
+	}
 	for _, resource := range resources {
 		subtitleURL := (&url.URL{
 			Scheme: "http",
@@ -133,7 +130,7 @@ func (cds *contentDirectoryService) cdsObjectToUpnpavObject(cdsObject object, fi
 		}).String()
 		item.Res = append(item.Res, upnpav.Resource{
 			URL:          subtitleURL,
-			ProtocolInfo: fmt.Sprintf("http-get:*:%s:*", "text/srt"),
+			ProtocolInfo: fmt.Sprintf("http-get:*:%s:*", profile.subtitleMimeType()),
 		})
 	}
 
@@ -141,8 +138,17 @@ func (cds *contentDirectoryService) cdsObjectToUpnpavObject(cdsObject object, fi
 	return
 }
 
+// nodeMimeType returns the mime type of node, read from the fs.Object
+// if possible, otherwise worked out from the file name.
+func nodeMimeType(node vfs.Node) string {
+	if o, ok := node.DirEntry().(fs.Object); ok {
+		return fs.MimeType(context.TODO(), o)
+	}
+	return fs.MimeTypeFromName(node.Name())
+}
+
 // Returns all the upnpav objects in a directory.
-func (cds *contentDirectoryService) readContainer(o object, host string) (ret []interface{}, err error) {
+func (cds *contentDirectoryService) readContainer(o object, host, client string, profile *deviceProfile) (ret []interface{}, err error) {
 	node, err := cds.vfs.Stat(o.Path)
 	if err != nil {
 		return
@@ -165,7 +171,7 @@ func (cds *contentDirectoryService) readContainer(o object, host string) (ret []
 		child := object{
 			path.Join(o.Path, de.Name()),
 		}
-		obj, err := cds.cdsObjectToUpnpavObject(child, de, mediaResources[de], host)
+		obj, err := cds.cdsObjectToUpnpavObject(child, de, mediaResources[de], host, client, profile)
 		if err != nil {
 			fs.Errorf(cds, "error with %s: %s", child.FilePath(), err)
 			continue
@@ -227,6 +233,22 @@ func mediaWithResources(nodes vfs.Nodes) (vfs.Nodes, map[vfs.Node]vfs.Nodes) {
 	return media, mediaResources
 }
 
+// findSubtitle returns the first external subtitle for file or nil
+// if there isn't one
+func (s *server) findSubtitle(file *vfs.File) vfs.Node {
+	nodes, err := file.Dir().ReadDirAll()
+	if err != nil {
+		return nil
+	}
+	media, mediaResources := mediaWithResources(nodes)
+	for _, node := range media {
+		if node.Path() == file.Path() && len(mediaResources[node]) > 0 {
+			return mediaResources[node][0]
+		}
+	}
+	return nil
+}
+
 type browse struct {
 	ObjectID       string
 	BrowseFlag     string
@@ -235,6 +257,14 @@ type browse struct {
 	RequestedCount int
 }
 
+// Arguments of the Samsung X_SetBookmark action
+type setBookmark struct {
+	CategoryType string
+	RID          string
+	ObjectID     string
+	PosSecond    int64
+}
+
 // ContentDirectory object from ObjectID.
 func (cds *contentDirectoryService) objectFromID(id string) (o object, err error) {
 	o.Path, err = url.QueryUnescape(id)
@@ -254,6 +284,8 @@ func (cds *contentDirectoryService) objectFromID(id string) (o object, err error
 
 func (cds *contentDirectoryService) Handle(action string, argsXML []byte, r *http.Request) (map[string]string, error) {
 	host := r.Host
+	client := bookmarkClient(r)
+	profile := cds.profileFor(r)
 
 	switch action {
 	case "GetSystemUpdateID":
@@ -275,7 +307,7 @@ func (cds *contentDirectoryService) Handle(action string, argsXML []byte, r *htt
 		}
 		switch browse.BrowseFlag {
 		case "BrowseDirectChildren":
-			objs, err := cds.readContainer(obj, host)
+			objs, err := cds.readContainer(obj, host, client, profile)
 			if err != nil {
 				return nil, upnp.Errorf(upnpav.NoSuchObjectErrorCode, err.Error())
 			}
@@ -287,8 +319,12 @@ func (cds *contentDirectoryService) Handle(action string, argsXML []byte, r *htt
 				}
 				return
 			}():]
-			if browse.RequestedCount != 0 && browse.RequestedCount < len(objs) {
-				objs = objs[:browse.RequestedCount]
+			requestedCount := browse.RequestedCount
+			if requestedCount == 0 {
+				requestedCount = profile.MaxBrowseCount
+			}
+			if requestedCount != 0 && requestedCount < len(objs) {
+				objs = objs[:requestedCount]
 			}
 			result, err := xml.Marshal(objs)
 			if err != nil {
@@ -306,7 +342,7 @@ func (cds *contentDirectoryService) Handle(action string, argsXML []byte, r *htt
 				return nil, err
 			}
 			// TODO: External subtitles won't appear in the metadata here, but probably should.
-			upnpObject, err := cds.cdsObjectToUpnpavObject(obj, node, vfs.Nodes{}, host)
+			upnpObject, err := cds.cdsObjectToUpnpavObject(obj, node, vfs.Nodes{}, host, client, profile)
 			if err != nil {
 				return nil, err
 			}
@@ -335,7 +371,18 @@ func (cds *contentDirectoryService) Handle(action string, argsXML []byte, r *htt
 	</Feature>
 </Features>`}, nil
 	case "X_SetBookmark":
-		// just ignore
+		var bookmark setBookmark
+		if err := xml.Unmarshal(argsXML, &bookmark); err != nil {
+			return nil, err
+		}
+		obj, err := cds.objectFromID(bookmark.ObjectID)
+		if err != nil {
+			return nil, upnp.Errorf(upnpav.NoSuchObjectErrorCode, err.Error())
+		}
+		fs.Debugf(cds, "%s: bookmark %q at %ds", client, obj.Path, bookmark.PosSecond)
+		if err := cds.bookmarks.set(client, obj.Path, bookmark.PosSecond); err != nil {
+			fs.Errorf(cds, "Failed to save bookmark: %v", err)
+		}
 		return map[string]string{}, nil
 	default:
 		return nil, upnp.InvalidActionError
@@ -8,11 +8,11 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"path"
 	"strconv"
 	"strings"
 	"time"
 
-	dms_dlna "github.com/anacrolix/dms/dlna"
 	"github.com/anacrolix/dms/soap"
 	"github.com/anacrolix/dms/ssdp"
 	"github.com/anacrolix/dms/upnp"
@@ -49,7 +49,11 @@ players might show files that they are not able to play back correctly.
 		f := cmd.NewFsSrc(args)
 
 		cmd.Run(false, false, command, func() error {
-			s := newServer(f, &dlnaflags.Opt)
+			defer cmd.ReloadOnSIGHUP()()
+			s, err := newServer(f, &dlnaflags.Opt)
+			if err != nil {
+				return err
+			}
 			if err := s.Serve(); err != nil {
 				return err
 			}
@@ -86,16 +90,33 @@ type server struct {
 	// Time interval between SSPD announces
 	AnnounceInterval time.Duration
 
+	// Device profiles to match clients against
+	profiles []*deviceProfile
+
+	// Set to probe media files for their DLNA profile
+	probeMedia bool
+
+	// Cache of the DLNA profiles found by probing
+	mediaProfiles mediaProfiles
+
+	// Playback positions saved by the clients
+	bookmarks *bookmarks
+
 	f   fs.Fs
 	vfs *vfs.VFS
 }
 
-func newServer(f fs.Fs, opt *dlnaflags.Options) *server {
+func newServer(f fs.Fs, opt *dlnaflags.Options) (*server, error) {
 	friendlyName := opt.FriendlyName
 	if friendlyName == "" {
 		friendlyName = makeDefaultFriendlyName()
 	}
 
+	profiles, err := loadProfiles(opt.DeviceProfiles)
+	if err != nil {
+		return nil, err
+	}
+
 	s := &server{
 		AnnounceInterval: 10 * time.Second,
 		FriendlyName:     friendlyName,
@@ -103,6 +124,9 @@ func newServer(f fs.Fs, opt *dlnaflags.Options) *server {
 		Interfaces:       listInterfaces(),
 
 		httpListenAddr: opt.ListenAddr,
+		profiles:       profiles,
+		probeMedia:     opt.ProbeMedia,
+		bookmarks:      newBookmarks(bookmarksPath(f)),
 
 		f:   f,
 		vfs: vfs.New(f, &vfsflags.Opt),
@@ -136,7 +160,7 @@ func newServer(f fs.Fs, opt *dlnaflags.Options) *server {
 			http.FileServer(data.Assets))))
 	s.handler = logging(withHeader("Server", serverField, r))
 
-	return s
+	return s, nil
 }
 
 // UPnPService is the interface for the SOAP service.
@@ -234,15 +258,30 @@ func (s *server) resourceHandler(w http.ResponseWriter, r *http.Request) {
 
 	w.Header().Set("Content-Length", strconv.FormatInt(node.Size(), 10))
 
+	file := node.(*vfs.File)
+
 	// add some DLNA specific headers
+	features := s.contentFeatures(file, nodeMimeType(node))
 	if r.Header.Get("getContentFeatures.dlna.org") != "" {
-		w.Header().Set("contentFeatures.dlna.org", dms_dlna.ContentFeatures{
-			SupportRange: true,
-		}.String())
+		w.Header().Set("contentFeatures.dlna.org", features.String())
+	}
+	if features.isImage {
+		w.Header().Set("transferMode.dlna.org", "Interactive")
+	} else {
+		w.Header().Set("transferMode.dlna.org", "Streaming")
+	}
+
+	// Samsung TVs ask for the subtitles with the media
+	if r.Header.Get("getCaptionInfo.sec") != "" && s.profileFor(r).CaptionInfo {
+		if subtitle := s.findSubtitle(file); subtitle != nil {
+			w.Header().Set("CaptionInfo.sec", (&url.URL{
+				Scheme: "http",
+				Host:   r.Host,
+				Path:   path.Join(resPath, subtitle.Path()),
+			}).String())
+		}
 	}
-	w.Header().Set("transferMode.dlna.org", "Streaming")
 
-	file := node.(*vfs.File)
 	in, err := file.Open(os.O_RDONLY)
 	if err != nil {
 		serveError(node, w, "Could not open resource", err)
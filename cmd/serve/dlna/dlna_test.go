@@ -8,6 +8,7 @@ import (
 	"io/ioutil"
 	"net/http"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -35,7 +36,9 @@ const (
 func startServer(t *testing.T, f fs.Fs) {
 	opt := dlnaflags.DefaultOpt
 	opt.ListenAddr = testBindAddress
-	dlnaServer = newServer(f, &opt)
+	var err error
+	dlnaServer, err = newServer(f, &opt)
+	require.NoError(t, err)
 	assert.NoError(t, dlnaServer.Serve())
 	baseURL = "http://" + dlnaServer.HTTPConn.Addr().String()
 }
@@ -175,6 +178,8 @@ func TestContentDirectoryBrowseDirectChildren(t *testing.T) {
 	require.Contains(t, string(body), "/r/video.mp4")
 	require.Contains(t, string(body), "/r/video.srt")
 	require.Contains(t, string(body), "/r/video.en.srt")
+	// and the DLNA flags in the protocolInfo of the media
+	require.Contains(t, string(body), "DLNA.ORG_OP=01;DLNA.ORG_CI=0;DLNA.ORG_FLAGS=01700000000000000000000000000000")
 
 	// Then a subdirectory
 	req, err = http.NewRequest("POST", baseURL+serviceControlURL, strings.NewReader(`
@@ -203,3 +208,160 @@ func TestContentDirectoryBrowseDirectChildren(t *testing.T) {
 	require.Contains(t, string(body), "/r/subdir/video.mp4")
 	require.Contains(t, string(body), "/r/subdir/video.srt")
 }
+
+// Check that the Samsung device profile is used for Samsung clients
+func TestDeviceProfileSamsung(t *testing.T) {
+	req, err := http.NewRequest("POST", baseURL+serviceControlURL, strings.NewReader(`
+<?xml version="1.0" encoding="utf-8"?>
+<s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/"
+            s:encodingStyle="http://schemas.xmlsoap.org/soap/encoding/">
+    <s:Body>
+        <u:Browse xmlns:u="urn:schemas-upnp-org:service:ContentDirectory:1">
+            <ObjectID>0</ObjectID>
+            <BrowseFlag>BrowseDirectChildren</BrowseFlag>
+            <Filter>*</Filter>
+            <StartingIndex>0</StartingIndex>
+            <RequestedCount>0</RequestedCount>
+            <SortCriteria></SortCriteria>
+        </u:Browse>
+    </s:Body>
+</s:Envelope>`))
+	require.NoError(t, err)
+	req.Header.Set("SOAPACTION", `"urn:schemas-upnp-org:service:ContentDirectory:1#Browse"`)
+	req.Header.Set("User-Agent", "SEC_HHP_[TV] Samsung Q7 Series (55)/1.0")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	body, err := ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Contains(t, string(body), "http-get:*:smi/caption:*")
+	assert.NotContains(t, string(body), "http-get:*:text/srt:*")
+
+	// The subtitles are sent with the media
+	req, err = http.NewRequest("GET", baseURL+"/r/video.mp4", nil)
+	require.NoError(t, err)
+	req.Header.Set("User-Agent", "SEC_HHP_[TV] Samsung Q7 Series (55)/1.0")
+	req.Header.Set("getCaptionInfo.sec", "1")
+	resp, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	captionInfo := resp.Header.Get("CaptionInfo.sec")
+	assert.True(t, strings.HasPrefix(captionInfo, baseURL+"/r/video."), captionInfo)
+	assert.True(t, strings.HasSuffix(captionInfo, ".srt"), captionInfo)
+
+	// But not to other clients
+	req.Header.Set("User-Agent", "VLC/3.0.16 LibVLC/3.0.16")
+	resp, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	assert.Equal(t, "", resp.Header.Get("CaptionInfo.sec"))
+}
+
+// Check that X_SetBookmark saves the position and it is returned by
+// Browse to the same client
+func TestBookmarks(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rclone-dlna-bookmarks")
+	require.NoError(t, err)
+	defer func() { _ = os.RemoveAll(dir) }()
+	path := filepath.Join(dir, "bookmarks.json")
+	oldBookmarks := dlnaServer.bookmarks
+	dlnaServer.bookmarks = newBookmarks(path)
+	defer func() { dlnaServer.bookmarks = oldBookmarks }()
+
+	soapCall := func(action, args string) string {
+		req, err := http.NewRequest("POST", baseURL+serviceControlURL, strings.NewReader(`
+<?xml version="1.0" encoding="utf-8"?>
+<s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/"
+            s:encodingStyle="http://schemas.xmlsoap.org/soap/encoding/">
+    <s:Body>
+        <u:`+action+` xmlns:u="urn:schemas-upnp-org:service:ContentDirectory:1">`+args+`</u:`+action+`>
+    </s:Body>
+</s:Envelope>`))
+		require.NoError(t, err)
+		req.Header.Set("SOAPACTION", `"urn:schemas-upnp-org:service:ContentDirectory:1#`+action+`"`)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		body, err := ioutil.ReadAll(resp.Body)
+		require.NoError(t, err)
+		require.NoError(t, resp.Body.Close())
+		return string(body)
+	}
+	setBookmark := func(pos int) {
+		soapCall("X_SetBookmark", fmt.Sprintf(`
+            <CategoryType>VIDEO</CategoryType>
+            <RID>0</RID>
+            <ObjectID>%%2Fvideo.mp4</ObjectID>
+            <PosSecond>%d</PosSecond>`, pos))
+	}
+	browse := func() string {
+		return soapCall("Browse", `
+            <ObjectID>0</ObjectID>
+            <BrowseFlag>BrowseDirectChildren</BrowseFlag>
+            <Filter>*</Filter>
+            <StartingIndex>0</StartingIndex>
+            <RequestedCount>0</RequestedCount>
+            <SortCriteria></SortCriteria>`)
+	}
+
+	assert.NotContains(t, browse(), "lastPlaybackPosition")
+
+	setBookmark(4354)
+	body := browse()
+	assert.Contains(t, body, html.EscapeString("<upnp:lastPlaybackPosition>1:12:34</upnp:lastPlaybackPosition>"))
+	assert.NotContains(t, body, "playbackCount")
+
+	// Going back to the start counts as watched
+	setBookmark(0)
+	body = browse()
+	assert.Contains(t, body, html.EscapeString("<upnp:lastPlaybackPosition>0:00:00</upnp:lastPlaybackPosition>"))
+	assert.Contains(t, body, html.EscapeString("<upnp:playbackCount>1</upnp:playbackCount>"))
+
+	// The bookmarks are saved
+	bm, found := newBookmarks(path).get("127.0.0.1", "/video.mp4")
+	require.True(t, found)
+	assert.Equal(t, int64(0), bm.Position)
+	assert.Equal(t, 1, bm.PlaybackCount)
+
+	// But only apply to the client which set them
+	_, found = newBookmarks(path).get("192.168.1.2", "/video.mp4")
+	assert.False(t, found)
+}
+
+func TestLoadProfiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rclone-dlna-test")
+	require.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+	path := dir + "/profiles.json"
+	require.NoError(t, ioutil.WriteFile(path, []byte(`[
+  {"name": "My TV", "friendly_name": "Living Room", "mime_types": {"video/x-matroska": "video/mkv"}, "max_browse_count": 10}
+]`), 0600))
+
+	profiles, err := loadProfiles(path)
+	require.NoError(t, err)
+	require.Equal(t, len(builtinProfiles)+1, len(profiles))
+	s := &server{profiles: profiles}
+
+	req, err := http.NewRequest("GET", "http://localhost/", nil)
+	require.NoError(t, err)
+	assert.Equal(t, defaultProfile, s.profileFor(req))
+	assert.Equal(t, "video/x-matroska", s.profileFor(req).mimeType("video/x-matroska"))
+
+	req.Header.Set("X-AV-Client-Info", `av=5.0; cn="Acme"; mn="Living Room TV"; mv="1.0";`)
+	profile := s.profileFor(req)
+	assert.Equal(t, "My TV", profile.Name)
+	assert.Equal(t, "video/mkv", profile.mimeType("video/x-matroska"))
+	assert.Equal(t, "video/mp4", profile.mimeType("video/mp4"))
+	assert.Equal(t, "text/srt", profile.subtitleMimeType())
+
+	require.NoError(t, ioutil.WriteFile(path, []byte(`[{"name": "Bad", "user_agent": "("}]`), 0600))
+	_, err = loadProfiles(path)
+	assert.Error(t, err)
+
+	require.NoError(t, ioutil.WriteFile(path, []byte(`[{"name": "Nothing"}]`), 0600))
+	_, err = loadProfiles(path)
+	assert.Error(t, err)
+}
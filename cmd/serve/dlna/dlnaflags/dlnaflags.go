@@ -19,13 +19,75 @@ default "rclone (hostname)".
 
 Use ` + "`--log-trace` in conjunction with `-vv`" + ` to enable additional debug
 logging of all UPNP traffic.
+
+### Device profiles
+
+Different players need slightly different responses from the server.
+Rclone has built in profiles for Samsung and LG TVs, the Xbox and the
+PlayStation 3 which it chooses from the User-Agent or the friendly
+name the player sends. Use ` + "`-vv`" + ` to see which profile is used.
+
+Use ` + "`--device-profiles`" + ` to add profiles for other players, or
+override the built in ones, from a JSON file like this
+
+    [
+      {
+        "name": "My TV",
+        "user_agent": "MyTV/[0-9]+",
+        "friendly_name": "Living Room",
+        "mime_types": {"video/x-matroska": "video/mkv"},
+        "subtitle_mime_type": "text/srt",
+        "no_subtitles": false,
+        "caption_info": false,
+        "max_browse_count": 0
+      }
+    ]
+
+` + "`user_agent` and `friendly_name`" + ` are regular expressions and a
+profile is used if either matches. ` + "`mime_types`" + ` maps the mime
+types rclone uses to the ones the player wants. ` + "`caption_info`" + `
+sends the subtitle URL in the ` + "`CaptionInfo.sec`" + ` header as
+Samsung TVs want. ` + "`max_browse_count`" + ` limits the number of
+entries in each directory listing for players which can't cope with
+large ones.
+
+### DLNA media profiles
+
+Some players, e.g. the PlayStation 4 and Samsung TVs, will only play
+media which is advertised with a DLNA profile (` + "`DLNA.ORG_PN`" + `)
+they support. MP3 files are always advertised as such, but finding
+the profile of other media means reading its headers.
+
+Use ` + "`--probe-media`" + ` to read the headers of JPEG and PNG images and
+MP4 video and audio to find their profiles. The results are cached
+while rclone is running, but listing a directory for the first time
+needs a few small reads of each file, which may be slow on some
+remotes.
+
+Only the common profiles are recognised: JPEG and PNG images, H.264
+video with AAC audio in MP4 up to 1080p, and AAC audio. Other media,
+including Matroska (mkv) files which have no DLNA profiles, are
+advertised without one.
+
+### Bookmarks
+
+Players which support the Samsung ` + "`X_SetBookmark`" + ` action, e.g.
+Samsung TVs, can save the playback position of media so they can
+resume where they left off.  Rclone saves the positions of each
+player, identified by its IP address, in a file in the
+` + "`--cache-dir`" + ` so they are kept when rclone is restarted.  They are
+returned in ` + "`upnp:lastPlaybackPosition`" + ` when the player lists
+the media.  A position set back to the start is taken to mean the
+media was played to the end and is counted in ` + "`upnp:playbackCount`" + `.
 `
 
 // Options is the type for DLNA serving options.
 type Options struct {
-	ListenAddr   string
-	FriendlyName string
-	LogTrace     bool
+	ListenAddr     string
+	FriendlyName   string
+	LogTrace       bool
+	DeviceProfiles string
+	ProbeMedia     bool
 }
 
 // DefaultOpt contains the defaults options for DLNA serving.
@@ -45,6 +107,8 @@ func addFlagsPrefix(flagSet *pflag.FlagSet, prefix string, Opt *Options) {
 	flags.StringVarP(flagSet, &Opt.ListenAddr, prefix+"addr", "", Opt.ListenAddr, "ip:port or :port to bind the DLNA http server to.")
 	flags.StringVarP(flagSet, &Opt.FriendlyName, prefix+"name", "", Opt.FriendlyName, "name of DLNA server")
 	flags.BoolVarP(flagSet, &Opt.LogTrace, prefix+"log-trace", "", Opt.LogTrace, "enable trace logging of SOAP traffic")
+	flags.StringVarP(flagSet, &Opt.DeviceProfiles, prefix+"device-profiles", "", Opt.DeviceProfiles, "JSON file of extra device profiles for DLNA clients")
+	flags.BoolVarP(flagSet, &Opt.ProbeMedia, prefix+"probe-media", "", Opt.ProbeMedia, "read the headers of media files to find their DLNA profile")
 }
 
 // AddFlags add the command line flags for DLNA serving.
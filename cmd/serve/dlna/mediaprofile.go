@@ -0,0 +1,352 @@
+package dlna
+
+import (
+	"encoding/binary"
+	"fmt"
+	"image"
+	_ "image/jpeg" // register the JPEG decoder for image.DecodeConfig
+	_ "image/png"  // register the PNG decoder for image.DecodeConfig
+	"io"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/pingme998/rclone/fs"
+	"github.com/pingme998/rclone/vfs"
+)
+
+// DLNA.ORG_FLAGS bits - see the DLNA guidelines 7.4.1.3.24
+const (
+	dlnaFlagStreamingTransferMode   = 1 << 24
+	dlnaFlagInteractiveTransferMode = 1 << 23
+	dlnaFlagBackgroundTransferMode  = 1 << 22
+	dlnaFlagConnectionStall         = 1 << 21
+	dlnaFlagDLNAv15                 = 1 << 20
+)
+
+// contentFeatures is the 4th field of the protocolInfo and the value
+// of the contentFeatures.dlna.org header
+type contentFeatures struct {
+	profileName string // DLNA.ORG_PN - may be empty
+	isImage     bool   // set for images which are transferred interactively
+}
+
+// String formats the content features, e.g.
+//
+//     DLNA.ORG_PN=MP3;DLNA.ORG_OP=01;DLNA.ORG_CI=0;DLNA.ORG_FLAGS=01700000000000000000000000000000
+func (cf contentFeatures) String() string {
+	var params []string
+	if cf.profileName != "" {
+		params = append(params, "DLNA.ORG_PN="+cf.profileName)
+	}
+	// Byte range seeks are supported but not time seeks and the
+	// content isn't transcoded
+	params = append(params, "DLNA.ORG_OP=01", "DLNA.ORG_CI=0")
+	flags := dlnaFlagBackgroundTransferMode | dlnaFlagConnectionStall | dlnaFlagDLNAv15
+	if cf.isImage {
+		flags |= dlnaFlagInteractiveTransferMode
+	} else {
+		flags |= dlnaFlagStreamingTransferMode
+	}
+	params = append(params, fmt.Sprintf("DLNA.ORG_FLAGS=%08x%024x", flags, 0))
+	return strings.Join(params, ";")
+}
+
+// mediaProfiles caches the DLNA profiles found by probing
+type mediaProfiles struct {
+	mu       sync.Mutex
+	profiles map[mediaProfileKey]string
+}
+
+// mediaProfileKey identifies a version of a file
+type mediaProfileKey struct {
+	path    string
+	size    int64
+	modTime time.Time
+}
+
+// contentFeatures returns the content features for file with
+// mimeType, probing it for its DLNA profile if --probe-media is set.
+func (s *server) contentFeatures(file *vfs.File, mimeType string) contentFeatures {
+	cf := contentFeatures{
+		isImage: strings.HasPrefix(mimeType, "image/"),
+	}
+	// MP3 files only have one profile so don't need probing
+	if strings.EqualFold(path.Ext(file.Name()), ".mp3") {
+		cf.profileName = "MP3"
+		return cf
+	}
+	if !s.probeMedia {
+		return cf
+	}
+	key := mediaProfileKey{path: file.Path(), size: file.Size(), modTime: file.ModTime()}
+	s.mediaProfiles.mu.Lock()
+	profileName, found := s.mediaProfiles.profiles[key]
+	s.mediaProfiles.mu.Unlock()
+	if !found {
+		var err error
+		profileName, err = probeFile(file, mimeType)
+		if err != nil {
+			fs.Debugf(file.Path(), "Failed to probe for DLNA profile: %v", err)
+		}
+		s.mediaProfiles.mu.Lock()
+		if s.mediaProfiles.profiles == nil {
+			s.mediaProfiles.profiles = map[mediaProfileKey]string{}
+		}
+		s.mediaProfiles.profiles[key] = profileName
+		s.mediaProfiles.mu.Unlock()
+	}
+	cf.profileName = profileName
+	return cf
+}
+
+// probeFile reads the headers of file to find its DLNA profile
+func probeFile(file *vfs.File, mimeType string) (profileName string, err error) {
+	switch mimeType {
+	case "image/jpeg", "image/png", "video/mp4", "audio/mp4", "video/quicktime":
+	default:
+		if !strings.EqualFold(path.Ext(file.Name()), ".m4a") {
+			return "", nil
+		}
+	}
+	in, err := file.Open(os.O_RDONLY)
+	if err != nil {
+		return "", err
+	}
+	defer fs.CheckClose(in, &err)
+	return probeProfile(mimeType, in, file.Size())
+}
+
+// probeProfile reads the headers of the media in r to find its DLNA
+// profile. It returns "" if the media doesn't match one.
+func probeProfile(mimeType string, r io.ReaderAt, size int64) (string, error) {
+	switch mimeType {
+	case "image/jpeg", "image/png":
+		config, format, err := image.DecodeConfig(io.NewSectionReader(r, 0, size))
+		if err != nil {
+			return "", err
+		}
+		return imageProfile(format, config.Width, config.Height), nil
+	}
+	info, err := probeMP4(r, size)
+	if err != nil {
+		return "", err
+	}
+	return info.profile(), nil
+}
+
+// imageProfile returns the DLNA profile for an image of format with
+// the dimensions given
+func imageProfile(format string, width, height int) string {
+	fits := func(w, h int) bool {
+		return width <= w && height <= h
+	}
+	switch format {
+	case "jpeg":
+		switch {
+		case fits(640, 480):
+			return "JPEG_SM"
+		case fits(1024, 768):
+			return "JPEG_MED"
+		case fits(4096, 4096):
+			return "JPEG_LRG"
+		}
+	case "png":
+		if fits(4096, 4096) {
+			return "PNG_LRG"
+		}
+	}
+	return ""
+}
+
+// mp4Info is the information about an MP4 file needed to find its
+// DLNA profile
+type mp4Info struct {
+	videoCodec    string // sample entry type, e.g. "avc1"
+	avcProfile    byte   // AVCProfileIndication, e.g. 100 for High
+	width, height int
+	audioCodec    string // sample entry type, e.g. "mp4a"
+	channels      int
+}
+
+// profile returns the DLNA profile for the MP4 or "" if it doesn't
+// match one. Only the common AVC and AAC profiles are recognised.
+func (info *mp4Info) profile() string {
+	audioOK := info.audioCodec == "" || info.audioCodec == "mp4a"
+	switch info.videoCodec {
+	case "":
+		if info.audioCodec != "mp4a" {
+			return ""
+		}
+		if info.channels > 2 {
+			return "AAC_MULT5_ISO"
+		}
+		return "AAC_ISO_320"
+	case "avc1", "avc3":
+		if !audioOK {
+			return ""
+		}
+		switch {
+		case info.width <= 720 && info.height <= 576:
+			return "AVC_MP4_MP_SD_AAC_MULT5"
+		case info.width > 1920 || info.height > 1080:
+			return ""
+		case info.avcProfile >= 100:
+			return "AVC_MP4_HP_HD_AAC"
+		case info.height <= 720:
+			return "AVC_MP4_MP_HD_720p_AAC"
+		default:
+			return "AVC_MP4_MP_HD_1080i_AAC"
+		}
+	}
+	return ""
+}
+
+// maxMP4Boxes limits the number of boxes probeMP4 will read so a
+// corrupt file can't keep it busy
+const maxMP4Boxes = 1000
+
+// errMP4Done is returned to stop the walk of the boxes
+var errMP4Done = errors.New("done")
+
+// mp4Walker walks the boxes in an MP4 file
+type mp4Walker struct {
+	r     io.ReaderAt
+	boxes int
+}
+
+// walk calls fn for each box between start and end with the offset
+// of its content and the end of the box
+func (w *mp4Walker) walk(start, end int64, fn func(boxType string, start, end int64) error) error {
+	var header [16]byte
+	for start+8 <= end {
+		w.boxes++
+		if w.boxes > maxMP4Boxes {
+			return errors.New("too many boxes")
+		}
+		if _, err := w.r.ReadAt(header[:8], start); err != nil {
+			return err
+		}
+		size := int64(binary.BigEndian.Uint32(header[:4]))
+		boxType := string(header[4:8])
+		contentStart := start + 8
+		switch size {
+		case 0:
+			size = end - start
+		case 1:
+			if _, err := w.r.ReadAt(header[8:16], start+8); err != nil {
+				return err
+			}
+			size = int64(binary.BigEndian.Uint64(header[8:16]))
+			contentStart += 8
+		}
+		if size < contentStart-start || start+size > end {
+			return errors.Errorf("bad size for %q box", boxType)
+		}
+		if err := fn(boxType, contentStart, start+size); err != nil {
+			return err
+		}
+		start += size
+	}
+	return nil
+}
+
+// readAt reads n bytes at off
+func (w *mp4Walker) readAt(off int64, n int) ([]byte, error) {
+	buf := make([]byte, n)
+	_, err := w.r.ReadAt(buf, off)
+	return buf, err
+}
+
+// probeMP4 reads the sample descriptions of the tracks in the MP4 in r
+func probeMP4(r io.ReaderAt, size int64) (info mp4Info, err error) {
+	w := &mp4Walker{r: r}
+	var handler string
+	var walkTrack func(boxType string, start, end int64) error
+	walkTrack = func(boxType string, start, end int64) error {
+		switch boxType {
+		case "mdia", "minf", "stbl":
+			return w.walk(start, end, walkTrack)
+		case "hdlr":
+			// Only the first hdlr in the track is the media handler
+			if handler != "" {
+				return nil
+			}
+			// version+flags, pre_defined, handler_type
+			buf, err := w.readAt(start+8, 4)
+			if err != nil {
+				return err
+			}
+			handler = string(buf)
+		case "stsd":
+			// version+flags, entry_count then the sample entries
+			return w.walk(start+8, end, func(entryType string, start, end int64) error {
+				switch handler {
+				case "vide":
+					if info.videoCodec != "" {
+						return nil
+					}
+					info.videoCodec = entryType
+					// VisualSampleEntry has the width and height at 24
+					buf, err := w.readAt(start+24, 4)
+					if err != nil {
+						return err
+					}
+					info.width = int(binary.BigEndian.Uint16(buf[0:2]))
+					info.height = int(binary.BigEndian.Uint16(buf[2:4]))
+					// and the child boxes at 78
+					return w.walk(start+78, end, func(boxType string, start, end int64) error {
+						if boxType == "avcC" {
+							buf, err := w.readAt(start+1, 1)
+							if err != nil {
+								return err
+							}
+							info.avcProfile = buf[0]
+						}
+						return nil
+					})
+				case "soun":
+					if info.audioCodec != "" {
+						return nil
+					}
+					info.audioCodec = entryType
+					// AudioSampleEntry has the channel count at 16
+					buf, err := w.readAt(start+16, 2)
+					if err != nil {
+						return err
+					}
+					info.channels = int(binary.BigEndian.Uint16(buf))
+				}
+				return nil
+			})
+		}
+		return nil
+	}
+	foundMoov := false
+	err = w.walk(0, size, func(boxType string, start, end int64) error {
+		if boxType != "moov" {
+			return nil
+		}
+		foundMoov = true
+		err := w.walk(start, end, func(boxType string, start, end int64) error {
+			if boxType != "trak" {
+				return nil
+			}
+			handler = ""
+			return w.walk(start, end, walkTrack)
+		})
+		if err != nil {
+			return err
+		}
+		return errMP4Done
+	})
+	if err == errMP4Done {
+		err = nil
+	} else if err == nil && !foundMoov {
+		err = errors.New("no moov box found")
+	}
+	return info, err
+}
@@ -0,0 +1,134 @@
+package dlna
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContentFeaturesString(t *testing.T) {
+	assert.Equal(t, "DLNA.ORG_PN=MP3;DLNA.ORG_OP=01;DLNA.ORG_CI=0;DLNA.ORG_FLAGS=01700000000000000000000000000000",
+		contentFeatures{profileName: "MP3"}.String())
+	assert.Equal(t, "DLNA.ORG_OP=01;DLNA.ORG_CI=0;DLNA.ORG_FLAGS=00f00000000000000000000000000000",
+		contentFeatures{isImage: true}.String())
+}
+
+func TestImageProfile(t *testing.T) {
+	for _, test := range []struct {
+		format        string
+		width, height int
+		want          string
+	}{
+		{"jpeg", 640, 480, "JPEG_SM"},
+		{"jpeg", 1024, 768, "JPEG_MED"},
+		{"jpeg", 768, 1024, "JPEG_LRG"},
+		{"jpeg", 4097, 100, ""},
+		{"png", 4096, 4096, "PNG_LRG"},
+		{"gif", 10, 10, ""},
+	} {
+		assert.Equal(t, test.want, imageProfile(test.format, test.width, test.height), test)
+	}
+}
+
+func TestProbeImage(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 800, 600))
+	var buf bytes.Buffer
+	require.NoError(t, jpeg.Encode(&buf, img, nil))
+	profile, err := probeProfile("image/jpeg", bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	assert.Equal(t, "JPEG_MED", profile)
+
+	buf.Reset()
+	require.NoError(t, png.Encode(&buf, img))
+	profile, err = probeProfile("image/png", bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	assert.Equal(t, "PNG_LRG", profile)
+}
+
+// box makes an MP4 box of boxType containing the contents
+func box(boxType string, contents ...[]byte) []byte {
+	content := bytes.Join(contents, nil)
+	out := make([]byte, 8, 8+len(content))
+	binary.BigEndian.PutUint32(out, uint32(8+len(content)))
+	copy(out[4:], boxType)
+	return append(out, content...)
+}
+
+// track makes a trak box with a handler and a sample entry
+func track(handler string, sampleEntry []byte) []byte {
+	hdlr := make([]byte, 12)
+	copy(hdlr[8:], handler)
+	stsd := make([]byte, 8)
+	binary.BigEndian.PutUint32(stsd[4:], 1)
+	return box("trak", box("mdia", box("hdlr", hdlr), box("minf",
+		box("hdlr", []byte("\x00\x00\x00\x00\x00\x00\x00\x00alis")),
+		box("stbl", box("stsd", stsd, sampleEntry)))))
+}
+
+// videoEntry makes an avc1 sample entry
+func videoEntry(width, height int, avcProfile byte) []byte {
+	entry := make([]byte, 78)
+	binary.BigEndian.PutUint16(entry[24:], uint16(width))
+	binary.BigEndian.PutUint16(entry[26:], uint16(height))
+	return box("avc1", entry, box("avcC", []byte{1, avcProfile, 0, 40}))
+}
+
+// audioEntry makes a sample entry of entryType
+func audioEntry(entryType string, channels int) []byte {
+	entry := make([]byte, 28)
+	binary.BigEndian.PutUint16(entry[16:], uint16(channels))
+	return box(entryType, entry)
+}
+
+func TestProbeMP4(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		tracks [][]byte
+		want   string
+	}{
+		{"SD", [][]byte{track("vide", videoEntry(720, 576, 77)), track("soun", audioEntry("mp4a", 2))}, "AVC_MP4_MP_SD_AAC_MULT5"},
+		{"720p", [][]byte{track("vide", videoEntry(1280, 720, 77)), track("soun", audioEntry("mp4a", 2))}, "AVC_MP4_MP_HD_720p_AAC"},
+		{"1080p", [][]byte{track("vide", videoEntry(1920, 1080, 77))}, "AVC_MP4_MP_HD_1080i_AAC"},
+		{"High", [][]byte{track("vide", videoEntry(1920, 1080, 100)), track("soun", audioEntry("mp4a", 6))}, "AVC_MP4_HP_HD_AAC"},
+		{"4K", [][]byte{track("vide", videoEntry(3840, 2160, 100))}, ""},
+		{"AC3", [][]byte{track("vide", videoEntry(1280, 720, 77)), track("soun", audioEntry("ac-3", 6))}, ""},
+		{"AAC", [][]byte{track("soun", audioEntry("mp4a", 2))}, "AAC_ISO_320"},
+		{"AAC5.1", [][]byte{track("soun", audioEntry("mp4a", 6))}, "AAC_MULT5_ISO"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			data := bytes.Join([][]byte{
+				box("ftyp", []byte("isom\x00\x00\x02\x00isom")),
+				box("mdat", make([]byte, 100)),
+				box("moov", test.tracks...),
+			}, nil)
+			profile, err := probeProfile("video/mp4", bytes.NewReader(data), int64(len(data)))
+			require.NoError(t, err)
+			assert.Equal(t, test.want, profile)
+		})
+	}
+}
+
+func TestProbeMP4Bad(t *testing.T) {
+	data, err := ioutil.ReadFile("testdata/files/video.mp4")
+	require.NoError(t, err)
+	profile, err := probeProfile("video/mp4", bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+	assert.Equal(t, "", profile)
+
+	// No moov box
+	data = box("ftyp", []byte("isom"))
+	_, err = probeProfile("video/mp4", bytes.NewReader(data), int64(len(data)))
+	assert.Error(t, err)
+
+	// Truncated
+	data = box("moov", track("vide", videoEntry(720, 576, 77)))
+	_, err = probeProfile("video/mp4", bytes.NewReader(data[:50]), 60)
+	assert.Error(t, err)
+}
@@ -0,0 +1,170 @@
+package dlna
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+
+	"github.com/pingme998/rclone/fs"
+	"github.com/pkg/errors"
+)
+
+// deviceProfile describes the quirks of a DLNA client so the server
+// can adjust its responses to suit it.
+//
+// Profiles can be loaded from a JSON file with --device-profiles
+// which uses the json names below.
+type deviceProfile struct {
+	// Name of the profile for logging
+	Name string `json:"name"`
+
+	// The profile is used if UserAgent matches the User-Agent
+	// header or FriendlyName matches the friendly name the client
+	// sends in the X-AV-Client-Info or FriendlyName.DLNA.ORG
+	// headers. These are regular expressions.
+	UserAgent    string `json:"user_agent,omitempty"`
+	FriendlyName string `json:"friendly_name,omitempty"`
+
+	// MimeTypes maps the mime types rclone uses to the ones the
+	// client wants, eg "video/x-matroska" to "video/x-mkv"
+	MimeTypes map[string]string `json:"mime_types,omitempty"`
+
+	// SubtitleMimeType is the mime type to advertise external
+	// subtitles with - "text/srt" if not set
+	SubtitleMimeType string `json:"subtitle_mime_type,omitempty"`
+
+	// NoSubtitles stops external subtitles being advertised
+	NoSubtitles bool `json:"no_subtitles,omitempty"`
+
+	// CaptionInfo sends the URL of the subtitles in the
+	// CaptionInfo.sec header when the media is fetched, as
+	// Samsung TVs want
+	CaptionInfo bool `json:"caption_info,omitempty"`
+
+	// MaxBrowseCount limits the number of entries returned from a
+	// Browse if the client doesn't ask for a limit
+	MaxBrowseCount int `json:"max_browse_count,omitempty"`
+
+	userAgentRe    *regexp.Regexp
+	friendlyNameRe *regexp.Regexp
+}
+
+// defaultProfile is used for clients which don't match a profile
+var defaultProfile = &deviceProfile{Name: "default"}
+
+// builtinProfiles are the quirks of clients known to need them
+var builtinProfiles = []*deviceProfile{
+	{
+		Name:             "Samsung",
+		UserAgent:        `(?i)samsung|SEC_HHP_`,
+		FriendlyName:     `(?i)samsung`,
+		MimeTypes:        map[string]string{"video/x-matroska": "video/x-mkv"},
+		SubtitleMimeType: "smi/caption",
+		CaptionInfo:      true,
+	},
+	{
+		Name:         "LG",
+		UserAgent:    `(?i)\bLGE\b|webOS`,
+		FriendlyName: `(?i)^LG\b|\[LG\]`,
+		MimeTypes:    map[string]string{"video/x-matroska": "video/x-mkv"},
+	},
+	{
+		Name:      "Xbox",
+		UserAgent: `(?i)xbox`,
+		MimeTypes: map[string]string{"video/x-msvideo": "video/avi"},
+	},
+	{
+		Name:        "PlayStation 3",
+		UserAgent:   `PLAYSTATION 3`,
+		MimeTypes:   map[string]string{"video/x-msvideo": "video/x-divx"},
+		NoSubtitles: true,
+	},
+}
+
+// compile the regular expressions in the profile
+func (p *deviceProfile) compile() (err error) {
+	if p.UserAgent == "" && p.FriendlyName == "" {
+		return errors.Errorf("device profile %q: needs user_agent or friendly_name", p.Name)
+	}
+	if p.UserAgent != "" {
+		p.userAgentRe, err = regexp.Compile(p.UserAgent)
+		if err != nil {
+			return errors.Wrapf(err, "device profile %q: bad user_agent", p.Name)
+		}
+	}
+	if p.FriendlyName != "" {
+		p.friendlyNameRe, err = regexp.Compile(p.FriendlyName)
+		if err != nil {
+			return errors.Wrapf(err, "device profile %q: bad friendly_name", p.Name)
+		}
+	}
+	return nil
+}
+
+// matches returns true if the client making r should use this profile
+func (p *deviceProfile) matches(r *http.Request) bool {
+	if p.userAgentRe != nil {
+		if userAgent := r.Header.Get("User-Agent"); userAgent != "" && p.userAgentRe.MatchString(userAgent) {
+			return true
+		}
+	}
+	if p.friendlyNameRe != nil {
+		for _, header := range []string{"FriendlyName.DLNA.ORG", "X-AV-Client-Info"} {
+			if name := r.Header.Get(header); name != "" && p.friendlyNameRe.MatchString(name) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// mimeType returns the mime type the client wants for mimeType
+func (p *deviceProfile) mimeType(mimeType string) string {
+	if alias, ok := p.MimeTypes[mimeType]; ok {
+		return alias
+	}
+	return mimeType
+}
+
+// subtitleMimeType returns the mime type to advertise subtitles with
+func (p *deviceProfile) subtitleMimeType() string {
+	if p.SubtitleMimeType != "" {
+		return p.SubtitleMimeType
+	}
+	return "text/srt"
+}
+
+// loadProfiles returns the profiles read from the JSON file at path,
+// if set, followed by the built in profiles so the ones from the file
+// take precedence.
+func loadProfiles(path string) (profiles []*deviceProfile, err error) {
+	if path != "" {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read device profiles")
+		}
+		err = json.Unmarshal(data, &profiles)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to parse device profiles")
+		}
+	}
+	profiles = append(profiles, builtinProfiles...)
+	for _, p := range profiles {
+		if err := p.compile(); err != nil {
+			return nil, err
+		}
+	}
+	return profiles, nil
+}
+
+// profileFor returns the profile for the client making r
+func (s *server) profileFor(r *http.Request) *deviceProfile {
+	for _, p := range s.profiles {
+		if p.matches(r) {
+			fs.Debugf(s, "Using %q device profile for %s", p.Name, r.RemoteAddr)
+			return p
+		}
+	}
+	return defaultProfile
+}
@@ -31,9 +31,11 @@ type Container struct {
 // Item description
 type Item struct {
 	Object
-	XMLName  xml.Name `xml:"item"`
-	Res      []Resource
-	InnerXML string `xml:",innerxml"`
+	XMLName              xml.Name `xml:"item"`
+	LastPlaybackPosition string   `xml:"upnp:lastPlaybackPosition,omitempty"`
+	PlaybackCount        int      `xml:"upnp:playbackCount,omitempty"`
+	Res                  []Resource
+	InnerXML             string `xml:",innerxml"`
 }
 
 // Object description
@@ -13,6 +13,7 @@ import (
 	"os/user"
 	"strconv"
 	"sync"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/pingme998/rclone/cmd"
@@ -23,11 +24,13 @@ import (
 	"github.com/pingme998/rclone/fs/config/flags"
 	"github.com/pingme998/rclone/fs/log"
 	"github.com/pingme998/rclone/fs/rc"
+	"github.com/pingme998/rclone/lib/clock"
 	"github.com/pingme998/rclone/vfs"
 	"github.com/pingme998/rclone/vfs/vfsflags"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 	ftp "goftp.io/server/core"
+	"golang.org/x/time/rate"
 )
 
 // Options contains options for the http Server
@@ -40,6 +43,10 @@ type Options struct {
 	BasicPass    string // password for BasicUser
 	TLSCert      string // TLS PEM key (concatenation of certificate and CA certificate)
 	TLSKey       string // TLS PEM Private key
+
+	MaxConnectionsPerUser int           // maximum number of sessions each user can have, 0 for unlimited
+	BwLimitPerUser        fs.SizeSuffix // bandwidth limit in bytes/s shared by the sessions of each user, 0 for unlimited
+	IdleTimeout           time.Duration // time after which idle sessions are closed, 0 for never
 }
 
 // DefaultOpt is the default values used for Options
@@ -49,6 +56,7 @@ var DefaultOpt = Options{
 	PassivePorts: "30000-32000",
 	BasicUser:    "anonymous",
 	BasicPass:    "",
+	IdleTimeout:  5 * time.Minute,
 }
 
 // Opt is options set by command line flags
@@ -64,6 +72,9 @@ func AddFlags(flagSet *pflag.FlagSet) {
 	flags.StringVarP(flagSet, &Opt.BasicPass, "pass", "", Opt.BasicPass, "Password for authentication. (empty value allow every password)")
 	flags.StringVarP(flagSet, &Opt.TLSCert, "cert", "", Opt.TLSCert, "TLS PEM key (concatenation of certificate and CA certificate)")
 	flags.StringVarP(flagSet, &Opt.TLSKey, "key", "", Opt.TLSKey, "TLS PEM Private key")
+	flags.IntVarP(flagSet, &Opt.MaxConnectionsPerUser, "max-connections-per-user", "", Opt.MaxConnectionsPerUser, "Maximum number of connections each user can have. (0 for unlimited)")
+	flags.FVarP(flagSet, &Opt.BwLimitPerUser, "bwlimit-per-user", "", "Bandwidth limit in bytes/s shared by the connections of each user. (0 for unlimited)")
+	flags.DurationVarP(flagSet, &Opt.IdleTimeout, "idle-timeout", "", Opt.IdleTimeout, "Close connections after they have been idle for this long. (0 for never)")
 }
 
 func init() {
@@ -96,6 +107,23 @@ then using Authentication is advised - see the next section for info.
 By default this will serve files without needing a login.
 
 You can set a single username and password with the --user and --pass flags.
+
+#### Limits
+
+Use --max-connections-per-user to limit the number of connections
+each user can have at once.  Logins over the limit are refused.
+
+Use --bwlimit-per-user to limit the bandwidth of each user, e.g.
+--bwlimit-per-user 1M.  The limit is shared by the uploads and
+downloads of all the connections of the user.
+
+Use --idle-timeout to set how long a connection can go without
+running a command or transferring data before it is timed out - the
+default is 5 minutes.  Commands on a timed out connection fail, so the
+client has to reconnect.  As rclone can't tell when clients disconnect,
+connections count towards --max-connections-per-user until they have
+been idle for --idle-timeout, so this must be set to use
+--max-connections-per-user.
 ` + vfs.Help + proxy.Help,
 	Run: func(command *cobra.Command, args []string) {
 		var f fs.Fs
@@ -106,6 +134,7 @@ You can set a single username and password with the --user and --pass flags.
 			cmd.CheckArgs(0, 0, command, args)
 		}
 		cmd.Run(false, false, command, func() error {
+			defer cmd.ReloadOnSIGHUP()()
 			s, err := newServer(context.Background(), f, &Opt)
 			if err != nil {
 				return err
@@ -124,6 +153,16 @@ type server struct {
 	vfs    *vfs.VFS
 	proxy  *proxy.Proxy
 	useTLS bool
+
+	usersMu sync.Mutex
+	users   map[string]*ftpUser // users with sessions by name
+}
+
+// ftpUser holds the state shared by all the sessions of a user
+type ftpUser struct {
+	name     string
+	sessions map[*Driver]struct{} // sessions logged in as this user
+	limiter  *rate.Limiter        // bandwidth limit for the user or nil if none
 }
 
 // Make a new FTP to serve the remote
@@ -137,10 +176,15 @@ func newServer(ctx context.Context, f fs.Fs, opt *Options) (*server, error) {
 		return nil, errors.New("Failed to parse host:port")
 	}
 
+	if opt.MaxConnectionsPerUser > 0 && opt.IdleTimeout <= 0 {
+		return nil, errors.New("--max-connections-per-user needs --idle-timeout to be set")
+	}
+
 	s := &server{
-		f:   f,
-		ctx: ctx,
-		opt: *opt,
+		f:     f,
+		ctx:   ctx,
+		opt:   *opt,
+		users: make(map[string]*ftpUser),
 	}
 	if proxyflags.Opt.AuthProxy != "" {
 		s.proxy = proxy.New(ctx, &proxyflags.Opt)
@@ -226,37 +270,183 @@ func (s *server) NewDriver() (ftp.Driver, error) {
 	return d, nil
 }
 
+// maxBurstSize is the most bytes read in one go when the bandwidth is limited
+const maxBurstSize = 1024 * 1024
+
+// errIdleTimeout is returned for commands on sessions which have timed out
+var errIdleTimeout = errors.New("connection timed out for being idle - please reconnect")
+
+// _expireIdle logs out the sessions of u which have been idle for too
+// long
+//
+// call with usersMu held
+func (s *server) _expireIdle(u *ftpUser, now time.Time) {
+	if s.opt.IdleTimeout <= 0 {
+		return
+	}
+	for d := range u.sessions {
+		if now.Sub(d.lastUsed) >= s.opt.IdleTimeout {
+			fs.Infof(nil, "%s: connection timed out after being idle for %v", u.name, s.opt.IdleTimeout)
+			d.timedOut = true
+			s._logout(d)
+		}
+	}
+}
+
+// _logout removes the session d from its user
+//
+// call with usersMu held
+func (s *server) _logout(d *Driver) {
+	u := d.user
+	if u == nil {
+		return
+	}
+	delete(u.sessions, d)
+	if len(u.sessions) == 0 {
+		delete(s.users, u.name)
+	}
+	d.user = nil
+}
+
+// login adds the session d to the sessions of user returning an error
+// if the user has too many already
+func (s *server) login(d *Driver, user string) error {
+	s.usersMu.Lock()
+	defer s.usersMu.Unlock()
+	now := clock.Get(s.ctx).Now()
+	s._logout(d)
+	if u := s.users[user]; u != nil {
+		s._expireIdle(u, now)
+	}
+	u := s.users[user]
+	if u == nil {
+		u = &ftpUser{
+			name:     user,
+			sessions: make(map[*Driver]struct{}),
+		}
+		if s.opt.BwLimitPerUser > 0 {
+			u.limiter = rate.NewLimiter(rate.Limit(s.opt.BwLimitPerUser), maxBurstSize)
+		}
+	}
+	if s.opt.MaxConnectionsPerUser > 0 && len(u.sessions) >= s.opt.MaxConnectionsPerUser {
+		return errors.Errorf("user %q has too many connections (%d)", user, len(u.sessions))
+	}
+	s.users[user] = u
+	u.sessions[d] = struct{}{}
+	d.user = u
+	d.lastUsed = now
+	d.timedOut = false
+	return nil
+}
+
 //Driver implementation of ftp server
 type Driver struct {
 	s    *server
 	vfs  *vfs.VFS
 	lock sync.Mutex
+
+	// these are protected by s.usersMu
+	user     *ftpUser  // user logged in or nil if none
+	lastUsed time.Time // when the session was last used
+	timedOut bool      // set if the session was timed out for being idle
 }
 
 // CheckPasswd handle auth based on configuration
 func (d *Driver) CheckPasswd(user, pass string) (ok bool, err error) {
 	s := d.s
+	var VFS *vfs.VFS
 	if s.proxy != nil {
-		var VFS *vfs.VFS
 		VFS, _, err = s.proxy.Call(user, pass, false)
 		if err != nil {
 			fs.Infof(nil, "proxy login failed: %v", err)
 			return false, nil
 		}
-		d.vfs = VFS
 	} else {
 		ok = s.opt.BasicUser == user && (s.opt.BasicPass == "" || s.opt.BasicPass == pass)
 		if !ok {
 			fs.Infof(nil, "login failed: bad credentials")
 			return false, nil
 		}
+		VFS = s.vfs
 	}
+	err = s.login(d, user)
+	if err != nil {
+		fs.Infof(nil, "login failed: %v", err)
+		return false, nil
+	}
+	d.vfs = VFS
 	return true, nil
 }
 
+// touch marks the session as in use returning an error if it has
+// timed out for being idle
+func (d *Driver) touch() error {
+	s := d.s
+	s.usersMu.Lock()
+	defer s.usersMu.Unlock()
+	if d.timedOut {
+		return errIdleTimeout
+	}
+	now := clock.Get(s.ctx).Now()
+	if d.user != nil {
+		s._expireIdle(d.user, now)
+		if d.timedOut {
+			return errIdleTimeout
+		}
+	}
+	d.lastUsed = now
+	return nil
+}
+
+// newReader returns a reader which marks the session as in use
+// while in is being read and limits the bandwidth of the user
+func (d *Driver) newReader(in io.Reader) *sessionReader {
+	d.s.usersMu.Lock()
+	defer d.s.usersMu.Unlock()
+	r := &sessionReader{Reader: in, d: d}
+	if d.user != nil {
+		r.limiter = d.user.limiter
+	}
+	return r
+}
+
+// sessionReader reads data transferred by a session
+type sessionReader struct {
+	io.Reader
+	d       *Driver
+	limiter *rate.Limiter // nil if the bandwidth isn't limited
+}
+
+// Read bytes limiting the bandwidth if required
+func (r *sessionReader) Read(p []byte) (n int, err error) {
+	if r.limiter != nil && len(p) > maxBurstSize {
+		p = p[:maxBurstSize]
+	}
+	n, err = r.Reader.Read(p)
+	s := r.d.s
+	s.usersMu.Lock()
+	r.d.lastUsed = clock.Get(s.ctx).Now()
+	s.usersMu.Unlock()
+	if r.limiter != nil && n > 0 {
+		if waitErr := r.limiter.WaitN(s.ctx, n); waitErr != nil {
+			fs.Errorf(nil, "Token bucket error: %v", waitErr)
+		}
+	}
+	return n, err
+}
+
+// sessionReadCloser is a sessionReader which can be closed
+type sessionReadCloser struct {
+	*sessionReader
+	io.Closer
+}
+
 //Stat get information on file or folder
 func (d *Driver) Stat(path string) (fi ftp.FileInfo, err error) {
 	defer log.Trace(path, "")("fi=%+v, err = %v", &fi, &err)
+	if err = d.touch(); err != nil {
+		return nil, err
+	}
 	n, err := d.vfs.Stat(path)
 	if err != nil {
 		return nil, err
@@ -269,6 +459,9 @@ func (d *Driver) ChangeDir(path string) (err error) {
 	d.lock.Lock()
 	defer d.lock.Unlock()
 	defer log.Trace(path, "")("err = %v", &err)
+	if err = d.touch(); err != nil {
+		return err
+	}
 	n, err := d.vfs.Stat(path)
 	if err != nil {
 		return err
@@ -284,6 +477,9 @@ func (d *Driver) ListDir(path string, callback func(ftp.FileInfo) error) (err er
 	d.lock.Lock()
 	defer d.lock.Unlock()
 	defer log.Trace(path, "")("err = %v", &err)
+	if err = d.touch(); err != nil {
+		return err
+	}
 	node, err := d.vfs.Stat(path)
 	if err == vfs.ENOENT {
 		return errors.New("Directory not found")
@@ -320,6 +516,9 @@ func (d *Driver) DeleteDir(path string) (err error) {
 	d.lock.Lock()
 	defer d.lock.Unlock()
 	defer log.Trace(path, "")("err = %v", &err)
+	if err = d.touch(); err != nil {
+		return err
+	}
 	node, err := d.vfs.Stat(path)
 	if err != nil {
 		return err
@@ -339,6 +538,9 @@ func (d *Driver) DeleteFile(path string) (err error) {
 	d.lock.Lock()
 	defer d.lock.Unlock()
 	defer log.Trace(path, "")("err = %v", &err)
+	if err = d.touch(); err != nil {
+		return err
+	}
 	node, err := d.vfs.Stat(path)
 	if err != nil {
 		return err
@@ -358,6 +560,9 @@ func (d *Driver) Rename(oldName, newName string) (err error) {
 	d.lock.Lock()
 	defer d.lock.Unlock()
 	defer log.Trace(oldName, "newName=%q", newName)("err = %v", &err)
+	if err = d.touch(); err != nil {
+		return err
+	}
 	return d.vfs.Rename(oldName, newName)
 }
 
@@ -366,6 +571,9 @@ func (d *Driver) MakeDir(path string) (err error) {
 	d.lock.Lock()
 	defer d.lock.Unlock()
 	defer log.Trace(path, "")("err = %v", &err)
+	if err = d.touch(); err != nil {
+		return err
+	}
 	dir, leaf, err := d.vfs.StatParent(path)
 	if err != nil {
 		return err
@@ -379,6 +587,9 @@ func (d *Driver) GetFile(path string, offset int64) (size int64, fr io.ReadClose
 	d.lock.Lock()
 	defer d.lock.Unlock()
 	defer log.Trace(path, "offset=%v", offset)("err = %v", &err)
+	if err = d.touch(); err != nil {
+		return 0, nil, err
+	}
 	node, err := d.vfs.Stat(path)
 	if err == vfs.ENOENT {
 		fs.Infof(path, "File not found")
@@ -403,7 +614,7 @@ func (d *Driver) GetFile(path string, offset int64) (size int64, fr io.ReadClose
 	tr := accounting.GlobalStats().NewTransferRemoteSize(path, node.Size())
 	defer tr.Done(d.s.ctx, nil)
 
-	return node.Size(), handle, nil
+	return node.Size(), sessionReadCloser{d.newReader(handle), handle}, nil
 }
 
 //PutFile upload a file
@@ -411,6 +622,9 @@ func (d *Driver) PutFile(path string, data io.Reader, appendData bool) (n int64,
 	d.lock.Lock()
 	defer d.lock.Unlock()
 	defer log.Trace(path, "append=%v", appendData)("err = %v", &err)
+	if err = d.touch(); err != nil {
+		return 0, err
+	}
 	var isExist bool
 	node, err := d.vfs.Stat(path)
 	if err == nil {
@@ -429,6 +643,7 @@ func (d *Driver) PutFile(path string, data io.Reader, appendData bool) (n int64,
 	if appendData && !isExist {
 		appendData = false
 	}
+	data = d.newReader(data)
 
 	if !appendData {
 		if isExist {
@@ -9,15 +9,21 @@ package ftp
 
 import (
 	"context"
+	"io/ioutil"
+	"os"
 	"testing"
+	"time"
 
 	_ "github.com/pingme998/rclone/backend/local"
 	"github.com/pingme998/rclone/cmd/serve/servetest"
 	"github.com/pingme998/rclone/fs"
 	"github.com/pingme998/rclone/fs/config/configmap"
 	"github.com/pingme998/rclone/fs/config/obscure"
+	"github.com/pingme998/rclone/lib/clock"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	ftp "goftp.io/server/core"
+	"golang.org/x/time/rate"
 )
 
 const (
@@ -69,3 +75,67 @@ func TestFTP(t *testing.T) {
 
 	servetest.Run(t, "ftp", start)
 }
+
+// TestLimits checks the per user connection limit, bandwidth limit
+// and the idle timeout
+func TestLimits(t *testing.T) {
+	m := clock.NewMock(time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC))
+	ctx := clock.WithClock(context.Background(), m)
+	dir, err := ioutil.TempDir("", "rclone-serve-ftp")
+	require.NoError(t, err)
+	defer func() { _ = os.RemoveAll(dir) }()
+	f, err := fs.NewFs(ctx, dir)
+	require.NoError(t, err)
+
+	opt := DefaultOpt
+	opt.BasicUser = testUSER
+	opt.BasicPass = testPASS
+	opt.MaxConnectionsPerUser = 2
+	opt.BwLimitPerUser = 1024 * 1024
+	opt.IdleTimeout = time.Minute
+	s, err := newServer(ctx, f, &opt)
+	require.NoError(t, err)
+
+	newDriver := func() *Driver {
+		d, err := s.NewDriver()
+		require.NoError(t, err)
+		return d.(*Driver)
+	}
+	login := func(d *Driver, pass string) bool {
+		ok, err := d.CheckPasswd(testUSER, pass)
+		require.NoError(t, err)
+		return ok
+	}
+
+	d1, d2, d3 := newDriver(), newDriver(), newDriver()
+	assert.False(t, login(d1, "potato"))
+	assert.True(t, login(d1, testPASS))
+	assert.True(t, login(d2, testPASS))
+	assert.False(t, login(d3, testPASS), "too many connections")
+
+	// The sessions of the user share the bandwidth limit
+	limiter := d1.newReader(nil).limiter
+	require.NotNil(t, limiter)
+	assert.Equal(t, rate.Limit(1024*1024), limiter.Limit())
+	assert.Same(t, limiter, d2.newReader(nil).limiter)
+
+	// Using d1 stops it timing out so d2 times out first
+	m.Advance(30 * time.Second)
+	assert.NoError(t, d1.ChangeDir("/"))
+	m.Advance(40 * time.Second)
+	assert.True(t, login(d3, testPASS))
+	assert.Equal(t, errIdleTimeout, d2.ChangeDir("/"))
+	assert.NoError(t, d1.ChangeDir("/"))
+	assert.False(t, login(newDriver(), testPASS), "too many connections")
+
+	// Logging in again starts a new session
+	m.Advance(2 * time.Minute)
+	assert.True(t, login(d2, testPASS))
+	assert.NoError(t, d2.ChangeDir("/"))
+	assert.Equal(t, errIdleTimeout, d1.ChangeDir("/"))
+
+	// Limiting connections needs the idle timeout
+	opt.IdleTimeout = 0
+	_, err = newServer(ctx, f, &opt)
+	assert.Error(t, err)
+}
@@ -63,6 +63,7 @@ control the stats printing.
 		cmd.CheckArgs(1, 1, command, args)
 		f := cmd.NewFsSrc(args)
 		cmd.Run(false, true, command, func() error {
+			defer cmd.ReloadOnSIGHUP()()
 			s := newServer(f, Opt.Template)
 			router, err := httplib.Router()
 			if err != nil {
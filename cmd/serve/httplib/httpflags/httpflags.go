@@ -28,6 +28,9 @@ func AddFlagsPrefix(flagSet *pflag.FlagSet, prefix string, Opt *httplib.Options)
 	flags.StringVarP(flagSet, &Opt.BasicPass, prefix+"pass", "", Opt.BasicPass, "Password for authentication.")
 	flags.StringVarP(flagSet, &Opt.BaseURL, prefix+"baseurl", "", Opt.BaseURL, "Prefix for URLs - leave blank for root.")
 	flags.StringVarP(flagSet, &Opt.Template, prefix+"template", "", Opt.Template, "User Specified Template.")
+	flags.BoolVarP(flagSet, &Opt.ReusePort, prefix+"reuse-port", "", Opt.ReusePort, "Set SO_REUSEPORT so several servers can listen on the same port.")
+	flags.StringVarP(flagSet, &Opt.AccessLog, prefix+"access-log", "", Opt.AccessLog, "File to write the access log to, \"-\" for stdout - leave blank for none.")
+	flags.StringVarP(flagSet, &Opt.AccessLogFormat, prefix+"access-log-format", "", Opt.AccessLogFormat, "Format of the access log: common, combined or json.")
 
 }
 
@@ -21,6 +21,8 @@ import (
 	"github.com/pkg/errors"
 	"github.com/pingme998/rclone/cmd/serve/http/data"
 	"github.com/pingme998/rclone/fs"
+	"github.com/pingme998/rclone/lib/atexit"
+	libhttp "github.com/pingme998/rclone/lib/http"
 )
 
 // Globals
@@ -77,6 +79,25 @@ to be used within the template to server pages:
 |-- .Size     | Size in Bytes of the entry. |
 |-- .ModTime  | The UTC timestamp of an entry. |
 
+Use --reuse-port to set SO_REUSEPORT on the listening socket (not on
+Windows). This allows several copies of rclone to listen on the same
+port, so a new copy can be started before the old one is stopped
+without refusing any connections. Use --shutdown-grace-period to let
+the old copy finish the requests in progress when it is stopped.
+
+If rclone is started by systemd socket activation, the sockets passed
+in by systemd are used instead of --addr. systemd keeps the sockets
+open while rclone is restarted so no connections are dropped. For
+example with a socket unit like this
+
+    [Socket]
+    ListenStream=8080
+
+    [Install]
+    WantedBy=sockets.target
+
+and a service unit of the same name which runs rclone serve.
+
 #### Authentication
 
 By default this will serve files without needing a login.
@@ -109,7 +130,7 @@ supply --client-ca also.
 of that with the CA certificate.  --key should be the PEM encoded
 private key and --client-ca should be the PEM encoded client
 certificate authority certificate.
-`
+` + libhttp.AccessLogHelp
 
 // Options contains options for the http Server
 type Options struct {
@@ -127,6 +148,10 @@ type Options struct {
 	BasicPass          string        // password for BasicUser
 	Auth               AuthFn        `json:"-"` // custom Auth (not set by command line flags)
 	Template           string        // User specified template
+	ReusePort          bool          // set SO_REUSEPORT on the listening socket
+	SocketActivation   bool          `json:"-"` // use the sockets passed in by systemd if any
+	AccessLog          string        // file to write the access log to, "-" for stdout
+	AccessLogFormat    string        // format of the access log - common, combined or json
 }
 
 // AuthFn if used will be used to authenticate user, pass. If an error
@@ -142,13 +167,17 @@ var DefaultOpt = Options{
 	ServerReadTimeout:  1 * time.Hour,
 	ServerWriteTimeout: 1 * time.Hour,
 	MaxHeaderBytes:     4096,
+	SocketActivation:   true,
+	AccessLogFormat:    libhttp.AccessLogCombined,
 }
 
 // Server contains info about the running http server
 type Server struct {
 	Opt             Options
-	handler         http.Handler // original handler
-	listener        net.Listener
+	handler         http.Handler   // original handler
+	listener        net.Listener   // the first of listeners
+	listeners       []net.Listener // all the listeners being served
+	gracefulHandle  atexit.GracefulHandle
 	waitChan        chan struct{} // for waiting on the listener to close
 	httpServer      *http.Server
 	basicPassHashed string
@@ -266,6 +295,15 @@ func NewServer(handler http.Handler, opt *Options) *Server {
 		s.usingAuth = true
 	}
 
+	// Log all the requests including the unauthorized ones if required
+	if s.Opt.AccessLog != "" {
+		accessLog, err := libhttp.NewAccessLog(s.Opt.AccessLog, s.Opt.AccessLogFormat)
+		if err != nil {
+			log.Fatalf(err.Error())
+		}
+		handler = accessLog(handler)
+	}
+
 	s.useSSL = s.Opt.SslKey != ""
 	if (s.Opt.SslCert != "") != s.useSSL {
 		log.Fatalf("Need both -cert and -key to use SSL")
@@ -316,47 +354,86 @@ func NewServer(handler http.Handler, opt *Options) *Server {
 	return s
 }
 
+// listen makes the listeners for the server - these are the sockets
+// passed in by systemd if any, otherwise a listener on ListenAddr.
+func (s *Server) listen() ([]net.Listener, error) {
+	if s.Opt.SocketActivation {
+		listeners, err := libhttp.SocketActivation()
+		if err != nil {
+			return nil, err
+		}
+		if len(listeners) > 0 {
+			fs.Infof(nil, "Using %d socket(s) passed in by systemd instead of %q", len(listeners), s.Opt.ListenAddr)
+			return listeners, nil
+		}
+	}
+	ln, err := libhttp.Listen(s.httpServer.Addr, s.Opt.ReusePort)
+	if err != nil {
+		return nil, err
+	}
+	return []net.Listener{ln}, nil
+}
+
 // Serve runs the server - returns an error only if
 // the listener was not started; does not block, so
 // use s.Wait() to block on the listener indefinitely.
 func (s *Server) Serve() error {
-	ln, err := net.Listen("tcp", s.httpServer.Addr)
+	listeners, err := s.listen()
 	if err != nil {
 		return errors.Wrapf(err, "start server failed")
 	}
-	s.listener = ln
+	s.listener = listeners[0]
+	s.listeners = listeners
 	s.waitChan = make(chan struct{})
-	go func() {
-		var err error
-		if s.useSSL {
-			// hacky hack to get this to work with old Go versions, which
-			// don't have ServeTLS on http.Server; see PR #2194.
-			type tlsServer interface {
-				ServeTLS(ln net.Listener, cert, key string) error
-			}
-			srvIface := interface{}(s.httpServer)
-			if tlsSrv, ok := srvIface.(tlsServer); ok {
-				// yay -- we get easy TLS support with HTTP/2
-				err = tlsSrv.ServeTLS(s.listener, s.Opt.SslCert, s.Opt.SslKey)
-			} else {
-				// oh well -- we can still do TLS but might not have HTTP/2
-				tlsConfig := new(tls.Config)
-				tlsConfig.Certificates = make([]tls.Certificate, 1)
-				tlsConfig.Certificates[0], err = tls.LoadX509KeyPair(s.Opt.SslCert, s.Opt.SslKey)
-				if err != nil {
-					log.Printf("Error loading key pair: %v", err)
-				}
-				tlsLn := tls.NewListener(s.listener, tlsConfig)
-				err = s.httpServer.Serve(tlsLn)
-			}
-		} else {
-			err = s.httpServer.Serve(s.listener)
+	for _, ln := range listeners {
+		go s.serve(ln)
+	}
+	// Finish the requests in progress before exiting if required
+	if fs.GetConfig(context.Background()).ShutdownGracePeriod > 0 {
+		s.gracefulHandle = atexit.RegisterGraceful(s.shutdown)
+	}
+	return nil
+}
+
+// serve runs the server on ln until it is closed
+func (s *Server) serve(ln net.Listener) {
+	var err error
+	if s.useSSL {
+		// hacky hack to get this to work with old Go versions, which
+		// don't have ServeTLS on http.Server; see PR #2194.
+		type tlsServer interface {
+			ServeTLS(ln net.Listener, cert, key string) error
 		}
-		if err != nil {
-			log.Printf("Error on serving HTTP server: %v", err)
+		srvIface := interface{}(s.httpServer)
+		if tlsSrv, ok := srvIface.(tlsServer); ok {
+			// yay -- we get easy TLS support with HTTP/2
+			err = tlsSrv.ServeTLS(ln, s.Opt.SslCert, s.Opt.SslKey)
+		} else {
+			// oh well -- we can still do TLS but might not have HTTP/2
+			tlsConfig := new(tls.Config)
+			tlsConfig.Certificates = make([]tls.Certificate, 1)
+			tlsConfig.Certificates[0], err = tls.LoadX509KeyPair(s.Opt.SslCert, s.Opt.SslKey)
+			if err != nil {
+				log.Printf("Error loading key pair: %v", err)
+			}
+			tlsLn := tls.NewListener(ln, tlsConfig)
+			err = s.httpServer.Serve(tlsLn)
 		}
-	}()
-	return nil
+	} else {
+		err = s.httpServer.Serve(ln)
+	}
+	if err != nil {
+		log.Printf("Error on serving HTTP server: %v", err)
+	}
+}
+
+// shutdown stops the server accepting new connections and waits for
+// the requests in progress to finish or ctx to be cancelled.
+func (s *Server) shutdown(ctx context.Context) {
+	err := s.httpServer.Shutdown(ctx)
+	if err != nil {
+		fs.Errorf(nil, "Error shutting down HTTP server: %v", err)
+	}
 }
 
 // Wait blocks while the listener is open.
@@ -366,6 +443,7 @@ func (s *Server) Wait() {
 
 // Close shuts the running server down
 func (s *Server) Close() {
+	atexit.UnregisterGraceful(s.gracefulHandle)
 	err := s.httpServer.Close()
 	if err != nil {
 		log.Printf("Error on closing HTTP server: %v", err)
@@ -7,8 +7,11 @@ import (
 	"crypto/sha256"
 	"crypto/subtle"
 	"encoding/json"
+	"fmt"
 	"os/exec"
+	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/pkg/errors"
@@ -48,8 +51,9 @@ config.
 This config generated must have this extra parameter
 - |_root| - root to use for the backend
 
-And it may have this parameter
+And it may have these parameters
 - |_obscure| - comma separated strings for parameters to obscure
+- |_agent| - set to |true| to authenticate with the client's SSH agent (|serve sftp| only)
 
 If password authentication was used by the client, input to the proxy
 process (on STDIN) would look similar to this:
@@ -103,6 +107,33 @@ before it takes effect.
 
 This can be used to build general purpose proxies to any kind of
 backend that rclone supports.  
+
+#### SSH agent forwarding
+
+With |rclone serve sftp| the proxy can return a config for an sftp
+backend with |_agent| set to |true| and no |pass| or |key_file|, for
+example
+
+|||
+{
+	"type": "sftp",
+	"_root": "",
+	"_agent": "true",
+	"user": "me",
+	"host": "sftp.example.com"
+}
+|||
+
+Instead of creating the backend when the user logs in, rclone waits
+for the client to forward its SSH agent (eg with |ssh -A|) and then
+creates a backend which authenticates to the upstream server with the
+keys in the client's agent. This means rclone can act as a jump host
+without storing any credentials for the upstream server.
+
+Each connection gets its own backend which is shut down when the
+client disconnects, as the agent is only available while the client
+is connected. Connections which don't forward their agent are
+refused.
 `, "|", "`", -1)
 
 // Options is options for creating the proxy
@@ -117,16 +148,21 @@ var DefaultOpt = Options{
 
 // Proxy represents a proxy to turn auth requests into a VFS
 type Proxy struct {
-	cmdLine  []string // broken down command line
-	vfsCache *libcache.Cache
-	ctx      context.Context // for global config
-	Opt      Options
+	cmdLine    []string // broken down command line
+	vfsCache   *libcache.Cache
+	ctx        context.Context // for global config
+	allowAgent bool            // set if the config may set _agent
+	agentCount int32           // number of backends made with NewAgentVFS
+	Opt        Options
 }
 
 // cacheEntry is what is stored in the vfsCache
 type cacheEntry struct {
-	vfs    *vfs.VFS          // stored VFS
+	vfs    *vfs.VFS          // stored VFS - nil if the backend needs the client's agent
 	pwHash [sha256.Size]byte // sha256 hash of the password/publicKey
+	// makes the backend with the name passed in if it needs the client's agent
+	newFs func(ctx context.Context, name string) (fs.Fs, error)
+	name  string // base name for the backend made by newFs
 }
 
 // New creates a new proxy with the Options passed in
@@ -139,6 +175,13 @@ func New(ctx context.Context, opt *Options) *Proxy {
 	}
 }
 
+// AllowAgent allows the proxy to return configs with _agent set. The
+// caller must use NeedsAgent and NewAgentVFS to make the VFS for them
+// as Call and Get return a nil VFS.
+func (p *Proxy) AllowAgent() {
+	p.allowAgent = true
+}
+
 // run the proxy command returning a config map
 func (p *Proxy) run(in map[string]string) (config configmap.Simple, err error) {
 	cmd := exec.Command(p.cmdLine[0], p.cmdLine[1:]...)
@@ -216,34 +259,60 @@ func (p *Proxy) call(user, auth string, isPublicKey bool) (value interface{}, er
 		return nil, errors.Wrapf(err, "proxy: couldn't find backend for %q", fsName)
 	}
 
+	// See if the backend needs the client's agent
+	needsAgent := false
+	if agent, ok := config.Get("_agent"); ok {
+		needsAgent, err = strconv.ParseBool(agent)
+		if err != nil {
+			return nil, errors.Wrap(err, "proxy: bad _agent in result")
+		}
+	}
+	if needsAgent && !p.allowAgent {
+		return nil, errors.New("proxy: _agent is only supported by serve sftp")
+	}
+
 	// base name of config on user name.  This may appear in logs
 	name := "proxy-" + user
 	fsString := name + ":" + root
 
-	// Look for fs in the VFS cache
-	value, err = p.vfsCache.Get(user, func(key string) (value interface{}, ok bool, err error) {
-		// Create the Fs from the cache
-		f, err := cache.GetFn(p.ctx, fsString, func(ctx context.Context, fsString string) (fs.Fs, error) {
-			// Update the config with the default values
-			for i := range fsInfo.Options {
-				o := &fsInfo.Options[i]
-				if _, found := config.Get(o.Name); !found && o.Default != nil && o.String() != "" {
-					config.Set(o.Name, o.String())
-				}
-			}
-			return fsInfo.NewFs(ctx, name, root, config)
-		})
-		if err != nil {
-			return nil, false, err
+	// Update the config with the default values
+	for i := range fsInfo.Options {
+		o := &fsInfo.Options[i]
+		if _, found := config.Get(o.Name); !found && o.Default != nil && o.String() != "" {
+			config.Set(o.Name, o.String())
 		}
+	}
+
+	// Make the Fs using ctx for the global config
+	newFs := func(ctx context.Context, name string) (fs.Fs, error) {
+		return fsInfo.NewFs(ctx, name, root, config)
+	}
 
+	// Look for fs in the VFS cache
+	value, err = p.vfsCache.Get(user, func(key string) (value interface{}, ok bool, err error) {
 		// We hash the auth here so we don't copy the auth more than we
 		// need to in memory. An attacker would find it easier to go
 		// after the unencrypted password in memory most likely.
 		entry := cacheEntry{
-			vfs:    vfs.New(f, &vfsflags.Opt),
 			pwHash: sha256.Sum256([]byte(auth)),
 		}
+
+		// The backend is made for each connection once the
+		// client has forwarded its agent
+		if needsAgent {
+			entry.newFs = newFs
+			entry.name = name
+			return entry, true, nil
+		}
+
+		// Create the Fs from the cache
+		f, err := cache.GetFn(p.ctx, fsString, func(ctx context.Context, fsString string) (fs.Fs, error) {
+			return newFs(ctx, name)
+		})
+		if err != nil {
+			return nil, false, err
+		}
+		entry.vfs = vfs.New(f, &vfsflags.Opt)
 		return entry, true, nil
 	})
 	if err != nil {
@@ -297,3 +366,38 @@ func (p *Proxy) Get(key string) *vfs.VFS {
 	entry := value.(cacheEntry)
 	return entry.vfs
 }
+
+// NeedsAgent returns true if the VFS for key must be made with
+// NewAgentVFS.
+func (p *Proxy) NeedsAgent(key string) bool {
+	value, ok := p.vfsCache.GetMaybe(key)
+	if !ok {
+		return false
+	}
+	entry := value.(cacheEntry)
+	return entry.newFs != nil
+}
+
+// NewAgentVFS makes a new VFS for key whose config needs the client's
+// agent. ctx should carry the agent for the backend to use.
+//
+// The VFS and its backend aren't cached or shared as the agent is only
+// valid for the client's connection, so the caller should shut them
+// down when the connection closes.
+func (p *Proxy) NewAgentVFS(ctx context.Context, key string) (*vfs.VFS, error) {
+	value, ok := p.vfsCache.GetMaybe(key)
+	if !ok {
+		return nil, errors.New("proxy: config not found in cache")
+	}
+	entry := value.(cacheEntry)
+	if entry.newFs == nil {
+		return nil, errors.New("proxy: config doesn't need an agent")
+	}
+	// Give each backend a unique name so the VFS isn't shared
+	n := atomic.AddInt32(&p.agentCount, 1)
+	f, err := entry.newFs(ctx, fmt.Sprintf("%s{agent%d}", entry.name, n))
+	if err != nil {
+		return nil, errors.Wrap(err, "proxy: failed to create backend")
+	}
+	return vfs.New(f, &vfsflags.Opt), nil
+}
@@ -22,6 +22,9 @@ func main() {
 	for k, v := range in {
 		switch k {
 		case "user":
+			if v == "agent" {
+				out["_agent"] = "true"
+			}
 			v += "-test"
 		case "error":
 			log.Fatal(v)
@@ -232,4 +232,48 @@ func TestRun(t *testing.T) {
 		// check cache is at the same level
 		assert.Equal(t, 1, p.vfsCache.Entries())
 	})
+
+	t.Run("Call w/Agent", func(t *testing.T) {
+		// check cache empty
+		assert.Equal(t, 0, p.vfsCache.Entries())
+		defer p.vfsCache.Clear()
+		const agentUser = "agent"
+
+		// not allowed unless AllowAgent has been called
+		_, _, err := p.Call(agentUser, testPass, false)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "_agent is only supported")
+		assert.Equal(t, 0, p.vfsCache.Entries())
+
+		p.AllowAgent()
+		defer func() {
+			p.allowAgent = false
+		}()
+		vfs, vfsKey, err := p.Call(agentUser, testPass, false)
+		require.NoError(t, err)
+		assert.Nil(t, vfs)
+		assert.Equal(t, agentUser, vfsKey)
+		assert.Nil(t, p.Get(vfsKey))
+		assert.True(t, p.NeedsAgent(vfsKey))
+		assert.False(t, p.NeedsAgent("unknown"))
+
+		// each VFS gets its own backend
+		vfs1, err := p.NewAgentVFS(context.Background(), vfsKey)
+		require.NoError(t, err)
+		defer vfs1.Shutdown()
+		vfs2, err := p.NewAgentVFS(context.Background(), vfsKey)
+		require.NoError(t, err)
+		defer vfs2.Shutdown()
+		assert.Equal(t, "proxy-agent{agent1}", vfs1.Fs().Name())
+		assert.Equal(t, "proxy-agent{agent2}", vfs2.Fs().Name())
+		assert.True(t, vfs1 != vfs2)
+
+		_, err = p.NewAgentVFS(context.Background(), "unknown")
+		require.Error(t, err)
+
+		// check the password is still checked
+		_, _, err = p.Call(agentUser, testPass+"wrong", false)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "incorrect password")
+	})
 }
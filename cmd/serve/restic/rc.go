@@ -0,0 +1,179 @@
+package restic
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/pingme998/rclone/fs"
+	fscache "github.com/pingme998/rclone/fs/cache"
+	"github.com/pingme998/rclone/fs/rc"
+	"github.com/pkg/errors"
+)
+
+// Keep track of active servers keyed on fs.ConfigString(f)
+var (
+	activeMu sync.Mutex
+	active   = map[string][]*Server{}
+)
+
+// addActive adds s to the active servers
+func addActive(s *Server) {
+	activeMu.Lock()
+	defer activeMu.Unlock()
+	configName := fs.ConfigString(s.f)
+	active[configName] = append(active[configName], s)
+}
+
+// removeActive removes s from the active servers
+func removeActive(s *Server) {
+	activeMu.Lock()
+	defer activeMu.Unlock()
+	configName := fs.ConfigString(s.f)
+	servers := active[configName]
+	for i, server := range servers {
+		if server == s {
+			servers[i] = nil
+			active[configName] = append(servers[:i], servers[i+1:]...)
+			break
+		}
+	}
+	if len(active[configName]) == 0 {
+		delete(active, configName)
+	}
+}
+
+const getServerHelp = `
+This takes an "fs" parameter. If this parameter is not supplied and
+there is only one restic server running then that will be used. If
+there is more than one then the "fs" parameter must be supplied.`
+
+// getServer finds the server for the "fs" parameter in in.
+//
+// If "fs" is not set and there is one and only one server running
+// then it returns it.
+func getServer(in rc.Params) (*Server, error) {
+	activeMu.Lock()
+	defer activeMu.Unlock()
+	fsString, err := in.GetString("fs")
+	if rc.IsErrParamNotFound(err) {
+		var s *Server
+		var count int
+		for _, servers := range active {
+			count += len(servers)
+			if len(servers) > 0 {
+				s = servers[0]
+			}
+		}
+		if count == 1 {
+			return s, nil
+		} else if count == 0 {
+			return nil, errors.New(`no restic server running and "fs" parameter not supplied`)
+		}
+		return nil, errors.New(`more than one restic server running - need "fs" parameter`)
+	} else if err != nil {
+		return nil, err
+	}
+	servers := active[fscache.Canonicalize(fsString)]
+	if len(servers) == 0 {
+		return nil, errors.Errorf("no restic server found with name %q", fsString)
+	}
+	return servers[0], nil
+}
+
+func init() {
+	rc.Add(rc.Call{
+		Path:  "restic/list",
+		Fn:    rcList,
+		Title: "List the repositories served by serve restic.",
+		Help: `
+This finds the restic repositories under the root of the served
+remote by looking for directories containing a "config" file. It
+doesn't look inside repositories once found.
+
+Repositories which have been written to through the server are
+always returned, even if they are beyond maxDepth.
+
+Parameters
+
+- maxDepth - limit the search to this many levels (default unlimited)
+
+Returns
+
+- repos - sorted list of repository paths, "" being the root
+` + getServerHelp,
+	})
+	rc.Add(rc.Call{
+		Path:  "restic/stats",
+		Fn:    rcStats,
+		Title: "Show object counts and sizes for a restic repository.",
+		Help: `
+This returns the number of objects, their total size and the most
+recent modification time for a repository served by serve restic,
+both in total and broken down by type (data, index, keys, locks and
+snapshots).
+
+The statistics are collected by listing the repository the first time
+they are asked for and are then cached until the repository is
+written to through the server.
+
+Parameters
+
+- repo - path of the repository relative to the served root (default "")
+- refresh - set to true to list the repository again even if cached
+
+Returns
+
+- stats - the statistics, with "collected" set to the time they were read
+` + getServerHelp,
+	})
+}
+
+// rcList implements restic/list
+func rcList(ctx context.Context, in rc.Params) (out rc.Params, err error) {
+	s, err := getServer(in)
+	if err != nil {
+		return nil, err
+	}
+	maxLevel, err := in.GetInt64("maxDepth")
+	if rc.IsErrParamNotFound(err) {
+		maxLevel = -1
+	} else if err != nil {
+		return nil, err
+	}
+	found, err := findRepos(ctx, s.f, int(maxLevel))
+	if err != nil {
+		return nil, err
+	}
+	seen := map[string]struct{}{}
+	repos := []string{}
+	for _, repo := range append(found, s.stats.known()...) {
+		if _, ok := seen[repo]; !ok {
+			seen[repo] = struct{}{}
+			repos = append(repos, repo)
+		}
+	}
+	sort.Strings(repos)
+	return rc.Params{"repos": repos}, nil
+}
+
+// rcStats implements restic/stats
+func rcStats(ctx context.Context, in rc.Params) (out rc.Params, err error) {
+	s, err := getServer(in)
+	if err != nil {
+		return nil, err
+	}
+	repo, err := in.GetString("repo")
+	if rc.NotErrParamNotFound(err) {
+		return nil, err
+	}
+	refresh, err := in.GetBool("refresh")
+	if rc.NotErrParamNotFound(err) {
+		return nil, err
+	}
+	stats, err := s.stats.get(ctx, s.f, repo, refresh)
+	if err != nil {
+		return nil, err
+	}
+	return rc.Params{"stats": stats}, nil
+}
@@ -0,0 +1,95 @@
+package restic
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/pingme998/rclone/cmd"
+	"github.com/pingme998/rclone/cmd/serve/httplib/httpflags"
+	"github.com/pingme998/rclone/fs"
+	"github.com/pingme998/rclone/fs/config/configfile"
+	"github.com/pingme998/rclone/fs/rc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepoOf(t *testing.T) {
+	for _, test := range []struct {
+		remote string
+		repo   string
+		ok     bool
+	}{
+		{"config", "", true},
+		{"keys/abcd", "", true},
+		{"data/21/2159dd48", "", true},
+		{"user1/repo/config", "user1/repo", true},
+		{"user1/repo/snapshots/abcd", "user1/repo", true},
+		{"data/repo/data/21/2159dd48", "data/repo", true},
+		{"repo/data/2159dd48", "", false},
+		{"repo/other/abcd", "", false},
+		{"repo", "", false},
+	} {
+		repo, ok := repoOf(test.remote)
+		assert.Equal(t, test.ok, ok, test.remote)
+		assert.Equal(t, test.repo, repo, test.remote)
+	}
+}
+
+func TestRcStats(t *testing.T) {
+	ctx := context.Background()
+	configfile.Install()
+
+	tempdir, err := ioutil.TempDir("", "rclone-restic-test-")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(tempdir))
+	}()
+
+	f := cmd.NewFsSrc([]string{tempdir})
+	srv := NewServer(f, &httpflags.Opt)
+	defer removeActive(srv)
+
+	post := func(path, body string) {
+		checkRequest(t, srv.ServeHTTP,
+			newRequest(t, "POST", path, strings.NewReader(body)),
+			[]wantFunc{wantCode(http.StatusOK)})
+	}
+	post("/repo/?create=true", "")
+	post("/repo/config", "config")
+	post("/repo/data/2159dd48", "data1")
+	post("/repo/data/3159dd48", "data22")
+	post("/repo/snapshots/abcd", "snap")
+
+	call := func(path string, in rc.Params) rc.Params {
+		in["fs"] = fs.ConfigString(f)
+		out, err := rc.Calls.Get(path).Fn(ctx, in)
+		require.NoError(t, err)
+		return out
+	}
+
+	out := call("restic/list", rc.Params{})
+	assert.Equal(t, []string{"repo"}, out["repos"])
+
+	out = call("restic/stats", rc.Params{"repo": "repo"})
+	stats := out["stats"].(*repoStats)
+	assert.Equal(t, int64(4), stats.Count)
+	assert.Equal(t, int64(21), stats.Size)
+	assert.Equal(t, int64(2), stats.Types["data"].Count)
+	assert.Equal(t, int64(11), stats.Types["data"].Size)
+	assert.Equal(t, int64(1), stats.Types["snapshots"].Count)
+	assert.Equal(t, int64(0), stats.Types["index"].Count)
+
+	// cached until written to
+	out = call("restic/stats", rc.Params{"repo": "repo"})
+	assert.Equal(t, stats, out["stats"])
+
+	post("/repo/index/abcd", "index")
+	out = call("restic/stats", rc.Params{"repo": "repo"})
+	stats = out["stats"].(*repoStats)
+	assert.Equal(t, int64(5), stats.Count)
+	assert.Equal(t, int64(1), stats.Types["index"].Count)
+}
@@ -128,11 +128,19 @@ these **must** end with /.  Eg
 
 The "--private-repos" flag can be used to limit users to repositories starting
 with a path of ` + "`/<username>/`" + `.
+
+#### Monitoring repositories ####
+
+If rclone is run with --rc then the repositories being served can be
+found with "rclone rc restic/list" and their object counts and sizes
+by type with "rclone rc restic/stats repo=path". The statistics are
+collected on demand and cached until the repository is written to.
 ` + httplib.Help,
 	Run: func(command *cobra.Command, args []string) {
 		cmd.CheckArgs(1, 1, command, args)
 		f := cmd.NewFsSrc(args)
 		cmd.Run(false, true, command, func() error {
+			defer cmd.ReloadOnSIGHUP()()
 			s := NewServer(f, &httpflags.Opt)
 			if stdio {
 				if terminal.IsTerminal(int(os.Stdout.Fd())) {
@@ -170,6 +178,7 @@ type Server struct {
 	*httplib.Server
 	f     fs.Fs
 	cache *cache
+	stats *statsCache
 }
 
 // NewServer returns an HTTP server that speaks the rest protocol
@@ -179,8 +188,10 @@ func NewServer(f fs.Fs, opt *httplib.Options) *Server {
 		Server: httplib.NewServer(mux, opt),
 		f:      f,
 		cache:  newCache(),
+		stats:  newStatsCache(),
 	}
 	mux.HandleFunc(s.Opt.BaseURL+"/", s.ServeHTTP)
+	addActive(s)
 	return s
 }
 
@@ -196,6 +207,12 @@ func (s *Server) Serve() error {
 	return nil
 }
 
+// Close shuts the running server down and removes it from the rc
+func (s *Server) Close() {
+	removeActive(s)
+	s.Server.Close()
+}
+
 var matchData = regexp.MustCompile("(?:^|/)data/([^/]{2,})$")
 
 // Makes a remote from a URL path.  This implements the backend layout
@@ -307,6 +324,7 @@ func (s *Server) postObject(w http.ResponseWriter, r *http.Request, remote strin
 
 	// if successfully uploaded add to cache
 	s.cache.add(remote, o)
+	s.stats.changed(remote)
 }
 
 // delete the remote
@@ -340,6 +358,7 @@ func (s *Server) deleteObject(w http.ResponseWriter, r *http.Request, remote str
 
 	// remove object from cache
 	s.cache.remove(remote)
+	s.stats.changed(remote)
 }
 
 // listItem is an element returned for the restic v2 list response
@@ -0,0 +1,193 @@
+package restic
+
+import (
+	"context"
+	"path"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pingme998/rclone/fs"
+	"github.com/pingme998/rclone/fs/fserrors"
+	"github.com/pingme998/rclone/fs/walk"
+)
+
+// repoTypes are the directories of a restic repository
+var repoTypes = []string{"data", "index", "keys", "locks", "snapshots"}
+
+// matchRepoObject finds the repository an object belongs to
+var matchRepoObject = regexp.MustCompile(`^(?:(.*)/)?(?:config|(?:data/[^/]{2}|index|keys|locks|snapshots)/[^/]+)$`)
+
+// repoOf returns the repository remote is part of and whether it was
+// found.
+func repoOf(remote string) (repo string, ok bool) {
+	parts := matchRepoObject.FindStringSubmatch(remote)
+	if parts == nil {
+		return "", false
+	}
+	return parts[1], true
+}
+
+// typeStats holds the statistics for one type of object in a
+// repository
+type typeStats struct {
+	Count     int64     `json:"count"`
+	Size      int64     `json:"size"`
+	LastWrite time.Time `json:"lastWrite"`
+}
+
+// add o to the statistics
+func (ts *typeStats) add(ctx context.Context, o fs.Object) {
+	ts.Count++
+	ts.Size += o.Size()
+	if modTime := o.ModTime(ctx); modTime.After(ts.LastWrite) {
+		ts.LastWrite = modTime
+	}
+}
+
+// repoStats holds the statistics for a repository
+type repoStats struct {
+	Path      string                `json:"path"`
+	Count     int64                 `json:"count"`
+	Size      int64                 `json:"size"`
+	LastWrite time.Time             `json:"lastWrite"`
+	Types     map[string]*typeStats `json:"types"`
+	Collected time.Time             `json:"collected"`
+}
+
+// newRepoStats makes an empty repoStats for repo
+func newRepoStats(repo string) *repoStats {
+	rs := &repoStats{
+		Path:  repo,
+		Types: make(map[string]*typeStats, len(repoTypes)),
+	}
+	for _, name := range repoTypes {
+		rs.Types[name] = &typeStats{}
+	}
+	return rs
+}
+
+// add o to the statistics - its remote should be relative to the
+// repository root
+func (rs *repoStats) add(ctx context.Context, remote string, o fs.Object) {
+	rs.Count++
+	rs.Size += o.Size()
+	if modTime := o.ModTime(ctx); modTime.After(rs.LastWrite) {
+		rs.LastWrite = modTime
+	}
+	name := remote
+	if i := strings.IndexRune(remote, '/'); i >= 0 {
+		name = remote[:i]
+	}
+	if ts, ok := rs.Types[name]; ok {
+		ts.add(ctx, o)
+	}
+}
+
+// statsCache holds the statistics for each repository, collected
+// lazily when asked for and thrown away when the repository changes.
+type statsCache struct {
+	mu    sync.Mutex            // protects the cache
+	repos map[string]*repoStats // collected stats keyed on repo
+	seen  map[string]struct{}   // repos written through the server
+}
+
+// create a new statsCache
+func newStatsCache() *statsCache {
+	return &statsCache{
+		repos: map[string]*repoStats{},
+		seen:  map[string]struct{}{},
+	}
+}
+
+// changed marks the repository containing remote as modified so its
+// statistics will be collected again next time they are read.
+func (c *statsCache) changed(remote string) {
+	repo, ok := repoOf(remote)
+	if !ok {
+		return
+	}
+	c.mu.Lock()
+	delete(c.repos, repo)
+	c.seen[repo] = struct{}{}
+	c.mu.Unlock()
+}
+
+// known returns the repositories which have been written through
+// this server.
+func (c *statsCache) known() (repos []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for repo := range c.seen {
+		repos = append(repos, repo)
+	}
+	return repos
+}
+
+// get returns the statistics for repo, collecting them from f if
+// they are not cached or refresh is set.
+func (c *statsCache) get(ctx context.Context, f fs.Fs, repo string, refresh bool) (*repoStats, error) {
+	repo = strings.Trim(repo, "/")
+	c.mu.Lock()
+	rs := c.repos[repo]
+	c.mu.Unlock()
+	if rs != nil && !refresh {
+		return rs, nil
+	}
+	rs, err := collectStats(ctx, f, repo)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	c.repos[repo] = rs
+	c.mu.Unlock()
+	return rs, nil
+}
+
+// collectStats lists the repository at repo in f and returns its
+// statistics.
+func collectStats(ctx context.Context, f fs.Fs, repo string) (*repoStats, error) {
+	rs := newRepoStats(repo)
+	prefix := repo
+	if prefix != "" {
+		prefix += "/"
+	}
+	err := walk.ListR(ctx, f, repo, true, -1, walk.ListObjects, func(entries fs.DirEntries) error {
+		for _, entry := range entries {
+			if o, ok := entry.(fs.Object); ok {
+				rs.add(ctx, strings.TrimPrefix(o.Remote(), prefix), o)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	rs.Collected = time.Now()
+	return rs, nil
+}
+
+// findRepos walks f looking for directories with a restic config file
+// in and returns their paths. It doesn't descend into repositories.
+func findRepos(ctx context.Context, f fs.Fs, maxLevel int) (repos []string, err error) {
+	err = walk.Walk(ctx, f, "", true, maxLevel, func(dirPath string, entries fs.DirEntries, err error) error {
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			if o, ok := entry.(fs.Object); ok && path.Base(o.Remote()) == "config" {
+				repos = append(repos, dirPath)
+				return walk.ErrorSkipDir
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		_, err = fserrors.Cause(err)
+		if err != fs.ErrorDirNotFound {
+			return nil, err
+		}
+	}
+	return repos, nil
+}
@@ -0,0 +1,135 @@
+// +build !plan9
+
+package sftp
+
+import (
+	"io"
+
+	"github.com/pkg/errors"
+	"github.com/pingme998/rclone/fs"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+// OpenSSH extensions for agent forwarding
+const (
+	agentRequestType = "auth-agent-req@openssh.com"
+	agentChannelType = "auth-agent@openssh.com"
+)
+
+// forwardedAgent is an agent.Agent which talks to the agent forwarded
+// by the client.
+//
+// It opens a new channel to the client for each call, as sshd does,
+// since clients don't exit while any of their channels are open.
+type forwardedAgent struct {
+	sshConn ssh.Conn
+	what    string
+}
+
+// call fn with a client talking to the forwarded agent
+func (a *forwardedAgent) call(fn func(client agent.ExtendedAgent) error) error {
+	channel, requests, err := a.sshConn.OpenChannel(agentChannelType, nil)
+	if err != nil {
+		return errors.Wrap(err, "failed to open channel to the forwarded agent")
+	}
+	go ssh.DiscardRequests(requests)
+	defer func() {
+		err := channel.Close()
+		if err != nil && err != io.EOF {
+			fs.Debugf(a.what, "Failed to close agent channel: %v", err)
+		}
+	}()
+	return fn(agent.NewClient(channel))
+}
+
+// List returns the identities known to the agent.
+func (a *forwardedAgent) List() (keys []*agent.Key, err error) {
+	err = a.call(func(client agent.ExtendedAgent) error {
+		keys, err = client.List()
+		return err
+	})
+	return keys, err
+}
+
+// Sign has the agent sign the data using a protocol 2 key as defined
+// in [PROTOCOL.agent] section 2.6.2.
+func (a *forwardedAgent) Sign(key ssh.PublicKey, data []byte) (signature *ssh.Signature, err error) {
+	err = a.call(func(client agent.ExtendedAgent) error {
+		signature, err = client.Sign(key, data)
+		return err
+	})
+	return signature, err
+}
+
+// Add adds a private key to the agent.
+func (a *forwardedAgent) Add(key agent.AddedKey) error {
+	return a.call(func(client agent.ExtendedAgent) error {
+		return client.Add(key)
+	})
+}
+
+// Remove removes all identities with the given public key.
+func (a *forwardedAgent) Remove(key ssh.PublicKey) error {
+	return a.call(func(client agent.ExtendedAgent) error {
+		return client.Remove(key)
+	})
+}
+
+// RemoveAll removes all identities.
+func (a *forwardedAgent) RemoveAll() error {
+	return a.call(func(client agent.ExtendedAgent) error {
+		return client.RemoveAll()
+	})
+}
+
+// Lock locks the agent. Sign and Remove will fail, and List will
+// return an empty list.
+func (a *forwardedAgent) Lock(passphrase []byte) error {
+	return a.call(func(client agent.ExtendedAgent) error {
+		return client.Lock(passphrase)
+	})
+}
+
+// Unlock undoes the effect of Lock
+func (a *forwardedAgent) Unlock(passphrase []byte) error {
+	return a.call(func(client agent.ExtendedAgent) error {
+		return client.Unlock(passphrase)
+	})
+}
+
+// Signers returns signers for all the known keys. These sign with the
+// forwarded agent so are usable for as long as the client is
+// connected.
+func (a *forwardedAgent) Signers() (signers []ssh.Signer, err error) {
+	keys, err := a.List()
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range keys {
+		signers = append(signers, &forwardedSigner{agent: a, pub: key})
+	}
+	return signers, nil
+}
+
+// forwardedSigner is an ssh.Signer for a key in the forwarded agent
+type forwardedSigner struct {
+	agent *forwardedAgent
+	pub   ssh.PublicKey
+}
+
+// PublicKey returns the public key of the signer
+func (s *forwardedSigner) PublicKey() ssh.PublicKey {
+	return s.pub
+}
+
+// Sign signs data with the forwarded agent
+func (s *forwardedSigner) Sign(rand io.Reader, data []byte) (*ssh.Signature, error) {
+	return s.agent.Sign(s.pub, data)
+}
+
+// Check interfaces
+var (
+	_ agent.Agent = (*forwardedAgent)(nil)
+	_ ssh.Signer  = (*forwardedSigner)(nil)
+)
@@ -0,0 +1,92 @@
+// +build !plan9
+
+package sftp
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+func TestForwardedAgent(t *testing.T) {
+	// Make a key for the client's agent
+	_, clientKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	keyring := agent.NewKeyring()
+	require.NoError(t, keyring.Add(agent.AddedKey{PrivateKey: clientKey}))
+
+	// Make a server and a client connected to it
+	_, hostKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	hostSigner, err := ssh.NewSignerFromKey(hostKey)
+	require.NoError(t, err)
+	serverConfig := &ssh.ServerConfig{NoClientAuth: true}
+	serverConfig.AddHostKey(hostSigner)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer func() {
+		_ = listener.Close()
+	}()
+	serverErr := make(chan error, 1)
+	var serverConn *ssh.ServerConn
+	go func() {
+		serverNetConn, err := listener.Accept()
+		if err != nil {
+			serverErr <- err
+			return
+		}
+		var chans <-chan ssh.NewChannel
+		var reqs <-chan *ssh.Request
+		serverConn, chans, reqs, err = ssh.NewServerConn(serverNetConn, serverConfig)
+		if err == nil {
+			go ssh.DiscardRequests(reqs)
+			go func() {
+				for newChannel := range chans {
+					_ = newChannel.Reject(ssh.UnknownChannelType, "unknown channel type")
+				}
+			}()
+		}
+		serverErr <- err
+	}()
+	clientNetConn, err := net.Dial("tcp", listener.Addr().String())
+	require.NoError(t, err)
+	clientConn, chans, reqs, err := ssh.NewClientConn(clientNetConn, "", &ssh.ClientConfig{
+		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+	})
+	require.NoError(t, err)
+	require.NoError(t, <-serverErr)
+	client := ssh.NewClient(clientConn, chans, reqs)
+	defer func() {
+		_ = client.Close()
+	}()
+	require.NoError(t, agent.ForwardToAgent(client, keyring))
+
+	a := &forwardedAgent{sshConn: serverConn, what: "test"}
+
+	keys, err := a.List()
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+
+	signers, err := a.Signers()
+	require.NoError(t, err)
+	require.Len(t, signers, 1)
+	pub := signers[0].PublicKey()
+	assert.Equal(t, keys[0].Marshal(), pub.Marshal())
+
+	// Check the signature can be verified with the public key
+	data := []byte("data to sign")
+	signature, err := signers[0].Sign(rand.Reader, data)
+	require.NoError(t, err)
+	assert.NoError(t, pub.Verify(data, signature))
+
+	// Check the agent can't be used once the client has gone
+	require.NoError(t, client.Close())
+	_, err = a.List()
+	assert.Error(t, err)
+}
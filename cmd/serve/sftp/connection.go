@@ -9,6 +9,7 @@ import (
 	"net"
 	"regexp"
 	"strings"
+	"sync"
 
 	"github.com/pkg/errors"
 	"github.com/pkg/sftp"
@@ -16,6 +17,7 @@ import (
 	"github.com/pingme998/rclone/fs/hash"
 	"github.com/pingme998/rclone/vfs"
 	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
 )
 
 func describeConn(c interface {
@@ -49,6 +51,58 @@ type conn struct {
 	vfs      *vfs.VFS
 	handlers sftp.Handlers
 	what     string
+	sshConn  ssh.Conn
+	// makes the VFS with the client's forwarded agent if the proxy
+	// needs it, in which case vfs and handlers are set by setupVFS
+	newAgentVFS    func(ag agent.Agent) (*vfs.VFS, error)
+	mu             sync.Mutex // protects the below and vfs and handlers if newAgentVFS is set
+	agentForwarded bool       // set if the client has requested agent forwarding
+	closed         bool       // set when the connection has closed
+}
+
+// setupVFS makes the VFS with the client's forwarded agent if it is
+// needed and hasn't been made yet.
+func (c *conn) setupVFS() error {
+	if c.newAgentVFS == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.vfs != nil {
+		return nil
+	}
+	if c.closed {
+		return errors.New("connection closed")
+	}
+	if !c.agentForwarded {
+		return errors.New("this login needs SSH agent forwarding (eg ssh -A)")
+	}
+	VFS, err := c.newAgentVFS(&forwardedAgent{sshConn: c.sshConn, what: c.what})
+	if err != nil {
+		return err
+	}
+	c.vfs = VFS
+	c.handlers = newVFSHandler(VFS)
+	return nil
+}
+
+// closeAgentVFS shuts down the VFS and backend made with the client's
+// agent when the connection has closed
+func (c *conn) closeAgentVFS() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closed = true
+	if c.vfs == nil {
+		return
+	}
+	f := c.vfs.Fs()
+	c.vfs.Shutdown()
+	if do := f.Features().Shutdown; do != nil {
+		err := do(context.Background())
+		if err != nil {
+			fs.Errorf(f, "Failed to shut down backend: %v", err)
+		}
+	}
 }
 
 // execCommand implements an extremely limited number of commands to
@@ -209,6 +263,14 @@ func (c *conn) handleChannel(newChannel ssh.NewChannel) {
 					ok = true
 					subSystemIsSFTP = false
 				}
+			case agentRequestType:
+				// Only accept agent forwarding if it is needed
+				if c.newAgentVFS != nil {
+					c.mu.Lock()
+					c.agentForwarded = true
+					c.mu.Unlock()
+					ok = true
+				}
 			}
 			fs.Debugf(c.what, " - accepted: %v\n", ok)
 			err = req.Reply(ok, reply)
@@ -216,7 +278,7 @@ func (c *conn) handleChannel(newChannel ssh.NewChannel) {
 				fs.Errorf(c.what, "Failed to Reply to request: %v", err)
 				return
 			}
-			if ok {
+			if ok && req.Type != agentRequestType {
 				// Wake up main routine after we have responded
 				isSFTP <- subSystemIsSFTP
 			}
@@ -225,6 +287,12 @@ func (c *conn) handleChannel(newChannel ssh.NewChannel) {
 
 	// Wait for either subsystem "sftp" or "exec" request
 	if <-isSFTP {
+		// The client has forwarded its agent by now if it is going to
+		err = c.setupVFS()
+		if err != nil {
+			fs.Errorf(c.what, "Failed to start SFTP server: %v", err)
+			return
+		}
 		fs.Debugf(c.what, "Starting SFTP server")
 		server := sftp.NewRequestServer(channel, c.handlers)
 		defer func() {
@@ -241,7 +309,10 @@ func (c *conn) handleChannel(newChannel ssh.NewChannel) {
 		}
 	} else {
 		var rc = uint32(0)
-		err := c.execCommand(context.TODO(), channel, command.Command)
+		err := c.setupVFS()
+		if err == nil {
+			err = c.execCommand(context.TODO(), channel, command.Command)
+		}
 		if err != nil {
 			rc = 1
 			_, errPrint := fmt.Fprintf(channel.Stderr(), "%v\n", err)
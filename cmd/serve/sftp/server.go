@@ -19,6 +19,7 @@ import (
 	"strings"
 
 	"github.com/pkg/errors"
+	sftpbackend "github.com/pingme998/rclone/backend/sftp"
 	"github.com/pingme998/rclone/cmd/serve/proxy"
 	"github.com/pingme998/rclone/cmd/serve/proxy/proxyflags"
 	"github.com/pingme998/rclone/fs"
@@ -27,6 +28,7 @@ import (
 	"github.com/pingme998/rclone/vfs"
 	"github.com/pingme998/rclone/vfs/vfsflags"
 	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
 )
 
 // server contains everything to run the server
@@ -50,6 +52,7 @@ func newServer(ctx context.Context, f fs.Fs, opt *Options) *server {
 	}
 	if proxyflags.Opt.AuthProxy != "" {
 		s.proxy = proxy.New(ctx, &proxyflags.Opt)
+		s.proxy.AllowAgent()
 	} else {
 		s.vfs = vfs.New(f, &vfsflags.Opt)
 	}
@@ -57,25 +60,33 @@ func newServer(ctx context.Context, f fs.Fs, opt *Options) *server {
 }
 
 // getVFS gets the vfs from s or the proxy
-func (s *server) getVFS(what string, sshConn *ssh.ServerConn) (VFS *vfs.VFS) {
+//
+// If the proxy needs the client's forwarded agent to make the VFS then
+// it returns a nil VFS and a function to make it with the agent.
+func (s *server) getVFS(what string, sshConn *ssh.ServerConn) (VFS *vfs.VFS, newAgentVFS func(ag agent.Agent) (*vfs.VFS, error)) {
 	if s.proxy == nil {
-		return s.vfs
+		return s.vfs, nil
 	}
 	if sshConn.Permissions == nil && sshConn.Permissions.Extensions == nil {
 		fs.Infof(what, "SSH Permissions Extensions not found")
-		return nil
+		return nil, nil
 	}
 	key := sshConn.Permissions.Extensions["_vfsKey"]
 	if key == "" {
 		fs.Infof(what, "VFS key not found")
-		return nil
+		return nil, nil
+	}
+	if s.proxy.NeedsAgent(key) {
+		return nil, func(ag agent.Agent) (*vfs.VFS, error) {
+			return s.proxy.NewAgentVFS(sftpbackend.ContextWithAgent(s.ctx, ag), key)
+		}
 	}
 	VFS = s.proxy.Get(key)
 	if VFS == nil {
 		fs.Infof(what, "failed to read VFS from cache")
-		return nil
+		return nil, nil
 	}
-	return VFS
+	return VFS, nil
 }
 
 // Accept a single connection - run in a go routine as the ssh
@@ -96,15 +107,24 @@ func (s *server) acceptConnection(nConn net.Conn) {
 	go ssh.DiscardRequests(reqs)
 
 	c := &conn{
-		what: what,
-		vfs:  s.getVFS(what, sshConn),
+		what:    what,
+		sshConn: sshConn,
 	}
-	if c.vfs == nil {
+	c.vfs, c.newAgentVFS = s.getVFS(what, sshConn)
+	if c.newAgentVFS != nil {
+		// The VFS is made when the client has forwarded its agent
+		// and shut down when the client disconnects
+		go func() {
+			_ = sshConn.Wait()
+			c.closeAgentVFS()
+		}()
+	} else if c.vfs == nil {
 		fs.Infof(what, "Closing unauthenticated connection (couldn't find VFS)")
 		_ = nConn.Close()
 		return
+	} else {
+		c.handlers = newVFSHandler(c.vfs)
 	}
-	c.handlers = newVFSHandler(c.vfs)
 
 	// Accept all channels
 	go c.handleChannels(chans)
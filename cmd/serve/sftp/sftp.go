@@ -100,6 +100,7 @@ sftp backend, but it may not be with other SFTP clients.
 			cmd.CheckArgs(0, 0, command, args)
 		}
 		cmd.Run(false, true, command, func() error {
+			defer cmd.ReloadOnSIGHUP()()
 			s := newServer(context.Background(), f, &Opt)
 			err := s.Serve()
 			if err != nil {
@@ -3,8 +3,10 @@ package webdav
 
 import (
 	"context"
+	"encoding/xml"
 	"net/http"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
@@ -62,6 +64,15 @@ supported hash on the backend or you can use a named hash such as
 
 Use "rclone hashsum" to see the full list.
 
+#### Quota
+
+If the remote supports "rclone about" then the free and used space
+are returned in the RFC 4331 quota-available-bytes and
+quota-used-bytes properties of directories so WebDAV clients, e.g.
+Windows Explorer and the macOS Finder, can show them.  These are
+cached for --dir-cache-time.  If --vfs-used-is-size is set then the
+used space is the total size of the files instead.
+
 ` + httplib.Help + vfs.Help + proxy.Help,
 	RunE: func(command *cobra.Command, args []string) error {
 		var f fs.Fs
@@ -84,6 +95,7 @@ Use "rclone hashsum" to see the full list.
 			fs.Debugf(f, "Using hash %v for ETag", hashType)
 		}
 		cmd.Run(false, false, command, func() error {
+			defer cmd.ReloadOnSIGHUP()()
 			s := newWebDAV(context.Background(), f, &httpflags.Opt)
 			err := s.serve()
 			if err != nil {
@@ -347,6 +359,50 @@ func (h Handle) Stat() (fi os.FileInfo, err error) {
 	return FileInfo{fi}, nil
 }
 
+// Names of the RFC 4331 quota properties
+var (
+	quotaAvailableBytes = xml.Name{Space: "DAV:", Local: "quota-available-bytes"}
+	quotaUsedBytes      = xml.Name{Space: "DAV:", Local: "quota-used-bytes"}
+)
+
+// DeadProps returns the RFC 4331 quota properties for directories so
+// clients can show the free space.
+//
+// These are only returned if the remote supports About or
+// --vfs-used-is-size is in use.
+func (h Handle) DeadProps() (map[xml.Name]webdav.Property, error) {
+	node := h.Handle.Node()
+	if !node.IsDir() {
+		return nil, nil
+	}
+	VFS := node.VFS()
+	if VFS.Fs().Features().About == nil && !VFS.Opt.UsedIsSize {
+		return nil, nil
+	}
+	_, used, free := VFS.Statfs()
+	return map[xml.Name]webdav.Property{
+		quotaAvailableBytes: {
+			XMLName:  quotaAvailableBytes,
+			InnerXML: []byte(strconv.FormatInt(free, 10)),
+		},
+		quotaUsedBytes: {
+			XMLName:  quotaUsedBytes,
+			InnerXML: []byte(strconv.FormatInt(used, 10)),
+		},
+	}, nil
+}
+
+// Patch refuses to set any properties as they can't be stored
+func (h Handle) Patch(proppatches []webdav.Proppatch) ([]webdav.Propstat, error) {
+	pstat := webdav.Propstat{Status: http.StatusForbidden}
+	for _, patch := range proppatches {
+		for _, p := range patch.Props {
+			pstat.Props = append(pstat.Props, webdav.Property{XMLName: p.XMLName})
+		}
+	}
+	return []webdav.Propstat{pstat}, nil
+}
+
 // FileInfo represents info about a file satisfying os.FileInfo and
 // also some additional interfaces for webdav for ETag and ContentType
 type FileInfo struct {
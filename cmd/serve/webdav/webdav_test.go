@@ -39,9 +39,10 @@ const (
 
 // check interfaces
 var (
-	_ os.FileInfo         = FileInfo{nil}
-	_ webdav.ETager       = FileInfo{nil}
-	_ webdav.ContentTyper = FileInfo{nil}
+	_ os.FileInfo            = FileInfo{nil}
+	_ webdav.ETager          = FileInfo{nil}
+	_ webdav.ContentTyper    = FileInfo{nil}
+	_ webdav.DeadPropsHolder = Handle{nil}
 )
 
 // TestWebDav runs the webdav server then runs the unit tests for the
@@ -126,6 +127,7 @@ func TestHTTPFunction(t *testing.T) {
 	}
 
 	HelpTestGET(t, testURL)
+	HelpTestQuota(t, testURL)
 }
 
 // check body against the file, or re-write body if -updategolden is
@@ -144,6 +146,33 @@ func checkGolden(t *testing.T, fileName string, got []byte) {
 	}
 }
 
+// HelpTestQuota checks the RFC 4331 quota properties are returned for
+// directories but not files
+func HelpTestQuota(t *testing.T, testURL string) {
+	propfind := func(URL string) string {
+		req, err := http.NewRequest("PROPFIND", testURL+URL, strings.NewReader(`<?xml version="1.0" encoding="utf-8" ?>
+<D:propfind xmlns:D="DAV:"><D:prop><D:quota-available-bytes/><D:quota-used-bytes/></D:prop></D:propfind>`))
+		require.NoError(t, err)
+		req.Header.Set("Depth", "0")
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer func() { _ = resp.Body.Close() }()
+		assert.Equal(t, http.StatusMultiStatus, resp.StatusCode)
+		body, err := ioutil.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return string(body)
+	}
+
+	body := propfind("")
+	assert.Regexp(t, `<D:quota-available-bytes>[0-9]+</D:quota-available-bytes>`, body)
+	assert.Regexp(t, `<D:quota-used-bytes>[0-9]+</D:quota-used-bytes>`, body)
+	assert.Contains(t, body, "200 OK")
+
+	body = propfind("two.txt")
+	assert.NotRegexp(t, `quota-available-bytes>[0-9]`, body)
+	assert.Contains(t, body, "404 Not Found")
+}
+
 func HelpTestGET(t *testing.T, testURL string) {
 	for _, test := range []struct {
 		URL    string
@@ -0,0 +1,180 @@
+// Package stat provides the stat command.
+package stat
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/pingme998/rclone/cmd"
+	"github.com/pingme998/rclone/fs"
+	"github.com/pingme998/rclone/fs/config/flags"
+	"github.com/pingme998/rclone/fs/fspath"
+	"github.com/pingme998/rclone/fs/operations"
+	"github.com/spf13/cobra"
+)
+
+var (
+	opt        operations.ListJSONOpt
+	jsonOutput bool
+)
+
+func init() {
+	cmd.Root.AddCommand(commandDefinition)
+	cmdFlags := commandDefinition.Flags()
+	flags.BoolVarP(cmdFlags, &jsonOutput, "json", "", false, "Format output as JSON")
+	flags.BoolVarP(cmdFlags, &opt.ShowHash, "hash", "", false, "Include hashes in the output (may take longer).")
+	flags.StringArrayVarP(cmdFlags, &opt.HashTypes, "hash-type", "", nil, "Show only this hash type (may be repeated).")
+	flags.BoolVarP(cmdFlags, &opt.NoModTime, "no-modtime", "", false, "Don't read the modification time (can speed things up).")
+	flags.BoolVarP(cmdFlags, &opt.NoMimeType, "no-mimetype", "", false, "Don't read the mime type (can speed things up).")
+	flags.BoolVarP(cmdFlags, &opt.ShowEncrypted, "encrypted", "M", false, "Show the encrypted names.")
+	flags.BoolVarP(cmdFlags, &opt.ShowOrigIDs, "original", "", false, "Show the ID of the underlying Object.")
+	flags.BoolVarP(cmdFlags, &opt.ShowMetadata, "metadata", "", false, "Include backend specific metadata in the output (may take longer).")
+}
+
+var commandDefinition = &cobra.Command{
+	Use:   "stat remote:path",
+	Short: `Prints the metadata of a single object or directory.`,
+	Long: `
+rclone stat prints everything rclone knows about the single object or
+directory at remote:path.
+
+    $ rclone stat --hash remote:path/file.txt
+    Path:     path/file.txt
+    Name:     file.txt
+    Size:     6 (6 Byte)
+    ModTime:  2017-05-31T16:15:57.034468261+01:00
+    IsDir:    false
+    MimeType: text/plain; charset=utf-8
+    ID:       y2djkhiujf83u33
+    Tier:     hot
+    MD5:      b1946ac92492d2347c6235b4d2611184
+    SHA-1:    f572d396fae9206628714fb2ce00f72e94f2258f
+
+Fields which the remote doesn't support or which are empty are left
+out.
+
+Use --metadata to show the backend specific metadata of an object,
+for example the user metadata on s3, as "Metadata.key" lines. See
+[rclone lsjson](/commands/rclone_lsjson/) for which backends have
+metadata.
+
+Use --json to print the same item as a single JSON object in the same
+format as each item of [rclone lsjson](/commands/rclone_lsjson/). This
+is useful for scripting as, if remote:path is an object, only that
+object needs to be looked up rather than listing the whole of its
+parent directory as lsjson would need to.
+
+If remote:path is a directory then its parent directory is listed to
+find it.
+
+Hashes aren't shown unless --hash or --hash-type is used as they may
+need to be calculated which can take a long time. The other flags work
+in the same way as for lsjson.
+
+rclone stat returns with exit code 3 (dir_not_found) if remote:path
+doesn't exist.
+`,
+	Run: func(command *cobra.Command, args []string) {
+		cmd.CheckArgs(1, 1, command, args)
+		remote := args[0]
+		if trimmed := strings.TrimRight(remote, "/"); trimmed != "" && !strings.HasSuffix(trimmed, ":") {
+			remote = trimmed
+		}
+		parent, leaf, err := fspath.Split(remote)
+		if err != nil {
+			log.Fatalf("Failed to parse %q: %v", remote, err)
+		}
+		if leaf == "." || leaf == ".." {
+			parent, leaf = remote, ""
+		} else if leaf == "" {
+			parent = remote
+		} else if parent == "" {
+			parent = "."
+		}
+		fsrc := cmd.NewFsDir([]string{parent})
+		cmd.Run(false, false, command, func() error {
+			item, err := operations.StatJSON(context.Background(), fsrc, leaf, &opt)
+			if err == fs.ErrorObjectNotFound {
+				return fs.ErrorDirNotFound
+			} else if err != nil {
+				return err
+			}
+			return Write(os.Stdout, item, jsonOutput)
+		})
+	},
+}
+
+// Write item to out as text or JSON
+func Write(out io.Writer, item *operations.ListJSONItem, asJSON bool) error {
+	if asJSON {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "\t")
+		return enc.Encode(item)
+	}
+	type field struct {
+		key, value string
+	}
+	fields := []field{
+		{"Path", item.Path},
+		{"Name", item.Name},
+	}
+	if item.EncryptedPath != "" {
+		fields = append(fields, field{"EncryptedPath", item.EncryptedPath}, field{"Encrypted", item.Encrypted})
+	}
+	if item.Size >= 0 {
+		fields = append(fields, field{"Size", fmt.Sprintf("%d (%v)", item.Size, fs.SizeSuffix(item.Size).ByteUnit())})
+	}
+	if !item.ModTime.When.IsZero() {
+		fields = append(fields, field{"ModTime", item.ModTime.When.Format(item.ModTime.Format)})
+	}
+	fields = append(fields, field{"IsDir", fmt.Sprint(item.IsDir)})
+	if item.IsBucket {
+		fields = append(fields, field{"IsBucket", "true"})
+	}
+	for _, f := range []field{
+		{"MimeType", item.MimeType},
+		{"ID", item.ID},
+		{"OrigID", item.OrigID},
+		{"Tier", item.Tier},
+	} {
+		if f.value != "" {
+			fields = append(fields, f)
+		}
+	}
+	hashNames := make([]string, 0, len(item.Hashes))
+	for name := range item.Hashes {
+		hashNames = append(hashNames, name)
+	}
+	sort.Strings(hashNames)
+	for _, name := range hashNames {
+		fields = append(fields, field{name, item.Hashes[name]})
+	}
+	metaKeys := make([]string, 0, len(item.Metadata))
+	for key := range item.Metadata {
+		metaKeys = append(metaKeys, key)
+	}
+	sort.Strings(metaKeys)
+	for _, key := range metaKeys {
+		fields = append(fields, field{"Metadata." + key, item.Metadata[key]})
+	}
+	width := 0
+	for _, f := range fields {
+		if len(f.key) > width {
+			width = len(f.key)
+		}
+	}
+	for _, f := range fields {
+		_, err := fmt.Fprintf(out, "%-*s %s\n", width+1, f.key+":", f.value)
+		if err != nil {
+			return errors.Wrap(err, "failed to write output")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package stat
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/pingme998/rclone/fs/operations"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWrite(t *testing.T) {
+	item := &operations.ListJSONItem{
+		Path:     "dir/file.txt",
+		Name:     "file.txt",
+		Size:     6,
+		MimeType: "text/plain",
+		ModTime: operations.Timestamp{
+			When:   time.Date(2017, 5, 31, 16, 15, 57, 0, time.UTC),
+			Format: time.RFC3339,
+		},
+		Hashes: map[string]string{
+			"SHA-1": "f572d396fae9206628714fb2ce00f72e94f2258f",
+			"MD5":   "b1946ac92492d2347c6235b4d2611184",
+		},
+		ID:       "y2djkhiujf83u33",
+		Metadata: map[string]string{"mtime": "1496243757", "colour": "blue"},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, Write(&buf, item, false))
+	assert.Equal(t, `Path:            dir/file.txt
+Name:            file.txt
+Size:            6 (6 Byte)
+ModTime:         2017-05-31T16:15:57Z
+IsDir:           false
+MimeType:        text/plain
+ID:              y2djkhiujf83u33
+MD5:             b1946ac92492d2347c6235b4d2611184
+SHA-1:           f572d396fae9206628714fb2ce00f72e94f2258f
+Metadata.colour: blue
+Metadata.mtime:  1496243757
+`, buf.String())
+
+	buf.Reset()
+	require.NoError(t, Write(&buf, item, true))
+	assert.Contains(t, buf.String(), `"Path": "dir/file.txt"`)
+	assert.Contains(t, buf.String(), `"ModTime": "2017-05-31T16:15:57Z"`)
+}
@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"encoding/json"
+	"io/ioutil"
+
+	"github.com/pingme998/rclone/fs"
+	"github.com/pingme998/rclone/fs/accounting"
+	"github.com/pingme998/rclone/fs/rc"
+	"github.com/pingme998/rclone/lib/exitcode"
+)
+
+// summary is written as JSON to the --summary-file on exit so that
+// scripts can find out what happened without parsing the log.
+type summary struct {
+	Command         string           `json:"command"`
+	ExitCode        int              `json:"exitCode"`
+	ExitCodeName    string           `json:"exitCodeName"`
+	ExitCodeMeaning string           `json:"exitCodeMeaning"`
+	Error           string           `json:"error,omitempty"`
+	ErrorsByClass   map[string]int64 `json:"errorsByClass"`
+	Stats           rc.Params        `json:"stats"`
+}
+
+// newSummary makes the summary for a run which exits with code and err
+func newSummary(code int, err error) (*summary, error) {
+	_, command := findCommand()
+	stats, statsErr := accounting.GlobalStats().RemoteStats()
+	if statsErr != nil {
+		return nil, statsErr
+	}
+	s := &summary{
+		Command:         command,
+		ExitCode:        code,
+		ExitCodeName:    exitcode.Name(code),
+		ExitCodeMeaning: exitcode.Meaning(code),
+		ErrorsByClass:   accounting.GlobalStats().ErrorsByClass(),
+		Stats:           stats,
+	}
+	if err != nil {
+		s.Error = err.Error()
+	}
+	return s, nil
+}
+
+// writeSummary writes the summary to --summary-file if set
+func writeSummary(code int, err error) {
+	if *summaryFile == "" {
+		return
+	}
+	s, err := newSummary(code, err)
+	if err != nil {
+		fs.Errorf(nil, "Failed to make summary: %v", err)
+		return
+	}
+	data, err := json.MarshalIndent(s, "", "\t")
+	if err != nil {
+		fs.Errorf(nil, "Failed to encode summary: %v", err)
+		return
+	}
+	data = append(data, '\n')
+	err = ioutil.WriteFile(*summaryFile, data, 0666)
+	if err != nil {
+		fs.Errorf(nil, "Failed to write summary to %q: %v", *summaryFile, err)
+	}
+}
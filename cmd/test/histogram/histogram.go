@@ -4,18 +4,32 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"math/bits"
 	"os"
 	"path"
+	"strings"
+	"sync"
 
 	"github.com/pingme998/rclone/cmd"
 	"github.com/pingme998/rclone/cmd/test"
 	"github.com/pingme998/rclone/fs"
+	"github.com/pingme998/rclone/fs/config/flags"
 	"github.com/pingme998/rclone/fs/walk"
 	"github.com/spf13/cobra"
 )
 
+var (
+	showSizes  bool
+	showFanOut bool
+	showDepth  bool
+)
+
 func init() {
 	test.Command.AddCommand(commandDefinition)
+	cmdFlags := commandDefinition.Flags()
+	flags.BoolVarP(cmdFlags, &showSizes, "sizes", "", false, "Add a histogram of file sizes in log2 buckets")
+	flags.BoolVarP(cmdFlags, &showFanOut, "fanout", "", false, "Add a histogram of the number of entries in each directory in log2 buckets")
+	flags.BoolVarP(cmdFlags, &showDepth, "depth", "", false, "Add a histogram of the depth of the files")
 }
 
 var commandDefinition = &cobra.Command{
@@ -26,29 +40,37 @@ in filenames in the remote:path specified.
 
 The data doesn't contain any identifying information but is useful for
 the rclone developers when developing filename compression.
+
+Use --sizes, --fanout and --depth to add more histograms which are
+useful when designing chunking and compression. If any of these are
+used then the output is a JSON object with these keys
+
+- chars - the histogram of file name characters as above
+- sizes - the number of files with sizes in log2 buckets
+- unknownSizes - the number of files with an unknown size
+- fanout - the number of directories with a number of entries in log2 buckets
+- depth - the number of files at each depth - 0 is remote:path
+
+The log2 buckets are arrays where index 0 counts 0, index 1 counts 1,
+index 2 counts 2-3, index 3 counts 4-7 and so on with index n
+counting values from 2^(n-1) to 2^n-1.
 `,
 	Run: func(command *cobra.Command, args []string) {
 		cmd.CheckArgs(1, 1, command, args)
 		f := cmd.NewFsDir(args)
 		ctx := context.Background()
-		ci := fs.GetConfig(ctx)
 		cmd.Run(false, false, command, func() error {
-			var hist [256]int64
-			err := walk.ListR(ctx, f, "", false, ci.MaxDepth, walk.ListObjects, func(entries fs.DirEntries) error {
-				for _, entry := range entries {
-					base := path.Base(entry.Remote())
-					for i := range base {
-						hist[base[i]]++
-					}
-				}
-				return nil
-			})
+			h, err := Histogram(ctx, f)
 			if err != nil {
 				return err
 			}
 			enc := json.NewEncoder(os.Stdout)
 			// enc.SetIndent("", "\t")
-			err = enc.Encode(&hist)
+			if showSizes || showFanOut || showDepth {
+				err = enc.Encode(h)
+			} else {
+				err = enc.Encode(&h.Chars)
+			}
 			if err != nil {
 				return err
 			}
@@ -57,3 +79,85 @@ the rclone developers when developing filename compression.
 		})
 	},
 }
+
+// Histograms are the histograms made by Histogram
+//
+// The histograms not asked for with the flags are left as nil.
+type Histograms struct {
+	Chars        [256]int64 `json:"chars"`
+	Sizes        []int64    `json:"sizes,omitempty"`
+	UnknownSizes int64      `json:"unknownSizes,omitempty"`
+	FanOut       []int64    `json:"fanout,omitempty"`
+	Depth        []int64    `json:"depth,omitempty"`
+}
+
+// log2Bucket returns the index of the log2 bucket for n
+func log2Bucket(n int64) int {
+	return bits.Len64(uint64(n))
+}
+
+// inc increments bucket i of h growing it if necessary
+func inc(h []int64, i int) []int64 {
+	for len(h) <= i {
+		h = append(h, 0)
+	}
+	h[i]++
+	return h
+}
+
+// Histogram reads f making the histograms selected by the flags
+func Histogram(ctx context.Context, f fs.Fs) (*Histograms, error) {
+	ci := fs.GetConfig(ctx)
+	h := new(Histograms)
+	listType := walk.ListObjects
+	if showFanOut {
+		listType = walk.ListAll
+	}
+	var mu sync.Mutex
+	entriesInDir := map[string]int64{}
+	if showFanOut {
+		entriesInDir[""] = 0
+	}
+	err := walk.ListR(ctx, f, "", false, ci.MaxDepth, listType, func(entries fs.DirEntries) error {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, entry := range entries {
+			remote := entry.Remote()
+			if showFanOut {
+				parent := path.Dir(remote)
+				if parent == "." {
+					parent = ""
+				}
+				entriesInDir[parent]++
+				if _, isDir := entry.(fs.Directory); isDir {
+					if _, found := entriesInDir[remote]; !found {
+						entriesInDir[remote] = 0
+					}
+					continue
+				}
+			}
+			base := path.Base(remote)
+			for i := range base {
+				h.Chars[base[i]]++
+			}
+			if showSizes {
+				if size := entry.Size(); size < 0 {
+					h.UnknownSizes++
+				} else {
+					h.Sizes = inc(h.Sizes, log2Bucket(size))
+				}
+			}
+			if showDepth {
+				h.Depth = inc(h.Depth, strings.Count(remote, "/"))
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, n := range entriesInDir {
+		h.FanOut = inc(h.FanOut, log2Bucket(n))
+	}
+	return h, nil
+}
@@ -0,0 +1,80 @@
+package histogram
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pingme998/rclone/backend/local"
+	"github.com/pingme998/rclone/fs/config/configmap"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLog2Bucket(t *testing.T) {
+	for _, test := range []struct {
+		n    int64
+		want int
+	}{
+		{0, 0},
+		{1, 1},
+		{2, 2},
+		{3, 2},
+		{4, 3},
+		{7, 3},
+		{8, 4},
+		{1 << 40, 41},
+	} {
+		assert.Equal(t, test.want, log2Bucket(test.n), test.n)
+	}
+}
+
+func TestHistogram(t *testing.T) {
+	ctx := context.Background()
+	dir, err := ioutil.TempDir("", "rclone-histogram")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(dir))
+	}()
+	writeFile := func(name string, size int) {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(p), 0777))
+		require.NoError(t, ioutil.WriteFile(p, make([]byte, size), 0666))
+	}
+	writeFile("a", 0)
+	writeFile("bb", 1)
+	writeFile("sub/ab", 5)
+	writeFile("sub/deep/b", 5)
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "empty"), 0777))
+	f, err := local.NewFs(ctx, "local", dir, configmap.Simple{})
+	require.NoError(t, err)
+
+	defer func() {
+		showSizes, showFanOut, showDepth = false, false, false
+	}()
+
+	// Only the characters by default
+	h, err := Histogram(ctx, f)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), h.Chars['a'])
+	assert.Equal(t, int64(4), h.Chars['b'])
+	assert.Equal(t, int64(0), h.Chars['s'], "directory names aren't counted")
+	assert.Nil(t, h.Sizes)
+	assert.Nil(t, h.FanOut)
+	assert.Nil(t, h.Depth)
+
+	showSizes, showFanOut, showDepth = true, true, true
+	h, err = Histogram(ctx, f)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), h.Chars['a'])
+	assert.Equal(t, int64(4), h.Chars['b'])
+	// sizes 0, 1, 5, 5
+	assert.Equal(t, []int64{1, 1, 0, 2}, h.Sizes)
+	assert.Equal(t, int64(0), h.UnknownSizes)
+	// root has 4 entries, sub 2, sub/deep 1 and empty 0
+	assert.Equal(t, []int64{1, 1, 1, 1}, h.FanOut)
+	// depth of the files
+	assert.Equal(t, []int64{2, 1, 1}, h.Depth)
+}
@@ -37,8 +37,12 @@ var (
 	checkControl       bool
 	checkLength        bool
 	checkStreaming     bool
+	checkLimits        bool
 	all                bool
 	uploadWait         time.Duration
+	stateFilePath      string
+	shard              string
+	state              *stateFile
 	positionLeftRe     = regexp.MustCompile(`(?s)^(.*)-position-left-([[:xdigit:]]+)$`)
 	positionMiddleRe   = regexp.MustCompile(`(?s)^position-middle-([[:xdigit:]]+)-(.*)-$`)
 	positionRightRe    = regexp.MustCompile(`(?s)^position-right-([[:xdigit:]]+)-(.*)$`)
@@ -53,7 +57,12 @@ func init() {
 	flags.DurationVarP(cmdFlags, &uploadWait, "upload-wait", "", 0, "Wait after writing a file.")
 	flags.BoolVarP(cmdFlags, &checkLength, "check-length", "", false, "Check max filename length.")
 	flags.BoolVarP(cmdFlags, &checkStreaming, "check-streaming", "", false, "Check uploads with indeterminate file size.")
+	flags.BoolVarP(cmdFlags, &checkLimits, "check-limits", "", false, "Check parallel upload, listing and small file upload limits.")
+	flags.IntVarP(cmdFlags, &limitsMaxConcurrency, "limits-max-concurrency", "", limitsMaxConcurrency, "Maximum number of parallel uploads to try with --check-limits.")
+	flags.IntVarP(cmdFlags, &limitsFiles, "limits-files", "", limitsFiles, "Number of small files to upload and list with --check-limits.")
 	flags.BoolVarP(cmdFlags, &all, "all", "", false, "Run all tests.")
+	flags.StringVarP(cmdFlags, &stateFilePath, "state-file", "", "", "Save the progress of the control character checks to this file and resume from it.")
+	flags.StringVarP(cmdFlags, &shard, "shard", "", "", "Only check shard N/M of the control characters, e.g. 2/4.")
 }
 
 var commandDefinition = &cobra.Command{
@@ -64,18 +73,68 @@ to write to the paths passed in and how long they can be.  It can take some
 time.  It will write test files into the remote:path passed in.  It outputs
 a bit of go code for each one.
 
+Use --check-limits to measure the practical limits of the remote
+
+- the number of parallel uploads it takes before the remote rate
+  limits (e.g. returns 429 errors) - doubling from 1 up to
+  --limits-max-concurrency
+- the number of small files per second which can be uploaded with
+  --transfers uploads at once
+- the time taken to list the --limits-files small files uploaded and
+  the average time for each page of the listing if the remote
+  supports ListR
+
+These are written to the "limits" directory of remote:path. Low level
+retries are disabled while probing so rate limiting isn't hidden.
+
+The control character checks can take hours on slow remotes. Use
+--state-file to save the characters checked so far to a file - if the
+run is interrupted then running it again with the same --state-file
+will carry on where it left off.
+
+Use --shard N/M to check only the Nth of M shards of the control
+characters, so the checks can be split across M parallel runs, eg
+
+    rclone test info --check-control --shard 1/4 --write-json info-1.json remote:path
+    ...
+    rclone test info --check-control --shard 4/4 --write-json info-4.json remote:path
+
+The other checks aren't sharded so only select them for one of the
+shards. build_csv merges the reports for the same remote so pass it
+all the shards. It takes the worst case result where reports for the
+same remote disagree so it can also be used to combine repeated runs.
+
+Use -format html with build_csv to write the control character matrix
+as a colour coded HTML table rather than CSV.
+
+Use --write-json to save the results. The JSON files from several
+remotes can be combined into a capability matrix with
+
+    go run ./cmd/test/info/internal/build_csv -o out.csv -json matrix.json -md matrix.md -limits limits.csv info-*.json
+
 **NB** this can create undeletable files and other hazards - use with care
 `,
 	Run: func(command *cobra.Command, args []string) {
 		cmd.CheckArgs(1, 1e6, command, args)
-		if !checkNormalization && !checkControl && !checkLength && !checkStreaming && !all {
+		if !checkNormalization && !checkControl && !checkLength && !checkStreaming && !checkLimits && !all {
 			log.Fatalf("no tests selected - select a test or use -all")
 		}
+		if _, _, err := internal.ParseShard(shard); err != nil {
+			log.Fatal(err)
+		}
+		if stateFilePath != "" {
+			var err error
+			state, err = loadState(stateFilePath)
+			if err != nil {
+				log.Fatal(err)
+			}
+		}
 		if all {
 			checkNormalization = true
 			checkControl = true
 			checkLength = true
 			checkStreaming = true
+			checkLimits = true
 		}
 		for i := range args {
 			f := cmd.NewFsDir(args[i : i+1])
@@ -97,6 +156,7 @@ type results struct {
 	canReadUnnormalized  bool
 	canReadRenormalized  bool
 	canStream            bool
+	limits               internal.Limits
 }
 
 func newResults(ctx context.Context, f fs.Fs) *results {
@@ -136,6 +196,12 @@ func (r *results) Print() {
 	if checkStreaming {
 		fmt.Printf("canStream = %v\n", r.canStream)
 	}
+	if checkLimits {
+		fmt.Printf("maxParallelUploads     = %d\n", r.limits.MaxParallelUploads)
+		fmt.Printf("rateLimited            = %v\n", r.limits.RateLimited)
+		fmt.Printf("smallFilePutsPerSecond = %.1f\n", r.limits.SmallFilePutsPerSecond)
+		fmt.Printf("listPageLatency        = %s\n", r.limits.ListPageLatency)
+	}
 }
 
 // WriteJSON writes the results to a JSON file when requested
@@ -145,7 +211,18 @@ func (r *results) WriteJSON() {
 	}
 
 	report := internal.InfoReport{
-		Remote: r.f.Name(),
+		Remote:    r.f.Name(),
+		Shard:     shard,
+		Version:   fs.Version,
+		Features:  r.f.Features().Enabled(),
+		Precision: r.f.Precision().String(),
+	}
+	for _, ht := range r.f.Hashes().Array() {
+		report.Hashes = append(report.Hashes, ht.String())
+	}
+	if fsInfo, _, _, config, err := fs.ConfigFs(fs.ConfigString(r.f)); err == nil {
+		report.Backend = fsInfo.Name
+		report.Encoding, _ = config.Get("encoding")
 	}
 	if checkControl {
 		report.ControlCharacters = &r.controlResults
@@ -161,6 +238,9 @@ func (r *results) WriteJSON() {
 	if checkStreaming {
 		report.CanStream = &r.canStream
 	}
+	if checkLimits {
+		report.Limits = &r.limits
+	}
 
 	if f, err := os.Create(writeJSON); err != nil {
 		fs.Errorf(r.f, "Creating JSON file failed: %s", err)
@@ -258,12 +338,43 @@ func (r *results) checkStringPositions(k, s string) {
 	r.stringNeedsEscaping[k] = positionError
 	r.controlResults[k] = res
 	r.mu.Unlock()
+	if state != nil {
+		state.save(r, k, positionError, res)
+	}
+}
+
+// controlStrings returns the strings to check in the control
+// character tests
+func controlStrings() (strs []string) {
+	for i := rune(1); i < 128; i++ {
+		if i != '/' {
+			strs = append(strs, string(i))
+		}
+	}
+	return append(strs, "＼", "\u00A0", "\xBF", "\xFE")
 }
 
 // check we can write a file with the control chars
-func (r *results) checkControls() {
+func (r *results) checkControls() error {
 	fs.Infof(r.f, "Trying to create control character file names")
 	ci := fs.GetConfig(context.Background())
+	n, m, err := internal.ParseShard(shard)
+	if err != nil {
+		return err
+	}
+	if state != nil {
+		done, err := state.resume(r)
+		if err != nil {
+			return err
+		}
+		if done > 0 {
+			fs.Infof(r.f, "Resuming with %d characters already checked", done)
+		}
+	}
+
+	// We're not even going to check NULL or /
+	r.stringNeedsEscaping["\x00"] = internal.PositionAll
+	r.stringNeedsEscaping["/"] = internal.PositionAll
 
 	// Concurrency control
 	tokens := make(chan struct{}, ci.Checkers)
@@ -271,23 +382,13 @@ func (r *results) checkControls() {
 		tokens <- struct{}{}
 	}
 	var wg sync.WaitGroup
-	for i := rune(0); i < 128; i++ {
-		s := string(i)
-		if i == 0 || i == '/' {
-			// We're not even going to check NULL or /
-			r.stringNeedsEscaping[s] = internal.PositionAll
+	for i, s := range controlStrings() {
+		if !internal.InShard(i, n, m) {
+			continue
+		}
+		if _, done := r.controlResults[s]; done {
 			continue
 		}
-		wg.Add(1)
-		go func(s string) {
-			defer wg.Done()
-			token := <-tokens
-			k := s
-			r.checkStringPositions(k, s)
-			tokens <- token
-		}(s)
-	}
-	for _, s := range []string{"＼", "\u00A0", "\xBF", "\xFE"} {
 		wg.Add(1)
 		go func(s string) {
 			defer wg.Done()
@@ -300,6 +401,7 @@ func (r *results) checkControls() {
 	wg.Wait()
 	r.checkControlsList()
 	fs.Infof(r.f, "Done trying to create control character file names")
+	return nil
 }
 
 func (r *results) checkControlsList() {
@@ -314,6 +416,13 @@ func (r *results) checkControlsList() {
 		namesMap[path.Base(s.Remote())] = struct{}{}
 	}
 
+	// Start afresh in case the results were resumed
+	for _, res := range r.controlResults {
+		for pos := range res.InList {
+			delete(res.InList, pos)
+		}
+	}
+
 	for path := range namesMap {
 		var pos internal.Position
 		var hex, value string
@@ -343,6 +452,11 @@ func (r *results) checkControlsList() {
 
 		hexStr := string(hexValue)
 		k := hexStr
+		if _, ok := r.controlResults[k]; !ok {
+			// checked by another shard
+			delete(namesMap, path)
+			continue
+		}
 		switch r.controlResults[k].InList[pos] {
 		case internal.Absent:
 			if hexStr == value {
@@ -445,7 +559,10 @@ func readInfo(ctx context.Context, f fs.Fs) error {
 	}
 	r := newResults(ctx, f)
 	if checkControl {
-		r.checkControls()
+		err = r.checkControls()
+		if err != nil {
+			return err
+		}
 	}
 	if checkLength {
 		r.findMaxLength()
@@ -456,6 +573,9 @@ func readInfo(ctx context.Context, f fs.Fs) error {
 	if checkStreaming {
 		r.checkStreaming()
 	}
+	if checkLimits {
+		r.checkLimits()
+	}
 	r.Print()
 	r.WriteJSON()
 	return nil
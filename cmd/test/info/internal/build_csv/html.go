@@ -0,0 +1,55 @@
+package main
+
+import (
+	"html/template"
+	"io"
+)
+
+// htmlTemplate renders the control character matrix with the results
+// colour coded
+var htmlTemplate = template.Must(template.New("matrix").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>rclone control character matrix</title>
+<style>
+table { border-collapse: collapse; font-family: monospace; }
+th, td { border: 1px solid #ccc; padding: 2px 4px; text-align: center; }
+th.remote { border-left: 2px solid #333; }
+.OK { background: #c8e6c9; }
+.ERR, .MIS { background: #ffcdd2; }
+.REN, .MUL { background: #ffe0b2; }
+</style>
+</head>
+<body>
+<table>
+<thead>
+<tr><th rowspan="3">Bytes</th><th rowspan="3">Char</th>{{range .Remotes}}<th class="remote" colspan="9">{{.}}</th>{{end}}</tr>
+<tr>{{range .Remotes}}<th class="remote" colspan="3">Write</th><th colspan="3">Get</th><th colspan="3">List</th>{{end}}</tr>
+<tr>{{range .Remotes}}{{range $.Positions}}<th>{{.}}</th>{{end}}{{end}}</tr>
+</thead>
+<tbody>
+{{range .Rows}}<tr><td>{{index . 0}}</td><td>{{index . 1}}</td>{{range slice . 2}}<td class="{{.}}">{{.}}</td>{{end}}</tr>
+{{end}}</tbody>
+</table>
+<p>OK - worked, ERR - error, MIS - missing from listing, REN - renamed in listing, MUL - multiple in listing</p>
+</body>
+</html>
+`))
+
+// writeHTML writes the rows of the control character matrix for
+// remoteNames as a colour coded HTML table to w
+//
+// Each row is the bytes and the quoted character followed by 9 results
+// for each remote as written to the CSV.
+func writeHTML(w io.Writer, remoteNames []string, rows [][]string) error {
+	return htmlTemplate.Execute(w, struct {
+		Remotes   []string
+		Positions []string
+		Rows      [][]string
+	}{
+		Remotes:   remoteNames,
+		Positions: []string{"L", "M", "R", "L", "M", "R", "L", "M", "R"},
+		Rows:      rows,
+	})
+}
@@ -0,0 +1,21 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteHTML(t *testing.T) {
+	rows := [][]string{
+		{"3C", "<", "OK", "OK", "ERR", "OK", "OK", "OK", "OK", "REN", "MIS"},
+	}
+	var buf bytes.Buffer
+	require.NoError(t, writeHTML(&buf, []string{"TestA"}, rows))
+	out := buf.String()
+	assert.Contains(t, out, `<th class="remote" colspan="9">TestA</th>`)
+	assert.Contains(t, out, `<td>3C</td><td>&lt;</td><td class="OK">OK</td><td class="OK">OK</td><td class="ERR">ERR</td>`)
+	assert.Contains(t, out, `<td class="REN">REN</td><td class="MIS">MIS</td></tr>`)
+}
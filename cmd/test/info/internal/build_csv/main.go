@@ -2,7 +2,6 @@ package main
 
 import (
 	"encoding/csv"
-	"encoding/json"
 	"flag"
 	"fmt"
 	"io"
@@ -12,33 +11,49 @@ import (
 	"strconv"
 
 	"github.com/pingme998/rclone/cmd/test/info/internal"
+	"github.com/pingme998/rclone/fs"
 )
 
 func main() {
 	fOut := flag.String("o", "out.csv", "Output file")
+	fFormat := flag.String("format", "csv", "Format of the output file - csv or html")
+	fJSON := flag.String("json", "", "Output file for the capability matrix as JSON")
+	fMarkdown := flag.String("md", "", "Output file for the capability matrix as markdown")
+	fLimits := flag.String("limits", "", "Output file for the practical limits from --check-limits as CSV")
 	flag.Parse()
+	if *fFormat != "csv" && *fFormat != "html" {
+		log.Fatalf("Unknown -format %q - must be csv or html", *fFormat)
+	}
 
 	args := flag.Args()
-	remotes := make([]internal.InfoReport, 0, len(args))
+	reports := make([]internal.InfoReport, 0, len(args))
 	for _, fn := range args {
-		f, err := os.Open(fn)
-		if err != nil {
-			log.Fatalf("Unable to open %q: %s", fn, err)
-		}
-		var remote internal.InfoReport
-		dec := json.NewDecoder(f)
-		err = dec.Decode(&remote)
+		report, err := internal.ReadReport(fn)
 		if err != nil {
-			log.Fatalf("Unable to decode %q: %s", fn, err)
+			log.Fatalf("Unable to read %q: %s", fn, err)
 		}
+		reports = append(reports, report)
+	}
+	// Combine the shards of the same remote
+	reports = internal.MergeReports(reports)
+	remotes := make([]internal.InfoReport, 0, len(reports))
+	for _, remote := range reports {
 		if remote.ControlCharacters == nil {
 			log.Printf("Skipping remote %s: no ControlCharacters", remote.Remote)
 		} else {
 			remotes = append(remotes, remote)
 		}
-		if err := f.Close(); err != nil {
-			log.Fatalf("Closing %q failed: %s", fn, err)
-		}
+	}
+
+	matrix := internal.NewMatrix(fs.Version, reports)
+	if *fJSON != "" {
+		writeOutput(*fJSON, matrix.WriteJSON)
+	}
+	if *fMarkdown != "" {
+		writeOutput(*fMarkdown, matrix.WriteMarkdown)
+	}
+	if *fLimits != "" {
+		writeOutput(*fLimits, matrix.WriteLimitsCSV)
 	}
 
 	charsMap := make(map[string]string)
@@ -110,28 +125,40 @@ func main() {
 		records = append(records, row)
 	}
 
-	var writer io.Writer
-	if *fOut == "-" {
-		writer = os.Stdout
-	} else {
-		f, err := os.Create(*fOut)
-		if err != nil {
-			log.Fatalf("Unable to create %q: %s", *fOut, err)
-		}
-		defer func() {
-			if err := f.Close(); err != nil {
-				log.Fatalln("Error writing csv:", err)
+	switch *fFormat {
+	case "csv":
+		writeOutput(*fOut, func(w io.Writer) error {
+			cw := csv.NewWriter(w)
+			err := cw.WriteAll(records)
+			if err == nil {
+				err = cw.Error()
 			}
-		}()
-		writer = f
+			return err
+		})
+	case "html":
+		writeOutput(*fOut, func(w io.Writer) error {
+			return writeHTML(w, remoteNames, records[3:])
+		})
 	}
+}
 
-	w := csv.NewWriter(writer)
-	err := w.WriteAll(records)
+// writeOutput calls write with the file fn or stdout if fn is "-"
+func writeOutput(fn string, write func(w io.Writer) error) {
+	if fn == "-" {
+		if err := write(os.Stdout); err != nil {
+			log.Fatalf("Error writing %q: %s", fn, err)
+		}
+		return
+	}
+	f, err := os.Create(fn)
 	if err != nil {
-		log.Fatalln("Error writing csv:", err)
-	} else if err := w.Error(); err != nil {
-		log.Fatalln("Error writing csv:", err)
+		log.Fatalf("Unable to create %q: %s", fn, err)
+	}
+	if err := write(f); err != nil {
+		log.Fatalf("Error writing %q: %s", fn, err)
+	}
+	if err := f.Close(); err != nil {
+		log.Fatalf("Error writing %q: %s", fn, err)
 	}
 }
 
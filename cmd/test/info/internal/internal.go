@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"os"
+	"strconv"
 	"strings"
 )
 
@@ -44,12 +46,91 @@ type ControlResult struct {
 // InfoReport is the structure of the JSON output
 type InfoReport struct {
 	Remote               string
+	Shard                string          `json:",omitempty"` // shard of the control characters checked, eg "2/4"
+	Backend              string          `json:",omitempty"` // name of the backend type, eg "s3"
+	Version              string          `json:",omitempty"` // rclone version which made the report
+	Encoding             string          `json:",omitempty"` // encoding in use for the remote
+	Features             map[string]bool `json:",omitempty"` // optional features of the backend
+	Hashes               []string        `json:",omitempty"` // hash types supported
+	Precision            string          `json:",omitempty"` // modification time precision
 	ControlCharacters    *map[string]ControlResult
 	MaxFileLength        *int
 	CanStream            *bool
 	CanWriteUnnormalized *bool
 	CanReadUnnormalized  *bool
 	CanReadRenormalized  *bool
+	Limits               *Limits `json:",omitempty"`
+}
+
+// Limits are the practical limits of a remote found by probing it
+type Limits struct {
+	MaxParallelUploads     int           // most parallel uploads which succeeded without errors
+	RateLimited            bool          // whether the remote rate limited any of the probes
+	SmallFilePutsPerSecond float64       // small files uploaded per second with --transfers uploads
+	ListEntries            int           // number of entries listed
+	ListPages              int           // number of pages the listing was returned in
+	ListDuration           string        // time taken for the whole listing
+	ListPageLatency        string        // average time taken for each page of the listing
+	Rounds                 []LimitsRound // the parallel upload rounds
+}
+
+// LimitsRound is the result of uploading Concurrency files at once
+type LimitsRound struct {
+	Concurrency int
+	Duration    string
+	Errors      int
+	RateLimited bool
+}
+
+// OK returns true if the character could be written, read back and
+// listed without change in all positions
+func (r ControlResult) OK() bool {
+	for _, pos := range PositionList {
+		if r.WriteError[pos] != "" || r.GetError[pos] != "" || r.InList[pos] != Present {
+			return false
+		}
+	}
+	return true
+}
+
+// ReadReport reads an InfoReport from the JSON file fn
+func ReadReport(fn string) (report InfoReport, err error) {
+	f, err := os.Open(fn)
+	if err != nil {
+		return report, err
+	}
+	defer func() {
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+	}()
+	err = json.NewDecoder(f).Decode(&report)
+	return report, err
+}
+
+// ParseShard parses a shard "N/M" returning N and M
+//
+// N counts from 1. An empty string returns 1/1 which is all of them.
+func ParseShard(s string) (n, m int, err error) {
+	if s == "" {
+		return 1, 1, nil
+	}
+	parts := strings.Split(s, "/")
+	if len(parts) == 2 {
+		n, err = strconv.Atoi(parts[0])
+		if err == nil {
+			m, err = strconv.Atoi(parts[1])
+		}
+		if err == nil && n >= 1 && n <= m {
+			return n, m, nil
+		}
+	}
+	return 0, 0, fmt.Errorf("invalid shard %q - must be N/M with 1 <= N <= M", s)
+}
+
+// InShard returns true if the i-th item is in shard n of m
+func InShard(i, n, m int) bool {
+	return i%m == n-1
 }
 
 func (e Position) String() string {
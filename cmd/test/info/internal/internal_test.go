@@ -0,0 +1,45 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseShard(t *testing.T) {
+	for _, test := range []struct {
+		in      string
+		n, m    int
+		wantErr bool
+	}{
+		{"", 1, 1, false},
+		{"1/1", 1, 1, false},
+		{"2/4", 2, 4, false},
+		{"4/4", 4, 4, false},
+		{"0/4", 0, 0, true},
+		{"5/4", 0, 0, true},
+		{"2", 0, 0, true},
+		{"a/4", 0, 0, true},
+		{"1/2/3", 0, 0, true},
+	} {
+		n, m, err := ParseShard(test.in)
+		assert.Equal(t, test.wantErr, err != nil, test.in)
+		assert.Equal(t, test.n, n, test.in)
+		assert.Equal(t, test.m, m, test.in)
+	}
+}
+
+func TestInShard(t *testing.T) {
+	count := map[int]int{}
+	for i := 0; i < 10; i++ {
+		for n := 1; n <= 3; n++ {
+			if InShard(i, n, 3) {
+				count[i]++
+			}
+		}
+		assert.True(t, InShard(i, 1, 1))
+	}
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, 1, count[i], "item %d should be in exactly one shard", i)
+	}
+}
@@ -0,0 +1,239 @@
+package internal
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// MatrixFormat is the version of the JSON format written by
+// Matrix.WriteJSON. It should be increased on incompatible changes.
+const MatrixFormat = 1
+
+// Matrix is a capability matrix of backends built from InfoReports
+type Matrix struct {
+	Format   int            // version of the format - see MatrixFormat
+	Version  string         // rclone version which built the matrix
+	Remotes  []Capabilities // one entry per remote sorted by remote name
+	Features []string       // all the features found in the Remotes sorted
+}
+
+// Capabilities summarises the InfoReport of a single remote
+type Capabilities struct {
+	Remote               string
+	Backend              string
+	Version              string
+	Encoding             string
+	Features             []string // optional features which are enabled, sorted
+	Hashes               []string
+	Precision            string
+	MaxFileLength        *int
+	CanStream            *bool
+	CanWriteUnnormalized *bool
+	CanReadUnnormalized  *bool
+	CanReadRenormalized  *bool
+	NeedsEscaping        *[]string // characters which can't be used unchanged in file names, sorted
+	Limits               *Limits   `json:",omitempty"`
+}
+
+// NewMatrix builds a capability matrix from the reports passed in
+//
+// version should be set to the rclone version building the matrix.
+func NewMatrix(version string, reports []InfoReport) *Matrix {
+	m := &Matrix{
+		Format:  MatrixFormat,
+		Version: version,
+		Remotes: make([]Capabilities, 0, len(reports)),
+	}
+	features := map[string]struct{}{}
+	for _, report := range reports {
+		c := Capabilities{
+			Remote:               report.Remote,
+			Backend:              report.Backend,
+			Version:              report.Version,
+			Encoding:             report.Encoding,
+			Hashes:               report.Hashes,
+			Precision:            report.Precision,
+			MaxFileLength:        report.MaxFileLength,
+			CanStream:            report.CanStream,
+			CanWriteUnnormalized: report.CanWriteUnnormalized,
+			CanReadUnnormalized:  report.CanReadUnnormalized,
+			CanReadRenormalized:  report.CanReadRenormalized,
+			Limits:               report.Limits,
+		}
+		for name, enabled := range report.Features {
+			if enabled {
+				c.Features = append(c.Features, name)
+				features[name] = struct{}{}
+			}
+		}
+		sort.Strings(c.Features)
+		if report.ControlCharacters != nil {
+			escape := []string{}
+			for char, result := range *report.ControlCharacters {
+				if !result.OK() {
+					escape = append(escape, char)
+				}
+			}
+			sort.Strings(escape)
+			c.NeedsEscaping = &escape
+		}
+		m.Remotes = append(m.Remotes, c)
+	}
+	sort.SliceStable(m.Remotes, func(i, j int) bool {
+		return m.Remotes[i].Remote < m.Remotes[j].Remote
+	})
+	for name := range features {
+		m.Features = append(m.Features, name)
+	}
+	sort.Strings(m.Features)
+	return m
+}
+
+// WriteJSON writes the matrix as indented JSON to w
+func (m *Matrix) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(m)
+}
+
+// WriteMarkdown writes the matrix as markdown tables to w
+func (m *Matrix) WriteMarkdown(w io.Writer) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "<!-- Generated by rclone %s (format %d) - do not edit -->\n\n", m.Version, m.Format)
+
+	b.WriteString("### Limits\n\n")
+	b.WriteString("| Remote | Backend | Version | Max name length | Streaming | Write unnormalized | Read unnormalized | Read renormalized | Hashes | Precision | Encoding |\n")
+	b.WriteString("|--------|---------|---------|-----------------|-----------|--------------------|-------------------|-------------------|--------|-----------|----------|\n")
+	for _, c := range m.Remotes {
+		maxLength := "-"
+		if c.MaxFileLength != nil {
+			maxLength = strconv.Itoa(*c.MaxFileLength)
+		}
+		fmt.Fprintf(&b, "| %s | %s | %s | %s | %s | %s | %s | %s | %s | %s | %s |\n",
+			mdEscape(c.Remote), mdEscape(c.Backend), mdEscape(c.Version), maxLength,
+			yesNo(c.CanStream), yesNo(c.CanWriteUnnormalized), yesNo(c.CanReadUnnormalized), yesNo(c.CanReadRenormalized),
+			mdEscape(strings.Join(c.Hashes, ", ")), mdEscape(c.Precision), mdEscape(c.Encoding))
+	}
+
+	if len(m.Features) > 0 {
+		b.WriteString("\n### Optional features\n\n| Feature |")
+		sep := "|---------|"
+		for _, c := range m.Remotes {
+			fmt.Fprintf(&b, " %s |", mdEscape(c.Remote))
+			sep += ":---:|"
+		}
+		b.WriteString("\n" + sep + "\n")
+		for _, name := range m.Features {
+			fmt.Fprintf(&b, "| %s |", name)
+			for _, c := range m.Remotes {
+				i := sort.SearchStrings(c.Features, name)
+				if i < len(c.Features) && c.Features[i] == name {
+					b.WriteString(" Yes |")
+				} else {
+					b.WriteString(" No |")
+				}
+			}
+			b.WriteString("\n")
+		}
+	}
+
+	if m.hasLimits() {
+		b.WriteString("\n### Practical limits\n\n")
+		b.WriteString("| Remote | Max parallel uploads | Rate limited | Small file PUTs/s | List entries | List pages | List page latency |\n")
+		b.WriteString("|--------|----------------------|--------------|-------------------|--------------|------------|-------------------|\n")
+		for _, c := range m.Remotes {
+			fmt.Fprintf(&b, "| %s | %s |\n", mdEscape(c.Remote), strings.Join(limitsColumns(c.Limits), " | "))
+		}
+	}
+
+	b.WriteString("\n### Characters needing escaping\n\n")
+	b.WriteString("| Remote | Characters |\n")
+	b.WriteString("|--------|------------|\n")
+	for _, c := range m.Remotes {
+		chars := "-"
+		if c.NeedsEscaping != nil {
+			quoted := make([]string, len(*c.NeedsEscaping))
+			for i, char := range *c.NeedsEscaping {
+				q := strconv.Quote(char)
+				q = q[1 : len(q)-1]
+				if strings.Contains(q, "`") {
+					quoted[i] = "`` " + q + " ``"
+				} else {
+					quoted[i] = "`" + q + "`"
+				}
+			}
+			chars = mdEscape(strings.Join(quoted, " "))
+		}
+		fmt.Fprintf(&b, "| %s | %s |\n", mdEscape(c.Remote), chars)
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// LimitsHeader is the header of the columns from limitsColumns
+var LimitsHeader = []string{"Remote", "MaxParallelUploads", "RateLimited", "SmallFilePutsPerSecond", "ListEntries", "ListPages", "ListPageLatency"}
+
+// WriteLimitsCSV writes the practical limits of each remote as CSV
+// to w with a header row of LimitsHeader
+func (m *Matrix) WriteLimitsCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(LimitsHeader); err != nil {
+		return err
+	}
+	for _, c := range m.Remotes {
+		if err := cw.Write(append([]string{c.Remote}, limitsColumns(c.Limits)...)); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// hasLimits returns true if any of the remotes had their limits probed
+func (m *Matrix) hasLimits() bool {
+	for _, c := range m.Remotes {
+		if c.Limits != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// limitsColumns describes the limits for a row of a table - "-" if
+// the limits weren't probed
+func limitsColumns(l *Limits) []string {
+	if l == nil {
+		return []string{"-", "-", "-", "-", "-", "-"}
+	}
+	rateLimited := l.RateLimited
+	return []string{
+		strconv.Itoa(l.MaxParallelUploads),
+		yesNo(&rateLimited),
+		strconv.FormatFloat(l.SmallFilePutsPerSecond, 'f', 1, 64),
+		strconv.Itoa(l.ListEntries),
+		strconv.Itoa(l.ListPages),
+		l.ListPageLatency,
+	}
+}
+
+// yesNo describes an optional bool
+func yesNo(b *bool) string {
+	switch {
+	case b == nil:
+		return "-"
+	case *b:
+		return "Yes"
+	}
+	return "No"
+}
+
+// mdEscape escapes s for use in a markdown table cell
+func mdEscape(s string) string {
+	return strings.ReplaceAll(s, "|", `\|`)
+}
@@ -0,0 +1,119 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testReports() []InfoReport {
+	maxLength := 255
+	canStream := true
+	good := ControlResult{
+		WriteError: map[Position]string{},
+		GetError:   map[Position]string{},
+		InList:     map[Position]Presence{PositionLeft: Present, PositionMiddle: Present, PositionRight: Present},
+	}
+	bad := ControlResult{
+		WriteError: map[Position]string{PositionRight: "invalid name"},
+		GetError:   map[Position]string{},
+		InList:     map[Position]Presence{PositionLeft: Present, PositionMiddle: Renamed, PositionRight: Absent},
+	}
+	chars := map[string]ControlResult{"a": good, "|": bad, "\x01": bad}
+	return []InfoReport{
+		{
+			Remote:            "TestZ",
+			Backend:           "s3",
+			Version:           "v1.56.0",
+			Features:          map[string]bool{"Copy": true, "Move": false},
+			Hashes:            []string{"MD5"},
+			Precision:         "1ns",
+			ControlCharacters: &chars,
+			MaxFileLength:     &maxLength,
+			Limits: &Limits{
+				MaxParallelUploads:     16,
+				RateLimited:            true,
+				SmallFilePutsPerSecond: 12.345,
+				ListEntries:            100,
+				ListPages:              1,
+				ListDuration:           "150ms",
+				ListPageLatency:        "150ms",
+				Rounds:                 []LimitsRound{{Concurrency: 32, Duration: "2s", Errors: 3, RateLimited: true}},
+			},
+		},
+		{
+			Remote:    "TestA",
+			Backend:   "local",
+			Features:  map[string]bool{"Move": true},
+			CanStream: &canStream,
+		},
+	}
+}
+
+func TestNewMatrix(t *testing.T) {
+	m := NewMatrix("v1.57.0", testReports())
+	assert.Equal(t, MatrixFormat, m.Format)
+	assert.Equal(t, "v1.57.0", m.Version)
+	assert.Equal(t, []string{"Copy", "Move"}, m.Features)
+	require.Len(t, m.Remotes, 2)
+
+	a, z := m.Remotes[0], m.Remotes[1]
+	assert.Equal(t, "TestA", a.Remote)
+	assert.Equal(t, []string{"Move"}, a.Features)
+	assert.Nil(t, a.NeedsEscaping)
+	assert.Nil(t, a.MaxFileLength)
+
+	assert.Equal(t, "TestZ", z.Remote)
+	assert.Equal(t, "s3", z.Backend)
+	assert.Equal(t, []string{"Copy"}, z.Features)
+	require.NotNil(t, z.NeedsEscaping)
+	assert.Equal(t, []string{"\x01", "|"}, *z.NeedsEscaping)
+	assert.Equal(t, 255, *z.MaxFileLength)
+	require.NotNil(t, z.Limits)
+	assert.Equal(t, 16, z.Limits.MaxParallelUploads)
+	assert.Nil(t, a.Limits)
+}
+
+func TestMatrixWriteJSON(t *testing.T) {
+	m := NewMatrix("v1.57.0", testReports())
+	var buf bytes.Buffer
+	require.NoError(t, m.WriteJSON(&buf))
+	var got Matrix
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &got))
+	assert.Equal(t, m, &got)
+}
+
+func TestMatrixWriteMarkdown(t *testing.T) {
+	m := NewMatrix("v1.57.0", testReports())
+	var buf bytes.Buffer
+	require.NoError(t, m.WriteMarkdown(&buf))
+	out := buf.String()
+	assert.Contains(t, out, "rclone v1.57.0 (format 1)")
+	assert.Contains(t, out, "| TestA | local |  | - | Yes | - | - | - |  |  |  |\n")
+	assert.Contains(t, out, "| TestZ | s3 | v1.56.0 | 255 | - | - | - | - | MD5 | 1ns |  |\n")
+	assert.Contains(t, out, "| Copy | No | Yes |\n")
+	assert.Contains(t, out, "| Move | Yes | No |\n")
+	assert.Contains(t, out, "| TestZ | `\\x01` `\\|` |\n")
+	assert.Contains(t, out, "| TestA | - |\n")
+	assert.Contains(t, out, "### Practical limits")
+	assert.Contains(t, out, "| TestA | - | - | - | - | - | - |\n")
+	assert.Contains(t, out, "| TestZ | 16 | Yes | 12.3 | 100 | 1 | 150ms |\n")
+}
+
+func TestMatrixWriteLimitsCSV(t *testing.T) {
+	m := NewMatrix("v1.57.0", testReports())
+	var buf bytes.Buffer
+	require.NoError(t, m.WriteLimitsCSV(&buf))
+	assert.Equal(t, `Remote,MaxParallelUploads,RateLimited,SmallFilePutsPerSecond,ListEntries,ListPages,ListPageLatency
+TestA,-,-,-,-,-,-
+TestZ,16,Yes,12.3,100,1,150ms
+`, buf.String())
+}
+
+func TestReadReport(t *testing.T) {
+	_, err := ReadReport("notfound.json")
+	assert.Error(t, err)
+}
@@ -0,0 +1,238 @@
+package internal
+
+import (
+	"time"
+)
+
+// MergeReports merges the reports for the same remote, eg from
+// several shards of the control character checks or from repeated
+// runs, returning one report per remote in the order first seen.
+//
+// Where the reports disagree the worst case result is taken, so a
+// character only passes if it passed in all the reports which checked
+// it, a capability is only present if all the reports found it and
+// limits are the lowest found.
+func MergeReports(reports []InfoReport) []InfoReport {
+	var merged []InfoReport
+	index := map[string]int{}
+	for _, report := range reports {
+		i, found := index[report.Remote]
+		if !found {
+			index[report.Remote] = len(merged)
+			merged = append(merged, copyReport(report))
+			continue
+		}
+		mergeReport(&merged[i], report)
+	}
+	return merged
+}
+
+// copyReport makes a copy of report which can be merged into without
+// changing report
+func copyReport(report InfoReport) InfoReport {
+	if report.ControlCharacters != nil {
+		chars := make(map[string]ControlResult, len(*report.ControlCharacters))
+		for k, v := range *report.ControlCharacters {
+			chars[k] = v
+		}
+		report.ControlCharacters = &chars
+	}
+	if report.Features != nil {
+		features := make(map[string]bool, len(report.Features))
+		for k, v := range report.Features {
+			features[k] = v
+		}
+		report.Features = features
+	}
+	if report.Limits != nil {
+		limits := *report.Limits
+		report.Limits = &limits
+	}
+	return report
+}
+
+// mergeReport merges report into m taking the worst case results
+func mergeReport(m *InfoReport, report InfoReport) {
+	if m.Shard != report.Shard {
+		m.Shard = ""
+	}
+	if m.Backend == "" {
+		m.Backend = report.Backend
+	}
+	if m.Version == "" {
+		m.Version = report.Version
+	}
+	if m.Encoding == "" {
+		m.Encoding = report.Encoding
+	}
+	if report.Features != nil {
+		if m.Features == nil {
+			m.Features = map[string]bool{}
+			for name, enabled := range report.Features {
+				m.Features[name] = enabled
+			}
+		} else {
+			for name, enabled := range report.Features {
+				m.Features[name] = m.Features[name] && enabled
+			}
+			for name := range m.Features {
+				if _, ok := report.Features[name]; !ok {
+					m.Features[name] = false
+				}
+			}
+		}
+	}
+	if report.Hashes != nil {
+		if m.Hashes == nil {
+			m.Hashes = report.Hashes
+		} else {
+			m.Hashes = intersect(m.Hashes, report.Hashes)
+		}
+	}
+	m.Precision = coarser(m.Precision, report.Precision)
+	if report.ControlCharacters != nil {
+		if m.ControlCharacters == nil {
+			chars := map[string]ControlResult{}
+			m.ControlCharacters = &chars
+		}
+		chars := *m.ControlCharacters
+		for k, v := range *report.ControlCharacters {
+			if old, ok := chars[k]; ok {
+				chars[k] = worseControlResult(old, v)
+			} else {
+				chars[k] = v
+			}
+		}
+	}
+	m.MaxFileLength = minInt(m.MaxFileLength, report.MaxFileLength)
+	m.CanStream = andBool(m.CanStream, report.CanStream)
+	m.CanWriteUnnormalized = andBool(m.CanWriteUnnormalized, report.CanWriteUnnormalized)
+	m.CanReadUnnormalized = andBool(m.CanReadUnnormalized, report.CanReadUnnormalized)
+	m.CanReadRenormalized = andBool(m.CanReadRenormalized, report.CanReadRenormalized)
+	m.Limits = worseLimits(m.Limits, report.Limits)
+}
+
+// presenceRank orders the Presence from best to worst
+var presenceRank = map[Presence]int{
+	Present:  0,
+	Renamed:  1,
+	Multiple: 2,
+	Absent:   3,
+}
+
+// worseControlResult combines a and b keeping the errors from both
+// and the worst presence in the listing
+func worseControlResult(a, b ControlResult) ControlResult {
+	r := ControlResult{
+		Text:       a.Text,
+		WriteError: map[Position]string{},
+		GetError:   map[Position]string{},
+		InList:     map[Position]Presence{},
+	}
+	for _, pos := range PositionList {
+		r.WriteError[pos] = firstNonEmpty(a.WriteError[pos], b.WriteError[pos])
+		if r.WriteError[pos] == "" {
+			delete(r.WriteError, pos)
+		}
+		r.GetError[pos] = firstNonEmpty(a.GetError[pos], b.GetError[pos])
+		if r.GetError[pos] == "" {
+			delete(r.GetError, pos)
+		}
+		r.InList[pos] = a.InList[pos]
+		if presenceRank[b.InList[pos]] > presenceRank[a.InList[pos]] {
+			r.InList[pos] = b.InList[pos]
+		}
+	}
+	return r
+}
+
+// worseLimits combines a and b taking the lowest limits
+func worseLimits(a, b *Limits) *Limits {
+	if a == nil {
+		return b
+	}
+	if b == nil {
+		return a
+	}
+	r := *a
+	if b.MaxParallelUploads < a.MaxParallelUploads {
+		r.MaxParallelUploads = b.MaxParallelUploads
+		r.Rounds = b.Rounds
+	}
+	r.RateLimited = a.RateLimited || b.RateLimited
+	if b.SmallFilePutsPerSecond < a.SmallFilePutsPerSecond {
+		r.SmallFilePutsPerSecond = b.SmallFilePutsPerSecond
+	}
+	if coarser(a.ListPageLatency, b.ListPageLatency) != a.ListPageLatency {
+		r.ListEntries = b.ListEntries
+		r.ListPages = b.ListPages
+		r.ListDuration = b.ListDuration
+		r.ListPageLatency = b.ListPageLatency
+	}
+	return &r
+}
+
+// firstNonEmpty returns a if it isn't empty otherwise b
+func firstNonEmpty(a, b string) string {
+	if a != "" {
+		return a
+	}
+	return b
+}
+
+// andBool returns false if either a or b is false, nil if both are
+// nil and true otherwise
+func andBool(a, b *bool) *bool {
+	if a == nil {
+		return b
+	}
+	if b == nil {
+		return a
+	}
+	r := *a && *b
+	return &r
+}
+
+// minInt returns the smallest of a and b ignoring nils
+func minInt(a, b *int) *int {
+	if a == nil {
+		return b
+	}
+	if b == nil || *a <= *b {
+		return a
+	}
+	return b
+}
+
+// coarser returns the larger of the durations a and b, or the one
+// which parses if only one does
+func coarser(a, b string) string {
+	da, errA := time.ParseDuration(a)
+	db, errB := time.ParseDuration(b)
+	switch {
+	case errA != nil && errB != nil:
+		return firstNonEmpty(a, b)
+	case errA != nil:
+		return b
+	case errB != nil:
+		return a
+	case db > da:
+		return b
+	}
+	return a
+}
+
+// intersect returns the items of a which are also in b
+func intersect(a, b []string) []string {
+	inB := make(map[string]struct{}, len(b))
+	for _, item := range b {
+		inB[item] = struct{}{}
+	}
+	r := []string{}
+	for _, item := range a {
+		if _, ok := inB[item]; ok {
+			r = append(r, item)
+		}
+	}
+	return r
+}
@@ -0,0 +1,89 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeReportsShards(t *testing.T) {
+	maxLength := 255
+	chars1 := map[string]ControlResult{"a": {}}
+	chars2 := map[string]ControlResult{"b": {}}
+	reports := []InfoReport{
+		{Remote: "TestA", Shard: "1/2", ControlCharacters: &chars1},
+		{Remote: "TestB", Backend: "local"},
+		{Remote: "TestA", Shard: "2/2", Backend: "s3", ControlCharacters: &chars2, MaxFileLength: &maxLength},
+	}
+	merged := MergeReports(reports)
+	require.Len(t, merged, 2)
+	a := merged[0]
+	assert.Equal(t, "TestA", a.Remote)
+	assert.Equal(t, "", a.Shard)
+	assert.Equal(t, "s3", a.Backend)
+	require.NotNil(t, a.ControlCharacters)
+	assert.Len(t, *a.ControlCharacters, 2)
+	assert.Equal(t, 255, *a.MaxFileLength)
+	assert.Equal(t, "TestB", merged[1].Remote)
+
+	// the input isn't modified
+	assert.Len(t, chars1, 1)
+}
+
+func TestMergeReportsWorstCase(t *testing.T) {
+	len1, len2 := 255, 143
+	yes, no := true, false
+	good := ControlResult{
+		WriteError: map[Position]string{},
+		GetError:   map[Position]string{},
+		InList:     map[Position]Presence{PositionLeft: Present, PositionMiddle: Present, PositionRight: Present},
+	}
+	bad := ControlResult{
+		WriteError: map[Position]string{PositionRight: "invalid name"},
+		GetError:   map[Position]string{},
+		InList:     map[Position]Presence{PositionLeft: Present, PositionMiddle: Renamed, PositionRight: Absent},
+	}
+	chars1 := map[string]ControlResult{"a": good, "b": bad}
+	chars2 := map[string]ControlResult{"a": bad, "b": good}
+	reports := []InfoReport{
+		{
+			Remote:            "TestA",
+			Features:          map[string]bool{"Copy": true, "Move": true},
+			Hashes:            []string{"MD5", "SHA-1"},
+			Precision:         "1ms",
+			ControlCharacters: &chars1,
+			MaxFileLength:     &len1,
+			CanStream:         &yes,
+			Limits:            &Limits{MaxParallelUploads: 16, SmallFilePutsPerSecond: 10, ListPageLatency: "100ms"},
+		},
+		{
+			Remote:            "TestA",
+			Features:          map[string]bool{"Copy": true, "Move": false, "Purge": true},
+			Hashes:            []string{"SHA-1"},
+			Precision:         "1s",
+			ControlCharacters: &chars2,
+			MaxFileLength:     &len2,
+			CanStream:         &no,
+			Limits:            &Limits{MaxParallelUploads: 32, RateLimited: true, SmallFilePutsPerSecond: 5, ListPageLatency: "50ms"},
+		},
+	}
+	merged := MergeReports(reports)
+	require.Len(t, merged, 1)
+	m := merged[0]
+	assert.Equal(t, map[string]bool{"Copy": true, "Move": false, "Purge": false}, m.Features)
+	assert.Equal(t, []string{"SHA-1"}, m.Hashes)
+	assert.Equal(t, "1s", m.Precision)
+	assert.Equal(t, 143, *m.MaxFileLength)
+	assert.False(t, *m.CanStream)
+	assert.Nil(t, m.CanReadUnnormalized)
+	assert.False(t, (*m.ControlCharacters)["a"].OK())
+	assert.False(t, (*m.ControlCharacters)["b"].OK())
+	assert.Equal(t, bad, (*m.ControlCharacters)["a"])
+	assert.Equal(t, &Limits{MaxParallelUploads: 16, RateLimited: true, SmallFilePutsPerSecond: 5, ListPageLatency: "100ms"}, m.Limits)
+
+	// the inputs aren't modified
+	assert.True(t, reports[0].Features["Move"])
+	assert.True(t, chars1["a"].OK())
+	assert.False(t, reports[0].Limits.RateLimited)
+}
@@ -0,0 +1,178 @@
+package info
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pingme998/rclone/cmd/test/info/internal"
+	"github.com/pingme998/rclone/fs"
+	"github.com/pingme998/rclone/fs/fserrors"
+	"github.com/pingme998/rclone/fs/object"
+	"github.com/pingme998/rclone/lib/random"
+)
+
+// limitsDir is the directory the limits are probed in
+const limitsDir = "limits"
+
+// limitsFileSize is the size of the files uploaded to probe the limits
+const limitsFileSize = 16
+
+var (
+	limitsMaxConcurrency = 64  // max parallel uploads to try
+	limitsFiles          = 100 // number of small files to upload and list
+)
+
+// isRateLimited returns true if err looks like the remote is rate
+// limiting us
+func isRateLimited(err error) bool {
+	if err == nil {
+		return false
+	}
+	if fserrors.IsRetryAfterError(err) {
+		return true
+	}
+	s := strings.ToLower(err.Error())
+	for _, marker := range []string{"429", "too many requests", "rate limit", "ratelimit", "throttl", "slow down", "slowdown"} {
+		if strings.Contains(s, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// putSmallFiles uploads n small files to dir with up to concurrency
+// uploads at once returning the time taken and the errors
+func (r *results) putSmallFiles(ctx context.Context, dir string, n, concurrency int) (elapsed time.Duration, errs []error) {
+	var (
+		mu     sync.Mutex
+		wg     sync.WaitGroup
+		tokens = make(chan struct{}, concurrency)
+	)
+	start := time.Now()
+	for i := 0; i < n; i++ {
+		tokens <- struct{}{}
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-tokens }()
+			contents := random.String(limitsFileSize)
+			remote := path.Join(dir, fmt.Sprintf("file-%04d", i))
+			src := object.NewStaticObjectInfo(remote, time.Now(), int64(len(contents)), true, nil, r.f)
+			_, err := r.f.Put(ctx, bytes.NewBufferString(contents), src)
+			if err != nil {
+				mu.Lock()
+				errs = append(errs, err)
+				mu.Unlock()
+			}
+		}(i)
+	}
+	wg.Wait()
+	return time.Since(start), errs
+}
+
+// probeParallelUploads uploads files with increasing concurrency
+// until the remote rate limits or returns errors or
+// limitsMaxConcurrency is reached.
+func (r *results) probeParallelUploads(ctx context.Context) {
+	for concurrency := 1; concurrency <= limitsMaxConcurrency; concurrency *= 2 {
+		dir := path.Join(limitsDir, fmt.Sprintf("parallel-%d", concurrency))
+		elapsed, errs := r.putSmallFiles(ctx, dir, concurrency, concurrency)
+		round := internal.LimitsRound{
+			Concurrency: concurrency,
+			Duration:    elapsed.String(),
+			Errors:      len(errs),
+		}
+		for _, err := range errs {
+			if isRateLimited(err) {
+				round.RateLimited = true
+			}
+		}
+		r.limits.Rounds = append(r.limits.Rounds, round)
+		fs.Infof(r.f, "Uploaded %d files in parallel in %v with %d errors (rate limited %v)", concurrency, elapsed, len(errs), round.RateLimited)
+		if round.RateLimited {
+			r.limits.RateLimited = true
+		}
+		if len(errs) > 0 {
+			fs.Infof(r.f, "First error with %d parallel uploads: %v", concurrency, errs[0])
+			return
+		}
+		r.limits.MaxParallelUploads = concurrency
+	}
+}
+
+// probeSmallFilePuts measures how many small files can be uploaded
+// per second with --transfers uploads at once
+func (r *results) probeSmallFilePuts(ctx context.Context) {
+	transfers := fs.GetConfig(ctx).Transfers
+	elapsed, errs := r.putSmallFiles(ctx, path.Join(limitsDir, "small"), limitsFiles, transfers)
+	if len(errs) > 0 {
+		fs.Infof(r.f, "Small file uploads had %d errors, first: %v", len(errs), errs[0])
+		if isRateLimited(errs[0]) {
+			r.limits.RateLimited = true
+		}
+	}
+	ok := limitsFiles - len(errs)
+	if elapsed > 0 {
+		r.limits.SmallFilePutsPerSecond = float64(ok) / elapsed.Seconds()
+	}
+	fs.Infof(r.f, "Uploaded %d small files with %d transfers in %v (%.1f files/s)", ok, transfers, elapsed, r.limits.SmallFilePutsPerSecond)
+}
+
+// probeListing measures the time taken to list the small files
+// written by probeSmallFilePuts and, if the remote supports ListR,
+// how long each page of the listing takes.
+func (r *results) probeListing(ctx context.Context) {
+	dir := path.Join(limitsDir, "small")
+	var (
+		pages   int
+		entries int
+		err     error
+	)
+	start := time.Now()
+	if ListR := r.f.Features().ListR; ListR != nil {
+		var mu sync.Mutex
+		err = ListR(ctx, dir, func(page fs.DirEntries) error {
+			mu.Lock()
+			pages++
+			entries += len(page)
+			mu.Unlock()
+			return nil
+		})
+	} else {
+		var list fs.DirEntries
+		list, err = r.f.List(ctx, dir)
+		pages, entries = 1, len(list)
+	}
+	elapsed := time.Since(start)
+	if err != nil {
+		fs.Infof(r.f, "Listing failed: %v", err)
+		if isRateLimited(err) {
+			r.limits.RateLimited = true
+		}
+		return
+	}
+	r.limits.ListEntries = entries
+	r.limits.ListPages = pages
+	r.limits.ListDuration = elapsed.String()
+	if pages > 0 {
+		r.limits.ListPageLatency = (elapsed / time.Duration(pages)).String()
+	}
+	fs.Infof(r.f, "Listed %d entries in %d pages in %v", entries, pages, elapsed)
+}
+
+// checkLimits probes the practical limits of the remote
+func (r *results) checkLimits() {
+	fs.Infof(r.f, "Probing limits")
+	// Don't retry so errors from the remote are seen straight away
+	ctx, ci := fs.AddConfig(r.ctx)
+	ci.LowLevelRetries = 1
+	r.probeParallelUploads(ctx)
+	r.probeSmallFilePuts(ctx)
+	r.probeListing(ctx)
+	fs.Infof(r.f, "Done probing limits")
+}
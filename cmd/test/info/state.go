@@ -0,0 +1,122 @@
+package info
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/pingme998/rclone/cmd/test/info/internal"
+	"github.com/pingme998/rclone/fs"
+)
+
+// controlState is the progress of the control character checks on a
+// single remote
+//
+// The maps are keyed on the hex of the characters as they aren't all
+// valid UTF-8 so would be changed by encoding them as JSON.
+type controlState struct {
+	Shard          string                            // shard being run, eg "2/4" or "" for all
+	NeedsEscaping  map[string]internal.Position      // stringNeedsEscaping for the characters done
+	ControlResults map[string]internal.ControlResult // controlResults for the characters done
+}
+
+// stateFile is the contents of the --state-file
+type stateFile struct {
+	mu      sync.Mutex
+	path    string
+	Remotes map[string]*controlState // keyed on the config string of the remote
+}
+
+// loadState reads the state from path if it exists
+func loadState(path string) (*stateFile, error) {
+	s := &stateFile{
+		path:    path,
+		Remotes: map[string]*controlState{},
+	}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	} else if err != nil {
+		return nil, errors.Wrap(err, "failed to read state file")
+	}
+	err = json.Unmarshal(data, s)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse state file")
+	}
+	if s.Remotes == nil {
+		s.Remotes = map[string]*controlState{}
+	}
+	return s, nil
+}
+
+// resume loads the characters already checked on r into r and
+// returns the number loaded
+func (s *stateFile) resume(r *results) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cs := s.Remotes[fs.ConfigString(r.f)]
+	if cs == nil {
+		return 0, nil
+	}
+	if cs.Shard != shard {
+		return 0, errors.Errorf("state file is for --shard %q not %q", cs.Shard, shard)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for hexKey, res := range cs.ControlResults {
+		key, err := hex.DecodeString(hexKey)
+		if err != nil {
+			return 0, errors.Wrap(err, "corrupted state file")
+		}
+		k := string(key)
+		res.Text = k
+		if res.WriteError == nil {
+			res.WriteError = map[internal.Position]string{}
+		}
+		if res.GetError == nil {
+			res.GetError = map[internal.Position]string{}
+		}
+		if res.InList == nil {
+			res.InList = map[internal.Position]internal.Presence{}
+		}
+		r.controlResults[k] = res
+		r.stringNeedsEscaping[k] = cs.NeedsEscaping[hexKey]
+	}
+	return len(cs.ControlResults), nil
+}
+
+// save records the character k checked on r and writes the state
+// file
+func (s *stateFile) save(r *results, k string, position internal.Position, res internal.ControlResult) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := fs.ConfigString(r.f)
+	cs := s.Remotes[key]
+	if cs == nil {
+		cs = &controlState{
+			Shard:          shard,
+			NeedsEscaping:  map[string]internal.Position{},
+			ControlResults: map[string]internal.ControlResult{},
+		}
+		s.Remotes[key] = cs
+	}
+	hexKey := hex.EncodeToString([]byte(k))
+	cs.NeedsEscaping[hexKey] = position
+	cs.ControlResults[hexKey] = res
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err == nil {
+		// Write to a temporary file then rename so an interrupted
+		// write doesn't lose the state
+		tmp := s.path + ".tmp"
+		err = ioutil.WriteFile(tmp, data, 0666)
+		if err == nil {
+			err = os.Rename(tmp, s.path)
+		}
+	}
+	if err != nil {
+		fs.Errorf(r.f, "Failed to write state file: %v", err)
+	}
+}
@@ -0,0 +1,264 @@
+// Package latency provides the latency test command.
+package latency
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+	"path"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/pingme998/rclone/cmd"
+	"github.com/pingme998/rclone/cmd/test"
+	"github.com/pingme998/rclone/fs"
+	"github.com/pingme998/rclone/fs/config/flags"
+	"github.com/pingme998/rclone/fs/object"
+	"github.com/pingme998/rclone/lib/random"
+	"github.com/spf13/cobra"
+)
+
+var (
+	iterations = 10
+	size       = fs.SizeSuffix(1024)
+	jsonOutput bool
+)
+
+func init() {
+	test.Command.AddCommand(commandDefinition)
+	cmdFlags := commandDefinition.Flags()
+	flags.IntVarP(cmdFlags, &iterations, "iterations", "", iterations, "Number of times to run each operation")
+	flags.FVarP(cmdFlags, &size, "size", "", "Size of the files written and read")
+	flags.BoolVarP(cmdFlags, &jsonOutput, "json", "", false, "Format output as JSON")
+}
+
+var commandDefinition = &cobra.Command{
+	Use:   "latency remote:path",
+	Short: `Measures the latency of operations on remote:path.`,
+	Long: `This command measures how long the basic operations take on the
+remote, which is useful to compare remotes and to tune the pacer
+settings (e.g. --tpslimit) for a remote.
+
+It makes a temporary directory in remote:path and runs these
+operations --iterations times, one at a time, on files of --size
+
+- write - upload a file
+- stat - look up the file
+- list - list the directory
+- read - download the file
+- delete - delete the file
+
+The temporary directory is removed at the end.
+
+The time for each operation is shown as the minimum, mean, 50th, 90th
+and 99th percentiles and the maximum along with the number of errors.
+
+    $ rclone test latency --iterations 20 remote:path
+    Operation  Count  Errors      Min     Mean      P50      P90      P99      Max
+    write         20       0  41.2ms   55.0ms   52.1ms   67.3ms   90.4ms   90.4ms
+    ...
+
+Use --json to output the results as JSON with the times in
+milliseconds.
+
+Note that the pacer and low level retries are included in the times,
+so use -vv to see if operations are being retried.
+`,
+	Run: func(command *cobra.Command, args []string) {
+		cmd.CheckArgs(1, 1, command, args)
+		f := cmd.NewFsDir(args)
+		cmd.Run(false, false, command, func() error {
+			report, err := Latency(context.Background(), f, iterations, int64(size))
+			if err != nil {
+				return err
+			}
+			return report.Write(os.Stdout, jsonOutput)
+		})
+	},
+}
+
+// Operations are the operations measured in the order they are run
+var Operations = []string{"write", "stat", "list", "read", "delete"}
+
+// Stats are the latency statistics for a single operation
+type Stats struct {
+	Operation string  `json:"operation"`
+	Count     int     `json:"count"`  // number of successful operations
+	Errors    int     `json:"errors"` // number of failed operations
+	Min       float64 `json:"minMs"`
+	Mean      float64 `json:"meanMs"`
+	P50       float64 `json:"p50Ms"`
+	P90       float64 `json:"p90Ms"`
+	P99       float64 `json:"p99Ms"`
+	Max       float64 `json:"maxMs"`
+}
+
+// Report is the result of running Latency
+type Report struct {
+	Remote     string   `json:"remote"`
+	Iterations int      `json:"iterations"`
+	Size       int64    `json:"size"`
+	Operations []*Stats `json:"operations"`
+}
+
+// milliseconds converts d into fractional milliseconds
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// Percentile returns the p-th percentile (0-100) of the sorted
+// durations using the nearest rank method
+func Percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// NewStats makes the Stats for operation from the durations of the
+// successful operations and the number of errors
+func NewStats(operation string, durations []time.Duration, errors int) *Stats {
+	s := &Stats{
+		Operation: operation,
+		Count:     len(durations),
+		Errors:    errors,
+	}
+	if len(durations) == 0 {
+		return s
+	}
+	sorted := append([]time.Duration(nil), durations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	var total time.Duration
+	for _, d := range sorted {
+		total += d
+	}
+	s.Min = milliseconds(sorted[0])
+	s.Mean = milliseconds(total / time.Duration(len(sorted)))
+	s.P50 = milliseconds(Percentile(sorted, 50))
+	s.P90 = milliseconds(Percentile(sorted, 90))
+	s.P99 = milliseconds(Percentile(sorted, 99))
+	s.Max = milliseconds(sorted[len(sorted)-1])
+	return s
+}
+
+// Latency measures the latency of the Operations on f running each
+// one iterations times on files of size bytes.
+//
+// The files are written into a temporary directory which is removed
+// afterwards.
+func Latency(ctx context.Context, f fs.Fs, iterations int, size int64) (*Report, error) {
+	if iterations < 1 {
+		return nil, errors.New("need at least 1 iteration")
+	}
+	dir := "rclone-latency-" + random.String(8)
+	err := f.Mkdir(ctx, dir)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to make test directory")
+	}
+	defer func() {
+		if err := f.Rmdir(ctx, dir); err != nil {
+			fs.Errorf(f, "Failed to remove test directory %q: %v", dir, err)
+		}
+	}()
+
+	durations := map[string][]time.Duration{}
+	errorCount := map[string]int{}
+	// measure runs fn recording the time taken for operation
+	measure := func(operation string, fn func() error) bool {
+		start := time.Now()
+		err := fn()
+		if err != nil {
+			fs.Errorf(f, "%s failed: %v", operation, err)
+			errorCount[operation]++
+			return false
+		}
+		durations[operation] = append(durations[operation], time.Since(start))
+		return true
+	}
+	for i := 0; i < iterations; i++ {
+		remote := path.Join(dir, fmt.Sprintf("file-%d", i))
+		contents := []byte(random.String(int(size)))
+		var o fs.Object
+		ok := measure("write", func() (err error) {
+			src := object.NewStaticObjectInfo(remote, time.Now(), size, true, nil, f)
+			o, err = f.Put(ctx, bytes.NewReader(contents), src)
+			return err
+		})
+		if !ok {
+			continue
+		}
+		measure("stat", func() error {
+			_, err := f.NewObject(ctx, remote)
+			return err
+		})
+		measure("list", func() error {
+			_, err := f.List(ctx, dir)
+			return err
+		})
+		measure("read", func() error {
+			in, err := o.Open(ctx)
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(ioutil.Discard, in)
+			if closeErr := in.Close(); err == nil {
+				err = closeErr
+			}
+			return err
+		})
+		measure("delete", func() error {
+			return o.Remove(ctx)
+		})
+		fs.Debugf(f, "Finished iteration %d/%d", i+1, iterations)
+	}
+
+	report := &Report{
+		Remote:     fs.ConfigString(f),
+		Iterations: iterations,
+		Size:       size,
+	}
+	for _, operation := range Operations {
+		report.Operations = append(report.Operations, NewStats(operation, durations[operation], errorCount[operation]))
+	}
+	return report, nil
+}
+
+// formatMs formats fractional milliseconds for the text output
+func formatMs(ms float64) string {
+	d := time.Duration(ms * float64(time.Millisecond))
+	if d < time.Millisecond {
+		return d.Round(time.Microsecond).String()
+	}
+	return d.Round(100 * time.Microsecond).String()
+}
+
+// Write the report to out as text or JSON
+func (r *Report) Write(out io.Writer, asJSON bool) error {
+	if asJSON {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "\t")
+		return enc.Encode(r)
+	}
+	_, err := fmt.Fprintf(out, "%-9s %6s %7s %8s %8s %8s %8s %8s %8s\n", "Operation", "Count", "Errors", "Min", "Mean", "P50", "P90", "P99", "Max")
+	if err != nil {
+		return err
+	}
+	for _, s := range r.Operations {
+		_, err = fmt.Fprintf(out, "%-9s %6d %7d %8s %8s %8s %8s %8s %8s\n", s.Operation, s.Count, s.Errors,
+			formatMs(s.Min), formatMs(s.Mean), formatMs(s.P50), formatMs(s.P90), formatMs(s.P99), formatMs(s.Max))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
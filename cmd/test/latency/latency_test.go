@@ -0,0 +1,78 @@
+package latency
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	_ "github.com/pingme998/rclone/backend/local"
+	"github.com/pingme998/rclone/fstest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMain drives the tests
+func TestMain(m *testing.M) {
+	fstest.TestMain(m)
+}
+
+func TestPercentile(t *testing.T) {
+	var sorted []time.Duration
+	for i := 1; i <= 10; i++ {
+		sorted = append(sorted, time.Duration(i)*time.Millisecond)
+	}
+	assert.Equal(t, time.Duration(0), Percentile(nil, 50))
+	assert.Equal(t, 1*time.Millisecond, Percentile(sorted, 0))
+	assert.Equal(t, 5*time.Millisecond, Percentile(sorted, 50))
+	assert.Equal(t, 9*time.Millisecond, Percentile(sorted, 90))
+	assert.Equal(t, 10*time.Millisecond, Percentile(sorted, 99))
+	assert.Equal(t, 10*time.Millisecond, Percentile(sorted, 100))
+}
+
+func TestNewStats(t *testing.T) {
+	s := NewStats("stat", []time.Duration{3 * time.Millisecond, time.Millisecond, 2 * time.Millisecond}, 1)
+	assert.Equal(t, &Stats{
+		Operation: "stat",
+		Count:     3,
+		Errors:    1,
+		Min:       1,
+		Mean:      2,
+		P50:       2,
+		P90:       3,
+		P99:       3,
+		Max:       3,
+	}, s)
+
+	s = NewStats("list", nil, 2)
+	assert.Equal(t, &Stats{Operation: "list", Errors: 2}, s)
+}
+
+func TestLatency(t *testing.T) {
+	ctx := context.Background()
+	r := fstest.NewRun(t)
+	defer r.Finalise()
+
+	report, err := Latency(ctx, r.Fremote, 3, 100)
+	require.NoError(t, err)
+	require.Len(t, report.Operations, len(Operations))
+	for i, s := range report.Operations {
+		assert.Equal(t, Operations[i], s.Operation)
+		assert.Equal(t, 3, s.Count, s.Operation)
+		assert.Equal(t, 0, s.Errors, s.Operation)
+	}
+	// the temporary directory should have been removed
+	fstest.CheckListingWithPrecision(t, r.Fremote, nil, nil, time.Second)
+
+	var buf bytes.Buffer
+	require.NoError(t, report.Write(&buf, false))
+	assert.Contains(t, buf.String(), "Operation  Count  Errors")
+	assert.Contains(t, buf.String(), "\ndelete         3       0 ")
+
+	buf.Reset()
+	require.NoError(t, report.Write(&buf, true))
+	var decoded Report
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+	assert.Equal(t, report, &decoded)
+}
@@ -0,0 +1,151 @@
+package verify
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/pingme998/rclone/cmd"
+	"github.com/pingme998/rclone/fs"
+	"github.com/pingme998/rclone/fs/config/flags"
+	"github.com/pingme998/rclone/fs/hash"
+	"github.com/pingme998/rclone/fs/operations"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// Globals
+var (
+	manifest      = ""
+	hashType      = ""
+	samplePercent = 0.0
+	stateFile     = ""
+	reportFile    = ""
+	reportKey     = ""
+)
+
+func init() {
+	cmd.Root.AddCommand(commandDefinition)
+	cmdFlags := commandDefinition.Flags()
+	flags.StringVarP(cmdFlags, &manifest, "manifest", "", manifest, "Verify against this hash sum file rather than a source")
+	flags.StringVarP(cmdFlags, &hashType, "hash", "", hashType, "Hash to use, default is the source's hash or MD5 with --manifest")
+	flags.Float64VarP(cmdFlags, &samplePercent, "sample-percent", "", samplePercent, "Only read this percentage of the files, chosen at random")
+	flags.StringVarP(cmdFlags, &stateFile, "state", "", stateFile, "Record progress in this file so verification can be resumed")
+	flags.StringVarP(cmdFlags, &reportFile, "report", "", reportFile, "Write a JSON audit report to this file (or - for stdout)")
+	flags.StringVarP(cmdFlags, &reportKey, "report-key", "", reportKey, "Sign the report with an HMAC-SHA256 using this key")
+}
+
+var commandDefinition = &cobra.Command{
+	Use:   "verify [source:path] dest:path",
+	Short: `Audit the contents of the files in the destination.`,
+	Long: strings.ReplaceAll(`
+Reads every file in the destination, recomputes its hash and compares
+it with the hash of the file in the source, or with the hash in a
+manifest if |--manifest| is used. Unlike |rclone check| this doesn't
+trust the hashes stored by the destination, so it will find files
+which have been corrupted at rest. It doesn't alter the source or
+destination.
+
+The manifest is a file in the format produced by |md5sum|,
+|sha1sum| or |rclone hashsum|. Use |--hash| to say which hash it
+contains if it isn't MD5, for example
+
+    rclone hashsum SHA-1 source:path --output-file manifest.sha1
+    rclone verify --manifest manifest.sha1 --hash SHA-1 dest:path
+
+Only the source's hashes are read - if the source doesn't support the
+hash in use then source files are read too.
+
+Use |--sample-percent| to read only a random sample of the files for a
+quick audit.
+
+Use |--state| to name a file in which the results are recorded as they
+are found. Files which were verified OK by a previous run with the same
+state file are skipped, so a very large destination can be audited
+over several runs, for example using |--max-duration| or
+|--max-transfer| to limit each run. Files which had errors are retried
+on the next run. Combined with |--sample-percent| this will gradually
+read more of the destination each run. Delete the state file to start
+again.
+
+Use |--report| to write a JSON report of the run including a list of
+files which failed to verify. If |--report-key| is also given then an
+HMAC-SHA256 of the report is written as hex to the report file name
+with |.sig| appended, which can be checked with, for example
+
+    openssl dgst -sha256 -hmac KEY report.json
+
+The files which failed are logged at ERROR level and the command exits
+with a non-zero status if any were found.
+`, "|", "`"),
+	Run: func(command *cobra.Command, args []string) {
+		opt := &operations.VerifyOpt{
+			SamplePercent: samplePercent,
+			StateFile:     stateFile,
+		}
+		if manifest != "" {
+			cmd.CheckArgs(1, 1, command, args)
+			opt.Fdst = cmd.NewFsSrc(args)
+		} else {
+			cmd.CheckArgs(2, 2, command, args)
+			opt.Fsrc, opt.Fdst = cmd.NewFsSrcDst(args)
+		}
+		cmd.Run(false, true, command, func() error {
+			if hashType != "" {
+				if err := opt.HashType.Set(hashType); err != nil {
+					return err
+				}
+			} else if manifest != "" {
+				opt.HashType = hash.MD5
+			}
+			if manifest != "" {
+				in, err := os.Open(manifest)
+				if err != nil {
+					return errors.Wrap(err, "failed to open manifest")
+				}
+				opt.Manifest, err = operations.ReadHashSums(in)
+				_ = in.Close()
+				if err != nil {
+					return err
+				}
+			}
+			report, err := operations.Verify(context.Background(), opt)
+			if report != nil && reportFile != "" {
+				if reportErr := writeReport(report); reportErr != nil {
+					fs.Errorf(nil, "%v", reportErr)
+					if err == nil {
+						err = reportErr
+					}
+				}
+			}
+			return err
+		})
+	},
+}
+
+// writeReport writes the report and its signature
+func writeReport(report *operations.VerifyReport) error {
+	data, signature, err := operations.SignVerifyReport(report, reportKey)
+	if err != nil {
+		return err
+	}
+	if reportFile == "-" {
+		_, err = os.Stdout.Write(data)
+		if err == nil && signature != "" {
+			fs.Logf(nil, "Report signature: %s", signature)
+		}
+		return err
+	}
+	err = ioutil.WriteFile(reportFile, data, 0666)
+	if err != nil {
+		return errors.Wrap(err, "failed to write report")
+	}
+	if signature != "" {
+		err = ioutil.WriteFile(reportFile+".sig", []byte(signature+"\n"), 0666)
+		if err != nil {
+			return errors.Wrap(err, "failed to write report signature")
+		}
+	}
+	return nil
+}
@@ -29,6 +29,29 @@ var ErrorMaxTransferLimitReachedFatal = fserrors.FatalError(ErrorMaxTransferLimi
 // transfer limit is reached and a graceful stop is required.
 var ErrorMaxTransferLimitReachedGraceful = fserrors.NoRetryError(ErrorMaxTransferLimitReached)
 
+// ErrorTransferStalled is returned from Read when the transfer has
+// been slower than --min-transfer-rate for --min-transfer-rate-time.
+//
+// It is a retry error so the transfer is retried with a fresh
+// connection.
+var ErrorTransferStalled = fserrors.RetryError(errors.New("transfer stalled: slower than --min-transfer-rate"))
+
+// ErrorTransferTimedOut is returned from Read when the transfer has
+// been running for longer than --max-transfer-time.
+//
+// It is a retry error so the transfer is retried with a fresh
+// connection.
+var ErrorTransferTimedOut = fserrors.RetryError(errors.New("transfer timed out: took longer than --max-transfer-time"))
+
+// ErrorMaxDurationReached is returned when --max-duration is reached.
+// Used for checking on exit and matching to correct exit code.
+var ErrorMaxDurationReached = errors.New("max duration reached as set by --max-duration")
+
+// ErrorMaxDurationReachedGraceful is returned from sync when
+// --max-duration is reached with a soft --cutoff-mode and the
+// running transfers have been allowed to finish.
+var ErrorMaxDurationReachedGraceful = fserrors.NoRetryError(ErrorMaxDurationReached)
+
 // Start sets up the accounting, in particular the bandwidth limiting
 func Start(ctx context.Context) {
 	// Start the token bucket limiter
@@ -60,10 +83,26 @@ type Account struct {
 	closed  bool          // set if the file is closed
 	exit    chan struct{} // channel that will be closed when transfer is finished
 	withBuf bool          // is using a buffered in
+	remotes []remoteKey   // remotes to account the bytes to
 
-	tokenBucket buckets // per file bandwidth limiter (may be nil)
+	tokenBucket buckets    // per file bandwidth limiter (may be nil)
+	bwLimiter   *bwLimiter // per job bandwidth limiter (may be nil)
 
 	values accountValues
+	stall  stallValues
+}
+
+// stallValues holds the state for --min-transfer-rate and
+// --max-transfer-time
+//
+// This has its own mutex as Read holds Account.mu while blocked.
+type stallValues struct {
+	mu      sync.Mutex
+	in      io.Closer // the reader to close if the transfer stalls
+	onStall func()    // called if the transfer stalls, may be nil
+	started time.Time // when the current attempt at the transfer started
+	since   time.Time // when the speed went below the minimum
+	err     error     // why the transfer was stopped if it stalled
 }
 
 // accountValues holds statistics for this Account
@@ -107,6 +146,10 @@ func newAccountSizeName(ctx context.Context, stats *StatsInfo, in io.ReadCloser,
 		acc.tokenBucket = newTokenBucket(currLimit.Bandwidth)
 	}
 
+	acc.bwLimiter = getBwLimiter(ctx)
+	acc.stall.in = in
+	acc.stall.started = time.Now()
+
 	go acc.averageLoop()
 	stats.inProgress.set(acc.name, acc)
 	return acc
@@ -201,6 +244,92 @@ func (acc *Account) UpdateReader(ctx context.Context, in io.ReadCloser) {
 	acc.values.lpBytes = 0
 	acc.values.bytes = 0
 	acc.values.mu.Unlock()
+
+	// Start stall detection afresh on the new reader
+	acc.stall.mu.Lock()
+	acc.stall.in = in
+	acc.stall.started = time.Now()
+	acc.stall.since = time.Time{}
+	acc.stall.err = nil
+	acc.stall.mu.Unlock()
+}
+
+// OnStall sets fn to be called if the transfer is stopped for being
+// slower than --min-transfer-rate or taking longer than
+// --max-transfer-time, e.g. to cancel the upload.
+func (acc *Account) OnStall(fn func()) {
+	acc.stall.mu.Lock()
+	acc.stall.onStall = fn
+	acc.stall.mu.Unlock()
+}
+
+// Stalled returns true if the transfer was stopped for being slower
+// than --min-transfer-rate or taking longer than --max-transfer-time
+func (acc *Account) Stalled() bool {
+	return acc.StallError() != nil
+}
+
+// StallError returns ErrorTransferStalled or ErrorTransferTimedOut if
+// the transfer was stopped, or nil if it wasn't
+func (acc *Account) StallError() error {
+	acc.stall.mu.Lock()
+	defer acc.stall.mu.Unlock()
+	return acc.stall.err
+}
+
+// checkStall is called every second with the speed over the last
+// second and stops the transfer if it has been slower than
+// --min-transfer-rate for --min-transfer-rate-time or has been
+// running for longer than --max-transfer-time.
+//
+// For --min-transfer-rate, transfers which haven't started reading
+// yet or have read all their data (and may be waiting for the remote
+// to finish) are ignored. --max-transfer-time counts from when the
+// attempt started whatever it is doing, so catches those too.
+func (acc *Account) checkStall(now time.Time, speed float64) {
+	if acc.ci.MinTransferRate <= 0 && acc.ci.MaxTransferTime <= 0 {
+		return
+	}
+	acc.values.mu.Lock()
+	active := !acc.values.start.IsZero() && (acc.size < 0 || acc.values.bytes < acc.size)
+	acc.values.mu.Unlock()
+	acc.stall.mu.Lock()
+	if acc.stall.err != nil {
+		acc.stall.mu.Unlock()
+		return
+	}
+	if acc.ci.MaxTransferTime > 0 && now.Sub(acc.stall.started) >= acc.ci.MaxTransferTime {
+		acc.stall.err = ErrorTransferTimedOut
+	} else if acc.ci.MinTransferRate > 0 && active && speed < float64(acc.ci.MinTransferRate) {
+		if acc.stall.since.IsZero() {
+			acc.stall.since = now
+		}
+		if now.Sub(acc.stall.since) >= acc.ci.MinTransferRateTime {
+			acc.stall.err = ErrorTransferStalled
+		}
+	} else {
+		acc.stall.since = time.Time{}
+	}
+	err, in, onStall := acc.stall.err, acc.stall.in, acc.stall.onStall
+	acc.stall.mu.Unlock()
+	if err == nil {
+		return
+	}
+
+	if err == ErrorTransferTimedOut {
+		fs.Errorf(acc.name, "Restarting transfer: took longer than --max-transfer-time %v", acc.ci.MaxTransferTime)
+	} else {
+		fs.Errorf(acc.name, "Restarting transfer: slower than --min-transfer-rate %v/s for %v", acc.ci.MinTransferRate, acc.ci.MinTransferRateTime)
+	}
+	acc.stats.Stalls(1)
+	// Close the input to unblock any Read which is waiting on a
+	// dead connection
+	if in != nil {
+		_ = in.Close()
+	}
+	if onStall != nil {
+		onStall()
+	}
 }
 
 // averageLoop calculates averages for the stats in the background
@@ -227,6 +356,7 @@ func (acc *Account) averageLoop() {
 			acc.values.lpTime = now
 			// Unlock stats
 			acc.values.mu.Unlock()
+			acc.checkStall(now, avg)
 		case <-acc.exit:
 			return
 		}
@@ -236,6 +366,10 @@ func (acc *Account) averageLoop() {
 // Check the read before it has happened is valid returning the number
 // of bytes remaining to read.
 func (acc *Account) checkReadBefore() (bytesUntilLimit int64, err error) {
+	// Check to see if the transfer was stopped for being too slow
+	if err = acc.StallError(); err != nil {
+		return 0, err
+	}
 	// Check to see if context is cancelled
 	if err = acc.ctx.Err(); err != nil {
 		return 0, err
@@ -292,6 +426,7 @@ func (acc *Account) ServerSideCopyEnd(n int64) {
 	acc.values.mu.Unlock()
 
 	acc.stats.Bytes(n)
+	acc.stats.remoteBytes(acc.remotes, n)
 }
 
 // DryRun accounts for statistics without running the operation
@@ -323,8 +458,12 @@ func (acc *Account) accountRead(n int) {
 	acc.values.mu.Unlock()
 
 	acc.stats.Bytes(int64(n))
+	acc.stats.remoteBytes(acc.remotes, int64(n))
 
 	TokenBucket.LimitBandwidth(TokenBucketSlotAccounting, n)
+	if acc.bwLimiter != nil {
+		acc.bwLimiter.limitBandwidth(n)
+	}
 	acc.limitPerFileBandwidth(n)
 }
 
@@ -335,6 +474,11 @@ func (acc *Account) read(in io.Reader, p []byte) (n int, err error) {
 		n, err = in.Read(p)
 		acc.accountRead(n)
 		n, err = acc.checkReadAfter(bytesUntilLimit, n, err)
+		if err != nil && err != io.EOF {
+			if stallErr := acc.StallError(); stallErr != nil {
+				err = stallErr
+			}
+		}
 	}
 	return n, err
 }
@@ -8,6 +8,7 @@ import (
 	"io/ioutil"
 	"strings"
 	"testing"
+	"time"
 	"unicode/utf8"
 
 	"github.com/pingme998/rclone/fs"
@@ -93,6 +94,98 @@ func TestAccountGetUpdateReader(t *testing.T) {
 	t.Run("Close", test(true))
 }
 
+func TestAccountStall(t *testing.T) {
+	ctx, ci := fs.AddConfig(context.Background())
+	ci.MinTransferRate = fs.SizeSuffix(1024)
+	ci.MinTransferRateTime = 10 * time.Second
+
+	pr, pw := io.Pipe()
+	go func() {
+		_, _ = pw.Write([]byte{1})
+	}()
+	stats := NewStats(ctx)
+	acc := newAccountSizeName(ctx, stats, pr, 100, "test")
+	defer acc.Done()
+	stalled := false
+	acc.OnStall(func() { stalled = true })
+
+	// read a byte to start the transfer
+	n, err := acc.Read(make([]byte, 1))
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	// fast enough
+	now := time.Now()
+	acc.checkStall(now, 2048)
+	assert.False(t, acc.Stalled())
+
+	// too slow but not for long enough
+	acc.checkStall(now, 10)
+	acc.checkStall(now.Add(5*time.Second), 10)
+	assert.False(t, acc.Stalled())
+
+	// speeding up resets the timer
+	acc.checkStall(now.Add(6*time.Second), 2048)
+	acc.checkStall(now.Add(7*time.Second), 10)
+	acc.checkStall(now.Add(12*time.Second), 10)
+	assert.False(t, acc.Stalled())
+
+	acc.checkStall(now.Add(17*time.Second), 10)
+	assert.True(t, acc.Stalled())
+	assert.True(t, stalled)
+	assert.Equal(t, int64(1), stats.stalls)
+
+	// the input is closed and reads return a retry error
+	_, err = acc.Read(make([]byte, 1))
+	assert.Equal(t, ErrorTransferStalled, err)
+	assert.True(t, fserrors.IsRetryError(err))
+
+	// a new reader starts again
+	acc.UpdateReader(ctx, ioutil.NopCloser(bytes.NewBuffer([]byte{1})))
+	assert.False(t, acc.Stalled())
+	n, err = acc.Read(make([]byte, 1))
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+}
+
+func TestAccountMaxTransferTime(t *testing.T) {
+	ctx, ci := fs.AddConfig(context.Background())
+	ci.MaxTransferTime = time.Minute
+
+	pr, pw := io.Pipe()
+	defer func() {
+		_ = pw.Close()
+	}()
+	stats := NewStats(ctx)
+	acc := newAccountSizeName(ctx, stats, pr, 100, "test")
+	defer acc.Done()
+	stalled := false
+	acc.OnStall(func() { stalled = true })
+
+	// not started reading yet but still timed out
+	now := time.Now()
+	acc.checkStall(now.Add(59*time.Second), 0)
+	assert.False(t, acc.Stalled())
+	acc.checkStall(now.Add(61*time.Second), 0)
+	assert.True(t, acc.Stalled())
+	assert.True(t, stalled)
+	assert.Equal(t, int64(1), stats.stalls)
+
+	// the input is closed and reads return a retry error
+	_, err := acc.Read(make([]byte, 1))
+	assert.Equal(t, ErrorTransferTimedOut, err)
+	assert.True(t, fserrors.IsRetryError(err))
+
+	// a new reader gets the full time again
+	acc.UpdateReader(ctx, ioutil.NopCloser(bytes.NewBuffer([]byte{1})))
+	assert.Nil(t, acc.StallError())
+	acc.checkStall(time.Now().Add(59*time.Second), 0)
+	assert.False(t, acc.Stalled())
+	n, err := acc.Read(make([]byte, 1))
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+}
+
 func TestAccountRead(t *testing.T) {
 	ctx := context.Background()
 	in := ioutil.NopCloser(bytes.NewBuffer([]byte{1, 2, 3}))
@@ -0,0 +1,122 @@
+package accounting
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pingme998/rclone/fs"
+	"github.com/pingme998/rclone/lib/atexit"
+)
+
+// Result is the record written to --log-results for each file
+// transferred, checked or deleted.
+type Result struct {
+	Time     time.Time `json:"time"`
+	Action   string    `json:"action"`
+	Path     string    `json:"path"`
+	Size     int64     `json:"size"`
+	Bytes    int64     `json:"bytes"`
+	Duration float64   `json:"duration"` // seconds
+	Speed    float64   `json:"speed"`    // bytes per second
+	HashType string    `json:"hash_type,omitempty"`
+	Hash     string    `json:"hash,omitempty"`
+	Error    string    `json:"error,omitempty"`
+	Group    string    `json:"group,omitempty"`
+}
+
+// resultsLog is the destination of --log-results
+var resultsLog struct {
+	mu   sync.Mutex
+	path string    // the path opened
+	out  io.Writer // where to write records, nil if not open
+	err  error     // error opening path
+	once sync.Once // registers closing the file at exit
+}
+
+// openResultsLog returns the writer for path opening it if
+// necessary.
+//
+// Call with resultsLog.mu held.
+func openResultsLog(path string) (io.Writer, error) {
+	if resultsLog.path == path {
+		return resultsLog.out, resultsLog.err
+	}
+	if c, ok := resultsLog.out.(io.Closer); ok && resultsLog.out != os.Stdout {
+		_ = c.Close()
+	}
+	resultsLog.path = path
+	resultsLog.out, resultsLog.err = nil, nil
+	if path == "-" {
+		resultsLog.out = os.Stdout
+	} else {
+		// If the file exists then append to it, the same as --log-file
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0666)
+		if err != nil {
+			resultsLog.err = err
+			fs.Errorf(nil, "Failed to open --log-results file: %v", err)
+		} else {
+			resultsLog.out = f
+			resultsLog.once.Do(func() {
+				atexit.Register(func() {
+					if err := CloseResultsLog(); err != nil {
+						fs.Errorf(nil, "Failed to close --log-results file: %v", err)
+					}
+				})
+			})
+		}
+	}
+	return resultsLog.out, resultsLog.err
+}
+
+// LogResult writes result to the --log-results file if set.
+//
+// Each result is written as a single line of JSON with a single write
+// so results from concurrent transfers don't get mixed up.
+func LogResult(ctx context.Context, result *Result) {
+	ci := fs.GetConfig(ctx)
+	if ci.LogResults == "" {
+		return
+	}
+	if result.Time.IsZero() {
+		result.Time = time.Now()
+	}
+	buf, err := json.Marshal(result)
+	if err != nil {
+		fs.Errorf(result.Path, "Failed to marshal result: %v", err)
+		return
+	}
+	buf = append(buf, '\n')
+	resultsLog.mu.Lock()
+	defer resultsLog.mu.Unlock()
+	out, err := openResultsLog(ci.LogResults)
+	if err != nil {
+		return
+	}
+	_, err = out.Write(buf)
+	if err != nil {
+		fs.Errorf(result.Path, "Failed to write to --log-results file: %v", err)
+	}
+}
+
+// CloseResultsLog flushes and closes the --log-results file if it is
+// open, returning any error.
+//
+// The file is opened again by the next call to LogResult.
+func CloseResultsLog() (err error) {
+	resultsLog.mu.Lock()
+	defer resultsLog.mu.Unlock()
+	if f, ok := resultsLog.out.(*os.File); ok && f != os.Stdout {
+		err = f.Sync()
+		closeErr := f.Close()
+		if err == nil {
+			err = closeErr
+		}
+	}
+	resultsLog.path = ""
+	resultsLog.out, resultsLog.err = nil, nil
+	return err
+}
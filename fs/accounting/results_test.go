@@ -0,0 +1,105 @@
+package accounting
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pingme998/rclone/fs"
+	"github.com/pingme998/rclone/fs/hash"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// readResults reads the --log-results records in path
+func readResults(t *testing.T, path string) (results []Result) {
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, f.Close())
+	}()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var result Result
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &result))
+		results = append(results, result)
+	}
+	require.NoError(t, scanner.Err())
+	return results
+}
+
+func TestLogResults(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rclone-log-results")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(dir))
+	}()
+	path := filepath.Join(dir, "results.jsonl")
+
+	ctx, ci := fs.AddConfig(context.Background())
+	ci.LogResults = path
+	s := NewStats(ctx)
+
+	tr := s.NewTransferRemoteSize("file1", 100)
+	tr.SetHash(hash.MD5, "abcd")
+	tr.Done(ctx, nil)
+
+	tr = s.NewTransferRemoteSize("file2", 10)
+	tr.SetAction("delete")
+	tr.Done(ctx, errors.New("boom"))
+
+	tr = newTransferRemoteSize(s, "file3", 5, true)
+	tr.Done(ctx, nil)
+
+	results := readResults(t, path)
+	require.Equal(t, 3, len(results))
+
+	assert.Equal(t, "transfer", results[0].Action)
+	assert.Equal(t, "file1", results[0].Path)
+	assert.Equal(t, int64(100), results[0].Size)
+	assert.Equal(t, "MD5", results[0].HashType)
+	assert.Equal(t, "abcd", results[0].Hash)
+	assert.Equal(t, "", results[0].Error)
+	assert.False(t, results[0].Time.IsZero())
+
+	assert.Equal(t, "delete", results[1].Action)
+	assert.Equal(t, "boom", results[1].Error)
+	assert.Equal(t, "", results[1].HashType)
+
+	assert.Equal(t, "check", results[2].Action)
+	assert.Equal(t, "file3", results[2].Path)
+}
+
+func TestCloseResultsLog(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rclone-log-results")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(dir))
+	}()
+	path := filepath.Join(dir, "results.jsonl")
+
+	ctx, ci := fs.AddConfig(context.Background())
+	ci.LogResults = path
+
+	// Closing when nothing is open is fine
+	require.NoError(t, CloseResultsLog())
+
+	LogResult(ctx, &Result{Action: "transfer", Path: "file1"})
+	require.NoError(t, CloseResultsLog())
+	assert.Nil(t, resultsLog.out)
+	assert.Equal(t, "", resultsLog.path)
+
+	// The file is opened again and appended to after closing
+	LogResult(ctx, &Result{Action: "transfer", Path: "file2"})
+	require.NoError(t, CloseResultsLog())
+
+	results := readResults(t, path)
+	require.Equal(t, 2, len(results))
+	assert.Equal(t, "file1", results[0].Path)
+	assert.Equal(t, "file2", results[1].Path)
+}
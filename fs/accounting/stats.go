@@ -29,6 +29,9 @@ type StatsInfo struct {
 	fatalError        bool
 	retryError        bool
 	retryAfter        time.Time
+	fatalErrors       int64
+	retryErrors       int64
+	noRetryErrors     int64
 	checks            int64
 	checking          *transferMap
 	checkQueue        int
@@ -42,6 +45,10 @@ type StatsInfo struct {
 	renameQueueSize   int64
 	deletes           int64
 	deletedDirs       int64
+	verifies          int64
+	verifyFailures    int64
+	stalls            int64
+	remotes           map[remoteKey]*remoteStats // breakdown by remote
 	inProgress        *inProgress
 	startedTransfers  []*Transfer   // currently active transfers
 	oldTimeRanges     timeRanges    // a merged list of time ranges for the transfers
@@ -87,7 +94,14 @@ func (s *StatsInfo) RemoteStats() (out rc.Params, err error) {
 	out["deletes"] = s.deletes
 	out["deletedDirs"] = s.deletedDirs
 	out["renames"] = s.renames
+	out["verifies"] = s.verifies
+	out["verifyFailures"] = s.verifyFailures
+	out["stalls"] = s.stalls
 	out["elapsedTime"] = time.Since(s.startTime).Seconds()
+	if remotes, backends := s._remotesStats(); remotes != nil {
+		out["remotes"] = remotes
+		out["backends"] = backends
+	}
 	eta, etaOK := eta(s.bytes, ts.totalBytes, ts.speed)
 	if etaOK {
 		out["eta"] = eta.Seconds()
@@ -371,6 +385,12 @@ func (s *StatsInfo) String() string {
 		if s.renames != 0 {
 			_, _ = fmt.Fprintf(buf, "Renamed:       %10d\n", s.renames)
 		}
+		if s.verifies != 0 {
+			_, _ = fmt.Fprintf(buf, "Verified:      %10d, %d failed\n", s.verifies, s.verifyFailures)
+		}
+		if s.stalls != 0 {
+			_, _ = fmt.Fprintf(buf, "Stalled:       %10d\n", s.stalls)
+		}
 		if s.transfers != 0 || ts.totalTransfers != 0 {
 			_, _ = fmt.Fprintf(buf, "Transferred:   %10d / %d, %s\n",
 				s.transfers, ts.totalTransfers, percent(s.transfers, ts.totalTransfers))
@@ -411,6 +431,18 @@ func (s *StatsInfo) Transferred() []TransferSnapshot {
 	return ts
 }
 
+// transfer returns the started transfer with id or nil if not found
+func (s *StatsInfo) transfer(id int64) *Transfer {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, tr := range s.startedTransfers {
+		if tr.id == id {
+			return tr
+		}
+	}
+	return nil
+}
+
 // Log outputs the StatsInfo to the log
 func (s *StatsInfo) Log() {
 	if s.ci.UseJSONLog {
@@ -532,6 +564,31 @@ func (s *StatsInfo) Renames(renames int64) int64 {
 	return s.renames
 }
 
+// Verifies updates the stats for uploads verified by --verify-uploads
+func (s *StatsInfo) Verifies(verifies int64) int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.verifies += verifies
+	return s.verifies
+}
+
+// VerifyFailures updates the stats for uploads which failed verification
+func (s *StatsInfo) VerifyFailures(verifyFailures int64) int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.verifyFailures += verifyFailures
+	return s.verifyFailures
+}
+
+// Stalls updates the stats for transfers restarted for being slower
+// than --min-transfer-rate or taking longer than --max-transfer-time
+func (s *StatsInfo) Stalls(stalls int64) int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stalls += stalls
+	return s.stalls
+}
+
 // ResetCounters sets the counters (bytes, checks, errors, transfers, deletes, renames) to 0 and resets lastError, fatalError and retryError
 func (s *StatsInfo) ResetCounters() {
 	s.mu.Lock()
@@ -542,11 +599,18 @@ func (s *StatsInfo) ResetCounters() {
 	s.fatalError = false
 	s.retryError = false
 	s.retryAfter = time.Time{}
+	s.fatalErrors = 0
+	s.retryErrors = 0
+	s.noRetryErrors = 0
 	s.checks = 0
 	s.transfers = 0
 	s.deletes = 0
 	s.deletedDirs = 0
 	s.renames = 0
+	s.verifies = 0
+	s.verifyFailures = 0
+	s.stalls = 0
+	s.remotes = nil
 	s.startedTransfers = nil
 	s.oldDuration = 0
 }
@@ -560,6 +624,9 @@ func (s *StatsInfo) ResetErrors() {
 	s.fatalError = false
 	s.retryError = false
 	s.retryAfter = time.Time{}
+	s.fatalErrors = 0
+	s.retryErrors = 0
+	s.noRetryErrors = 0
 }
 
 // Errored returns whether there have been any errors
@@ -583,18 +650,38 @@ func (s *StatsInfo) Error(err error) error {
 	switch {
 	case fserrors.IsFatalError(err):
 		s.fatalError = true
+		s.fatalErrors++
 	case fserrors.IsRetryAfterError(err):
 		retryAfter := fserrors.RetryAfterErrorTime(err)
 		if s.retryAfter.IsZero() || retryAfter.Sub(s.retryAfter) > 0 {
 			s.retryAfter = retryAfter
 		}
 		s.retryError = true
+		s.retryErrors++
 	case !fserrors.IsNoRetryError(err):
 		s.retryError = true
+		s.retryErrors++
+	default:
+		s.noRetryErrors++
 	}
 	return err
 }
 
+// ErrorsByClass returns the number of errors of each class.
+//
+// "fatal", "retry" and "noRetry" count the errors passed to Error and
+// "other" counts those only added to the total with Errors.
+func (s *StatsInfo) ErrorsByClass() map[string]int64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return map[string]int64{
+		"fatal":   s.fatalErrors,
+		"retry":   s.retryErrors,
+		"noRetry": s.noRetryErrors,
+		"other":   s.errors - s.fatalErrors - s.retryErrors - s.noRetryErrors,
+	}
+}
+
 // RetryAfter returns the time to retry after if it is set.  It will
 // be Zero if it isn't set.
 func (s *StatsInfo) RetryAfter() time.Time {
@@ -625,6 +712,11 @@ func (s *StatsInfo) GetTransfers() int64 {
 	return s.transfers
 }
 
+// GetTransfersInProgress returns the number of transfers in progress
+func (s *StatsInfo) GetTransfersInProgress() int {
+	return s.transferring.count()
+}
+
 // NewTransfer adds a transfer to the stats from the object.
 func (s *StatsInfo) NewTransfer(obj fs.Object) *Transfer {
 	tr := newTransfer(s, obj)
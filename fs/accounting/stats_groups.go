@@ -4,6 +4,7 @@ import (
 	"context"
 	"sync"
 
+	"github.com/pkg/errors"
 	"github.com/pingme998/rclone/fs/rc"
 
 	"github.com/pingme998/rclone/fs"
@@ -102,6 +103,20 @@ Returns the following values:
 	"totalTransfers": total number of transfers in the group,
 	"transferTime" : total time spent on running jobs,
 	"transfers": number of transferred files,
+	"verifies": number of uploads verified with --verify-uploads,
+	"verifyFailures": number of uploads which failed verification,
+	"stalls": number of transfers restarted for being slower than --min-transfer-rate or taking longer than --max-transfer-time,
+	"remotes": a breakdown of the stats by remote:
+		{
+			"remote_name": {
+				"backend": the type of backend the remote uses,
+				"bytes": bytes transferred to or from the remote,
+				"checks": number of files checked on the remote,
+				"errors": number of errors on the remote,
+				"transfers": number of files transferred to or from the remote
+			}
+		},
+	"backends": the same breakdown as "remotes" summed by backend type,
 	"transferring": an array of currently active file transfers:
 		[
 			{
@@ -111,14 +126,28 @@ Returns the following values:
 				"percentage": progress of the file transfer in percent,
 				"speed": average speed over the whole transfer in bytes per second,
 				"speedAvg": current speed in bytes per second as an exponentially weighted moving average,
-				"size": size of the file in bytes
+				"size": size of the file in bytes,
+				"id": id of the transfer to pass to core/transfer,
+				"retries": number of low level retries of the transfer,
+				"srcFs": remote the file is being transferred from,
+				"dstFs": remote the file is being transferred to,
+				"chunks": number of chunks the transfer is split into,
+				"chunksDone": number of chunks finished
 			}
 		],
 	"checking": an array of names of currently active file checks
 		[]
 }
 ` + "```" + `
-Values for "transferring", "checking" and "lastError" are only assigned if data is available.
+Values for "transferring", "checking", "lastError", "remotes" and
+"backends" are only assigned if data is available.
+Within "transferring" the values for "srcFs" and "dstFs" are only
+assigned if known, and "chunks" and "chunksDone" only for transfers
+split into chunks such as multi-thread copies.
+
+A file copied from one remote to another is counted in the stats of
+both remotes so the totals in "remotes" and "backends" may be more
+than "bytes" and "transfers".
 The value for "eta" is null if an eta cannot be determined.
 `,
 	})
@@ -181,6 +210,65 @@ Returns the following values:
 	})
 }
 
+func rcTransferStats(ctx context.Context, in rc.Params) (rc.Params, error) {
+	id, err := in.GetInt64("id")
+	if err != nil {
+		return nil, err
+	}
+	// Check to see if we should filter by group.
+	group, err := in.GetString("group")
+	if rc.NotErrParamNotFound(err) {
+		return rc.Params{}, err
+	}
+
+	var stats *StatsInfo
+	if group != "" {
+		stats = StatsGroup(ctx, group)
+	} else {
+		stats = groups.sum(ctx)
+	}
+	tr := stats.transfer(id)
+	if tr == nil {
+		return nil, errors.Errorf("transfer %d not found", id)
+	}
+	return tr.rcDetail(), nil
+}
+
+func init() {
+	rc.Add(rc.Call{
+		Path:  "core/transfer",
+		Fn:    rcTransferStats,
+		Title: "Returns the details of a single transfer.",
+		Help: `
+This returns the progress of an active transfer, or the result of a
+completed one, given its id from the "transferring" entries of
+core/stats:
+
+	rclone rc core/transfer id=123
+
+Completed transfers can be found until they are pruned, as with
+core/transferred.
+
+Parameters
+
+- id - id of the transfer (integer)
+- group - name of the stats group (string)
+
+Returns the values in the "transferring" entries of core/stats along
+with the following:
+` + "```" + `
+{
+	"checked": if the transfer is only checked (skipped, deleted),
+	"started_at": time the transfer started,
+	"completed_at": time the transfer completed or null if it is still running,
+	"error": string description of the error (empty if successful or still running),
+	"group": the stats group the transfer is in
+}
+` + "```" + `
+`,
+	})
+}
+
 func rcResetStats(ctx context.Context, in rc.Params) (rc.Params, error) {
 	// Check to see if we should filter by group.
 	group, err := in.GetString("group")
@@ -360,11 +448,20 @@ func (sg *statsGroups) sum(ctx context.Context) *StatsInfo {
 			sum.errors += stats.errors
 			sum.fatalError = sum.fatalError || stats.fatalError
 			sum.retryError = sum.retryError || stats.retryError
+			sum.fatalErrors += stats.fatalErrors
+			sum.retryErrors += stats.retryErrors
+			sum.noRetryErrors += stats.noRetryErrors
 			sum.checks += stats.checks
 			sum.transfers += stats.transfers
 			sum.deletes += stats.deletes
 			sum.deletedDirs += stats.deletedDirs
 			sum.renames += stats.renames
+			sum.verifies += stats.verifies
+			sum.verifyFailures += stats.verifyFailures
+			sum.stalls += stats.stalls
+			for key, rs := range stats.remotes {
+				sum._remote(key).add(rs)
+			}
 			sum.checking.merge(stats.checking)
 			sum.transferring.merge(stats.transferring)
 			sum.inProgress.merge(stats.inProgress)
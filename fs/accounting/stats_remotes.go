@@ -0,0 +1,138 @@
+package accounting
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/pingme998/rclone/fs"
+	"github.com/pingme998/rclone/fs/rc"
+)
+
+// remoteKey identifies a remote and the backend it uses
+type remoteKey struct {
+	name    string
+	backend string
+}
+
+// remoteStats is the breakdown of the stats for one remote
+type remoteStats struct {
+	bytes     int64
+	checks    int64
+	transfers int64
+	errors    int64
+}
+
+// add the counters in b to a
+func (a *remoteStats) add(b *remoteStats) {
+	a.bytes += b.bytes
+	a.checks += b.checks
+	a.transfers += b.transfers
+	a.errors += b.errors
+}
+
+// rcStats returns the stats for the rc
+func (a *remoteStats) rcStats() rc.Params {
+	return rc.Params{
+		"bytes":     a.bytes,
+		"checks":    a.checks,
+		"transfers": a.transfers,
+		"errors":    a.errors,
+	}
+}
+
+// backendCache caches the backend type of each remote name
+var backendCache sync.Map
+
+// newRemoteKey returns the remoteKey for the remote f is on
+func newRemoteKey(f fs.Info) remoteKey {
+	name := f.Name()
+	// Strip the suffix added to remotes with config overrides
+	if i := strings.IndexRune(name, '{'); i > 0 {
+		name = name[:i]
+	}
+	if backend, ok := backendCache.Load(name); ok {
+		return remoteKey{name: name, backend: backend.(string)}
+	}
+	backend := strings.TrimPrefix(name, ":")
+	if fsInfo, _, _, _, err := fs.ParseRemote(name + ":"); err == nil {
+		backend = fsInfo.Name
+	}
+	backendCache.Store(name, backend)
+	return remoteKey{name: name, backend: backend}
+}
+
+// _remote returns the stats for key making them if necessary
+//
+// Call with s.mu held
+func (s *StatsInfo) _remote(key remoteKey) *remoteStats {
+	if s.remotes == nil {
+		s.remotes = make(map[remoteKey]*remoteStats)
+	}
+	rs := s.remotes[key]
+	if rs == nil {
+		rs = new(remoteStats)
+		s.remotes[key] = rs
+	}
+	return rs
+}
+
+// remoteBytes accounts n bytes to each of the remotes
+func (s *StatsInfo) remoteBytes(remotes []remoteKey, n int64) {
+	if len(remotes) == 0 {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, key := range remotes {
+		s._remote(key).bytes += n
+	}
+}
+
+// remoteDone accounts a finished transfer or check to each of the
+// remotes
+func (s *StatsInfo) remoteDone(remotes []remoteKey, checking bool, err error) {
+	if len(remotes) == 0 {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, key := range remotes {
+		rs := s._remote(key)
+		switch {
+		case err != nil:
+			rs.errors++
+		case checking:
+			rs.checks++
+		default:
+			rs.transfers++
+		}
+	}
+}
+
+// _remotesStats returns the stats broken down by remote and by
+// backend for the rc or nil if there aren't any
+//
+// Call with s.mu held for reading
+func (s *StatsInfo) _remotesStats() (remotes, backends rc.Params) {
+	if len(s.remotes) == 0 {
+		return nil, nil
+	}
+	remotes = rc.Params{}
+	byBackend := map[string]*remoteStats{}
+	for key, rs := range s.remotes {
+		out := rs.rcStats()
+		out["backend"] = key.backend
+		remotes[key.name] = out
+		total := byBackend[key.backend]
+		if total == nil {
+			total = new(remoteStats)
+			byBackend[key.backend] = total
+		}
+		total.add(rs)
+	}
+	backends = rc.Params{}
+	for backend, rs := range byBackend {
+		backends[backend] = rs.rcStats()
+	}
+	return remotes, backends
+}
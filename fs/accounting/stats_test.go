@@ -1,15 +1,20 @@
 package accounting
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"testing"
 	"time"
 
 	"github.com/pkg/errors"
 	"github.com/pingme998/rclone/fs"
 	"github.com/pingme998/rclone/fs/fserrors"
+	"github.com/pingme998/rclone/fs/rc"
+	"github.com/pingme998/rclone/fstest/mockfs"
+	"github.com/pingme998/rclone/fstest/mockobject"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -118,8 +123,12 @@ func TestStatsError(t *testing.T) {
 	assert.True(t, s.HadRetryError())
 	assert.Equal(t, t1, s.RetryAfter())
 
+	s.Errors(1)
+	assert.Equal(t, map[string]int64{"fatal": 1, "retry": 3, "noRetry": 0, "other": 1}, s.ErrorsByClass())
+
 	s.ResetErrors()
 	assert.Equal(t, int64(0), s.GetErrors())
+	assert.Equal(t, map[string]int64{"fatal": 0, "retry": 0, "noRetry": 0, "other": 0}, s.ErrorsByClass())
 	assert.False(t, s.HadFatalError())
 	assert.False(t, s.HadRetryError())
 	assert.Equal(t, time.Time{}, s.RetryAfter())
@@ -131,6 +140,7 @@ func TestStatsError(t *testing.T) {
 	assert.False(t, s.HadFatalError())
 	assert.False(t, s.HadRetryError())
 	assert.Equal(t, time.Time{}, s.RetryAfter())
+	assert.Equal(t, int64(1), s.ErrorsByClass()["noRetry"])
 }
 
 func TestStatsTotalDuration(t *testing.T) {
@@ -436,3 +446,119 @@ func TestPruneTransfers(t *testing.T) {
 		})
 	}
 }
+
+func TestRemoteStatsBreakdown(t *testing.T) {
+	ctx := context.Background()
+	s := NewStats(ctx)
+	src := mockfs.NewFs(ctx, "src", "root")
+	dst := mockfs.NewFs(ctx, ":local", "root")
+
+	// copy 5 bytes from src to dst
+	obj := mockobject.New("file1").WithContent([]byte("hello"), mockobject.SeekModeNone)
+	obj.SetFs(src)
+	tr := s.NewTransfer(obj)
+	tr.AddRemote(dst)
+	tr.AddRemote(src) // ignored as already present
+	in := tr.Account(ctx, ioutil.NopCloser(bytes.NewBufferString("hello")))
+	_, err := ioutil.ReadAll(in)
+	require.NoError(t, err)
+	tr.Done(ctx, nil)
+
+	// failed check on src
+	tr = s.NewCheckingTransfer(obj)
+	tr.Done(ctx, errors.New("boom"))
+
+	// transfers without an Fs aren't broken down
+	tr = s.NewTransfer(mockobject.New("file2"))
+	tr.Done(ctx, nil)
+
+	out, err := s.RemoteStats()
+	require.NoError(t, err)
+	assert.Equal(t, rc.Params{
+		"src": rc.Params{
+			"backend":   "src",
+			"bytes":     int64(5),
+			"checks":    int64(0),
+			"transfers": int64(1),
+			"errors":    int64(1),
+		},
+		":local": rc.Params{
+			"backend":   "local",
+			"bytes":     int64(5),
+			"checks":    int64(0),
+			"transfers": int64(1),
+			"errors":    int64(0),
+		},
+	}, out["remotes"])
+	assert.Equal(t, rc.Params{
+		"src": rc.Params{
+			"bytes":     int64(5),
+			"checks":    int64(0),
+			"transfers": int64(1),
+			"errors":    int64(1),
+		},
+		"local": rc.Params{
+			"bytes":     int64(5),
+			"checks":    int64(0),
+			"transfers": int64(1),
+			"errors":    int64(0),
+		},
+	}, out["backends"])
+
+	s.ResetCounters()
+	out, err = s.RemoteStats()
+	require.NoError(t, err)
+	assert.Nil(t, out["remotes"])
+	assert.Nil(t, out["backends"])
+}
+
+func TestTransferRCStats(t *testing.T) {
+	ctx := context.Background()
+	s := NewStatsGroup(ctx, "test-transfer-rc")
+	defer groups.delete("test-transfer-rc")
+	src := mockfs.NewFs(ctx, "src", "root")
+	dst := mockfs.NewFs(ctx, "dst", "root")
+
+	obj := mockobject.New("file1").WithContent([]byte("hello"), mockobject.SeekModeNone)
+	obj.SetFs(src)
+	tr := s.NewTransfer(obj)
+	tr.AddRemote(dst)
+	tr.Retry()
+	tr.SetChunks(4)
+	tr.ChunkDone()
+	in := tr.Account(ctx, ioutil.NopCloser(bytes.NewBufferString("hello")))
+	_, err := ioutil.ReadAll(in)
+	require.NoError(t, err)
+
+	out, err := s.RemoteStats()
+	require.NoError(t, err)
+	transferring := out["transferring"].([]rc.Params)
+	require.Len(t, transferring, 1)
+	st := transferring[0]
+	assert.Equal(t, tr.ID(), st["id"])
+	assert.Equal(t, "file1", st["name"])
+	assert.Equal(t, int64(5), st["bytes"])
+	assert.Equal(t, 1, st["retries"])
+	assert.Equal(t, "src:root", st["srcFs"])
+	assert.Equal(t, "dst:root", st["dstFs"])
+	assert.Equal(t, 4, st["chunks"])
+	assert.Equal(t, 1, st["chunksDone"])
+
+	// Check the details of the transfer are found by id
+	call := rc.Calls.Get("core/transfer")
+	require.NotNil(t, call)
+	detail, err := call.Fn(ctx, rc.Params{"id": tr.ID(), "group": "test-transfer-rc"})
+	require.NoError(t, err)
+	assert.Equal(t, "dst:root", detail["dstFs"])
+	assert.Nil(t, detail["completed_at"])
+
+	tr.Done(ctx, errors.New("boom"))
+	detail, err = call.Fn(ctx, rc.Params{"id": tr.ID()})
+	require.NoError(t, err)
+	assert.Equal(t, "boom", detail["error"])
+	assert.NotNil(t, detail["completed_at"])
+	assert.Equal(t, int64(5), detail["bytes"])
+
+	_, err = call.Fn(ctx, rc.Params{"id": tr.ID() + 1000})
+	assert.Error(t, err)
+}
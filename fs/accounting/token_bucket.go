@@ -29,12 +29,14 @@ type buckets [TokenBucketSlots]*rate.Limiter
 
 // tokenBucket holds info about the rate limiters in use
 type tokenBucket struct {
-	mu          sync.RWMutex // protects the token bucket variables
-	curr        buckets
-	prev        buckets
-	toggledOff  bool
-	currLimitMu sync.Mutex // protects changes to the timeslot
-	currLimit   fs.BwTimeSlot
+	mu            sync.RWMutex // protects the token bucket variables
+	curr          buckets
+	prev          buckets
+	toggledOff    bool
+	currLimitMu   sync.Mutex // protects changes to the timeslot
+	currLimit     fs.BwTimeSlot
+	timetable     fs.BwTimetable // the timetable the ticker follows
+	tickerRunning bool           // set if the timetable ticker has been started
 }
 
 // Return true if limit is disabled
@@ -108,48 +110,89 @@ func (tb *tokenBucket) StartTokenTicker(ctx context.Context) {
 	if len(ci.BwLimit) <= 1 {
 		return
 	}
+	tb.startTicker(ci.BwLimit)
+}
+
+// startTicker sets the timetable the ticker follows and starts the
+// ticker if it isn't already running
+func (tb *tokenBucket) startTicker(timetable fs.BwTimetable) {
+	tb.currLimitMu.Lock()
+	defer tb.currLimitMu.Unlock()
+	tb.timetable = timetable
+	if tb.tickerRunning {
+		return
+	}
+	tb.tickerRunning = true
 
 	ticker := time.NewTicker(time.Minute)
 	go func() {
 		for range ticker.C {
-			limitNow := ci.BwLimit.LimitAt(time.Now())
 			tb.currLimitMu.Lock()
-
-			if tb.currLimit.Bandwidth != limitNow.Bandwidth {
-				tb.mu.Lock()
-
-				// If bwlimit is toggled off, the change should only
-				// become active on the next toggle, which causes
-				// an exchange of tb.curr <-> tb.prev
-				var targetBucket *buckets
-				if tb.toggledOff {
-					targetBucket = &tb.prev
-				} else {
-					targetBucket = &tb.curr
-				}
-
-				// Set new bandwidth. If unlimited, set tokenbucket to nil.
-				if limitNow.Bandwidth.IsSet() {
-					*targetBucket = newTokenBucket(limitNow.Bandwidth)
-					if tb.toggledOff {
-						fs.Logf(nil, "Scheduled bandwidth change. "+
-							"Limit will be set to %v Byte/s when toggled on again.", &limitNow.Bandwidth)
-					} else {
-						fs.Logf(nil, "Scheduled bandwidth change. Limit set to %v Byte/s", &limitNow.Bandwidth)
-					}
-				} else {
-					targetBucket._setOff()
-					fs.Logf(nil, "Scheduled bandwidth change. Bandwidth limits disabled")
-				}
-
-				tb.currLimit = limitNow
-				tb.mu.Unlock()
-			}
+			timetable := tb.timetable
 			tb.currLimitMu.Unlock()
+			tb.updateLimit(timetable.LimitAt(time.Now()), "Scheduled bandwidth change")
 		}
 	}()
 }
 
+// updateLimit sets the bandwidth limiter to limitNow if it has
+// changed, logging the change with reason
+func (tb *tokenBucket) updateLimit(limitNow fs.BwTimeSlot, reason string) {
+	tb.currLimitMu.Lock()
+	defer tb.currLimitMu.Unlock()
+
+	if tb.currLimit.Bandwidth == limitNow.Bandwidth {
+		return
+	}
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+
+	// If bwlimit is toggled off, the change should only
+	// become active on the next toggle, which causes
+	// an exchange of tb.curr <-> tb.prev
+	var targetBucket *buckets
+	if tb.toggledOff {
+		targetBucket = &tb.prev
+	} else {
+		targetBucket = &tb.curr
+	}
+
+	// Set new bandwidth. If unlimited, set tokenbucket to nil.
+	if limitNow.Bandwidth.IsSet() {
+		*targetBucket = newTokenBucket(limitNow.Bandwidth)
+		if tb.toggledOff {
+			fs.Logf(nil, "%s. "+
+				"Limit will be set to %v Byte/s when toggled on again.", reason, &limitNow.Bandwidth)
+		} else {
+			fs.Logf(nil, "%s. Limit set to %v Byte/s", reason, &limitNow.Bandwidth)
+		}
+	} else {
+		targetBucket._setOff()
+		fs.Logf(nil, "%s. Bandwidth limits disabled", reason)
+	}
+
+	tb.currLimit = limitNow
+}
+
+// Reload re-reads the bandwidth limit from the config in ctx, setting
+// the limit for the current time and starting the ticker if it is
+// now a timetable.
+func (tb *tokenBucket) Reload(ctx context.Context) {
+	tb.setTimetable(fs.GetConfig(ctx).BwLimit, "Reloaded bandwidth limit")
+}
+
+// setTimetable sets the limit for the current time from timetable,
+// logging the change with reason, and makes the ticker follow it.
+func (tb *tokenBucket) setTimetable(timetable fs.BwTimetable, reason string) {
+	tb.currLimitMu.Lock()
+	tb.timetable = timetable
+	tb.currLimitMu.Unlock()
+	tb.updateLimit(timetable.LimitAt(time.Now()), reason)
+	if len(timetable) > 1 {
+		tb.startTicker(timetable)
+	}
+}
+
 // LimitBandwidth sleeps for the correct amount of time for the passage
 // of n bytes according to the current bandwidth limit
 func (tb *tokenBucket) LimitBandwidth(i TokenBucketSlot, n int) {
@@ -166,6 +209,28 @@ func (tb *tokenBucket) LimitBandwidth(i TokenBucketSlot, n int) {
 	tb.mu.RUnlock()
 }
 
+// isLimited returns true if any of the bandwidth limits are set
+func (tb *tokenBucket) isLimited() bool {
+	tb.mu.RLock()
+	defer tb.mu.RUnlock()
+	for _, limiter := range tb.curr {
+		if limiter != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// IsBwLimited returns true if the transfers made with ctx have their
+// bandwidth limited by --bwlimit, --bwlimit-file or a per job limit
+func IsBwLimited(ctx context.Context) bool {
+	if TokenBucket.isLimited() || getBwLimiter(ctx) != nil {
+		return true
+	}
+	currLimit := fs.GetConfig(ctx).BwLimitFile.LimitAt(time.Now())
+	return currLimit.Bandwidth.IsSet()
+}
+
 // SetBwLimit sets the current bandwidth limit
 func (tb *tokenBucket) SetBwLimit(bandwidth fs.BwPair) {
 	tb.mu.Lock()
@@ -181,6 +246,21 @@ func (tb *tokenBucket) SetBwLimit(bandwidth fs.BwPair) {
 
 // read and set the bandwidth limits
 func (tb *tokenBucket) rcBwlimit(ctx context.Context, in rc.Params) (out rc.Params, err error) {
+	if in["rate"] != nil && in["schedule"] != nil {
+		return out, errors.New("can't set both rate and schedule")
+	}
+	if in["schedule"] != nil {
+		schedule, err := in.GetString("schedule")
+		if err != nil {
+			return out, err
+		}
+		var bws fs.BwTimetable
+		err = bws.Set(schedule)
+		if err != nil {
+			return out, errors.Wrap(err, "bad bwlimit schedule")
+		}
+		tb.setTimetable(bws, "Bandwidth schedule set by rc")
+	}
 	if in["rate"] != nil {
 		bwlimit, err := in.GetString("rate")
 		if err != nil {
@@ -216,6 +296,11 @@ func (tb *tokenBucket) rcBwlimit(ctx context.Context, in rc.Params) (out rc.Para
 		"bytesPerSecondTx": int64(bp.Tx),
 		"bytesPerSecondRx": int64(bp.Rx),
 	}
+	tb.currLimitMu.Lock()
+	if len(tb.timetable) > 1 {
+		out["schedule"] = tb.timetable.String()
+	}
+	tb.currLimitMu.Unlock()
 	return out, nil
 }
 
@@ -269,8 +354,93 @@ If the rate parameter is not supplied then the bandwidth is queried
 The format of the parameter is exactly the same as passed to --bwlimit
 except only one bandwidth may be specified.
 
+A rate set like this lasts until the next change in the bandwidth
+schedule, if there is one.
+
+To set a whole schedule pass it in the schedule parameter instead of
+rate, in exactly the same format as --bwlimit. This replaces the
+schedule from --bwlimit, if any, and sets the limit for the current
+time straight away.
+
+    rclone rc core/bwlimit schedule="Mon-00:00,512k Fri-19:00,10M Sun-23:00,512k"
+    {
+        "bytesPerSecond": 524288,
+        "bytesPerSecondTx": 524288,
+        "bytesPerSecondRx": 524288,
+        "rate": "512Ki",
+        "schedule": "Mon-00:00,512Ki Fri-19:00,10Mi Sun-23:00,512Ki"
+    }
+
+Setting a schedule with a single entry, e.g. schedule=off, removes
+the schedule.
+
 In either case "rate" is returned as a human readable string, and
-"bytesPerSecond" is returned as a number.
+"bytesPerSecond" is returned as a number. If a schedule is in use it
+is returned as "schedule".
+
+To limit the bandwidth of a single job, e.g. one of several syncs
+running in the same rclone rcd, pass BwLimit in its _config instead,
+e.g. "_config":{"BwLimit":"1M"}. This limits the transfers of the job
+to that rate, or schedule, in addition to the global limit, without
+affecting any other jobs.
 `,
 	})
 }
+
+// Type of key used to store the bandwidth limiter in the context
+type bwLimiterContextKeyType struct{}
+
+// Context key for the bandwidth limiter
+var bwLimiterContextKey = bwLimiterContextKeyType{}
+
+// bwLimiter limits the bandwidth of all the transfers made with a
+// context, e.g. those of one rc job, to a timetable of their own.
+type bwLimiter struct {
+	mu        sync.Mutex
+	timetable fs.BwTimetable
+	slot      fs.BwTimeSlot // the time slot limiter was made for
+	limiter   *rate.Limiter // nil if unlimited
+	checked   time.Time     // when the timetable was last checked
+}
+
+// WithBwLimit returns a copy of ctx in which all the transfers share
+// a bandwidth limit following timetable. This is in addition to the
+// global limit set by --bwlimit.
+//
+// As with --bwlimit-file the limit applies to the data transferred,
+// so an upload:download pair only limits if both are set, in which
+// case the larger is used.
+func WithBwLimit(ctx context.Context, timetable fs.BwTimetable) context.Context {
+	return context.WithValue(ctx, bwLimiterContextKey, &bwLimiter{timetable: timetable})
+}
+
+// getBwLimiter returns the bandwidth limiter in ctx or nil if there
+// isn't one
+func getBwLimiter(ctx context.Context) *bwLimiter {
+	l, _ := ctx.Value(bwLimiterContextKey).(*bwLimiter)
+	return l
+}
+
+// limitBandwidth sleeps for the correct amount of time for the
+// passage of n bytes, checking the timetable once a minute.
+func (l *bwLimiter) limitBandwidth(n int) {
+	l.mu.Lock()
+	now := time.Now()
+	if l.checked.IsZero() || now.Sub(l.checked) >= time.Minute {
+		slot := l.timetable.LimitAt(now)
+		if l.checked.IsZero() || slot.Bandwidth != l.slot.Bandwidth {
+			l.slot = slot
+			l.limiter = newTokenBucket(slot.Bandwidth)[TokenBucketSlotAccounting]
+		}
+		l.checked = now
+	}
+	limiter := l.limiter
+	l.mu.Unlock()
+
+	if limiter != nil {
+		err := limiter.WaitN(context.Background(), n)
+		if err != nil {
+			fs.Errorf(nil, "Token bucket error: %v", err)
+		}
+	}
+}
@@ -1,9 +1,12 @@
 package accounting
 
 import (
+	"bytes"
 	"context"
+	"io/ioutil"
 	"testing"
 
+	"github.com/pingme998/rclone/fs"
 	"github.com/pingme998/rclone/fs/rc"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -90,3 +93,111 @@ func TestRcBwLimit(t *testing.T) {
 	}, out)
 
 }
+
+func TestRcBwLimitSchedule(t *testing.T) {
+	call := rc.Calls.Get("core/bwlimit")
+	assert.NotNil(t, call)
+	defer TokenBucket.setTimetable(nil, "Test finished")
+
+	// Set a schedule
+	out, err := call.Fn(context.Background(), rc.Params{
+		"schedule": "Mon-00:00,512k Fri-19:00,10M Sun-23:00,512k",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Mon-00:00,512Ki Fri-19:00,10Mi Sun-23:00,512Ki", out["schedule"])
+
+	// The limit for now is set straight away
+	out, err = call.Fn(context.Background(), rc.Params{
+		"schedule": "Mon-00:00,1M Thu-00:00,1M",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, rc.Params{
+		"bytesPerSecond":   int64(1048576),
+		"bytesPerSecondTx": int64(1048576),
+		"bytesPerSecondRx": int64(1048576),
+		"rate":             "1Mi",
+		"schedule":         "Mon-00:00,1Mi Thu-00:00,1Mi",
+	}, out)
+	assert.Equal(t, rate.Limit(1048576), TokenBucket.curr[0].Limit())
+	assert.True(t, TokenBucket.tickerRunning)
+
+	// Query
+	out, err = call.Fn(context.Background(), rc.Params{})
+	require.NoError(t, err)
+	assert.Equal(t, "Mon-00:00,1Mi Thu-00:00,1Mi", out["schedule"])
+
+	// Can't set both
+	_, err = call.Fn(context.Background(), rc.Params{
+		"rate":     "1M",
+		"schedule": "1M",
+	})
+	assert.Error(t, err)
+	_, err = call.Fn(context.Background(), rc.Params{
+		"schedule": "potato",
+	})
+	assert.Error(t, err)
+
+	// Remove the schedule
+	out, err = call.Fn(context.Background(), rc.Params{
+		"schedule": "off",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, rc.Params{
+		"bytesPerSecond":   int64(-1),
+		"bytesPerSecondTx": int64(-1),
+		"bytesPerSecondRx": int64(-1),
+		"rate":             "off",
+	}, out)
+	assert.Nil(t, TokenBucket.curr[0])
+}
+
+func TestWithBwLimit(t *testing.T) {
+	ctx := context.Background()
+	assert.Nil(t, getBwLimiter(ctx))
+
+	var timetable fs.BwTimetable
+	require.NoError(t, timetable.Set("10M"))
+	ctx = WithBwLimit(ctx, timetable)
+	l := getBwLimiter(ctx)
+	require.NotNil(t, l)
+
+	// The limiter is made on first use
+	l.limitBandwidth(1)
+	require.NotNil(t, l.limiter)
+	assert.Equal(t, rate.Limit(10*1048576), l.limiter.Limit())
+
+	// and shared by the Accounts made with ctx
+	acc := newAccountSizeName(ctx, NewStats(ctx), ioutil.NopCloser(bytes.NewBuffer([]byte{1})), 1, "test")
+	defer acc.Done()
+	assert.Equal(t, l, acc.bwLimiter)
+
+	// An upload:download pair only limits if both are set
+	require.NoError(t, timetable.Set("10M:off"))
+	l = getBwLimiter(WithBwLimit(ctx, timetable))
+	l.limitBandwidth(1)
+	assert.Nil(t, l.limiter)
+}
+
+func TestTokenBucketReload(t *testing.T) {
+	var tb tokenBucket
+	ctx, ci := fs.AddConfig(context.Background())
+
+	// Set a limit
+	require.NoError(t, ci.BwLimit.Set("1M"))
+	tb.Reload(ctx)
+	require.NotNil(t, tb.curr[0])
+	assert.Equal(t, rate.Limit(1048576), tb.curr[0].Limit())
+	assert.False(t, tb.tickerRunning)
+
+	// Change it to a timetable which starts the ticker
+	require.NoError(t, ci.BwLimit.Set("00:00,2M 00:00,2M"))
+	tb.Reload(ctx)
+	require.NotNil(t, tb.curr[0])
+	assert.Equal(t, rate.Limit(2*1048576), tb.curr[0].Limit())
+	assert.True(t, tb.tickerRunning)
+
+	// Remove the limit
+	require.NoError(t, ci.BwLimit.Set("off"))
+	tb.Reload(ctx)
+	assert.Nil(t, tb.curr[0])
+}
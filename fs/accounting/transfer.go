@@ -5,9 +5,11 @@ import (
 	"encoding/json"
 	"io"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/pingme998/rclone/fs"
+	"github.com/pingme998/rclone/fs/hash"
 	"github.com/pingme998/rclone/fs/rc"
 )
 
@@ -40,12 +42,17 @@ func (as TransferSnapshot) MarshalJSON() ([]byte, error) {
 	})
 }
 
+// transferID is the ID of the last Transfer made - read and written
+// with atomic
+var transferID int64
+
 // Transfer keeps track of initiated transfers and provides access to
 // accounting functions.
 // Transfer needs to be closed on completion.
 type Transfer struct {
 	// these are initialised at creation and may be accessed without locking
 	stats     *StatsInfo
+	id        int64
 	remote    string
 	size      int64
 	startedAt time.Time
@@ -60,21 +67,34 @@ type Transfer struct {
 	acc         *Account
 	err         error
 	completedAt time.Time
+	action      string    // action for --log-results if set
+	hashType    hash.Type // hash for --log-results if set
+	hash        string
+	remotes     []remoteKey // remotes to account the transfer to
+	fsNames     []string    // the source and destination of the transfer, if known
+	retries     int         // number of low level retries
+	chunks      int         // number of chunks the transfer is split into, if it is
+	chunksDone  int         // number of chunks finished
 }
 
 // newCheckingTransfer instantiates new checking of the object.
 func newCheckingTransfer(stats *StatsInfo, obj fs.Object) *Transfer {
-	return newTransferRemoteSize(stats, obj.Remote(), obj.Size(), true)
+	tr := newTransferRemoteSize(stats, obj.Remote(), obj.Size(), true)
+	tr.AddRemote(obj.Fs())
+	return tr
 }
 
 // newTransfer instantiates new transfer.
 func newTransfer(stats *StatsInfo, obj fs.Object) *Transfer {
-	return newTransferRemoteSize(stats, obj.Remote(), obj.Size(), false)
+	tr := newTransferRemoteSize(stats, obj.Remote(), obj.Size(), false)
+	tr.AddRemote(obj.Fs())
+	return tr
 }
 
 func newTransferRemoteSize(stats *StatsInfo, remote string, size int64, checking bool) *Transfer {
 	tr := &Transfer{
 		stats:     stats,
+		id:        atomic.AddInt64(&transferID, 1),
 		remote:    remote,
 		size:      size,
 		startedAt: time.Now(),
@@ -97,10 +117,13 @@ func (tr *Transfer) Done(ctx context.Context, err error) {
 
 	tr.mu.RLock()
 	acc := tr.acc
+	remotes := tr.remotes
 	tr.mu.RUnlock()
 
 	ci := fs.GetConfig(ctx)
+	var bytes int64
 	if acc != nil {
+		bytes, _ = acc.progress()
 		// Close the file if it is still open
 		if err := acc.Close(); err != nil {
 			fs.LogLevelPrintf(ci.StatsLogLevel, nil, "can't close account: %+v\n", err)
@@ -115,6 +138,11 @@ func (tr *Transfer) Done(ctx context.Context, err error) {
 	tr.completedAt = time.Now()
 	tr.mu.Unlock()
 
+	if ci.LogResults != "" {
+		LogResult(ctx, tr.result(bytes))
+	}
+
+	tr.stats.remoteDone(remotes, tr.checking, err)
 	if tr.checking {
 		tr.stats.DoneChecking(tr.remote)
 	} else {
@@ -123,6 +151,112 @@ func (tr *Transfer) Done(ctx context.Context, err error) {
 	tr.stats.PruneTransfers()
 }
 
+// AddRemote adds the remote f is on to the remotes the transfer is
+// accounted to in the per remote stats. The transfer is accounted to
+// the remote of the object it was made from already.
+//
+// This should be called before Account.
+func (tr *Transfer) AddRemote(f fs.Info) {
+	if f == nil {
+		return
+	}
+	key := newRemoteKey(f)
+	fsName := f.String()
+	if fsys, ok := f.(fs.Fs); ok {
+		fsName = fs.ConfigString(fsys)
+	}
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	if len(tr.fsNames) < 2 && (len(tr.fsNames) == 0 || tr.fsNames[0] != fsName) {
+		tr.fsNames = append(tr.fsNames, fsName)
+	}
+	for _, remote := range tr.remotes {
+		if remote == key {
+			return
+		}
+	}
+	tr.remotes = append(tr.remotes, key)
+}
+
+// ID returns the ID of the transfer, which is unique while rclone is
+// running
+func (tr *Transfer) ID() int64 {
+	return tr.id
+}
+
+// Retry records that the transfer is being retried with a low level
+// retry
+func (tr *Transfer) Retry() {
+	tr.mu.Lock()
+	tr.retries++
+	tr.mu.Unlock()
+}
+
+// SetChunks sets the number of chunks the transfer is split into for
+// the progress reported to the rc
+func (tr *Transfer) SetChunks(chunks int) {
+	tr.mu.Lock()
+	tr.chunks = chunks
+	tr.chunksDone = 0
+	tr.mu.Unlock()
+}
+
+// ChunkDone records that a chunk of the transfer has finished
+func (tr *Transfer) ChunkDone() {
+	tr.mu.Lock()
+	tr.chunksDone++
+	tr.mu.Unlock()
+}
+
+// SetAction sets the action recorded in --log-results for this
+// transfer, e.g. "delete". By default it is "check" or "transfer".
+func (tr *Transfer) SetAction(action string) {
+	tr.mu.Lock()
+	tr.action = action
+	tr.mu.Unlock()
+}
+
+// SetHash sets the hash recorded in --log-results for this transfer
+func (tr *Transfer) SetHash(ht hash.Type, sum string) {
+	tr.mu.Lock()
+	tr.hashType, tr.hash = ht, sum
+	tr.mu.Unlock()
+}
+
+// result makes the --log-results record for the finished transfer
+// which read bytes
+func (tr *Transfer) result(bytes int64) *Result {
+	tr.mu.RLock()
+	defer tr.mu.RUnlock()
+	r := &Result{
+		Time:   tr.completedAt,
+		Action: tr.action,
+		Path:   tr.remote,
+		Size:   tr.size,
+		Bytes:  bytes,
+		Hash:   tr.hash,
+		Group:  tr.stats.group,
+	}
+	if r.Action == "" {
+		r.Action = "transfer"
+		if tr.checking {
+			r.Action = "check"
+		}
+	}
+	if tr.hashType != hash.None && tr.hash != "" {
+		r.HashType = tr.hashType.String()
+	}
+	if tr.err != nil {
+		r.Error = tr.err.Error()
+	}
+	duration := tr.completedAt.Sub(tr.startedAt)
+	r.Duration = duration.Seconds()
+	if duration > 0 {
+		r.Speed = float64(bytes) / r.Duration
+	}
+	return r
+}
+
 // Reset allows to switch the Account to another transfer method.
 func (tr *Transfer) Reset(ctx context.Context) {
 	tr.mu.RLock()
@@ -143,6 +277,7 @@ func (tr *Transfer) Account(ctx context.Context, in io.ReadCloser) *Account {
 	tr.mu.Lock()
 	if tr.acc == nil {
 		tr.acc = newAccountSizeName(ctx, tr.stats, in, tr.size, tr.remote)
+		tr.acc.remotes = tr.remotes
 	} else {
 		tr.acc.UpdateReader(ctx, in)
 	}
@@ -188,8 +323,55 @@ func (tr *Transfer) Snapshot() TransferSnapshot {
 
 // rcStats returns stats for the transfer suitable for the rc
 func (tr *Transfer) rcStats() rc.Params {
-	return rc.Params{
+	out := rc.Params{
 		"name": tr.remote, // no locking needed to access thess
 		"size": tr.size,
 	}
+	tr.addRCStats(out)
+	return out
+}
+
+// addRCStats adds the details of the transfer to the rc stats in out
+func (tr *Transfer) addRCStats(out rc.Params) {
+	tr.mu.RLock()
+	defer tr.mu.RUnlock()
+	out["id"] = tr.id
+	out["retries"] = tr.retries
+	if len(tr.fsNames) > 0 {
+		out["srcFs"] = tr.fsNames[0]
+	}
+	if len(tr.fsNames) > 1 {
+		out["dstFs"] = tr.fsNames[1]
+	}
+	if tr.chunks > 0 {
+		out["chunks"] = tr.chunks
+		out["chunksDone"] = tr.chunksDone
+	}
+}
+
+// rcDetail returns all the details of the transfer for the rc
+func (tr *Transfer) rcDetail() rc.Params {
+	tr.mu.RLock()
+	acc := tr.acc
+	tr.mu.RUnlock()
+	var out rc.Params
+	if acc != nil {
+		out = acc.rcStats()
+		tr.addRCStats(out)
+	} else {
+		out = tr.rcStats()
+	}
+	snapshot := tr.Snapshot()
+	out["checked"] = snapshot.Checked
+	out["started_at"] = snapshot.StartedAt
+	out["group"] = snapshot.Group
+	out["completed_at"] = nil
+	if !snapshot.CompletedAt.IsZero() {
+		out["completed_at"] = snapshot.CompletedAt
+	}
+	out["error"] = ""
+	if snapshot.Error != nil {
+		out["error"] = snapshot.Error.Error()
+	}
+	return out
 }
@@ -150,7 +150,9 @@ func (tm *transferMap) rcStats(progress *inProgress) (t []rc.Params) {
 	defer tm.mu.RUnlock()
 	for _, tr := range tm._sortedSlice() {
 		if acc := progress.get(tr.remote); acc != nil {
-			t = append(t, acc.rcStats())
+			out := acc.rcStats()
+			tr.addRCStats(out)
+			t = append(t, out)
 		} else {
 			t = append(t, tr.rcStats())
 		}
@@ -5,6 +5,7 @@ import (
 	"context"
 	"runtime"
 	"sync"
+	"time"
 
 	"github.com/pingme998/rclone/fs"
 	"github.com/pingme998/rclone/fs/filter"
@@ -16,6 +17,10 @@ var (
 	c     *cache.Cache
 	mu    sync.Mutex            // mutex to protect remap
 	remap = map[string]string{} // map user supplied names to canonical names
+
+	pinMu     sync.Mutex             // mutex to protect pins and removedFs
+	pins      = map[fs.Fs]int{}      // number of times each Fs is pinned
+	removedFs = map[fs.Fs]struct{}{} // pinned Fs removed from the cache to shut down when unpinned
 )
 
 // Create the cache just once
@@ -25,9 +30,53 @@ func createOnFirstUse() {
 		c = cache.New()
 		c.SetExpireDuration(ci.FsCacheExpireDuration)
 		c.SetExpireInterval(ci.FsCacheExpireInterval)
+		c.SetMaxEntries(ci.FsCacheMaxEntries)
+		c.SetFinalizer(removed)
 	})
 }
 
+// removed is called on the Fs removed from the cache by expiry or
+// eviction. It is shut down straight away unless it is still cached
+// under another name, which happens when an Fs pointing to a file is
+// cached under its parent's name too, or it is still pinned, in which
+// case it is shut down by Unpin when the last pin is released.
+func removed(key string, value interface{}) {
+	f, ok := value.(fs.Fs)
+	if !ok || f.Features().Shutdown == nil {
+		return
+	}
+	for _, entry := range c.List() {
+		if other, ok := entry.Value.(fs.Fs); ok && other == f {
+			return
+		}
+	}
+	pinMu.Lock()
+	pinned := pins[f] > 0
+	if pinned {
+		removedFs[f] = struct{}{}
+	}
+	pinMu.Unlock()
+	if pinned {
+		fs.Debugf(f, "fs cache: %q removed from the cache - will shut down when unpinned", key)
+		return
+	}
+	_ = shutdown(context.Background(), f)
+}
+
+// shutdown calls the Shutdown method of f if it has one
+func shutdown(ctx context.Context, f fs.Fs) error {
+	do := f.Features().Shutdown
+	if do == nil {
+		return nil
+	}
+	fs.Debugf(f, "fs cache: shutting down")
+	err := do(ctx)
+	if err != nil {
+		fs.Errorf(f, "fs cache: failed to shut down: %v", err)
+	}
+	return err
+}
+
 // Canonicalize looks up fsString in the mapping from user supplied
 // names to canonical names and return the canonical form
 func Canonicalize(fsString string) string {
@@ -92,9 +141,16 @@ func GetFn(ctx context.Context, fsString string, create func(ctx context.Context
 }
 
 // Pin f into the cache until Unpin is called
+//
+// Anything which uses an Fs for longer than the cache might keep it
+// should pin it, as an Fs removed from the cache is shut down once
+// it is no longer pinned.
 func Pin(f fs.Fs) {
 	createOnFirstUse()
 	c.Pin(fs.ConfigString(f))
+	pinMu.Lock()
+	pins[f]++
+	pinMu.Unlock()
 }
 
 // PinUntilFinalized pins f into the cache until x is garbage collected
@@ -110,9 +166,24 @@ func PinUntilFinalized(f fs.Fs, x interface{}) {
 }
 
 // Unpin f from the cache
+//
+// If this releases the last pin on an Fs which has been removed from
+// the cache then it is shut down.
 func Unpin(f fs.Fs) {
 	createOnFirstUse()
-	c.Pin(fs.ConfigString(f))
+	c.Unpin(fs.ConfigString(f))
+	pinMu.Lock()
+	pins[f]--
+	_, wasRemoved := removedFs[f]
+	unpinned := pins[f] <= 0
+	if unpinned {
+		delete(pins, f)
+		delete(removedFs, f)
+	}
+	pinMu.Unlock()
+	if unpinned && wasRemoved {
+		_ = shutdown(context.Background(), f)
+	}
 }
 
 // Get gets an fs.Fs named fsString either from the cache or creates it afresh
@@ -156,6 +227,14 @@ func ClearConfig(name string) (deleted int) {
 	return c.DeletePrefix(name + ":")
 }
 
+// Delete removes the Fs named fsString from the cache
+//
+// Returns true if it was found
+func Delete(fsString string) bool {
+	createOnFirstUse()
+	return c.Delete(Canonicalize(fsString))
+}
+
 // Clear removes everything from the cache
 func Clear() {
 	createOnFirstUse()
@@ -167,3 +246,61 @@ func Entries() int {
 	createOnFirstUse()
 	return c.Entries()
 }
+
+// Shutdown calls the Shutdown method of every Fs in the cache, or
+// removed from it while still pinned, which has one, returning the
+// first error
+//
+// It should only be called when rclone exits as the Fs may still be
+// in use until then.
+func Shutdown(ctx context.Context) (err error) {
+	createOnFirstUse()
+	pinMu.Lock()
+	fses := make([]fs.Fs, 0, len(removedFs))
+	for f := range removedFs {
+		fses = append(fses, f)
+	}
+	removedFs = map[fs.Fs]struct{}{}
+	pinMu.Unlock()
+	for _, entry := range c.List() {
+		if f, ok := entry.Value.(fs.Fs); ok {
+			fses = append(fses, f)
+		}
+	}
+	done := map[fs.Fs]struct{}{}
+	for _, f := range fses {
+		if _, found := done[f]; found {
+			continue
+		}
+		done[f] = struct{}{}
+		if shutdownErr := shutdown(ctx, f); shutdownErr != nil && err == nil {
+			err = shutdownErr
+		}
+	}
+	return err
+}
+
+// Entry describes an Fs in the cache as returned by List
+type Entry struct {
+	Name     string    // canonical name of the Fs
+	Fs       fs.Fs     // the Fs
+	IsFile   bool      // set if the Fs was created pointing at a file
+	LastUsed time.Time // time last used
+	PinCount int       // non zero if the Fs is pinned
+}
+
+// List returns the entries in the cache sorted by name
+func List() (entries []Entry) {
+	createOnFirstUse()
+	for _, info := range c.List() {
+		f, _ := info.Value.(fs.Fs)
+		entries = append(entries, Entry{
+			Name:     info.Key,
+			Fs:       f,
+			IsFile:   info.Err == fs.ErrorIsFile,
+			LastUsed: info.LastUsed,
+			PinCount: info.PinCount,
+		})
+	}
+	return entries
+}
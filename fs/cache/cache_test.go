@@ -209,3 +209,150 @@ func TestEntries(t *testing.T) {
 
 	assert.Equal(t, 1, Entries())
 }
+
+func TestDelete(t *testing.T) {
+	cleanup, create := mockNewFs(t)
+	defer cleanup()
+
+	_, err := GetFn(context.Background(), "mock:/", create)
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, Entries())
+	assert.False(t, Delete("mock:/notfound"))
+	assert.Equal(t, 1, Entries())
+	assert.True(t, Delete("mock:/"))
+	assert.Equal(t, 0, Entries())
+}
+
+func TestList(t *testing.T) {
+	cleanup, create := mockNewFs(t)
+	defer cleanup()
+
+	assert.Equal(t, 0, len(List()))
+
+	_, err := GetFn(context.Background(), "mock:/file.txt", create)
+	require.Equal(t, fs.ErrorIsFile, err)
+	called = 0
+	f, err := GetFn(context.Background(), "mock:/", create)
+	require.NoError(t, err)
+	Pin(f)
+
+	entries := List()
+	require.Equal(t, 2, len(entries))
+	assert.Equal(t, "mock:/", entries[0].Name)
+	assert.Equal(t, f, entries[0].Fs)
+	assert.False(t, entries[0].IsFile)
+	assert.Equal(t, 1, entries[0].PinCount)
+	assert.Equal(t, "mock:/file.txt", entries[1].Name)
+	assert.True(t, entries[1].IsFile)
+	assert.Equal(t, 0, entries[1].PinCount)
+
+	Unpin(f)
+	assert.Equal(t, 0, List()[0].PinCount)
+}
+
+// shutdownFs is a mock Fs which counts the calls to Shutdown
+type shutdownFs struct {
+	fs.Fs
+	features  *fs.Features
+	shutdowns int
+}
+
+func newShutdownFs(root string) *shutdownFs {
+	ctx := context.Background()
+	f := &shutdownFs{Fs: mockfs.NewFs(ctx, "mock", root)}
+	f.features = (&fs.Features{}).Fill(ctx, f)
+	return f
+}
+
+func (f *shutdownFs) Features() *fs.Features {
+	return f.features
+}
+
+func (f *shutdownFs) Shutdown(ctx context.Context) error {
+	f.shutdowns++
+	return nil
+}
+
+func TestMaxEntries(t *testing.T) {
+	createOnFirstUse()
+	c.SetMaxEntries(1)
+	defer func() {
+		c.SetMaxEntries(0)
+		Clear()
+	}()
+
+	f1 := newShutdownFs("/one")
+	Put("mock:/one", f1)
+	f2 := newShutdownFs("/two")
+	Put("mock:/two", f2)
+
+	// The evicted Fs is shut down straight away
+	assert.Equal(t, 1, Entries())
+	assert.Equal(t, 1, f1.shutdowns)
+	assert.Equal(t, 0, f2.shutdowns)
+
+	// Evicting an Fs which is still cached under another name
+	// doesn't shut it down
+	c.SetMaxEntries(2)
+	c.Put("mock:/other", f2)
+	c.SetMaxEntries(1)
+	f3 := newShutdownFs("/three")
+	Put("mock:/three", f3)
+	assert.Equal(t, 1, Entries())
+	assert.Equal(t, 1, f2.shutdowns)
+
+	// Clearing the cache doesn't shut anything down
+	Clear()
+	assert.Equal(t, 1, f1.shutdowns)
+	assert.Equal(t, 1, f2.shutdowns)
+	assert.Equal(t, 0, f3.shutdowns)
+}
+
+func TestRemovedWhilePinned(t *testing.T) {
+	createOnFirstUse()
+	c.SetMaxEntries(1)
+	defer func() {
+		c.SetMaxEntries(0)
+		Clear()
+	}()
+
+	// Cache f1 under a name other than its canonical one so
+	// pinning it doesn't stop it being evicted
+	f1 := newShutdownFs("/one")
+	c.Put("mock:/alias", f1)
+	Pin(f1)
+	Pin(f1)
+	f2 := newShutdownFs("/two")
+	Put("mock:/two", f2)
+	assert.Equal(t, 1, Entries())
+
+	// The evicted Fs is only shut down when the last pin goes
+	assert.Equal(t, 0, f1.shutdowns)
+	Unpin(f1)
+	assert.Equal(t, 0, f1.shutdowns)
+	Unpin(f1)
+	assert.Equal(t, 1, f1.shutdowns)
+
+	// and isn't shut down again on exit
+	require.NoError(t, Shutdown(context.Background()))
+	assert.Equal(t, 1, f1.shutdowns)
+	assert.Equal(t, 1, f2.shutdowns)
+}
+
+func TestShutdown(t *testing.T) {
+	defer Clear()
+
+	f1 := newShutdownFs("/one")
+	Put("mock:/one", f1)
+	c.Put("mock:/alias", f1)
+	f2 := newShutdownFs("/two")
+	Put("mock:/two", f2)
+
+	require.NoError(t, Shutdown(context.Background()))
+
+	// Each Fs is only shut down once
+	assert.Equal(t, 1, f1.shutdowns)
+	assert.Equal(t, 1, f2.shutdowns)
+	assert.Equal(t, 3, Entries())
+}
@@ -0,0 +1,70 @@
+package fs
+
+import (
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Config file keys which can be set in the section of any remote to
+// choose how its files are compared in a sync
+const (
+	ConfigCompareModifyWindow = "compare_modify_window"
+	ConfigCompareSizeOnly     = "compare_size_only"
+	ConfigCompareChecksum     = "compare_checksum"
+)
+
+// CompareOptions is how the files of a remote should be compared
+//
+// These are read from the config file section of the remote.
+type CompareOptions struct {
+	ModifyWindow time.Duration // minimum modify window - 0 if not set
+	SizeOnly     bool          // compare with size only
+	CheckSum     bool          // compare with checksum and size
+}
+
+// remember which bad config values have been warned about
+var compareWarned sync.Map
+
+// GetCompareOptions reads the comparison options for f from its
+// section of the config file
+//
+// Bad values are logged once and ignored.
+func GetCompareOptions(f Info) (opt CompareOptions) {
+	if f == nil {
+		return opt
+	}
+	name := f.Name()
+	get := func(key string) (string, bool) {
+		value, ok := ConfigFileGet(name, key)
+		return value, ok && value != ""
+	}
+	warn := func(key, value string, err error) {
+		if _, loaded := compareWarned.LoadOrStore(name+"\x00"+key+"\x00"+value, struct{}{}); !loaded {
+			Errorf(nil, "Ignoring bad %s = %q for remote %q: %v", key, value, name, err)
+		}
+	}
+	if value, ok := get(ConfigCompareModifyWindow); ok {
+		var window Duration
+		if err := window.Set(value); err != nil {
+			warn(ConfigCompareModifyWindow, value, err)
+		} else {
+			opt.ModifyWindow = time.Duration(window)
+		}
+	}
+	if value, ok := get(ConfigCompareSizeOnly); ok {
+		sizeOnly, err := strconv.ParseBool(value)
+		if err != nil {
+			warn(ConfigCompareSizeOnly, value, err)
+		}
+		opt.SizeOnly = sizeOnly
+	}
+	if value, ok := get(ConfigCompareChecksum); ok {
+		checkSum, err := strconv.ParseBool(value)
+		if err != nil {
+			warn(ConfigCompareChecksum, value, err)
+		}
+		opt.CheckSum = checkSum
+	}
+	return opt
+}
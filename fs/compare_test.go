@@ -0,0 +1,68 @@
+package fs
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pingme998/rclone/fs/hash"
+	"github.com/stretchr/testify/assert"
+)
+
+// compareInfo is an Info with a name and precision for testing
+type compareInfo struct {
+	name      string
+	precision time.Duration
+}
+
+func (f compareInfo) Name() string             { return f.name }
+func (f compareInfo) Root() string             { return "" }
+func (f compareInfo) String() string           { return f.name + ":" }
+func (f compareInfo) Precision() time.Duration { return f.precision }
+func (f compareInfo) Hashes() hash.Set         { return hash.Set(hash.None) }
+func (f compareInfo) Features() *Features      { return &Features{} }
+
+func TestGetCompareOptions(t *testing.T) {
+	oldConfigFileGet := ConfigFileGet
+	ConfigFileGet = func(section, key string) (string, bool) {
+		values := map[string]map[string]string{
+			"quirky": {
+				ConfigCompareModifyWindow: "2s",
+				ConfigCompareSizeOnly:     "true",
+				ConfigCompareChecksum:     "false",
+			},
+			"bad": {
+				ConfigCompareModifyWindow: "potato",
+				ConfigCompareSizeOnly:     "sausage",
+				ConfigCompareChecksum:     "true",
+			},
+			"empty": {
+				ConfigCompareModifyWindow: "",
+			},
+		}
+		value, ok := values[section][key]
+		return value, ok
+	}
+	defer func() {
+		ConfigFileGet = oldConfigFileGet
+	}()
+
+	assert.Equal(t, CompareOptions{}, GetCompareOptions(nil))
+	assert.Equal(t, CompareOptions{}, GetCompareOptions(compareInfo{name: "normal"}))
+	assert.Equal(t, CompareOptions{}, GetCompareOptions(compareInfo{name: "empty"}))
+	assert.Equal(t, CompareOptions{
+		ModifyWindow: 2 * time.Second,
+		SizeOnly:     true,
+	}, GetCompareOptions(compareInfo{name: "quirky"}))
+	assert.Equal(t, CompareOptions{
+		CheckSum: true,
+	}, GetCompareOptions(compareInfo{name: "bad"}))
+
+	// check the modify window of the remotes is used
+	ctx := context.Background()
+	normal := compareInfo{name: "normal", precision: time.Millisecond}
+	quirky := compareInfo{name: "quirky", precision: time.Millisecond}
+	assert.Equal(t, time.Millisecond, GetModifyWindow(ctx, normal))
+	assert.Equal(t, 2*time.Second, GetModifyWindow(ctx, normal, quirky))
+	assert.Equal(t, ModTimeNotSupported, GetModifyWindow(ctx, quirky, compareInfo{name: "normal", precision: ModTimeNotSupported}))
+}
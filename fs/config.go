@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/pkg/errors"
+	"github.com/pingme998/rclone/fs/fserrors"
 )
 
 // Global
@@ -45,10 +46,16 @@ var (
 // ConfigInfo is filesystem config options
 type ConfigInfo struct {
 	LogLevel               LogLevel
+	SubsystemLogLevels     SubsystemLogLevels
+	LogRepeatLimit         int
+	LogRepeatWindow        time.Duration
 	StatsLogLevel          LogLevel
 	UseJSONLog             bool
+	LogResults             string
 	DryRun                 bool
+	DryRunOutput           string
 	Interactive            bool
+	InteractiveLog         string
 	CheckSum               bool
 	SizeOnly               bool
 	IgnoreTimes            bool
@@ -57,6 +64,7 @@ type ConfigInfo struct {
 	ModifyWindow           time.Duration
 	Checkers               int
 	Transfers              int
+	TransfersAuto          bool          // tune the number of transfers and checkers while running
 	ConnectTimeout         time.Duration // Connect timeout
 	Timeout                time.Duration // Data channel timeout
 	ExpectContinueTimeout  time.Duration
@@ -72,6 +80,7 @@ type ConfigInfo struct {
 	MaxDepth               int
 	IgnoreSize             bool
 	IgnoreChecksum         bool
+	VerifyUploads          bool
 	IgnoreCaseSync         bool
 	NoTraverse             bool
 	CheckFirst             bool
@@ -84,6 +93,8 @@ type ConfigInfo struct {
 	BackupDir              string
 	Suffix                 string
 	SuffixKeepExtension    bool
+	SuffixKeep             int
+	DeleteDelayedTo        string
 	UseListR               bool
 	BufferSize             SizeSuffix
 	BwLimit                BwTimetable
@@ -99,10 +110,15 @@ type ConfigInfo struct {
 	StatsFileNameLength    int
 	AskPassword            bool
 	PasswordCommand        SpaceSepList
+	PasswordStore          string
 	UseServerModTime       bool
 	MaxTransfer            SizeSuffix
 	MaxDuration            time.Duration
 	CutoffMode             CutoffMode
+	MinTransferRate        SizeSuffix
+	MinTransferRateTime    time.Duration
+	MaxTransferTime        time.Duration
+	RetryRules             []fserrors.RetryRule
 	MaxBacklog             int
 	MaxStatsGroups         int
 	StatsOneLine           bool
@@ -119,15 +135,20 @@ type ConfigInfo struct {
 	MultiThreadCutoff      SizeSuffix
 	MultiThreadStreams     int
 	MultiThreadSet         bool   // whether MultiThreadStreams was set (set in fs/config/configflags)
+	Inplace                bool   // upload directly to the destination file rather than a partial file
+	PartialSuffix          string // suffix for partial files which are renamed when the upload completes
 	OrderBy                string // instructions on how to order the transfer
 	UploadHeaders          []*HTTPOption
 	DownloadHeaders        []*HTTPOption
 	Headers                []*HTTPOption
+	Tags                   map[string]string // user defined tags to set on uploaded objects
 	RefreshTimes           bool
 	NoConsole              bool
 	TrafficClass           uint8
 	FsCacheExpireDuration  time.Duration
 	FsCacheExpireInterval  time.Duration
+	FsCacheMaxEntries      int
+	ShutdownGracePeriod    time.Duration
 	DisableHTTP2           bool
 }
 
@@ -140,6 +161,8 @@ func NewConfig() *ConfigInfo {
 	// Set any values which aren't the zero for the type
 	c.LogLevel = LogLevelNotice
 	c.StatsLogLevel = LogLevelInfo
+	c.LogRepeatWindow = time.Minute
+	c.DryRunOutput = "text"
 	c.ModifyWindow = time.Nanosecond
 	c.Checkers = 8
 	c.Transfers = 4
@@ -159,6 +182,7 @@ func NewConfig() *ConfigInfo {
 	c.AskPassword = true
 	c.TPSLimitBurst = 1
 	c.MaxTransfer = -1
+	c.MinTransferRateTime = 60 * time.Second
 	c.MaxBacklog = 10000
 	// We do not want to set the default here. We use this variable being empty as part of the fall-through of options.
 	//	c.StatsOneLineDateFormat = "2006/01/02 15:04:05 - "
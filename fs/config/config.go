@@ -312,13 +312,26 @@ func GetConfigPath() string {
 	return configPath
 }
 
+// remoteConfigPathRe matches config paths which are URLs
+var remoteConfigPathRe = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9+.-]+://`)
+
+// IsRemoteConfigPath returns true if path is a URL pointing to config
+// stored remotely rather than a local file
+func IsRemoteConfigPath(path string) bool {
+	return remoteConfigPathRe.MatchString(path)
+}
+
 // SetConfigPath sets new config file path
 //
 // Checks for empty string, os null device, or special path, all of which indicates in-memory config.
+//
+// URLs, eg https://example.com/rclone.conf, are used as is.
 func SetConfigPath(path string) (err error) {
 	var cfgPath string
 	if path == "" || path == os.DevNull {
 		cfgPath = ""
+	} else if IsRemoteConfigPath(path) {
+		cfgPath = path
 	} else if filepath.Base(path) == noConfigFile {
 		cfgPath = ""
 	} else if err = file.IsReserved(path); err != nil {
@@ -346,7 +359,9 @@ func LoadedData() Storage {
 	if !dataLoaded {
 		// Set RCLONE_CONFIG_DIR for backend config and subprocesses
 		// If empty configPath (in-memory only) the value will be "."
-		_ = os.Setenv("RCLONE_CONFIG_DIR", filepath.Dir(configPath))
+		if !IsRemoteConfigPath(configPath) {
+			_ = os.Setenv("RCLONE_CONFIG_DIR", filepath.Dir(configPath))
+		}
 		// Load configuration from file (or initialize sensible default if no file or error)
 		if err := data.Load(); err == nil {
 			fs.Debugf(nil, "Using config file from %q", configPath)
@@ -626,10 +641,48 @@ func FileDeleteKey(section, key string) bool {
 
 var matchEnv = regexp.MustCompile(`^RCLONE_CONFIG_(.*?)_TYPE=.*$`)
 
-// FileSections returns the sections in the config file
+// FilterProfilePrefix is the prefix of the config file sections
+// which hold named filter profiles rather than remotes. It contains a
+// ":" so it can never clash with a remote name.
+const FilterProfilePrefix = "filter-profile:"
+
+// ProfilePrefix is the prefix of the config file sections which
+// hold named option profiles selected with --profile.
+const ProfilePrefix = "profile:"
+
+// CommandPrefix is the prefix of the config file sections which hold
+// the default options for a command, eg "command:sync".
+const CommandPrefix = "command:"
+
+// TaskPrefix is the prefix of the config file sections which hold the
+// tasks run on a schedule by rclone rcd, eg "task:nightly".
+const TaskPrefix = "task:"
+
+// IsRemoteSection returns true if section in the config file
+// describes a remote rather than, say, a filter profile.
+func IsRemoteSection(section string) bool {
+	return !strings.HasPrefix(section, FilterProfilePrefix) &&
+		!strings.HasPrefix(section, ProfilePrefix) &&
+		!strings.HasPrefix(section, CommandPrefix) &&
+		!strings.HasPrefix(section, TaskPrefix)
+}
+
+// remoteSections returns the sections in the config file which
+// describe remotes
+func remoteSections() (sections []string) {
+	sections = []string{}
+	for _, section := range LoadedData().GetSectionList() {
+		if IsRemoteSection(section) {
+			sections = append(sections, section)
+		}
+	}
+	return sections
+}
+
+// FileSections returns the remote sections in the config file
 // including any defined by environment variables.
 func FileSections() []string {
-	sections := LoadedData().GetSectionList()
+	sections := remoteSections()
 	for _, item := range os.Environ() {
 		matches := matchEnv.FindStringSubmatch(item)
 		if len(matches) == 2 {
@@ -4,8 +4,6 @@ package configfile
 import (
 	"bytes"
 	"io/ioutil"
-	"os"
-	"path/filepath"
 	"sync"
 
 	"github.com/Unknwon/goconfig"
@@ -21,23 +19,59 @@ func Install() {
 
 // Storage implements config.Storage for saving and loading config
 // data in a simple INI based file.
+//
+// The file is read from and written to a Location, which is a local
+// file unless the config path is a URL.
 type Storage struct {
-	gc *goconfig.ConfigFile // config file loaded - thread safe
-	mu sync.Mutex           // to protect the following variables
-	fi os.FileInfo          // stat of the file when last loaded
+	gc      *goconfig.ConfigFile // config file loaded - thread safe
+	mu      sync.Mutex           // to protect the following variables
+	loc     Location             // where the config is stored
+	locPath string               // config path loc was made from
+	version string               // version of the config when last loaded or saved
+	base    []byte               // config as last loaded or saved for merging changes
+}
+
+// maxMergeTries is the number of times Save merges the changes in
+// the stored config before giving up
+const maxMergeTries = 5
+
+// _location returns the Location for the current config path or nil
+// if the config is memory only
+//
+// mu must be held when calling this
+func (s *Storage) _location() (Location, error) {
+	configPath := config.GetConfigPath()
+	if configPath == "" {
+		return nil, nil
+	}
+	if s.loc == nil || s.locPath != configPath {
+		loc, err := newLocation(configPath)
+		if err != nil {
+			return nil, err
+		}
+		s.loc, s.locPath, s.version = loc, configPath, ""
+	}
+	return s.loc, nil
 }
 
 // Check to see if we need to reload the config
+//
+// Only local config files are checked - config stored remotely is
+// reloaded when it is saved.
 func (s *Storage) check() {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	if configPath := config.GetConfigPath(); configPath != "" {
+	loc, err := s._location()
+	if err != nil {
+		return
+	}
+	if fileLoc, ok := loc.(*fileLocation); ok {
 		// Check to see if config file has changed since it was last loaded
-		fi, err := os.Stat(configPath)
+		version, err := fileLoc.version()
 		if err == nil {
 			// check to see if config file has changed and if it has, reload it
-			if s.fi == nil || !fi.ModTime().Equal(s.fi.ModTime()) || fi.Size() != s.fi.Size() {
+			if s.gc == nil || version != s.version {
 				fs.Debugf(nil, "Config file has changed externaly - reloading")
 				err := s._load()
 				if err != nil {
@@ -59,33 +93,38 @@ func (s *Storage) _load() (err error) {
 		}
 	}()
 
-	configPath := config.GetConfigPath()
-	if configPath == "" {
+	loc, err := s._location()
+	if err != nil {
+		return err
+	}
+	if loc == nil {
 		return config.ErrorConfigFileNotFound
 	}
 
-	fd, err := os.Open(configPath)
-	if err != nil {
-		if os.IsNotExist(err) {
-			return config.ErrorConfigFileNotFound
-		}
+	data, version, err := loc.Read()
+	if err == config.ErrorConfigFileNotFound {
+		s.version, s.base = "", nil
+		return err
+	} else if err != nil {
 		return err
 	}
-	defer fs.CheckClose(fd, &err)
-
-	// Update s.fi with the current file info
-	s.fi, _ = os.Stat(configPath)
 
-	cryptReader, err := config.Decrypt(fd)
+	cryptReader, err := config.Decrypt(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	base, err := ioutil.ReadAll(cryptReader)
 	if err != nil {
 		return err
 	}
 
-	gc, err := goconfig.LoadFromReader(cryptReader)
+	gc, err := goconfig.LoadFromReader(bytes.NewReader(base))
 	if err != nil {
 		return err
 	}
 	s.gc = gc
+	s.version = version
+	s.base = base
 
 	return nil
 }
@@ -97,77 +136,93 @@ func (s *Storage) Load() (err error) {
 	return s._load()
 }
 
-// Save the config to permanent storage, encrypting if necessary
-func (s *Storage) Save() error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	configPath := config.GetConfigPath()
-	if configPath == "" {
-		return errors.Errorf("Failed to save config file: Path is empty")
-	}
-
-	dir, name := filepath.Split(configPath)
-	err := os.MkdirAll(dir, os.ModePerm)
-	if err != nil {
-		return errors.Wrap(err, "failed to create config directory")
-	}
-	f, err := ioutil.TempFile(dir, name)
+// _merge reloads the stored config and applies the changes made to
+// it since it was last loaded or saved
+//
+// mu must be held when calling this
+func (s *Storage) _merge() error {
+	local := s.gc
+	base, err := goconfig.LoadFromReader(bytes.NewReader(s.base))
 	if err != nil {
-		return errors.Errorf("Failed to create temp file for new config: %v", err)
-	}
-	defer func() {
-		_ = f.Close()
-		if err := os.Remove(f.Name()); err != nil && !os.IsNotExist(err) {
-			fs.Errorf(nil, "Failed to remove temp config file: %v", err)
-		}
-	}()
-
-	var buf bytes.Buffer
-	if err := goconfig.SaveConfigData(s.gc, &buf); err != nil {
-		return errors.Errorf("Failed to save config file: %v", err)
+		return err
 	}
-
-	if err := config.Encrypt(&buf, f); err != nil {
+	err = s._load()
+	if err == config.ErrorConfigFileNotFound {
+		s.gc, _ = goconfig.LoadFromReader(bytes.NewReader([]byte{}))
+	} else if err != nil {
+		s.gc = local
 		return err
 	}
+	mergeConfig(base, local, s.gc)
+	return nil
+}
 
-	_ = f.Sync()
-	err = f.Close()
-	if err != nil {
-		return errors.Errorf("Failed to close config file: %v", err)
+// mergeConfig applies the changes between base and local to remote
+func mergeConfig(base, local, remote *goconfig.ConfigFile) {
+	for _, section := range base.GetSectionList() {
+		if _, err := local.GetSection(section); err != nil {
+			remote.DeleteSection(section)
+			continue
+		}
+		for _, key := range base.GetKeyList(section) {
+			if _, err := local.GetValue(section, key); err != nil {
+				remote.DeleteKey(section, key)
+			}
+		}
 	}
-
-	var fileMode os.FileMode = 0600
-	info, err := os.Stat(configPath)
-	if err != nil {
-		fs.Debugf(nil, "Using default permissions for config file: %v", fileMode)
-	} else if info.Mode() != fileMode {
-		fs.Debugf(nil, "Keeping previous permissions for config file: %v", info.Mode())
-		fileMode = info.Mode()
+	for _, section := range local.GetSectionList() {
+		for _, key := range local.GetKeyList(section) {
+			value, _ := local.GetValue(section, key)
+			if baseValue, err := base.GetValue(section, key); err != nil || baseValue != value {
+				remote.SetValue(section, key, value)
+			}
+		}
 	}
+}
 
-	attemptCopyGroup(configPath, f.Name())
+// Save the config to permanent storage, encrypting if necessary
+//
+// If the stored config was changed since it was loaded the changes
+// made here are merged into it before it is saved.
+func (s *Storage) Save() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-	err = os.Chmod(f.Name(), fileMode)
+	loc, err := s._location()
 	if err != nil {
-		fs.Errorf(nil, "Failed to set permissions on config file: %v", err)
-	}
-
-	if err = os.Rename(configPath, configPath+".old"); err != nil && !os.IsNotExist(err) {
-		return errors.Errorf("Failed to move previous config to backup location: %v", err)
-	}
-	if err = os.Rename(f.Name(), configPath); err != nil {
-		return errors.Errorf("Failed to move newly written config from %s to final location: %v", f.Name(), err)
+		return err
 	}
-	if err := os.Remove(configPath + ".old"); err != nil && !os.IsNotExist(err) {
-		fs.Errorf(nil, "Failed to remove backup config file: %v", err)
+	if loc == nil {
+		return errors.Errorf("Failed to save config file: Path is empty")
 	}
 
-	// Update s.fi with the newly written file
-	s.fi, _ = os.Stat(configPath)
+	for tries := 1; ; tries++ {
+		var buf bytes.Buffer
+		if err := goconfig.SaveConfigData(s.gc, &buf); err != nil {
+			return errors.Errorf("Failed to save config file: %v", err)
+		}
+		base := buf.Bytes()
 
-	return nil
+		var out bytes.Buffer
+		if err := config.Encrypt(&buf, &out); err != nil {
+			return err
+		}
+
+		version, err := loc.Write(out.Bytes(), s.version)
+		if err == nil {
+			s.version = version
+			s.base = base
+			return nil
+		}
+		if err != ErrVersionConflict || tries >= maxMergeTries {
+			return err
+		}
+		fs.Infof(nil, "Config at %s was changed by someone else - merging changes", loc)
+		err = s._merge()
+		if err != nil {
+			return errors.Wrap(err, "failed to merge config changes")
+		}
+	}
 }
 
 // Serialize the config into a string
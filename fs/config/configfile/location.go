@@ -0,0 +1,271 @@
+package configfile
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/pingme998/rclone/fs"
+	"github.com/pingme998/rclone/fs/config"
+)
+
+// ErrVersionConflict is returned by Location.Write if the config
+// was changed since the version passed in was read.
+var ErrVersionConflict = errors.New("config was changed by someone else")
+
+// Location is where the config file data is stored.
+//
+// The data passed in and out is the config file as stored, so it
+// will be encrypted if the config is encrypted.
+type Location interface {
+	// String returns a description of the location for logging
+	String() string
+
+	// Read the config data returning it with an opaque version
+	// string which changes whenever the data changes.
+	//
+	// It should return config.ErrorConfigFileNotFound if there
+	// isn't any config stored yet.
+	Read() (data []byte, version string, err error)
+
+	// Write the config data returning the new version.
+	//
+	// version is the version the data was based on, or "" if
+	// there wasn't any config stored. Locations which can should
+	// only write the data if the stored version still matches
+	// and return ErrVersionConflict if it doesn't.
+	Write(data []byte, version string) (newVersion string, err error)
+}
+
+// NewLocationFn makes a Location for the config stored at the URL
+type NewLocationFn func(u *url.URL) (Location, error)
+
+var (
+	locationsMu sync.Mutex
+	locations   = map[string]NewLocationFn{}
+)
+
+// RegisterLocation registers newLocation to read and write config
+// for URLs with the given scheme, eg "https".
+//
+// The config is then used with --config scheme://...
+func RegisterLocation(scheme string, newLocation NewLocationFn) {
+	locationsMu.Lock()
+	defer locationsMu.Unlock()
+	locations[strings.ToLower(scheme)] = newLocation
+}
+
+// Schemes returns the sorted URL schemes config can be stored at
+func Schemes() (schemes []string) {
+	locationsMu.Lock()
+	defer locationsMu.Unlock()
+	for scheme := range locations {
+		schemes = append(schemes, scheme)
+	}
+	sort.Strings(schemes)
+	return schemes
+}
+
+// newLocation returns the Location for configPath
+//
+// Local paths are read and written directly. Remote locations are
+// wrapped so the config is cached locally.
+func newLocation(configPath string) (Location, error) {
+	if !config.IsRemoteConfigPath(configPath) {
+		return &fileLocation{path: configPath}, nil
+	}
+	u, err := url.Parse(configPath)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse config URL")
+	}
+	locationsMu.Lock()
+	newLocation := locations[strings.ToLower(u.Scheme)]
+	locationsMu.Unlock()
+	if newLocation == nil {
+		return nil, errors.Errorf("can't store config at %q URLs - must be one of %s", u.Scheme, strings.Join(Schemes(), ", "))
+	}
+	loc, err := newLocation(u)
+	if err != nil {
+		return nil, err
+	}
+	return newCachedLocation(loc, configPath), nil
+}
+
+// redactURL returns u as a string with any secrets removed
+func redactURL(u *url.URL) string {
+	redacted := *u
+	if redacted.User != nil {
+		if _, hasPassword := redacted.User.Password(); hasPassword {
+			redacted.User = url.UserPassword(redacted.User.Username(), "xxxxx")
+		}
+	}
+	query := redacted.Query()
+	if query.Get("token") != "" {
+		query.Set("token", "xxxxx")
+		redacted.RawQuery = query.Encode()
+	}
+	return redacted.String()
+}
+
+// fileLocation stores the config in a local file
+type fileLocation struct {
+	path string
+}
+
+// String returns a description of the location for logging
+func (l *fileLocation) String() string {
+	return l.path
+}
+
+// version returns the version of the config file from its stat
+func (l *fileLocation) version() (string, error) {
+	fi, err := os.Stat(l.path)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%d/%d", fi.ModTime().UnixNano(), fi.Size()), nil
+}
+
+// Read the config data returning it with its version
+func (l *fileLocation) Read() (data []byte, version string, err error) {
+	data, err = ioutil.ReadFile(l.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, "", config.ErrorConfigFileNotFound
+		}
+		return nil, "", err
+	}
+	version, _ = l.version()
+	return data, version, nil
+}
+
+// Write the config data returning the new version
+//
+// This doesn't check the version, the last writer wins as it always
+// has for local config files.
+func (l *fileLocation) Write(data []byte, version string) (newVersion string, err error) {
+	configPath := l.path
+	dir, name := filepath.Split(configPath)
+	err = os.MkdirAll(dir, os.ModePerm)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to create config directory")
+	}
+	f, err := ioutil.TempFile(dir, name)
+	if err != nil {
+		return "", errors.Errorf("Failed to create temp file for new config: %v", err)
+	}
+	defer func() {
+		_ = f.Close()
+		if err := os.Remove(f.Name()); err != nil && !os.IsNotExist(err) {
+			fs.Errorf(nil, "Failed to remove temp config file: %v", err)
+		}
+	}()
+
+	if _, err := f.Write(data); err != nil {
+		return "", errors.Errorf("Failed to write config file: %v", err)
+	}
+
+	_ = f.Sync()
+	err = f.Close()
+	if err != nil {
+		return "", errors.Errorf("Failed to close config file: %v", err)
+	}
+
+	var fileMode os.FileMode = 0600
+	info, err := os.Stat(configPath)
+	if err != nil {
+		fs.Debugf(nil, "Using default permissions for config file: %v", fileMode)
+	} else if info.Mode() != fileMode {
+		fs.Debugf(nil, "Keeping previous permissions for config file: %v", info.Mode())
+		fileMode = info.Mode()
+	}
+
+	attemptCopyGroup(configPath, f.Name())
+
+	err = os.Chmod(f.Name(), fileMode)
+	if err != nil {
+		fs.Errorf(nil, "Failed to set permissions on config file: %v", err)
+	}
+
+	if err = os.Rename(configPath, configPath+".old"); err != nil && !os.IsNotExist(err) {
+		return "", errors.Errorf("Failed to move previous config to backup location: %v", err)
+	}
+	if err = os.Rename(f.Name(), configPath); err != nil {
+		return "", errors.Errorf("Failed to move newly written config from %s to final location: %v", f.Name(), err)
+	}
+	if err := os.Remove(configPath + ".old"); err != nil && !os.IsNotExist(err) {
+		fs.Errorf(nil, "Failed to remove backup config file: %v", err)
+	}
+
+	newVersion, _ = l.version()
+	return newVersion, nil
+}
+
+// cachedLocation keeps a local copy of the config stored in a remote
+// Location so rclone can still start if the remote is unavailable.
+type cachedLocation struct {
+	Location
+	path string // path of the cached config - the version is in path+".version"
+}
+
+// newCachedLocation wraps loc caching its data under the cache
+// directory in a file named after configPath
+func newCachedLocation(loc Location, configPath string) *cachedLocation {
+	hash := md5.Sum([]byte(configPath))
+	return &cachedLocation{
+		Location: loc,
+		path:     filepath.Join(config.CacheDir, "config", hex.EncodeToString(hash[:])+".conf"),
+	}
+}
+
+// save the data and version to the cache
+func (l *cachedLocation) save(data []byte, version string) {
+	err := os.MkdirAll(filepath.Dir(l.path), 0700)
+	if err == nil {
+		err = ioutil.WriteFile(l.path, data, 0600)
+	}
+	if err == nil {
+		err = ioutil.WriteFile(l.path+".version", []byte(version), 0600)
+	}
+	if err != nil {
+		fs.Errorf(nil, "Failed to cache config from %s: %v", l.Location, err)
+	}
+}
+
+// Read the config data from the remote falling back to the cached
+// copy if that fails.
+func (l *cachedLocation) Read() (data []byte, version string, err error) {
+	data, version, err = l.Location.Read()
+	if err == nil {
+		l.save(data, version)
+		return data, version, nil
+	}
+	if err == config.ErrorConfigFileNotFound {
+		return nil, "", err
+	}
+	cachedData, cacheErr := ioutil.ReadFile(l.path)
+	if cacheErr != nil {
+		return nil, "", errors.Wrapf(err, "failed to read config from %s", l.Location)
+	}
+	cachedVersion, _ := ioutil.ReadFile(l.path + ".version")
+	fs.Errorf(nil, "Failed to read config from %s - using cached copy: %v", l.Location, err)
+	return cachedData, string(cachedVersion), nil
+}
+
+// Write the config data to the remote updating the cache
+func (l *cachedLocation) Write(data []byte, version string) (newVersion string, err error) {
+	newVersion, err = l.Location.Write(data, version)
+	if err != nil {
+		return "", err
+	}
+	l.save(data, newVersion)
+	return newVersion, nil
+}
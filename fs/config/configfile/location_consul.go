@@ -0,0 +1,151 @@
+package configfile
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/pingme998/rclone/fs/config"
+	"github.com/pingme998/rclone/fs/fshttp"
+)
+
+func init() {
+	RegisterLocation("consul", newConsulLocation)
+	RegisterLocation("consul+https", newConsulLocation)
+}
+
+// consulLocation stores the config in a key in the Consul KV store.
+//
+// The ModifyIndex of the key is used as the version with a check
+// and set to stop concurrent writes overwriting each other.
+type consulLocation struct {
+	url      *url.URL
+	endpoint string // base URL of the API
+	key      string
+	token    string
+	client   *http.Client
+}
+
+// newConsulLocation makes a Location for the config at a URL like
+// consul://host:8500/path/to/key?token=XXX
+//
+// The token may also be supplied with CONSUL_HTTP_TOKEN
+func newConsulLocation(u *url.URL) (Location, error) {
+	key := strings.Trim(u.Path, "/")
+	if key == "" {
+		return nil, errors.New("consul config URL needs a key, eg consul://localhost:8500/rclone/config")
+	}
+	scheme := "http"
+	if strings.HasSuffix(u.Scheme, "+https") {
+		scheme = "https"
+	}
+	token := u.Query().Get("token")
+	if token == "" {
+		token = os.Getenv("CONSUL_HTTP_TOKEN")
+	}
+	return &consulLocation{
+		url:      u,
+		endpoint: scheme + "://" + u.Host + "/v1/",
+		key:      key,
+		token:    token,
+		client:   fshttp.NewClient(context.Background()),
+	}, nil
+}
+
+// String returns a description of the location for logging
+func (l *consulLocation) String() string {
+	return redactURL(l.url)
+}
+
+// consulKV is a key as returned by the consul API
+type consulKV struct {
+	Key         string
+	Value       []byte
+	ModifyIndex uint64 `json:",omitempty"`
+	Verb        string `json:",omitempty"`
+	Index       uint64
+}
+
+// call the consul API decoding the JSON response into result
+//
+// It returns the response status code
+func (l *consulLocation) call(method, path string, in, result interface{}) (int, error) {
+	var body []byte
+	if in != nil {
+		var err error
+		body, err = json.Marshal(in)
+		if err != nil {
+			return 0, err
+		}
+	}
+	req, err := http.NewRequest(method, l.endpoint+path, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	if l.token != "" {
+		req.Header.Set("X-Consul-Token", l.token)
+	}
+	resp, err := l.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := ioutil.ReadAll(resp.Body)
+		return resp.StatusCode, errors.Errorf("consul error %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return resp.StatusCode, json.NewDecoder(resp.Body).Decode(result)
+}
+
+// Read the config data returning it with the ModifyIndex as the version
+func (l *consulLocation) Read() (data []byte, version string, err error) {
+	var kvs []consulKV
+	status, err := l.call("GET", "kv/"+l.key, nil, &kvs)
+	if status == http.StatusNotFound || (err == nil && len(kvs) == 0) {
+		return nil, "", config.ErrorConfigFileNotFound
+	} else if err != nil {
+		return nil, "", errors.Wrap(err, "failed to read config")
+	}
+	return kvs[0].Value, strconv.FormatUint(kvs[0].ModifyIndex, 10), nil
+}
+
+// Write the config data if the ModifyIndex still matches version
+func (l *consulLocation) Write(data []byte, version string) (newVersion string, err error) {
+	var index uint64
+	if version != "" {
+		index, err = strconv.ParseUint(version, 10, 64)
+		if err != nil {
+			return "", errors.Wrap(err, "bad config version")
+		}
+	}
+	// Use a transaction rather than a PUT with ?cas= as it returns
+	// the new ModifyIndex
+	txn := []struct{ KV consulKV }{{KV: consulKV{
+		Verb:  "cas",
+		Key:   l.key,
+		Value: data,
+		Index: index,
+	}}}
+	var result struct {
+		Results []struct{ KV consulKV }
+	}
+	status, err := l.call("PUT", "txn", txn, &result)
+	if status == http.StatusConflict {
+		return "", ErrVersionConflict
+	} else if err != nil {
+		return "", errors.Wrap(err, "failed to write config")
+	}
+	if len(result.Results) == 0 {
+		return "", errors.New("failed to write config: no result from consul")
+	}
+	return strconv.FormatUint(result.Results[0].KV.ModifyIndex, 10), nil
+}
@@ -0,0 +1,147 @@
+package configfile
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/pingme998/rclone/fs/config"
+	"github.com/pingme998/rclone/fs/fshttp"
+)
+
+func init() {
+	RegisterLocation("etcd", newEtcdLocation)
+	RegisterLocation("etcd+https", newEtcdLocation)
+}
+
+// etcdLocation stores the config in a key in etcd using the JSON
+// gateway to the v3 API.
+//
+// The mod_revision of the key is used as the version with a
+// transaction to stop concurrent writes overwriting each other.
+type etcdLocation struct {
+	url      *url.URL
+	endpoint string // base URL of the API
+	key      []byte
+	client   *http.Client
+}
+
+// newEtcdLocation makes a Location for the config at a URL like
+// etcd://host:2379/path/to/key
+func newEtcdLocation(u *url.URL) (Location, error) {
+	if strings.Trim(u.Path, "/") == "" {
+		return nil, errors.New("etcd config URL needs a key, eg etcd://localhost:2379/rclone/config")
+	}
+	scheme := "http"
+	if strings.HasSuffix(u.Scheme, "+https") {
+		scheme = "https"
+	}
+	return &etcdLocation{
+		url:      u,
+		endpoint: scheme + "://" + u.Host + "/v3/",
+		key:      []byte(u.Path),
+		client:   fshttp.NewClient(context.Background()),
+	}, nil
+}
+
+// String returns a description of the location for logging
+func (l *etcdLocation) String() string {
+	return redactURL(l.url)
+}
+
+// etcdKV is a key as returned by the etcd API
+type etcdKV struct {
+	Key         []byte `json:"key"`
+	Value       []byte `json:"value"`
+	ModRevision int64  `json:"mod_revision,string"`
+}
+
+// etcdHeader is returned with every etcd API response
+type etcdHeader struct {
+	Revision int64 `json:"revision,string"`
+}
+
+// call the etcd API decoding the JSON response into result
+func (l *etcdLocation) call(path string, in, result interface{}) error {
+	body, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+	resp, err := l.client.Post(l.endpoint+path, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := ioutil.ReadAll(resp.Body)
+		return errors.Errorf("etcd error %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return json.NewDecoder(resp.Body).Decode(result)
+}
+
+// Read the config data returning it with the mod_revision as the
+// version
+func (l *etcdLocation) Read() (data []byte, version string, err error) {
+	var result struct {
+		Kvs []etcdKV `json:"kvs"`
+	}
+	err = l.call("kv/range", map[string]interface{}{"key": l.key}, &result)
+	if err != nil {
+		return nil, "", errors.Wrap(err, "failed to read config")
+	}
+	if len(result.Kvs) == 0 {
+		return nil, "", config.ErrorConfigFileNotFound
+	}
+	return result.Kvs[0].Value, strconv.FormatInt(result.Kvs[0].ModRevision, 10), nil
+}
+
+// Write the config data if the mod_revision still matches version
+func (l *etcdLocation) Write(data []byte, version string) (newVersion string, err error) {
+	// If there was no config then check the key hasn't been created
+	compare := map[string]interface{}{
+		"key":             l.key,
+		"target":          "CREATE",
+		"create_revision": "0",
+		"result":          "EQUAL",
+	}
+	if version != "" {
+		compare = map[string]interface{}{
+			"key":          l.key,
+			"target":       "MOD",
+			"mod_revision": version,
+			"result":       "EQUAL",
+		}
+	}
+	txn := map[string]interface{}{
+		"compare": []interface{}{compare},
+		"success": []interface{}{
+			map[string]interface{}{
+				"request_put": map[string]interface{}{
+					"key":   l.key,
+					"value": data,
+				},
+			},
+		},
+	}
+	var result struct {
+		Header    etcdHeader `json:"header"`
+		Succeeded bool       `json:"succeeded"`
+	}
+	err = l.call("kv/txn", txn, &result)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to write config")
+	}
+	if !result.Succeeded {
+		return "", ErrVersionConflict
+	}
+	// The key is modified at the revision of the transaction
+	return strconv.FormatInt(result.Header.Revision, 10), nil
+}
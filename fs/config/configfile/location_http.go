@@ -0,0 +1,110 @@
+package configfile
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+
+	"github.com/pkg/errors"
+	"github.com/pingme998/rclone/fs/config"
+	"github.com/pingme998/rclone/fs/fshttp"
+)
+
+func init() {
+	RegisterLocation("http", newHTTPLocation)
+	RegisterLocation("https", newHTTPLocation)
+}
+
+// httpLocation stores the config on a web server which supports GET
+// and PUT, eg a WebDAV server.
+//
+// The ETag is used as the version with If-Match and If-None-Match to
+// stop concurrent writes overwriting each other.
+type httpLocation struct {
+	url    *url.URL
+	client *http.Client
+}
+
+// newHTTPLocation makes a Location for the config at u
+func newHTTPLocation(u *url.URL) (Location, error) {
+	return &httpLocation{
+		url:    u,
+		client: fshttp.NewClient(context.Background()),
+	}, nil
+}
+
+// String returns a description of the location for logging
+func (l *httpLocation) String() string {
+	return redactURL(l.url)
+}
+
+// do makes an HTTP request returning the response which must be
+// closed if there wasn't an error
+func (l *httpLocation) do(method string, body []byte, header http.Header) (*http.Response, error) {
+	req, err := http.NewRequest(method, l.url.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	return l.client.Do(req)
+}
+
+// Read the config data returning it with its ETag as the version
+func (l *httpLocation) Read() (data []byte, version string, err error) {
+	resp, err := l.do("GET", nil, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, "", config.ErrorConfigFileNotFound
+	case resp.StatusCode < 200 || resp.StatusCode > 299:
+		return nil, "", errors.Errorf("failed to read config: HTTP error %s", resp.Status)
+	}
+	data, err = ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", errors.Wrap(err, "failed to read config")
+	}
+	return data, resp.Header.Get("ETag"), nil
+}
+
+// Write the config data if the ETag still matches version
+func (l *httpLocation) Write(data []byte, version string) (newVersion string, err error) {
+	header := http.Header{}
+	header.Set("Content-Type", "text/plain; charset=utf-8")
+	if version == "" {
+		header.Set("If-None-Match", "*")
+	} else {
+		header.Set("If-Match", version)
+	}
+	resp, err := l.do("PUT", data, header)
+	if err != nil {
+		return "", err
+	}
+	_ = resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusPreconditionFailed || resp.StatusCode == http.StatusConflict:
+		return "", ErrVersionConflict
+	case resp.StatusCode < 200 || resp.StatusCode > 299:
+		return "", errors.Errorf("failed to write config: HTTP error %s", resp.Status)
+	}
+	newVersion = resp.Header.Get("ETag")
+	if newVersion == "" {
+		// Not all servers return the ETag from a PUT so HEAD the
+		// config to find it
+		resp, err = l.do("HEAD", nil, nil)
+		if err != nil {
+			return "", errors.Wrap(err, "failed to read config version")
+		}
+		_ = resp.Body.Close()
+		newVersion = resp.Header.Get("ETag")
+	}
+	return newVersion, nil
+}
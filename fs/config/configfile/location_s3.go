@@ -0,0 +1,132 @@
+package configfile
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/pkg/errors"
+	"github.com/pingme998/rclone/fs/config"
+	"github.com/pingme998/rclone/fs/fshttp"
+)
+
+func init() {
+	RegisterLocation("s3", newS3Location)
+}
+
+// s3Location stores the config in an object in S3 or an S3
+// compatible object store.
+//
+// The ETag is used as the version with conditional writes to stop
+// concurrent writes overwriting each other. Object stores which don't
+// support conditional writes will ignore the check.
+type s3Location struct {
+	url    *url.URL
+	bucket string
+	key    string
+	svc    *s3.S3
+}
+
+// newS3Location makes a Location for the config at a URL like
+// s3://bucket/path/to/rclone.conf?region=eu-west-1&endpoint=https://s3.example.com
+//
+// Credentials are read from the environment, the shared AWS config
+// files or the instance role in the same way as the aws CLI.
+func newS3Location(u *url.URL) (Location, error) {
+	key := strings.TrimPrefix(u.Path, "/")
+	if u.Host == "" || key == "" {
+		return nil, errors.New("s3 config URL needs a bucket and a key, eg s3://bucket/rclone.conf")
+	}
+	query := u.Query()
+	awsConfig := aws.Config{
+		HTTPClient: fshttp.NewClient(context.Background()),
+	}
+	if region := query.Get("region"); region != "" {
+		awsConfig.Region = aws.String(region)
+	}
+	if endpoint := query.Get("endpoint"); endpoint != "" {
+		awsConfig.Endpoint = aws.String(endpoint)
+		awsConfig.S3ForcePathStyle = aws.Bool(true)
+	}
+	sess, err := session.NewSessionWithOptions(session.Options{
+		Config:            awsConfig,
+		SharedConfigState: session.SharedConfigEnable,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to make S3 session for config")
+	}
+	if aws.StringValue(sess.Config.Region) == "" {
+		sess.Config.Region = aws.String("us-east-1")
+	}
+	return &s3Location{
+		url:    u,
+		bucket: u.Host,
+		key:    key,
+		svc:    s3.New(sess),
+	}, nil
+}
+
+// String returns a description of the location for logging
+func (l *s3Location) String() string {
+	return redactURL(l.url)
+}
+
+// statusCode returns the HTTP status code of err or 0
+func statusCode(err error) int {
+	if reqErr, ok := err.(awserr.RequestFailure); ok {
+		return reqErr.StatusCode()
+	}
+	return 0
+}
+
+// Read the config data returning it with its ETag as the version
+func (l *s3Location) Read() (data []byte, version string, err error) {
+	resp, err := l.svc.GetObject(&s3.GetObjectInput{
+		Bucket: aws.String(l.bucket),
+		Key:    aws.String(l.key),
+	})
+	if statusCode(err) == http.StatusNotFound {
+		return nil, "", config.ErrorConfigFileNotFound
+	} else if err != nil {
+		return nil, "", errors.Wrap(err, "failed to read config")
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	data, err = ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", errors.Wrap(err, "failed to read config")
+	}
+	return data, aws.StringValue(resp.ETag), nil
+}
+
+// Write the config data if the ETag still matches version
+func (l *s3Location) Write(data []byte, version string) (newVersion string, err error) {
+	req, resp := l.svc.PutObjectRequest(&s3.PutObjectInput{
+		Bucket:      aws.String(l.bucket),
+		Key:         aws.String(l.key),
+		Body:        bytes.NewReader(data),
+		ContentType: aws.String("text/plain; charset=utf-8"),
+	})
+	if version == "" {
+		req.HTTPRequest.Header.Set("If-None-Match", "*")
+	} else {
+		req.HTTPRequest.Header.Set("If-Match", version)
+	}
+	err = req.Send()
+	switch statusCode(err) {
+	case http.StatusPreconditionFailed, http.StatusConflict:
+		return "", ErrVersionConflict
+	}
+	if err != nil {
+		return "", errors.Wrap(err, "failed to write config")
+	}
+	return aws.StringValue(resp.ETag), nil
+}
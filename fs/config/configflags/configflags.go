@@ -9,9 +9,11 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/pkg/errors"
 	"github.com/pingme998/rclone/fs"
 	"github.com/pingme998/rclone/fs/config"
 	"github.com/pingme998/rclone/fs/config/flags"
+	"github.com/pingme998/rclone/fs/fserrors"
 	fsLog "github.com/pingme998/rclone/fs/log"
 	"github.com/pingme998/rclone/fs/rc"
 	"github.com/sirupsen/logrus"
@@ -22,6 +24,7 @@ var (
 	// these will get interpreted into fs.Config via SetFlags() below
 	verbose         int
 	quiet           bool
+	logLevelSet     bool
 	configPath      string
 	dumpHeaders     bool
 	dumpBodies      bool
@@ -34,6 +37,8 @@ var (
 	uploadHeaders   []string
 	downloadHeaders []string
 	headers         []string
+	tags            []string
+	retryRules      []string
 )
 
 // AddFlags adds the non filing system specific flags to the command
@@ -44,8 +49,9 @@ func AddFlags(ci *fs.ConfigInfo, flagSet *pflag.FlagSet) {
 	flags.BoolVarP(flagSet, &quiet, "quiet", "q", false, "Print as little stuff as possible")
 	flags.DurationVarP(flagSet, &ci.ModifyWindow, "modify-window", "", ci.ModifyWindow, "Max time diff to be considered the same")
 	flags.IntVarP(flagSet, &ci.Checkers, "checkers", "", ci.Checkers, "Number of checkers to run in parallel.")
-	flags.IntVarP(flagSet, &ci.Transfers, "transfers", "", ci.Transfers, "Number of file transfers to run in parallel.")
+	flags.FVarP(flagSet, &transfersValue{ci: ci}, "transfers", "", "Number of file transfers to run in parallel, or auto to tune it while running.")
 	flags.StringVarP(flagSet, &configPath, "config", "", config.GetConfigPath(), "Config file.")
+	flags.StringVarP(flagSet, &profile, "profile", "", "", "Use the options from this named profile in the config file.")
 	flags.StringVarP(flagSet, &config.CacheDir, "cache-dir", "", config.CacheDir, "Directory rclone will use for caching.")
 	flags.BoolVarP(flagSet, &ci.CheckSum, "checksum", "c", ci.CheckSum, "Skip based on checksum (if available) & size, not mod-time & size")
 	flags.BoolVarP(flagSet, &ci.SizeOnly, "size-only", "", ci.SizeOnly, "Skip based on size only, not mod-time or checksum")
@@ -53,7 +59,9 @@ func AddFlags(ci *fs.ConfigInfo, flagSet *pflag.FlagSet) {
 	flags.BoolVarP(flagSet, &ci.IgnoreExisting, "ignore-existing", "", ci.IgnoreExisting, "Skip all files that exist on destination")
 	flags.BoolVarP(flagSet, &ci.IgnoreErrors, "ignore-errors", "", ci.IgnoreErrors, "delete even if there are I/O errors")
 	flags.BoolVarP(flagSet, &ci.DryRun, "dry-run", "n", ci.DryRun, "Do a trial run with no permanent changes")
+	flags.StringVarP(flagSet, &ci.DryRunOutput, "dry-run-output", "", ci.DryRunOutput, "Summary of a --dry-run to print at the end: text, json or none")
 	flags.BoolVarP(flagSet, &ci.Interactive, "interactive", "i", ci.Interactive, "Enable interactive mode")
+	flags.StringVarP(flagSet, &ci.InteractiveLog, "interactive-log", "", ci.InteractiveLog, "Append the decisions made in interactive mode to this file")
 	flags.DurationVarP(flagSet, &ci.ConnectTimeout, "contimeout", "", ci.ConnectTimeout, "Connect timeout")
 	flags.DurationVarP(flagSet, &ci.Timeout, "timeout", "", ci.Timeout, "IO idle timeout")
 	flags.DurationVarP(flagSet, &ci.ExpectContinueTimeout, "expect-continue-timeout", "", ci.ExpectContinueTimeout, "Timeout when using expect / 100-continue in HTTP")
@@ -62,19 +70,22 @@ func AddFlags(ci *fs.ConfigInfo, flagSet *pflag.FlagSet) {
 	flags.BoolVarP(flagSet, &ci.InsecureSkipVerify, "no-check-certificate", "", ci.InsecureSkipVerify, "Do not verify the server SSL certificate. Insecure.")
 	flags.BoolVarP(flagSet, &ci.AskPassword, "ask-password", "", ci.AskPassword, "Allow prompt for password for encrypted configuration.")
 	flags.FVarP(flagSet, &ci.PasswordCommand, "password-command", "", "Command for supplying password for encrypted configuration.")
+	flags.StringVarP(flagSet, &ci.PasswordStore, "password-store", "", ci.PasswordStore, "Store the password for encrypted configuration in: keyring.")
 	flags.BoolVarP(flagSet, &deleteBefore, "delete-before", "", false, "When synchronizing, delete files on destination before transferring")
 	flags.BoolVarP(flagSet, &deleteDuring, "delete-during", "", false, "When synchronizing, delete files during transfer")
 	flags.BoolVarP(flagSet, &deleteAfter, "delete-after", "", false, "When synchronizing, delete files on destination after transferring (default)")
 	flags.Int64VarP(flagSet, &ci.MaxDelete, "max-delete", "", -1, "When synchronizing, limit the number of deletes")
 	flags.BoolVarP(flagSet, &ci.TrackRenames, "track-renames", "", ci.TrackRenames, "When synchronizing, track file renames and do a server-side move if possible")
-	flags.StringVarP(flagSet, &ci.TrackRenamesStrategy, "track-renames-strategy", "", ci.TrackRenamesStrategy, "Strategies to use when synchronizing using track-renames hash|modtime|leaf")
+	flags.StringVarP(flagSet, &ci.TrackRenamesStrategy, "track-renames-strategy", "", ci.TrackRenamesStrategy, "Strategies to use when synchronizing using track-renames hash|modtime|leaf|id")
 	flags.IntVarP(flagSet, &ci.LowLevelRetries, "low-level-retries", "", ci.LowLevelRetries, "Number of low level retries to do.")
+	flags.StringArrayVarP(flagSet, &retryRules, "retry-rule", "", nil, "Override whether errors are retried, e.g. 'remote=s3,status=403,match=Slow Down,retry=true'")
 	flags.BoolVarP(flagSet, &ci.UpdateOlder, "update", "u", ci.UpdateOlder, "Skip files that are newer on the destination.")
 	flags.BoolVarP(flagSet, &ci.UseServerModTime, "use-server-modtime", "", ci.UseServerModTime, "Use server modified time instead of object metadata")
 	flags.BoolVarP(flagSet, &ci.NoGzip, "no-gzip-encoding", "", ci.NoGzip, "Don't set Accept-Encoding: gzip.")
 	flags.IntVarP(flagSet, &ci.MaxDepth, "max-depth", "", ci.MaxDepth, "If set limits the recursion depth to this.")
 	flags.BoolVarP(flagSet, &ci.IgnoreSize, "ignore-size", "", false, "Ignore size when skipping use mod-time or checksum.")
 	flags.BoolVarP(flagSet, &ci.IgnoreChecksum, "ignore-checksum", "", ci.IgnoreChecksum, "Skip post copy check of checksums.")
+	flags.BoolVarP(flagSet, &ci.VerifyUploads, "verify-uploads", "", ci.VerifyUploads, "Read back each upload and check its hash or contents match the source.")
 	flags.BoolVarP(flagSet, &ci.IgnoreCaseSync, "ignore-case-sync", "", ci.IgnoreCaseSync, "Ignore case when synchronizing")
 	flags.BoolVarP(flagSet, &ci.NoTraverse, "no-traverse", "", ci.NoTraverse, "Don't traverse destination file system on copy.")
 	flags.BoolVarP(flagSet, &ci.CheckFirst, "check-first", "", ci.CheckFirst, "Do all the checks before starting transfers.")
@@ -86,6 +97,8 @@ func AddFlags(ci *fs.ConfigInfo, flagSet *pflag.FlagSet) {
 	flags.StringVarP(flagSet, &ci.BackupDir, "backup-dir", "", ci.BackupDir, "Make backups into hierarchy based in DIR.")
 	flags.StringVarP(flagSet, &ci.Suffix, "suffix", "", ci.Suffix, "Suffix to add to changed files.")
 	flags.BoolVarP(flagSet, &ci.SuffixKeepExtension, "suffix-keep-extension", "", ci.SuffixKeepExtension, "Preserve the extension when using --suffix.")
+	flags.IntVarP(flagSet, &ci.SuffixKeep, "suffix-keep", "", ci.SuffixKeep, "Max number of numbered versions to keep when --suffix contains {n} (0 = unlimited).")
+	flags.StringVarP(flagSet, &ci.DeleteDelayedTo, "delete-delayed-to", "", ci.DeleteDelayedTo, "Stage deletions and overwrites in DIR and only commit them if the sync succeeds.")
 	flags.BoolVarP(flagSet, &ci.UseListR, "fast-list", "", ci.UseListR, "Use recursive list if available. Uses more memory but fewer transactions.")
 	flags.Float64VarP(flagSet, &ci.TPSLimit, "tpslimit", "", ci.TPSLimit, "Limit HTTP transactions per second to this.")
 	flags.IntVarP(flagSet, &ci.TPSLimitBurst, "tpslimit-burst", "", ci.TPSLimitBurst, "Max burst of transactions for --tpslimit.")
@@ -95,7 +108,9 @@ func AddFlags(ci *fs.ConfigInfo, flagSet *pflag.FlagSet) {
 	flags.BoolVarP(flagSet, &ci.Immutable, "immutable", "", ci.Immutable, "Do not modify files. Fail if existing files have been modified.")
 	flags.BoolVarP(flagSet, &ci.AutoConfirm, "auto-confirm", "", ci.AutoConfirm, "If enabled, do not request console confirmation.")
 	flags.IntVarP(flagSet, &ci.StatsFileNameLength, "stats-file-name-length", "", ci.StatsFileNameLength, "Max file name length in stats. 0 for no limit")
-	flags.FVarP(flagSet, &ci.LogLevel, "log-level", "", "Log level DEBUG|INFO|NOTICE|ERROR")
+	flags.FVarP(flagSet, logLevelValue{ci}, "log-level", "", "Log level DEBUG|INFO|NOTICE|ERROR, and/or subsystem=LEVEL,... to set the log level of subsystems")
+	flags.IntVarP(flagSet, &ci.LogRepeatLimit, "log-repeat-limit", "", ci.LogRepeatLimit, "Suppress identical log messages after this many in --log-repeat-window. 0 for no limit.")
+	flags.DurationVarP(flagSet, &ci.LogRepeatWindow, "log-repeat-window", "", ci.LogRepeatWindow, "Time window for --log-repeat-limit.")
 	flags.FVarP(flagSet, &ci.StatsLogLevel, "stats-log-level", "", "Log level to show --stats output DEBUG|INFO|NOTICE|ERROR")
 	flags.FVarP(flagSet, &ci.BwLimit, "bwlimit", "", "Bandwidth limit in KiByte/s, or use suffix B|K|M|G|T|P or a full timetable.")
 	flags.FVarP(flagSet, &ci.BwLimitFile, "bwlimit-file", "", "Bandwidth limit per file in KiByte/s, or use suffix B|K|M|G|T|P or a full timetable.")
@@ -103,8 +118,11 @@ func AddFlags(ci *fs.ConfigInfo, flagSet *pflag.FlagSet) {
 	flags.FVarP(flagSet, &ci.StreamingUploadCutoff, "streaming-upload-cutoff", "", "Cutoff for switching to chunked upload if file size is unknown. Upload starts after reaching cutoff or when file ends.")
 	flags.FVarP(flagSet, &ci.Dump, "dump", "", "List of items to dump from: "+fs.DumpFlagsList)
 	flags.FVarP(flagSet, &ci.MaxTransfer, "max-transfer", "", "Maximum size of data to transfer.")
-	flags.DurationVarP(flagSet, &ci.MaxDuration, "max-duration", "", 0, "Maximum duration rclone will transfer data for.")
-	flags.FVarP(flagSet, &ci.CutoffMode, "cutoff-mode", "", "Mode to stop transfers when reaching the max transfer limit HARD|SOFT|CAUTIOUS")
+	flags.DurationVarP(flagSet, &ci.MaxDuration, "max-duration", "", 0, "Maximum duration rclone will transfer data for, see --cutoff-mode.")
+	flags.FVarP(flagSet, &ci.CutoffMode, "cutoff-mode", "", "Mode to stop transfers when reaching the --max-transfer or --max-duration limit HARD|SOFT|CAUTIOUS")
+	flags.FVarP(flagSet, &ci.MinTransferRate, "min-transfer-rate", "", "Restart transfers slower than this for --min-transfer-rate-time in KiByte/s, or use suffix B|K|M|G|T|P.")
+	flags.DurationVarP(flagSet, &ci.MinTransferRateTime, "min-transfer-rate-time", "", ci.MinTransferRateTime, "How long a transfer must be slower than --min-transfer-rate to be restarted.")
+	flags.DurationVarP(flagSet, &ci.MaxTransferTime, "max-transfer-time", "", 0, "Restart any transfer which takes longer than this.")
 	flags.IntVarP(flagSet, &ci.MaxBacklog, "max-backlog", "", ci.MaxBacklog, "Maximum number of objects in sync or check backlog.")
 	flags.IntVarP(flagSet, &ci.MaxStatsGroups, "max-stats-groups", "", ci.MaxStatsGroups, "Maximum number of stats groups to keep in memory. On max oldest is discarded.")
 	flags.BoolVarP(flagSet, &ci.StatsOneLine, "stats-one-line", "", ci.StatsOneLine, "Make the stats fit on one line.")
@@ -120,16 +138,22 @@ func AddFlags(ci *fs.ConfigInfo, flagSet *pflag.FlagSet) {
 	flags.StringVarP(flagSet, &ci.ClientKey, "client-key", "", ci.ClientKey, "Client SSL private key (PEM) for mutual TLS auth")
 	flags.FVarP(flagSet, &ci.MultiThreadCutoff, "multi-thread-cutoff", "", "Use multi-thread downloads for files above this size.")
 	flags.IntVarP(flagSet, &ci.MultiThreadStreams, "multi-thread-streams", "", ci.MultiThreadStreams, "Max number of streams to use for multi-thread downloads.")
+	flags.BoolVarP(flagSet, &ci.Inplace, "inplace", "", ci.Inplace, "Upload directly to the destination file instead of a partial file which is renamed when complete.")
+	flags.StringVarP(flagSet, &ci.PartialSuffix, "partial-suffix", "", ci.PartialSuffix, "Upload files to their name with partial-suffix added and rename them when complete.")
 	flags.BoolVarP(flagSet, &ci.UseJSONLog, "use-json-log", "", ci.UseJSONLog, "Use json log format.")
+	flags.StringVarP(flagSet, &ci.LogResults, "log-results", "", ci.LogResults, "Append a JSON record for each file transferred, checked or deleted to this file.")
 	flags.StringVarP(flagSet, &ci.OrderBy, "order-by", "", ci.OrderBy, "Instructions on how to order the transfers, e.g. 'size,descending'")
 	flags.StringArrayVarP(flagSet, &uploadHeaders, "header-upload", "", nil, "Set HTTP header for upload transactions")
 	flags.StringArrayVarP(flagSet, &downloadHeaders, "header-download", "", nil, "Set HTTP header for download transactions")
 	flags.StringArrayVarP(flagSet, &headers, "header", "", nil, "Set HTTP header for all transactions")
+	flags.StringArrayVarP(flagSet, &tags, "tag", "", nil, "Set a tag on uploaded objects in the form key=value")
 	flags.BoolVarP(flagSet, &ci.RefreshTimes, "refresh-times", "", ci.RefreshTimes, "Refresh the modtime of remote files.")
 	flags.BoolVarP(flagSet, &ci.NoConsole, "no-console", "", ci.NoConsole, "Hide console window. Supported on Windows only.")
 	flags.StringVarP(flagSet, &dscp, "dscp", "", "", "Set DSCP value to connections. Can be value or names, eg. CS1, LE, DF, AF21.")
 	flags.DurationVarP(flagSet, &ci.FsCacheExpireDuration, "fs-cache-expire-duration", "", ci.FsCacheExpireDuration, "cache remotes for this long (0 to disable caching)")
 	flags.DurationVarP(flagSet, &ci.FsCacheExpireInterval, "fs-cache-expire-interval", "", ci.FsCacheExpireInterval, "interval to check for expired remotes")
+	flags.IntVarP(flagSet, &ci.FsCacheMaxEntries, "fs-cache-max-entries", "", ci.FsCacheMaxEntries, "max number of remotes to cache, least recently used are removed first (0 for unlimited)")
+	flags.DurationVarP(flagSet, &ci.ShutdownGracePeriod, "shutdown-grace-period", "", ci.ShutdownGracePeriod, "On exit signals wait this long for uploads to finish (0 to exit straight away)")
 	flags.BoolVarP(flagSet, &ci.DisableHTTP2, "disable-http2", "", ci.DisableHTTP2, "Disable HTTP/2 in the global transport.")
 }
 
@@ -150,6 +174,47 @@ func ParseHeaders(headers []string) []*fs.HTTPOption {
 	return opts
 }
 
+// ParseTags converts a list of key=value strings into a map of tags
+func ParseTags(tags []string) map[string]string {
+	m := make(map[string]string, len(tags))
+	for _, tag := range tags {
+		parts := strings.SplitN(tag, "=", 2)
+		if len(parts) == 1 || strings.TrimSpace(parts[0]) == "" {
+			log.Fatalf("Failed to parse '%s' as a tag. Expecting a string like: 'project=apollo'", tag)
+		}
+		m[strings.TrimSpace(parts[0])] = parts[1]
+	}
+	return m
+}
+
+// logLevelValue is the value of --log-level which sets the log level
+// and the log levels of any subsystems given as subsystem=LEVEL
+type logLevelValue struct {
+	ci *fs.ConfigInfo
+}
+
+// String turns the log levels into a string
+func (v logLevelValue) String() string {
+	if len(v.ci.SubsystemLogLevels) == 0 {
+		return v.ci.LogLevel.String()
+	}
+	return v.ci.LogLevel.String() + "," + v.ci.SubsystemLogLevels.String()
+}
+
+// Set the log levels
+func (v logLevelValue) Set(s string) error {
+	levelSet, err := v.ci.SetLogLevel(s)
+	if levelSet {
+		logLevelSet = true
+	}
+	return err
+}
+
+// Type of the value
+func (v logLevelValue) Type() string {
+	return "string"
+}
+
 // SetFlags converts any flags into config which weren't straight forward
 func SetFlags(ci *fs.ConfigInfo) {
 	if verbose >= 2 {
@@ -160,6 +225,11 @@ func SetFlags(ci *fs.ConfigInfo) {
 	if (ci.DryRun || ci.Interactive) && ci.StatsLogLevel > fs.LogLevelNotice {
 		ci.StatsLogLevel = fs.LogLevelNotice
 	}
+	switch ci.DryRunOutput {
+	case "text", "json", "none":
+	default:
+		log.Fatalf("--dry-run-output must be one of text, json or none")
+	}
 	if quiet {
 		if verbose > 0 {
 			log.Fatalf("Can't set -v and -q")
@@ -167,7 +237,7 @@ func SetFlags(ci *fs.ConfigInfo) {
 		ci.LogLevel = fs.LogLevelError
 	}
 	logLevelFlag := pflag.Lookup("log-level")
-	if logLevelFlag != nil && logLevelFlag.Changed {
+	if logLevelFlag != nil && logLevelFlag.Changed && logLevelSet {
 		if verbose > 0 {
 			log.Fatalf("Can't set -v and --log-level")
 		}
@@ -175,13 +245,23 @@ func SetFlags(ci *fs.ConfigInfo) {
 			log.Fatalf("Can't set -q and --log-level")
 		}
 	}
+	if strings.Contains(","+fsLog.Opt.Format+",", ",json,") {
+		ci.UseJSONLog = true
+	}
 	if ci.UseJSONLog {
 		logrus.AddHook(fsLog.NewCallerHook())
 		logrus.SetFormatter(&logrus.JSONFormatter{
 			TimestampFormat: "2006-01-02T15:04:05.999999-07:00",
 		})
 		logrus.SetLevel(logrus.DebugLevel)
-		switch ci.LogLevel {
+		// logrus needs to let through the logs of the most verbose subsystem
+		logLevel := ci.LogLevel
+		for _, subsystemLevel := range ci.SubsystemLogLevels {
+			if subsystemLevel > logLevel {
+				logLevel = subsystemLevel
+			}
+		}
+		switch logLevel {
 		case fs.LogLevelEmergency, fs.LogLevelAlert:
 			logrus.SetLevel(logrus.PanicLevel)
 		case fs.LogLevelCritical:
@@ -260,6 +340,16 @@ func SetFlags(ci *fs.ConfigInfo) {
 	if len(headers) != 0 {
 		ci.Headers = ParseHeaders(headers)
 	}
+	if len(tags) != 0 {
+		ci.Tags = ParseTags(tags)
+	}
+	for _, s := range retryRules {
+		rule, err := fserrors.ParseRetryRule(s)
+		if err != nil {
+			log.Fatalf("--retry-rule: %v", err)
+		}
+		ci.RetryRules = append(ci.RetryRules, rule)
+	}
 	if len(dscp) != 0 {
 		if value, ok := parseDSCP(dscp); ok {
 			ci.TrafficClass = value << 2
@@ -288,6 +378,41 @@ func SetFlags(ci *fs.ConfigInfo) {
 	nonZero(&ci.Checkers)
 }
 
+// transfersValue is the value of --transfers which may be a number or
+// "auto"
+type transfersValue struct {
+	ci *fs.ConfigInfo
+}
+
+// String turns the value into a string
+func (v *transfersValue) String() string {
+	if v.ci.TransfersAuto {
+		return "auto"
+	}
+	return strconv.Itoa(v.ci.Transfers)
+}
+
+// Set the value from a string
+func (v *transfersValue) Set(s string) error {
+	if strings.ToLower(s) == "auto" {
+		// Transfers is left as the starting point for the tuning
+		v.ci.TransfersAuto = true
+		return nil
+	}
+	transfers, err := strconv.Atoi(s)
+	if err != nil {
+		return errors.Errorf("invalid --transfers %q - must be a number or auto", s)
+	}
+	v.ci.Transfers = transfers
+	v.ci.TransfersAuto = false
+	return nil
+}
+
+// Type of the value
+func (v *transfersValue) Type() string {
+	return "string"
+}
+
 // parseHeaders converts DSCP names to value
 func parseDSCP(dscp string) (uint8, bool) {
 	if s, err := strconv.ParseUint(dscp, 10, 6); err == nil {
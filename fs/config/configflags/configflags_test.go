@@ -0,0 +1,28 @@
+package configflags
+
+import (
+	"testing"
+
+	"github.com/pingme998/rclone/fs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransfersValue(t *testing.T) {
+	ci := &fs.ConfigInfo{Transfers: 4}
+	v := &transfersValue{ci: ci}
+	assert.Equal(t, "4", v.String())
+
+	require.NoError(t, v.Set("AUTO"))
+	assert.True(t, ci.TransfersAuto)
+	assert.Equal(t, 4, ci.Transfers)
+	assert.Equal(t, "auto", v.String())
+
+	require.NoError(t, v.Set("8"))
+	assert.False(t, ci.TransfersAuto)
+	assert.Equal(t, 8, ci.Transfers)
+	assert.Equal(t, "8", v.String())
+
+	assert.Error(t, v.Set("potato"))
+	assert.Equal(t, 8, ci.Transfers)
+}
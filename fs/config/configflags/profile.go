@@ -0,0 +1,97 @@
+package configflags
+
+import (
+	"os"
+	"strings"
+
+	"github.com/pingme998/rclone/fs"
+	"github.com/pingme998/rclone/fs/config"
+	"github.com/pkg/errors"
+	"github.com/spf13/pflag"
+)
+
+// profile is the name of the option profile set with --profile
+var profile string
+
+// ApplyProfiles sets the options from the option profile selected
+// with --profile and the defaults for command from the config file,
+// then calls SetFlags again to convert them into ci.
+//
+// command is the name of the command being run, eg "sync" or "serve
+// webdav", and flagSets are the flag sets to look the options up in.
+//
+// Options set on the command line or with environment variables take
+// precedence over the profile which takes precedence over the command
+// defaults.
+func ApplyProfiles(ci *fs.ConfigInfo, command string, flagSets ...*pflag.FlagSet) error {
+	if profile == "" && config.NeedsPassword() {
+		// Don't ask for the password just to look for the command
+		// defaults as the command may not need the config at all
+		fs.Debugf(nil, "Not reading command defaults from encrypted config")
+		return nil
+	}
+	var sections []string
+	if profile != "" {
+		section := config.ProfilePrefix + profile
+		if !config.LoadedData().HasSection(section) {
+			return errors.Errorf("profile %q not found - add a [%s] section to the config file", profile, section)
+		}
+		sections = append(sections, section)
+	}
+	if command != "" {
+		section := config.CommandPrefix + command
+		if config.LoadedData().HasSection(section) {
+			sections = append(sections, section)
+		}
+	}
+	if len(sections) == 0 {
+		return nil
+	}
+	set := map[string]bool{}
+	for _, section := range sections {
+		err := applySection(section, set, flagSets)
+		if err != nil {
+			return err
+		}
+	}
+	SetFlags(ci)
+	return nil
+}
+
+// lookupFlag finds the flag called name in flagSets
+func lookupFlag(name string, flagSets []*pflag.FlagSet) *pflag.Flag {
+	for _, flagSet := range flagSets {
+		if flag := flagSet.Lookup(name); flag != nil {
+			return flag
+		}
+	}
+	return nil
+}
+
+// applySection sets the flags named by the keys in section
+//
+// Flags which were set on the command line, with an environment
+// variable or are in set already are left alone. The flags set are
+// added to set.
+func applySection(section string, set map[string]bool, flagSets []*pflag.FlagSet) error {
+	for _, key := range config.LoadedData().GetKeyList(section) {
+		name := strings.Replace(key, "_", "-", -1)
+		flag := lookupFlag(name, flagSets)
+		if flag == nil {
+			return errors.Errorf("[%s]: unknown option %q", section, key)
+		}
+		if flag.Changed || set[name] {
+			continue
+		}
+		if _, found := os.LookupEnv(fs.OptionToEnv(name)); found {
+			continue
+		}
+		value := config.FileGet(section, key)
+		if err := flag.Value.Set(value); err != nil {
+			return errors.Wrapf(err, "[%s]: bad value for %q", section, key)
+		}
+		set[name] = true
+		fs.Debugf(nil, "Set --%s to %q from [%s]", name, value, section)
+	}
+	return nil
+}
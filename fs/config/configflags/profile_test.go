@@ -0,0 +1,93 @@
+package configflags
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/pingme998/rclone/fs"
+	"github.com/pingme998/rclone/fs/config"
+	"github.com/pingme998/rclone/fs/config/configfile"
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyProfiles(t *testing.T) {
+	configData := `[profile:slow-link]
+transfers = 2
+checkers = 3
+
+[command:sync]
+transfers = 8
+checkers = 16
+dry_run = true
+
+[command:bad]
+potato = 1
+`
+	fd, err := ioutil.TempFile("", "rclone-profile-test")
+	require.NoError(t, err)
+	_, err = fd.Write([]byte(configData))
+	require.NoError(t, err)
+	require.NoError(t, fd.Close())
+	defer func() {
+		_ = os.Remove(fd.Name())
+	}()
+
+	ctx := context.Background()
+	ci := fs.GetConfig(ctx)
+	oldConfig := *ci
+	oldConfigPath := config.GetConfigPath()
+	oldProfile := profile
+	defer func() {
+		*ci = oldConfig
+		profile = oldProfile
+		configPath = oldConfigPath
+		assert.NoError(t, config.SetConfigPath(oldConfigPath))
+	}()
+	configfile.Install()
+
+	// Make a flag set with some of the options and apply the
+	// profiles to it
+	apply := func(command string, args ...string) error {
+		newCi := fs.NewConfig()
+		*ci = *newCi
+		flagSet := pflag.NewFlagSet("test", pflag.ContinueOnError)
+		flagSet.IntVar(&ci.Transfers, "transfers", ci.Transfers, "")
+		flagSet.IntVar(&ci.Checkers, "checkers", ci.Checkers, "")
+		flagSet.BoolVar(&ci.DryRun, "dry-run", ci.DryRun, "")
+		require.NoError(t, flagSet.Parse(args))
+		configPath = fd.Name()
+		require.NoError(t, config.SetConfigPath(fd.Name()))
+		return ApplyProfiles(ci, command, flagSet)
+	}
+
+	profile = ""
+	err = apply("")
+	require.NoError(t, err)
+	assert.Equal(t, 4, ci.Transfers)
+
+	err = apply("sync")
+	require.NoError(t, err)
+	assert.Equal(t, 8, ci.Transfers)
+	assert.Equal(t, 16, ci.Checkers)
+	assert.True(t, ci.DryRun)
+
+	// Command line beats the profile which beats the command defaults
+	profile = "slow-link"
+	err = apply("sync", "--checkers=5")
+	require.NoError(t, err)
+	assert.Equal(t, 2, ci.Transfers)
+	assert.Equal(t, 5, ci.Checkers)
+	assert.True(t, ci.DryRun)
+
+	profile = "potato"
+	err = apply("sync")
+	assert.EqualError(t, err, `profile "potato" not found - add a [profile:potato] section to the config file`)
+
+	profile = ""
+	err = apply("bad")
+	assert.EqualError(t, err, `[command:bad]: unknown option "potato"`)
+}
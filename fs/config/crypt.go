@@ -12,6 +12,7 @@ import (
 	"io/ioutil"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
 
 	"github.com/pkg/errors"
@@ -19,6 +20,7 @@ import (
 
 	"github.com/pingme998/rclone/fs"
 	"github.com/pingme998/rclone/fs/config/obscure"
+	"github.com/pingme998/rclone/lib/keyring"
 )
 
 var (
@@ -40,13 +42,124 @@ var (
 	// loaded. This can be used to pass the configKey to a child
 	// process.
 	PassConfigKeyForDaemonization = false
+
+	// Functions to access the OS keyring - overridden in the tests
+	keyringGet    = keyring.Get
+	keyringSet    = keyring.Set
+	keyringDelete = keyring.Delete
 )
 
+// keyringService is the name the configuration password is stored
+// under in the OS keyring along with the path of the config file
+const keyringService = "rclone"
+
+// useKeyring returns true if --password-store is set to use the OS
+// keyring
+func useKeyring(ci *fs.ConfigInfo) (bool, error) {
+	switch ci.PasswordStore {
+	case "":
+		return false, nil
+	case "keyring":
+		return true, nil
+	}
+	return false, errors.Errorf("unknown --password-store %q - must be keyring", ci.PasswordStore)
+}
+
+// keyringUser returns the user the configuration password is stored
+// under in the OS keyring
+func keyringUser() string {
+	path := GetConfigPath()
+	if IsRemoteConfigPath(path) {
+		return path
+	}
+	if absPath, err := filepath.Abs(path); err == nil {
+		path = absPath
+	}
+	return path
+}
+
+// getKeyringPassword sets the configKey from the password in the OS
+// keyring returning true if it was found
+func getKeyringPassword() bool {
+	password, err := keyringGet(keyringService, keyringUser())
+	if err == keyring.ErrNotFound {
+		fs.Debugf(nil, "Configuration password not found in keyring")
+		return false
+	} else if err != nil {
+		fs.Errorf(nil, "Failed to read configuration password from keyring: %v", err)
+		return false
+	}
+	err = SetConfigPassword(password)
+	if err != nil {
+		fs.Errorf(nil, "Using configuration password from keyring returned: %v", err)
+		return false
+	}
+	fs.Debugf(nil, "Using configuration password from keyring.")
+	return true
+}
+
+// setKeyringPassword stores password in the OS keyring
+func setKeyringPassword(password string) {
+	err := keyringSet(keyringService, keyringUser(), password)
+	if err != nil {
+		fs.Errorf(nil, "Failed to store configuration password in keyring: %v", err)
+		return
+	}
+	fs.Infof(nil, "Stored configuration password in keyring")
+}
+
+// deleteKeyringPassword removes the password from the OS keyring
+func deleteKeyringPassword() {
+	err := keyringDelete(keyringService, keyringUser())
+	if err != nil && err != keyring.ErrNotFound {
+		fs.Errorf(nil, "Failed to remove configuration password from keyring: %v", err)
+	}
+}
+
+// NeedsPassword returns true if loading the config would have to ask
+// the user for the password, ie the config file is encrypted and the
+// password can't be found any other way.
+func NeedsPassword() bool {
+	if len(configKey) != 0 || os.Getenv("RCLONE_CONFIG_PASS") != "" {
+		return false
+	}
+	ci := fs.GetConfig(context.Background())
+	if len(ci.PasswordCommand) != 0 || ci.PasswordStore != "" {
+		return false
+	}
+	configPath := GetConfigPath()
+	if configPath == "" || IsRemoteConfigPath(configPath) {
+		return false
+	}
+	fd, err := os.Open(configPath)
+	if err != nil {
+		return false
+	}
+	defer func() {
+		_ = fd.Close()
+	}()
+	scanner := bufio.NewScanner(fd)
+	for scanner.Scan() {
+		l := strings.TrimSpace(scanner.Text())
+		if len(l) == 0 || strings.HasPrefix(l, ";") || strings.HasPrefix(l, "#") {
+			continue
+		}
+		return strings.HasPrefix(l, "RCLONE_ENCRYPT_V")
+	}
+	return false
+}
+
 // Decrypt will automatically decrypt a reader
 func Decrypt(b io.ReadSeeker) (io.Reader, error) {
 	ctx := context.Background()
 	ci := fs.GetConfig(ctx)
 	var usingPasswordCommand bool
+	usingKeyring, err := useKeyring(ci)
+	if err != nil {
+		return nil, err
+	}
+	// The password to store in the keyring if it decrypts the config
+	var storePassword string
 
 	// Find first non-empty line
 	r := bufio.NewReader(b)
@@ -110,6 +223,8 @@ func Decrypt(b io.ReadSeeker) (io.Reader, error) {
 				return nil, errors.New("unable to decrypt configuration: incorrect password")
 			}
 			usingPasswordCommand = true
+		} else if usingKeyring && getKeyringPassword() {
+			usingPasswordCommand = false
 		} else {
 			usingPasswordCommand = false
 
@@ -121,6 +236,7 @@ func Decrypt(b io.ReadSeeker) (io.Reader, error) {
 					fs.Errorf(nil, "Using RCLONE_CONFIG_PASS returned: %v", err)
 				} else {
 					fs.Debugf(nil, "Using RCLONE_CONFIG_PASS password.")
+					storePassword = envpw
 				}
 			}
 		}
@@ -162,7 +278,7 @@ func Decrypt(b io.ReadSeeker) (io.Reader, error) {
 				if !ci.AskPassword {
 					return nil, errors.New("unable to decrypt configuration and not allowed to ask for password - set RCLONE_CONFIG_PASS to your configuration password")
 				}
-				getConfigPassword("Enter configuration password:")
+				storePassword = getConfigPassword("Enter configuration password:")
 			}
 		}
 
@@ -182,6 +298,10 @@ func Decrypt(b io.ReadSeeker) (io.Reader, error) {
 		// Retry
 		fs.Errorf(nil, "Couldn't decrypt configuration, most likely wrong password.")
 		configKey = nil
+		storePassword = ""
+	}
+	if usingKeyring && storePassword != "" {
+		setKeyringPassword(storePassword)
 	}
 	return bytes.NewReader(out), nil
 }
@@ -225,16 +345,16 @@ func Encrypt(src io.Reader, dst io.Writer) error {
 }
 
 // getConfigPassword will query the user for a password the
-// first time it is required.
-func getConfigPassword(q string) {
+// first time it is required returning the password entered.
+func getConfigPassword(q string) string {
 	if len(configKey) != 0 {
-		return
+		return ""
 	}
 	for {
 		password := GetPassword(q)
 		err := SetConfigPassword(password)
 		if err == nil {
-			return
+			return password
 		}
 		_, _ = fmt.Fprintln(os.Stderr, "Error:", err)
 	}
@@ -298,9 +418,13 @@ func ClearConfigPassword() {
 // for a password. If the same password is entered
 // twice the key is updated.
 func changeConfigPassword() {
-	err := SetConfigPassword(ChangePassword("NEW configuration"))
+	password := ChangePassword("NEW configuration")
+	err := SetConfigPassword(password)
 	if err != nil {
 		fmt.Printf("Failed to set config password: %v\n", err)
 		return
 	}
+	if usingKeyring, _ := useKeyring(fs.GetConfig(context.Background())); usingKeyring {
+		setKeyringPassword(password)
+	}
 }
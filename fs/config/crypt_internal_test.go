@@ -1,8 +1,13 @@
 package config
 
 import (
+	"context"
+	"io/ioutil"
+	"os"
 	"testing"
 
+	"github.com/pingme998/rclone/fs"
+	"github.com/pingme998/rclone/lib/keyring"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -46,3 +51,68 @@ func TestPassword(t *testing.T) {
 	hashedKeyCompare(t, "abcdef", "ABCDEF", false)
 
 }
+
+func TestDecryptKeyring(t *testing.T) {
+	ctx := context.Background()
+	ci := fs.GetConfig(ctx)
+	oldConfig := *ci
+	oldGet, oldSet := keyringGet, keyringSet
+	defer func() {
+		*ci = oldConfig
+		keyringGet, keyringSet = oldGet, oldSet
+		configKey = nil
+	}()
+
+	// Fake keyring
+	store := map[string]string{}
+	keyringGet = func(service, user string) (string, error) {
+		assert.Equal(t, keyringService, service)
+		password, ok := store[user]
+		if !ok {
+			return "", keyring.ErrNotFound
+		}
+		return password, nil
+	}
+	keyringSet = func(service, user, password string) error {
+		assert.Equal(t, keyringService, service)
+		store[user] = password
+		return nil
+	}
+
+	decrypt := func() error {
+		configKey = nil
+		fd, err := os.Open("./testdata/encrypted.conf")
+		require.NoError(t, err)
+		defer func() {
+			require.NoError(t, fd.Close())
+		}()
+		out, err := Decrypt(fd)
+		if err != nil {
+			return err
+		}
+		_, err = ioutil.ReadAll(out)
+		return err
+	}
+
+	ci.AskPassword = false
+	ci.PasswordStore = "potato"
+	assert.EqualError(t, decrypt(), `unknown --password-store "potato" - must be keyring`)
+
+	// Not in the keyring and no other password
+	ci.PasswordStore = "keyring"
+	assert.Error(t, decrypt())
+	assert.Equal(t, 0, len(store))
+
+	// Password from the environment is stored in the keyring
+	require.NoError(t, os.Setenv("RCLONE_CONFIG_PASS", "asdf"))
+	require.NoError(t, decrypt())
+	require.NoError(t, os.Unsetenv("RCLONE_CONFIG_PASS"))
+	assert.Equal(t, map[string]string{keyringUser(): "asdf"}, store)
+
+	// Password is read from the keyring
+	require.NoError(t, decrypt())
+
+	// Wrong password in the keyring
+	store[keyringUser()] = "wrong"
+	assert.Error(t, decrypt())
+}
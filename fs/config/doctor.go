@@ -0,0 +1,425 @@
+package config
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/pingme998/rclone/fs"
+	"github.com/pingme998/rclone/fs/config/configmap"
+	"github.com/pingme998/rclone/fs/config/obscure"
+	"github.com/pingme998/rclone/fs/fserrors"
+	"github.com/pingme998/rclone/fs/fshttp"
+	"github.com/pingme998/rclone/fs/rc"
+)
+
+// Status of a Check
+const (
+	CheckOK      = "ok"
+	CheckWarning = "warning"
+	CheckFailed  = "failed"
+	CheckSkipped = "skipped"
+)
+
+// Thresholds for the doctor checks to warn at
+const (
+	maxClockSkew      = time.Minute
+	minCertificateAge = 14 * 24 * time.Hour
+)
+
+// Check is the result of a single diagnostic check
+type Check struct {
+	Name     string    `json:"name"`              // name of the check, eg "dns"
+	Status   string    `json:"status"`            // one of ok, warning, failed or skipped
+	Duration float64   `json:"duration"`          // how long the check took in seconds
+	Message  string    `json:"message,omitempty"` // human readable result
+	Error    string    `json:"error,omitempty"`   // the error if the check failed
+	Class    string    `json:"class,omitempty"`   // class of the error - fatal, retry or noRetry
+	Info     rc.Params `json:"info,omitempty"`    // extra structured information
+}
+
+// Diagnosis is the result of running a series of checks
+type Diagnosis struct {
+	OK     bool     `json:"ok"`     // set if none of the checks failed
+	Checks []*Check `json:"checks"` // the checks in the order they were run
+}
+
+// newDiagnosis makes an empty Diagnosis
+func newDiagnosis() *Diagnosis {
+	return &Diagnosis{
+		OK:     true,
+		Checks: []*Check{},
+	}
+}
+
+// run the check called name with fn recording the result
+//
+// fn may set the Status to CheckWarning or CheckSkipped. If it returns
+// an error then the check fails and the error is returned.
+func (d *Diagnosis) run(name string, fn func(c *Check) error) error {
+	c := &Check{
+		Name: name,
+		Info: rc.Params{},
+	}
+	start := time.Now()
+	err := fn(c)
+	c.Duration = time.Since(start).Seconds()
+	if err != nil {
+		c.Status = CheckFailed
+		c.Error = err.Error()
+		c.Class = errorClass(err)
+		d.OK = false
+	} else if c.Status == "" {
+		c.Status = CheckOK
+	}
+	if len(c.Info) == 0 {
+		c.Info = nil
+	}
+	d.Checks = append(d.Checks, c)
+	return err
+}
+
+// skip records the check called name as skipped because of why
+func (d *Diagnosis) skip(name, why string) {
+	d.Checks = append(d.Checks, &Check{
+		Name:    name,
+		Status:  CheckSkipped,
+		Message: why,
+	})
+}
+
+// errorClass returns the class of err in the same terms as the
+// accounting
+func errorClass(err error) string {
+	switch {
+	case fserrors.IsFatalError(err):
+		return "fatal"
+	case fserrors.ShouldRetry(err):
+		return "retry"
+	case fserrors.IsNoRetryError(err):
+		return "noRetry"
+	}
+	return ""
+}
+
+// checkConnect makes the Fs with newFs then lists the root of it to
+// check the credentials work.
+func (d *Diagnosis) checkConnect(ctx context.Context, newFs func() (fs.Fs, error)) {
+	var f fs.Fs
+	err := d.run("connect", func(c *Check) (err error) {
+		f, err = newFs()
+		if err == fs.ErrorIsFile {
+			err = nil
+		}
+		if err != nil {
+			return err
+		}
+		c.Message = fmt.Sprintf("Connected to %s", f)
+		return nil
+	})
+	if err != nil {
+		d.skip("list", "couldn't connect")
+		return
+	}
+	_ = d.run("list", func(c *Check) error {
+		entries, err := f.List(ctx, "")
+		if err != nil {
+			return err
+		}
+		c.Message = fmt.Sprintf("Listed %d entries in the root", len(entries))
+		c.Info["entries"] = len(entries)
+		return nil
+	})
+}
+
+// ValidateRemote checks the proposed configuration for a remote of
+// remoteType with parameters works without saving it in the config
+// file.
+//
+// It checks that the required options are present, that the backend
+// can be created and that the root can be listed, which checks the
+// credentials.
+//
+// Passwords in parameters are obscured according to opt in the same
+// way as CreateRemote.
+func ValidateRemote(ctx context.Context, remoteType string, parameters rc.Params, opt UpdateRemoteOpt) (*Diagnosis, error) {
+	if opt.Obscure && opt.NoObscure {
+		return nil, errors.New("can't use --obscure and --no-obscure together")
+	}
+	d := newDiagnosis()
+	var ri *fs.RegInfo
+	m := configmap.Simple{}
+	err := d.run("config", func(c *Check) (err error) {
+		ri, err = fs.Find(remoteType)
+		if err != nil {
+			return errors.Errorf("couldn't find backend for type %q", remoteType)
+		}
+		for k, v := range parameters {
+			vStr := fmt.Sprint(v)
+			if option := ri.Options.Get(k); option != nil && option.IsPassword && !opt.NoObscure {
+				if _, err := obscure.Reveal(vStr); err != nil || opt.Obscure {
+					vStr, err = obscure.Obscure(vStr)
+					if err != nil {
+						return errors.Wrap(err, "obscure failed")
+					}
+				}
+			}
+			m.Set(k, vStr)
+		}
+		var missing []string
+		for _, option := range ri.Options {
+			if _, found := m.Get(option.Name); option.Required && !found && option.String() == "" {
+				missing = append(missing, option.Name)
+			}
+		}
+		if len(missing) > 0 {
+			return errors.Errorf("missing required options: %s", strings.Join(missing, ", "))
+		}
+		c.Message = fmt.Sprintf("Config for %q backend is complete", remoteType)
+		return nil
+	})
+	if err != nil {
+		d.skip("connect", "config is invalid")
+		d.skip("list", "config is invalid")
+		return d, nil
+	}
+	// Use an on the fly name so nothing is written to the config file
+	name := ":" + remoteType
+	d.checkConnect(ctx, func() (fs.Fs, error) {
+		return ri.NewFs(ctx, name, "", fs.ConfigMap(ri, name, m))
+	})
+	return d, nil
+}
+
+// doctorEndpoints are the hosts to check for backends which don't
+// have them in their config
+var doctorEndpoints = map[string]string{
+	"b2":                   "https://api.backblazeb2.com/",
+	"box":                  "https://api.box.com/",
+	"drive":                "https://www.googleapis.com/",
+	"dropbox":              "https://api.dropboxapi.com/",
+	"google cloud storage": "https://storage.googleapis.com/",
+	"onedrive":             "https://graph.microsoft.com/",
+	"pcloud":               "https://api.pcloud.com/",
+	"s3":                   "https://s3.amazonaws.com/",
+}
+
+// doctorKeys are config keys which hold a host name or URL
+var doctorKeys = map[string]bool{
+	"endpoint": true,
+	"host":     true,
+	"hostname": true,
+	"server":   true,
+	"url":      true,
+}
+
+// remoteEndpoints returns the endpoints the remote called name
+// connects to as read from its config. URLs without a scheme only
+// have the host checked.
+func remoteEndpoints(name string, ri *fs.RegInfo) (endpoints []*url.URL) {
+	seen := map[string]bool{}
+	add := func(value string) {
+		if !strings.Contains(value, "://") {
+			value = "//" + value
+		}
+		u, err := url.Parse(value)
+		if err != nil || u.Hostname() == "" || seen[u.Host] {
+			return
+		}
+		seen[u.Host] = true
+		endpoints = append(endpoints, u)
+	}
+	for _, key := range LoadedData().GetKeyList(name) {
+		if option := ri.Options.Get(key); option != nil && option.IsPassword {
+			continue
+		}
+		value := FileGet(name, key)
+		if doctorKeys[key] || strings.HasPrefix(value, "https://") || strings.HasPrefix(value, "http://") {
+			add(value)
+		}
+	}
+	if len(endpoints) == 0 && doctorEndpoints[ri.Name] != "" {
+		add(doctorEndpoints[ri.Name])
+	}
+	return endpoints
+}
+
+// checkDNS looks up the hosts of endpoints
+func (d *Diagnosis) checkDNS(ctx context.Context, endpoints []*url.URL) {
+	_ = d.run("dns", func(c *Check) error {
+		for _, u := range endpoints {
+			host := u.Hostname()
+			if net.ParseIP(host) != nil {
+				c.Info[host] = []string{host}
+				continue
+			}
+			addrs, err := net.DefaultResolver.LookupHost(ctx, host)
+			if err != nil {
+				return errors.Wrapf(err, "failed to look up %q", host)
+			}
+			c.Info[host] = addrs
+		}
+		c.Message = fmt.Sprintf("Resolved %d hosts", len(endpoints))
+		return nil
+	})
+}
+
+// checkTLS connects to the https endpoints and checks their
+// certificates
+func (d *Diagnosis) checkTLS(ctx context.Context, endpoints []*url.URL) {
+	ci := fs.GetConfig(ctx)
+	var hosts []string
+	for _, u := range endpoints {
+		if u.Scheme == "https" {
+			hosts = append(hosts, u.Host)
+		}
+	}
+	if len(hosts) == 0 {
+		d.skip("tls", "no https endpoints found")
+		return
+	}
+	_ = d.run("tls", func(c *Check) error {
+		for _, host := range hosts {
+			addr := host
+			if _, _, err := net.SplitHostPort(addr); err != nil {
+				addr = net.JoinHostPort(addr, "443")
+			}
+			dialer := &net.Dialer{Timeout: ci.ConnectTimeout}
+			conn, err := tls.DialWithDialer(dialer, "tcp", addr, &tls.Config{
+				InsecureSkipVerify: ci.InsecureSkipVerify,
+			})
+			if err != nil {
+				return errors.Wrapf(err, "TLS connection to %q failed", host)
+			}
+			state := conn.ConnectionState()
+			_ = conn.Close()
+			info := rc.Params{
+				"version": tlsVersion(state.Version),
+			}
+			if len(state.PeerCertificates) > 0 {
+				cert := state.PeerCertificates[0]
+				info["subject"] = cert.Subject.CommonName
+				info["expires"] = cert.NotAfter
+				if time.Until(cert.NotAfter) < minCertificateAge {
+					c.Status = CheckWarning
+					c.Message = fmt.Sprintf("Certificate for %q expires at %v", host, cert.NotAfter)
+				}
+			}
+			c.Info[host] = info
+		}
+		if c.Message == "" {
+			c.Message = fmt.Sprintf("Checked %d TLS connections", len(hosts))
+		}
+		return nil
+	})
+}
+
+// tlsVersion returns a readable name for the TLS version
+func tlsVersion(version uint16) string {
+	switch version {
+	case tls.VersionTLS10:
+		return "TLS 1.0"
+	case tls.VersionTLS11:
+		return "TLS 1.1"
+	case tls.VersionTLS12:
+		return "TLS 1.2"
+	case tls.VersionTLS13:
+		return "TLS 1.3"
+	}
+	return fmt.Sprintf("0x%04x", version)
+}
+
+// checkClock compares the local clock with the Date header returned
+// by the first http or https endpoint
+func (d *Diagnosis) checkClock(ctx context.Context, endpoints []*url.URL) {
+	var endpoint *url.URL
+	for _, u := range endpoints {
+		if u.Scheme == "https" || u.Scheme == "http" {
+			endpoint = u
+			break
+		}
+	}
+	if endpoint == nil {
+		d.skip("clock", "no http endpoints found")
+		return
+	}
+	_ = d.run("clock", func(c *Check) error {
+		req, err := http.NewRequest("HEAD", endpoint.String(), nil)
+		if err != nil {
+			return err
+		}
+		req = req.WithContext(ctx)
+		resp, err := fshttp.NewClient(ctx).Do(req)
+		if err != nil {
+			return errors.Wrapf(err, "failed to read time from %q", endpoint.Host)
+		}
+		_ = resp.Body.Close()
+		date, err := http.ParseTime(resp.Header.Get("Date"))
+		if err != nil {
+			c.Status = CheckSkipped
+			c.Message = fmt.Sprintf("%q didn't return the time", endpoint.Host)
+			return nil
+		}
+		skew := time.Since(date).Truncate(time.Second)
+		c.Info["host"] = endpoint.Host
+		c.Info["skew"] = skew.Seconds()
+		if skew < -maxClockSkew || skew > maxClockSkew {
+			c.Status = CheckWarning
+			c.Message = fmt.Sprintf("Local clock differs from %q by %v - this may cause authentication failures", endpoint.Host, skew)
+		} else {
+			c.Message = fmt.Sprintf("Local clock is within %v of %q", maxClockSkew, endpoint.Host)
+		}
+		return nil
+	})
+}
+
+// DoctorRemote runs diagnostic checks on the remote called name from
+// the config file.
+//
+// It checks the DNS, TLS and clock skew for the endpoints the remote
+// uses then connects to the remote and lists the root.
+func DoctorRemote(ctx context.Context, name string) (*Diagnosis, error) {
+	if !LoadedData().HasSection(name) || !IsRemoteSection(name) {
+		return nil, errors.Errorf("remote %q not found in config", name)
+	}
+	d := newDiagnosis()
+	var ri *fs.RegInfo
+	err := d.run("config", func(c *Check) (err error) {
+		fsType := FileGet(name, "type")
+		if fsType == "" {
+			return errors.New("couldn't find type field in config")
+		}
+		ri, err = fs.Find(fsType)
+		if err != nil {
+			return errors.Errorf("couldn't find backend for type %q", fsType)
+		}
+		c.Message = fmt.Sprintf("Remote is a %q backend", fsType)
+		return nil
+	})
+	if err != nil {
+		for _, check := range []string{"dns", "tls", "clock", "connect", "list"} {
+			d.skip(check, "config is invalid")
+		}
+		return d, nil
+	}
+	endpoints := remoteEndpoints(name, ri)
+	if len(endpoints) == 0 {
+		for _, check := range []string{"dns", "tls", "clock"} {
+			d.skip(check, "no network endpoints found in config")
+		}
+	} else {
+		d.checkDNS(ctx, endpoints)
+		d.checkTLS(ctx, endpoints)
+		d.checkClock(ctx, endpoints)
+	}
+	d.checkConnect(ctx, func() (fs.Fs, error) {
+		return fs.NewFs(ctx, name+":")
+	})
+	return d, nil
+}
@@ -73,7 +73,7 @@ See the [listremotes command](/commands/rclone_listremotes/) command for more in
 // Return the a list of remotes in the config file
 func rcListRemotes(ctx context.Context, in rc.Params) (out rc.Params, err error) {
 	var remotes = []string{}
-	for _, remote := range LoadedData().GetSectionList() {
+	for _, remote := range remoteSections() {
 		remotes = append(remotes, remote)
 	}
 	out = rc.Params{
@@ -220,3 +220,114 @@ func rcDelete(ctx context.Context, in rc.Params) (out rc.Params, err error) {
 	DeleteRemote(name)
 	return nil, nil
 }
+
+// Shared help for the diagnostic calls
+const diagnosisHelp = `
+Returns a JSON object:
+
+- ok - true if none of the checks failed
+- checks - an array of the checks run, each with
+    - name - name of the check
+    - status - one of "ok", "warning", "failed" or "skipped"
+    - duration - how long the check took in seconds
+    - message - human readable result of the check
+    - error - the error if the check failed
+    - class - class of the error if known - "fatal", "retry" or "noRetry"
+    - info - extra information about the check
+
+The call only returns an error if the parameters are wrong. A
+configuration which doesn't work is reported in the checks.
+`
+
+func init() {
+	rc.Add(rc.Call{
+		Path:         "config/validate",
+		Fn:           rcValidate,
+		Title:        "Check a proposed configuration for a remote works.",
+		AuthRequired: true,
+		Help: `This takes the following parameters
+
+- type - type of the remote
+- parameters - a map of \{ "key": "value" \} pairs
+- opt - a dictionary of options
+    - obscure - declare passwords are plain and need obscuring
+    - noObscure - declare passwords are already obscured and don't need obscuring
+
+This doesn't save anything in the config file. It runs these checks
+
+- config - the backend exists and the required options are set
+- connect - the backend can be created with the parameters
+- list - the root can be listed which checks the credentials
+` + diagnosisHelp,
+	})
+}
+
+// Validate a proposed remote config
+func rcValidate(ctx context.Context, in rc.Params) (out rc.Params, err error) {
+	remoteType, err := in.GetString("type")
+	if err != nil {
+		return nil, err
+	}
+	parameters := rc.Params{}
+	err = in.GetStruct("parameters", &parameters)
+	if err != nil && !rc.IsErrParamNotFound(err) {
+		return nil, err
+	}
+	var opt UpdateRemoteOpt
+	err = in.GetStruct("opt", &opt)
+	if err != nil && !rc.IsErrParamNotFound(err) {
+		return nil, err
+	}
+	d, err := ValidateRemote(ctx, remoteType, parameters, opt)
+	if err != nil {
+		return nil, err
+	}
+	err = rc.Reshape(&out, d)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func init() {
+	rc.Add(rc.Call{
+		Path:         "config/doctor",
+		Fn:           rcDoctor,
+		Title:        "Run diagnostic checks on a remote in the config file.",
+		AuthRequired: true,
+		Help: `This takes the following parameters
+
+- name - name of remote to check
+
+This runs these checks
+
+- config - the remote's backend exists
+- dns - the hosts the remote uses can be looked up
+- tls - TLS connections can be made to the hosts and their certificates aren't about to expire
+- clock - the local clock agrees with the remote's to within a minute
+- connect - the remote can be created
+- list - the root can be listed which checks the credentials
+
+The hosts are read from the URLs and host names in the remote's
+config, or are the default hosts for common backends. The dns, tls
+and clock checks are skipped if no hosts can be found.
+` + diagnosisHelp,
+	})
+}
+
+// Run the diagnostics on a remote
+func rcDoctor(ctx context.Context, in rc.Params) (out rc.Params, err error) {
+	name, err := in.GetString("name")
+	if err != nil {
+		return nil, err
+	}
+	d, err := DoctorRemote(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	err = rc.Reshape(&out, d)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
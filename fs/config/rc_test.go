@@ -121,6 +121,36 @@ func TestRc(t *testing.T) {
 		assert.Equal(t, pw2, obscure.MustReveal(config.FileGet(testName, "test_key2")))
 	})
 
+	t.Run("Doctor", func(t *testing.T) {
+		call := rc.Calls.Get("config/doctor")
+		assert.NotNil(t, call)
+		in := rc.Params{
+			"name": testName,
+		}
+		out, err := call.Fn(context.Background(), in)
+		require.NoError(t, err)
+		require.NotNil(t, out)
+
+		var d config.Diagnosis
+		require.NoError(t, rc.Reshape(&d, out))
+		assert.True(t, d.OK)
+		status := map[string]string{}
+		for _, check := range d.Checks {
+			status[check.Name] = check.Status
+		}
+		assert.Equal(t, map[string]string{
+			"config":  config.CheckOK,
+			"dns":     config.CheckSkipped,
+			"tls":     config.CheckSkipped,
+			"clock":   config.CheckSkipped,
+			"connect": config.CheckOK,
+			"list":    config.CheckOK,
+		}, status)
+
+		_, err = call.Fn(context.Background(), rc.Params{"name": "notARemote"})
+		assert.Error(t, err)
+	})
+
 	// Delete the test remote
 	call = rc.Calls.Get("config/delete")
 	assert.NotNil(t, call)
@@ -134,6 +164,36 @@ func TestRc(t *testing.T) {
 	assert.Equal(t, "", config.FileGet(testName, "test_key"))
 }
 
+func TestRcValidate(t *testing.T) {
+	call := rc.Calls.Get("config/validate")
+	assert.NotNil(t, call)
+
+	validate := func(in rc.Params) (d config.Diagnosis) {
+		out, err := call.Fn(context.Background(), in)
+		require.NoError(t, err)
+		require.NoError(t, rc.Reshape(&d, out))
+		return d
+	}
+
+	d := validate(rc.Params{"type": "local"})
+	assert.True(t, d.OK)
+	require.Equal(t, 3, len(d.Checks))
+	for _, check := range d.Checks {
+		assert.Equal(t, config.CheckOK, check.Status, check.Name)
+	}
+
+	d = validate(rc.Params{"type": "potato"})
+	assert.False(t, d.OK)
+	require.Equal(t, 3, len(d.Checks))
+	assert.Equal(t, config.CheckFailed, d.Checks[0].Status)
+	assert.Contains(t, d.Checks[0].Error, "potato")
+	assert.Equal(t, config.CheckSkipped, d.Checks[1].Status)
+	assert.Equal(t, config.CheckSkipped, d.Checks[2].Status)
+
+	_, err := call.Fn(context.Background(), rc.Params{})
+	assert.Error(t, err)
+}
+
 func TestRcProviders(t *testing.T) {
 	call := rc.Calls.Get("config/providers")
 	assert.NotNil(t, call)
@@ -172,7 +172,7 @@ func ChooseNumber(what string, min, max int) int {
 
 // ShowRemotes shows an overview of the config file
 func ShowRemotes() {
-	remotes := LoadedData().GetSectionList()
+	remotes := remoteSections()
 	if len(remotes) == 0 {
 		return
 	}
@@ -186,7 +186,7 @@ func ShowRemotes() {
 
 // ChooseRemote chooses a remote name
 func ChooseRemote() string {
-	remotes := LoadedData().GetSectionList()
+	remotes := remoteSections()
 	sort.Strings(remotes)
 	return Choose("remote", remotes, nil, false)
 }
@@ -513,6 +513,9 @@ func CopyRemote(name string) {
 func ShowConfigLocation() {
 	if configPath := GetConfigPath(); configPath == "" {
 		fmt.Println("Configuration is in memory only")
+	} else if IsRemoteConfigPath(configPath) {
+		fmt.Println("Configuration is stored remotely at:")
+		fmt.Printf("%s\n", configPath)
 	} else {
 		if _, err := os.Stat(configPath); os.IsNotExist(err) {
 			fmt.Println("Configuration file doesn't exist, but rclone will use this path:")
@@ -538,7 +541,7 @@ func ShowConfig() {
 // EditConfig edits the config file interactively
 func EditConfig(ctx context.Context) (err error) {
 	for {
-		haveRemotes := len(LoadedData().GetSectionList()) != 0
+		haveRemotes := len(remoteSections()) != 0
 		what := []string{"eEdit existing remote", "nNew remote", "dDelete remote", "rRename remote", "cCopy remote", "sSet configuration password", "qQuit config"}
 		if haveRemotes {
 			fmt.Printf("Current remotes:\n\n")
@@ -646,6 +649,9 @@ func SetPassword() {
 			case 'u':
 				configKey = nil
 				SaveConfig()
+				if usingKeyring, _ := useKeyring(fs.GetConfig(context.Background())); usingKeyring {
+					deleteKeyringPassword()
+				}
 				continue
 			case 'q':
 				return
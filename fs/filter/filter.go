@@ -96,6 +96,13 @@ type Opt struct {
 	MinSize        fs.SizeSuffix
 	MaxSize        fs.SizeSuffix
 	IgnoreCase     bool
+	MimeInclude    []string
+	MimeExclude    []string
+	HashPresent    string
+	HashMissing    string
+	TierInclude    []string
+	TierExclude    []string
+	Profile        string
 }
 
 // DefaultOpt is the default config for the filter
@@ -115,6 +122,7 @@ type Filter struct {
 	dirRules    rules
 	files       FilesMap // files if filesFrom
 	dirs        FilesMap // dirs from filesFrom
+	metadata    metadataRules
 }
 
 // NewFilter parses the command line options and creates a Filter
@@ -142,6 +150,11 @@ func NewFilter(opt *Opt) (f *Filter, err error) {
 		fs.Debugf(nil, "--max-age %v to %v", f.Opt.MaxAge, f.ModTimeFrom)
 	}
 
+	f.metadata, err = newMetadataRules(&f.Opt)
+	if err != nil {
+		return nil, err
+	}
+
 	addImplicitExclude := false
 	foundExcludeRule := false
 
@@ -370,7 +383,8 @@ func (f *Filter) InActive() bool {
 		f.Opt.MaxSize < 0 &&
 		f.fileRules.len() == 0 &&
 		f.dirRules.len() == 0 &&
-		len(f.Opt.ExcludeFile) == 0)
+		len(f.Opt.ExcludeFile) == 0 &&
+		!f.metadata.active())
 }
 
 // includeRemote returns whether this remote passes the filter rules.
@@ -482,7 +496,10 @@ func (f *Filter) IncludeObject(ctx context.Context, o fs.Object) bool {
 		modTime = time.Unix(0, 0)
 	}
 
-	return f.Include(o.Remote(), o.Size(), modTime)
+	if !f.Include(o.Remote(), o.Size(), modTime) {
+		return false
+	}
+	return f.metadata.include(ctx, o)
 }
 
 // forEachLine calls fn on every line in the file pointed to by path
@@ -533,6 +550,10 @@ func (f *Filter) DumpFilters() string {
 	for _, dirRule := range f.dirRules.rules {
 		rules = append(rules, dirRule.String())
 	}
+	if f.metadata.active() {
+		rules = append(rules, "--- Metadata filter rules ---")
+		rules = append(rules, f.metadata.dump()...)
+	}
 	return strings.Join(rules, "\n")
 }
 
@@ -18,7 +18,7 @@ var (
 // Reload the filters from the flags
 func Reload(ctx context.Context) (err error) {
 	fi := filter.GetConfig(ctx)
-	newFilter, err := filter.NewFilter(&Opt)
+	newFilter, err := newFilter(Opt.Profile)
 	if err != nil {
 		return err
 	}
@@ -44,5 +44,12 @@ func AddFlags(flagSet *pflag.FlagSet) {
 	flags.FVarP(flagSet, &Opt.MinSize, "min-size", "", "Only transfer files bigger than this in KiB or suffix B|K|M|G|T|P")
 	flags.FVarP(flagSet, &Opt.MaxSize, "max-size", "", "Only transfer files smaller than this in KiB or suffix B|K|M|G|T|P")
 	flags.BoolVarP(flagSet, &Opt.IgnoreCase, "ignore-case", "", false, "Ignore case in filters (case insensitive)")
+	flags.StringArrayVarP(flagSet, &Opt.MimeInclude, "mime-include", "", nil, "Include only files whose mime type matches pattern, e.g. video/*")
+	flags.StringArrayVarP(flagSet, &Opt.MimeExclude, "mime-exclude", "", nil, "Exclude files whose mime type matches pattern")
+	flags.StringVarP(flagSet, &Opt.HashPresent, "hash-present", "", "", "Include only files which have a hash of this type, e.g. MD5")
+	flags.StringVarP(flagSet, &Opt.HashMissing, "hash-missing", "", "", "Include only files which don't have a hash of this type")
+	flags.StringArrayVarP(flagSet, &Opt.TierInclude, "tier-include", "", nil, "Include only files in this storage tier, e.g. GLACIER")
+	flags.StringArrayVarP(flagSet, &Opt.TierExclude, "tier-exclude", "", nil, "Exclude files in this storage tier")
+	flags.StringVarP(flagSet, &Opt.Profile, "filter-profile", "", "", "Add the filter rules from this named profile in the config file")
 	//cvsExclude     = BoolP("cvs-exclude", "C", false, "Exclude files in the same way CVS does")
 }
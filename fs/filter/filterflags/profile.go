@@ -0,0 +1,185 @@
+package filterflags
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/pingme998/rclone/fs"
+	"github.com/pingme998/rclone/fs/config"
+	"github.com/pingme998/rclone/fs/filter"
+	"github.com/pingme998/rclone/fs/fspath"
+	"github.com/pkg/errors"
+)
+
+// profileKey is the key in a remote's config section which names its
+// default filter profile
+const profileKey = "filter_profile"
+
+// addList parses value as a comma separated list and appends it to
+// the rules in *list
+func addList(list *[]string) func(string) error {
+	return func(value string) error {
+		var items fs.CommaSepList
+		if err := items.Set(value); err != nil {
+			return err
+		}
+		*list = append(*list, items...)
+		return nil
+	}
+}
+
+// setString sets *s to value unless it was set on the command line
+func setString(s *string) func(string) error {
+	return func(value string) error {
+		if *s == "" {
+			*s = value
+		}
+		return nil
+	}
+}
+
+// setBool sets *b if value is true
+func setBool(b *bool) func(string) error {
+	return func(value string) error {
+		v, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		*b = *b || v
+		return nil
+	}
+}
+
+// setDuration sets *d to value unless it was set on the command line
+func setDuration(d *fs.Duration) func(string) error {
+	return func(value string) error {
+		if d.IsSet() {
+			return nil
+		}
+		return d.Set(value)
+	}
+}
+
+// setSize sets *size to value unless it was set on the command line
+func setSize(size *fs.SizeSuffix) func(string) error {
+	return func(value string) error {
+		if *size >= 0 {
+			return nil
+		}
+		return size.Set(value)
+	}
+}
+
+// profileSetters returns the keys which may be used in a filter
+// profile along with how to merge each of them into opt.
+//
+// The keys are the names of the command line flags with "-" replaced
+// by "_" as elsewhere in the config file.
+func profileSetters(opt *filter.Opt) map[string]func(string) error {
+	return map[string]func(string) error{
+		"delete_excluded":    setBool(&opt.DeleteExcluded),
+		"filter":             addList(&opt.FilterRule),
+		"filter_from":        addList(&opt.FilterFrom),
+		"exclude":            addList(&opt.ExcludeRule),
+		"exclude_from":       addList(&opt.ExcludeFrom),
+		"exclude_if_present": setString(&opt.ExcludeFile),
+		"include":            addList(&opt.IncludeRule),
+		"include_from":       addList(&opt.IncludeFrom),
+		"files_from":         addList(&opt.FilesFrom),
+		"files_from_raw":     addList(&opt.FilesFromRaw),
+		"min_age":            setDuration(&opt.MinAge),
+		"max_age":            setDuration(&opt.MaxAge),
+		"min_size":           setSize(&opt.MinSize),
+		"max_size":           setSize(&opt.MaxSize),
+		"ignore_case":        setBool(&opt.IgnoreCase),
+		"mime_include":       addList(&opt.MimeInclude),
+		"mime_exclude":       addList(&opt.MimeExclude),
+		"hash_present":       setString(&opt.HashPresent),
+		"hash_missing":       setString(&opt.HashMissing),
+		"tier_include":       addList(&opt.TierInclude),
+		"tier_exclude":       addList(&opt.TierExclude),
+	}
+}
+
+// applyProfile merges the filter profile called name from the config
+// file into opt.
+//
+// Rules from the profile are added after those from the command line
+// so the command line takes precedence, as it does for single valued
+// options.
+func applyProfile(opt *filter.Opt, name string) error {
+	section := config.FilterProfilePrefix + name
+	if !config.LoadedData().HasSection(section) {
+		return errors.Errorf("filter profile %q not found - add a [%s] section to the config file", name, section)
+	}
+	setters := profileSetters(opt)
+	for _, key := range config.LoadedData().GetKeyList(section) {
+		set, ok := setters[key]
+		if !ok {
+			return errors.Errorf("filter profile %q: unknown key %q", name, key)
+		}
+		if err := set(config.FileGet(section, key)); err != nil {
+			return errors.Wrapf(err, "filter profile %q: bad %q", name, key)
+		}
+	}
+	fs.Debugf(nil, "Using filter profile %q", name)
+	return nil
+}
+
+// newFilter makes a filter from Opt and the filter profile named by
+// profile if set
+func newFilter(profile string) (*filter.Filter, error) {
+	opt := Opt
+	if profile != "" {
+		// copy the slices so the profile doesn't modify Opt
+		opt = copyOpt(Opt)
+		if err := applyProfile(&opt, profile); err != nil {
+			return nil, err
+		}
+	}
+	return filter.NewFilter(&opt)
+}
+
+// copyOpt returns a copy of opt which doesn't share any slices with it
+func copyOpt(opt filter.Opt) filter.Opt {
+	for _, list := range []*[]string{
+		&opt.FilterRule, &opt.FilterFrom, &opt.ExcludeRule, &opt.ExcludeFrom,
+		&opt.IncludeRule, &opt.IncludeFrom, &opt.FilesFrom, &opt.FilesFromRaw,
+		&opt.MimeInclude, &opt.MimeExclude, &opt.TierInclude, &opt.TierExclude,
+	} {
+		*list = append([]string(nil), *list...)
+	}
+	return opt
+}
+
+// AddRemoteProfile returns a context whose filter is the filter in
+// ctx with the default filter profile of the remote in fsString
+// added, if it has one.
+//
+// The filter in ctx isn't modified so the profile only applies to
+// the users of the returned context.
+//
+// It returns ctx unchanged if --filter-profile was given as that
+// takes precedence.
+func AddRemoteProfile(ctx context.Context, fsString string) (context.Context, error) {
+	if Opt.Profile != "" {
+		return ctx, nil
+	}
+	parsed, err := fspath.Parse(fsString)
+	if err != nil || parsed.Name == "" || parsed.Name[0] == ':' {
+		return ctx, nil
+	}
+	profile := config.FileGet(parsed.Name, profileKey)
+	if profile == "" {
+		return ctx, nil
+	}
+	opt := copyOpt(filter.GetConfig(ctx).Opt)
+	if err := applyProfile(&opt, profile); err != nil {
+		return ctx, errors.Wrapf(err, "remote %q", parsed.Name)
+	}
+	newFilter, err := filter.NewFilter(&opt)
+	if err != nil {
+		return ctx, errors.Wrapf(err, "remote %q", parsed.Name)
+	}
+	return filter.ReplaceConfig(ctx, newFilter), nil
+}
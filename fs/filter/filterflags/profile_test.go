@@ -0,0 +1,126 @@
+package filterflags
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pingme998/rclone/fs"
+	"github.com/pingme998/rclone/fs/config"
+	"github.com/pingme998/rclone/fs/config/configfile"
+	"github.com/pingme998/rclone/fs/filter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testConfig = `[filter-profile:media-only]
+include = *.jpg,*.mp4
+min_size = 1k
+ignore_case = true
+
+[filter-profile:bad]
+potato = true
+
+[photos]
+type = local
+filter_profile = media-only
+
+[plain]
+type = local
+`
+
+// setupConfig installs testConfig as the config file, returning a
+// function to restore the old one
+func setupConfig(t *testing.T) func() {
+	dir, err := ioutil.TempDir("", "rclone-filter-profile-test")
+	require.NoError(t, err)
+	path := filepath.Join(dir, "rclone.conf")
+	require.NoError(t, ioutil.WriteFile(path, []byte(testConfig), 0600))
+	oldConfigPath := config.GetConfigPath()
+	oldOpt := Opt
+	config.ClearConfigPassword()
+	require.NoError(t, config.SetConfigPath(path))
+	configfile.Install()
+	return func() {
+		Opt = oldOpt
+		assert.NoError(t, config.SetConfigPath(oldConfigPath))
+		configfile.Install()
+		assert.NoError(t, os.RemoveAll(dir))
+	}
+}
+
+func TestApplyProfile(t *testing.T) {
+	defer setupConfig(t)()
+
+	opt := filter.DefaultOpt
+	opt.IncludeRule = []string{"*.png"}
+	opt.MinSize = fs.SizeSuffix(100)
+	require.NoError(t, applyProfile(&opt, "media-only"))
+	assert.Equal(t, []string{"*.png", "*.jpg", "*.mp4"}, opt.IncludeRule)
+	assert.Equal(t, fs.SizeSuffix(100), opt.MinSize)
+	assert.True(t, opt.IgnoreCase)
+
+	err := applyProfile(&opt, "missing")
+	assert.Error(t, err)
+	err = applyProfile(&opt, "bad")
+	assert.Error(t, err)
+}
+
+func TestReloadProfile(t *testing.T) {
+	defer setupConfig(t)()
+	ctx, fi := filter.AddConfig(context.Background())
+
+	Opt = filter.DefaultOpt
+	Opt.Profile = "media-only"
+	require.NoError(t, Reload(ctx))
+	assert.Equal(t, []string{"*.jpg", "*.mp4"}, fi.Opt.IncludeRule)
+	assert.Nil(t, Opt.IncludeRule, "Opt must not be modified")
+	assert.True(t, fi.Include("a.JPG", 2048, time.Now()))
+	assert.False(t, fi.Include("a.txt", 2048, time.Now()))
+}
+
+func TestAddRemoteProfile(t *testing.T) {
+	defer setupConfig(t)()
+	ctx, fi := filter.AddConfig(context.Background())
+
+	Opt = filter.DefaultOpt
+	newCtx, err := AddRemoteProfile(ctx, "plain:dir")
+	require.NoError(t, err)
+	assert.Equal(t, ctx, newCtx)
+	newCtx, err = AddRemoteProfile(ctx, "/local/path")
+	require.NoError(t, err)
+	assert.Equal(t, ctx, newCtx)
+
+	newCtx, err = AddRemoteProfile(ctx, "photos:dir")
+	require.NoError(t, err)
+	newFi := filter.GetConfig(newCtx)
+	assert.False(t, newFi.InActive())
+	assert.Equal(t, []string{"*.jpg", "*.mp4"}, newFi.Opt.IncludeRule)
+
+	// The filter in ctx and the global filter aren't modified
+	assert.True(t, fi.InActive())
+	assert.True(t, filter.GetConfig(context.Background()).InActive())
+
+	// The profile is added to the rules of the filter in ctx
+	ctx, fi = filter.AddConfig(context.Background())
+	fi.Opt.IncludeRule = []string{"*.png"}
+	newCtx, err = AddRemoteProfile(ctx, "photos:dir")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"*.png", "*.jpg", "*.mp4"}, filter.GetConfig(newCtx).Opt.IncludeRule)
+	assert.Equal(t, []string{"*.png"}, fi.Opt.IncludeRule)
+
+	// --filter-profile takes precedence
+	ctx, _ = filter.AddConfig(context.Background())
+	Opt.Profile = "bad"
+	newCtx, err = AddRemoteProfile(ctx, "photos:dir")
+	require.NoError(t, err)
+	assert.Equal(t, ctx, newCtx)
+}
+
+func TestRemoteSections(t *testing.T) {
+	defer setupConfig(t)()
+	assert.Equal(t, []string{"photos", "plain"}, config.FileSections())
+}
@@ -0,0 +1,160 @@
+package filter
+
+import (
+	"context"
+	"path"
+	"strings"
+
+	"github.com/pingme998/rclone/fs"
+	"github.com/pingme998/rclone/fs/hash"
+	"github.com/pkg/errors"
+)
+
+// metadataRules filter objects on the metadata which lsjson shows
+// for them rather than on their name, size or modification time.
+type metadataRules struct {
+	mimeInclude []string  // lower case mime type patterns to include
+	mimeExclude []string  // lower case mime type patterns to exclude
+	hashPresent hash.Type // objects must have this hash if set
+	hashMissing hash.Type // objects must not have this hash if set
+	tierInclude []string  // lower case tiers to include
+	tierExclude []string  // lower case tiers to exclude
+}
+
+// newMetadataRules parses the metadata rules out of opt
+func newMetadataRules(opt *Opt) (mr metadataRules, err error) {
+	for _, pattern := range opt.MimeInclude {
+		pattern = strings.ToLower(pattern)
+		if _, err := path.Match(pattern, ""); err != nil {
+			return mr, errors.Wrapf(err, "bad --mime-include pattern %q", pattern)
+		}
+		mr.mimeInclude = append(mr.mimeInclude, pattern)
+	}
+	for _, pattern := range opt.MimeExclude {
+		pattern = strings.ToLower(pattern)
+		if _, err := path.Match(pattern, ""); err != nil {
+			return mr, errors.Wrapf(err, "bad --mime-exclude pattern %q", pattern)
+		}
+		mr.mimeExclude = append(mr.mimeExclude, pattern)
+	}
+	if opt.HashPresent != "" {
+		if err := mr.hashPresent.Set(opt.HashPresent); err != nil {
+			return mr, errors.Wrap(err, "bad --hash-present")
+		}
+	}
+	if opt.HashMissing != "" {
+		if err := mr.hashMissing.Set(opt.HashMissing); err != nil {
+			return mr, errors.Wrap(err, "bad --hash-missing")
+		}
+	}
+	for _, tier := range opt.TierInclude {
+		mr.tierInclude = append(mr.tierInclude, strings.ToLower(tier))
+	}
+	for _, tier := range opt.TierExclude {
+		mr.tierExclude = append(mr.tierExclude, strings.ToLower(tier))
+	}
+	return mr, nil
+}
+
+// active returns true if any metadata rules are set
+func (mr *metadataRules) active() bool {
+	return len(mr.mimeInclude) != 0 ||
+		len(mr.mimeExclude) != 0 ||
+		mr.hashPresent != hash.None ||
+		mr.hashMissing != hash.None ||
+		len(mr.tierInclude) != 0 ||
+		len(mr.tierExclude) != 0
+}
+
+// dump returns a description of each rule for DumpFilters
+func (mr *metadataRules) dump() (rules []string) {
+	for _, pattern := range mr.mimeInclude {
+		rules = append(rules, "+ mime "+pattern)
+	}
+	for _, pattern := range mr.mimeExclude {
+		rules = append(rules, "- mime "+pattern)
+	}
+	if mr.hashPresent != hash.None {
+		rules = append(rules, "+ hash "+mr.hashPresent.String())
+	}
+	if mr.hashMissing != hash.None {
+		rules = append(rules, "- hash "+mr.hashMissing.String())
+	}
+	for _, tier := range mr.tierInclude {
+		rules = append(rules, "+ tier "+tier)
+	}
+	for _, tier := range mr.tierExclude {
+		rules = append(rules, "- tier "+tier)
+	}
+	return rules
+}
+
+// matchMime returns true if mimeType matches any of patterns
+func matchMime(patterns []string, mimeType string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, mimeType); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// matchTier returns true if tier is in tiers
+func matchTier(tiers []string, tier string) bool {
+	for _, t := range tiers {
+		if t == tier {
+			return true
+		}
+	}
+	return false
+}
+
+// include returns whether o passes the metadata rules.
+//
+// Only the metadata needed by the rules set is read as reading hashes
+// in particular may be expensive.
+func (mr *metadataRules) include(ctx context.Context, o fs.Object) bool {
+	if len(mr.mimeInclude) != 0 || len(mr.mimeExclude) != 0 {
+		mimeType := strings.ToLower(fs.MimeType(ctx, o))
+		if i := strings.IndexRune(mimeType, ';'); i >= 0 {
+			mimeType = strings.TrimSpace(mimeType[:i])
+		}
+		if len(mr.mimeInclude) != 0 && !matchMime(mr.mimeInclude, mimeType) {
+			return false
+		}
+		if matchMime(mr.mimeExclude, mimeType) {
+			return false
+		}
+	}
+	if mr.hashPresent != hash.None && !hasHash(ctx, o, mr.hashPresent) {
+		return false
+	}
+	if mr.hashMissing != hash.None && hasHash(ctx, o, mr.hashMissing) {
+		return false
+	}
+	if len(mr.tierInclude) != 0 || len(mr.tierExclude) != 0 {
+		tier := ""
+		if do, ok := o.(fs.GetTierer); ok {
+			tier = strings.ToLower(do.GetTier())
+		}
+		if len(mr.tierInclude) != 0 && !matchTier(mr.tierInclude, tier) {
+			return false
+		}
+		if matchTier(mr.tierExclude, tier) {
+			return false
+		}
+	}
+	return true
+}
+
+// hasHash returns true if o has a non empty hash of type ht
+func hasHash(ctx context.Context, o fs.Object, ht hash.Type) bool {
+	sum, err := o.Hash(ctx, ht)
+	if err != nil {
+		if err != hash.ErrUnsupported {
+			fs.Debugf(o, "Failed to read %v hash for filter: %v", ht, err)
+		}
+		return false
+	}
+	return sum != ""
+}
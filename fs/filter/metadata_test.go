@@ -0,0 +1,100 @@
+package filter
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/pingme998/rclone/fs"
+	"github.com/pingme998/rclone/fs/hash"
+	"github.com/pingme998/rclone/fstest/mockobject"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// metadataObject is a mock object with a mime type, tier and
+// optionally no hashes
+type metadataObject struct {
+	*mockobject.ContentMockObject
+	mimeType string
+	tier     string
+	noHash   bool
+}
+
+func (o *metadataObject) MimeType(ctx context.Context) string { return o.mimeType }
+func (o *metadataObject) GetTier() string                     { return o.tier }
+func (o *metadataObject) Hash(ctx context.Context, t hash.Type) (string, error) {
+	if o.noHash {
+		return "", nil
+	}
+	return o.ContentMockObject.Hash(ctx, t)
+}
+
+func newMetadataObject(remote, mimeType, tier string, noHash bool) fs.Object {
+	return &metadataObject{
+		ContentMockObject: mockobject.New(remote).WithContent([]byte(remote), mockobject.SeekModeNone),
+		mimeType:          mimeType,
+		tier:              tier,
+		noHash:            noHash,
+	}
+}
+
+func TestMetadataRules(t *testing.T) {
+	ctx := context.Background()
+	video := newMetadataObject("film.mp4", "video/mp4", "GLACIER", false)
+	image := newMetadataObject("pic.jpg", "image/jpeg", "STANDARD", true)
+	text := newMetadataObject("notes.txt", "text/plain; charset=utf-8", "", false)
+	for _, test := range []struct {
+		name string
+		opt  func(opt *Opt)
+		want []bool // video, image, text
+	}{
+		{"MimeInclude", func(opt *Opt) { opt.MimeInclude = []string{"video/*"} }, []bool{true, false, false}},
+		{"MimeIncludeParams", func(opt *Opt) { opt.MimeInclude = []string{"text/plain"} }, []bool{false, false, true}},
+		{"MimeExclude", func(opt *Opt) { opt.MimeExclude = []string{"IMAGE/*"} }, []bool{true, false, true}},
+		{"HashPresent", func(opt *Opt) { opt.HashPresent = "MD5" }, []bool{true, false, true}},
+		{"HashMissing", func(opt *Opt) { opt.HashMissing = "MD5" }, []bool{false, true, false}},
+		{"TierInclude", func(opt *Opt) { opt.TierInclude = []string{"glacier"} }, []bool{true, false, false}},
+		{"TierExclude", func(opt *Opt) { opt.TierExclude = []string{"GLACIER"} }, []bool{false, true, true}},
+		{"Combined", func(opt *Opt) {
+			opt.MimeInclude = []string{"video/*", "image/*"}
+			opt.TierExclude = []string{"STANDARD"}
+		}, []bool{true, false, false}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			opt := DefaultOpt
+			test.opt(&opt)
+			f, err := NewFilter(&opt)
+			require.NoError(t, err)
+			assert.False(t, f.InActive())
+			for i, o := range []fs.Object{video, image, text} {
+				assert.Equal(t, test.want[i], f.IncludeObject(ctx, o), o.Remote())
+			}
+		})
+	}
+}
+
+func TestMetadataRulesErrors(t *testing.T) {
+	opt := DefaultOpt
+	opt.MimeInclude = []string{"video/["}
+	_, err := NewFilter(&opt)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `bad --mime-include pattern "video/["`)
+
+	opt = DefaultOpt
+	opt.HashPresent = "potato"
+	_, err = NewFilter(&opt)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "bad --hash-present")
+
+	// Metadata rules can't be mixed with --files-from
+	opt = DefaultOpt
+	opt.TierInclude = []string{"GLACIER"}
+	opt.FilesFrom = []string{testFile(t, "file1\nfile2\n")}
+	defer func() {
+		_ = os.Remove(opt.FilesFrom[0])
+	}()
+	_, err = NewFilter(&opt)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "The usage of --files-from overrides all other filters")
+}
@@ -21,9 +21,9 @@ import (
 	"github.com/pkg/errors"
 	"github.com/pingme998/rclone/fs/config/configmap"
 	"github.com/pingme998/rclone/fs/config/configstruct"
-	"github.com/pingme998/rclone/fs/fserrors"
 	"github.com/pingme998/rclone/fs/fspath"
 	"github.com/pingme998/rclone/fs/hash"
+	"github.com/pingme998/rclone/lib/clock"
 	"github.com/pingme998/rclone/lib/pacer"
 )
 
@@ -475,6 +475,20 @@ type GetTierer interface {
 	GetTier() string
 }
 
+// Metadataer is an optional interface for Object
+type Metadataer interface {
+	// Metadata returns backend specific metadata for the Object,
+	// eg user metadata or properties, or nil if there isn't any
+	Metadata(ctx context.Context) (map[string]string, error)
+}
+
+// Tagger is an optional interface for Object
+type Tagger interface {
+	// Tags returns the user defined tags of the Object, or nil if
+	// there aren't any
+	Tags(ctx context.Context) (map[string]string, error)
+}
+
 // FullObjectInfo contains all the read-only optional interfaces
 //
 // Use for checking making wrapping ObjectInfos implement everything
@@ -484,6 +498,8 @@ type FullObjectInfo interface {
 	IDer
 	ObjectUnWrapper
 	GetTierer
+	Metadataer
+	Tagger
 }
 
 // FullObject contains all the optional interfaces for Object
@@ -496,6 +512,8 @@ type FullObject interface {
 	ObjectUnWrapper
 	GetTierer
 	SetTierer
+	Metadataer
+	Tagger
 }
 
 // ObjectOptionalInterfaces returns the names of supported and
@@ -639,6 +657,12 @@ type Features struct {
 	// PublicLink generates a public link to the remote path (usually readable by anyone)
 	PublicLink func(ctx context.Context, remote string, expire Duration, unlink bool) (string, error)
 
+	// ListLinks returns the public links to dir and everything under it
+	ListLinks func(ctx context.Context, dir string) ([]Link, error)
+
+	// RevokeLink removes the public link with the URL given
+	RevokeLink func(ctx context.Context, url string) error
+
 	// Put in to the remote path with the modTime given of the given size
 	//
 	// May create the object even if it returns an error - if so
@@ -714,6 +738,14 @@ type Features struct {
 	// Shutdown the backend, closing any background tasks and any
 	// cached connections.
 	Shutdown func(ctx context.Context) error
+
+	// GetLifecycle reads the object expiry rules of the bucket
+	GetLifecycle func(ctx context.Context) ([]LifecycleRule, error)
+
+	// SetLifecycle replaces the object expiry rules of the bucket
+	//
+	// An empty list removes all the rules
+	SetLifecycle func(ctx context.Context, rules []LifecycleRule) error
 }
 
 // Disable nil's out the named feature.  If it isn't found then it
@@ -808,6 +840,10 @@ func (ft *Features) Fill(ctx context.Context, f Fs) *Features {
 	if do, ok := f.(PublicLinker); ok {
 		ft.PublicLink = do.PublicLink
 	}
+	if do, ok := f.(LinkManager); ok {
+		ft.ListLinks = do.ListLinks
+		ft.RevokeLink = do.RevokeLink
+	}
 	if do, ok := f.(PutUncheckeder); ok {
 		ft.PutUnchecked = do.PutUnchecked
 	}
@@ -841,6 +877,10 @@ func (ft *Features) Fill(ctx context.Context, f Fs) *Features {
 	if do, ok := f.(Shutdowner); ok {
 		ft.Shutdown = do.Shutdown
 	}
+	if do, ok := f.(Lifecycler); ok {
+		ft.GetLifecycle = do.GetLifecycle
+		ft.SetLifecycle = do.SetLifecycle
+	}
 	return ft.DisableList(GetConfig(ctx).DisableFeatures)
 }
 
@@ -893,6 +933,12 @@ func (ft *Features) Mask(ctx context.Context, f Fs) *Features {
 	if mask.PublicLink == nil {
 		ft.PublicLink = nil
 	}
+	if mask.ListLinks == nil {
+		ft.ListLinks = nil
+	}
+	if mask.RevokeLink == nil {
+		ft.RevokeLink = nil
+	}
 	if mask.PutUnchecked == nil {
 		ft.PutUnchecked = nil
 	}
@@ -924,6 +970,12 @@ func (ft *Features) Mask(ctx context.Context, f Fs) *Features {
 	if mask.Shutdown == nil {
 		ft.Shutdown = nil
 	}
+	if mask.GetLifecycle == nil {
+		ft.GetLifecycle = nil
+	}
+	if mask.SetLifecycle == nil {
+		ft.SetLifecycle = nil
+	}
 	return ft.DisableList(GetConfig(ctx).DisableFeatures)
 }
 
@@ -1067,6 +1119,22 @@ type PublicLinker interface {
 	PublicLink(ctx context.Context, remote string, expire Duration, unlink bool) (string, error)
 }
 
+// Link describes a public link
+type Link struct {
+	Remote  string    `json:"remote"`            // path of the linked file or directory
+	URL     string    `json:"url"`               // the public link
+	Expires time.Time `json:"expires,omitempty"` // when the link expires - zero for never
+}
+
+// LinkManager is an optional interface for Fs
+type LinkManager interface {
+	// ListLinks returns the public links to dir and everything under it
+	ListLinks(ctx context.Context, dir string) ([]Link, error)
+
+	// RevokeLink removes the public link with the URL given
+	RevokeLink(ctx context.Context, url string) error
+}
+
 // MergeDirser is an option interface for Fs
 type MergeDirser interface {
 	// MergeDirs merges the contents of all the directories passed
@@ -1176,6 +1244,27 @@ type Shutdowner interface {
 	Shutdown(ctx context.Context) error
 }
 
+// LifecycleRule describes a rule for expiring objects in a bucket
+//
+// Prefix is relative to the bucket, not the root of the Fs
+type LifecycleRule struct {
+	ID                   string `json:"id,omitempty"`                   // name of the rule if the backend supports it
+	Prefix               string `json:"prefix,omitempty"`               // only apply to objects starting with this
+	ExpireDays           int    `json:"expireDays,omitempty"`           // delete objects this many days after upload
+	NoncurrentExpireDays int    `json:"noncurrentExpireDays,omitempty"` // delete old versions this many days after they were replaced
+}
+
+// Lifecycler is an optional interface for Fs
+type Lifecycler interface {
+	// GetLifecycle reads the object expiry rules of the bucket
+	GetLifecycle(ctx context.Context) ([]LifecycleRule, error)
+
+	// SetLifecycle replaces the object expiry rules of the bucket
+	//
+	// An empty list removes all the rules
+	SetLifecycle(ctx context.Context, rules []LifecycleRule) error
+}
+
 // ObjectsChan is a channel of Objects
 type ObjectsChan chan Object
 
@@ -1433,6 +1522,7 @@ func NewFs(ctx context.Context, path string) (Fs, error) {
 	if err != nil {
 		return nil, err
 	}
+	ctx = withRemote(ctx, configName, fsInfo.Name)
 	overridden := fsInfo.Options.Overridden(config)
 	if len(overridden) > 0 {
 		extraConfig := overridden.String()
@@ -1502,8 +1592,8 @@ func FileExists(ctx context.Context, fs Fs, remote string) (bool, error) {
 	return true, nil
 }
 
-// GetModifyWindow calculates the maximum modify window between the given Fses
-// and the Config.ModifyWindow parameter.
+// GetModifyWindow calculates the maximum modify window between the given Fses,
+// their compare_modify_window config and the Config.ModifyWindow parameter.
 func GetModifyWindow(ctx context.Context, fss ...Info) time.Duration {
 	window := GetConfig(ctx).ModifyWindow
 	for _, f := range fss {
@@ -1515,6 +1605,9 @@ func GetModifyWindow(ctx context.Context, fss ...Info) time.Duration {
 			if precision > window {
 				window = precision
 			}
+			if remoteWindow := GetCompareOptions(f).ModifyWindow; remoteWindow > window {
+				window = remoteWindow
+			}
 		}
 	}
 	return window
@@ -1530,6 +1623,9 @@ type logCalculator struct {
 }
 
 // NewPacer creates a Pacer for the given Fs and Calculator.
+//
+// It sleeps between calls using the clock in the context and applies
+// the retry rules for the remote being made by NewFs, if any.
 func NewPacer(ctx context.Context, c pacer.Calculator) *Pacer {
 	ci := GetConfig(ctx)
 	retries := ci.LowLevelRetries
@@ -1538,10 +1634,11 @@ func NewPacer(ctx context.Context, c pacer.Calculator) *Pacer {
 	}
 	p := &Pacer{
 		Pacer: pacer.New(
-			pacer.InvokerOption(pacerInvoker),
+			pacer.InvokerOption(newPacerInvoker(ctx)),
 			pacer.MaxConnectionsOption(ci.Checkers+ci.Transfers),
 			pacer.RetriesOption(retries),
 			pacer.CalculatorOption(c),
+			pacer.ClockOption(clock.Get(ctx)),
 		),
 	}
 	p.SetCalculator(c)
@@ -1593,12 +1690,3 @@ func (p *Pacer) ModifyCalculator(f func(pacer.Calculator)) {
 		}
 	})
 }
-
-func pacerInvoker(try, retries int, f pacer.Paced) (retry bool, err error) {
-	retry, err = f()
-	if retry {
-		Debugf("pacer", "low level retry %d/%d (error %v)", try, retries, err)
-		err = fserrors.RetryError(err)
-	}
-	return
-}
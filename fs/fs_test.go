@@ -152,6 +152,37 @@ func TestPacerCallNoRetry(t *testing.T) {
 	require.Implements(t, (*fserrors.Retrier)(nil), err)
 }
 
+func TestPacerCallRetryRules(t *testing.T) {
+	ctx, ci := AddConfig(context.Background())
+	ci.LowLevelRetries = 3
+	ci.RetryRules = []fserrors.RetryRule{
+		{Remote: "retryme", Match: "foo", Retry: true},
+		{Remote: "dontretry", Match: "foo", Retry: false},
+	}
+	newPacer := func(name string) *Pacer {
+		return NewPacer(withRemote(ctx, name, "dummy"), pacer.NewDefault(pacer.MinSleep(1*time.Millisecond), pacer.MaxSleep(2*time.Millisecond)))
+	}
+
+	// rule makes the backend retry
+	dp := &dummyPaced{retry: false}
+	err := newPacer("retryme").Call(dp.fn)
+	assert.Equal(t, 3, dp.called)
+	assert.True(t, fserrors.IsRetryError(err))
+
+	// rule stops the backend retrying
+	dp = &dummyPaced{retry: true}
+	err = newPacer("dontretry").Call(dp.fn)
+	assert.Equal(t, 1, dp.called)
+	assert.True(t, fserrors.IsNoLowLevelRetryError(err))
+	assert.False(t, fserrors.ShouldRetry(err))
+
+	// rules for other remotes don't apply
+	dp = &dummyPaced{retry: false}
+	err = newPacer("other").Call(dp.fn)
+	assert.Equal(t, 1, dp.called)
+	assert.Equal(t, errFoo, err)
+}
+
 // Test options
 var (
 	nouncOption = Option{
@@ -0,0 +1,257 @@
+package fserrors
+
+// User configurable rules to override whether errors are retried
+
+import (
+	"net/http"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pingme998/rclone/lib/errors"
+)
+
+// RetryRule overrides the decision of a backend as to whether the
+// errors matching it should be retried.
+//
+// All the fields which are set must match for the rule to apply.
+type RetryRule struct {
+	Remote string // name of the remote or type of the backend, "" for all
+	Status int    // HTTP status code, 0 for any
+	Match  string // text the error must contain ignoring case, "" for any
+	Retry  bool   // set to retry the matching errors, unset to not
+}
+
+// ParseRetryRule parses a rule in the form
+//
+//     remote=X,status=403,match=Slow Down,retry=true
+//
+// where all the keys apart from retry are optional.
+func ParseRetryRule(s string) (rule RetryRule, err error) {
+	haveRetry := false
+	for _, part := range strings.Split(s, ",") {
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 {
+			return rule, errors.Errorf("retry rule %q: expecting key=value but got %q", s, part)
+		}
+		key, value := strings.TrimSpace(kv[0]), strings.TrimSpace(kv[1])
+		switch strings.ToLower(key) {
+		case "remote":
+			rule.Remote = strings.TrimSuffix(value, ":")
+		case "status":
+			rule.Status, err = strconv.Atoi(value)
+			if err != nil || rule.Status < 100 || rule.Status > 599 {
+				return rule, errors.Errorf("retry rule %q: bad HTTP status %q", s, value)
+			}
+		case "match":
+			rule.Match = value
+		case "retry":
+			rule.Retry, err = strconv.ParseBool(value)
+			if err != nil {
+				return rule, errors.Errorf("retry rule %q: bad retry %q", s, value)
+			}
+			haveRetry = true
+		default:
+			return rule, errors.Errorf("retry rule %q: unknown key %q", s, key)
+		}
+	}
+	if !haveRetry {
+		return rule, errors.Errorf("retry rule %q: retry=true or retry=false is required", s)
+	}
+	if rule.Status == 0 && rule.Match == "" {
+		return rule, errors.Errorf("retry rule %q: needs a status or match to say which errors it is for", s)
+	}
+	return rule, nil
+}
+
+// String returns the rule in the form ParseRetryRule reads
+func (rule RetryRule) String() string {
+	var parts []string
+	if rule.Remote != "" {
+		parts = append(parts, "remote="+rule.Remote)
+	}
+	if rule.Status != 0 {
+		parts = append(parts, "status="+strconv.Itoa(rule.Status))
+	}
+	if rule.Match != "" {
+		parts = append(parts, "match="+rule.Match)
+	}
+	parts = append(parts, "retry="+strconv.FormatBool(rule.Retry))
+	return strings.Join(parts, ",")
+}
+
+// matches returns true if the rule applies to the error
+func (rule *RetryRule) matches(remote, backend string, status int, errString string) bool {
+	if rule.Remote != "" && rule.Remote != remote && rule.Remote != backend {
+		return false
+	}
+	if rule.Status != 0 && rule.Status != status {
+		return false
+	}
+	if rule.Match != "" && !strings.Contains(errString, strings.ToLower(rule.Match)) {
+		return false
+	}
+	return true
+}
+
+// StatusCode returns the HTTP status code of the response which
+// caused err or 0 if it can't be found.
+//
+// It looks through the causes of err for a StatusCode() method, as
+// used by the AWS SDK, or an int field called StatusCode or Code with
+// an HTTP status in, as used by lots of API error types.
+func StatusCode(err error) (status int) {
+	errors.Walk(err, func(c error) bool {
+		if x, ok := c.(interface {
+			StatusCode() int
+		}); ok {
+			status = x.StatusCode()
+			return true
+		}
+		v := reflect.ValueOf(c)
+		if v.Kind() == reflect.Ptr {
+			v = v.Elem()
+		}
+		if v.Kind() != reflect.Struct {
+			return false
+		}
+		for _, name := range []string{"StatusCode", "Code"} {
+			field := v.FieldByName(name)
+			if field.IsValid() && field.Kind() == reflect.Int && field.Int() >= 100 && field.Int() <= 599 {
+				status = int(field.Int())
+				return true
+			}
+		}
+		return false
+	})
+	return status
+}
+
+// RetryDecision records how an error was classified
+type RetryDecision struct {
+	Time    time.Time `json:"time"`             // when the decision was made
+	Remote  string    `json:"remote"`           // the remote the error came from
+	Error   string    `json:"error"`            // the error
+	Status  int       `json:"status,omitempty"` // the HTTP status of the error if known
+	Default bool      `json:"default"`          // whether the backend wanted to retry
+	Retry   bool      `json:"retry"`            // whether the error was retried
+	Rule    string    `json:"rule,omitempty"`   // the rule which decided, if any
+}
+
+// maxRetryDecisions is the number of decisions remembered
+const maxRetryDecisions = 100
+
+// the most recent decisions, oldest first
+var (
+	retryDecisionsMu sync.Mutex
+	retryDecisions   []RetryDecision
+)
+
+// recordRetryDecision remembers d, forgetting the oldest decision if
+// there are too many
+func recordRetryDecision(d RetryDecision) {
+	retryDecisionsMu.Lock()
+	defer retryDecisionsMu.Unlock()
+	if len(retryDecisions) >= maxRetryDecisions {
+		retryDecisions = append(retryDecisions[:0], retryDecisions[1:]...)
+	}
+	retryDecisions = append(retryDecisions, d)
+}
+
+// RetryDecisions returns the most recent decisions made by
+// ClassifyRetry, oldest first
+func RetryDecisions() []RetryDecision {
+	retryDecisionsMu.Lock()
+	defer retryDecisionsMu.Unlock()
+	return append([]RetryDecision(nil), retryDecisions...)
+}
+
+// ResetRetryDecisions forgets the decisions made so far
+func ResetRetryDecisions() {
+	retryDecisionsMu.Lock()
+	retryDecisions = nil
+	retryDecisionsMu.Unlock()
+}
+
+// ClassifyRetry decides whether err, returned from the remote with
+// the backend type given, should be retried.
+//
+// retry is the decision of the backend which is used unless one of
+// the rules matches err, in which case the first which does decides.
+// The decision is recorded for RetryDecisions.
+func ClassifyRetry(rules []RetryRule, remote, backend string, retry bool, err error) bool {
+	if err == nil {
+		return retry
+	}
+	d := RetryDecision{
+		Time:    time.Now(),
+		Remote:  remote,
+		Error:   err.Error(),
+		Status:  StatusCode(err),
+		Default: retry,
+		Retry:   retry,
+	}
+	errString := strings.ToLower(d.Error)
+	for i := range rules {
+		if rules[i].matches(remote, backend, d.Status, errString) {
+			d.Retry = rules[i].Retry
+			d.Rule = rules[i].String()
+			break
+		}
+	}
+	recordRetryDecision(d)
+	return d.Retry
+}
+
+// quotaErrorStrings are found in the errors of remotes which are out
+// of space or quota once lower cased with spaces and underscores
+// removed, e.g. "storageQuotaExceeded" from Google Drive
+var quotaErrorStrings = []string{
+	"quotaexceeded",
+	"overquota",
+	"quotalimitreached",
+	"insufficientstorage",
+	"nospaceleftondevice",
+}
+
+// IsQuotaError returns true if err was caused by the destination
+// running out of space or quota
+func IsQuotaError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if isErrNoSpaceOrQuota(err) || StatusCode(err) == http.StatusInsufficientStorage {
+		return true
+	}
+	errString := strings.NewReplacer(" ", "", "_", "").Replace(strings.ToLower(err.Error()))
+	for _, s := range quotaErrorStrings {
+		if strings.Contains(errString, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsPermissionError returns true if err was caused by permission
+// being denied, either locally or by the remote returning HTTP status
+// 401 or 403.
+//
+// Errors which should be retried, e.g. 403 errors returned for rate
+// limiting, are not counted as permission errors.
+func IsPermissionError(err error) (isPermission bool) {
+	if err == nil || IsRetryError(err) || ShouldRetry(err) {
+		return false
+	}
+	errors.Walk(err, func(c error) bool {
+		isPermission = os.IsPermission(c)
+		return isPermission
+	})
+	if isPermission {
+		return true
+	}
+	status := StatusCode(err)
+	return status == http.StatusUnauthorized || status == http.StatusForbidden
+}
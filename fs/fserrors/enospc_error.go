@@ -21,3 +21,13 @@ func IsErrNoSpace(cause error) (isNoSpc bool) {
 	})
 	return
 }
+
+// isErrNoSpaceOrQuota checks a possibly wrapped error to see if it
+// contains a ENOSPC or EDQUOT error
+func isErrNoSpaceOrQuota(cause error) (isNoSpace bool) {
+	errors.Walk(cause, func(c error) bool {
+		isNoSpace = c == syscall.ENOSPC || c == syscall.EDQUOT
+		return isNoSpace
+	})
+	return isNoSpace
+}
@@ -8,3 +8,9 @@ func IsErrNoSpace(cause error) (isNoSpc bool) {
 	isNoSpc = false
 	return 
 }
+
+// isErrNoSpaceOrQuota on plan9 returns false because plan9 does not
+// support syscall.ENOSPC or syscall.EDQUOT errors.
+func isErrNoSpaceOrQuota(cause error) bool {
+	return false
+}
@@ -189,3 +189,132 @@ func TestContextError(t *testing.T) {
 	assert.True(t, ContextError(ctx, &err))
 	assert.Equal(t, context.Canceled, err)
 }
+
+func TestParseRetryRule(t *testing.T) {
+	for _, test := range []struct {
+		in      string
+		want    RetryRule
+		wantErr string
+	}{
+		{in: "status=403,retry=true", want: RetryRule{Status: 403, Retry: true}},
+		{in: "remote=s3:, status=403, match=Slow Down, retry=true", want: RetryRule{Remote: "s3", Status: 403, Match: "Slow Down", Retry: true}},
+		{in: "match=quota,retry=false", want: RetryRule{Match: "quota"}},
+		{in: "status=403", wantErr: "retry=true or retry=false is required"},
+		{in: "remote=s3,retry=true", wantErr: "needs a status or match"},
+		{in: "status=potato,retry=true", wantErr: "bad HTTP status"},
+		{in: "status=42,retry=true", wantErr: "bad HTTP status"},
+		{in: "status=403,retry=maybe", wantErr: "bad retry"},
+		{in: "status=403,retry=true,colour=blue", wantErr: "unknown key"},
+		{in: "status=403,retry=true,", wantErr: "expecting key=value"},
+	} {
+		got, err := ParseRetryRule(test.in)
+		if test.wantErr != "" {
+			assert.Error(t, err, test.in)
+			assert.Contains(t, fmt.Sprint(err), test.wantErr, test.in)
+			continue
+		}
+		assert.NoError(t, err, test.in)
+		assert.Equal(t, test.want, got, test.in)
+		// check it round trips
+		again, err := ParseRetryRule(got.String())
+		assert.NoError(t, err, test.in)
+		assert.Equal(t, got, again, test.in)
+	}
+}
+
+type statusMethodError struct{}
+
+func (statusMethodError) Error() string   { return "method" }
+func (statusMethodError) StatusCode() int { return 503 }
+
+type statusFieldError struct {
+	Code    int
+	Message string
+}
+
+func (e *statusFieldError) Error() string { return e.Message }
+
+func TestStatusCode(t *testing.T) {
+	assert.Equal(t, 0, StatusCode(nil))
+	assert.Equal(t, 0, StatusCode(io.EOF))
+	assert.Equal(t, 503, StatusCode(statusMethodError{}))
+	assert.Equal(t, 503, StatusCode(errors.Wrap(statusMethodError{}, "wrapped")))
+	assert.Equal(t, 403, StatusCode(&statusFieldError{Code: 403}))
+	assert.Equal(t, 0, StatusCode(&statusFieldError{Code: 7}))
+}
+
+func TestClassifyRetry(t *testing.T) {
+	ResetRetryDecisions()
+	defer ResetRetryDecisions()
+	rules := []RetryRule{
+		{Remote: "myremote", Status: 403, Match: "slow down", Retry: true},
+		{Remote: "s3", Match: "quota", Retry: false},
+	}
+	slowDown := &statusFieldError{Code: 403, Message: "Slow Down please"}
+	quota := errors.New("quota exceeded")
+
+	assert.True(t, ClassifyRetry(nil, "myremote", "s3", true, nil))
+	assert.True(t, ClassifyRetry(rules, "myremote", "s3", false, slowDown))
+	assert.False(t, ClassifyRetry(rules, "other", "s3", false, slowDown))
+	assert.False(t, ClassifyRetry(rules, "other", "s3", true, quota))
+	assert.True(t, ClassifyRetry(rules, "other", "drive", true, quota))
+
+	decisions := RetryDecisions()
+	assert.Equal(t, 4, len(decisions))
+	d := decisions[0]
+	assert.Equal(t, "myremote", d.Remote)
+	assert.Equal(t, "Slow Down please", d.Error)
+	assert.Equal(t, 403, d.Status)
+	assert.False(t, d.Default)
+	assert.True(t, d.Retry)
+	assert.Equal(t, "remote=myremote,status=403,match=slow down,retry=true", d.Rule)
+	assert.Equal(t, "", decisions[1].Rule)
+	assert.Equal(t, "remote=s3,match=quota,retry=false", decisions[2].Rule)
+	assert.True(t, decisions[3].Retry)
+
+	// only the most recent are kept
+	for i := 0; i < maxRetryDecisions; i++ {
+		ClassifyRetry(nil, "other", "s3", true, quota)
+	}
+	decisions = RetryDecisions()
+	assert.Equal(t, maxRetryDecisions, len(decisions))
+	assert.Equal(t, "other", decisions[0].Remote)
+}
+
+func TestIsQuotaError(t *testing.T) {
+	for i, test := range []struct {
+		err  error
+		want bool
+	}{
+		{nil, false},
+		{io.EOF, false},
+		{syscall.ENOSPC, true},
+		{&os.PathError{Op: "write", Path: "/tmp/x", Err: syscall.EDQUOT}, true},
+		{errors.Wrap(syscall.ENOSPC, "wrapped"), true},
+		{&statusFieldError{Code: 507}, true},
+		{&statusFieldError{Code: 403, Message: "The user's Drive storage quota has been exceeded. (storageQuotaExceeded)"}, true},
+		{errors.New("quota_exceeded"), true},
+		{&statusFieldError{Code: 403, Message: "forbidden"}, false},
+	} {
+		assert.Equal(t, test.want, IsQuotaError(test.err), fmt.Sprintf("test %d: %v", i, test.err))
+	}
+}
+
+func TestIsPermissionError(t *testing.T) {
+	for i, test := range []struct {
+		err  error
+		want bool
+	}{
+		{nil, false},
+		{io.EOF, false},
+		{os.ErrPermission, true},
+		{&os.PathError{Op: "open", Path: "/root/x", Err: syscall.EACCES}, true},
+		{errors.Wrap(&os.PathError{Op: "open", Path: "/root/x", Err: syscall.EPERM}, "wrapped"), true},
+		{&statusFieldError{Code: 401}, true},
+		{&statusFieldError{Code: 403}, true},
+		{RetryError(&statusFieldError{Code: 403}), false},
+		{&statusFieldError{Code: 404}, false},
+	} {
+		assert.Equal(t, test.want, IsPermissionError(test.err), fmt.Sprintf("test %d: %v", i, test.err))
+	}
+}
@@ -3,6 +3,7 @@ package fshttp
 import (
 	"context"
 	"net"
+	"sync/atomic"
 	"time"
 
 	"github.com/pingme998/rclone/fs"
@@ -71,6 +72,7 @@ func (d *Dialer) DialContext(ctx context.Context, network, address string) (net.
 type timeoutConn struct {
 	net.Conn
 	timeout time.Duration
+	closed  int32 // set to 1 when closed, accessed atomically
 }
 
 // create a timeoutConn using the timeout
@@ -80,9 +82,19 @@ func newTimeoutConn(conn net.Conn, timeout time.Duration) (c *timeoutConn, err e
 		timeout: timeout,
 	}
 	err = c.nudgeDeadline()
+	atomic.AddInt64(&transportStats.connections, 1)
+	atomic.AddInt64(&transportStats.open, 1)
 	return
 }
 
+// Close the connection
+func (c *timeoutConn) Close() error {
+	if atomic.CompareAndSwapInt32(&c.closed, 0, 1) {
+		atomic.AddInt64(&transportStats.open, -1)
+	}
+	return c.Conn.Close()
+}
+
 // Nudge the deadline for an idle timeout on by c.timeout if non-zero
 func (c *timeoutConn) nudgeDeadline() (err error) {
 	if c.timeout == 0 {
@@ -96,6 +108,7 @@ func (c *timeoutConn) nudgeDeadline() (err error) {
 func (c *timeoutConn) Read(b []byte) (n int, err error) {
 	// Ideally we would LimitBandwidth(len(b)) here and replace tokens we didn't use
 	n, err = c.Conn.Read(b)
+	atomic.AddInt64(&transportStats.rxBytes, int64(n))
 	accounting.TokenBucket.LimitBandwidth(accounting.TokenBucketSlotTransportRx, n)
 	// Don't nudge if no bytes or an error
 	if n == 0 || err != nil {
@@ -110,6 +123,7 @@ func (c *timeoutConn) Read(b []byte) (n int, err error) {
 func (c *timeoutConn) Write(b []byte) (n int, err error) {
 	accounting.TokenBucket.LimitBandwidth(accounting.TokenBucketSlotTransportTx, len(b))
 	n, err = c.Conn.Write(b)
+	atomic.AddInt64(&transportStats.txBytes, int64(n))
 	// Don't nudge if no bytes or an error
 	if n == 0 || err != nil {
 		return
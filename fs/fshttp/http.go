@@ -13,6 +13,7 @@ import (
 	"net/http/cookiejar"
 	"net/http/httputil"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/pingme998/rclone/fs"
@@ -121,6 +122,14 @@ func NewTransport(ctx context.Context) http.RoundTripper {
 	return transport
 }
 
+// CloseIdleConnections closes any idle connections of the transport
+// made by NewTransport
+func CloseIdleConnections() {
+	if t, ok := transport.(interface{ CloseIdleConnections() }); ok {
+		t.CloseIdleConnections()
+	}
+}
+
 // NewClient returns an http.Client with the correct timeouts
 func NewClient(ctx context.Context) *http.Client {
 	ci := fs.GetConfig(ctx)
@@ -269,6 +278,10 @@ func (t *Transport) RoundTrip(req *http.Request) (resp *http.Response, err error
 	}
 	// Do round trip
 	resp, err = t.Transport.RoundTrip(req)
+	atomic.AddInt64(&transportStats.requests, 1)
+	if err != nil {
+		atomic.AddInt64(&transportStats.errors, 1)
+	}
 	// Logf response
 	if t.dump&(fs.DumpHeaders|fs.DumpBodies|fs.DumpAuth|fs.DumpRequests|fs.DumpResponses) != 0 {
 		logMutex.Lock()
@@ -0,0 +1,83 @@
+package fshttp
+
+import (
+	"sync/atomic"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// transportStats are updated by the transport and the dialer and
+// exported by TransportCollector. Access them atomically.
+var transportStats struct {
+	connections int64 // number of connections made
+	open        int64 // number of connections currently open
+	rxBytes     int64 // bytes read from the connections
+	txBytes     int64 // bytes written to the connections
+	requests    int64 // number of HTTP requests made
+	errors      int64 // number of HTTP requests which failed without a response
+}
+
+// TransportCollector is a Prometheus collector for the HTTP transport
+// used by the backends
+type TransportCollector struct {
+	connections *prometheus.Desc
+	open        *prometheus.Desc
+	rxBytes     *prometheus.Desc
+	txBytes     *prometheus.Desc
+	requests    *prometheus.Desc
+	errors      *prometheus.Desc
+}
+
+// NewTransportCollector makes a new TransportCollector
+func NewTransportCollector() *TransportCollector {
+	const namespace = "rclone_http_"
+	return &TransportCollector{
+		connections: prometheus.NewDesc(namespace+"connections_total",
+			"Number of connections made by the HTTP transport",
+			nil, nil,
+		),
+		open: prometheus.NewDesc(namespace+"connections_open",
+			"Number of connections currently open",
+			nil, nil,
+		),
+		rxBytes: prometheus.NewDesc(namespace+"received_bytes_total",
+			"Total bytes received including protocol overhead",
+			nil, nil,
+		),
+		txBytes: prometheus.NewDesc(namespace+"sent_bytes_total",
+			"Total bytes sent including protocol overhead",
+			nil, nil,
+		),
+		requests: prometheus.NewDesc(namespace+"requests_total",
+			"Number of HTTP requests made",
+			nil, nil,
+		),
+		errors: prometheus.NewDesc(namespace+"request_errors_total",
+			"Number of HTTP requests which failed without a response",
+			nil, nil,
+		),
+	}
+}
+
+// Describe is part of the Collector interface: https://godoc.org/github.com/prometheus/client_golang/prometheus#Collector
+func (c *TransportCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.connections
+	ch <- c.open
+	ch <- c.rxBytes
+	ch <- c.txBytes
+	ch <- c.requests
+	ch <- c.errors
+}
+
+// Collect is part of the Collector interface: https://godoc.org/github.com/prometheus/client_golang/prometheus#Collector
+func (c *TransportCollector) Collect(ch chan<- prometheus.Metric) {
+	counter := func(desc *prometheus.Desc, p *int64) {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.CounterValue, float64(atomic.LoadInt64(p)))
+	}
+	counter(c.connections, &transportStats.connections)
+	counter(c.rxBytes, &transportStats.rxBytes)
+	counter(c.txBytes, &transportStats.txBytes)
+	counter(c.requests, &transportStats.requests)
+	counter(c.errors, &transportStats.errors)
+	ch <- prometheus.MustNewConstMetric(c.open, prometheus.GaugeValue, float64(atomic.LoadInt64(&transportStats.open)))
+}
@@ -12,6 +12,8 @@ import (
 
 	"github.com/jzelinskie/whirlpool"
 	"github.com/pkg/errors"
+	"github.com/zeebo/blake3"
+	"github.com/zeebo/xxh3"
 )
 
 // Type indicates a standard hashing algorithm
@@ -60,6 +62,12 @@ var (
 
 	// CRC32 indicates CRC-32 support
 	CRC32 Type
+
+	// XXH3 indicates 64 bit XXH3 support
+	XXH3 Type
+
+	// BLAKE3 indicates 256 bit BLAKE3 support
+	BLAKE3 Type
 )
 
 func init() {
@@ -67,6 +75,8 @@ func init() {
 	SHA1 = RegisterHash("SHA-1", 40, sha1.New)
 	Whirlpool = RegisterHash("Whirlpool", 128, whirlpool.New)
 	CRC32 = RegisterHash("CRC-32", 8, func() hash.Hash { return crc32.NewIEEE() })
+	XXH3 = RegisterHash("XXH3", 16, func() hash.Hash { return xxh3.New() })
+	BLAKE3 = RegisterHash("BLAKE3", 64, func() hash.Hash { return blake3.New() })
 }
 
 // Supported returns a set of all the supported hashes by
@@ -2,8 +2,15 @@ package fs
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
@@ -80,10 +87,253 @@ func (l *LogLevel) UnmarshalJSON(in []byte) error {
 	})
 }
 
+// SubsystemLogLevels are the log levels of the subsystems of rclone
+// which are logged at a different level to LogLevel, as set with
+// --log-level vfscache=DEBUG,pacer=INFO
+//
+// The subsystem of a log is the object logged if it is a string, e.g.
+// "pacer", or the text before the ":" which starts the message, e.g.
+// "vfs cache". Case and spaces are ignored in subsystem names.
+type SubsystemLogLevels map[string]LogLevel
+
+// String turns SubsystemLogLevels into a string
+func (levels SubsystemLogLevels) String() string {
+	var out []string
+	for subsystem, level := range levels {
+		out = append(out, subsystem+"="+level.String())
+	}
+	sort.Strings(out)
+	return strings.Join(out, ",")
+}
+
+// UnmarshalJSON makes sure the subsystems set in JSON, e.g. with the
+// options/set rc call, are normalized like those set with SetLogLevel
+func (levels *SubsystemLogLevels) UnmarshalJSON(in []byte) error {
+	var raw map[string]LogLevel
+	err := json.Unmarshal(in, &raw)
+	if err != nil {
+		return err
+	}
+	if raw == nil {
+		*levels = nil
+		return nil
+	}
+	*levels = make(SubsystemLogLevels, len(raw))
+	for subsystem, level := range raw {
+		(*levels)[normalizeSubsystem(subsystem)] = level
+	}
+	return nil
+}
+
+// normalizeSubsystem returns the subsystem name with case and spaces
+// removed
+func normalizeSubsystem(subsystem string) string {
+	return strings.ToLower(strings.Replace(subsystem, " ", "", -1))
+}
+
+// logSubsystemPrefix returns the subsystem at the start of the
+// format text of a log, e.g. "vfs cache" from "vfs cache: %v", or ""
+func logSubsystemPrefix(text string) string {
+	i := strings.IndexByte(text, ':')
+	if i <= 0 {
+		return ""
+	}
+	for _, c := range text[:i] {
+		if !(c == ' ' || c == '-' || c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')) {
+			return ""
+		}
+	}
+	return text[:i]
+}
+
+// find returns the level of the subsystem of a log of o with the
+// format text, if one has been set
+//
+// The subsystems in levels must have been normalized with
+// normalizeSubsystem which SetLogLevel and UnmarshalJSON do.
+func (levels SubsystemLogLevels) find(o interface{}, text string) (level LogLevel, found bool) {
+	if name, ok := o.(string); ok {
+		if level, found = levels[normalizeSubsystem(name)]; found {
+			return level, true
+		}
+	}
+	if prefix := logSubsystemPrefix(text); prefix != "" {
+		if level, found = levels[normalizeSubsystem(prefix)]; found {
+			return level, true
+		}
+	}
+	return 0, false
+}
+
+// SetLogLevel sets the log levels from s as passed to --log-level.
+//
+// This is a comma separated list of a log level, e.g. INFO, and
+// subsystem=LEVEL pairs which set SubsystemLogLevels, e.g.
+// "INFO,vfscache=DEBUG,pacer=ERROR". Either may be left out.
+//
+// It returns true if LogLevel was set.
+func (c *ConfigInfo) SetLogLevel(s string) (levelSet bool, err error) {
+	subsystems := SubsystemLogLevels{}
+	for _, part := range strings.Split(s, ",") {
+		kv := strings.SplitN(part, "=", 2)
+		var level LogLevel
+		err = level.Set(strings.ToUpper(strings.TrimSpace(kv[len(kv)-1])))
+		if err != nil {
+			return false, err
+		}
+		if len(kv) == 1 {
+			c.LogLevel = level
+			levelSet = true
+			continue
+		}
+		subsystem := normalizeSubsystem(strings.TrimSpace(kv[0]))
+		if subsystem == "" {
+			return false, errors.Errorf("no subsystem in %q", part)
+		}
+		subsystems[subsystem] = level
+	}
+	if len(subsystems) > 0 {
+		c.SubsystemLogLevels = subsystems
+	}
+	return levelSet, nil
+}
+
+// logEnabled returns true if a log at level of o with the format text
+// should be output
+func (c *ConfigInfo) logEnabled(level LogLevel, o interface{}, text string) bool {
+	if len(c.SubsystemLogLevels) > 0 {
+		if subsystemLevel, found := c.SubsystemLogLevels.find(o, text); found {
+			return subsystemLevel >= level
+		}
+	}
+	return c.LogLevel >= level
+}
+
+// logRepeats counts the logs output recently so repeats of the same
+// log can be suppressed with --log-repeat-limit
+type logRepeats struct {
+	mu     sync.Mutex
+	seen   map[string]*logRepeat // logs seen in their current window
+	pruned time.Time             // when seen was last pruned
+	timer  *time.Timer           // reports the repeats suppressed when their window ends
+}
+
+// logRepeat is a log seen in the current window
+type logRepeat struct {
+	start time.Time   // when the window started
+	count int         // number of times the log was seen in the window
+	ci    *ConfigInfo // config the log was made with
+	level LogLevel    // level of the log
+	o     interface{} // object of the log
+	out   string      // text of the log
+}
+
+// the logs seen recently
+var repeats = logRepeats{
+	seen: map[string]*logRepeat{},
+}
+
+// check counts the log and returns true if it should be suppressed as
+// it has been seen more than ci.LogRepeatLimit times in
+// ci.LogRepeatWindow.
+//
+// It should only be called if ci.LogRepeatLimit and ci.LogRepeatWindow
+// are set.
+//
+// callDepth is passed to reportRepeats for the repeats reported.
+func (r *logRepeats) check(callDepth int, ci *ConfigInfo, level LogLevel, o interface{}, out string) (suppress bool) {
+	now := time.Now()
+	key := fmt.Sprintf("%d\x00%v\x00%s", level, o, out)
+	var finished []*logRepeat
+	r.mu.Lock()
+	if now.Sub(r.pruned) >= ci.LogRepeatWindow {
+		finished = r._prune(now, false)
+	}
+	x := r.seen[key]
+	if x == nil {
+		x = &logRepeat{start: now, ci: ci, level: level, o: o, out: out}
+		r.seen[key] = x
+	}
+	x.count++
+	if x.count == ci.LogRepeatLimit+1 && r.timer == nil {
+		// report the repeats when the window ends
+		r.timer = time.AfterFunc(x.start.Add(ci.LogRepeatWindow).Sub(now), r.flush)
+	}
+	suppress = x.count > ci.LogRepeatLimit
+	r.mu.Unlock()
+	reportRepeats(callDepth+1, finished)
+	return suppress
+}
+
+// _prune removes the logs whose window has finished, or all of them if
+// all is set, returning those which had repeats suppressed so the
+// number can be reported.
+//
+// It arms the timer to report the repeats suppressed in the windows
+// still running.
+//
+// Call with mu held
+func (r *logRepeats) _prune(now time.Time, all bool) (finished []*logRepeat) {
+	var next time.Time
+	for k, x := range r.seen {
+		end := x.start.Add(x.ci.LogRepeatWindow)
+		if all || !now.Before(end) {
+			if x.count > x.ci.LogRepeatLimit {
+				finished = append(finished, x)
+			}
+			delete(r.seen, k)
+		} else if x.count > x.ci.LogRepeatLimit && (next.IsZero() || end.Before(next)) {
+			next = end
+		}
+	}
+	r.pruned = now
+	if r.timer != nil {
+		r.timer.Stop()
+		r.timer = nil
+	}
+	if !next.IsZero() {
+		r.timer = time.AfterFunc(next.Sub(now), r.flush)
+	}
+	return finished
+}
+
+// flush reports the repeats suppressed in the windows which have
+// finished
+func (r *logRepeats) flush() {
+	r.mu.Lock()
+	finished := r._prune(time.Now(), false)
+	r.mu.Unlock()
+	reportRepeats(1, finished)
+}
+
+// reportRepeats logs the number of repeats suppressed for each log
+//
+// callDepth is the number of stack frames to skip to find the caller
+// to report the logs as coming from, as passed to log.Output.
+func reportRepeats(callDepth int, finished []*logRepeat) {
+	for _, x := range finished {
+		logOutput(context.Background(), callDepth+1, x.ci, x.level, x.o, fmt.Sprintf("%s (suppressed %d repeats within %v)", x.out, x.count-x.ci.LogRepeatLimit, x.ci.LogRepeatWindow), nil)
+	}
+}
+
+// FlushLogRepeats reports the number of repeats suppressed by
+// --log-repeat-limit in the windows which haven't finished yet. It
+// should be called when rclone exits.
+func FlushLogRepeats() {
+	repeats.mu.Lock()
+	finished := repeats._prune(time.Now(), true)
+	repeats.mu.Unlock()
+	reportRepeats(2, finished)
+}
+
 // LogPrint sends the text to the logger of level
-var LogPrint = func(level LogLevel, text string) {
+//
+// callDepth is the number of stack frames to skip to find the caller
+// of Errorf, Infof etc, as passed to log.Output, so the file and line
+// logged with --log-format shortfile or longfile are theirs.
+var LogPrint = func(level LogLevel, callDepth int, text string) {
 	text = fmt.Sprintf("%-6s: %s", level, text)
-	_ = log.Output(4, text)
+	_ = log.Output(callDepth+1, text)
 }
 
 // LogValueItem describes keyed item for a JSON log entry
@@ -125,11 +375,87 @@ func (j LogValueItem) String() string {
 	return fmt.Sprint(j.value)
 }
 
+// Type of key used to store the LogSpan in the context
+type logSpanContextKeyType struct{}
+
+// Context key for the LogSpan
+var logSpanContextKey = logSpanContextKeyType{}
+
+// LogSpan identifies an operation, e.g. a sync or the transfer of a
+// single file, in the JSON logs so the logs from operations running
+// in parallel can be told apart.
+//
+// All the spans started from the same span share its TraceID.
+type LogSpan struct {
+	TraceID  string // identifies the operation this was started from
+	SpanID   string // identifies this operation
+	ParentID string // the SpanID of the span this was started from, if any
+}
+
+// newLogID returns a random ID of n bytes as hex
+func newLogID(n int) string {
+	id := make([]byte, n)
+	_, _ = rand.Read(id)
+	return hex.EncodeToString(id)
+}
+
+// StartLogSpan returns a copy of ctx with a new LogSpan in which
+// continues the trace of the LogSpan in ctx, if any.
+//
+// The IDs of the span are added to the JSON logs made with the Ctx
+// variants of the logging functions, e.g. DebugfCtx.
+func StartLogSpan(ctx context.Context) context.Context {
+	span := &LogSpan{
+		SpanID: newLogID(8),
+	}
+	if parent := GetLogSpan(ctx); parent != nil {
+		span.TraceID = parent.TraceID
+		span.ParentID = parent.SpanID
+	} else {
+		span.TraceID = newLogID(16)
+	}
+	return context.WithValue(ctx, logSpanContextKey, span)
+}
+
+// GetLogSpan returns the LogSpan in ctx or nil if there isn't one
+func GetLogSpan(ctx context.Context) *LogSpan {
+	if ctx == nil {
+		return nil
+	}
+	span, _ := ctx.Value(logSpanContextKey).(*LogSpan)
+	return span
+}
+
 // LogPrintf produces a log string from the arguments passed in
 func LogPrintf(level LogLevel, o interface{}, text string, args ...interface{}) {
+	logPrintf(context.TODO(), 2, level, o, text, args...)
+}
+
+// LogPrintfCtx produces a log string from the arguments passed in
+// adding the IDs of the LogSpan in ctx, if any, to the JSON logs.
+func LogPrintfCtx(ctx context.Context, level LogLevel, o interface{}, text string, args ...interface{}) {
+	logPrintf(ctx, 2, level, o, text, args...)
+}
+
+// logPrintf does the work for LogPrintf, Errorf etc.
+//
+// callDepth is the number of stack frames to skip to find the caller
+// to report the log as coming from, as passed to log.Output, so 2 is
+// the caller of the function calling logPrintf.
+func logPrintf(ctx context.Context, callDepth int, level LogLevel, o interface{}, text string, args ...interface{}) {
 	out := fmt.Sprintf(text, args...)
+	ci := GetConfig(ctx)
+	if ci.LogRepeatLimit > 0 && ci.LogRepeatWindow > 0 {
+		if repeats.check(callDepth+1, ci, level, o, out) {
+			return
+		}
+	}
+	logOutput(ctx, callDepth+1, ci, level, o, out, args)
+}
 
-	if GetConfig(context.TODO()).UseJSONLog {
+// logOutput sends the log to logrus or LogPrint
+func logOutput(ctx context.Context, callDepth int, ci *ConfigInfo, level LogLevel, o interface{}, out string, args []interface{}) {
+	if ci.UseJSONLog {
 		fields := logrus.Fields{}
 		if o != nil {
 			fields = logrus.Fields{
@@ -137,6 +463,13 @@ func LogPrintf(level LogLevel, o interface{}, text string, args ...interface{})
 				"objectType": fmt.Sprintf("%T", o),
 			}
 		}
+		if span := GetLogSpan(ctx); span != nil {
+			fields["trace_id"] = span.TraceID
+			fields["span_id"] = span.SpanID
+			if span.ParentID != "" {
+				fields["parent_id"] = span.ParentID
+			}
+		}
 		for _, arg := range args {
 			if item, ok := arg.(LogValueItem); ok {
 				fields[item.key] = item.value
@@ -160,22 +493,29 @@ func LogPrintf(level LogLevel, o interface{}, text string, args ...interface{})
 		if o != nil {
 			out = fmt.Sprintf("%v: %s", o, out)
 		}
-		LogPrint(level, out)
+		LogPrint(level, callDepth+1, out)
 	}
 }
 
 // LogLevelPrintf writes logs at the given level
 func LogLevelPrintf(level LogLevel, o interface{}, text string, args ...interface{}) {
-	if GetConfig(context.TODO()).LogLevel >= level {
-		LogPrintf(level, o, text, args...)
+	if GetConfig(context.TODO()).logEnabled(level, o, text) {
+		logPrintf(context.TODO(), 2, level, o, text, args...)
 	}
 }
 
 // Errorf writes error log output for this Object or Fs.  It
 // should always be seen by the user.
 func Errorf(o interface{}, text string, args ...interface{}) {
-	if GetConfig(context.TODO()).LogLevel >= LogLevelError {
-		LogPrintf(LogLevelError, o, text, args...)
+	if GetConfig(context.TODO()).logEnabled(LogLevelError, o, text) {
+		logPrintf(context.TODO(), 2, LogLevelError, o, text, args...)
+	}
+}
+
+// ErrorfCtx is like Errorf but uses the config and LogSpan in ctx
+func ErrorfCtx(ctx context.Context, o interface{}, text string, args ...interface{}) {
+	if GetConfig(ctx).logEnabled(LogLevelError, o, text) {
+		logPrintf(ctx, 2, LogLevelError, o, text, args...)
 	}
 }
 
@@ -185,8 +525,15 @@ func Errorf(o interface{}, text string, args ...interface{}) {
 // important things the user should see.  The user can filter these
 // out with the -q flag.
 func Logf(o interface{}, text string, args ...interface{}) {
-	if GetConfig(context.TODO()).LogLevel >= LogLevelNotice {
-		LogPrintf(LogLevelNotice, o, text, args...)
+	if GetConfig(context.TODO()).logEnabled(LogLevelNotice, o, text) {
+		logPrintf(context.TODO(), 2, LogLevelNotice, o, text, args...)
+	}
+}
+
+// LogfCtx is like Logf but uses the config and LogSpan in ctx
+func LogfCtx(ctx context.Context, o interface{}, text string, args ...interface{}) {
+	if GetConfig(ctx).logEnabled(LogLevelNotice, o, text) {
+		logPrintf(ctx, 2, LogLevelNotice, o, text, args...)
 	}
 }
 
@@ -194,16 +541,30 @@ func Logf(o interface{}, text string, args ...interface{}) {
 // level for logging transfers, deletions and things which should
 // appear with the -v flag.
 func Infof(o interface{}, text string, args ...interface{}) {
-	if GetConfig(context.TODO()).LogLevel >= LogLevelInfo {
-		LogPrintf(LogLevelInfo, o, text, args...)
+	if GetConfig(context.TODO()).logEnabled(LogLevelInfo, o, text) {
+		logPrintf(context.TODO(), 2, LogLevelInfo, o, text, args...)
+	}
+}
+
+// InfofCtx is like Infof but uses the config and LogSpan in ctx
+func InfofCtx(ctx context.Context, o interface{}, text string, args ...interface{}) {
+	if GetConfig(ctx).logEnabled(LogLevelInfo, o, text) {
+		logPrintf(ctx, 2, LogLevelInfo, o, text, args...)
 	}
 }
 
 // Debugf writes debugging output for this Object or Fs.  Use this for
 // debug only.  The user must have to specify -vv to see this.
 func Debugf(o interface{}, text string, args ...interface{}) {
-	if GetConfig(context.TODO()).LogLevel >= LogLevelDebug {
-		LogPrintf(LogLevelDebug, o, text, args...)
+	if GetConfig(context.TODO()).logEnabled(LogLevelDebug, o, text) {
+		logPrintf(context.TODO(), 2, LogLevelDebug, o, text, args...)
+	}
+}
+
+// DebugfCtx is like Debugf but uses the config and LogSpan in ctx
+func DebugfCtx(ctx context.Context, o interface{}, text string, args ...interface{}) {
+	if GetConfig(ctx).logEnabled(LogLevelDebug, o, text) {
+		logPrintf(ctx, 2, LogLevelDebug, o, text, args...)
 	}
 }
 
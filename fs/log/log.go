@@ -12,6 +12,7 @@ import (
 
 	systemd "github.com/iguanesolutions/go-systemd/v5"
 	"github.com/pingme998/rclone/fs"
+	"github.com/pingme998/rclone/lib/atexit"
 	"github.com/sirupsen/logrus"
 )
 
@@ -148,6 +149,9 @@ func InitLogging() {
 	if Opt.LogSystemdSupport {
 		startSystemdLog()
 	}
+
+	// Report any repeats suppressed by --log-repeat-limit on exit
+	atexit.Register(fs.FlushLogRepeats)
 }
 
 // Redirected returns true if the log has been redirected from stdout
@@ -24,9 +24,9 @@ func startSystemdLog() bool {
 		flags |= log.Lshortfile
 	}
 	log.SetFlags(flags)
-	fs.LogPrint = func(level fs.LogLevel, text string) {
+	fs.LogPrint = func(level fs.LogLevel, callDepth int, text string) {
 		text = fmt.Sprintf("%s%-6s: %s", systemdLogPrefix(level), level, text)
-		_ = log.Output(4, text)
+		_ = log.Output(callDepth+1, text)
 	}
 	return true
 }
@@ -1,11 +1,20 @@
 package fs
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
+	"log"
+	"os"
+	"runtime"
 	"strconv"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -90,3 +99,265 @@ func TestLogLevelUnmarshalJSON(t *testing.T) {
 		assert.Equal(t, test.want, logLevel, test.in)
 	}
 }
+
+func TestStartLogSpan(t *testing.T) {
+	ctx := context.Background()
+	assert.Nil(t, GetLogSpan(ctx))
+
+	ctx = StartLogSpan(ctx)
+	parent := GetLogSpan(ctx)
+	require.NotNil(t, parent)
+	assert.Len(t, parent.TraceID, 32)
+	assert.Len(t, parent.SpanID, 16)
+	assert.Equal(t, "", parent.ParentID)
+
+	child := GetLogSpan(StartLogSpan(ctx))
+	require.NotNil(t, child)
+	assert.Equal(t, parent.TraceID, child.TraceID)
+	assert.Equal(t, parent.SpanID, child.ParentID)
+	assert.NotEqual(t, parent.SpanID, child.SpanID)
+
+	other := GetLogSpan(StartLogSpan(context.Background()))
+	assert.NotEqual(t, parent.TraceID, other.TraceID)
+}
+
+func TestLogPrintfCtxJSON(t *testing.T) {
+	var buf bytes.Buffer
+	logrus.SetOutput(&buf)
+	logrus.SetFormatter(&logrus.JSONFormatter{})
+	logrus.SetLevel(logrus.DebugLevel)
+	defer func() {
+		logrus.SetOutput(os.Stderr)
+		logrus.SetFormatter(new(logrus.TextFormatter))
+		logrus.SetLevel(logrus.InfoLevel)
+	}()
+	ctx, ci := AddConfig(context.Background())
+	ci.UseJSONLog = true
+	ci.LogLevel = LogLevelDebug
+
+	decode := func() (fields map[string]interface{}) {
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &fields))
+		buf.Reset()
+		return fields
+	}
+
+	// No span
+	ErrorfCtx(ctx, nil, "hello %d", 1)
+	fields := decode()
+	assert.Equal(t, "hello 1", fields["msg"])
+	assert.Nil(t, fields["trace_id"])
+
+	// With spans
+	ctx = StartLogSpan(ctx)
+	parent := GetLogSpan(ctx)
+	InfofCtx(ctx, nil, "parent")
+	fields = decode()
+	assert.Equal(t, parent.TraceID, fields["trace_id"])
+	assert.Equal(t, parent.SpanID, fields["span_id"])
+	assert.Nil(t, fields["parent_id"])
+
+	childCtx := StartLogSpan(ctx)
+	DebugfCtx(childCtx, nil, "child")
+	fields = decode()
+	assert.Equal(t, parent.TraceID, fields["trace_id"])
+	assert.Equal(t, GetLogSpan(childCtx).SpanID, fields["span_id"])
+	assert.Equal(t, parent.SpanID, fields["parent_id"])
+
+	// The level is read from the config in ctx
+	ci.LogLevel = LogLevelNotice
+	DebugfCtx(ctx, nil, "not logged")
+	assert.Equal(t, 0, buf.Len())
+	LogfCtx(ctx, nil, "logged")
+	assert.Equal(t, "logged", decode()["msg"])
+}
+
+func TestSetLogLevel(t *testing.T) {
+	for _, test := range []struct {
+		in         string
+		wantLevel  LogLevel
+		wantLevels SubsystemLogLevels
+		levelSet   bool
+		err        bool
+	}{
+		{"DEBUG", LogLevelDebug, nil, true, false},
+		{"vfscache=DEBUG,pacer=INFO", LogLevelNotice, SubsystemLogLevels{"vfscache": LogLevelDebug, "pacer": LogLevelInfo}, false, false},
+		{"ERROR, VFS Cache=debug", LogLevelError, SubsystemLogLevels{"vfscache": LogLevelDebug}, true, false},
+		{"Potato", LogLevelNotice, nil, false, true},
+		{"vfscache=Potato", LogLevelNotice, nil, false, true},
+		{"=DEBUG", LogLevelNotice, nil, false, true},
+	} {
+		ci := NewConfig()
+		levelSet, err := ci.SetLogLevel(test.in)
+		if test.err {
+			require.Error(t, err, test.in)
+		} else {
+			require.NoError(t, err, test.in)
+		}
+		assert.Equal(t, test.levelSet, levelSet, test.in)
+		assert.Equal(t, test.wantLevel, ci.LogLevel, test.in)
+		assert.Equal(t, test.wantLevels, ci.SubsystemLogLevels, test.in)
+	}
+	assert.Equal(t, "pacer=INFO,vfscache=DEBUG", SubsystemLogLevels{"vfscache": LogLevelDebug, "pacer": LogLevelInfo}.String())
+}
+
+func TestLogEnabled(t *testing.T) {
+	ci := NewConfig()
+	assert.True(t, ci.logEnabled(LogLevelNotice, nil, "vfs cache: hello"))
+	assert.False(t, ci.logEnabled(LogLevelDebug, nil, "vfs cache: hello"))
+
+	_, err := ci.SetLogLevel("vfscache=DEBUG,pacer=ERROR,file.txt=DEBUG")
+	require.NoError(t, err)
+	for _, test := range []struct {
+		level LogLevel
+		o     interface{}
+		text  string
+		want  bool
+	}{
+		{LogLevelDebug, nil, "vfs cache: hello", true},
+		{LogLevelDebug, "file.txt", "vfs cache: hello", true},
+		{LogLevelDebug, nil, "VFS Cache: hello", true},
+		{LogLevelDebug, nil, "fs cache: hello", false},
+		{LogLevelNotice, nil, "fs cache: hello", true},
+		{LogLevelNotice, "pacer", "Reducing sleep to %v", false},
+		{LogLevelError, "pacer", "Reducing sleep to %v", true},
+		{LogLevelDebug, "file.txt", "Copied", true},
+		{LogLevelDebug, "other.txt", "Copied", false},
+		{LogLevelDebug, nil, "%s: vfs cache", false},
+		{LogLevelDebug, nil, "no subsystem", false},
+	} {
+		got := ci.logEnabled(test.level, test.o, test.text)
+		assert.Equal(t, test.want, got, fmt.Sprintf("%v %v %q", test.level, test.o, test.text))
+	}
+}
+
+func TestLogRepeatLimit(t *testing.T) {
+	var (
+		mu   sync.Mutex
+		logs []string
+	)
+	getLogs := func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		out := logs
+		logs = nil
+		return out
+	}
+	oldLogPrint := LogPrint
+	LogPrint = func(level LogLevel, callDepth int, text string) {
+		mu.Lock()
+		logs = append(logs, text)
+		mu.Unlock()
+	}
+	defer func() {
+		LogPrint = oldLogPrint
+	}()
+	ctx, ci := AddConfig(context.Background())
+	ci.LogRepeatLimit = 2
+	ci.LogRepeatWindow = 100 * time.Millisecond
+
+	for i := 0; i < 5; i++ {
+		LogfCtx(ctx, nil, "vfs cache: in KickCleaner")
+		LogfCtx(ctx, "file.txt", "different %d", i)
+	}
+	assert.Equal(t, []string{
+		"vfs cache: in KickCleaner",
+		"file.txt: different 0",
+		"vfs cache: in KickCleaner",
+		"file.txt: different 1",
+		"file.txt: different 2",
+		"file.txt: different 3",
+		"file.txt: different 4",
+	}, getLogs())
+
+	// The number suppressed is reported when the window ends
+	// without needing another log
+	time.Sleep(2 * ci.LogRepeatWindow)
+	assert.Equal(t, []string{
+		"vfs cache: in KickCleaner (suppressed 3 repeats within 100ms)",
+	}, getLogs())
+	LogfCtx(ctx, nil, "vfs cache: in KickCleaner")
+	assert.Equal(t, []string{
+		"vfs cache: in KickCleaner",
+	}, getLogs())
+
+	// And on exit for the windows still running
+	ci.LogRepeatWindow = time.Hour
+	for i := 0; i < 3; i++ {
+		LogfCtx(ctx, nil, "vfs cache: in KickCleaner")
+	}
+	assert.Equal(t, []string{
+		"vfs cache: in KickCleaner",
+	}, getLogs())
+	FlushLogRepeats()
+	assert.Equal(t, []string{
+		"vfs cache: in KickCleaner (suppressed 2 repeats within 1h0m0s)",
+	}, getLogs())
+	FlushLogRepeats()
+	assert.Nil(t, getLogs())
+
+	// No limit
+	ci.LogRepeatLimit = 0
+	for i := 0; i < 5; i++ {
+		LogfCtx(ctx, nil, "vfs cache: in KickCleaner")
+	}
+	assert.Len(t, getLogs(), 5)
+	repeats.mu.Lock()
+	assert.Empty(t, repeats.seen)
+	repeats.mu.Unlock()
+}
+
+func TestSubsystemLogLevelsUnmarshalJSON(t *testing.T) {
+	var ci ConfigInfo
+	err := json.Unmarshal([]byte(`{"SubsystemLogLevels":{"VFS Cache":"DEBUG","pacer":6}}`), &ci)
+	require.NoError(t, err)
+	assert.Equal(t, SubsystemLogLevels{"vfscache": LogLevelDebug, "pacer": LogLevelInfo}, ci.SubsystemLogLevels)
+	assert.True(t, ci.logEnabled(LogLevelDebug, nil, "vfs cache: hello"))
+
+	err = json.Unmarshal([]byte(`{"SubsystemLogLevels":{"pacer":"Potato"}}`), &ci)
+	require.Error(t, err)
+}
+
+func TestLogCallDepth(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	log.SetFlags(log.Lshortfile)
+	defer func() {
+		log.SetOutput(os.Stderr)
+		log.SetFlags(log.LstdFlags)
+	}()
+	// where returns the line it is called from
+	where := func() int {
+		_, _, line, _ := runtime.Caller(1)
+		return line
+	}
+	// logged checks the log was reported as coming from line
+	logged := func(line int) {
+		prefix := fmt.Sprintf("log_test.go:%d: ", line)
+		assert.True(t, strings.HasPrefix(buf.String(), prefix), "want %q in %q", prefix, buf.String())
+		buf.Reset()
+	}
+	ctx, ci := AddConfig(context.Background())
+	FlushLogRepeats()
+
+	Logf(nil, "%d", where())
+	logged(where() - 1)
+	LogfCtx(ctx, nil, "%d", where())
+	logged(where() - 1)
+	LogPrintf(LogLevelNotice, nil, "%d", where())
+	logged(where() - 1)
+	LogPrintfCtx(ctx, LogLevelNotice, nil, "%d", where())
+	logged(where() - 1)
+	LogLevelPrintf(LogLevelNotice, nil, "%d", where())
+	logged(where() - 1)
+
+	// The repeats are reported as coming from the caller of
+	// FlushLogRepeats
+	ci.LogRepeatLimit = 1
+	ci.LogRepeatWindow = time.Hour
+	for i := 0; i < 2; i++ {
+		LogfCtx(ctx, nil, "repeated")
+	}
+	logged(where() - 2)
+	FlushLogRepeats()
+	logged(where() - 1)
+}
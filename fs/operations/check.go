@@ -114,7 +114,6 @@ func (c *checkMarch) SrcOnly(src fs.DirEntry) (recurse bool) {
 
 // check to see if two objects are identical using the check function
 func (c *checkMarch) checkIdentical(ctx context.Context, dst, src fs.Object) (differ bool, noHash bool, err error) {
-	ci := fs.GetConfig(ctx)
 	tr := accounting.Stats(ctx).NewCheckingTransfer(src)
 	defer func() {
 		tr.Done(ctx, err)
@@ -124,7 +123,7 @@ func (c *checkMarch) checkIdentical(ctx context.Context, dst, src fs.Object) (di
 		fs.Errorf(src, "%v", err)
 		return true, false, nil
 	}
-	if ci.SizeOnly {
+	if defaultEqualOpt(ctx, c.opt.Fsrc, c.opt.Fdst).sizeOnly {
 		return false, false, nil
 	}
 	return c.opt.Check(ctx, dst, src)
@@ -0,0 +1,152 @@
+package operations
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/pingme998/rclone/fs"
+	"github.com/pingme998/rclone/fs/hash"
+	"github.com/pkg/errors"
+)
+
+// ChecksumCreate writes a checksum manifest of the objects in f to w
+// using hash ht, sorted by path.
+//
+// If sfv is set then the manifest is written in SFV format which
+// requires a CRC-32 hash, otherwise it is written in the format used
+// by md5sum and sha1sum.
+//
+// If download is set the objects are read and hashed locally,
+// otherwise the hashes are read from the remote.
+//
+// Obeys includes and excludes.
+func ChecksumCreate(ctx context.Context, f fs.Fs, ht hash.Type, sfv bool, download bool, w io.Writer) error {
+	if sfv && ht != hash.CRC32 {
+		return errors.Errorf("SFV files need a %v hash not %v", hash.CRC32, ht)
+	}
+	if !download && !f.Hashes().Contains(ht) {
+		return errors.Errorf("%v doesn't support %v hashes - use --download", f, ht)
+	}
+	var (
+		mu    sync.Mutex
+		wg    sync.WaitGroup
+		lines []string
+	)
+	tokens := make(chan struct{}, fs.GetConfig(ctx).Transfers)
+	err := ListFn(ctx, f, func(o fs.Object) {
+		wg.Add(1)
+		tokens <- struct{}{}
+		go func() {
+			defer func() {
+				<-tokens
+				wg.Done()
+			}()
+			sum, err := hashSum(ctx, ht, download, o)
+			if err == nil && sum == "" {
+				err = errors.Errorf("%v hash not available - use --download", ht)
+			}
+			if err != nil {
+				err = fs.CountError(err)
+				fs.Errorf(o, "%v", err)
+				return
+			}
+			var line string
+			if sfv {
+				line = fmt.Sprintf("%s %s", o.Remote(), strings.ToUpper(sum))
+			} else {
+				line = fmt.Sprintf("%s  %s", sum, o.Remote())
+			}
+			mu.Lock()
+			lines = append(lines, line)
+			mu.Unlock()
+		}()
+	})
+	wg.Wait()
+	if err != nil {
+		return err
+	}
+	if sfv {
+		sort.Strings(lines)
+	} else {
+		// sort on the path which is after the fixed width hash
+		sort.Slice(lines, func(i, j int) bool {
+			return lines[i][hash.Width(ht):] < lines[j][hash.Width(ht):]
+		})
+	}
+	for _, line := range lines {
+		_, err = fmt.Fprintln(w, line)
+		if err != nil {
+			return errors.Wrap(err, "failed to write manifest")
+		}
+	}
+	return nil
+}
+
+// ReadSFV reads a manifest in SFV format returning a map of CRC-32
+// hashes indexed by path
+//
+// Lines starting with ; are comments.
+func ReadSFV(in io.Reader) (sums map[string]string, err error) {
+	sums = make(map[string]string)
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(nil, 1024*1024)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if line == "" || line[0] == ';' {
+			continue
+		}
+		i := strings.LastIndex(line, " ")
+		if i <= 0 || len(line)-i-1 != hash.Width(hash.CRC32) {
+			return nil, errors.Errorf("line %d: expecting \"path CRC32\"", lineNumber)
+		}
+		sums[line[:i]] = strings.ToLower(line[i+1:])
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "failed to read SFV file")
+	}
+	return sums, nil
+}
+
+// ReadChecksumManifest reads a checksum manifest written by
+// ChecksumCreate, md5sum, sha1sum or an SFV tool, returning the hashes
+// indexed by path and the hash type.
+//
+// If ht is hash.None then the type is chosen from the length of the
+// hashes.
+func ReadChecksumManifest(in io.Reader, sfv bool, ht hash.Type) (sums map[string]string, hashType hash.Type, err error) {
+	if sfv {
+		if ht != hash.None && ht != hash.CRC32 {
+			return nil, hash.None, errors.Errorf("SFV files contain %v hashes not %v", hash.CRC32, ht)
+		}
+		sums, err = ReadSFV(in)
+		return sums, hash.CRC32, err
+	}
+	sums, err = ReadHashSums(in)
+	if err != nil {
+		return nil, hash.None, err
+	}
+	if ht != hash.None {
+		return sums, ht, nil
+	}
+	width := -1
+	for _, sum := range sums {
+		width = len(sum)
+		break
+	}
+	if width < 0 {
+		return sums, hash.MD5, nil
+	}
+	for _, ht = range hash.Supported().Array() {
+		if hash.Width(ht) == width {
+			return sums, ht, nil
+		}
+	}
+	return nil, hash.None, errors.Errorf("can't find a hash type for hashes %d characters long - use --hash", width)
+}
@@ -0,0 +1,99 @@
+package operations_test
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/pingme998/rclone/fs/hash"
+	"github.com/pingme998/rclone/fs/operations"
+	"github.com/pingme998/rclone/fstest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChecksumCreate(t *testing.T) {
+	ctx := context.Background()
+	r := fstest.NewRun(t)
+	defer r.Finalise()
+	r.WriteFile("b", "hello", t1)
+	r.WriteFile("a/file", "HELLO", t1)
+
+	var buf bytes.Buffer
+	require.NoError(t, operations.ChecksumCreate(ctx, r.Flocal, hash.MD5, false, false, &buf))
+	assert.Equal(t, `eb61eead90e3b899c6bcbe27ac581660  a/file
+5d41402abc4b2a76b9719d911017c592  b
+`, buf.String())
+	sums, ht, err := operations.ReadChecksumManifest(&buf, false, hash.None)
+	require.NoError(t, err)
+	assert.Equal(t, hash.MD5, ht)
+	assert.Equal(t, map[string]string{
+		"a/file": "eb61eead90e3b899c6bcbe27ac581660",
+		"b":      "5d41402abc4b2a76b9719d911017c592",
+	}, sums)
+
+	buf.Reset()
+	require.NoError(t, operations.ChecksumCreate(ctx, r.Flocal, hash.CRC32, true, true, &buf))
+	assert.Equal(t, `a/file C1446436
+b 3610A686
+`, buf.String())
+	sums, ht, err = operations.ReadChecksumManifest(&buf, true, hash.None)
+	require.NoError(t, err)
+	assert.Equal(t, hash.CRC32, ht)
+	assert.Equal(t, map[string]string{"a/file": "c1446436", "b": "3610a686"}, sums)
+
+	// verify the remote against the manifest
+	_, err = operations.Verify(ctx, &operations.VerifyOpt{
+		Fdst:          r.Flocal,
+		Manifest:      sums,
+		HashType:      ht,
+		UseRemoteHash: true,
+	})
+	require.NoError(t, err)
+	sums["b"] = "00000000"
+	report, err := operations.Verify(ctx, &operations.VerifyOpt{
+		Fdst:          r.Flocal,
+		Manifest:      sums,
+		HashType:      ht,
+		UseRemoteHash: true,
+	})
+	require.Error(t, err)
+	assert.Equal(t, []operations.VerifyProblem{{Path: "b", Result: operations.VerifyDiffer}}, report.Problems)
+
+	// SFV needs CRC-32
+	assert.Error(t, operations.ChecksumCreate(ctx, r.Flocal, hash.MD5, true, true, &buf))
+}
+
+func TestReadChecksumManifest(t *testing.T) {
+	sums, ht, err := operations.ReadChecksumManifest(strings.NewReader("; comment\r\nfile with spaces 3610A686\r\n\r\n"), true, hash.None)
+	require.NoError(t, err)
+	assert.Equal(t, hash.CRC32, ht)
+	assert.Equal(t, map[string]string{"file with spaces": "3610a686"}, sums)
+
+	_, ht, err = operations.ReadChecksumManifest(strings.NewReader("aaf4c61ddcc5e8a2dabede0f3b482cd9aea9434d  file\n"), false, hash.None)
+	require.NoError(t, err)
+	assert.Equal(t, hash.SHA1, ht)
+
+	_, ht, err = operations.ReadChecksumManifest(strings.NewReader(""), false, hash.None)
+	require.NoError(t, err)
+	assert.Equal(t, hash.MD5, ht)
+
+	_, ht, err = operations.ReadChecksumManifest(strings.NewReader("abc  file\n"), false, hash.SHA1)
+	require.NoError(t, err)
+	assert.Equal(t, hash.SHA1, ht)
+
+	for _, test := range []struct {
+		in  string
+		sfv bool
+		ht  hash.Type
+	}{
+		{"abc  file\n", false, hash.None},
+		{"file 3610A6\n", true, hash.None},
+		{"3610A686\n", true, hash.None},
+		{"file 3610A686\n", true, hash.MD5},
+	} {
+		_, _, err = operations.ReadChecksumManifest(strings.NewReader(test.in), test.sfv, test.ht)
+		assert.Error(t, err, test.in)
+	}
+}
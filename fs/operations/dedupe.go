@@ -15,6 +15,7 @@ import (
 	"github.com/pingme998/rclone/fs/config"
 	"github.com/pingme998/rclone/fs/hash"
 	"github.com/pingme998/rclone/fs/walk"
+	"golang.org/x/text/unicode/norm"
 )
 
 // dedupeRename renames the objs slice to different names
@@ -189,6 +190,7 @@ const (
 	DeduplicateLargest                            // choose the largest object
 	DeduplicateSmallest                           // choose the smallest object
 	DeduplicateList                               // list duplicates only
+	DeduplicateDirs                               // merge directories differing in normalization or trailing spaces
 )
 
 func (x DeduplicateMode) String() string {
@@ -211,6 +213,8 @@ func (x DeduplicateMode) String() string {
 		return "smallest"
 	case DeduplicateList:
 		return "list"
+	case DeduplicateDirs:
+		return "dirs"
 	}
 	return "unknown"
 }
@@ -236,6 +240,8 @@ func (x *DeduplicateMode) Set(s string) error {
 		*x = DeduplicateSmallest
 	case "list":
 		*x = DeduplicateList
+	case "dirs":
+		*x = DeduplicateDirs
 	default:
 		return errors.Errorf("Unknown mode for dedupe %q.", s)
 	}
@@ -376,6 +382,201 @@ func dedupeMergeDuplicateDirs(ctx context.Context, f fs.Fs, duplicateDirs [][]*d
 	return nil
 }
 
+// dedupeNormalizeName returns name with the differences which make
+// duplicate directories on bucket based remotes removed, ie with
+// unicode normalized to NFC and trailing spaces removed.
+func dedupeNormalizeName(name string) string {
+	return strings.TrimRight(norm.NFC.String(name), " ")
+}
+
+// dedupeNormalizePath normalizes each directory name in dir
+func dedupeNormalizePath(dir string) string {
+	if dir == "" {
+		return ""
+	}
+	parts := strings.Split(dir, "/")
+	for i := range parts {
+		parts[i] = dedupeNormalizeName(parts[i])
+	}
+	return strings.Join(parts, "/")
+}
+
+// dedupeParent returns the parent directory of remote or "" for the root
+func dedupeParent(remote string) string {
+	parent := path.Dir(remote)
+	if parent == "." {
+		parent = ""
+	}
+	return parent
+}
+
+// dedupeFreeName finds a name like remote which isn't in use
+func dedupeFreeName(ctx context.Context, f fs.Fs, remote string) (string, error) {
+	ext := path.Ext(remote)
+	base := remote[:len(remote)-len(ext)]
+	for suffix := 1; suffix <= 100; suffix++ {
+		newName := fmt.Sprintf("%s-%d%s", base, suffix, ext)
+		_, err := f.NewObject(ctx, newName)
+		if err == fs.ErrorObjectNotFound {
+			return newName, nil
+		} else if err != nil {
+			return "", errors.Wrap(err, "failed to check for existing object")
+		}
+	}
+	return "", errors.New("could not find an available new name")
+}
+
+// dedupeMoveObject moves o to newRemote
+//
+// If there is an identical object there already o is deleted, and if
+// there is a different one o is renamed to be different.
+func dedupeMoveObject(ctx context.Context, f fs.Fs, o fs.Object, newRemote string) error {
+	dst, err := f.NewObject(ctx, newRemote)
+	if err == nil {
+		if Equal(ctx, o, dst) {
+			fs.Infof(o, "Deleting as identical to %q", newRemote)
+			return DeleteFile(ctx, o)
+		}
+		newRemote, err = dedupeFreeName(ctx, f, newRemote)
+		if err != nil {
+			return err
+		}
+	} else if err != fs.ErrorObjectNotFound {
+		return errors.Wrap(err, "failed to check for existing object")
+	}
+	newObj, err := Move(ctx, f, nil, newRemote, o)
+	if err == nil && newObj != nil {
+		fs.Infof(newObj, "Moved from %q", o.Remote())
+	}
+	return err
+}
+
+// dedupeNormalizedDirs finds directories whose names differ only in
+// unicode normalization or trailing spaces and merges them.
+//
+// These are common on bucket based remotes after migrations from
+// Windows as they are just different prefixes of the object keys.
+//
+// The directory with the most objects is kept to minimize the number
+// of server-side moves, preferring the normalized name if there is a
+// tie.
+func dedupeNormalizedDirs(ctx context.Context, f fs.Fs) error {
+	ci := fs.GetConfig(ctx)
+	var objs []fs.Object
+	counts := map[string]int{}       // number of objects in each directory recursively
+	allDirs := map[string]struct{}{} // all the directories found
+	dirs := map[string][]string{}    // directories by normalized path
+	addDir := func(dir string) {
+		if _, found := allDirs[dir]; !found {
+			allDirs[dir] = struct{}{}
+			key := dedupeNormalizePath(dir)
+			dirs[key] = append(dirs[key], dir)
+		}
+	}
+	err := walk.ListR(ctx, f, "", true, ci.MaxDepth, walk.ListAll, func(entries fs.DirEntries) error {
+		for _, entry := range entries {
+			switch x := entry.(type) {
+			case fs.Object:
+				objs = append(objs, x)
+				// Bucket based remotes may not return the directories
+				for dir := dedupeParent(x.Remote()); dir != ""; dir = dedupeParent(dir) {
+					counts[dir]++
+					addDir(dir)
+				}
+			case fs.Directory:
+				addDir(x.Remote())
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return errors.Wrap(err, "find duplicate dirs")
+	}
+
+	// Work out which directory each normalized path is merged into
+	canonical := map[string]string{}
+	var canonicalPath func(key string) string
+	canonicalPath = func(key string) string {
+		if key == "" {
+			return ""
+		}
+		if dir, found := canonical[key]; found {
+			return dir
+		}
+		members := dirs[key]
+		sort.Slice(members, func(i, j int) bool {
+			a, b := members[i], members[j]
+			if counts[a] != counts[b] {
+				return counts[a] > counts[b]
+			}
+			if (a == key) != (b == key) {
+				return a == key
+			}
+			return a < b
+		})
+		dir := path.Join(canonicalPath(dedupeParent(key)), path.Base(members[0]))
+		canonical[key] = dir
+		return dir
+	}
+
+	// Report the duplicates
+	var keys []string
+	for key := range dirs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	duplicates := 0
+	for _, key := range keys {
+		members := dirs[key]
+		dir := canonicalPath(key)
+		if len(members) <= 1 {
+			continue
+		}
+		duplicates += len(members) - 1
+		fmt.Printf("%s: %d directories with names differing only in unicode normalization or trailing spaces\n", dir, len(members))
+		for _, member := range members {
+			fmt.Printf("  %q: %d objects\n", member, counts[member])
+		}
+	}
+	if duplicates == 0 {
+		fs.Logf(f, "No duplicate directories found")
+		return nil
+	}
+
+	// Move the objects into the directories being kept
+	for _, o := range objs {
+		remote := o.Remote()
+		newRemote := path.Join(canonicalPath(dedupeNormalizePath(dedupeParent(remote))), path.Base(remote))
+		if newRemote == remote {
+			continue
+		}
+		err := dedupeMoveObject(ctx, f, o, newRemote)
+		if err != nil {
+			err = fs.CountError(err)
+			fs.Errorf(o, "Failed to move to %q: %v", newRemote, err)
+		}
+	}
+
+	// Remove the directories merged, deepest first
+	var oldDirs []string
+	for dir := range allDirs {
+		if canonicalPath(dedupeNormalizePath(dir)) != dir {
+			oldDirs = append(oldDirs, dir)
+		}
+	}
+	sort.Slice(oldDirs, func(i, j int) bool {
+		return strings.Count(oldDirs[i], "/") > strings.Count(oldDirs[j], "/")
+	})
+	for _, dir := range oldDirs {
+		err := TryRmdir(ctx, f, dir)
+		if err != nil {
+			fs.Debugf(fs.LogDirName(f, dir), "Failed to remove merged directory: %v", err)
+		}
+	}
+	fs.Logf(f, "Merged %d duplicate directories", duplicates)
+	return nil
+}
+
 // sort oldest first
 func sortOldestFirst(objs []fs.Object) {
 	sort.Slice(objs, func(i, j int) bool {
@@ -404,8 +605,18 @@ func Deduplicate(ctx context.Context, f fs.Fs, mode DeduplicateMode, byHash bool
 		}
 		what = ht.String() + " hashes"
 	}
+	if mode == DeduplicateDirs {
+		if byHash {
+			return errors.New("can't use --by-hash with dirs mode")
+		}
+		what = "directory names"
+	}
 	fs.Infof(f, "Looking for duplicate %s using %v mode.", what, mode)
 
+	if mode == DeduplicateDirs {
+		return dedupeNormalizedDirs(ctx, f)
+	}
+
 	// Find duplicate directories first and fix them
 	if !byHash {
 		duplicateDirs, err := dedupeFindDuplicateDirs(ctx, f)
@@ -289,3 +289,36 @@ func TestMergeDirs(t *testing.T) {
 	assert.Equal(t, 0, len(objs))
 	assert.Equal(t, "dupe1", dirs[0].Remote())
 }
+
+func TestDeduplicateDirs(t *testing.T) {
+	r := fstest.NewRun(t)
+	defer r.Finalise()
+	if r.Fremote.Features().Move == nil && r.Fremote.Features().Copy == nil {
+		t.Skip("Can't test dirs mode without server-side move or copy")
+	}
+	ctx := context.Background()
+	nfc := "café"
+	nfd := "café"
+
+	file1 := r.WriteObject(ctx, "a/one.txt", "This is one", t1)
+	file2 := r.WriteObject(ctx, "a/two.txt", "This is two", t1)
+	file3 := r.WriteObject(ctx, "a /one.txt", "This is one", t1)
+	file4 := r.WriteObject(ctx, "a /two.txt", "This is a different two", t1)
+	file5 := r.WriteObject(ctx, "a /sub /three.txt", "This is three", t1)
+	file6 := r.WriteObject(ctx, nfd+"/four.txt", "This is four", t1)
+	file7 := r.WriteObject(ctx, nfc+"/five.txt", "This is five", t1)
+	file8 := r.WriteObject(ctx, "a/six.txt", "This is six", t1)
+	r.CheckWithDuplicates(t, file1, file2, file3, file4, file5, file6, file7, file8)
+
+	err := operations.Deduplicate(ctx, r.Fremote, operations.DeduplicateDirs, false)
+	require.NoError(t, err)
+
+	file4.Path = "a/two-1.txt"
+	file5.Path = "a/sub /three.txt"
+	file6.Path = nfc + "/four.txt"
+	fstest.CheckListingWithPrecision(t, r.Fremote, []fstest.Item{file1, file2, file4, file5, file6, file7, file8}, []string{
+		"a",
+		"a/sub ",
+		nfc,
+	}, fs.GetModifyWindow(ctx, r.Fremote))
+}
@@ -0,0 +1,146 @@
+package operations
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/pingme998/rclone/fs"
+)
+
+// dryRun accumulates the actions skipped because of --dry-run so a
+// summary can be shown at the end of the run.
+var dryRun = struct {
+	mu      sync.Mutex
+	actions map[string]map[string]map[string]int64 // action => dir => name => size
+}{
+	actions: map[string]map[string]map[string]int64{},
+}
+
+// dryRunRecord records that action was skipped on subject
+func dryRunRecord(subject interface{}, action string, size int64) {
+	remote := subjectRemote(subject)
+	dir := subjectDir(subject)
+	dryRun.mu.Lock()
+	defer dryRun.mu.Unlock()
+	dirs := dryRun.actions[action]
+	if dirs == nil {
+		dirs = map[string]map[string]int64{}
+		dryRun.actions[action] = dirs
+	}
+	names := dirs[dir]
+	if names == nil {
+		names = map[string]int64{}
+		dirs[dir] = names
+	}
+	names[remote] = size
+}
+
+// DryRunDir is the summary of an action in a single directory
+type DryRunDir struct {
+	Dir     string   `json:"dir"`     // directory the entries are in
+	Count   int      `json:"count"`   // number of entries
+	Bytes   int64    `json:"bytes"`   // total size of the entries with a size
+	Entries []string `json:"entries"` // sorted paths of the entries
+}
+
+// DryRunAction is the summary of a single action
+type DryRunAction struct {
+	Action string       `json:"action"` // the action, eg "copy" or "delete"
+	Count  int          `json:"count"`  // number of entries in all directories
+	Bytes  int64        `json:"bytes"`  // total size of the entries with a size
+	Dirs   []*DryRunDir `json:"dirs"`   // the directories sorted by name
+}
+
+// DryRunSummary returns the actions skipped because of --dry-run
+// grouped by action and directory, sorted by action name.
+func DryRunSummary() (actions []*DryRunAction) {
+	dryRun.mu.Lock()
+	defer dryRun.mu.Unlock()
+	actions = []*DryRunAction{}
+	for action, dirs := range dryRun.actions {
+		a := &DryRunAction{Action: action}
+		for dir, names := range dirs {
+			d := &DryRunDir{Dir: dir}
+			for name, size := range names {
+				d.Entries = append(d.Entries, name)
+				if size > 0 {
+					d.Bytes += size
+				}
+			}
+			sort.Strings(d.Entries)
+			d.Count = len(d.Entries)
+			a.Count += d.Count
+			a.Bytes += d.Bytes
+			a.Dirs = append(a.Dirs, d)
+		}
+		sort.Slice(a.Dirs, func(i, j int) bool { return a.Dirs[i].Dir < a.Dirs[j].Dir })
+		actions = append(actions, a)
+	}
+	sort.Slice(actions, func(i, j int) bool { return actions[i].Action < actions[j].Action })
+	return actions
+}
+
+// ResetDryRun clears the actions recorded for DryRunSummary
+func ResetDryRun() {
+	dryRun.mu.Lock()
+	dryRun.actions = map[string]map[string]map[string]int64{}
+	dryRun.mu.Unlock()
+}
+
+// WriteDryRunSummary writes the DryRunSummary to out in format which
+// should be "text" or "json".
+//
+// Nothing is written in text format if no actions were skipped.
+func WriteDryRunSummary(out io.Writer, format string) error {
+	actions := DryRunSummary()
+	switch format {
+	case "json":
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "\t")
+		return enc.Encode(map[string]interface{}{
+			"actions": actions,
+		})
+	case "text":
+		if len(actions) == 0 {
+			return nil
+		}
+		_, err := fmt.Fprintf(out, "Summary of actions skipped by --dry-run:\n")
+		if err != nil {
+			return err
+		}
+		for _, a := range actions {
+			_, err = fmt.Fprintf(out, "%s: %s\n", a.Action, dryRunCount(a.Count, a.Bytes))
+			if err != nil {
+				return err
+			}
+			for _, d := range a.Dirs {
+				dir := d.Dir
+				if dir == "" {
+					dir = "/"
+				}
+				_, err = fmt.Fprintf(out, "    %s: %s\n", dir, dryRunCount(d.Count, d.Bytes))
+				if err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	return errors.Errorf("unknown --dry-run-output format %q", format)
+}
+
+// dryRunCount describes count entries of total size bytes
+func dryRunCount(count int, bytes int64) string {
+	s := fmt.Sprintf("%d entries", count)
+	if count == 1 {
+		s = "1 entry"
+	}
+	if bytes > 0 {
+		s += fmt.Sprintf(" (%v)", fs.SizeSuffix(bytes).ByteUnit())
+	}
+	return s
+}
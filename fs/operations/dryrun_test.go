@@ -0,0 +1,60 @@
+package operations_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/pingme998/rclone/fs"
+	"github.com/pingme998/rclone/fs/operations"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDryRunSummary(t *testing.T) {
+	ctx := context.Background()
+	ctx, ci := fs.AddConfig(ctx)
+	ci.DryRun = true
+	operations.ResetDryRun()
+	defer operations.ResetDryRun()
+
+	assert.True(t, operations.SkipDestructive(ctx, "dir/a", "delete"))
+	assert.True(t, operations.SkipDestructive(ctx, "dir/b", "delete"))
+	assert.True(t, operations.SkipDestructive(ctx, "dir/b", "delete"))
+	assert.True(t, operations.SkipDestructive(ctx, "c", "delete"))
+	assert.True(t, operations.SkipDestructive(ctx, "dir/sub", "make directory"))
+
+	actions := operations.DryRunSummary()
+	require.Equal(t, 2, len(actions))
+	assert.Equal(t, "delete", actions[0].Action)
+	assert.Equal(t, 3, actions[0].Count)
+	require.Equal(t, 2, len(actions[0].Dirs))
+	assert.Equal(t, "", actions[0].Dirs[0].Dir)
+	assert.Equal(t, []string{"c"}, actions[0].Dirs[0].Entries)
+	assert.Equal(t, "dir", actions[0].Dirs[1].Dir)
+	assert.Equal(t, []string{"dir/a", "dir/b"}, actions[0].Dirs[1].Entries)
+	assert.Equal(t, "make directory", actions[1].Action)
+	assert.Equal(t, 1, actions[1].Count)
+
+	var buf bytes.Buffer
+	require.NoError(t, operations.WriteDryRunSummary(&buf, "text"))
+	assert.Equal(t, `Summary of actions skipped by --dry-run:
+delete: 3 entries
+    /: 1 entry
+    dir: 2 entries
+make directory: 1 entry
+    dir: 1 entry
+`, buf.String())
+
+	buf.Reset()
+	require.NoError(t, operations.WriteDryRunSummary(&buf, "json"))
+	assert.Contains(t, buf.String(), `"action": "make directory"`)
+
+	assert.Error(t, operations.WriteDryRunSummary(&buf, "potato"))
+
+	// Nothing is recorded without --dry-run
+	operations.ResetDryRun()
+	ci.DryRun = false
+	assert.False(t, operations.SkipDestructive(ctx, "dir/a", "delete"))
+	assert.Equal(t, 0, len(operations.DryRunSummary()))
+}
@@ -0,0 +1,212 @@
+package operations
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pingme998/rclone/fs"
+	"github.com/pingme998/rclone/fs/config"
+	"github.com/pingme998/rclone/lib/atexit"
+)
+
+// interactiveRule is a decision made by the user in --interactive
+// mode which applies to future operations
+type interactiveRule struct {
+	action  string // the action the rule applies to
+	dir     string // if inDir is set only match subjects in this directory
+	inDir   bool
+	maxSize int64 // if >= 0 only match subjects larger than this
+	skip    bool  // whether to skip the operations matched
+}
+
+// matches returns true if the rule applies to action on a subject in
+// dir of size
+func (r *interactiveRule) matches(action, dir string, size int64) bool {
+	if r.action != action {
+		return false
+	}
+	if r.inDir && r.dir != dir {
+		return false
+	}
+	if r.maxSize >= 0 && size <= r.maxSize {
+		return false
+	}
+	return true
+}
+
+// String describes the rule
+func (r *interactiveRule) String() string {
+	var s string
+	if r.skip {
+		s = "skip all " + r.action + " operations"
+	} else {
+		s = "do all " + r.action + " operations"
+	}
+	if r.inDir {
+		s += fmt.Sprintf(" in %q", r.dir)
+	}
+	if r.maxSize >= 0 {
+		s += fmt.Sprintf(" larger than %v", fs.SizeSuffix(r.maxSize))
+	}
+	return s
+}
+
+var (
+	interactiveMu    sync.Mutex
+	interactiveRules []*interactiveRule // the most recent rule takes precedence
+)
+
+// addInteractiveRule adds a rule and logs it
+//
+// Call with interactiveMu held
+func addInteractiveRule(rule *interactiveRule) {
+	interactiveRules = append(interactiveRules, rule)
+	fs.Logf(nil, "Will %s from now on without asking", rule)
+}
+
+// findInteractiveRule finds the rule for action on a subject in dir
+// of size or returns nil if there isn't one
+//
+// Call with interactiveMu held
+func findInteractiveRule(action, dir string, size int64) *interactiveRule {
+	for i := len(interactiveRules) - 1; i >= 0; i-- {
+		if rule := interactiveRules[i]; rule.matches(action, dir, size) {
+			return rule
+		}
+	}
+	return nil
+}
+
+// resetInteractiveRules removes all the rules - for testing
+func resetInteractiveRules() {
+	interactiveMu.Lock()
+	interactiveRules = nil
+	interactiveMu.Unlock()
+}
+
+// skipDestructiveChoose asks the user which action to take
+//
+// Call with interactiveMu held
+func skipDestructiveChoose(ctx context.Context, subject interface{}, action string, size int64) (skip bool) {
+	fmt.Printf("rclone: %s \"%v\"?\n", action, subject)
+	dir := subjectDir(subject)
+	commands := []string{
+		"yYes, this is OK",
+		"nNo, skip this",
+		fmt.Sprintf("dDo all %s operations in this directory with no more questions", action),
+	}
+	if size >= 0 {
+		commands = append(commands, fmt.Sprintf("lSkip all %s operations on files larger than a given size with no more questions", action))
+	}
+	commands = append(commands,
+		fmt.Sprintf("sSkip all %s operations with no more questions", action),
+		fmt.Sprintf("!Do all %s operations with no more questions", action),
+		"qExit rclone now.",
+	)
+	switch i := config.CommandDefault(commands, 0); i {
+	case 'y':
+		skip = false
+	case 'n':
+		skip = true
+	case 'd':
+		skip = false
+		addInteractiveRule(&interactiveRule{action: action, dir: dir, inDir: true, maxSize: -1})
+	case 'l':
+		for {
+			var maxSize fs.SizeSuffix
+			err := maxSize.Set(config.ReadNonEmptyLine("Size, e.g. 100M> "))
+			if err != nil {
+				fmt.Printf("Bad size: %v\n", err)
+				continue
+			}
+			rule := &interactiveRule{action: action, maxSize: int64(maxSize), skip: true}
+			addInteractiveRule(rule)
+			skip = rule.matches(action, dir, size)
+			break
+		}
+	case 's':
+		skip = true
+		addInteractiveRule(&interactiveRule{action: action, maxSize: -1, skip: true})
+	case '!':
+		skip = false
+		addInteractiveRule(&interactiveRule{action: action, maxSize: -1})
+	case 'q':
+		fs.Logf(nil, "Quitting rclone now")
+		atexit.Run()
+		os.Exit(0)
+	default:
+		skip = true
+		fs.Errorf(nil, "Bad choice %c", i)
+	}
+	return skip
+}
+
+// interactiveSkip decides whether to skip action on subject of size
+// in --interactive mode, asking the user unless a rule applies, and
+// records the decision in the --interactive-log.
+func interactiveSkip(ctx context.Context, subject interface{}, action string, size int64) (skip bool) {
+	interactiveMu.Lock()
+	defer interactiveMu.Unlock()
+	reason := "answered"
+	if rule := findInteractiveRule(action, subjectDir(subject), size); rule != nil {
+		skip = rule.skip
+		reason = "rule: " + rule.String()
+	} else {
+		skip = skipDestructiveChoose(ctx, subject, action, size)
+	}
+	logInteractiveDecision(ctx, subject, action, size, skip, reason)
+	return skip
+}
+
+// interactiveDecision is a line in the --interactive-log
+type interactiveDecision struct {
+	Time     time.Time `json:"time"`
+	Action   string    `json:"action"`
+	Subject  string    `json:"subject"`
+	Size     int64     `json:"size"`
+	Decision string    `json:"decision"` // "do" or "skip"
+	Reason   string    `json:"reason"`   // "answered" or the rule which applied
+}
+
+// logInteractiveDecision appends the decision to the --interactive-log
+// if set
+//
+// Call with interactiveMu held
+func logInteractiveDecision(ctx context.Context, subject interface{}, action string, size int64, skip bool, reason string) {
+	ci := fs.GetConfig(ctx)
+	if ci.InteractiveLog == "" {
+		return
+	}
+	decision := interactiveDecision{
+		Time:     time.Now(),
+		Action:   action,
+		Subject:  fmt.Sprint(subject),
+		Size:     size,
+		Decision: "do",
+		Reason:   reason,
+	}
+	if skip {
+		decision.Decision = "skip"
+	}
+	data, err := json.Marshal(decision)
+	if err != nil {
+		fs.Errorf(nil, "Failed to encode --interactive-log entry: %v", err)
+		return
+	}
+	f, err := os.OpenFile(ci.InteractiveLog, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0666)
+	if err != nil {
+		fs.Errorf(nil, "Failed to open --interactive-log: %v", err)
+		return
+	}
+	_, err = f.Write(append(data, '\n'))
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		fs.Errorf(nil, "Failed to write --interactive-log: %v", err)
+	}
+}
@@ -0,0 +1,114 @@
+package operations
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"strconv"
+
+	"github.com/pkg/errors"
+	"github.com/pingme998/rclone/fs"
+)
+
+// Lifecycle runs the generic lifecycle backend command on f
+//
+// arg[0] should be "get" or "set"
+func Lifecycle(ctx context.Context, f fs.Fs, arg []string, opt map[string]string) (out interface{}, err error) {
+	features := f.Features()
+	if features.GetLifecycle == nil || features.SetLifecycle == nil {
+		return nil, errors.Errorf("%v: doesn't support lifecycle rules", f)
+	}
+	if len(arg) == 0 {
+		return nil, errors.New("need \"get\" or \"set\" argument")
+	}
+	switch arg[0] {
+	case "get":
+		if len(arg) != 1 || len(opt) != 0 {
+			return nil, errors.New("\"get\" takes no arguments or options")
+		}
+		return features.GetLifecycle(ctx)
+	case "set":
+		rules, err := parseLifecycleRules(arg[1:], opt)
+		if err != nil {
+			return nil, err
+		}
+		if SkipDestructive(ctx, f, "set lifecycle rules") {
+			return nil, nil
+		}
+		err = features.SetLifecycle(ctx, rules)
+		if err != nil {
+			return nil, err
+		}
+		return features.GetLifecycle(ctx)
+	}
+	return nil, errors.Errorf("unknown lifecycle command %q - need \"get\" or \"set\"", arg[0])
+}
+
+// parseLifecycleRules reads the rules for lifecycle set from the
+// arguments and options
+func parseLifecycleRules(arg []string, opt map[string]string) (rules []fs.LifecycleRule, err error) {
+	var rulesJSON []byte
+	switch {
+	case len(arg) > 1:
+		return nil, errors.New("\"set\" takes at most one file name")
+	case len(arg) == 1:
+		if len(opt) != 0 {
+			return nil, errors.New("can't use options with a rules file")
+		}
+		rulesJSON, err = ioutil.ReadFile(arg[0])
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read rules")
+		}
+	case opt["rules"] != "":
+		if len(opt) != 1 {
+			return nil, errors.New("can't use other options with the rules option")
+		}
+		rulesJSON = []byte(opt["rules"])
+	}
+	if rulesJSON != nil {
+		err = json.Unmarshal(rulesJSON, &rules)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to parse rules")
+		}
+		if rules == nil {
+			rules = []fs.LifecycleRule{}
+		}
+	} else {
+		rule, err := parseLifecycleRule(opt)
+		if err != nil {
+			return nil, err
+		}
+		rules = []fs.LifecycleRule{rule}
+	}
+	for i, rule := range rules {
+		if rule.ExpireDays < 0 || rule.NoncurrentExpireDays < 0 {
+			return nil, errors.Errorf("rule %d: days can't be negative", i+1)
+		}
+		if rule.ExpireDays == 0 && rule.NoncurrentExpireDays == 0 {
+			return nil, errors.Errorf("rule %d: need expireDays or noncurrentExpireDays", i+1)
+		}
+	}
+	return rules, nil
+}
+
+// parseLifecycleRule makes a single rule from the options
+func parseLifecycleRule(opt map[string]string) (rule fs.LifecycleRule, err error) {
+	for k, v := range opt {
+		switch k {
+		case "id":
+			rule.ID = v
+		case "prefix":
+			rule.Prefix = v
+		case "expire-days":
+			rule.ExpireDays, err = strconv.Atoi(v)
+		case "noncurrent-expire-days":
+			rule.NoncurrentExpireDays, err = strconv.Atoi(v)
+		default:
+			return rule, errors.Errorf("unknown option %q", k)
+		}
+		if err != nil {
+			return rule, errors.Wrapf(err, "bad %q option", k)
+		}
+	}
+	return rule, nil
+}
@@ -0,0 +1,96 @@
+package operations_test
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/pingme998/rclone/fs"
+	"github.com/pingme998/rclone/fs/operations"
+	"github.com/pingme998/rclone/fstest/mockfs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// lifecycleFs is an Fs which stores lifecycle rules
+type lifecycleFs struct {
+	*mockfs.Fs
+	rules []fs.LifecycleRule
+}
+
+func (f *lifecycleFs) Features() *fs.Features {
+	return (&fs.Features{}).Fill(context.Background(), f)
+}
+
+func (f *lifecycleFs) GetLifecycle(ctx context.Context) ([]fs.LifecycleRule, error) {
+	return f.rules, nil
+}
+
+func (f *lifecycleFs) SetLifecycle(ctx context.Context, rules []fs.LifecycleRule) error {
+	f.rules = rules
+	return nil
+}
+
+func TestLifecycle(t *testing.T) {
+	ctx := context.Background()
+	f := &lifecycleFs{Fs: mockfs.NewFs(ctx, "bucket", "")}
+
+	// not supported
+	_, err := operations.Lifecycle(ctx, f.Fs, []string{"get"}, nil)
+	assert.Contains(t, err.Error(), "doesn't support lifecycle rules")
+
+	// bad commands
+	_, err = operations.Lifecycle(ctx, f, nil, nil)
+	assert.Error(t, err)
+	_, err = operations.Lifecycle(ctx, f, []string{"potato"}, nil)
+	assert.Error(t, err)
+	_, err = operations.Lifecycle(ctx, f, []string{"get"}, map[string]string{"prefix": "x"})
+	assert.Error(t, err)
+
+	// set a single rule from the options
+	out, err := operations.Lifecycle(ctx, f, []string{"set"}, map[string]string{
+		"id":                     "logs",
+		"prefix":                 "logs/",
+		"expire-days":            "30",
+		"noncurrent-expire-days": "7",
+	})
+	require.NoError(t, err)
+	want := []fs.LifecycleRule{{ID: "logs", Prefix: "logs/", ExpireDays: 30, NoncurrentExpireDays: 7}}
+	assert.Equal(t, want, out)
+
+	out, err = operations.Lifecycle(ctx, f, []string{"get"}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, want, out)
+
+	// set rules from a file
+	rulesFile := filepath.Join(t.TempDir(), "rules.json")
+	require.NoError(t, ioutil.WriteFile(rulesFile, []byte(`[{"prefix":"tmp/","expireDays":1},{"noncurrentExpireDays":3}]`), 0666))
+	out, err = operations.Lifecycle(ctx, f, []string{"set", rulesFile}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []fs.LifecycleRule{{Prefix: "tmp/", ExpireDays: 1}, {NoncurrentExpireDays: 3}}, out)
+
+	// clear the rules
+	out, err = operations.Lifecycle(ctx, f, []string{"set"}, map[string]string{"rules": "[]"})
+	require.NoError(t, err)
+	assert.Equal(t, []fs.LifecycleRule{}, out)
+
+	// bad rules leave the rules alone
+	f.rules = want
+	for _, opt := range []map[string]string{
+		{},
+		{"prefix": "logs/"},
+		{"expire-days": "potato"},
+		{"expire-days": "-1"},
+		{"carrot": "1"},
+		{"rules": "{"},
+		{"rules": "[{}]"},
+		{"rules": "[]", "expire-days": "1"},
+	} {
+		_, err = operations.Lifecycle(ctx, f, []string{"set"}, opt)
+		assert.Error(t, err, opt)
+	}
+	_, err = operations.Lifecycle(ctx, f, []string{"set", rulesFile, "another"}, nil)
+	assert.Error(t, err)
+	assert.Equal(t, want, f.rules)
+}
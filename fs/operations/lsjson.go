@@ -27,6 +27,8 @@ type ListJSONItem struct {
 	OrigID        string            `json:",omitempty"`
 	Tier          string            `json:",omitempty"`
 	IsBucket      bool              `json:",omitempty"`
+	Metadata      map[string]string `json:",omitempty"`
+	Tags          map[string]string `json:",omitempty"`
 }
 
 // Timestamp a time in the provided format
@@ -79,43 +81,147 @@ type ListJSONOpt struct {
 	DirsOnly      bool     `json:"dirsOnly"`
 	FilesOnly     bool     `json:"filesOnly"`
 	HashTypes     []string `json:"hashTypes"` // hash types to show if ShowHash is set, e.g. "MD5", "SHA-1"
+	ShowMetadata  bool     `json:"showMetadata"`
+	ShowTags      bool     `json:"showTags"`
 }
 
-// ListJSON lists fsrc using the options in opt calling callback for each item
-func ListJSON(ctx context.Context, fsrc fs.Fs, remote string, opt *ListJSONOpt, callback func(*ListJSONItem) error) error {
-	var cipher *crypt.Cipher
+// listJSON is used to make ListJSONItem from fs.DirEntry
+type listJSON struct {
+	opt        *ListJSONOpt
+	cipher     *crypt.Cipher
+	canGetTier bool
+	format     string
+	isBucket   bool
+	showHash   bool
+	hashTypes  []hash.Type
+}
+
+// newListJSON makes a listJSON for listing remote in fsrc using opt
+func newListJSON(ctx context.Context, fsrc fs.Fs, remote string, opt *ListJSONOpt) (*listJSON, error) {
+	lj := &listJSON{
+		opt: opt,
+	}
 	if opt.ShowEncrypted {
 		fsInfo, _, _, config, err := fs.ConfigFs(fsrc.Name() + ":" + fsrc.Root())
 		if err != nil {
-			return errors.Wrap(err, "ListJSON failed to load config for crypt remote")
+			return nil, errors.Wrap(err, "ListJSON failed to load config for crypt remote")
 		}
 		if fsInfo.Name != "crypt" {
-			return errors.New("The remote needs to be of type \"crypt\"")
+			return nil, errors.New("The remote needs to be of type \"crypt\"")
 		}
-		cipher, err = crypt.NewCipher(config)
+		lj.cipher, err = crypt.NewCipher(config)
 		if err != nil {
-			return errors.Wrap(err, "ListJSON failed to make new crypt remote")
+			return nil, errors.Wrap(err, "ListJSON failed to make new crypt remote")
 		}
 	}
 	features := fsrc.Features()
-	canGetTier := features.GetTier
-	format := formatForPrecision(fsrc.Precision())
-	isBucket := features.BucketBased && remote == "" && fsrc.Root() == "" // if bucket based remote listing the root mark directories as buckets
-	showHash := opt.ShowHash
-	hashTypes := fsrc.Hashes().Array()
+	lj.canGetTier = features.GetTier
+	lj.format = formatForPrecision(fsrc.Precision())
+	lj.isBucket = features.BucketBased && remote == "" && fsrc.Root() == "" // if bucket based remote listing the root mark directories as buckets
+	lj.showHash = opt.ShowHash
+	lj.hashTypes = fsrc.Hashes().Array()
 	if len(opt.HashTypes) != 0 {
-		showHash = true
-		hashTypes = []hash.Type{}
+		lj.showHash = true
+		lj.hashTypes = []hash.Type{}
 		for _, hashType := range opt.HashTypes {
 			var ht hash.Type
 			err := ht.Set(hashType)
 			if err != nil {
-				return err
+				return nil, err
+			}
+			lj.hashTypes = append(lj.hashTypes, ht)
+		}
+	}
+	return lj, nil
+}
+
+// entry converts entry into a ListJSONItem
+func (lj *listJSON) entry(ctx context.Context, entry fs.DirEntry) *ListJSONItem {
+	item := &ListJSONItem{
+		Path: entry.Remote(),
+		Name: path.Base(entry.Remote()),
+		Size: entry.Size(),
+	}
+	if !lj.opt.NoModTime {
+		item.ModTime = Timestamp{When: entry.ModTime(ctx), Format: lj.format}
+	}
+	if !lj.opt.NoMimeType {
+		item.MimeType = fs.MimeTypeDirEntry(ctx, entry)
+	}
+	if lj.cipher != nil {
+		switch entry.(type) {
+		case fs.Directory:
+			item.EncryptedPath = lj.cipher.EncryptDirName(entry.Remote())
+		case fs.Object:
+			item.EncryptedPath = lj.cipher.EncryptFileName(entry.Remote())
+		default:
+			fs.Errorf(nil, "Unknown type %T in listing", entry)
+		}
+		item.Encrypted = path.Base(item.EncryptedPath)
+	}
+	if do, ok := entry.(fs.IDer); ok {
+		item.ID = do.ID()
+	}
+	if o, ok := entry.(fs.Object); lj.opt.ShowOrigIDs && ok {
+		if do, ok := fs.UnWrapObject(o).(fs.IDer); ok {
+			item.OrigID = do.ID()
+		}
+	}
+	switch x := entry.(type) {
+	case fs.Directory:
+		item.IsDir = true
+		item.IsBucket = lj.isBucket
+	case fs.Object:
+		item.IsDir = false
+		if lj.showHash {
+			item.Hashes = make(map[string]string)
+			for _, hashType := range lj.hashTypes {
+				hash, err := x.Hash(ctx, hashType)
+				if err != nil {
+					fs.Errorf(x, "Failed to read hash: %v", err)
+				} else if hash != "" {
+					item.Hashes[hashType.String()] = hash
+				}
 			}
-			hashTypes = append(hashTypes, ht)
 		}
+		if lj.canGetTier {
+			if do, ok := x.(fs.GetTierer); ok {
+				item.Tier = do.GetTier()
+			}
+		}
+		if lj.opt.ShowMetadata {
+			if do, ok := x.(fs.Metadataer); ok {
+				metadata, err := do.Metadata(ctx)
+				if err != nil {
+					fs.Errorf(x, "Failed to read metadata: %v", err)
+				} else if len(metadata) != 0 {
+					item.Metadata = metadata
+				}
+			}
+		}
+		if lj.opt.ShowTags {
+			if do, ok := x.(fs.Tagger); ok {
+				tags, err := do.Tags(ctx)
+				if err != nil {
+					fs.Errorf(x, "Failed to read tags: %v", err)
+				} else if len(tags) != 0 {
+					item.Tags = tags
+				}
+			}
+		}
+	default:
+		fs.Errorf(nil, "Unknown type %T in listing in ListJSON", entry)
 	}
-	err := walk.ListR(ctx, fsrc, remote, false, ConfigMaxDepth(ctx, opt.Recurse), walk.ListAll, func(entries fs.DirEntries) (err error) {
+	return item
+}
+
+// ListJSON lists fsrc using the options in opt calling callback for each item
+func ListJSON(ctx context.Context, fsrc fs.Fs, remote string, opt *ListJSONOpt, callback func(*ListJSONItem) error) error {
+	lj, err := newListJSON(ctx, fsrc, remote, opt)
+	if err != nil {
+		return err
+	}
+	err = walk.ListR(ctx, fsrc, remote, false, ConfigMaxDepth(ctx, opt.Recurse), walk.ListAll, func(entries fs.DirEntries) (err error) {
 		for _, entry := range entries {
 			switch entry.(type) {
 			case fs.Directory:
@@ -129,63 +235,7 @@ func ListJSON(ctx context.Context, fsrc fs.Fs, remote string, opt *ListJSONOpt,
 			default:
 				fs.Errorf(nil, "Unknown type %T in listing", entry)
 			}
-
-			item := ListJSONItem{
-				Path: entry.Remote(),
-				Name: path.Base(entry.Remote()),
-				Size: entry.Size(),
-			}
-			if !opt.NoModTime {
-				item.ModTime = Timestamp{When: entry.ModTime(ctx), Format: format}
-			}
-			if !opt.NoMimeType {
-				item.MimeType = fs.MimeTypeDirEntry(ctx, entry)
-			}
-			if cipher != nil {
-				switch entry.(type) {
-				case fs.Directory:
-					item.EncryptedPath = cipher.EncryptDirName(entry.Remote())
-				case fs.Object:
-					item.EncryptedPath = cipher.EncryptFileName(entry.Remote())
-				default:
-					fs.Errorf(nil, "Unknown type %T in listing", entry)
-				}
-				item.Encrypted = path.Base(item.EncryptedPath)
-			}
-			if do, ok := entry.(fs.IDer); ok {
-				item.ID = do.ID()
-			}
-			if o, ok := entry.(fs.Object); opt.ShowOrigIDs && ok {
-				if do, ok := fs.UnWrapObject(o).(fs.IDer); ok {
-					item.OrigID = do.ID()
-				}
-			}
-			switch x := entry.(type) {
-			case fs.Directory:
-				item.IsDir = true
-				item.IsBucket = isBucket
-			case fs.Object:
-				item.IsDir = false
-				if showHash {
-					item.Hashes = make(map[string]string)
-					for _, hashType := range hashTypes {
-						hash, err := x.Hash(ctx, hashType)
-						if err != nil {
-							fs.Errorf(x, "Failed to read hash: %v", err)
-						} else if hash != "" {
-							item.Hashes[hashType.String()] = hash
-						}
-					}
-				}
-				if canGetTier {
-					if do, ok := x.(fs.GetTierer); ok {
-						item.Tier = do.GetTier()
-					}
-				}
-			default:
-				fs.Errorf(nil, "Unknown type %T in listing in ListJSON", entry)
-			}
-			err = callback(&item)
+			err = callback(lj.entry(ctx, entry))
 			if err != nil {
 				return errors.Wrap(err, "callback failed in ListJSON")
 			}
@@ -198,3 +248,50 @@ func ListJSON(ctx context.Context, fsrc fs.Fs, remote string, opt *ListJSONOpt,
 	}
 	return nil
 }
+
+// StatJSON returns a ListJSONItem for the single object or directory
+// at remote in fsrc using the options in opt.
+//
+// This is cheaper than ListJSON on the parent directory if remote is
+// an object as it only needs to look that object up. If remote is ""
+// then the root of fsrc is returned which only has Path, Name, IsDir
+// and IsBucket set.
+//
+// It returns fs.ErrorObjectNotFound if remote can't be found.
+func StatJSON(ctx context.Context, fsrc fs.Fs, remote string, opt *ListJSONOpt) (*ListJSONItem, error) {
+	parent := path.Dir(remote)
+	if parent == "." || parent == "/" {
+		parent = ""
+	}
+	lj, err := newListJSON(ctx, fsrc, parent, opt)
+	if err != nil {
+		return nil, err
+	}
+	if remote == "" {
+		return &ListJSONItem{
+			Size:     -1,
+			IsDir:    true,
+			IsBucket: fsrc.Features().BucketBased && fsrc.Root() == "",
+		}, nil
+	}
+	o, err := fsrc.NewObject(ctx, remote)
+	if err == nil {
+		return lj.entry(ctx, o), nil
+	}
+	if cause := errors.Cause(err); cause != fs.ErrorObjectNotFound && cause != fs.ErrorNotAFile {
+		return nil, errors.Wrap(err, "error in StatJSON")
+	}
+	// Not an object so look for a directory in the parent
+	entries, err := fsrc.List(ctx, parent)
+	if err == fs.ErrorDirNotFound {
+		return nil, fs.ErrorObjectNotFound
+	} else if err != nil {
+		return nil, errors.Wrap(err, "error in StatJSON")
+	}
+	for _, entry := range entries {
+		if dir, ok := entry.(fs.Directory); ok && dir.Remote() == remote {
+			return lj.entry(ctx, dir), nil
+		}
+	}
+	return nil, fs.ErrorObjectNotFound
+}
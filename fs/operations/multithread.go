@@ -52,6 +52,7 @@ type multiThreadCopyState struct {
 	wc       fs.WriterAtCloser
 	src      fs.Object
 	acc      *accounting.Account
+	tr       *accounting.Transfer
 	streams  int
 }
 
@@ -118,6 +119,7 @@ func (mc *multiThreadCopyState) copyStream(ctx context.Context, stream int) (err
 	}
 
 	fs.Debugf(mc.src, "multi-thread copy: stream %d/%d (%d-%d) size %v finished", stream+1, mc.streams, start, end, fs.SizeSuffix(end-start))
+	mc.tr.ChunkDone()
 	return nil
 }
 
@@ -156,12 +158,14 @@ func multiThreadCopy(ctx context.Context, f fs.Fs, remote string, src fs.Object,
 		ctx:     gCtx,
 		size:    src.Size(),
 		src:     src,
+		tr:      tr,
 		streams: streams,
 	}
 	mc.calculateChunks()
 
 	// Make accounting
 	mc.acc = tr.Account(ctx, nil)
+	tr.SetChunks(mc.streams)
 
 	// create write file handle
 	mc.wc, err = openWriterAt(gCtx, remote, mc.size)
@@ -11,7 +11,6 @@ import (
 	"io"
 	"io/ioutil"
 	"net/http"
-	"os"
 	"path"
 	"path/filepath"
 	"sort"
@@ -25,14 +24,12 @@ import (
 	"github.com/pingme998/rclone/fs"
 	"github.com/pingme998/rclone/fs/accounting"
 	"github.com/pingme998/rclone/fs/cache"
-	"github.com/pingme998/rclone/fs/config"
 	"github.com/pingme998/rclone/fs/filter"
 	"github.com/pingme998/rclone/fs/fserrors"
 	"github.com/pingme998/rclone/fs/fshttp"
 	"github.com/pingme998/rclone/fs/hash"
 	"github.com/pingme998/rclone/fs/object"
 	"github.com/pingme998/rclone/fs/walk"
-	"github.com/pingme998/rclone/lib/atexit"
 	"github.com/pingme998/rclone/lib/pacer"
 	"github.com/pingme998/rclone/lib/random"
 	"github.com/pingme998/rclone/lib/readers"
@@ -121,7 +118,7 @@ func checkHashes(ctx context.Context, src fs.ObjectInfo, dst fs.Object, ht hash.
 // Otherwise the file is considered to be not equal including if there
 // were errors reading info.
 func Equal(ctx context.Context, src fs.ObjectInfo, dst fs.Object) bool {
-	return equal(ctx, src, dst, defaultEqualOpt(ctx))
+	return equal(ctx, src, dst, defaultEqualOpt(ctx, src.Fs(), dst.Fs()))
 }
 
 // sizeDiffers compare the size of src and dst taking into account the
@@ -144,15 +141,30 @@ type equalOpt struct {
 	forceModTimeMatch bool // if set assume modtimes match
 }
 
-// default set of options for equal()
-func defaultEqualOpt(ctx context.Context) equalOpt {
+// default set of options for equal() comparing files on fss
+//
+// If neither --size-only nor --checksum is set then the
+// compare_checksum and compare_size_only config of the remotes are
+// used, preferring checksums.
+func defaultEqualOpt(ctx context.Context, fss ...fs.Info) equalOpt {
 	ci := fs.GetConfig(ctx)
-	return equalOpt{
+	opt := equalOpt{
 		sizeOnly:          ci.SizeOnly,
 		checkSum:          ci.CheckSum,
 		updateModTime:     !ci.NoUpdateModTime,
 		forceModTimeMatch: false,
 	}
+	if !opt.sizeOnly && !opt.checkSum {
+		for _, f := range fss {
+			compareOpt := fs.GetCompareOptions(f)
+			opt.sizeOnly = opt.sizeOnly || compareOpt.SizeOnly
+			opt.checkSum = opt.checkSum || compareOpt.CheckSum
+		}
+		if opt.checkSum {
+			opt.sizeOnly = false
+		}
+	}
+	return opt
 }
 
 var modTimeUploadOnce sync.Once
@@ -282,6 +294,66 @@ func removeFailedCopy(ctx context.Context, dst fs.Object) bool {
 	return true
 }
 
+// removePartial removes the partial file left at remote on f by a
+// failed upload, if any.
+//
+// This is done even if ctx has been cancelled, e.g. by a hard
+// --max-duration, so interrupted uploads don't leave partial files.
+func removePartial(ctx context.Context, f fs.Fs, remote string) {
+	if ctx.Err() != nil {
+		ctx = fs.CopyConfig(context.Background(), ctx)
+	}
+	partial, err := f.NewObject(ctx, remote)
+	if err != nil {
+		return
+	}
+	removeFailedCopy(ctx, partial)
+}
+
+// usePartial returns true if uploads to f should be made to a partial
+// file which is renamed when the upload completes
+//
+// Backends which allow duplicate files are never used as renaming
+// the partial file would make a duplicate rather than replacing the
+// existing file, and replacing it by removing it first loses its
+// identity, e.g. the ID, revisions and sharing of a drive file.
+func usePartial(ctx context.Context, f fs.Fs) bool {
+	ci := fs.GetConfig(ctx)
+	features := f.Features()
+	return !ci.Inplace && ci.PartialSuffix != "" && features.Move != nil && !features.DuplicateFiles
+}
+
+// finalizePartial renames the partial file uploaded to f into place
+// at remote, replacing the existing object if there is one.
+//
+// The partial file is moved straight over the existing object so it
+// is never missing. Backends which can't overwrite with Move have the
+// existing object removed first.
+//
+// The partial file is left if the rename fails so the upload isn't
+// lost.
+func finalizePartial(ctx context.Context, f fs.Fs, existing, partial fs.Object, remote string) (fs.Object, error) {
+	doMove := f.Features().Move
+	if existing != nil {
+		// keep the name of the existing object as Update would
+		remote = existing.Remote()
+		newDst, err := doMove(ctx, partial, remote)
+		if err == nil {
+			return newDst, nil
+		}
+		fs.Debugf(existing, "Couldn't rename partial file over existing file so removing it first: %v", err)
+		err = existing.Remove(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to remove existing file before renaming partial file")
+		}
+	}
+	newDst, err := doMove(ctx, partial, remote)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to rename partial file %q", partial.Remote())
+	}
+	return newDst, nil
+}
+
 // OverrideRemote is a wrapper to override the Remote for an
 // ObjectInfo
 type OverrideRemote struct {
@@ -337,6 +409,22 @@ func (o *OverrideRemote) GetTier() string {
 	return ""
 }
 
+// Metadata returns the backend specific metadata of the Object if known
+func (o *OverrideRemote) Metadata(ctx context.Context) (map[string]string, error) {
+	if do, ok := o.ObjectInfo.(fs.Metadataer); ok {
+		return do.Metadata(ctx)
+	}
+	return nil, nil
+}
+
+// Tags returns the user defined tags of the Object if known
+func (o *OverrideRemote) Tags(ctx context.Context) (map[string]string, error) {
+	if do, ok := o.ObjectInfo.(fs.Tagger); ok {
+		return do.Tags(ctx)
+	}
+	return nil, nil
+}
+
 // Check all optional interfaces satisfied
 var _ fs.FullObjectInfo = (*OverrideRemote)(nil)
 
@@ -363,8 +451,10 @@ func CommonHash(ctx context.Context, fa, fb fs.Info) (hash.Type, *fs.HashesOptio
 // It returns the destination object if possible.  Note that this may
 // be nil.
 func Copy(ctx context.Context, f fs.Fs, dst fs.Object, remote string, src fs.Object) (newDst fs.Object, err error) {
+	ctx = fs.StartLogSpan(ctx)
 	ci := fs.GetConfig(ctx)
 	tr := accounting.Stats(ctx).NewTransfer(src)
+	tr.AddRemote(f)
 	defer func() {
 		tr.Done(ctx, err)
 	}()
@@ -377,8 +467,17 @@ func Copy(ctx context.Context, f fs.Fs, dst fs.Object, remote string, src fs.Obj
 	maxTries := ci.LowLevelRetries
 	tries := 0
 	doUpdate := dst != nil
+	existing := dst
 	hashType, hashOption := CommonHash(ctx, f, src.Fs())
 
+	// Upload to a partial file and rename it when complete so
+	// readers never see a half written file
+	uploadRemote := remote
+	if usePartial(ctx, f) {
+		uploadRemote = remote + ci.PartialSuffix
+	}
+	uploadedPartial := false
+
 	var actionTaken string
 	for {
 		// Try server-side copy first - if has optional interface and
@@ -417,6 +516,7 @@ func Copy(ctx context.Context, f fs.Fs, dst fs.Object, remote string, src fs.Obj
 		}
 		// If can't server-side copy, do it manually
 		if err == fs.ErrorCantCopy {
+			uploadedPartial = uploadRemote != remote
 			if doMultiThreadCopy(ctx, f, src) {
 				// Number of streams proportional to size
 				streams := src.Size() / int64(ci.MultiThreadCutoff)
@@ -427,7 +527,7 @@ func Copy(ctx context.Context, f fs.Fs, dst fs.Object, remote string, src fs.Obj
 				if streams < 2 {
 					streams = 2
 				}
-				dst, err = multiThreadCopy(ctx, f, remote, src, int(streams), tr)
+				dst, err = multiThreadCopy(ctx, f, uploadRemote, src, int(streams), tr)
 				if doUpdate {
 					actionTaken = "Multi-thread Copied (replaced existing)"
 				} else {
@@ -451,14 +551,18 @@ func Copy(ctx context.Context, f fs.Fs, dst fs.Object, remote string, src fs.Obj
 							actionTaken = "Copied (Rcat, new)"
 						}
 						// NB Rcat closes in0
-						dst, err = Rcat(ctx, f, remote, in0, src.ModTime(ctx))
+						dst, err = Rcat(ctx, f, uploadRemote, in0, src.ModTime(ctx))
 						newDst = dst
 					} else {
-						in := tr.Account(ctx, in0).WithBuffer() // account and buffer the transfer
+						// cancel the upload if it is restarted by --min-transfer-rate
+						// or --max-transfer-time
+						putCtx, cancel := context.WithCancel(ctx)
+						in := tr.Account(putCtx, in0).WithBuffer() // account and buffer the transfer
+						in.OnStall(cancel)
 						var wrappedSrc fs.ObjectInfo = src
 						// We try to pass the original object if possible
-						if src.Remote() != remote {
-							wrappedSrc = NewOverrideRemote(src, remote)
+						if src.Remote() != uploadRemote {
+							wrappedSrc = NewOverrideRemote(src, uploadRemote)
 						}
 						options := []fs.OpenOption{hashOption}
 						for _, option := range ci.UploadHeaders {
@@ -466,13 +570,19 @@ func Copy(ctx context.Context, f fs.Fs, dst fs.Object, remote string, src fs.Obj
 						}
 						if doUpdate {
 							actionTaken = "Copied (replaced existing)"
-							err = dst.Update(ctx, in, wrappedSrc, options...)
 						} else {
 							actionTaken = "Copied (new)"
-							dst, err = f.Put(ctx, in, wrappedSrc, options...)
+						}
+						if doUpdate && !uploadedPartial {
+							err = dst.Update(putCtx, in, wrappedSrc, options...)
+						} else {
+							dst, err = f.Put(putCtx, in, wrappedSrc, options...)
 						}
 						closeErr := in.Close()
-						if err == nil {
+						cancel()
+						if stallErr := in.StallError(); stallErr != nil {
+							err = stallErr
+						} else if err == nil {
 							newDst = dst
 							err = closeErr
 						}
@@ -480,6 +590,18 @@ func Copy(ctx context.Context, f fs.Fs, dst fs.Object, remote string, src fs.Obj
 				}
 			}
 		}
+		// Read back the upload if --verify-uploads is set
+		if err == nil {
+			verifyRemote := remote
+			if uploadedPartial {
+				verifyRemote = uploadRemote
+			}
+			err = verifyCopy(ctx, f, src, dst, verifyRemote)
+			if err != nil && !uploadedPartial {
+				// the failed upload was removed so upload it afresh
+				doUpdate = false
+			}
+		}
 		tries++
 		if tries >= maxTries {
 			break
@@ -492,12 +614,13 @@ func Copy(ctx context.Context, f fs.Fs, dst fs.Object, remote string, src fs.Obj
 		if fserrors.IsRetryError(err) || fserrors.ShouldRetry(err) {
 			retry = true
 		} else if t, ok := pacer.IsRetryAfter(err); ok {
-			fs.Debugf(src, "Sleeping for %v (as indicated by the server) to obey Retry-After error: %v", t, err)
+			fs.DebugfCtx(ctx, src, "Sleeping for %v (as indicated by the server) to obey Retry-After error: %v", t, err)
 			time.Sleep(t)
 			retry = true
 		}
 		if retry {
-			fs.Debugf(src, "Received error: %v - low level retry %d/%d", err, tries, maxTries)
+			fs.DebugfCtx(ctx, src, "Received error: %v - low level retry %d/%d", err, tries, maxTries)
+			tr.Retry()
 			tr.Reset(ctx) // skip incomplete accounting - will be overwritten by retry
 			continue
 		}
@@ -506,14 +629,17 @@ func Copy(ctx context.Context, f fs.Fs, dst fs.Object, remote string, src fs.Obj
 	}
 	if err != nil {
 		err = fs.CountError(err)
-		fs.Errorf(src, "Failed to copy: %v", err)
+		fs.ErrorfCtx(ctx, src, "Failed to copy: %v", err)
+		if uploadedPartial {
+			removePartial(ctx, f, uploadRemote)
+		}
 		return newDst, err
 	}
 
 	// Verify sizes are the same after transfer
 	if sizeDiffers(ctx, src, dst) {
 		err = errors.Errorf("corrupted on transfer: sizes differ %d vs %d", src.Size(), dst.Size())
-		fs.Errorf(dst, "%v", err)
+		fs.ErrorfCtx(ctx, dst, "%v", err)
 		err = fs.CountError(err)
 		removeFailedCopy(ctx, dst)
 		return newDst, err
@@ -525,16 +651,33 @@ func Copy(ctx context.Context, f fs.Fs, dst fs.Object, remote string, src fs.Obj
 		equal, _, srcSum, dstSum, _ := checkHashes(ctx, src, dst, hashType)
 		if !equal {
 			err = errors.Errorf("corrupted on transfer: %v hash differ %q vs %q", hashType, srcSum, dstSum)
-			fs.Errorf(dst, "%v", err)
+			fs.ErrorfCtx(ctx, dst, "%v", err)
 			err = fs.CountError(err)
 			removeFailedCopy(ctx, dst)
 			return newDst, err
 		}
+		if dstSum != "" {
+			tr.SetHash(hashType, dstSum)
+		} else {
+			tr.SetHash(hashType, srcSum)
+		}
 	}
+
+	// Rename the partial file into place
+	if uploadedPartial {
+		dst, err = finalizePartial(ctx, f, existing, dst, remote)
+		if err != nil {
+			err = fs.CountError(err)
+			fs.ErrorfCtx(ctx, src, "Failed to copy: %v", err)
+			return newDst, err
+		}
+		newDst = dst
+	}
+
 	if newDst != nil && src.String() != newDst.String() {
-		fs.Infof(src, "%s to: %s", actionTaken, newDst.String())
+		fs.InfofCtx(ctx, src, "%s to: %s", actionTaken, newDst.String())
 	} else {
-		fs.Infof(src, actionTaken)
+		fs.InfofCtx(ctx, src, actionTaken)
 	}
 	return newDst, err
 }
@@ -575,7 +718,9 @@ func SameObject(src, dst fs.Object) bool {
 // It returns the destination object if possible.  Note that this may
 // be nil.
 func Move(ctx context.Context, fdst fs.Fs, dst fs.Object, remote string, src fs.Object) (newDst fs.Object, err error) {
+	ctx = fs.StartLogSpan(ctx)
 	tr := accounting.Stats(ctx).NewCheckingTransfer(src)
+	tr.SetAction("move")
 	defer func() {
 		if err == nil {
 			accounting.Stats(ctx).Renames(1)
@@ -602,24 +747,24 @@ func Move(ctx context.Context, fdst fs.Fs, dst fs.Object, remote string, src fs.
 		switch err {
 		case nil:
 			if newDst != nil && src.String() != newDst.String() {
-				fs.Infof(src, "Moved (server-side) to: %s", newDst.String())
+				fs.InfofCtx(ctx, src, "Moved (server-side) to: %s", newDst.String())
 			} else {
-				fs.Infof(src, "Moved (server-side)")
+				fs.InfofCtx(ctx, src, "Moved (server-side)")
 			}
 
 			return newDst, nil
 		case fs.ErrorCantMove:
-			fs.Debugf(src, "Can't move, switching to copy")
+			fs.DebugfCtx(ctx, src, "Can't move, switching to copy")
 		default:
 			err = fs.CountError(err)
-			fs.Errorf(src, "Couldn't move: %v", err)
+			fs.ErrorfCtx(ctx, src, "Couldn't move: %v", err)
 			return newDst, err
 		}
 	}
 	// Move not found or didn't work so copy dst <- src
 	newDst, err = Copy(ctx, fdst, dst, remote, src)
 	if err != nil {
-		fs.Errorf(src, "Not deleting source as copy failed: %v", err)
+		fs.ErrorfCtx(ctx, src, "Not deleting source as copy failed: %v", err)
 		return newDst, err
 	}
 	// Delete src if no error on copy
@@ -658,6 +803,7 @@ func SuffixName(ctx context.Context, remote string) string {
 // If backupDir is set then it moves the file to there instead of
 // deleting
 func DeleteFileWithBackupDir(ctx context.Context, dst fs.Object, backupDir fs.Fs) (err error) {
+	ctx = fs.StartLogSpan(ctx)
 	ci := fs.GetConfig(ctx)
 	tr := accounting.Stats(ctx).NewCheckingTransfer(dst)
 	defer func() {
@@ -670,6 +816,9 @@ func DeleteFileWithBackupDir(ctx context.Context, dst fs.Object, backupDir fs.Fs
 	action, actioned := "delete", "Deleted"
 	if backupDir != nil {
 		action, actioned = "move into backup dir", "Moved into backup dir"
+		tr.SetAction("backup")
+	} else {
+		tr.SetAction("delete")
 	}
 	skip := SkipDestructive(ctx, dst, action)
 	if skip {
@@ -680,10 +829,10 @@ func DeleteFileWithBackupDir(ctx context.Context, dst fs.Object, backupDir fs.Fs
 		err = dst.Remove(ctx)
 	}
 	if err != nil {
-		fs.Errorf(dst, "Couldn't %s: %v", action, err)
+		fs.ErrorfCtx(ctx, dst, "Couldn't %s: %v", action, err)
 		err = fs.CountError(err)
 	} else if !skip {
-		fs.Infof(dst, actioned)
+		fs.InfofCtx(ctx, dst, actioned)
 	}
 	return err
 }
@@ -1307,6 +1456,27 @@ func PublicLink(ctx context.Context, f fs.Fs, remote string, expire fs.Duration,
 	return doPublicLink(ctx, remote, expire, unlink)
 }
 
+// ListLinks returns the public links to dir and everything under it
+func ListLinks(ctx context.Context, f fs.Fs, dir string) ([]fs.Link, error) {
+	doListLinks := f.Features().ListLinks
+	if doListLinks == nil {
+		return nil, errors.Errorf("%v doesn't support listing public links", f)
+	}
+	return doListLinks(ctx, dir)
+}
+
+// RevokeLink removes the public link with the URL given
+func RevokeLink(ctx context.Context, f fs.Fs, url string) error {
+	doRevokeLink := f.Features().RevokeLink
+	if doRevokeLink == nil {
+		return errors.Errorf("%v doesn't support revoking public links", f)
+	}
+	if SkipDestructive(ctx, url, "revoke link") {
+		return nil
+	}
+	return doRevokeLink(ctx, url)
+}
+
 // Rmdirs removes any empty directories (or directories only
 // containing empty directories) under f, including f.
 //
@@ -1454,7 +1624,7 @@ func copyDest(ctx context.Context, fdst fs.Fs, dst, src fs.Object, CopyDest, bac
 	default:
 		return false, err
 	}
-	opt := defaultEqualOpt(ctx)
+	opt := defaultEqualOpt(ctx, src.Fs(), CopyDestFile.Fs())
 	opt.updateModTime = false
 	if equal(ctx, src, CopyDestFile, opt) {
 		if dst == nil || !Equal(ctx, src, dst) {
@@ -1545,7 +1715,7 @@ func NeedTransfer(ctx context.Context, dst, src fs.Object) bool {
 			return false
 		case dt <= -modifyWindow:
 			// force --checksum on for the check and do update modtimes by default
-			opt := defaultEqualOpt(ctx)
+			opt := defaultEqualOpt(ctx, src.Fs(), dst.Fs())
 			opt.forceModTimeMatch = true
 			if equal(ctx, src, dst, opt) {
 				fs.Debugf(src, "Unchanged skipping")
@@ -1553,8 +1723,8 @@ func NeedTransfer(ctx context.Context, dst, src fs.Object) bool {
 			}
 		default:
 			// Do a size only compare unless --checksum is set
-			opt := defaultEqualOpt(ctx)
-			opt.sizeOnly = !ci.CheckSum
+			opt := defaultEqualOpt(ctx, src.Fs(), dst.Fs())
+			opt.sizeOnly = !opt.checkSum
 			if equal(ctx, src, dst, opt) {
 				fs.Debugf(src, "Destination mod time is within %v of source and files identical, skipping", modifyWindow)
 				return false
@@ -1705,7 +1875,19 @@ func BackupDir(ctx context.Context, fdst fs.Fs, fsrc fs.Fs, srcFileName string)
 }
 
 // MoveBackupDir moves a file to the backup dir
+//
+// If --suffix is numbered then the file is moved to the next version
+// and old versions are pruned according to --suffix-keep.
 func MoveBackupDir(ctx context.Context, backupDir fs.Fs, dst fs.Object) (err error) {
+	if SuffixNumbered(ctx) {
+		ctx = WithSuffixVersionsCache(ctx)
+		remote := dst.Remote()
+		err = moveBackupVersion(ctx, backupDir, dst)
+		if err != nil {
+			return err
+		}
+		return pruneSuffixVersions(ctx, backupDir, remote)
+	}
 	remoteWithSuffix := SuffixName(ctx, dst.Remote())
 	overwritten, _ := backupDir.NewObject(ctx, remoteWithSuffix)
 	_, err = Move(ctx, backupDir, overwritten, remoteWithSuffix, dst)
@@ -1964,6 +2146,29 @@ func (l *ListFormat) AddMimeType() {
 	})
 }
 
+// AddMetadata adds the values of the backend specific metadata keys
+// to the output, or all the metadata as key=value separated by ","
+// sorted by key if no keys are given
+func (l *ListFormat) AddMetadata(keys []string) {
+	if len(keys) == 0 {
+		l.AppendOutput(func(entry *ListJSONItem) string {
+			out := make([]string, 0, len(entry.Metadata))
+			for key, value := range entry.Metadata {
+				out = append(out, key+"="+value)
+			}
+			sort.Strings(out)
+			return strings.Join(out, ",")
+		})
+		return
+	}
+	for _, key := range keys {
+		key := key
+		l.AppendOutput(func(entry *ListJSONItem) string {
+			return entry.Metadata[key]
+		})
+	}
+}
+
 // AppendOutput adds string generated by specific function to printed output
 func (l *ListFormat) AppendOutput(functionToAppend func(item *ListJSONItem) string) {
 	l.output = append(l.output, functionToAppend)
@@ -2112,44 +2317,28 @@ func GetFsInfo(f fs.Fs) *FsInfo {
 	return info
 }
 
-var (
-	interactiveMu sync.Mutex
-	skipped       = map[string]bool{}
-)
+// subjectRemote returns the path of the subject passed to
+// SkipDestructive or "" if it is the root
+func subjectRemote(subject interface{}) string {
+	switch x := subject.(type) {
+	case fs.DirEntry:
+		return x.Remote()
+	case fs.Info:
+		return ""
+	case string:
+		return x
+	}
+	return fmt.Sprint(subject)
+}
 
-// skipDestructiveChoose asks the user which action to take
-//
-// Call with interactiveMu held
-func skipDestructiveChoose(ctx context.Context, subject interface{}, action string) (skip bool) {
-	fmt.Printf("rclone: %s \"%v\"?\n", action, subject)
-	switch i := config.CommandDefault([]string{
-		"yYes, this is OK",
-		"nNo, skip this",
-		fmt.Sprintf("sSkip all %s operations with no more questions", action),
-		fmt.Sprintf("!Do all %s operations with no more questions", action),
-		"qExit rclone now.",
-	}, 0); i {
-	case 'y':
-		skip = false
-	case 'n':
-		skip = true
-	case 's':
-		skip = true
-		skipped[action] = true
-		fs.Logf(nil, "Skipping all %s operations from now on without asking", action)
-	case '!':
-		skip = false
-		skipped[action] = false
-		fs.Logf(nil, "Doing all %s operations from now on without asking", action)
-	case 'q':
-		fs.Logf(nil, "Quitting rclone now")
-		atexit.Run()
-		os.Exit(0)
-	default:
-		skip = true
-		fs.Errorf(nil, "Bad choice %c", i)
+// subjectDir returns the directory the subject passed to
+// SkipDestructive is in or "" if it is in the root
+func subjectDir(subject interface{}) string {
+	dir := path.Dir(subjectRemote(subject))
+	if dir == "." || dir == "/" {
+		dir = ""
 	}
-	return skip
+	return dir
 }
 
 // SkipDestructive should be called whenever rclone is about to do an destructive operation.
@@ -2165,32 +2354,29 @@ func skipDestructiveChoose(ctx context.Context, subject interface{}, action stri
 func SkipDestructive(ctx context.Context, subject interface{}, action string) (skip bool) {
 	var flag string
 	ci := fs.GetConfig(ctx)
+	size := int64(-1)
+	if do, ok := subject.(interface{ Size() int64 }); ok {
+		size = do.Size()
+	}
 	switch {
 	case ci.DryRun:
 		flag = "--dry-run"
 		skip = true
 	case ci.Interactive:
 		flag = "--interactive"
-		interactiveMu.Lock()
-		defer interactiveMu.Unlock()
-		var found bool
-		skip, found = skipped[action]
-		if !found {
-			skip = skipDestructiveChoose(ctx, subject, action)
-		}
+		skip = interactiveSkip(ctx, subject, action, size)
 	default:
 		return false
 	}
 	if skip {
-		size := int64(-1)
-		if do, ok := subject.(interface{ Size() int64 }); ok {
-			size = do.Size()
-		}
 		if size >= 0 {
 			fs.Logf(subject, "Skipped %s as %s is set (size %v)", fs.LogValue("skipped", action), flag, fs.LogValue("size", fs.SizeSuffix(size)))
 		} else {
 			fs.Logf(subject, "Skipped %s as %s is set", fs.LogValue("skipped", action), flag)
 		}
+		if ci.DryRun {
+			dryRunRecord(subject, action, size)
+		}
 	}
 	return skip
 }
@@ -5,12 +5,19 @@ package operations
 import (
 	"context"
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/pingme998/rclone/fs"
+	"github.com/pingme998/rclone/fs/config"
 	"github.com/pingme998/rclone/fs/object"
+	"github.com/pingme998/rclone/fstest/mockfs"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestSizeDiffers(t *testing.T) {
@@ -41,3 +48,113 @@ func TestSizeDiffers(t *testing.T) {
 		assert.Equal(t, test.want, got, fmt.Sprintf("ignoreSize=%v, srcSize=%v, dstSize=%v", test.ignoreSize, test.srcSize, test.dstSize))
 	}
 }
+
+func TestDefaultEqualOptRemoteConfig(t *testing.T) {
+	ctx := context.Background()
+	oldConfigFileGet := fs.ConfigFileGet
+	fs.ConfigFileGet = func(section, key string) (string, bool) {
+		switch {
+		case section == "sizeonly" && key == fs.ConfigCompareSizeOnly:
+			return "true", true
+		case section == "checksum" && key == fs.ConfigCompareChecksum:
+			return "true", true
+		}
+		return "", false
+	}
+	defer func() {
+		fs.ConfigFileGet = oldConfigFileGet
+	}()
+	normal := mockfs.NewFs(ctx, "normal", "")
+	sizeOnly := mockfs.NewFs(ctx, "sizeonly", "")
+	checkSum := mockfs.NewFs(ctx, "checksum", "")
+
+	for _, test := range []struct {
+		name         string
+		flagSizeOnly bool
+		flagCheckSum bool
+		fss          []fs.Info
+		wantSizeOnly bool
+		wantCheckSum bool
+	}{
+		{"none", false, false, []fs.Info{normal, normal}, false, false},
+		{"remote size only", false, false, []fs.Info{normal, sizeOnly}, true, false},
+		{"remote checksum", false, false, []fs.Info{checkSum, normal}, false, true},
+		{"remote checksum preferred", false, false, []fs.Info{sizeOnly, checkSum}, false, true},
+		{"flag size only wins", true, false, []fs.Info{checkSum, normal}, true, false},
+		{"flag checksum wins", false, true, []fs.Info{sizeOnly, normal}, false, true},
+		{"nil fs", false, false, []fs.Info{nil, sizeOnly}, true, false},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			ctx, ci := fs.AddConfig(ctx)
+			ci.SizeOnly = test.flagSizeOnly
+			ci.CheckSum = test.flagCheckSum
+			opt := defaultEqualOpt(ctx, test.fss...)
+			assert.Equal(t, test.wantSizeOnly, opt.sizeOnly)
+			assert.Equal(t, test.wantCheckSum, opt.checkSum)
+		})
+	}
+}
+
+func TestInteractiveRules(t *testing.T) {
+	ctx := context.Background()
+	ctx, ci := fs.AddConfig(ctx)
+	ci.Interactive = true
+	tempDir, err := ioutil.TempDir("", "rclone-interactive")
+	require.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(tempDir)
+	}()
+	ci.InteractiveLog = filepath.Join(tempDir, "decisions.log")
+
+	// Answer the questions from answers, failing if they run out
+	var answers []string
+	oldReadLine := config.ReadLine
+	config.ReadLine = func() string {
+		require.NotEqual(t, 0, len(answers), "unexpected question")
+		answer := answers[0]
+		answers = answers[1:]
+		return answer
+	}
+	defer func() {
+		config.ReadLine = oldReadLine
+	}()
+	resetInteractiveRules()
+	defer resetInteractiveRules()
+
+	when := time.Now()
+	obj := func(remote string, size int64) fs.ObjectInfo {
+		return object.NewStaticObjectInfo(remote, when, size, true, nil, nil)
+	}
+	check := func(subject interface{}, action string, wantSkip bool, answer ...string) {
+		answers = answer
+		assert.Equal(t, wantSkip, SkipDestructive(ctx, subject, action), fmt.Sprintf("%v %s", subject, action))
+		assert.Equal(t, 0, len(answers), "questions not asked")
+	}
+
+	// Plain yes and no
+	check(obj("dir/a", 1), "copy", false, "y")
+	check(obj("dir/a", 1), "copy", true, "n")
+
+	// Do all copies in dir
+	check(obj("dir/b", 1), "copy", false, "d")
+	check(obj("dir/c", 1), "copy", false)
+	check(obj("dir/sub/c", 1), "copy", true, "n")
+	check(obj("dir/d", 1), "delete", true, "n")
+
+	// Skip all copies larger than 100 bytes
+	check(obj("e", 1000), "copy", true, "l", "potato", "100B")
+	check(obj("f", 200), "copy", true)
+	check(obj("g", 10), "copy", false, "y")
+	check(obj("dir/h", 200), "copy", true) // the newest rule wins
+
+	// Do all deletes
+	check(obj("i", 1), "delete", false, "!")
+	check(obj("dir/j", 1), "delete", false)
+
+	data, err := ioutil.ReadFile(ci.InteractiveLog)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	assert.Equal(t, 12, len(lines))
+	assert.Contains(t, lines[3], `"decision":"do","reason":"rule: do all copy operations in \"dir\""`)
+	assert.Contains(t, lines[7], `"decision":"skip","reason":"rule: skip all copy operations larger than 100"`)
+}
@@ -22,6 +22,7 @@ package operations_test
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -33,6 +34,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/pkg/errors"
 	_ "github.com/pingme998/rclone/backend/all" // import all backends
 	"github.com/pingme998/rclone/fs"
 	"github.com/pingme998/rclone/fs/accounting"
@@ -40,6 +42,7 @@ import (
 	"github.com/pingme998/rclone/fs/fserrors"
 	"github.com/pingme998/rclone/fs/fshttp"
 	"github.com/pingme998/rclone/fs/hash"
+	"github.com/pingme998/rclone/fs/object"
 	"github.com/pingme998/rclone/fs/operations"
 	"github.com/pingme998/rclone/fstest"
 	"github.com/pingme998/rclone/lib/random"
@@ -898,6 +901,231 @@ func TestCopyFile(t *testing.T) {
 	fstest.CheckItems(t, r.Fremote, file2)
 }
 
+// putRecorder records the remotes uploaded with Put
+type putRecorder struct {
+	fs.Fs
+	remotes        []string
+	noMoveOver     bool // if set Move fails if the destination exists
+	duplicateFiles bool // if set the DuplicateFiles feature is set
+	movesOver      int  // number of Moves over an existing object
+	movesRefused   int  // number of Moves refused because of noMoveOver
+	corrupt        int  // number of uploads left to corrupt
+}
+
+// Features overrides Move and DuplicateFiles
+func (f *putRecorder) Features() *fs.Features {
+	features := *f.Fs.Features()
+	features.Move = f.move
+	features.DuplicateFiles = f.duplicateFiles
+	return &features
+}
+
+// move records Moves over existing objects, refusing them if
+// noMoveOver is set
+func (f *putRecorder) move(ctx context.Context, src fs.Object, remote string) (fs.Object, error) {
+	if _, err := f.Fs.NewObject(ctx, remote); err == nil {
+		if f.noMoveOver {
+			f.movesRefused++
+			return nil, errors.New("destination exists")
+		}
+		f.movesOver++
+	}
+	return f.Fs.Features().Move(ctx, src, remote)
+}
+
+// Put records the remote then uploads it
+func (f *putRecorder) Put(ctx context.Context, in io.Reader, src fs.ObjectInfo, options ...fs.OpenOption) (fs.Object, error) {
+	f.remotes = append(f.remotes, src.Remote())
+	if f.corrupt > 0 {
+		f.corrupt--
+		data, err := ioutil.ReadAll(in)
+		if err != nil {
+			return nil, err
+		}
+		if len(data) > 0 {
+			data[0] ^= 0xFF
+		}
+		in = bytes.NewReader(data)
+	}
+	return f.Fs.Put(ctx, in, src, options...)
+}
+
+// uploadSrc returns a source object for item with contents on a
+// remote other than the one under test so Copy uploads it rather than
+// copying it server-side
+func uploadSrc(item fstest.Item, contents string) fs.Object {
+	return object.NewMemoryObject(item.Path, item.ModTime, []byte(contents))
+}
+
+func TestCopyPartial(t *testing.T) {
+	ctx := context.Background()
+	ctx, ci := fs.AddConfig(ctx)
+	r := fstest.NewRun(t)
+	defer r.Finalise()
+	if r.Fremote.Features().Move == nil {
+		t.Skip("Can't test partial files on a remote without Move")
+	}
+	fdst := &putRecorder{Fs: r.Fremote}
+
+	file1 := fstest.NewItem("file1", "file1 contents", t1)
+	src := uploadSrc(file1, "file1 contents")
+
+	// Partial files aren't used by default
+	_, err := operations.Copy(ctx, fdst, nil, file1.Path, src)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"file1"}, fdst.remotes)
+	fstest.CheckItems(t, r.Fremote, file1)
+	dst, err := r.Fremote.NewObject(ctx, file1.Path)
+	require.NoError(t, err)
+	require.NoError(t, dst.Remove(ctx))
+
+	// New file is uploaded to a partial file and renamed
+	ci.PartialSuffix = ".partial"
+	fdst.remotes = nil
+	_, err = operations.Copy(ctx, fdst, nil, file1.Path, src)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"file1.partial"}, fdst.remotes)
+	fstest.CheckItems(t, r.Fremote, file1)
+
+	// Existing file is replaced
+	file2 := fstest.NewItem("file1", "file1 new contents", t2)
+	src = uploadSrc(file2, "file1 new contents")
+	dst, err = r.Fremote.NewObject(ctx, file1.Path)
+	require.NoError(t, err)
+	fdst.remotes = nil
+	_, err = operations.Copy(ctx, fdst, dst, file2.Path, src)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"file1.partial"}, fdst.remotes)
+	fstest.CheckItems(t, r.Fremote, file2)
+	assert.Equal(t, 1, fdst.movesOver)
+
+	// Existing file is removed first if it can't be moved over
+	file2b := fstest.NewItem("file1", "file1 newer contents", t3)
+	src = uploadSrc(file2b, "file1 newer contents")
+	dst, err = r.Fremote.NewObject(ctx, file1.Path)
+	require.NoError(t, err)
+	fdst.remotes = nil
+	fdst.noMoveOver = true
+	_, err = operations.Copy(ctx, fdst, dst, file2b.Path, src)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"file1.partial"}, fdst.remotes)
+	assert.Equal(t, 1, fdst.movesRefused)
+	fstest.CheckItems(t, r.Fremote, file2b)
+	file2 = file2b
+
+	// Backends which allow duplicates update the existing file in
+	// place rather than removing it to rename the partial file over it
+	file2c := fstest.NewItem("file1", "file1 newest contents", t1)
+	src = uploadSrc(file2c, "file1 newest contents")
+	dst, err = r.Fremote.NewObject(ctx, file1.Path)
+	require.NoError(t, err)
+	fdst.remotes = nil
+	fdst.movesOver = 0
+	fdst.movesRefused = 0
+	fdst.duplicateFiles = true
+	_, err = operations.Copy(ctx, fdst, dst, file2c.Path, src)
+	require.NoError(t, err)
+	assert.Empty(t, fdst.remotes)
+	assert.Equal(t, 0, fdst.movesOver)
+	assert.Equal(t, 0, fdst.movesRefused)
+	fstest.CheckItems(t, r.Fremote, file2c)
+	file2 = file2c
+	fdst.duplicateFiles = false
+
+	// Server-side copies are made in place
+	file4 := r.WriteFile("file4", "file4 contents", t3)
+	if operations.SameConfig(r.Flocal, r.Fremote) && r.Fremote.Features().Copy != nil {
+		src, err = r.Flocal.NewObject(ctx, file4.Path)
+		require.NoError(t, err)
+		fdst.remotes = nil
+		_, err = operations.Copy(ctx, fdst, nil, file4.Path, src)
+		require.NoError(t, err)
+		assert.Empty(t, fdst.remotes)
+		fstest.CheckItems(t, r.Fremote, file2, file4)
+		dst, err = r.Fremote.NewObject(ctx, file4.Path)
+		require.NoError(t, err)
+		require.NoError(t, dst.Remove(ctx))
+	}
+
+	// --inplace uploads to the file directly
+	ci.Inplace = true
+	file3 := fstest.NewItem("file3", "file3 contents", t3)
+	src = uploadSrc(file3, "file3 contents")
+	fdst.remotes = nil
+	_, err = operations.Copy(ctx, fdst, nil, file3.Path, src)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"file3"}, fdst.remotes)
+	fstest.CheckItems(t, r.Fremote, file2, file3)
+}
+
+func TestCopyVerifyUploads(t *testing.T) {
+	ctx := context.Background()
+	ctx, ci := fs.AddConfig(ctx)
+	ctx = accounting.WithStatsGroup(ctx, "test-copy-verify-uploads")
+	r := fstest.NewRun(t)
+	defer r.Finalise()
+	ci.VerifyUploads = true
+	fdst := &putRecorder{Fs: r.Fremote, corrupt: 1}
+
+	file1 := fstest.NewItem("file1", "file1 contents", t1)
+	src := uploadSrc(file1, "file1 contents")
+
+	// The corrupted upload fails verification and is uploaded again
+	_, err := operations.Copy(ctx, fdst, nil, file1.Path, src)
+	require.NoError(t, err)
+	assert.Equal(t, 2, len(fdst.remotes))
+	fstest.CheckItems(t, r.Fremote, file1)
+	assert.Equal(t, int64(2), accounting.Stats(ctx).Verifies(0))
+	assert.Equal(t, int64(1), accounting.Stats(ctx).VerifyFailures(0))
+	assert.Equal(t, int64(0), accounting.Stats(ctx).GetErrors())
+}
+
+func TestCopyFileLogResults(t *testing.T) {
+	ctx := context.Background()
+	ctx, ci := fs.AddConfig(ctx)
+	r := fstest.NewRun(t)
+	defer r.Finalise()
+
+	resultsFile, err := ioutil.TempFile("", "rclone-log-results")
+	require.NoError(t, err)
+	require.NoError(t, resultsFile.Close())
+	defer func() {
+		require.NoError(t, os.Remove(resultsFile.Name()))
+	}()
+	ci.LogResults = resultsFile.Name()
+
+	file1 := r.WriteFile("file1", "file1 contents", t1)
+	fstest.CheckItems(t, r.Flocal, file1)
+
+	err = operations.CopyFile(ctx, r.Fremote, r.Flocal, file1.Path, file1.Path)
+	require.NoError(t, err)
+	fstest.CheckItems(t, r.Fremote, file1)
+
+	obj, err := r.Fremote.NewObject(ctx, file1.Path)
+	require.NoError(t, err)
+	require.NoError(t, operations.DeleteFile(ctx, obj))
+
+	data, err := ioutil.ReadFile(resultsFile.Name())
+	require.NoError(t, err)
+	var results []accounting.Result
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		var result accounting.Result
+		require.NoError(t, json.Unmarshal([]byte(line), &result))
+		results = append(results, result)
+	}
+	require.Equal(t, 2, len(results), string(data))
+	assert.Equal(t, "transfer", results[0].Action)
+	assert.Equal(t, file1.Path, results[0].Path)
+	assert.Equal(t, file1.Size, results[0].Size)
+	assert.Equal(t, "", results[0].Error)
+	if ht := r.Fremote.Hashes().Overlap(r.Flocal.Hashes()).GetOne(); ht != hash.None {
+		assert.Equal(t, ht.String(), results[0].HashType)
+		assert.Equal(t, file1.Hashes[ht], results[0].Hash)
+	}
+	assert.Equal(t, "delete", results[1].Action)
+	assert.Equal(t, file1.Path, results[1].Path)
+}
+
 func TestCopyFileBackupDir(t *testing.T) {
 	ctx := context.Background()
 	ctx, ci := fs.AddConfig(ctx)
@@ -1309,6 +1537,55 @@ func TestListFormat(t *testing.T) {
 	assert.Equal(t, "a|encryptedFileName", list.Format(item0))
 	assert.Equal(t, "subdir/|encryptedDirName/", list.Format(item1))
 
+	item0.Metadata = map[string]string{"owner": "bob", "replication": "3"}
+	list.SetOutput(nil)
+	list.SetSeparator("|")
+	list.AddMetadata(nil)
+	assert.Equal(t, "owner=bob,replication=3", list.Format(item0))
+	assert.Equal(t, "", list.Format(item1))
+
+	list.SetOutput(nil)
+	list.AddMetadata([]string{"replication", "potato", "owner"})
+	assert.Equal(t, "3||bob", list.Format(item0))
+	assert.Equal(t, "||", list.Format(item1))
+}
+
+func TestStatJSON(t *testing.T) {
+	ctx := context.Background()
+	r := fstest.NewRun(t)
+	defer r.Finalise()
+	file1 := r.WriteObject(ctx, "sub dir/hello world", "hello world", t1)
+	fstest.CheckItems(t, r.Fremote, file1)
+
+	opt := &operations.ListJSONOpt{ShowHash: true}
+	item, err := operations.StatJSON(ctx, r.Fremote, "sub dir/hello world", opt)
+	require.NoError(t, err)
+	assert.Equal(t, "sub dir/hello world", item.Path)
+	assert.Equal(t, "hello world", item.Name)
+	assert.Equal(t, int64(11), item.Size)
+	assert.False(t, item.IsDir)
+	fstest.AssertTimeEqualWithPrecision(t, item.Path, t1, item.ModTime.When, fs.GetModifyWindow(ctx, r.Fremote))
+	if r.Fremote.Hashes().Contains(hash.MD5) {
+		assert.Equal(t, "5eb63bbbe01eeed093cb22bb8f5acdc3", item.Hashes["MD5"])
+	}
+
+	item, err = operations.StatJSON(ctx, r.Fremote, "sub dir", opt)
+	require.NoError(t, err)
+	assert.Equal(t, "sub dir", item.Path)
+	assert.Equal(t, "sub dir", item.Name)
+	assert.True(t, item.IsDir)
+	assert.Nil(t, item.Hashes)
+
+	item, err = operations.StatJSON(ctx, r.Fremote, "", opt)
+	require.NoError(t, err)
+	assert.Equal(t, "", item.Path)
+	assert.True(t, item.IsDir)
+
+	_, err = operations.StatJSON(ctx, r.Fremote, "sub dir/potato", opt)
+	assert.Equal(t, fs.ErrorObjectNotFound, err)
+
+	_, err = operations.StatJSON(ctx, r.Fremote, "not found/potato", opt)
+	assert.Equal(t, fs.ErrorObjectNotFound, err)
 }
 
 func TestDirMove(t *testing.T) {
@@ -6,12 +6,15 @@ import (
 	"mime"
 	"mime/multipart"
 	"net/http"
+	"net/url"
 	"path"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/pkg/errors"
 	"github.com/pingme998/rclone/fs"
+	"github.com/pingme998/rclone/fs/accounting"
 	"github.com/pingme998/rclone/fs/rc"
 )
 
@@ -31,6 +34,8 @@ func init() {
     - showEncrypted -  If set show decrypted names
     - showOrigIDs - If set show the IDs for each item if known
     - showHash - If set return a dictionary of hashes
+    - showMetadata - If set return a dictionary of backend specific metadata
+    - showTags - If set return a dictionary of the user defined tags
 
 The result is
 
@@ -347,6 +352,80 @@ func rcPublicLink(ctx context.Context, in rc.Params) (out rc.Params, err error)
 	return out, nil
 }
 
+func init() {
+	rc.Add(rc.Call{
+		Path:         "operations/listlinks",
+		AuthRequired: true,
+		Fn:           rcListLinks,
+		Title:        "List the public links to the given folder and everything under it.",
+		Help: `This takes the following parameters
+
+- fs - a remote name string e.g. "drive:"
+- remote - a path within that remote e.g. "dir" (optional)
+
+Returns
+
+- links - a list of links, each with
+    - remote - path of the linked file or folder
+    - url - URL of the link
+    - expires - when the link expires if set
+
+See the [link command](/commands/rclone_link/) command for more information on the above.
+`,
+	})
+}
+
+// List the public links
+func rcListLinks(ctx context.Context, in rc.Params) (out rc.Params, err error) {
+	f, err := rc.GetFs(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	remote, err := in.GetString("remote")
+	if err != nil && !rc.IsErrParamNotFound(err) {
+		return nil, err
+	}
+	links, err := ListLinks(ctx, f, remote)
+	if err != nil {
+		return nil, err
+	}
+	if links == nil {
+		links = []fs.Link{}
+	}
+	out = make(rc.Params)
+	out["links"] = links
+	return out, nil
+}
+
+func init() {
+	rc.Add(rc.Call{
+		Path:         "operations/revokelink",
+		AuthRequired: true,
+		Fn:           rcRevokeLink,
+		Title:        "Revoke a public link.",
+		Help: `This takes the following parameters
+
+- fs - a remote name string e.g. "drive:"
+- url - the URL of the link to revoke
+
+See the [link command](/commands/rclone_link/) command for more information on the above.
+`,
+	})
+}
+
+// Revoke a public link
+func rcRevokeLink(ctx context.Context, in rc.Params) (out rc.Params, err error) {
+	f, err := rc.GetFs(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	url, err := in.GetString("url")
+	if err != nil {
+		return nil, err
+	}
+	return nil, RevokeLink(ctx, f, url)
+}
+
 func init() {
 	rc.Add(rc.Call{
 		Path:  "operations/fsinfo",
@@ -471,6 +550,11 @@ command:
 
 Note that arguments must be preceded by the "-a" flag
 
+The "lifecycle" command is handled by rclone for backends with native
+object expiry rules, e.g.
+
+    rclone rc backend/command command=lifecycle fs=s3:bucket -a get
+
 See the [backend](/commands/rclone_backend/) command for more information.
 `,
 	})
@@ -482,14 +566,19 @@ func rcBackend(ctx context.Context, in rc.Params) (out rc.Params, err error) {
 	if err != nil {
 		return nil, err
 	}
-	doCommand := f.Features().Command
-	if doCommand == nil {
-		return nil, errors.Errorf("%v: doesn't support backend commands", f)
-	}
 	command, err := in.GetString("command")
 	if err != nil {
 		return nil, err
 	}
+	doCommand := f.Features().Command
+	if command == "lifecycle" {
+		doCommand = func(ctx context.Context, name string, arg []string, opt map[string]string) (interface{}, error) {
+			return Lifecycle(ctx, f, arg, opt)
+		}
+	}
+	if doCommand == nil {
+		return nil, errors.Errorf("%v: doesn't support backend commands", f)
+	}
 	var opt = map[string]string{}
 	err = in.GetStructMissingOK("opt", &opt)
 	if err != nil {
@@ -509,3 +598,233 @@ func rcBackend(ctx context.Context, in rc.Params) (out rc.Params, err error) {
 	out["result"] = result
 	return out, nil
 }
+
+func init() {
+	rc.Add(rc.Call{
+		Path:         "operations/rename",
+		AuthRequired: true,
+		Fn:           rcRename,
+		Title:        "Rename a file or directory in place using server-side move",
+		Help: `This takes the following parameters
+
+- fs - a remote name string e.g. "drive:"
+- remote - a path within that remote e.g. "dir/file.txt"
+- newName - the new leaf name e.g. "file2.txt"
+
+This renames the file or directory without moving it to another
+directory. It fails if the remote can't rename it server-side or if
+newName already exists.
+`,
+	})
+}
+
+// Rename a file or directory in place
+func rcRename(ctx context.Context, in rc.Params) (out rc.Params, err error) {
+	f, remote, err := rc.GetFsAndRemote(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	newName, err := in.GetString("newName")
+	if err != nil {
+		return nil, err
+	}
+	return nil, Rename(ctx, f, remote, newName)
+}
+
+// Rename renames the file or directory at remote to newName in the
+// same directory using server-side move.
+func Rename(ctx context.Context, f fs.Fs, remote, newName string) error {
+	if newName == "" || newName == "." || newName == ".." || strings.ContainsRune(newName, '/') {
+		return errors.Errorf("bad new name %q", newName)
+	}
+	if remote == "" {
+		return errors.New("can't rename the root")
+	}
+	newRemote := path.Join(path.Dir(remote), newName)
+	if path.Dir(remote) == "." {
+		newRemote = newName
+	}
+	if newRemote == remote {
+		return nil
+	}
+	if _, err := f.NewObject(ctx, newRemote); err == nil {
+		return errors.Errorf("can't rename %q as %q already exists", remote, newName)
+	}
+	o, err := f.NewObject(ctx, remote)
+	if err == nil {
+		doMove := f.Features().Move
+		if doMove == nil {
+			return errors.Errorf("can't rename %q: %v doesn't support server-side move", remote, f)
+		}
+		if SkipDestructive(ctx, o, "rename") {
+			return nil
+		}
+		_, err = doMove(ctx, o, newRemote)
+		if err != nil {
+			return errors.Wrapf(err, "failed to rename %q", remote)
+		}
+		accounting.Stats(ctx).Renames(1)
+		fs.Infof(o, "Renamed to %q", newName)
+		return nil
+	}
+	if cause := errors.Cause(err); cause != fs.ErrorObjectNotFound && cause != fs.ErrorNotAFile {
+		return err
+	}
+	doDirMove := f.Features().DirMove
+	if doDirMove == nil {
+		return errors.Errorf("can't rename %q: %v doesn't support server-side directory move", remote, f)
+	}
+	if SkipDestructive(ctx, remote, "rename") {
+		return nil
+	}
+	err = doDirMove(ctx, f, remote, newRemote)
+	if err != nil {
+		return errors.Wrapf(err, "failed to rename %q", remote)
+	}
+	accounting.Stats(ctx).Renames(1)
+	fs.Infof(f, "Renamed %q to %q", remote, newName)
+	return nil
+}
+
+func init() {
+	rc.Add(rc.Call{
+		Path:         "operations/deletemany",
+		AuthRequired: true,
+		Fn:           rcDeleteMany,
+		Title:        "Remove a list of files and directories",
+		Help: `This takes the following parameters
+
+- fs - a remote name string e.g. "drive:"
+- remotes - a list of paths within that remote e.g. ["file.txt", "dir"]
+
+Files are deleted and directories are purged along with their
+contents.
+
+Run this with _async=true to follow its progress with core/stats for
+the job's group - "checks" out of "totalChecks" items have been done
+so far and "deletes" and "deletedDirs" count what has been removed.
+
+Returns
+
+- deleted - the number of items deleted
+- errors - a map of the paths which couldn't be deleted to the error
+
+This returns an error if none of the items could be deleted.
+`,
+	})
+}
+
+// Delete a list of files and directories
+func rcDeleteMany(ctx context.Context, in rc.Params) (out rc.Params, err error) {
+	f, err := rc.GetFs(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	var remotes []string
+	err = in.GetStruct("remotes", &remotes)
+	if err != nil {
+		return nil, err
+	}
+	stats := accounting.Stats(ctx)
+	deleted := 0
+	errs := map[string]string{}
+	for i, remote := range remotes {
+		stats.SetCheckQueue(len(remotes)-i, 0)
+		o, err := f.NewObject(ctx, remote)
+		switch cause := errors.Cause(err); {
+		case err == nil:
+			err = DeleteFile(ctx, o)
+		case cause == fs.ErrorObjectNotFound || cause == fs.ErrorNotAFile:
+			err = Purge(ctx, f, remote)
+		}
+		if err != nil {
+			fs.Errorf(remote, "Failed to delete: %v", err)
+			errs[remote] = err.Error()
+		} else {
+			deleted++
+		}
+		stats.DoneChecking(remote)
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+	}
+	stats.SetCheckQueue(0, 0)
+	if deleted == 0 && len(errs) > 0 {
+		return nil, errors.Errorf("failed to delete %d items", len(errs))
+	}
+	out = make(rc.Params)
+	out["deleted"] = deleted
+	out["errors"] = errs
+	return out, nil
+}
+
+func init() {
+	rc.Add(rc.Call{
+		Path:         "operations/serveurl",
+		AuthRequired: true,
+		Fn:           rcServeURL,
+		Title:        "Make a URL to download a file, directory or thumbnail from the rc server",
+		Help: `This takes the following parameters
+
+- fs - a remote name string e.g. "drive:"
+- remote - a path within that remote e.g. "dir/file.jpg"
+- type - one of "file" (the default), "zip" or "thumbnail"
+- size - largest width or height of a thumbnail in pixels (default 256)
+
+Returns
+
+- url - a URL relative to the rc server e.g. "/[drive:]/dir/file.jpg"
+
+For "zip" the remote should be a directory and the URL fetches its
+contents as a zip file. For "thumbnail" the remote should be a GIF,
+JPEG or PNG image and the URL fetches a JPEG scaled to fit in a square
+of size pixels.
+
+The URLs are only served if the rc server was started with --rc-serve,
+which --rc-web-gui sets.
+`,
+	})
+}
+
+// Make a URL to fetch remote from the rc server
+func rcServeURL(ctx context.Context, in rc.Params) (out rc.Params, err error) {
+	fsName, err := in.GetString("fs")
+	if err != nil {
+		return nil, err
+	}
+	remote, err := in.GetString("remote")
+	if err != nil && !rc.IsErrParamNotFound(err) {
+		return nil, err
+	}
+	kind, err := in.GetString("type")
+	if rc.IsErrParamNotFound(err) {
+		kind = "file"
+	} else if err != nil {
+		return nil, err
+	}
+	size, err := in.GetInt64("size")
+	if rc.IsErrParamNotFound(err) {
+		size = 256
+	} else if err != nil {
+		return nil, err
+	}
+	remote = strings.Trim(remote, "/")
+	segments := strings.Split(remote, "/")
+	for i := range segments {
+		segments[i] = url.PathEscape(segments[i])
+	}
+	u := "/[" + url.PathEscape(fsName) + "]/" + strings.Join(segments, "/")
+	switch kind {
+	case "file":
+	case "zip":
+		if remote != "" {
+			u += "/"
+		}
+		u += "?download=zip"
+	case "thumbnail":
+		u += "?thumbnail=" + strconv.FormatInt(size, 10)
+	default:
+		return nil, errors.Errorf("unknown type %q: must be file, zip or thumbnail", kind)
+	}
+	return rc.Params{"url": u}, nil
+}
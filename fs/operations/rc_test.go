@@ -418,6 +418,35 @@ func TestRcPublicLink(t *testing.T) {
 	assert.Contains(t, err.Error(), "doesn't support public links")
 }
 
+// operations/listlinks: List the public links to the given folder and everything under it.
+func TestRcListLinks(t *testing.T) {
+	r, call := rcNewRun(t, "operations/listlinks")
+	defer r.Finalise()
+	in := rc.Params{
+		"fs": r.FremoteName,
+	}
+	_, err := call.Fn(context.Background(), in)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "doesn't support listing public links")
+}
+
+// operations/revokelink: Revoke a public link.
+func TestRcRevokeLink(t *testing.T) {
+	r, call := rcNewRun(t, "operations/revokelink")
+	defer r.Finalise()
+	in := rc.Params{
+		"fs": r.FremoteName,
+	}
+	_, err := call.Fn(context.Background(), in)
+	require.Error(t, err)
+	assert.True(t, rc.IsErrParamNotFound(err))
+
+	in["url"] = "https://example.com/link"
+	_, err = call.Fn(context.Background(), in)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "doesn't support revoking public links")
+}
+
 // operations/fsinfo: Return information about the remote
 func TestRcFsInfo(t *testing.T) {
 	r, call := rcNewRun(t, "operations/fsinfo")
@@ -542,3 +571,107 @@ func TestRcCommand(t *testing.T) {
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), errTxt)
 }
+
+// operations/rename: Rename a file or directory in place
+func TestRcRename(t *testing.T) {
+	r, call := rcNewRun(t, "operations/rename")
+	defer r.Finalise()
+	if r.Fremote.Features().Move == nil || r.Fremote.Features().DirMove == nil {
+		t.Skip("Skipping test as remote can't move")
+	}
+
+	file1 := r.WriteObject(context.Background(), "dir/small", "1234567890", t2)
+	file2 := r.WriteObject(context.Background(), "dir/medium", "------------------------------------------------------------", t1)
+	fstest.CheckItems(t, r.Fremote, file1, file2)
+
+	// rename a file
+	out, err := call.Fn(context.Background(), rc.Params{
+		"fs":      r.FremoteName,
+		"remote":  "dir/small",
+		"newName": "tiny",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, rc.Params(nil), out)
+	file1.Path = "dir/tiny"
+	fstest.CheckItems(t, r.Fremote, file1, file2)
+
+	// renaming over an existing file fails
+	_, err = call.Fn(context.Background(), rc.Params{
+		"fs":      r.FremoteName,
+		"remote":  "dir/tiny",
+		"newName": "medium",
+	})
+	assert.Error(t, err)
+
+	// names with a / in are rejected
+	_, err = call.Fn(context.Background(), rc.Params{
+		"fs":      r.FremoteName,
+		"remote":  "dir/tiny",
+		"newName": "other/tiny",
+	})
+	assert.Error(t, err)
+
+	// rename a directory
+	_, err = call.Fn(context.Background(), rc.Params{
+		"fs":      r.FremoteName,
+		"remote":  "dir",
+		"newName": "dir2",
+	})
+	require.NoError(t, err)
+	file1.Path = "dir2/tiny"
+	file2.Path = "dir2/medium"
+	fstest.CheckItems(t, r.Fremote, file1, file2)
+}
+
+// operations/deletemany: Remove a list of files and directories
+func TestRcDeleteMany(t *testing.T) {
+	r, call := rcNewRun(t, "operations/deletemany")
+	defer r.Finalise()
+
+	file1 := r.WriteObject(context.Background(), "small", "1234567890", t2)
+	file2 := r.WriteObject(context.Background(), "dir/medium", "------------------------------------------------------------", t1)
+	file3 := r.WriteObject(context.Background(), "large", "AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA", t1)
+	fstest.CheckItems(t, r.Fremote, file1, file2, file3)
+
+	out, err := call.Fn(context.Background(), rc.Params{
+		"fs":      r.FremoteName,
+		"remotes": []string{"small", "dir", "notfound"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 2, out["deleted"])
+	errs := out["errors"].(map[string]string)
+	assert.Contains(t, errs, "notfound")
+	assert.Equal(t, 1, len(errs))
+	fstest.CheckItems(t, r.Fremote, file3)
+
+	// fails if nothing could be deleted
+	_, err = call.Fn(context.Background(), rc.Params{
+		"fs":      r.FremoteName,
+		"remotes": []string{"notfound"},
+	})
+	assert.Error(t, err)
+	fstest.CheckItems(t, r.Fremote, file3)
+}
+
+// operations/serveurl: Make a URL to download a file, directory or thumbnail
+func TestRcServeURL(t *testing.T) {
+	call := rc.Calls.Get("operations/serveurl")
+	require.NotNil(t, call)
+	for _, test := range []struct {
+		in   rc.Params
+		want string
+	}{
+		{rc.Params{"fs": "drive:", "remote": "dir/file.txt"}, "/[drive:]/dir/file.txt"},
+		{rc.Params{"fs": "drive:", "remote": "a dir/a#file?"}, "/[drive:]/a%20dir/a%23file%3F"},
+		{rc.Params{"fs": "drive:", "remote": "dir", "type": "zip"}, "/[drive:]/dir/?download=zip"},
+		{rc.Params{"fs": "drive:", "type": "zip"}, "/[drive:]/?download=zip"},
+		{rc.Params{"fs": "drive:", "remote": "image.jpg", "type": "thumbnail"}, "/[drive:]/image.jpg?thumbnail=256"},
+		{rc.Params{"fs": "drive:", "remote": "image.jpg", "type": "thumbnail", "size": 64}, "/[drive:]/image.jpg?thumbnail=64"},
+	} {
+		out, err := call.Fn(context.Background(), test.in)
+		require.NoError(t, err, test.in)
+		assert.Equal(t, test.want, out["url"], test.in)
+	}
+	_, err := call.Fn(context.Background(), rc.Params{"fs": "drive:", "type": "potato"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,326 @@
+package operations
+
+import (
+	"context"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/pingme998/rclone/fs"
+	"github.com/pkg/errors"
+)
+
+// suffixNumber is the placeholder in --suffix which is replaced by a
+// version number
+const suffixNumber = "{n}"
+
+// SuffixNumbered returns true if --suffix contains the {n} version
+// number placeholder
+func SuffixNumbered(ctx context.Context) bool {
+	ci := fs.GetConfig(ctx)
+	return strings.Contains(ci.Suffix, suffixNumber)
+}
+
+// suffixParts splits remote into the parts before and after the
+// version number for a numbered --suffix
+func suffixParts(ctx context.Context, remote string) (before, after string) {
+	ci := fs.GetConfig(ctx)
+	i := strings.Index(ci.Suffix, suffixNumber)
+	pre, post := ci.Suffix[:i], ci.Suffix[i+len(suffixNumber):]
+	if ci.SuffixKeepExtension {
+		ext := path.Ext(remote)
+		base := remote[:len(remote)-len(ext)]
+		return base + pre, post + ext
+	}
+	return remote + pre, post
+}
+
+// SuffixVersionName returns the name of version n of remote using a
+// numbered --suffix, obeying --suffix-keep-extension if set
+func SuffixVersionName(ctx context.Context, remote string, n int) string {
+	before, after := suffixParts(ctx, remote)
+	return before + strconv.Itoa(n) + after
+}
+
+// SuffixVersion is a numbered backup of a file made with --suffix
+type SuffixVersion struct {
+	N   int
+	Obj fs.Object
+}
+
+// suffixDirKey identifies a directory in a backup dir
+type suffixDirKey struct {
+	f   fs.Fs
+	dir string
+}
+
+// suffixDir is the cached listing of a directory in a backup dir
+type suffixDir struct {
+	mu     sync.Mutex
+	listed bool
+	objs   []fs.Object // sorted by Remote
+}
+
+// suffixVersionsCache caches the listings of the directories that
+// numbered --suffix versions are looked for in, so each is only
+// listed once rather than once per file backed up.
+type suffixVersionsCache struct {
+	mu   sync.Mutex
+	dirs map[suffixDirKey]*suffixDir
+}
+
+// newSuffixVersionsCache makes an empty suffixVersionsCache
+func newSuffixVersionsCache() *suffixVersionsCache {
+	return &suffixVersionsCache{
+		dirs: make(map[suffixDirKey]*suffixDir),
+	}
+}
+
+type suffixVersionsCacheKey struct{}
+
+// WithSuffixVersionsCache returns a context in which the directory
+// listings used to find numbered --suffix versions are cached.
+//
+// Use this for operations which back up many files, like sync. The
+// listings are kept up to date with the versions made and deleted
+// through the context, but not with changes made outside it.
+func WithSuffixVersionsCache(ctx context.Context) context.Context {
+	if _, ok := ctx.Value(suffixVersionsCacheKey{}).(*suffixVersionsCache); ok {
+		return ctx
+	}
+	return context.WithValue(ctx, suffixVersionsCacheKey{}, newSuffixVersionsCache())
+}
+
+// getSuffixVersionsCache returns the cache from ctx or a new one which
+// lasts as long as the caller holds it if there isn't one
+func getSuffixVersionsCache(ctx context.Context) *suffixVersionsCache {
+	if c, ok := ctx.Value(suffixVersionsCacheKey{}).(*suffixVersionsCache); ok {
+		return c
+	}
+	return newSuffixVersionsCache()
+}
+
+// dir returns the cached directory remote is in, listing it if
+// necessary, with its lock held
+func (c *suffixVersionsCache) dir(ctx context.Context, backupDir fs.Fs, remote string) (d *suffixDir, err error) {
+	dir := path.Dir(remote)
+	if dir == "." {
+		dir = ""
+	}
+	key := suffixDirKey{f: backupDir, dir: dir}
+	c.mu.Lock()
+	d, ok := c.dirs[key]
+	if !ok {
+		d = &suffixDir{}
+		c.dirs[key] = d
+	}
+	c.mu.Unlock()
+	d.mu.Lock()
+	if d.listed {
+		return d, nil
+	}
+	entries, err := backupDir.List(ctx, dir)
+	if err == fs.ErrorDirNotFound {
+		entries = nil
+	} else if err != nil {
+		d.mu.Unlock()
+		return nil, errors.Wrap(err, "failed to list versions")
+	}
+	for _, entry := range entries {
+		if o, ok := entry.(fs.Object); ok {
+			d.objs = append(d.objs, o)
+		}
+	}
+	sort.Slice(d.objs, func(i, j int) bool {
+		return d.objs[i].Remote() < d.objs[j].Remote()
+	})
+	d.listed = true
+	return d, nil
+}
+
+// _find returns the index of the first object whose name is >= name
+//
+// call with d.mu held
+func (d *suffixDir) _find(name string) int {
+	return sort.Search(len(d.objs), func(i int) bool {
+		return d.objs[i].Remote() >= name
+	})
+}
+
+// _versions returns the numbered versions of remote, oldest first
+//
+// call with d.mu held
+func (d *suffixDir) _versions(ctx context.Context, remote string) (versions []SuffixVersion) {
+	before, after := suffixParts(ctx, remote)
+	// All the versions start with before so are together in objs
+	for i := d._find(before); i < len(d.objs); i++ {
+		o := d.objs[i]
+		name := o.Remote()
+		if !strings.HasPrefix(name, before) {
+			break
+		}
+		if len(name) <= len(before)+len(after) || !strings.HasSuffix(name, after) {
+			continue
+		}
+		n, err := strconv.Atoi(name[len(before) : len(name)-len(after)])
+		if err != nil || n <= 0 {
+			continue
+		}
+		versions = append(versions, SuffixVersion{N: n, Obj: o})
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		return versions[i].N < versions[j].N
+	})
+	return versions
+}
+
+// _add records that o has been made
+//
+// call with d.mu held
+func (d *suffixDir) _add(o fs.Object) {
+	i := d._find(o.Remote())
+	if i < len(d.objs) && d.objs[i].Remote() == o.Remote() {
+		d.objs[i] = o
+		return
+	}
+	d.objs = append(d.objs, nil)
+	copy(d.objs[i+1:], d.objs[i:])
+	d.objs[i] = o
+}
+
+// _remove records that o has been deleted
+//
+// call with d.mu held
+func (d *suffixDir) _remove(o fs.Object) {
+	i := d._find(o.Remote())
+	if i < len(d.objs) && d.objs[i].Remote() == o.Remote() {
+		d.objs = append(d.objs[:i], d.objs[i+1:]...)
+	}
+}
+
+// checkSuffixNumbered returns an error if --suffix isn't numbered
+func checkSuffixNumbered(ctx context.Context) error {
+	if !SuffixNumbered(ctx) {
+		return errors.Errorf("--suffix %q doesn't contain %s", fs.GetConfig(ctx).Suffix, suffixNumber)
+	}
+	return nil
+}
+
+// SuffixVersions returns the numbered versions of remote found in
+// backupDir, oldest first.
+func SuffixVersions(ctx context.Context, backupDir fs.Fs, remote string) (versions []SuffixVersion, err error) {
+	if err = checkSuffixNumbered(ctx); err != nil {
+		return nil, err
+	}
+	d, err := getSuffixVersionsCache(ctx).dir(ctx, backupDir, remote)
+	if err != nil {
+		return nil, err
+	}
+	defer d.mu.Unlock()
+	return d._versions(ctx, remote), nil
+}
+
+// moveBackupVersion moves dst to the next numbered version in backupDir
+func moveBackupVersion(ctx context.Context, backupDir fs.Fs, dst fs.Object) (err error) {
+	if err = checkSuffixNumbered(ctx); err != nil {
+		return err
+	}
+	remote := dst.Remote()
+	d, err := getSuffixVersionsCache(ctx).dir(ctx, backupDir, remote)
+	if err != nil {
+		return err
+	}
+	defer d.mu.Unlock()
+	versions := d._versions(ctx, remote)
+	n := 1
+	if len(versions) > 0 {
+		n = versions[len(versions)-1].N + 1
+	}
+	newDst, err := Move(ctx, backupDir, nil, SuffixVersionName(ctx, remote, n), dst)
+	if err != nil {
+		return err
+	}
+	if newDst != nil {
+		d._add(newDst)
+	}
+	return nil
+}
+
+// pruneSuffixVersions deletes the oldest numbered versions of remote
+// so no more than --suffix-keep remain
+func pruneSuffixVersions(ctx context.Context, backupDir fs.Fs, remote string) (err error) {
+	ci := fs.GetConfig(ctx)
+	if ci.SuffixKeep <= 0 {
+		return nil
+	}
+	d, err := getSuffixVersionsCache(ctx).dir(ctx, backupDir, remote)
+	if err != nil {
+		return err
+	}
+	defer d.mu.Unlock()
+	versions := d._versions(ctx, remote)
+	for len(versions) > ci.SuffixKeep {
+		err = DeleteFile(ctx, versions[0].Obj)
+		if err != nil {
+			return err
+		}
+		d._remove(versions[0].Obj)
+		versions = versions[1:]
+	}
+	return nil
+}
+
+// RestoreSuffix restores version n of remote made with a numbered
+// --suffix. If n is 0 then the latest version is restored.
+//
+// If remote exists then it is backed up as a new version first.
+func RestoreSuffix(ctx context.Context, f fs.Fs, remote string, n int) (err error) {
+	ctx = WithSuffixVersionsCache(ctx)
+	backupDir, err := BackupDir(ctx, f, f, remote)
+	if err != nil {
+		return err
+	}
+	versions, err := SuffixVersions(ctx, backupDir, remote)
+	if err != nil {
+		return err
+	}
+	if len(versions) == 0 {
+		return errors.Errorf("no versions of %q found", remote)
+	}
+	var version *SuffixVersion
+	if n == 0 {
+		version = &versions[len(versions)-1]
+	} else {
+		for i := range versions {
+			if versions[i].N == n {
+				version = &versions[i]
+				break
+			}
+		}
+		if version == nil {
+			return errors.Errorf("version %d of %q not found", n, remote)
+		}
+	}
+	current, err := f.NewObject(ctx, remote)
+	if err == nil {
+		err = moveBackupVersion(ctx, backupDir, current)
+		if err != nil {
+			return errors.Wrap(err, "failed to back up current version")
+		}
+	} else if err != fs.ErrorObjectNotFound {
+		return err
+	}
+	_, err = Move(ctx, f, nil, remote, version.Obj)
+	if err != nil {
+		return errors.Wrapf(err, "failed to restore version %d", version.N)
+	}
+	d, err := getSuffixVersionsCache(ctx).dir(ctx, backupDir, remote)
+	if err != nil {
+		return err
+	}
+	d._remove(version.Obj)
+	d.mu.Unlock()
+	return pruneSuffixVersions(ctx, backupDir, remote)
+}
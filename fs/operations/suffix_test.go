@@ -0,0 +1,136 @@
+package operations_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pingme998/rclone/fs"
+	"github.com/pingme998/rclone/fs/operations"
+	"github.com/pingme998/rclone/fstest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSuffixVersionName(t *testing.T) {
+	ctx := context.Background()
+	ctx, ci := fs.AddConfig(ctx)
+	for _, test := range []struct {
+		remote  string
+		suffix  string
+		keepExt bool
+		n       int
+		want    string
+	}{
+		{"test.txt", ".~{n}~", false, 1, "test.txt.~1~"},
+		{"test.txt", ".~{n}~", true, 2, "test.~2~.txt"},
+		{"dir/test", "-v{n}", false, 10, "dir/test-v10"},
+		{"dir/test", "-v{n}", true, 10, "dir/test-v10"},
+	} {
+		ci.Suffix = test.suffix
+		ci.SuffixKeepExtension = test.keepExt
+		assert.True(t, operations.SuffixNumbered(ctx))
+		got := operations.SuffixVersionName(ctx, test.remote, test.n)
+		assert.Equal(t, test.want, got, fmt.Sprintf("%+v", test))
+	}
+	ci.Suffix = ".bak"
+	assert.False(t, operations.SuffixNumbered(ctx))
+}
+
+func TestCopyFileSuffixNumbered(t *testing.T) {
+	ctx := context.Background()
+	ctx, ci := fs.AddConfig(ctx)
+	r := fstest.NewRun(t)
+	defer r.Finalise()
+	if !operations.CanServerSideMove(r.Fremote) {
+		t.Skip("Skipping test as remote does not support server-side move or copy")
+	}
+
+	ci.Suffix = ".~{n}~"
+	ci.SuffixKeep = 2
+
+	// Make the file and 3 overwrites of it
+	file := r.WriteObject(ctx, "sub/file", "version 0", t1)
+	for i := 1; i <= 3; i++ {
+		src := r.WriteFile("file", fmt.Sprintf("version %d", i), t2.Add(time.Duration(i)*time.Second))
+		err := operations.CopyFile(ctx, r.Fremote, r.Flocal, "sub/file", "file")
+		require.NoError(t, err)
+		file = src
+		file.Path = "sub/file"
+	}
+	v2 := fstest.NewItem("sub/file.~2~", "version 1", t2.Add(time.Second))
+	v3 := fstest.NewItem("sub/file.~3~", "version 2", t2.Add(2*time.Second))
+	fstest.CheckItems(t, r.Fremote, file, v2, v3)
+
+	versions, err := operations.SuffixVersions(ctx, r.Fremote, "sub/file")
+	require.NoError(t, err)
+	require.Len(t, versions, 2)
+	assert.Equal(t, 2, versions[0].N)
+	assert.Equal(t, 3, versions[1].N)
+
+	// Restore version 2 - the current file becomes version 4 and
+	// version 3 is the only old one kept
+	err = operations.RestoreSuffix(ctx, r.Fremote, "sub/file", 2)
+	require.NoError(t, err)
+	v4 := file
+	v4.Path = "sub/file.~4~"
+	restored := v2
+	restored.Path = "sub/file"
+	fstest.CheckItems(t, r.Fremote, restored, v3, v4)
+
+	// Restore the latest
+	err = operations.RestoreSuffix(ctx, r.Fremote, "sub/file", 0)
+	require.NoError(t, err)
+	v5 := restored
+	v5.Path = "sub/file.~5~"
+	fstest.CheckItems(t, r.Fremote, file, v3, v5)
+
+	err = operations.RestoreSuffix(ctx, r.Fremote, "sub/file", 1)
+	assert.Error(t, err)
+}
+
+// listCounter counts the calls to List
+type listCounter struct {
+	fs.Fs
+	mu    sync.Mutex
+	lists int
+}
+
+func (f *listCounter) List(ctx context.Context, dir string) (fs.DirEntries, error) {
+	f.mu.Lock()
+	f.lists++
+	f.mu.Unlock()
+	return f.Fs.List(ctx, dir)
+}
+
+func TestMoveBackupDirSuffixNumberedListsOnce(t *testing.T) {
+	ctx := context.Background()
+	ctx, ci := fs.AddConfig(ctx)
+	r := fstest.NewRun(t)
+	defer r.Finalise()
+	if !operations.CanServerSideMove(r.Fremote) {
+		t.Skip("Skipping test as remote does not support server-side move or copy")
+	}
+
+	ci.Suffix = ".~{n}~"
+	ci.SuffixKeep = 1
+	ctx = operations.WithSuffixVersionsCache(ctx)
+	backupDir := &listCounter{Fs: r.Fremote}
+
+	var items []fstest.Item
+	for round := 1; round <= 2; round++ {
+		items = items[:0]
+		for _, name := range []string{"a", "b", "c"} {
+			file := r.WriteObject(ctx, "sub/"+name, fmt.Sprintf("%s round %d", name, round), t1)
+			obj, err := r.Fremote.NewObject(ctx, file.Path)
+			require.NoError(t, err)
+			require.NoError(t, operations.MoveBackupDir(ctx, backupDir, obj))
+			file.Path = fmt.Sprintf("sub/%s.~%d~", name, round)
+			items = append(items, file)
+		}
+	}
+	fstest.CheckItems(t, r.Fremote, items...)
+	assert.Equal(t, 1, backupDir.lists)
+}
@@ -0,0 +1,130 @@
+package operations
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"math/rand"
+
+	"github.com/pingme998/rclone/fs"
+	"github.com/pingme998/rclone/fs/accounting"
+	"github.com/pingme998/rclone/fs/fserrors"
+	"github.com/pingme998/rclone/fs/hash"
+	"github.com/pkg/errors"
+)
+
+const (
+	// size of each range read by a spot check
+	spotCheckSize = 64 * 1024
+	// number of ranges read by a spot check
+	spotCheckRanges = 3
+)
+
+// VerifyUpload checks the object at remote in f matches src by
+// reading it back from the remote.
+//
+// If src and f have a hash in common then the hash of the newly read
+// object is compared with src. Otherwise ranges from the start, end
+// and a random place in the middle of the two objects are compared.
+//
+// It returns nil if they match or an error if not.
+func VerifyUpload(ctx context.Context, f fs.Fs, src fs.Object, remote string) (err error) {
+	dst, err := f.NewObject(ctx, remote)
+	if err != nil {
+		return errors.Wrap(err, "failed to read back uploaded object")
+	}
+	if src.Size() >= 0 && dst.Size() >= 0 && src.Size() != dst.Size() {
+		return errors.Errorf("sizes differ %d vs %d", src.Size(), dst.Size())
+	}
+	hashType := src.Fs().Hashes().Overlap(f.Hashes()).GetOne()
+	if hashType != hash.None {
+		srcSum, err := src.Hash(ctx, hashType)
+		if err != nil {
+			return errors.Wrap(err, "failed to read source hash")
+		}
+		dstSum, err := dst.Hash(ctx, hashType)
+		if err != nil {
+			return errors.Wrap(err, "failed to read destination hash")
+		}
+		if srcSum != "" && dstSum != "" {
+			if srcSum != dstSum {
+				return errors.Errorf("%v hash differ %q vs %q", hashType, srcSum, dstSum)
+			}
+			fs.Debugf(dst, "Verified upload: %v = %s OK", hashType, dstSum)
+			return nil
+		}
+	}
+	return spotCheck(ctx, src, dst)
+}
+
+// spotCheck compares a few ranges of src and dst
+func spotCheck(ctx context.Context, src, dst fs.Object) (err error) {
+	size := src.Size()
+	if size < 0 {
+		return errors.New("can't verify an object of unknown size")
+	}
+	var offsets []int64
+	if size <= spotCheckSize*spotCheckRanges {
+		// small enough to check the whole object
+		offsets = append(offsets, 0)
+	} else {
+		offsets = append(offsets, 0, spotCheckSize+rand.Int63n(size-3*spotCheckSize), size-spotCheckSize)
+	}
+	for _, offset := range offsets {
+		option := &fs.RangeOption{Start: offset, End: offset + spotCheckSize - 1}
+		if len(offsets) == 1 {
+			option.End = -1
+		}
+		srcData, err := readRange(ctx, src, option)
+		if err != nil {
+			return errors.Wrap(err, "failed to read source")
+		}
+		dstData, err := readRange(ctx, dst, option)
+		if err != nil {
+			return errors.Wrap(err, "failed to read destination")
+		}
+		if !bytes.Equal(srcData, dstData) {
+			return errors.Errorf("contents differ at offset %d", offset)
+		}
+	}
+	fs.Debugf(dst, "Verified upload: %d ranges OK", len(offsets))
+	return nil
+}
+
+// readRange reads the range given by option from o
+func readRange(ctx context.Context, o fs.Object, option *fs.RangeOption) (data []byte, err error) {
+	in, err := o.Open(ctx, option)
+	if err != nil {
+		return nil, err
+	}
+	defer fs.CheckClose(in, &err)
+	if option.End >= 0 {
+		// some backends ignore the range so limit what we read
+		return ioutil.ReadAll(io.LimitReader(in, option.End-option.Start+1))
+	}
+	return ioutil.ReadAll(in)
+}
+
+// verifyCopy runs VerifyUpload on the object at remote if
+// --verify-uploads is set recording the result in the stats.
+//
+// If verification fails the destination is removed and a retriable
+// error is returned so Copy can upload it again. The error isn't
+// counted here as Copy counts it if it runs out of retries.
+func verifyCopy(ctx context.Context, f fs.Fs, src fs.Object, dst fs.Object, remote string) (err error) {
+	ci := fs.GetConfig(ctx)
+	if !ci.VerifyUploads {
+		return nil
+	}
+	err = VerifyUpload(ctx, f, src, remote)
+	accounting.Stats(ctx).Verifies(1)
+	if err == nil {
+		return nil
+	}
+	accounting.Stats(ctx).VerifyFailures(1)
+	err = errors.Wrap(err, "upload verification failed")
+	fs.Debugf(dst, "%v", err)
+	removeFailedCopy(ctx, dst)
+	return fserrors.RetryError(err)
+}
@@ -0,0 +1,95 @@
+package operations
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/pingme998/rclone/fs"
+	"github.com/pingme998/rclone/fs/accounting"
+	"github.com/pingme998/rclone/fs/fserrors"
+	"github.com/pingme998/rclone/fs/hash"
+	"github.com/pingme998/rclone/fstest/mockfs"
+	"github.com/pingme998/rclone/fstest/mockobject"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyUpload(t *testing.T) {
+	ctx := context.Background()
+	small := []byte("hello world")
+	large := bytes.Repeat([]byte("0123456789"), spotCheckSize)
+	corrupt := func(in []byte, i int) []byte {
+		out := append([]byte(nil), in...)
+		out[i] ^= 0xFF
+		return out
+	}
+	for _, test := range []struct {
+		name    string
+		hashes  hash.Set
+		src     []byte
+		dst     []byte
+		wantErr string
+	}{
+		{"HashOK", hash.Set(hash.MD5), small, small, ""},
+		{"HashDiffer", hash.Set(hash.MD5), small, corrupt(small, 0), "hash differ"},
+		{"SizeDiffer", hash.Set(hash.MD5), small, small[1:], "sizes differ"},
+		{"SpotCheckSmallOK", hash.Set(hash.None), small, small, ""},
+		{"SpotCheckSmallDiffer", hash.Set(hash.None), small, corrupt(small, 5), "contents differ at offset 0"},
+		{"SpotCheckLargeOK", hash.Set(hash.None), large, large, ""},
+		{"SpotCheckLargeDiffer", hash.Set(hash.None), large, corrupt(large, len(large)-1), "contents differ"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			srcFs := mockfs.NewFs(ctx, "src", "")
+			srcFs.SetHashes(test.hashes)
+			dstFs := mockfs.NewFs(ctx, "dst", "")
+			dstFs.SetHashes(test.hashes)
+			src := mockobject.New("file").WithContent(test.src, mockobject.SeekModeNone)
+			srcFs.AddObject(src)
+			dst := mockobject.New("file").WithContent(test.dst, mockobject.SeekModeNone)
+			dstFs.AddObject(dst)
+
+			err := VerifyUpload(ctx, dstFs, src, "file")
+			if test.wantErr == "" {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), test.wantErr)
+			}
+		})
+	}
+}
+
+func TestVerifyUploadNotFound(t *testing.T) {
+	ctx := context.Background()
+	srcFs := mockfs.NewFs(ctx, "src", "")
+	src := mockobject.New("file").WithContent([]byte("hello"), mockobject.SeekModeNone)
+	srcFs.AddObject(src)
+	dstFs := mockfs.NewFs(ctx, "dst", "")
+
+	err := VerifyUpload(ctx, dstFs, src, "file")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to read back uploaded object")
+}
+
+func TestVerifyCopy(t *testing.T) {
+	ctx := context.Background()
+	ctx, ci := fs.AddConfig(ctx)
+	ctx = accounting.WithStatsGroup(ctx, "test-verify-copy")
+	srcFs := mockfs.NewFs(ctx, "src", "")
+	src := mockobject.New("file").WithContent([]byte("hello"), mockobject.SeekModeNone)
+	srcFs.AddObject(src)
+	dstFs := mockfs.NewFs(ctx, "dst", "")
+
+	// Does nothing without --verify-uploads
+	require.NoError(t, verifyCopy(ctx, dstFs, src, nil, "file"))
+	assert.Equal(t, int64(0), accounting.Stats(ctx).GetErrors())
+
+	// A failure is retriable and not counted so Copy can retry it
+	ci.VerifyUploads = true
+	err := verifyCopy(ctx, dstFs, src, nil, "file")
+	require.Error(t, err)
+	assert.True(t, fserrors.IsRetryError(err))
+	assert.Contains(t, err.Error(), "upload verification failed")
+	assert.Equal(t, int64(0), accounting.Stats(ctx).GetErrors())
+}
@@ -0,0 +1,406 @@
+package operations
+
+import (
+	"bufio"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"math/rand"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pingme998/rclone/fs"
+	"github.com/pingme998/rclone/fs/filter"
+	"github.com/pingme998/rclone/fs/fserrors"
+	"github.com/pingme998/rclone/fs/hash"
+	"github.com/pkg/errors"
+)
+
+// Results of verifying a file
+const (
+	VerifyOK       = "ok"       // the hashes match
+	VerifyDiffer   = "differ"   // the hashes differ
+	VerifyMissing  = "missing"  // in the manifest but not the destination
+	VerifyNotFound = "notfound" // in the destination but not the source or manifest
+	VerifyError    = "error"    // there was an error reading or hashing the file
+)
+
+// VerifyOpt contains options for Verify
+type VerifyOpt struct {
+	Fdst          fs.Fs             // fs to verify
+	Fsrc          fs.Fs             // fs to compare with - if nil Manifest is used
+	Manifest      map[string]string // hashes to compare with, indexed by path
+	HashType      hash.Type         // hash to use - hash.None chooses one from Fsrc
+	SamplePercent float64           // percentage of files to read - 0 means all
+	StateFile     string            // file to record progress in so runs can be resumed
+	UseRemoteHash bool              // use Fdst's hashes if supported rather than reading the files
+}
+
+// VerifyProblem describes a file which didn't verify
+type VerifyProblem struct {
+	Path   string
+	Result string
+	Error  string `json:",omitempty"`
+}
+
+// VerifyReport is the audit report produced by Verify
+type VerifyReport struct {
+	Started       time.Time
+	Finished      time.Time
+	Destination   string
+	Source        string `json:",omitempty"`
+	HashType      string
+	SamplePercent float64 `json:",omitempty"`
+	Checked       int64   // files checked in this run
+	Resumed       int64   // files verified OK in previous runs
+	NotSampled    int64   // files skipped by sampling
+	Matched       int64   // files which matched, including Resumed
+	Differ        int64
+	Missing       int64
+	NotFound      int64
+	Errors        int64
+	Problems      []VerifyProblem
+}
+
+// verifyState is a line in the state file
+type verifyState struct {
+	Path   string
+	Size   int64
+	Hash   string
+	Result string
+}
+
+// verifier holds the state while running Verify
+type verifier struct {
+	opt      VerifyOpt
+	mu       sync.Mutex
+	report   VerifyReport
+	seen     map[string]struct{}    // paths seen in the destination if using a manifest
+	previous map[string]verifyState // results of previous runs
+	state    *json.Encoder          // where to write the state or nil
+	fatalErr error                  // set if a fatal error was received
+	sample   func() bool            // returns true if this file should be checked
+}
+
+// ReadHashSums reads a manifest in the format output by md5sum,
+// sha1sum and rclone hashsum returning a map of hashes indexed by path
+func ReadHashSums(in io.Reader) (sums map[string]string, err error) {
+	sums = make(map[string]string)
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(nil, 1024*1024)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := scanner.Text()
+		if line == "" {
+			continue
+		}
+		i := strings.Index(line, " ")
+		if i <= 0 || len(line) < i+3 || (line[i+1] != ' ' && line[i+1] != '*') {
+			return nil, errors.Errorf("line %d: expecting \"hash  path\"", lineNumber)
+		}
+		sums[line[i+2:]] = strings.ToLower(line[:i])
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "failed to read hash sums")
+	}
+	return sums, nil
+}
+
+// readState reads the state file from previous runs if it exists
+func (v *verifier) readState() error {
+	v.previous = make(map[string]verifyState)
+	in, err := os.Open(v.opt.StateFile)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return errors.Wrap(err, "failed to open state file")
+	}
+	defer fs.CheckClose(in, &err)
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(nil, 1024*1024)
+	for scanner.Scan() {
+		var state verifyState
+		if err := json.Unmarshal(scanner.Bytes(), &state); err != nil {
+			// the last line may be truncated if the previous run was interrupted
+			fs.Debugf(nil, "Ignoring bad line in state file: %v", err)
+			continue
+		}
+		v.previous[state.Path] = state
+	}
+	if err = scanner.Err(); err != nil {
+		return errors.Wrap(err, "failed to read state file")
+	}
+	return nil
+}
+
+// record the result of checking remote
+func (v *verifier) record(o fs.Object, result, sum string, err error) {
+	remote := o.Remote()
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	switch result {
+	case VerifyOK:
+		v.report.Matched++
+	case VerifyDiffer:
+		v.report.Differ++
+	case VerifyNotFound:
+		v.report.NotFound++
+	case VerifyError:
+		v.report.Errors++
+	}
+	if result != VerifyOK {
+		problem := VerifyProblem{Path: remote, Result: result}
+		if err != nil {
+			problem.Error = err.Error()
+		}
+		v.report.Problems = append(v.report.Problems, problem)
+	}
+	// Errors aren't recorded so they are retried on the next run
+	if v.state != nil && result != VerifyError {
+		err := v.state.Encode(verifyState{Path: remote, Size: o.Size(), Hash: sum, Result: result})
+		if err != nil {
+			fs.Errorf(o, "Failed to write state file: %v", err)
+		}
+	}
+}
+
+// expectedHash finds the hash the object should have
+func (v *verifier) expectedHash(ctx context.Context, dst fs.Object) (sum string, found bool, err error) {
+	if v.opt.Fsrc == nil {
+		sum, found = v.opt.Manifest[dst.Remote()]
+		return sum, found, nil
+	}
+	src, err := v.opt.Fsrc.NewObject(ctx, dst.Remote())
+	if err == fs.ErrorObjectNotFound {
+		return "", false, nil
+	} else if err != nil {
+		return "", false, err
+	}
+	sum, err = v.hash(ctx, v.opt.Fsrc.Hashes().Contains(v.opt.HashType), src)
+	if err != nil {
+		return "", true, errors.Wrap(err, "failed to read source hash")
+	}
+	return sum, true, nil
+}
+
+// hash reads the hash of o, asking the remote for it if useRemote is
+// set, otherwise or if it isn't available by reading the object
+func (v *verifier) hash(ctx context.Context, useRemote bool, o fs.Object) (sum string, err error) {
+	if useRemote {
+		sum, err = hashSum(ctx, v.opt.HashType, false, o)
+		if err != nil || sum != "" {
+			return sum, err
+		}
+	}
+	return hashSum(ctx, v.opt.HashType, true, o)
+}
+
+// check the object reading its contents
+func (v *verifier) check(ctx context.Context, dst fs.Object) {
+	expected, found, err := v.expectedHash(ctx, dst)
+	if err != nil {
+		err = fs.CountError(err)
+		fs.Errorf(dst, "%v", err)
+		v.record(dst, VerifyError, "", err)
+		return
+	}
+	if !found {
+		err = fs.CountError(errors.New("not found in source"))
+		fs.Errorf(dst, "%v", err)
+		v.record(dst, VerifyNotFound, "", nil)
+		return
+	}
+	useRemote := v.opt.UseRemoteHash && v.opt.Fdst.Hashes().Contains(v.opt.HashType)
+	sum, err := v.hash(ctx, useRemote, dst)
+	if err != nil {
+		if fserrors.IsFatalError(err) {
+			v.mu.Lock()
+			v.fatalErr = err
+			v.mu.Unlock()
+		}
+		err = fs.CountError(err)
+		fs.Errorf(dst, "%v", err)
+		v.record(dst, VerifyError, "", err)
+		return
+	}
+	if !hash.Equals(sum, expected) {
+		err = fs.CountError(errors.Errorf("%v differ", v.opt.HashType))
+		fs.Errorf(dst, "%v", err)
+		v.record(dst, VerifyDiffer, sum, nil)
+		return
+	}
+	fs.Debugf(dst, "OK")
+	v.record(dst, VerifyOK, sum, nil)
+}
+
+// Verify reads the objects in opt.Fdst, recomputing their hashes and
+// comparing them with opt.Fsrc or opt.Manifest.
+//
+// If opt.StateFile is set then files which verified OK in previous
+// runs are skipped so a large remote can be audited over several
+// runs.
+//
+// If opt.UseRemoteHash is set then the hashes stored by opt.Fdst are
+// used where available rather than reading the objects.
+//
+// It returns a report of what was found along with an error if any
+// files failed to verify.
+func Verify(ctx context.Context, opt *VerifyOpt) (report *VerifyReport, err error) {
+	ci := fs.GetConfig(ctx)
+	v := &verifier{opt: *opt}
+	if v.opt.Fsrc == nil && v.opt.Manifest == nil {
+		return nil, errors.New("need a source or a manifest to verify against")
+	}
+	if v.opt.HashType == hash.None {
+		if v.opt.Fsrc == nil {
+			return nil, errors.New("need a hash type to verify against a manifest")
+		}
+		v.opt.HashType = v.opt.Fsrc.Hashes().GetOne()
+		if v.opt.HashType == hash.None {
+			v.opt.HashType = hash.MD5
+		}
+	}
+	if v.opt.SamplePercent < 0 || v.opt.SamplePercent > 100 {
+		return nil, errors.Errorf("sample percentage %g must be between 0 and 100", v.opt.SamplePercent)
+	}
+	v.sample = func() bool { return true }
+	if v.opt.SamplePercent > 0 && v.opt.SamplePercent < 100 {
+		var sampleMu sync.Mutex
+		rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+		v.sample = func() bool {
+			sampleMu.Lock()
+			defer sampleMu.Unlock()
+			return rnd.Float64()*100 < v.opt.SamplePercent
+		}
+	}
+	if v.opt.Fsrc == nil {
+		v.seen = make(map[string]struct{})
+	}
+	v.report = VerifyReport{
+		Started:       time.Now(),
+		Destination:   fs.ConfigString(v.opt.Fdst),
+		HashType:      v.opt.HashType.String(),
+		SamplePercent: v.opt.SamplePercent,
+		Problems:      []VerifyProblem{},
+	}
+	if v.opt.Fsrc != nil {
+		v.report.Source = fs.ConfigString(v.opt.Fsrc)
+	}
+	if v.opt.StateFile != "" {
+		if err = v.readState(); err != nil {
+			return nil, err
+		}
+		out, err := os.OpenFile(v.opt.StateFile, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0666)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to open state file")
+		}
+		defer fs.CheckClose(out, &err)
+		v.state = json.NewEncoder(out)
+	}
+
+	tokens := make(chan struct{}, ci.Transfers)
+	var wg sync.WaitGroup
+	err = ListFn(ctx, v.opt.Fdst, func(o fs.Object) {
+		remote := o.Remote()
+		v.mu.Lock()
+		if v.seen != nil {
+			v.seen[remote] = struct{}{}
+		}
+		fatalErr := v.fatalErr
+		previous, resumed := v.previous[remote]
+		if resumed && previous.Result == VerifyOK && previous.Size == o.Size() {
+			v.report.Resumed++
+			v.report.Matched++
+			v.mu.Unlock()
+			return
+		}
+		v.mu.Unlock()
+		if fatalErr != nil {
+			return
+		}
+		if !v.sample() {
+			v.mu.Lock()
+			v.report.NotSampled++
+			v.mu.Unlock()
+			return
+		}
+		v.mu.Lock()
+		v.report.Checked++
+		v.mu.Unlock()
+		wg.Add(1)
+		tokens <- struct{}{}
+		go func() {
+			defer func() {
+				<-tokens
+				wg.Done()
+			}()
+			v.check(ctx, o)
+		}()
+	})
+	wg.Wait()
+	if err == nil {
+		err = v.fatalErr
+	}
+
+	// Files in the manifest which aren't in the destination
+	if v.seen != nil && err == nil {
+		fi := filter.GetConfig(ctx)
+		var missing []string
+		for remote := range v.opt.Manifest {
+			if _, found := v.seen[remote]; !found && fi.Include(remote, 0, time.Now()) {
+				missing = append(missing, remote)
+			}
+		}
+		sort.Strings(missing)
+		for _, remote := range missing {
+			fs.Errorf(remote, "%v", fs.CountError(errors.Errorf("file not in %v", v.opt.Fdst)))
+			v.report.Missing++
+			v.report.Problems = append(v.report.Problems, VerifyProblem{Path: remote, Result: VerifyMissing})
+		}
+	}
+
+	sort.Slice(v.report.Problems, func(i, j int) bool {
+		return v.report.Problems[i].Path < v.report.Problems[j].Path
+	})
+	v.report.Finished = time.Now()
+	report = &v.report
+
+	fs.Logf(v.opt.Fdst, "%d files checked, %d verified in previous runs, %d not sampled", report.Checked, report.Resumed, report.NotSampled)
+	fs.Logf(v.opt.Fdst, "%d matching files", report.Matched)
+	if err != nil {
+		return report, err
+	}
+	if problems := len(report.Problems); problems > 0 {
+		// Return an already counted error so we don't double count this error too
+		err = fserrors.FsError(errors.Errorf("%d files failed to verify", problems))
+		fserrors.Count(err)
+		return report, err
+	}
+	return report, nil
+}
+
+// SignVerifyReport encodes the report as JSON and signs it with an
+// HMAC-SHA256 using key, returning the JSON and the hex encoded
+// signature.
+//
+// If key is empty then no signature is returned.
+func SignVerifyReport(report *VerifyReport, key string) (data []byte, signature string, err error) {
+	data, err = json.MarshalIndent(report, "", "\t")
+	if err != nil {
+		return nil, "", errors.Wrap(err, "failed to encode report")
+	}
+	data = append(data, '\n')
+	if key == "" {
+		return data, "", nil
+	}
+	mac := hmac.New(sha256.New, []byte(key))
+	_, _ = mac.Write(data)
+	return data, hex.EncodeToString(mac.Sum(nil)), nil
+}
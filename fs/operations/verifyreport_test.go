@@ -0,0 +1,136 @@
+package operations_test
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pingme998/rclone/fs/hash"
+	"github.com/pingme998/rclone/fs/operations"
+	"github.com/pingme998/rclone/fstest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadHashSums(t *testing.T) {
+	sums, err := operations.ReadHashSums(strings.NewReader(`d41d8cd98f00b204e9800998ecf8427e  empty
+5D41402ABC4B2A76B9719D911017C592  dir/hello world
+
+c1a5298f939e87e8f962a5edfc206918 *binary
+`))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"empty":           "d41d8cd98f00b204e9800998ecf8427e",
+		"dir/hello world": "5d41402abc4b2a76b9719d911017c592",
+		"binary":          "c1a5298f939e87e8f962a5edfc206918",
+	}, sums)
+
+	for _, bad := range []string{"nospace", " path", "hash path", "hash  "} {
+		_, err = operations.ReadHashSums(strings.NewReader(bad))
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	ctx := context.Background()
+	r := fstest.NewRun(t)
+	defer r.Finalise()
+	r.WriteFile("ok", "hello", t1)
+	r.WriteFile("differ", "hello", t1)
+	r.WriteObject(ctx, "ok", "hello", t1)
+	r.WriteObject(ctx, "differ", "HELLO", t1)
+	r.WriteObject(ctx, "extra", "extra", t1)
+
+	// against the source
+	report, err := operations.Verify(ctx, &operations.VerifyOpt{
+		Fdst:     r.Fremote,
+		Fsrc:     r.Flocal,
+		HashType: hash.MD5,
+	})
+	require.Error(t, err)
+	assert.Equal(t, int64(3), report.Checked)
+	assert.Equal(t, int64(1), report.Matched)
+	assert.Equal(t, int64(1), report.Differ)
+	assert.Equal(t, int64(1), report.NotFound)
+	assert.Equal(t, []operations.VerifyProblem{
+		{Path: "differ", Result: operations.VerifyDiffer},
+		{Path: "extra", Result: operations.VerifyNotFound},
+	}, report.Problems)
+
+	// against a manifest
+	manifest := map[string]string{
+		"ok":      "5d41402abc4b2a76b9719d911017c592",
+		"differ":  "5d41402abc4b2a76b9719d911017c592",
+		"extra":   "ea9f91b2cda019730f2891bd12a7a4d6",
+		"missing": "5d41402abc4b2a76b9719d911017c592",
+	}
+	stateFile := filepath.Join(t.TempDir(), "state")
+	opt := &operations.VerifyOpt{
+		Fdst:      r.Fremote,
+		Manifest:  manifest,
+		HashType:  hash.MD5,
+		StateFile: stateFile,
+	}
+	report, err = operations.Verify(ctx, opt)
+	require.Error(t, err)
+	assert.Equal(t, int64(3), report.Checked)
+	assert.Equal(t, int64(0), report.Resumed)
+	assert.Equal(t, int64(2), report.Matched)
+	assert.Equal(t, int64(1), report.Differ)
+	assert.Equal(t, int64(1), report.Missing)
+	assert.Equal(t, []operations.VerifyProblem{
+		{Path: "differ", Result: operations.VerifyDiffer},
+		{Path: "missing", Result: operations.VerifyMissing},
+	}, report.Problems)
+
+	// resuming only reads the files which didn't verify
+	manifest["differ"] = "eb61eead90e3b899c6bcbe27ac581660"
+	delete(manifest, "missing")
+	report, err = operations.Verify(ctx, opt)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), report.Checked)
+	assert.Equal(t, int64(2), report.Resumed)
+	assert.Equal(t, int64(3), report.Matched)
+	assert.Equal(t, []operations.VerifyProblem{}, report.Problems)
+
+	// sampling nothing new to read
+	report, err = operations.Verify(ctx, &operations.VerifyOpt{
+		Fdst:          r.Fremote,
+		Manifest:      manifest,
+		HashType:      hash.MD5,
+		SamplePercent: 1e-9,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), report.Checked)
+	assert.Equal(t, int64(3), report.NotSampled)
+
+	// bad options
+	_, err = operations.Verify(ctx, &operations.VerifyOpt{Fdst: r.Fremote})
+	assert.Error(t, err)
+	_, err = operations.Verify(ctx, &operations.VerifyOpt{Fdst: r.Fremote, Manifest: manifest})
+	assert.Error(t, err)
+	_, err = operations.Verify(ctx, &operations.VerifyOpt{Fdst: r.Fremote, Fsrc: r.Flocal, SamplePercent: 101})
+	assert.Error(t, err)
+}
+
+func TestSignVerifyReport(t *testing.T) {
+	report := &operations.VerifyReport{Destination: "remote:", HashType: "md5", Matched: 1}
+
+	data, signature, err := operations.SignVerifyReport(report, "")
+	require.NoError(t, err)
+	assert.Equal(t, "", signature)
+	var decoded operations.VerifyReport
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, *report, decoded)
+
+	data, signature, err = operations.SignVerifyReport(report, "secret")
+	require.NoError(t, err)
+	mac := hmac.New(sha256.New, []byte("secret"))
+	_, _ = mac.Write(data)
+	assert.Equal(t, hex.EncodeToString(mac.Sum(nil)), signature)
+}
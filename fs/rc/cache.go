@@ -5,6 +5,7 @@ package rc
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/pingme998/rclone/fs"
 	"github.com/pingme998/rclone/fs/cache"
@@ -100,14 +101,44 @@ are cached for a short while to make repeated rc calls more efficient.
 If you change the parameters of a backend then you may want to call
 this to clear an existing remote out of the cache before re-creating
 it.
+
+Parameters
+- fs - optional, remove only this remote, e.g. "drive:path/to/dir"
+- name - optional, remove all the remotes made from this config name, e.g. "drive"
+
+If neither fs nor name is supplied then the whole cache is cleared,
+including pinned entries.
+
+Returns
+- deleted - number of entries removed if fs or name was supplied
 `,
 	})
 }
 
 // Clear the fs cache
 func rcCacheClear(ctx context.Context, in Params) (out Params, err error) {
-	cache.Clear()
-	return nil, nil
+	fsString, err := in.GetString("fs")
+	if err != nil && !IsErrParamNotFound(err) {
+		return nil, err
+	}
+	name, err := in.GetString("name")
+	if err != nil && !IsErrParamNotFound(err) {
+		return nil, err
+	}
+	if fsString == "" && name == "" {
+		cache.Clear()
+		return nil, nil
+	}
+	deleted := 0
+	if fsString != "" && cache.Delete(fsString) {
+		deleted++
+	}
+	if name != "" {
+		deleted += cache.ClearConfig(name)
+	}
+	return Params{
+		"deleted": deleted,
+	}, nil
 }
 
 func init() {
@@ -131,3 +162,43 @@ func rcCacheEntries(ctx context.Context, in Params) (out Params, err error) {
 		"entries": cache.Entries(),
 	}, nil
 }
+
+func init() {
+	Add(Call{
+		Path:         "fscache/list",
+		Fn:           rcCacheList,
+		Title:        "Lists the entries in the fs cache.",
+		AuthRequired: true,
+		Help: `
+This lists the remotes in the fs cache. This is useful to see which
+remotes a long running rclone rcd has accumulated, for example from
+on the fly remotes, before removing them with fscache/clear.
+
+Returns
+- entries - array of
+    - fs - name of the remote
+    - isFile - true if the remote was created pointing to a file
+    - lastUsed - time the remote was last used
+    - age - seconds since the remote was last used
+    - pinCount - number of pins on the remote - pinned remotes aren't expired
+`,
+	})
+}
+
+// List the entries in the fs cache
+func rcCacheList(ctx context.Context, in Params) (out Params, err error) {
+	now := time.Now()
+	entries := []Params{}
+	for _, entry := range cache.List() {
+		entries = append(entries, Params{
+			"fs":       entry.Name,
+			"isFile":   entry.IsFile,
+			"lastUsed": entry.LastUsed,
+			"age":      now.Sub(entry.LastUsed).Seconds(),
+			"pinCount": entry.PinCount,
+		})
+	}
+	return Params{
+		"entries": entries,
+	}, nil
+}
@@ -192,6 +192,41 @@ func TestGetFsAndRemote(t *testing.T) {
 			assert.NotEqual(t, 0, getEntries())
 		})
 
+		t.Run("List", func(t *testing.T) {
+			call := Calls.Get("fscache/list")
+			require.NotNil(t, call)
+
+			out, err := call.Fn(context.Background(), Params{})
+			require.NoError(t, err)
+			entries := out["entries"].([]Params)
+			require.Equal(t, getEntries(), len(entries))
+			assert.Equal(t, "mock:mock", entries[0]["fs"])
+			assert.Equal(t, false, entries[0]["isFile"])
+			assert.Equal(t, 0, entries[0]["pinCount"])
+			assert.True(t, entries[0]["age"].(float64) >= 0)
+		})
+
+		t.Run("ClearFs", func(t *testing.T) {
+			call := Calls.Get("fscache/clear")
+			require.NotNil(t, call)
+
+			before := getEntries()
+			out, err := call.Fn(context.Background(), Params{"fs": "mock:/notfound"})
+			require.NoError(t, err)
+			assert.Equal(t, Params{"deleted": 0}, out)
+			assert.Equal(t, before, getEntries())
+
+			out, err = call.Fn(context.Background(), Params{"name": "notfound"})
+			require.NoError(t, err)
+			assert.Equal(t, Params{"deleted": 0}, out)
+			assert.Equal(t, before, getEntries())
+
+			out, err = call.Fn(context.Background(), Params{"fs": "/"})
+			require.NoError(t, err)
+			assert.Equal(t, Params{"deleted": 1}, out)
+			assert.Equal(t, before-1, getEntries())
+		})
+
 		t.Run("Clear", func(t *testing.T) {
 			call := Calls.Get("fscache/clear")
 			require.NotNil(t, call)
@@ -3,11 +3,15 @@
 package rc
 
 import (
+	"bytes"
 	"context"
+	"fmt"
+	"io"
 	"net/http"
 	"os"
 	"os/exec"
 	"runtime"
+	"runtime/pprof"
 	"strings"
 	"time"
 
@@ -16,6 +20,7 @@ import (
 
 	"github.com/pingme998/rclone/fs"
 	"github.com/pingme998/rclone/fs/config/obscure"
+	"github.com/pingme998/rclone/fs/fserrors"
 	"github.com/pingme998/rclone/lib/atexit"
 	"github.com/pingme998/rclone/lib/buildinfo"
 )
@@ -346,6 +351,295 @@ func rcSetBlockProfileRate(ctx context.Context, in Params) (out Params, err erro
 	return nil, nil
 }
 
+// writeAttachment writes the output of write to the HTTP response as
+// an attachment called name if there is one, otherwise it returns it
+// as "name" and "data".
+//
+// The output is buffered so errors can still be returned as JSON.
+func writeAttachment(in Params, name, contentType string, write func(w io.Writer) error) (out Params, err error) {
+	var buf bytes.Buffer
+	err = write(&buf)
+	if err != nil {
+		return nil, err
+	}
+	w, err := in.GetHTTPResponseWriter()
+	if IsErrParamNotFound(err) {
+		// Not called over HTTP, e.g. with --loopback
+		out = Params{"name": name}
+		if strings.HasPrefix(contentType, "text/") {
+			out["data"] = buf.String()
+		} else {
+			out["data"] = buf.Bytes()
+		}
+		return out, nil
+	} else if err != nil {
+		return nil, err
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name))
+	_, err = w.Write(buf.Bytes())
+	return nil, err
+}
+
+// sleepContext sleeps for d or until ctx is cancelled
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// getProfileSeconds reads the seconds parameter returning def if not
+// found
+func getProfileSeconds(in Params, def int64) (time.Duration, error) {
+	seconds, err := in.GetInt64("seconds")
+	if IsErrParamNotFound(err) {
+		seconds = def
+	} else if err != nil {
+		return 0, err
+	}
+	if seconds < 0 {
+		return 0, errors.Errorf("seconds must not be negative, got %d", seconds)
+	}
+	return time.Duration(seconds) * time.Second, nil
+}
+
+func init() {
+	Add(Call{
+		Path:          "debug/pprof",
+		AuthRequired:  true,
+		Fn:            rcPprof,
+		NeedsResponse: true,
+		Title:         "Return a pprof profile of rclone.",
+		Help: `
+This returns a profile of the running rclone as an attachment in the
+same format as the profiles served on /debug/pprof/ by the rc server,
+so it can be used where that isn't available, e.g. when the rc only
+listens on a unix socket or the profiles are needed from a mount
+which seems to have hung.
+
+Parameters
+
+- profile - name of the profile, one of cpu, goroutine, heap, allocs, threadcreate, block or mutex
+- seconds - for the cpu profile, how long to profile for (optional, default 30)
+- debug - 0 for the binary format (default) or 1 or 2 for text formats (optional)
+
+For example
+
+    rclone rc debug/pprof profile=heap > heap.pprof
+    go tool pprof heap.pprof
+
+Over HTTP the profile is the body of the response. When called
+without HTTP, e.g. with --loopback, the results are
+
+- name - the file name of the profile
+- data - the profile
+
+Note that the block and mutex profiles are empty unless profiling has
+been turned on with debug/set-block-profile-rate or
+debug/set-mutex-profile-fraction.
+`,
+	})
+}
+
+// Returns a pprof profile
+func rcPprof(ctx context.Context, in Params) (out Params, err error) {
+	profile, err := in.GetString("profile")
+	if err != nil {
+		return nil, err
+	}
+	debug, err := in.GetInt64("debug")
+	if NotErrParamNotFound(err) {
+		return nil, err
+	}
+	name, contentType := profile+".pprof", "application/octet-stream"
+	if debug > 0 {
+		name, contentType = profile+".txt", "text/plain; charset=utf-8"
+	}
+	if profile == "cpu" {
+		d, err := getProfileSeconds(in, 30)
+		if err != nil {
+			return nil, err
+		}
+		return writeAttachment(in, name, contentType, func(w io.Writer) error {
+			err := pprof.StartCPUProfile(w)
+			if err != nil {
+				return errors.Wrap(err, "failed to start CPU profile")
+			}
+			err = sleepContext(ctx, d)
+			pprof.StopCPUProfile()
+			return err
+		})
+	}
+	p := pprof.Lookup(profile)
+	if p == nil {
+		return nil, errors.Errorf("unknown profile %q", profile)
+	}
+	return writeAttachment(in, name, contentType, func(w io.Writer) error {
+		return p.WriteTo(w, int(debug))
+	})
+}
+
+func init() {
+	Add(Call{
+		Path:          "debug/goroutines",
+		AuthRequired:  true,
+		Fn:            rcGoroutines,
+		NeedsResponse: true,
+		Title:         "Return the stack traces of all the goroutines.",
+		Help: `
+This returns the stack traces of all the goroutines in the running
+rclone as a text attachment. This is the first thing to look at when
+diagnosing a hang, e.g. of a mount, as it shows what each goroutine is
+waiting for and for how long.
+
+For example
+
+    rclone rc debug/goroutines > goroutines.txt
+
+When called without HTTP, e.g. with --loopback, the results are
+
+- name - the file name of the stack traces
+- data - the stack traces
+`,
+	})
+}
+
+// Returns the stack traces of all the goroutines
+func rcGoroutines(ctx context.Context, in Params) (out Params, err error) {
+	return writeAttachment(in, "goroutines.txt", "text/plain; charset=utf-8", func(w io.Writer) error {
+		return pprof.Lookup("goroutine").WriteTo(w, 2)
+	})
+}
+
+func init() {
+	Add(Call{
+		Path:          "debug/blockprofile",
+		AuthRequired:  true,
+		Fn:            rcBlockProfile,
+		NeedsResponse: true,
+		Title:         "Return a profile of where goroutines block.",
+		Help: `
+This turns on block profiling for the number of seconds given, then
+returns the block profile as an attachment and turns block profiling
+off again. This shows where goroutines wait on locks and channels,
+which is useful for finding what is holding up a slow or hung mount.
+
+Parameters
+
+- seconds - how long to profile for (optional, default 30)
+- rate - the block profile rate to use, as in debug/set-block-profile-rate (optional, default 1)
+- debug - 0 for the binary format (default) or 1 for text (optional)
+
+If seconds is 0 the block profile is returned straight away without
+changing the rate, which is useful if block profiling has been turned
+on with debug/set-block-profile-rate.
+
+For example
+
+    rclone rc debug/blockprofile seconds=10 > block.pprof
+    go tool pprof block.pprof
+
+When called without HTTP, e.g. with --loopback, the results are
+
+- name - the file name of the profile
+- data - the profile
+`,
+	})
+}
+
+// Returns a block profile
+func rcBlockProfile(ctx context.Context, in Params) (out Params, err error) {
+	d, err := getProfileSeconds(in, 30)
+	if err != nil {
+		return nil, err
+	}
+	rate, err := in.GetInt64("rate")
+	if IsErrParamNotFound(err) {
+		rate = 1
+	} else if err != nil {
+		return nil, err
+	}
+	debug, err := in.GetInt64("debug")
+	if NotErrParamNotFound(err) {
+		return nil, err
+	}
+	name, contentType := "block.pprof", "application/octet-stream"
+	if debug > 0 {
+		name, contentType = "block.txt", "text/plain; charset=utf-8"
+	}
+	return writeAttachment(in, name, contentType, func(w io.Writer) error {
+		if d > 0 {
+			runtime.SetBlockProfileRate(int(rate))
+			err := sleepContext(ctx, d)
+			runtime.SetBlockProfileRate(0)
+			if err != nil {
+				return err
+			}
+		}
+		return pprof.Lookup("block").WriteTo(w, int(debug))
+	})
+}
+
+func init() {
+	Add(Call{
+		Path:  "debug/retry-decisions",
+		Fn:    rcRetryDecisions,
+		Title: "Show how recent errors were classified for retrying.",
+		Help: `
+This shows how the errors returned by the remotes were classified,
+whether they were retried and which retry rule decided, if any. This
+is useful when writing rules with --retry-rule or the retry_rules
+config file option.
+
+The last 100 errors classified are returned, oldest first.
+
+Parameters
+
+- clear - set to true to forget the errors after returning them (optional)
+
+Results
+
+- rules - the --retry-rule rules in use
+- decisions - a list of errors each with
+    - time - when the error happened
+    - remote - the name of the remote it came from
+    - error - the error
+    - status - the HTTP status code of the error if known
+    - default - whether the backend wanted to retry it
+    - retry - whether it was retried
+    - rule - the rule which decided, if any
+`,
+	})
+}
+
+// Returns the recent retry decisions
+func rcRetryDecisions(ctx context.Context, in Params) (out Params, err error) {
+	clearDecisions, err := in.GetBool("clear")
+	if NotErrParamNotFound(err) {
+		return nil, err
+	}
+	rules := []string{}
+	for _, rule := range fs.GetConfig(ctx).RetryRules {
+		rules = append(rules, rule.String())
+	}
+	decisions := fserrors.RetryDecisions()
+	if decisions == nil {
+		decisions = []fserrors.RetryDecision{}
+	}
+	if clearDecisions {
+		fserrors.ResetRetryDecisions()
+	}
+	return Params{
+		"rules":     rules,
+		"decisions": decisions,
+	}, nil
+}
+
 func init() {
 	Add(Call{
 		Path:          "core/command",
@@ -196,3 +196,71 @@ func TestCoreCommand(t *testing.T) {
 		test("unknown_command", "STREAM", version+errorString, true)
 	})
 }
+
+func TestRcPprof(t *testing.T) {
+	call := Calls.Get("debug/pprof")
+	assert.NotNil(t, call)
+
+	// Over HTTP the profile is an attachment
+	rec := httptest.NewRecorder()
+	out, err := call.Fn(context.Background(), Params{
+		"profile":   "goroutine",
+		"debug":     1,
+		"_response": http.ResponseWriter(rec),
+	})
+	require.NoError(t, err)
+	assert.Nil(t, out)
+	assert.Equal(t, `attachment; filename="goroutine.txt"`, rec.Header().Get("Content-Disposition"))
+	assert.Contains(t, rec.Body.String(), "goroutine profile:")
+
+	// Otherwise it is returned
+	out, err = call.Fn(context.Background(), Params{
+		"profile": "heap",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "heap.pprof", out["name"])
+	assert.NotEmpty(t, out["data"])
+
+	out, err = call.Fn(context.Background(), Params{
+		"profile": "cpu",
+		"seconds": 0,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "cpu.pprof", out["name"])
+
+	_, err = call.Fn(context.Background(), Params{
+		"profile": "potato",
+	})
+	assert.Error(t, err)
+	_, err = call.Fn(context.Background(), Params{})
+	assert.Error(t, err)
+}
+
+func TestRcGoroutines(t *testing.T) {
+	call := Calls.Get("debug/goroutines")
+	assert.NotNil(t, call)
+	out, err := call.Fn(context.Background(), Params{})
+	require.NoError(t, err)
+	assert.Equal(t, "goroutines.txt", out["name"])
+	assert.Contains(t, out["data"], "TestRcGoroutines")
+}
+
+func TestRcBlockProfile(t *testing.T) {
+	call := Calls.Get("debug/blockprofile")
+	assert.NotNil(t, call)
+	out, err := call.Fn(context.Background(), Params{
+		"seconds": 0,
+		"debug":   1,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "block.txt", out["name"])
+	assert.Contains(t, out["data"], "contention")
+
+	// Stops early if the context is cancelled
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = call.Fn(ctx, Params{
+		"seconds": 10,
+	})
+	assert.Equal(t, context.Canceled, err)
+}
@@ -28,6 +28,8 @@ type Job struct {
 	Finished  bool      `json:"finished"`
 	Success   bool      `json:"success"`
 	Duration  float64   `json:"duration"`
+	Async     bool      `json:"async"`
+	TraceID   string    `json:"traceId"`
 	Output    rc.Params `json:"output"`
 	Stop      func()    `json:"-"`
 	listeners []*func()
@@ -213,6 +215,15 @@ func getConfig(ctx context.Context, in rc.Params) (context.Context, error) {
 	if err != nil {
 		return ctx, err
 	}
+	// If BwLimit is set then give the job a bandwidth limit of its own
+	var keys map[string]interface{}
+	err = in.GetStruct("_config", &keys)
+	if err != nil {
+		return ctx, err
+	}
+	if _, found := keys["BwLimit"]; found {
+		ctx = accounting.WithBwLimit(ctx, ci.BwLimit)
+	}
 	delete(in, "_config") // remove the parameter
 	return ctx, nil
 }
@@ -263,6 +274,8 @@ func (jobs *Jobs) NewJob(ctx context.Context, fn rc.Func, in rc.Params) (job *Jo
 		return nil, nil, err
 	}
 
+	// Give the job its own span so its JSON logs can be found
+	ctx = fs.StartLogSpan(ctx)
 	ctx, cancel := context.WithCancel(ctx)
 	stop := func() {
 		cancel()
@@ -273,6 +286,8 @@ func (jobs *Jobs) NewJob(ctx context.Context, fn rc.Func, in rc.Params) (job *Jo
 		ID:        id,
 		Group:     group,
 		StartTime: time.Now(),
+		Async:     isAsync,
+		TraceID:   fs.GetLogSpan(ctx).TraceID,
 		Stop:      stop,
 	}
 	jobs.mu.Lock()
@@ -328,9 +343,11 @@ Results
 - endTime - time the job finished (e.g. "2018-10-26T18:50:20.528746884+01:00")
 - error - error from the job or empty string for no error
 - finished - boolean whether the job has finished or not
+- async - boolean whether the job was started with _async
 - id - as passed in above
 - startTime - time the job started (e.g. "2018-10-26T18:50:20.528336039+01:00")
 - success - boolean - true for success false otherwise
+- traceId - the trace_id of the JSON logs made by the job
 - output - output of the job as would have been returned if called synchronously
 - progress - output of the progress related to the underlying job
 `,
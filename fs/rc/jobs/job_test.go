@@ -1,7 +1,10 @@
 package jobs
 
 import (
+	"bytes"
 	"context"
+	"io"
+	"io/ioutil"
 	"runtime"
 	"testing"
 	"time"
@@ -281,6 +284,57 @@ func TestExecuteJobWithConfig(t *testing.T) {
 	assert.NotEqual(t, 42*fs.Mebi, ci.BufferSize)
 }
 
+func TestExecuteJobLogSpan(t *testing.T) {
+	jobID = 0
+	var span *fs.LogSpan
+	job, _, err := NewJob(context.Background(), func(ctx context.Context, in rc.Params) (rc.Params, error) {
+		span = fs.GetLogSpan(ctx)
+		return nil, nil
+	}, rc.Params{})
+	require.NoError(t, err)
+	require.NotNil(t, span)
+	assert.Equal(t, span.TraceID, job.TraceID)
+}
+
+func TestExecuteJobWithBwLimit(t *testing.T) {
+	ctx := context.Background()
+	jobID = 0
+	// read n bytes through an Account made with ctx returning how long it took
+	timeRead := func(ctx context.Context, n int) time.Duration {
+		tr := accounting.Stats(ctx).NewTransferRemoteSize("test", int64(n))
+		defer tr.Done(ctx, nil)
+		in := tr.Account(ctx, ioutil.NopCloser(bytes.NewReader(make([]byte, n))))
+		start := time.Now()
+		_, err := io.Copy(ioutil.Discard, in)
+		require.NoError(t, err)
+		return time.Since(start)
+	}
+	var elapsed time.Duration
+	jobFn := func(ctx context.Context, in rc.Params) (rc.Params, error) {
+		elapsed = timeRead(ctx, 20*1024)
+		return nil, nil
+	}
+
+	// 20k at 100k/s should take 200ms
+	_, _, err := NewJob(ctx, jobFn, rc.Params{
+		"_config": rc.Params{
+			"BwLimit": "100k",
+		},
+	})
+	require.NoError(t, err)
+	assert.True(t, elapsed >= 150*time.Millisecond, "elapsed = %v", elapsed)
+
+	// Other jobs and the global limit aren't affected
+	_, _, err = NewJob(ctx, jobFn, rc.Params{
+		"_config": rc.Params{
+			"BufferSize": "42M",
+		},
+	})
+	require.NoError(t, err)
+	assert.True(t, elapsed < 150*time.Millisecond, "elapsed = %v", elapsed)
+	assert.True(t, timeRead(ctx, 20*1024) < 150*time.Millisecond)
+}
+
 func TestExecuteJobWithFilter(t *testing.T) {
 	ctx := context.Background()
 	called := false
@@ -348,6 +402,7 @@ func TestRcJobStatus(t *testing.T) {
 	assert.Equal(t, "", out["error"])
 	assert.Equal(t, false, out["finished"])
 	assert.Equal(t, false, out["success"])
+	assert.Len(t, out["traceId"], 32)
 
 	in = rc.Params{"jobid": 123123123}
 	_, err = call.Fn(context.Background(), in)
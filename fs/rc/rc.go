@@ -30,6 +30,7 @@ type Options struct {
 	WebGUIFetchURL           string // set the default url for fetching webgui
 	AccessControlAllowOrigin string // set the access control for CORS configuration
 	EnableMetrics            bool   // set to disable prometheus metrics on /metrics
+	MetricsAddr              string // if set serve prometheus metrics on this address
 	JobExpireDuration        time.Duration
 	JobExpireInterval        time.Duration
 }
@@ -44,6 +45,9 @@ var DefaultOpt = Options{
 
 func init() {
 	DefaultOpt.HTTPOptions.ListenAddr = "localhost:5572"
+	// Leave the sockets passed in by systemd for the command being
+	// run - rclone rcd turns this back on
+	DefaultOpt.HTTPOptions.SocketActivation = false
 }
 
 // WriteJSON writes JSON in out to w
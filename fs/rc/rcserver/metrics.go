@@ -0,0 +1,48 @@
+package rcserver
+
+import (
+	"net"
+	"net/http"
+	"sync"
+
+	"github.com/pingme998/rclone/fs"
+	"github.com/pingme998/rclone/lib/atexit"
+	"github.com/pkg/errors"
+)
+
+// The metrics server is started at most once per process as Start is
+// called for every command and again by rcd.
+var (
+	metricsMu     sync.Mutex
+	metricsServer *http.Server
+	metricsAddr   net.Addr
+)
+
+// startMetrics serves the prometheus metrics on /metrics at addr
+// unless that has been done already
+func startMetrics(addr string) error {
+	metricsMu.Lock()
+	defer metricsMu.Unlock()
+	if metricsServer != nil {
+		return nil
+	}
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return errors.Wrap(err, "failed to start metrics server")
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promHandler)
+	metricsServer = &http.Server{Handler: mux}
+	metricsAddr = listener.Addr()
+	go func() {
+		err := metricsServer.Serve(listener)
+		if err != nil && err != http.ErrServerClosed {
+			fs.Errorf(nil, "Metrics server failed: %v", err)
+		}
+	}()
+	atexit.Register(func() {
+		_ = metricsServer.Close()
+	})
+	fs.Logf(nil, "Serving prometheus metrics on http://%s/metrics", metricsAddr)
+	return nil
+}
@@ -0,0 +1,32 @@
+package rcserver
+
+import (
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStartMetrics(t *testing.T) {
+	require.NoError(t, startMetrics("localhost:0"))
+	addr := metricsAddr
+	// starting again is a no-op
+	require.NoError(t, startMetrics("localhost:0"))
+	assert.Equal(t, addr, metricsAddr)
+
+	resp, err := http.Get("http://" + addr.String() + "/metrics")
+	require.NoError(t, err)
+	body, err := ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, string(body), "rclone_bytes_transferred_total")
+	assert.Contains(t, string(body), "rclone_http_requests_total")
+
+	resp, err = http.Get("http://" + addr.String() + "/other")
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
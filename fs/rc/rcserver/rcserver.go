@@ -14,6 +14,7 @@ import (
 	"path/filepath"
 	"regexp"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -30,12 +31,14 @@ import (
 	"github.com/pingme998/rclone/fs/accounting"
 	"github.com/pingme998/rclone/fs/cache"
 	"github.com/pingme998/rclone/fs/config"
+	"github.com/pingme998/rclone/fs/fshttp"
 	"github.com/pingme998/rclone/fs/list"
 	"github.com/pingme998/rclone/fs/rc"
 	"github.com/pingme998/rclone/fs/rc/jobs"
 	"github.com/pingme998/rclone/fs/rc/rcflags"
 	"github.com/pingme998/rclone/lib/http/serve"
 	"github.com/pingme998/rclone/lib/random"
+	"github.com/pingme998/rclone/vfs"
 )
 
 var promHandler http.Handler
@@ -44,6 +47,8 @@ var onlyOnceWarningAllowOrigin sync.Once
 func init() {
 	rcloneCollector := accounting.NewRcloneCollector(context.Background())
 	prometheus.MustRegister(rcloneCollector)
+	prometheus.MustRegister(fshttp.NewTransportCollector())
+	prometheus.MustRegister(vfs.NewCollector())
 	promHandler = promhttp.Handler()
 }
 
@@ -52,6 +57,11 @@ func init() {
 // If the server wasn't configured the *Server returned may be nil
 func Start(ctx context.Context, opt *rc.Options) (*Server, error) {
 	jobs.SetOpt(opt) // set the defaults for jobs
+	if opt.MetricsAddr != "" {
+		if err := startMetrics(opt.MetricsAddr); err != nil {
+			return nil, err
+		}
+	}
 	if opt.Enabled {
 		// Serve on the DefaultServeMux so can have global registrations appear
 		s := newServer(ctx, opt, http.DefaultServeMux)
@@ -280,6 +290,12 @@ func (s *Server) handlePost(w http.ResponseWriter, r *http.Request, path string)
 		writeError(path, inOrig, w, err, http.StatusInternalServerError)
 		return
 	}
+	// Calls which reply with an attachment, e.g. debug/pprof, have
+	// written the response already
+	if out == nil && strings.HasPrefix(w.Header().Get("Content-Disposition"), "attachment") {
+		fs.Debugf(nil, "rc: %q: reply with attachment", path)
+		return
+	}
 	if out == nil {
 		out = make(rc.Params)
 	}
@@ -322,6 +338,10 @@ func (s *Server) serveRemote(w http.ResponseWriter, r *http.Request, path string
 	}
 	if path == "" || strings.HasSuffix(path, "/") {
 		path = strings.Trim(path, "/")
+		if r.URL.Query().Get("download") == "zip" {
+			serve.Zip(w, r, f, path)
+			return
+		}
 		entries, err := list.DirSorted(r.Context(), f, false, path)
 		if err != nil {
 			writeError(path, nil, w, errors.Wrap(err, "failed to list directory"), http.StatusInternalServerError)
@@ -346,6 +366,15 @@ func (s *Server) serveRemote(w http.ResponseWriter, r *http.Request, path string
 			writeError(path, nil, w, errors.Wrap(err, "failed to find object"), http.StatusInternalServerError)
 			return
 		}
+		if thumbnail := r.URL.Query().Get("thumbnail"); thumbnail != "" {
+			size, err := strconv.Atoi(thumbnail)
+			if err != nil {
+				writeError(path, nil, w, errors.Wrap(err, "bad thumbnail size"), http.StatusBadRequest)
+				return
+			}
+			serve.Thumbnail(w, r, o, size)
+			return
+		}
 		serve.Object(w, r, o)
 	}
 }
@@ -323,6 +323,21 @@ func TestRemoteServing(t *testing.T) {
 			Status:   http.StatusPartialContent,
 			Range:    "bytes=8-12",
 			Expected: `file1`,
+		}, {
+			Name:     "dir-zip-head",
+			URL:      remoteURL + "dir/?download=zip",
+			Method:   "HEAD",
+			Status:   http.StatusOK,
+			Expected: ``,
+			Headers: map[string]string{
+				"Content-Type":        "application/zip",
+				"Content-Disposition": `attachment; filename="dir.zip"`,
+			},
+		}, {
+			Name:     "file-thumbnail-not-image",
+			URL:      remoteURL + "file.txt?thumbnail=64",
+			Status:   http.StatusUnsupportedMediaType,
+			Contains: regexp.MustCompile(`can't decode image`),
 		}, {
 			Name:   "bad-remote",
 			URL:    "[notfoundremote:]/",
@@ -535,6 +550,18 @@ Unknown command
 	"status": 500
 }
 `, fs.Version),
+	}, {
+		Name:        "debug-goroutines",
+		URL:         "debug/goroutines",
+		Method:      "POST",
+		Body:        `{}`,
+		ContentType: "application/json",
+		Status:      http.StatusOK,
+		Contains:    regexp.MustCompile(`(?s)^goroutine \d+ \[running\]:.*\n$`),
+		Headers: map[string]string{
+			"Content-Type":        "text/plain; charset=utf-8",
+			"Content-Disposition": `attachment; filename="goroutines.txt"`,
+		},
 	}}
 	opt := newTestOpt()
 	opt.Serve = true
@@ -0,0 +1,199 @@
+package scheduler
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/pingme998/rclone/fs"
+)
+
+// schedule works out when a task should next run
+type schedule interface {
+	// Next returns the first time the task should run after t
+	Next(t time.Time) time.Time
+}
+
+// every runs a task at a fixed interval
+type every time.Duration
+
+// Next returns the first time the task should run after t
+func (e every) Next(t time.Time) time.Time {
+	return t.Add(time.Duration(e))
+}
+
+// cron runs a task at the times matched by a cron expression. Each
+// field is a bitset of the values which match.
+type cron struct {
+	minute, hour, dom, month, dow uint64
+	domStar, dowStar              bool // set if dom or dow started with "*"
+}
+
+// cronField describes a field of a cron expression
+type cronField struct {
+	name     string
+	min, max int
+	names    []string // names for the values starting at min if any
+}
+
+var cronFields = []cronField{
+	{name: "minute", min: 0, max: 59},
+	{name: "hour", min: 0, max: 23},
+	{name: "day of month", min: 1, max: 31},
+	{name: "month", min: 1, max: 12, names: []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}},
+	{name: "day of week", min: 0, max: 7, names: []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}},
+}
+
+// cronDescriptors are the shortcuts for common cron expressions
+var cronDescriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// parseSchedule parses a cron expression with 5 fields (minute hour
+// day-of-month month day-of-week), one of the @hourly style
+// descriptors or "@every duration".
+func parseSchedule(spec string) (schedule, error) {
+	spec = strings.TrimSpace(spec)
+	if strings.HasPrefix(spec, "@every ") {
+		d, err := fs.ParseDuration(strings.TrimSpace(spec[len("@every "):]))
+		if err != nil {
+			return nil, errors.Wrapf(err, "bad schedule %q", spec)
+		}
+		if d < time.Minute {
+			return nil, errors.Errorf("bad schedule %q: interval must be at least 1m", spec)
+		}
+		return every(d), nil
+	}
+	if expanded, ok := cronDescriptors[strings.ToLower(spec)]; ok {
+		spec = expanded
+	}
+	fields := strings.Fields(spec)
+	if len(fields) != len(cronFields) {
+		return nil, errors.Errorf("bad schedule %q: need %d fields but got %d", spec, len(cronFields), len(fields))
+	}
+	var c cron
+	bits := []*uint64{&c.minute, &c.hour, &c.dom, &c.month, &c.dow}
+	for i, field := range fields {
+		value, err := cronFields[i].parse(field)
+		if err != nil {
+			return nil, errors.Wrapf(err, "bad schedule %q", spec)
+		}
+		*bits[i] = value
+	}
+	// Sunday can be 0 or 7
+	if c.dow&(1<<7) != 0 {
+		c.dow |= 1
+	}
+	c.domStar = strings.HasPrefix(fields[2], "*")
+	c.dowStar = strings.HasPrefix(fields[4], "*")
+	return &c, nil
+}
+
+// parseValue parses a single value of the field which may be a name
+func (f *cronField) parseValue(s string) (int, error) {
+	for i, name := range f.names {
+		if strings.EqualFold(s, name) {
+			return f.min + i, nil
+		}
+	}
+	value, err := strconv.Atoi(s)
+	if err != nil || value < f.min || value > f.max {
+		return 0, errors.Errorf("bad %s %q: must be %d-%d", f.name, s, f.min, f.max)
+	}
+	return value, nil
+}
+
+// parse a comma separated list of "*", values and ranges with an
+// optional "/step" into a bitset
+func (f *cronField) parse(s string) (bits uint64, err error) {
+	for _, part := range strings.Split(s, ",") {
+		step := 1
+		if i := strings.IndexRune(part, '/'); i >= 0 {
+			step, err = strconv.Atoi(part[i+1:])
+			if err != nil || step <= 0 {
+				return 0, errors.Errorf("bad step in %s %q", f.name, part)
+			}
+			part = part[:i]
+		}
+		var start, end int
+		switch {
+		case part == "*":
+			start, end = f.min, f.max
+		case strings.ContainsRune(part, '-'):
+			i := strings.IndexRune(part, '-')
+			if start, err = f.parseValue(part[:i]); err != nil {
+				return 0, err
+			}
+			if end, err = f.parseValue(part[i+1:]); err != nil {
+				return 0, err
+			}
+			if end < start {
+				return 0, errors.Errorf("bad range in %s %q", f.name, part)
+			}
+		default:
+			if start, err = f.parseValue(part); err != nil {
+				return 0, err
+			}
+			end = start
+			if step > 1 {
+				// "n/step" means from n to the end
+				end = f.max
+			}
+		}
+		for value := start; value <= end; value += step {
+			bits |= 1 << uint(value)
+		}
+	}
+	return bits, nil
+}
+
+// matches returns true if value is in the bitset
+func matches(bits uint64, value int) bool {
+	return bits&(1<<uint(value)) != 0
+}
+
+// dayMatches returns true if the day of t matches. As in cron if both
+// the day of month and day of week are restricted then either may
+// match.
+func (c *cron) dayMatches(t time.Time) bool {
+	domMatch := matches(c.dom, t.Day())
+	dowMatch := matches(c.dow, int(t.Weekday()))
+	if c.domStar || c.dowStar {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
+
+// Next returns the first time the task should run after t
+func (c *cron) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	// Give up if nothing matches in 5 years, eg "0 0 30 2 *"
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		if !matches(c.month, int(t.Month())) {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !c.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !matches(c.hour, t.Hour()) {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if !matches(c.minute, t.Minute()) {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
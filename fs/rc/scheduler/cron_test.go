@@ -0,0 +1,67 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSchedule(t *testing.T) {
+	for _, test := range []struct {
+		spec string
+		err  bool
+	}{
+		{"* * * * *", false},
+		{"*/15 1-5,22 1 jan-mar mon-fri", false},
+		{"0 0 * * 7", false},
+		{"@daily", false},
+		{"@every 2h", false},
+		{"@every 1s", true},
+		{"@every potato", true},
+		{"* * * *", true},
+		{"60 * * * *", true},
+		{"* 24 * * *", true},
+		{"* * 0 * *", true},
+		{"* * * 13 *", true},
+		{"* * * * 8", true},
+		{"5-1 * * * *", true},
+		{"*/0 * * * *", true},
+		{"* * * potato *", true},
+	} {
+		_, err := parseSchedule(test.spec)
+		assert.Equal(t, test.err, err != nil, test.spec)
+	}
+}
+
+func TestScheduleNext(t *testing.T) {
+	// Wednesday
+	start := time.Date(2021, 3, 17, 10, 30, 20, 0, time.UTC)
+	for _, test := range []struct {
+		spec string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2021, 3, 17, 10, 31, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2021, 3, 17, 10, 45, 0, 0, time.UTC)},
+		{"0 * * * *", time.Date(2021, 3, 17, 11, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2021, 3, 17, 11, 0, 0, 0, time.UTC)},
+		{"30 2 * * *", time.Date(2021, 3, 18, 2, 30, 0, 0, time.UTC)},
+		{"@daily", time.Date(2021, 3, 18, 0, 0, 0, 0, time.UTC)},
+		{"0 9 * * mon", time.Date(2021, 3, 22, 9, 0, 0, 0, time.UTC)},
+		{"0 9 * * 0", time.Date(2021, 3, 21, 9, 0, 0, 0, time.UTC)},
+		{"0 9 * * 7", time.Date(2021, 3, 21, 9, 0, 0, 0, time.UTC)},
+		{"0 0 1 * *", time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		// day of month or day of week when both are set
+		{"0 0 20 * fri", time.Date(2021, 3, 19, 0, 0, 0, 0, time.UTC)},
+		{"5/20 10 * * *", time.Date(2021, 3, 17, 10, 45, 0, 0, time.UTC)},
+		{"@every 90m", time.Date(2021, 3, 17, 12, 0, 20, 0, time.UTC)},
+		// never
+		{"0 0 30 2 *", time.Time{}},
+	} {
+		s, err := parseSchedule(test.spec)
+		require.NoError(t, err, test.spec)
+		assert.Equal(t, test.want, s.Next(start), test.spec)
+	}
+}
@@ -0,0 +1,425 @@
+// Package scheduler runs the sync tasks defined in the config file
+// on a schedule in rclone rcd.
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/pingme998/rclone/fs"
+	"github.com/pingme998/rclone/fs/config"
+	"github.com/pingme998/rclone/fs/rc"
+	"github.com/pingme998/rclone/fs/rc/jobs"
+)
+
+// historyLength is the number of runs of each task kept
+const historyLength = 20
+
+// Run is the record of a run of a task
+type Run struct {
+	StartTime time.Time `json:"startTime"`
+	EndTime   time.Time `json:"endTime"`
+	Manual    bool      `json:"manual"`  // set if started with task/run
+	Skipped   bool      `json:"skipped"` // set if not run as the previous run was still going
+	Tries     int       `json:"tries"`
+	JobIDs    []int64   `json:"jobids"`
+	Success   bool      `json:"success"`
+	Error     string    `json:"error"`
+}
+
+// Task is a sync, copy or move run on a schedule
+type Task struct {
+	Name               string    `json:"name"`
+	Schedule           string    `json:"schedule"`
+	Command            string    `json:"command"`
+	Src                string    `json:"src"`
+	Dst                string    `json:"dst"`
+	Retries            int       `json:"retries"`
+	RetryDelay         string    `json:"retryDelay"`
+	CreateEmptySrcDirs bool      `json:"createEmptySrcDirs"`
+	Running            bool      `json:"running"`
+	NextRun            time.Time `json:"nextRun"`
+	LastRun            *Run      `json:"lastRun"`
+
+	schedule   schedule
+	retryDelay time.Duration
+	config     rc.Params // _config for the job
+	filter     rc.Params // _filter for the job
+	history    []Run
+}
+
+// parseTask reads the task called name from the config file
+func parseTask(name string) (*Task, error) {
+	section := config.TaskPrefix + name
+	t := &Task{
+		Name:       name,
+		Command:    "sync",
+		RetryDelay: "1m",
+	}
+	var err error
+	for _, key := range config.LoadedData().GetKeyList(section) {
+		value := config.FileGet(section, key)
+		switch key {
+		case "schedule":
+			t.Schedule = value
+		case "command":
+			t.Command = value
+		case "src":
+			t.Src = value
+		case "dst":
+			t.Dst = value
+		case "retries":
+			t.Retries, err = strconv.Atoi(value)
+		case "retry_delay":
+			t.RetryDelay = value
+		case "create_empty_src_dirs":
+			t.CreateEmptySrcDirs, err = strconv.ParseBool(value)
+		case "config":
+			err = json.Unmarshal([]byte(value), &t.config)
+		case "filter":
+			err = json.Unmarshal([]byte(value), &t.filter)
+		default:
+			err = errors.New("unknown option")
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "[%s]: bad value for %q", section, key)
+		}
+	}
+	if t.Schedule == "" || t.Src == "" || t.Dst == "" {
+		return nil, errors.Errorf("[%s]: schedule, src and dst must be set", section)
+	}
+	switch t.Command {
+	case "sync", "copy", "move":
+	default:
+		return nil, errors.Errorf("[%s]: command must be sync, copy or move not %q", section, t.Command)
+	}
+	t.schedule, err = parseSchedule(t.Schedule)
+	if err != nil {
+		return nil, errors.Wrapf(err, "[%s]", section)
+	}
+	t.retryDelay, err = fs.ParseDuration(t.RetryDelay)
+	if err != nil {
+		return nil, errors.Wrapf(err, "[%s]: bad value for \"retry_delay\"", section)
+	}
+	return t, nil
+}
+
+// Scheduler runs the tasks on their schedules
+type Scheduler struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+	mu     sync.Mutex
+	tasks  map[string]*Task
+}
+
+// the scheduler used by the rc calls
+var (
+	globalMu sync.Mutex
+	global   *Scheduler
+)
+
+// Start reads the tasks from the config file and runs them on their
+// schedules until Stop is called.
+func Start(ctx context.Context) (*Scheduler, error) {
+	s := &Scheduler{
+		tasks: map[string]*Task{},
+	}
+	for _, section := range config.LoadedData().GetSectionList() {
+		if !strings.HasPrefix(section, config.TaskPrefix) {
+			continue
+		}
+		t, err := parseTask(strings.TrimPrefix(section, config.TaskPrefix))
+		if err != nil {
+			return nil, err
+		}
+		s.tasks[t.Name] = t
+	}
+	s.ctx, s.cancel = context.WithCancel(ctx)
+	for _, t := range s.tasks {
+		s.wg.Add(1)
+		go s.loop(t)
+	}
+	if len(s.tasks) > 0 {
+		fs.Infof(nil, "Scheduler: started %d task(s)", len(s.tasks))
+	}
+	globalMu.Lock()
+	global = s
+	globalMu.Unlock()
+	return s, nil
+}
+
+// Stop the scheduler, stopping any running tasks, and wait for it to
+// finish
+func (s *Scheduler) Stop() {
+	globalMu.Lock()
+	if global == s {
+		global = nil
+	}
+	globalMu.Unlock()
+	s.cancel()
+	s.wg.Wait()
+}
+
+// loop runs the task each time it is due
+func (s *Scheduler) loop(t *Task) {
+	defer s.wg.Done()
+	now := time.Now()
+	for {
+		next := t.schedule.Next(now)
+		s.mu.Lock()
+		t.NextRun = next
+		s.mu.Unlock()
+		if next.IsZero() {
+			fs.Errorf(nil, "Scheduler: task %q will never run", t.Name)
+			return
+		}
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-s.ctx.Done():
+			timer.Stop()
+			return
+		case now = <-timer.C:
+		}
+		_ = s.start(t, false)
+	}
+}
+
+// start runs the task in the background unless it is already running
+func (s *Scheduler) start(t *Task, manual bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if t.Running {
+		fs.Errorf(nil, "Scheduler: not starting task %q as the previous run is still going", t.Name)
+		now := time.Now()
+		s.addRun(t, Run{StartTime: now, EndTime: now, Manual: manual, Skipped: true, Error: "previous run still going"})
+		return errors.Errorf("task %q is already running", t.Name)
+	}
+	t.Running = true
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		r := s.run(t)
+		r.Manual = manual
+		s.mu.Lock()
+		t.Running = false
+		s.addRun(t, r)
+		s.mu.Unlock()
+	}()
+	return nil
+}
+
+// addRun adds r to the history of t - call with s.mu held
+func (s *Scheduler) addRun(t *Task, r Run) {
+	t.history = append(t.history, r)
+	if len(t.history) > historyLength {
+		t.history = t.history[len(t.history)-historyLength:]
+	}
+	t.LastRun = &t.history[len(t.history)-1]
+}
+
+// run the task retrying it if it fails
+func (s *Scheduler) run(t *Task) (r Run) {
+	r.StartTime = time.Now()
+	defer func() {
+		r.EndTime = time.Now()
+	}()
+	for {
+		r.Tries++
+		fs.Infof(nil, "Scheduler: starting task %q (try %d/%d)", t.Name, r.Tries, t.Retries+1)
+		jobID, err := s.runJob(t)
+		if jobID != 0 {
+			r.JobIDs = append(r.JobIDs, jobID)
+		}
+		if err == nil {
+			fs.Infof(nil, "Scheduler: task %q succeeded", t.Name)
+			r.Success = true
+			return r
+		}
+		r.Error = err.Error()
+		fs.Errorf(nil, "Scheduler: task %q failed (try %d/%d): %v", t.Name, r.Tries, t.Retries+1, err)
+		if r.Tries > t.Retries || s.ctx.Err() != nil {
+			return r
+		}
+		select {
+		case <-s.ctx.Done():
+			return r
+		case <-time.After(t.retryDelay):
+		}
+	}
+}
+
+// runJob runs the task as an rc job returning its ID and error
+func (s *Scheduler) runJob(t *Task) (jobID int64, err error) {
+	call := rc.Calls.Get("sync/" + t.Command)
+	if call == nil {
+		return 0, errors.Errorf("rc call sync/%s not found", t.Command)
+	}
+	in := rc.Params{
+		"srcFs":              t.Src,
+		"dstFs":              t.Dst,
+		"createEmptySrcDirs": t.CreateEmptySrcDirs,
+		"_async":             true,
+	}
+	if t.config != nil {
+		in["_config"] = t.config
+	}
+	if t.filter != nil {
+		in["_filter"] = t.filter
+	}
+	job, _, err := jobs.NewJob(s.ctx, call.Fn, in)
+	if err != nil {
+		return 0, err
+	}
+	finished := make(chan struct{})
+	cancel, err := jobs.OnFinish(job.ID, func() { close(finished) })
+	if err != nil {
+		return job.ID, err
+	}
+	defer cancel()
+	select {
+	case <-finished:
+	case <-s.ctx.Done():
+		job.Stop()
+		<-finished
+	}
+	out, err := rc.Calls.Get("job/status").Fn(s.ctx, rc.Params{"jobid": job.ID})
+	if err != nil {
+		return job.ID, err
+	}
+	if success, _ := out.GetBool("success"); !success {
+		errString, _ := out.GetString("error")
+		return job.ID, errors.New(errString)
+	}
+	return job.ID, nil
+}
+
+// getTask returns the task named in the "name" parameter
+func getTask(in rc.Params) (*Scheduler, *Task, error) {
+	globalMu.Lock()
+	s := global
+	globalMu.Unlock()
+	if s == nil {
+		return nil, nil, errors.New("the task scheduler is only run by rclone rcd")
+	}
+	name, err := in.GetString("name")
+	if err != nil {
+		return nil, nil, err
+	}
+	t := s.tasks[name]
+	if t == nil {
+		return nil, nil, errors.Errorf("task %q not found", name)
+	}
+	return s, t, nil
+}
+
+func init() {
+	rc.Add(rc.Call{
+		Path:         "task/list",
+		AuthRequired: true,
+		Fn:           rcList,
+		Title:        "Lists the scheduled tasks",
+		Help: `Parameters - None
+
+Results
+
+- tasks - array of tasks, each with
+    - name - name of the task
+    - schedule, command, src, dst, retries, retryDelay, createEmptySrcDirs - as set in the config file
+    - running - boolean whether the task is running
+    - nextRun - time the task will next run
+    - lastRun - the last run of the task as returned by task/history or null
+`,
+	})
+}
+
+// Lists the tasks
+func rcList(ctx context.Context, in rc.Params) (out rc.Params, err error) {
+	globalMu.Lock()
+	s := global
+	globalMu.Unlock()
+	tasks := []*Task{}
+	if s != nil {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		for _, t := range s.tasks {
+			tasks = append(tasks, t)
+		}
+	}
+	sort.Slice(tasks, func(i, j int) bool { return tasks[i].Name < tasks[j].Name })
+	out = make(rc.Params)
+	out["tasks"] = tasks
+	// Reshape while locked so the tasks are read consistently
+	err = rc.Reshape(&out, out)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func init() {
+	rc.Add(rc.Call{
+		Path:         "task/history",
+		AuthRequired: true,
+		Fn:           rcHistory,
+		Title:        "Shows the recent runs of a scheduled task",
+		Help: `Parameters
+
+- name - name of the task
+
+Results
+
+- runs - array of the recent runs, oldest first, each with
+    - startTime, endTime - when the run started and finished
+    - manual - boolean whether it was started with task/run
+    - skipped - boolean whether it was skipped as the previous run was still going
+    - tries - number of tries made
+    - jobids - the IDs of the jobs for each try
+    - success - boolean whether the run succeeded
+    - error - error from the last try or empty string
+`,
+	})
+}
+
+// Shows the history of a task
+func rcHistory(ctx context.Context, in rc.Params) (out rc.Params, err error) {
+	s, t, err := getTask(in)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out = rc.Params{"runs": append([]Run{}, t.history...)}
+	return out, nil
+}
+
+func init() {
+	rc.Add(rc.Call{
+		Path:         "task/run",
+		AuthRequired: true,
+		Fn:           rcRun,
+		Title:        "Runs a scheduled task now",
+		Help: `Parameters
+
+- name - name of the task
+
+This starts the task in the background, returning an error if it is
+already running. Use task/history to see the result.
+`,
+	})
+}
+
+// Runs a task now
+func rcRun(ctx context.Context, in rc.Params) (out rc.Params, err error) {
+	s, t, err := getTask(in)
+	if err != nil {
+		return nil, err
+	}
+	return rc.Params{}, s.start(t, true)
+}
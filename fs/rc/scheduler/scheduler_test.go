@@ -0,0 +1,135 @@
+package scheduler
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	_ "github.com/pingme998/rclone/backend/local"
+	"github.com/pingme998/rclone/fs/config"
+	"github.com/pingme998/rclone/fs/rc"
+	_ "github.com/pingme998/rclone/fs/sync"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setTask sets the task called name in the config file
+func setTask(t *testing.T, name string, keyValues ...string) {
+	section := config.TaskPrefix + name
+	for i := 0; i < len(keyValues); i += 2 {
+		config.FileSet(section, keyValues[i], keyValues[i+1])
+	}
+	t.Cleanup(func() {
+		config.LoadedData().DeleteSection(section)
+	})
+}
+
+func TestParseTask(t *testing.T) {
+	setTask(t, "good", "schedule", "@daily", "command", "copy", "src", "/a", "dst", "/b", "retries", "2", "retry_delay", "10s", "config", `{"Transfers": 8}`)
+	task, err := parseTask("good")
+	require.NoError(t, err)
+	assert.Equal(t, "copy", task.Command)
+	assert.Equal(t, 2, task.Retries)
+	assert.Equal(t, 10*time.Second, task.retryDelay)
+	assert.Equal(t, rc.Params{"Transfers": float64(8)}, task.config)
+	assert.False(t, config.IsRemoteSection(config.TaskPrefix+"good"))
+
+	for _, bad := range [][]string{
+		{"schedule", "@daily", "src", "/a"},
+		{"schedule", "@potato", "src", "/a", "dst", "/b"},
+		{"schedule", "@daily", "src", "/a", "dst", "/b", "command", "purge"},
+		{"schedule", "@daily", "src", "/a", "dst", "/b", "retries", "x"},
+		{"schedule", "@daily", "src", "/a", "dst", "/b", "potato", "1"},
+		{"schedule", "@daily", "src", "/a", "dst", "/b", "config", "{"},
+	} {
+		setTask(t, "bad", bad...)
+		_, err := parseTask("bad")
+		assert.Error(t, err, bad)
+		config.LoadedData().DeleteSection(config.TaskPrefix + "bad")
+	}
+}
+
+// waitForRuns waits for the task to have n runs and returns them
+func waitForRuns(t *testing.T, name string, n int) []Run {
+	for i := 0; i < 1000; i++ {
+		out, err := rcHistory(context.Background(), rc.Params{"name": name})
+		require.NoError(t, err)
+		runs := out["runs"].([]Run)
+		if len(runs) >= n {
+			return runs
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("timed out waiting for %d runs of %q", n, name)
+	return nil
+}
+
+func TestScheduler(t *testing.T) {
+	ctx := context.Background()
+	dir, err := ioutil.TempDir("", "rclone-scheduler")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(dir))
+	}()
+	src := filepath.Join(dir, "src")
+	dst := filepath.Join(dir, "dst")
+	require.NoError(t, os.Mkdir(src, 0777))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(src, "file"), []byte("hello"), 0666))
+
+	setTask(t, "ok", "schedule", "@every 1h", "command", "copy", "src", src, "dst", dst)
+	setTask(t, "fail", "schedule", "@every 1h", "src", filepath.Join(dir, "missing"), "dst", dst, "retries", "1", "retry_delay", "1ms")
+
+	_, err = rcRun(ctx, rc.Params{"name": "ok"})
+	assert.Error(t, err, "scheduler not running")
+
+	s, err := Start(ctx)
+	require.NoError(t, err)
+	defer s.Stop()
+
+	out, err := rcList(ctx, nil)
+	require.NoError(t, err)
+	tasks := out["tasks"].([]interface{})
+	require.Len(t, tasks, 2)
+	assert.Equal(t, "fail", tasks[0].(map[string]interface{})["name"])
+	assert.Equal(t, "ok", tasks[1].(map[string]interface{})["name"])
+
+	// Run a task which succeeds
+	_, err = rcRun(ctx, rc.Params{"name": "ok"})
+	require.NoError(t, err)
+	runs := waitForRuns(t, "ok", 1)
+	assert.True(t, runs[0].Success)
+	assert.True(t, runs[0].Manual)
+	assert.Equal(t, 1, runs[0].Tries)
+	assert.Len(t, runs[0].JobIDs, 1)
+	data, err := ioutil.ReadFile(filepath.Join(dst, "file"))
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(data))
+
+	// Run a task which fails and is retried
+	_, err = rcRun(ctx, rc.Params{"name": "fail"})
+	require.NoError(t, err)
+	runs = waitForRuns(t, "fail", 1)
+	assert.False(t, runs[0].Success)
+	assert.Equal(t, 2, runs[0].Tries)
+	assert.Len(t, runs[0].JobIDs, 2)
+	assert.NotEqual(t, "", runs[0].Error)
+
+	// A task isn't started while it is running
+	task := s.tasks["ok"]
+	s.mu.Lock()
+	task.Running = true
+	s.mu.Unlock()
+	_, err = rcRun(ctx, rc.Params{"name": "ok"})
+	assert.Error(t, err)
+	runs = waitForRuns(t, "ok", 2)
+	assert.True(t, runs[1].Skipped)
+	s.mu.Lock()
+	task.Running = false
+	s.mu.Unlock()
+
+	_, err = rcRun(ctx, rc.Params{"name": "potato"})
+	assert.Error(t, err)
+}
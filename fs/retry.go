@@ -0,0 +1,90 @@
+package fs
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/pingme998/rclone/fs/fserrors"
+	"github.com/pingme998/rclone/lib/pacer"
+)
+
+// ConfigRetryRules is the config file key which can be set in the
+// section of any remote to override which of its errors are retried.
+//
+// It is a list of rules as read by fserrors.ParseRetryRule separated
+// by ";". These are checked before the rules in --retry-rule.
+const ConfigRetryRules = "retry_rules"
+
+// Type of key used to store the remote being made in the context
+type remoteContextKeyType struct{}
+
+// Context key for the remote being made
+var remoteContextKey = remoteContextKeyType{}
+
+// remoteContext is stored in the context passed to the backend's
+// NewFs so NewPacer knows which remote it is for
+type remoteContext struct {
+	name    string // name of the remote in the config file
+	backend string // type of the backend, e.g. "s3"
+}
+
+// withRemote returns a copy of ctx which tells NewPacer the pacers
+// made are for the remote name of the backend type given
+func withRemote(ctx context.Context, name, backend string) context.Context {
+	return context.WithValue(ctx, remoteContextKey, remoteContext{name: name, backend: backend})
+}
+
+// remember which bad retry rules have been warned about
+var retryRulesWarned sync.Map
+
+// getRetryRules returns the rules which apply to the remote name,
+// those in its section of the config file then those in
+// --retry-rule.
+//
+// Bad rules in the config file are logged once and ignored.
+func getRetryRules(ci *ConfigInfo, name string) (rules []fserrors.RetryRule) {
+	if name != "" {
+		value, _ := ConfigFileGet(name, ConfigRetryRules)
+		for _, s := range strings.Split(value, ";") {
+			if strings.TrimSpace(s) == "" {
+				continue
+			}
+			rule, err := fserrors.ParseRetryRule(s)
+			if err != nil {
+				if _, loaded := retryRulesWarned.LoadOrStore(name+"\x00"+s, struct{}{}); !loaded {
+					Errorf(nil, "Ignoring bad %s for remote %q: %v", ConfigRetryRules, name, err)
+				}
+				continue
+			}
+			// rules in the section only apply to this remote
+			rule.Remote = name
+			rules = append(rules, rule)
+		}
+	}
+	return append(rules, ci.RetryRules...)
+}
+
+// newPacerInvoker returns a pacer.InvokerFunc which logs the low
+// level retries and applies the retry rules for the remote in ctx to
+// the decisions of the backend.
+func newPacerInvoker(ctx context.Context) pacer.InvokerFunc {
+	remote, _ := ctx.Value(remoteContextKey).(remoteContext)
+	rules := getRetryRules(GetConfig(ctx), remote.name)
+	return func(try, retries int, f pacer.Paced) (retry bool, err error) {
+		retry, err = f()
+		if err != nil {
+			wanted := retry
+			retry = fserrors.ClassifyRetry(rules, remote.name, remote.backend, retry, err)
+			if wanted && !retry {
+				Debugf("pacer", "not retrying as a retry rule matched (error %v)", err)
+				err = fserrors.NoLowLevelRetryError(err)
+			}
+		}
+		if retry {
+			Debugf("pacer", "low level retry %d/%d (error %v)", try, retries, err)
+			err = fserrors.RetryError(err)
+		}
+		return
+	}
+}
@@ -0,0 +1,193 @@
+package sync
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pingme998/rclone/fs"
+	"github.com/pingme998/rclone/fs/accounting"
+	"github.com/pingme998/rclone/lib/pacer"
+)
+
+// Bounds and timing for --transfers auto
+const (
+	autoTuneInterval     = 5 * time.Second
+	autoTuneMinTransfers = 1
+	autoTuneMaxTransfers = 64
+	autoTuneImprovement  = 1.05 // rate must improve by this factor to keep going
+)
+
+// concurrencyLimit limits the number of workers which may be active
+// at once to a limit which can be changed while they are running.
+//
+// A nil *concurrencyLimit imposes no limit.
+type concurrencyLimit struct {
+	mu     sync.Mutex
+	cond   *sync.Cond
+	limit  int
+	active int
+}
+
+// newConcurrencyLimit makes a concurrencyLimit allowing limit workers
+func newConcurrencyLimit(limit int) *concurrencyLimit {
+	l := &concurrencyLimit{limit: limit}
+	l.cond = sync.NewCond(&l.mu)
+	return l
+}
+
+// acquire waits until a worker may become active
+func (l *concurrencyLimit) acquire() {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	for l.active >= l.limit {
+		l.cond.Wait()
+	}
+	l.active++
+	l.mu.Unlock()
+}
+
+// release marks a worker acquired with acquire as inactive
+func (l *concurrencyLimit) release() {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	l.active--
+	l.mu.Unlock()
+	l.cond.Signal()
+}
+
+// setLimit changes the number of workers allowed to be active
+func (l *concurrencyLimit) setLimit(limit int) {
+	l.mu.Lock()
+	l.limit = limit
+	l.mu.Unlock()
+	l.cond.Broadcast()
+}
+
+// getLimit returns the number of workers allowed to be active
+func (l *concurrencyLimit) getLimit() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.limit
+}
+
+// autoTuneState is the state of the hill climb done by --transfers auto
+type autoTuneState struct {
+	transfers int     // current number of transfers
+	direction int     // +1 or -1 - the direction of the last change
+	rate      float64 // bytes/s measured at the last step
+}
+
+// step works out the number of transfers to use next from the rate
+// measured with the current number of transfers and whether errors
+// or retries were seen.
+//
+// Errors and retries mean the remote is overloaded so the transfers
+// are cut back sharply. Otherwise the number of transfers keeps
+// moving in the same direction while the rate improves and turns
+// round when it doesn't.
+func (st *autoTuneState) step(rate float64, overloaded bool) {
+	switch {
+	case overloaded:
+		cut := st.transfers / 4
+		if cut < 1 {
+			cut = 1
+		}
+		st.transfers -= cut
+		st.direction = -1
+	case rate <= 0:
+		// Nothing transferred so nothing to learn from
+		return
+	case rate > st.rate*autoTuneImprovement:
+		st.transfers += st.direction
+	default:
+		st.direction = -st.direction
+		st.transfers += st.direction
+	}
+	if st.transfers < autoTuneMinTransfers {
+		st.transfers = autoTuneMinTransfers
+		st.direction = 1
+	} else if st.transfers > autoTuneMaxTransfers {
+		st.transfers = autoTuneMaxTransfers
+		st.direction = -1
+	}
+	st.rate = rate
+}
+
+// autoTuner adjusts the number of transfers and checkers for
+// --transfers auto using the feedback from the accounting and the
+// pacers.
+type autoTuner struct {
+	ctx       context.Context
+	fdst      fs.Fs
+	transfers *concurrencyLimit
+	checkers  *concurrencyLimit
+	state     autoTuneState
+	done      chan struct{}
+	wg        sync.WaitGroup
+}
+
+// newAutoTuner makes an autoTuner starting from the configured
+// number of transfers
+func newAutoTuner(ctx context.Context, fdst fs.Fs) *autoTuner {
+	ci := fs.GetConfig(ctx)
+	transfers := ci.Transfers
+	if transfers > autoTuneMaxTransfers {
+		transfers = autoTuneMaxTransfers
+	}
+	return &autoTuner{
+		ctx:       ctx,
+		fdst:      fdst,
+		transfers: newConcurrencyLimit(transfers),
+		checkers:  newConcurrencyLimit(2 * transfers),
+		state:     autoTuneState{transfers: transfers, direction: 1},
+		done:      make(chan struct{}),
+	}
+}
+
+// start the tuning in the background
+func (t *autoTuner) start() {
+	t.wg.Add(1)
+	go t.run()
+}
+
+// stop the tuning and wait for it to finish
+func (t *autoTuner) stop() {
+	close(t.done)
+	t.wg.Wait()
+}
+
+// run measures the throughput every autoTuneInterval and adjusts the
+// limits
+func (t *autoTuner) run() {
+	defer t.wg.Done()
+	ticker := time.NewTicker(autoTuneInterval)
+	defer ticker.Stop()
+	stats := accounting.Stats(t.ctx)
+	lastBytes, lastErrors, lastRetries := stats.GetBytes(), stats.GetErrors(), pacer.Retries()
+	lastTime := time.Now()
+	for {
+		select {
+		case <-t.done:
+			return
+		case <-t.ctx.Done():
+			return
+		case now := <-ticker.C:
+			bytes, errors, retries := stats.GetBytes(), stats.GetErrors(), pacer.Retries()
+			rate := float64(bytes-lastBytes) / now.Sub(lastTime).Seconds()
+			overloaded := errors > lastErrors || retries > lastRetries
+			lastBytes, lastErrors, lastRetries, lastTime = bytes, errors, retries, now
+			old := t.state.transfers
+			t.state.step(rate, overloaded)
+			if t.state.transfers != old {
+				fs.Infof(t.fdst, "--transfers auto: changing transfers from %d to %d (%s/s, overloaded %v)", old, t.state.transfers, fs.SizeSuffix(int64(rate)), overloaded)
+				t.transfers.setLimit(t.state.transfers)
+				t.checkers.setLimit(2 * t.state.transfers)
+			}
+		}
+	}
+}
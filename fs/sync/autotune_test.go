@@ -0,0 +1,77 @@
+package sync
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAutoTuneStep(t *testing.T) {
+	st := autoTuneState{transfers: 4, direction: 1}
+
+	// Keeps going up while the rate improves
+	st.step(100, false)
+	assert.Equal(t, 5, st.transfers)
+	st.step(200, false)
+	assert.Equal(t, 6, st.transfers)
+
+	// Turns round when it doesn't
+	st.step(201, false)
+	assert.Equal(t, 5, st.transfers)
+	assert.Equal(t, -1, st.direction)
+
+	// Holds when nothing was transferred
+	st.step(0, false)
+	assert.Equal(t, 5, st.transfers)
+	assert.Equal(t, float64(201), st.rate)
+
+	// Cuts back when overloaded
+	st = autoTuneState{transfers: 16, direction: 1}
+	st.step(1000, true)
+	assert.Equal(t, 12, st.transfers)
+	assert.Equal(t, -1, st.direction)
+
+	// Stays within the bounds
+	st = autoTuneState{transfers: autoTuneMinTransfers, direction: -1}
+	st.step(1000, true)
+	assert.Equal(t, autoTuneMinTransfers, st.transfers)
+	assert.Equal(t, 1, st.direction)
+	st = autoTuneState{transfers: autoTuneMaxTransfers, direction: 1, rate: 1}
+	st.step(1000, false)
+	assert.Equal(t, autoTuneMaxTransfers, st.transfers)
+	assert.Equal(t, -1, st.direction)
+}
+
+func TestConcurrencyLimit(t *testing.T) {
+	// nil doesn't limit
+	var nilLimit *concurrencyLimit
+	nilLimit.acquire()
+	nilLimit.release()
+
+	l := newConcurrencyLimit(1)
+	assert.Equal(t, 1, l.getLimit())
+	l.acquire()
+	acquired := make(chan struct{})
+	go func() {
+		l.acquire()
+		close(acquired)
+	}()
+	select {
+	case <-acquired:
+		t.Fatal("acquired more than the limit")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// Raising the limit lets the waiter in
+	l.setLimit(2)
+	assert.Equal(t, 2, l.getLimit())
+	select {
+	case <-acquired:
+	case <-time.After(5 * time.Second):
+		t.Fatal("not acquired after raising the limit")
+	}
+	l.release()
+	l.release()
+	assert.Equal(t, 0, l.active)
+}
@@ -0,0 +1,114 @@
+package sync
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/pingme998/rclone/fs"
+	"github.com/pingme998/rclone/fs/config"
+)
+
+// renameIDs remembers the backend IDs of the source files between
+// syncs for --track-renames-strategy id.
+//
+// A file copied to the destination gets a new ID there, so the IDs of
+// the source files are saved along with their paths at the end of each
+// sync. On the next sync a destination file which is about to be
+// deleted can be matched with a source file which has the ID the file
+// at its path had last time.
+type renameIDs struct {
+	path     string            // file the IDs are saved in
+	previous map[string]string // source ID by path from the previous sync
+	mu       sync.Mutex        // protect current
+	current  map[string]string // source ID by path seen in this sync
+}
+
+// newRenameIDs loads the IDs saved by the previous sync from fsrc to
+// fdst
+func newRenameIDs(fdst, fsrc fs.Fs) *renameIDs {
+	hash := md5.Sum([]byte(fs.ConfigString(fsrc) + "\x00" + fs.ConfigString(fdst)))
+	r := &renameIDs{
+		path:     filepath.Join(config.CacheDir, "track-renames", hex.EncodeToString(hash[:])+".json"),
+		previous: map[string]string{},
+		current:  map[string]string{},
+	}
+	data, err := ioutil.ReadFile(r.path)
+	if os.IsNotExist(err) {
+		fs.Infof(fdst, "No IDs saved by a previous sync - renames will be tracked by ID from the next sync")
+		return r
+	}
+	if err == nil {
+		err = json.Unmarshal(data, &r.previous)
+	}
+	if err != nil {
+		fs.Errorf(fdst, "Failed to load IDs for --track-renames: %v", err)
+		r.previous = map[string]string{}
+	}
+	return r
+}
+
+// srcID returns the ID of the source object or "" if it hasn't got one
+func (r *renameIDs) srcID(obj fs.Object) string {
+	do, ok := obj.(fs.IDer)
+	if !ok {
+		return ""
+	}
+	return do.ID()
+}
+
+// dstID returns the ID the source file at the path of the destination
+// object had in the previous sync or "" if not known
+func (r *renameIDs) dstID(obj fs.Object) string {
+	return r.previous[obj.Remote()]
+}
+
+// add records the ID of the source object to save at the end of the
+// sync
+func (r *renameIDs) add(obj fs.Object) {
+	id := r.srcID(obj)
+	if id == "" {
+		return
+	}
+	r.mu.Lock()
+	r.current[obj.Remote()] = id
+	r.mu.Unlock()
+}
+
+// save the IDs seen in this sync for the next one.
+//
+// If merge is set, eg because filters are in use, IDs of files not
+// seen in this sync are kept.
+func (r *renameIDs) save(merge bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ids := r.current
+	if merge {
+		ids = make(map[string]string, len(r.previous)+len(r.current))
+		for remote, id := range r.previous {
+			ids[remote] = id
+		}
+		for remote, id := range r.current {
+			ids[remote] = id
+		}
+	}
+	data, err := json.Marshal(ids)
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(filepath.Dir(r.path), 0700)
+	if err != nil {
+		return err
+	}
+	// Write to a temporary file and rename so a partial write
+	// doesn't lose the previous IDs
+	err = ioutil.WriteFile(r.path+".tmp", data, 0600)
+	if err != nil {
+		return err
+	}
+	return os.Rename(r.path+".tmp", r.path)
+}
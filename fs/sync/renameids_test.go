@@ -0,0 +1,85 @@
+package sync
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/pingme998/rclone/fs/config"
+	"github.com/pingme998/rclone/fstest/mockfs"
+	"github.com/pingme998/rclone/fstest/mockobject"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// idObject is a mock object with an ID
+type idObject struct {
+	mockobject.Object
+	id string
+}
+
+// ID returns the ID of the object
+func (o idObject) ID() string {
+	return o.id
+}
+
+func TestRenameIDs(t *testing.T) {
+	cacheDir, err := ioutil.TempDir("", "rclone-renameids")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(cacheDir))
+	}()
+	oldCacheDir := config.CacheDir
+	config.CacheDir = cacheDir
+	defer func() {
+		config.CacheDir = oldCacheDir
+	}()
+
+	ctx := context.Background()
+	fsrc := mockfs.NewFs(ctx, "src", "root")
+	fdst := mockfs.NewFs(ctx, "dst", "root")
+	load := func() *renameIDs {
+		return newRenameIDs(fdst, fsrc)
+	}
+
+	// Nothing saved to start with
+	r := load()
+	assert.Equal(t, map[string]string{}, r.previous)
+
+	// Objects without IDs aren't saved
+	r.add(idObject{mockobject.New("a"), "ID-A"})
+	r.add(idObject{mockobject.New("dir/b"), "ID-B"})
+	r.add(mockobject.New("no-id"))
+	require.NoError(t, r.save(false))
+
+	r = load()
+	assert.Equal(t, map[string]string{"a": "ID-A", "dir/b": "ID-B"}, r.previous)
+	assert.Equal(t, "ID-B", r.dstID(mockobject.New("dir/b")))
+	assert.Equal(t, "", r.dstID(mockobject.New("c")))
+	assert.Equal(t, "ID-C", r.srcID(idObject{mockobject.New("c"), "ID-C"}))
+	assert.Equal(t, "", r.srcID(mockobject.New("c")))
+
+	// The IDs are kept for each source and destination
+	assert.Equal(t, map[string]string{}, newRenameIDs(fsrc, fdst).previous)
+
+	// Check renameID matches the source with the destination
+	s := &syncCopyMove{renameIDs: r}
+	assert.Equal(t, "0,ID-A", s.renameID(idObject{mockobject.New("new/a"), "ID-A"}, trackRenamesStrategyID, 0, true))
+	assert.Equal(t, "0,ID-A", s.renameID(mockobject.New("a"), trackRenamesStrategyID, 0, false))
+	assert.Equal(t, "", s.renameID(mockobject.New("new/a"), trackRenamesStrategyID, 0, false))
+	assert.Equal(t, "", s.renameID(mockobject.New("a"), trackRenamesStrategyID, 0, true))
+	assert.Equal(t, "0,a,ID-A", s.renameID(mockobject.New("a"), trackRenamesStrategyID|trackRenamesStrategyLeaf, 0, false))
+
+	// Without merge the IDs are replaced by those seen
+	r.add(idObject{mockobject.New("new/a"), "ID-A"})
+	require.NoError(t, r.save(false))
+	r = load()
+	assert.Equal(t, map[string]string{"new/a": "ID-A"}, r.previous)
+
+	// With merge the previous IDs are kept
+	r.add(idObject{mockobject.New("c"), "ID-C"})
+	require.NoError(t, r.save(true))
+	r = load()
+	assert.Equal(t, map[string]string{"new/a": "ID-A", "c": "ID-C"}, r.previous)
+}
@@ -0,0 +1,114 @@
+package sync
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/pingme998/rclone/fs"
+	"github.com/pingme998/rclone/fs/cache"
+	"github.com/pingme998/rclone/fs/filter"
+	"github.com/pingme998/rclone/fs/fserrors"
+	"github.com/pingme998/rclone/fs/operations"
+)
+
+// makeStagingDir makes the Fs for --delete-delayed-to checking it can
+// be used to stage the files deleted or overwritten in fdst
+func makeStagingDir(ctx context.Context, fdst, fsrc fs.Fs) (staging fs.Fs, err error) {
+	ci := fs.GetConfig(ctx)
+	staging, err = cache.Get(ctx, ci.DeleteDelayedTo)
+	if err != nil {
+		return nil, fserrors.FatalError(errors.Errorf("Failed to make fs for --delete-delayed-to %q: %v", ci.DeleteDelayedTo, err))
+	}
+	if !operations.SameConfig(fdst, staging) {
+		return nil, fserrors.FatalError(errors.New("parameter to --delete-delayed-to has to be on the same remote as destination"))
+	}
+	if operations.Overlapping(fdst, staging) {
+		return nil, fserrors.FatalError(errors.New("destination and parameter to --delete-delayed-to mustn't overlap"))
+	}
+	if operations.Overlapping(fsrc, staging) {
+		return nil, fserrors.FatalError(errors.New("source and parameter to --delete-delayed-to mustn't overlap"))
+	}
+	if !operations.CanServerSideMove(staging) {
+		return nil, fserrors.FatalError(errors.New("can't use --delete-delayed-to on a remote which doesn't support server-side move or copy"))
+	}
+	// Files left by an interrupted sync would be committed or
+	// rolled back along with the files from this one
+	empty, err := isEmptyDir(ctx, staging)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list --delete-delayed-to")
+	}
+	if !empty {
+		return nil, fserrors.FatalError(errors.Errorf("--delete-delayed-to %q isn't empty - move the files left by an interrupted sync back to the destination or delete them", ci.DeleteDelayedTo))
+	}
+	return staging, nil
+}
+
+// isEmptyDir returns true if the root of f doesn't exist or is empty
+func isEmptyDir(ctx context.Context, f fs.Fs) (bool, error) {
+	entries, err := f.List(ctx, "")
+	if err == fs.ErrorDirNotFound {
+		return true, nil
+	}
+	return len(entries) == 0, err
+}
+
+// runStaged syncs fsrc to fdst moving the files which are deleted or
+// overwritten aside into --delete-delayed-to.
+//
+// If the sync succeeds the staged files are deleted, otherwise they
+// are moved back into fdst so a failed sync never leaves fdst missing
+// files.
+func runStaged(ctx context.Context, fdst, fsrc fs.Fs, deleteMode fs.DeleteMode, DoMove bool, deleteEmptySrcDirs bool, copyEmptySrcDirs bool) error {
+	ci := fs.GetConfig(ctx)
+	if DoMove {
+		return fserrors.FatalError(errors.New("can't use --delete-delayed-to with move"))
+	}
+	if ci.BackupDir != "" || ci.Suffix != "" {
+		return fserrors.FatalError(errors.New("can't use --delete-delayed-to with --backup-dir or --suffix"))
+	}
+	staging, err := makeStagingDir(ctx, fdst, fsrc)
+	if err != nil {
+		return err
+	}
+
+	// Run the sync using the staging directory as the --backup-dir
+	syncCtx, syncCi := fs.AddConfig(ctx)
+	syncCi.BackupDir = ci.DeleteDelayedTo
+	syncCi.DeleteDelayedTo = ""
+	err = runSyncCopyMove(syncCtx, fdst, fsrc, deleteMode, DoMove, deleteEmptySrcDirs, copyEmptySrcDirs)
+
+	// Commit or roll back every staged file regardless of the
+	// filters and the options which skip files
+	opCtx, opCi := fs.AddConfig(ctx)
+	opCi.DeleteDelayedTo = ""
+	opCi.IgnoreTimes = true
+	opCi.IgnoreExisting = false
+	opCi.UpdateOlder = false
+	opCi.Immutable = false
+	opCi.CompareDest = nil
+	opCi.CopyDest = nil
+	fi, fiErr := filter.NewFilter(nil)
+	if fiErr != nil {
+		return fiErr
+	}
+	opCtx = filter.ReplaceConfig(opCtx, fi)
+
+	if err == nil {
+		empty, err := isEmptyDir(opCtx, staging)
+		if err != nil || empty {
+			return err
+		}
+		fs.Infof(staging, "Sync succeeded - deleting the files staged in --delete-delayed-to")
+		err = operations.Purge(opCtx, staging, "")
+		if err != nil {
+			return errors.Wrap(err, "failed to delete the files staged in --delete-delayed-to")
+		}
+		return nil
+	}
+	fs.Errorf(staging, "Sync failed - moving the files staged in --delete-delayed-to back: %v", err)
+	rollbackErr := MoveDir(opCtx, fdst, staging, true, false)
+	if rollbackErr != nil {
+		fs.Errorf(staging, "Failed to move the staged files back - they have been left in --delete-delayed-to: %v", rollbackErr)
+	}
+	return err
+}
@@ -36,6 +36,7 @@ type syncCopyMove struct {
 	cancel                 func()                 // cancel the context
 	inCtx                  context.Context        // internal context for controlling march
 	inCancel               func()                 // cancel the march context
+	maxDurationEndTime     time.Time              // end time if --max-duration is set
 	noTraverse             bool                   // if set don't traverse the dst
 	noCheckDest            bool                   // if set transfer all objects regardless without checking dst
 	noUnicodeNormalization bool                   // don't normalize unicode characters in filenames
@@ -43,6 +44,7 @@ type syncCopyMove struct {
 	deleteFilesCh          chan fs.Object         // channel to receive deletes if delete before
 	trackRenames           bool                   // set if we should do server-side renames
 	trackRenamesStrategy   trackRenamesStrategy   // strategies used for tracking renames
+	renameIDs              *renameIDs             // IDs of the source files - only used by trackRenamesStrategy id
 	dstFilesMu             sync.Mutex             // protect dstFiles
 	dstFiles               map[string]fs.Object   // dst files, always filled
 	srcFiles               map[string]fs.Object   // src files, only used if deleteBefore
@@ -73,6 +75,7 @@ type syncCopyMove struct {
 	compareCopyDest        []fs.Fs                // place to check for files to server side copy
 	backupDir              fs.Fs                  // place to store overwrites/deletes
 	checkFirst             bool                   // if set run all the checkers before starting transfers
+	autoTune               *autoTuner             // adjusts the transfers and checkers - only used by --transfers auto
 }
 
 type trackRenamesStrategy byte
@@ -81,6 +84,7 @@ const (
 	trackRenamesStrategyHash trackRenamesStrategy = 1 << iota
 	trackRenamesStrategyModtime
 	trackRenamesStrategyLeaf
+	trackRenamesStrategyID
 )
 
 func (strategy trackRenamesStrategy) hash() bool {
@@ -95,10 +99,19 @@ func (strategy trackRenamesStrategy) leaf() bool {
 	return (strategy & trackRenamesStrategyLeaf) != 0
 }
 
+func (strategy trackRenamesStrategy) id() bool {
+	return (strategy & trackRenamesStrategyID) != 0
+}
+
 func newSyncCopyMove(ctx context.Context, fdst, fsrc fs.Fs, deleteMode fs.DeleteMode, DoMove bool, deleteEmptySrcDirs bool, copyEmptySrcDirs bool) (*syncCopyMove, error) {
 	if (deleteMode != fs.DeleteModeOff || DoMove) && operations.Overlapping(fdst, fsrc) {
 		return nil, fserrors.FatalError(fs.ErrorOverlapping)
 	}
+	// Start a span so the JSON logs of the transfers share a trace_id
+	ctx = fs.StartLogSpan(ctx)
+	// List each directory of the --backup-dir only once when looking
+	// for numbered --suffix versions
+	ctx = operations.WithSuffixVersionsCache(ctx)
 	ci := fs.GetConfig(ctx)
 	fi := filter.GetConfig(ctx)
 	s := &syncCopyMove{
@@ -144,16 +157,26 @@ func newSyncCopyMove(ctx context.Context, fdst, fsrc fs.Fs, deleteMode fs.Delete
 	if err != nil {
 		return nil, err
 	}
-	// If a max session duration has been defined add a deadline to the context
 	if ci.MaxDuration > 0 {
-		endTime := time.Now().Add(ci.MaxDuration)
-		fs.Infof(s.fdst, "Transfer session deadline: %s", endTime.Format("2006/01/02 15:04:05"))
-		s.ctx, s.cancel = context.WithDeadline(ctx, endTime)
+		s.maxDurationEndTime = time.Now().Add(ci.MaxDuration)
+		fs.Infof(s.fdst, "Transfer session %v deadline: %s", ci.CutoffMode, s.maxDurationEndTime.Format("2006/01/02 15:04:05"))
+	}
+	// If a max session duration has been defined with a hard cutoff
+	// add a deadline to the context which cuts off the transfers
+	if !s.maxDurationEndTime.IsZero() && ci.CutoffMode == fs.CutoffModeHard {
+		s.ctx, s.cancel = context.WithDeadline(ctx, s.maxDurationEndTime)
 	} else {
 		s.ctx, s.cancel = context.WithCancel(ctx)
 	}
 	// Input context - cancel this for graceful stop
-	s.inCtx, s.inCancel = context.WithCancel(s.ctx)
+	//
+	// With a soft cutoff the deadline goes here instead so no new
+	// transfers are started but the running ones finish
+	if !s.maxDurationEndTime.IsZero() && ci.CutoffMode != fs.CutoffModeHard {
+		s.inCtx, s.inCancel = context.WithDeadline(s.ctx, s.maxDurationEndTime)
+	} else {
+		s.inCtx, s.inCancel = context.WithCancel(s.ctx)
+	}
 	if s.noTraverse && s.deleteMode != fs.DeleteModeOff {
 		if !fi.HaveFilesFrom() {
 			fs.Errorf(nil, "Ignoring --no-traverse with sync")
@@ -196,6 +219,12 @@ func newSyncCopyMove(ctx context.Context, fdst, fsrc fs.Fs, deleteMode fs.Delete
 			s.trackRenames = false
 		}
 	}
+	if s.trackRenames && s.trackRenamesStrategy.id() {
+		s.renameIDs = newRenameIDs(fdst, fsrc)
+	}
+	if s.ci.TransfersAuto {
+		s.autoTune = newAutoTuner(ctx, fdst)
+	}
 	if s.trackRenames {
 		// track renames needs delete after
 		if s.deleteMode != fs.DeleteModeOff {
@@ -258,7 +287,10 @@ func (s *syncCopyMove) processError(err error) {
 	if err == nil {
 		return
 	}
-	if err == context.DeadlineExceeded {
+	if err == context.DeadlineExceeded && s.ctx.Err() == nil && s.inCtx.Err() != nil {
+		// Ignore the soft --max-duration deadline - reported at the end
+		return
+	} else if err == context.DeadlineExceeded {
 		err = fserrors.NoRetryError(err)
 	} else if err == accounting.ErrorMaxTransferLimitReachedGraceful {
 		if s.inCtx.Err() == nil {
@@ -312,6 +344,7 @@ func (s *syncCopyMove) pairChecker(in *pipe, out *pipe, fraction int, wg *sync.W
 		if !ok {
 			return
 		}
+		s.checkersLimit().acquire()
 		src := pair.Src
 		var err error
 		tr := accounting.Stats(s.ctx).NewCheckingTransfer(src)
@@ -338,12 +371,14 @@ func (s *syncCopyMove) pairChecker(in *pipe, out *pipe, fraction int, wg *sync.W
 							pair.Dst = nil
 							ok = out.Put(s.ctx, pair)
 							if !ok {
+								s.checkersLimit().release()
 								return
 							}
 						}
 					} else {
 						ok = out.Put(s.ctx, pair)
 						if !ok {
+							s.checkersLimit().release()
 							return
 						}
 					}
@@ -361,6 +396,7 @@ func (s *syncCopyMove) pairChecker(in *pipe, out *pipe, fraction int, wg *sync.W
 			}
 		}
 		tr.Done(s.ctx, err)
+		s.checkersLimit().release()
 	}
 }
 
@@ -393,21 +429,46 @@ func (s *syncCopyMove) pairCopyOrMove(ctx context.Context, in *pipe, fdst fs.Fs,
 		if !ok {
 			return
 		}
+		s.transfersLimit().acquire()
 		src := pair.Src
 		if s.DoMove {
 			_, err = operations.Move(ctx, fdst, pair.Dst, src.Remote(), src)
 		} else {
 			_, err = operations.Copy(ctx, fdst, pair.Dst, src.Remote(), src)
 		}
+		s.transfersLimit().release()
 		s.processError(err)
 	}
 }
 
+// checkersLimit returns the limit on the active checkers or nil if
+// there isn't one
+func (s *syncCopyMove) checkersLimit() *concurrencyLimit {
+	if s.autoTune == nil {
+		return nil
+	}
+	return s.autoTune.checkers
+}
+
+// transfersLimit returns the limit on the active transfers or nil if
+// there isn't one
+func (s *syncCopyMove) transfersLimit() *concurrencyLimit {
+	if s.autoTune == nil {
+		return nil
+	}
+	return s.autoTune.transfers
+}
+
 // This starts the background checkers.
 func (s *syncCopyMove) startCheckers() {
-	s.checkerWg.Add(s.ci.Checkers)
-	for i := 0; i < s.ci.Checkers; i++ {
-		fraction := (100 * i) / s.ci.Checkers
+	checkers := s.ci.Checkers
+	if s.autoTune != nil {
+		// start enough checkers for the largest limit
+		checkers = 2 * autoTuneMaxTransfers
+	}
+	s.checkerWg.Add(checkers)
+	for i := 0; i < checkers; i++ {
+		fraction := (100 * i) / checkers
 		go s.pairChecker(s.toBeChecked, s.toBeUploaded, fraction, &s.checkerWg)
 	}
 }
@@ -421,9 +482,14 @@ func (s *syncCopyMove) stopCheckers() {
 
 // This starts the background transfers
 func (s *syncCopyMove) startTransfers() {
-	s.transfersWg.Add(s.ci.Transfers)
-	for i := 0; i < s.ci.Transfers; i++ {
-		fraction := (100 * i) / s.ci.Transfers
+	transfers := s.ci.Transfers
+	if s.autoTune != nil {
+		// start enough transfers for the largest limit
+		transfers = autoTuneMaxTransfers
+	}
+	s.transfersWg.Add(transfers)
+	for i := 0; i < transfers; i++ {
+		fraction := (100 * i) / transfers
 		go s.pairCopyOrMove(s.ctx, s.toBeUploaded, s.fdst, fraction, &s.transfersWg)
 	}
 }
@@ -642,6 +708,8 @@ func parseTrackRenamesStrategy(strategies string) (strategy trackRenamesStrategy
 			strategy |= trackRenamesStrategyModtime
 		case "leaf":
 			strategy |= trackRenamesStrategyLeaf
+		case "id":
+			strategy |= trackRenamesStrategyID
 		case "size":
 			// ignore
 		default:
@@ -653,8 +721,10 @@ func parseTrackRenamesStrategy(strategies string) (strategy trackRenamesStrategy
 
 // renameID makes a string with the size and the other identifiers of the requested rename strategies
 //
+// isSrc should be set if obj is from the source
+//
 // it may return an empty string in which case no hash could be made
-func (s *syncCopyMove) renameID(obj fs.Object, renamesStrategy trackRenamesStrategy, precision time.Duration) string {
+func (s *syncCopyMove) renameID(obj fs.Object, renamesStrategy trackRenamesStrategy, precision time.Duration, isSrc bool) string {
 	var builder strings.Builder
 
 	fmt.Fprintf(&builder, "%d", obj.Size())
@@ -683,6 +753,20 @@ func (s *syncCopyMove) renameID(obj fs.Object, renamesStrategy trackRenamesStrat
 		builder.WriteString(path.Base(obj.Remote()))
 	}
 
+	if renamesStrategy.id() {
+		var id string
+		if isSrc {
+			id = s.renameIDs.srcID(obj)
+		} else {
+			id = s.renameIDs.dstID(obj)
+		}
+		if id == "" {
+			return ""
+		}
+		builder.WriteRune(',')
+		builder.WriteString(id)
+	}
+
 	return builder.String()
 }
 
@@ -759,7 +843,7 @@ func (s *syncCopyMove) makeRenameMap() {
 				// only create hash for dst fs.Object if its size could match
 				if _, found := possibleSizes[obj.Size()]; found {
 					tr := accounting.Stats(s.ctx).NewCheckingTransfer(obj)
-					hash := s.renameID(obj, s.trackRenamesStrategy, s.modifyWindow)
+					hash := s.renameID(obj, s.trackRenamesStrategy, s.modifyWindow, false)
 
 					if hash != "" {
 						s.pushRenameMap(hash, obj)
@@ -778,7 +862,7 @@ func (s *syncCopyMove) makeRenameMap() {
 // possible, it returns true if the object was renamed.
 func (s *syncCopyMove) tryRename(src fs.Object) bool {
 	// Calculate the hash of the src object
-	hash := s.renameID(src, s.trackRenamesStrategy, fs.GetModifyWindow(s.ctx, s.fsrc, s.fdst))
+	hash := s.renameID(src, s.trackRenamesStrategy, fs.GetModifyWindow(s.ctx, s.fsrc, s.fdst), true)
 
 	if hash == "" {
 		return false
@@ -823,6 +907,9 @@ func (s *syncCopyMove) run() error {
 	}
 
 	// Start background checking and transferring pipeline
+	if s.autoTune != nil {
+		s.autoTune.start()
+	}
 	s.startCheckers()
 	s.startRenamers()
 	if !s.checkFirst {
@@ -869,6 +956,9 @@ func (s *syncCopyMove) run() error {
 	s.stopRenamers()
 	s.stopTransfers()
 	s.stopDeleters()
+	if s.autoTune != nil {
+		s.autoTune.stop()
+	}
 
 	if s.copyEmptySrcDirs {
 		s.processError(copyEmptyDirectories(s.ctx, s.fdst, s.srcEmptyDirs))
@@ -899,9 +989,23 @@ func (s *syncCopyMove) run() error {
 		s.processError(s.deleteEmptyDirectories(s.ctx, s.fsrc, s.srcEmptyDirs))
 	}
 
+	// Report a soft --max-duration cutoff so the sync isn't retried
+	if s.ctx.Err() == nil && s.inCtx.Err() == context.DeadlineExceeded {
+		fs.Logf(s.fdst, "%v - stopped starting new transfers", accounting.ErrorMaxDurationReached)
+		s.processError(accounting.ErrorMaxDurationReachedGraceful)
+	}
+
 	// Read the error out of the context if there is one
 	s.processError(s.ctx.Err())
 
+	// Save the IDs of the source files for the next sync
+	if s.renameIDs != nil && s.currentError() == nil {
+		err := s.renameIDs.save(!s.fi.InActive())
+		if err != nil {
+			fs.Errorf(s.fdst, "Failed to save IDs for --track-renames: %v", err)
+		}
+	}
+
 	// Print nothing to transfer message if there were no transfers and no errors
 	if s.deleteMode != fs.DeleteModeOnly && accounting.Stats(s.ctx).GetTransfers() == 0 && s.currentError() == nil {
 		fs.Infof(nil, "There was nothing to transfer")
@@ -964,6 +1068,9 @@ func (s *syncCopyMove) SrcOnly(src fs.DirEntry) (recurse bool) {
 		s.srcParentDirCheck(src)
 		s.srcEmptyDirsMu.Unlock()
 
+		if s.renameIDs != nil {
+			s.renameIDs.add(x)
+		}
 		if s.trackRenames {
 			// Save object to check for a rename later
 			select {
@@ -1010,6 +1117,9 @@ func (s *syncCopyMove) Match(ctx context.Context, dst, src fs.DirEntry) (recurse
 		if s.deleteMode == fs.DeleteModeOnly {
 			return false
 		}
+		if s.renameIDs != nil {
+			s.renameIDs.add(srcX)
+		}
 		dstX, ok := dst.(fs.Object)
 		if ok {
 			ok = s.toBeChecked.Put(s.ctx, fs.ObjectPair{Src: srcX, Dst: dstX})
@@ -1054,18 +1164,35 @@ func (s *syncCopyMove) Match(ctx context.Context, dst, src fs.DirEntry) (recurse
 // If DoMove is true then files will be moved instead of copied
 //
 // dir is the start directory, "" for root
-func runSyncCopyMove(ctx context.Context, fdst, fsrc fs.Fs, deleteMode fs.DeleteMode, DoMove bool, deleteEmptySrcDirs bool, copyEmptySrcDirs bool) error {
+func runSyncCopyMove(ctx context.Context, fdst, fsrc fs.Fs, deleteMode fs.DeleteMode, DoMove bool, deleteEmptySrcDirs bool, copyEmptySrcDirs bool) (err error) {
 	ci := fs.GetConfig(ctx)
+	if ci.LogResults != "" {
+		defer func() {
+			closeErr := accounting.CloseResultsLog()
+			if closeErr == nil {
+				return
+			}
+			if err == nil {
+				err = errors.Wrap(closeErr, "failed to close --log-results file")
+			} else {
+				fs.Errorf(nil, "Failed to close --log-results file: %v", closeErr)
+			}
+		}()
+	}
 	if deleteMode != fs.DeleteModeOff && DoMove {
 		return fserrors.FatalError(errors.New("can't delete and move at the same time"))
 	}
+	if ci.DeleteDelayedTo != "" {
+		return runStaged(ctx, fdst, fsrc, deleteMode, DoMove, deleteEmptySrcDirs, copyEmptySrcDirs)
+	}
 	// Run an extra pass to delete only
+	var do *syncCopyMove
 	if deleteMode == fs.DeleteModeBefore {
 		if ci.TrackRenames {
 			return fserrors.FatalError(errors.New("can't use --delete-before with --track-renames"))
 		}
 		// only delete stuff during in this pass
-		do, err := newSyncCopyMove(ctx, fdst, fsrc, fs.DeleteModeOnly, false, deleteEmptySrcDirs, copyEmptySrcDirs)
+		do, err = newSyncCopyMove(ctx, fdst, fsrc, fs.DeleteModeOnly, false, deleteEmptySrcDirs, copyEmptySrcDirs)
 		if err != nil {
 			return err
 		}
@@ -1076,7 +1203,7 @@ func runSyncCopyMove(ctx context.Context, fdst, fsrc fs.Fs, deleteMode fs.Delete
 		// Next pass does a copy only
 		deleteMode = fs.DeleteModeOff
 	}
-	do, err := newSyncCopyMove(ctx, fdst, fsrc, deleteMode, DoMove, deleteEmptySrcDirs, copyEmptySrcDirs)
+	do, err = newSyncCopyMove(ctx, fdst, fsrc, deleteMode, DoMove, deleteEmptySrcDirs, copyEmptySrcDirs)
 	if err != nil {
 		return err
 	}
@@ -19,6 +19,8 @@ import (
 	"github.com/pingme998/rclone/fs/hash"
 	"github.com/pingme998/rclone/fs/operations"
 	"github.com/pingme998/rclone/fstest"
+	"github.com/pingme998/rclone/fstest/chaos"
+	"github.com/pingme998/rclone/lib/random"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"golang.org/x/text/unicode/norm"
@@ -1043,6 +1045,7 @@ func TestSyncWithMaxDuration(t *testing.T) {
 
 	maxDuration := 250 * time.Millisecond
 	ci.MaxDuration = maxDuration
+	ci.PartialSuffix = ".partial"
 	bytesPerSecond := 300
 	accounting.TokenBucket.SetBwLimit(fs.BwPair{Tx: fs.SizeSuffix(bytesPerSecond), Rx: fs.SizeSuffix(bytesPerSecond)})
 	ci.Transfers = 1
@@ -1069,6 +1072,51 @@ func TestSyncWithMaxDuration(t *testing.T) {
 	require.True(t, elapsed < 5*time.Second, what)
 	// we must not have transferred all files during the session
 	require.True(t, accounting.GlobalStats().GetTransfers() < int64(len(testFiles)))
+	// and the interrupted transfer mustn't leave a partial file
+	entries, err := r.Fremote.List(ctx, "")
+	require.NoError(t, err)
+	for _, entry := range entries {
+		assert.False(t, strings.HasSuffix(entry.Remote(), ci.PartialSuffix), entry.Remote())
+	}
+}
+
+// Test with a max transfer duration and a soft cutoff
+func TestSyncWithMaxDurationSoft(t *testing.T) {
+	ctx := context.Background()
+	ctx, ci := fs.AddConfig(ctx)
+	if *fstest.RemoteName != "" {
+		t.Skip("Skipping test on non local remote")
+	}
+	r := fstest.NewRun(t)
+	defer r.Finalise()
+
+	ci.MaxDuration = 250 * time.Millisecond
+	ci.CutoffMode = fs.CutoffModeSoft
+	bytesPerSecond := 300
+	accounting.TokenBucket.SetBwLimit(fs.BwPair{Tx: fs.SizeSuffix(bytesPerSecond), Rx: fs.SizeSuffix(bytesPerSecond)})
+	ci.Transfers = 1
+	defer accounting.TokenBucket.SetBwLimit(fs.BwPair{Tx: -1, Rx: -1})
+
+	testFiles := make([]fstest.Item, 5)
+	for i := 0; i < len(testFiles); i++ {
+		testFiles[i] = r.WriteFile(fmt.Sprintf("file%d", i), "------------------------------------------------------------", t1)
+	}
+
+	accounting.GlobalStats().ResetCounters()
+	err := Sync(ctx, r.Fremote, r.Flocal, false)
+	require.Equal(t, accounting.ErrorMaxDurationReached, errors.Cause(err))
+	assert.True(t, fserrors.IsNoRetryError(err))
+
+	// the running transfers finished and no new ones were started
+	transfers := accounting.GlobalStats().GetTransfers()
+	assert.True(t, transfers > 0 && transfers < int64(len(testFiles)), "transfers = %d", transfers)
+	assert.Equal(t, int64(0), accounting.GlobalStats().GetErrors())
+	entries, err := r.Fremote.List(ctx, "")
+	require.NoError(t, err)
+	assert.Equal(t, int(transfers), len(entries))
+	for _, entry := range entries {
+		assert.Equal(t, int64(60), entry.Size(), entry.Remote())
+	}
 }
 
 // Test with TrackRenames set
@@ -1123,6 +1171,8 @@ func TestParseRenamesStrategyModtime(t *testing.T) {
 		{"size", 0, false},
 		{"modtime,hash", trackRenamesStrategyModtime | trackRenamesStrategyHash, false},
 		{"hash,modtime,size", trackRenamesStrategyModtime | trackRenamesStrategyHash, false},
+		{"id", trackRenamesStrategyID, false},
+		{"id,leaf", trackRenamesStrategyID | trackRenamesStrategyLeaf, false},
 		{"size,boom", 0, true},
 	} {
 		got, err := parseTrackRenamesStrategy(test.in)
@@ -1742,6 +1792,80 @@ func TestSyncBackupDirSuffixOnly(t *testing.T) {
 	testSyncBackupDir(t, "", ".bak", false)
 }
 
+// Test with --delete-delayed-to when the sync succeeds
+func TestSyncDeleteDelayedTo(t *testing.T) {
+	ctx := context.Background()
+	ctx, ci := fs.AddConfig(ctx)
+	r := fstest.NewRun(t)
+	defer r.Finalise()
+
+	if !operations.CanServerSideMove(r.Fremote) {
+		t.Skip("Skipping test as remote does not support server-side move")
+	}
+	r.Mkdir(ctx, r.Fremote)
+	ci.DeleteDelayedTo = r.FremoteName + "/staging"
+
+	// Make the setup so we have one, two, three in the dest
+	// and one (different), two (same) in the source
+	r.WriteObject(ctx, "dst/one", "one", t1)
+	file2 := r.WriteObject(ctx, "dst/two", "two", t1)
+	r.WriteObject(ctx, "dst/three", "three", t1)
+	r.WriteFile("two", "two", t1)
+	file1a := r.WriteFile("one", "oneA", t2)
+
+	fdst, err := fs.NewFs(ctx, r.FremoteName+"/dst")
+	require.NoError(t, err)
+
+	accounting.GlobalStats().ResetCounters()
+	require.NoError(t, Sync(ctx, fdst, r.Flocal, false))
+
+	// The staged files should have been deleted
+	file1a.Path = "dst/one"
+	fstest.CheckItems(t, r.Fremote, file1a, file2)
+}
+
+// Test with --delete-delayed-to when the sync fails
+func TestSyncDeleteDelayedToRollback(t *testing.T) {
+	ctx := context.Background()
+	ctx, ci := fs.AddConfig(ctx)
+	r := fstest.NewRun(t)
+	defer r.Finalise()
+
+	if !operations.CanServerSideMove(r.Fremote) {
+		t.Skip("Skipping test as remote does not support server-side move")
+	}
+	r.Mkdir(ctx, r.Fremote)
+	ci.DeleteDelayedTo = r.FremoteName + "/staging"
+	ci.DeleteMode = fs.DeleteModeDuring
+	// Make the sync fail as two has been modified
+	ci.Immutable = true
+
+	// Make the setup so we have two, three in the dest
+	// and one, two (different) in the source
+	file2 := r.WriteObject(ctx, "dst/two", "two", t1)
+	file3 := r.WriteObject(ctx, "dst/three", "three", t1)
+	file1 := r.WriteFile("one", "one", t1)
+	r.WriteFile("two", "twoA", t2)
+
+	fdst, err := fs.NewFs(ctx, r.FremoteName+"/dst")
+	require.NoError(t, err)
+
+	accounting.GlobalStats().ResetCounters()
+	err = Sync(ctx, fdst, r.Flocal, false)
+	require.Error(t, err)
+
+	// three should have been moved back and the staging dir emptied
+	file1.Path = "dst/one"
+	fstest.CheckItems(t, r.Fremote, file1, file2, file3)
+
+	// Check a staging dir which isn't empty is refused
+	ci.Immutable = false
+	r.WriteObject(ctx, "staging/left", "left", t1)
+	err = Sync(ctx, fdst, r.Flocal, false)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "isn't empty")
+}
+
 // Test with Suffix set
 func testSyncSuffix(t *testing.T, suffix string, suffixKeepExtension bool) {
 	ctx := context.Background()
@@ -1963,3 +2087,72 @@ func TestMaxTransfer(t *testing.T) {
 	t.Run("Soft", func(t *testing.T) { test(t, fs.CutoffModeSoft) })
 	t.Run("Cautious", func(t *testing.T) { test(t, fs.CutoffModeCautious) })
 }
+
+// Test a sync with --transfers auto
+func TestSyncTransfersAuto(t *testing.T) {
+	ctx := context.Background()
+	ctx, ci := fs.AddConfig(ctx)
+	r := fstest.NewRun(t)
+	defer r.Finalise()
+	ci.TransfersAuto = true
+
+	file1 := r.WriteFile("one", "one", t1)
+	file2 := r.WriteFile("sub dir/two", "two", t2)
+	r.WriteObject(ctx, "three", "three", t3)
+
+	accounting.GlobalStats().ResetCounters()
+	err := Sync(ctx, r.Fremote, r.Flocal, false)
+	require.NoError(t, err)
+
+	fstest.CheckItems(t, r.Flocal, file1, file2)
+	fstest.CheckItems(t, r.Fremote, file1, file2)
+}
+
+// Test a sync copes with transient errors, latency and truncated
+// reads injected into the source and the destination
+func TestSyncWithFaults(t *testing.T) {
+	ctx := context.Background()
+	ctx, ci := fs.AddConfig(ctx)
+	r := fstest.NewRun(t)
+	defer r.Finalise()
+	ci.LowLevelRetries = 50
+
+	seed := *fstest.ChaosSeed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	t.Logf("Injecting faults with -chaos-seed %d", seed)
+	opt := chaos.Options{
+		Seed:         seed,
+		ErrorRate:    0.3,
+		TruncateRate: 0.3,
+		MaxLatency:   10 * time.Millisecond,
+	}
+	fsrc := chaos.NewFs(ctx, r.Flocal, opt)
+	opt.Seed++
+	fdst := chaos.NewFs(ctx, r.Fremote, opt)
+
+	var items []fstest.Item
+	for i := 0; i < 40; i++ {
+		items = append(items, r.WriteFile(fmt.Sprintf("dir%d/file%d", i%4, i), random.String(100+i*97), t1))
+	}
+	r.Mkdir(ctx, r.Fremote)
+
+	accounting.GlobalStats().ResetCounters()
+	err := Sync(ctx, fdst, fsrc, false)
+	require.NoError(t, err)
+	fstest.CheckItems(t, r.Fremote, items...)
+
+	// Change some of the files so they are updated
+	for i := 0; i < len(items); i += 3 {
+		items[i] = r.WriteFile(items[i].Path, random.String(200+i*31), t2)
+	}
+	accounting.GlobalStats().ResetCounters()
+	err = Sync(ctx, fdst, fsrc, false)
+	require.NoError(t, err)
+	fstest.CheckItems(t, r.Fremote, items...)
+
+	srcStats, dstStats := fsrc.Stats(), fdst.Stats()
+	assert.NotZero(t, srcStats.Errors+dstStats.Errors, "no errors injected")
+	assert.NotZero(t, srcStats.Truncated, "no reads truncated")
+}
@@ -0,0 +1,284 @@
+// Package chaos provides an Fs wrapper which injects faults for testing
+//
+// It is used to check that the retries in the sync layer and the
+// backends cope with transient errors, slow responses and reads which
+// are cut short.
+package chaos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/pingme998/rclone/fs"
+	"github.com/pingme998/rclone/fs/fserrors"
+)
+
+// Errors injected
+var (
+	ErrInjected  = errors.New("chaos: injected transient error")
+	ErrTruncated = errors.New("chaos: injected truncated read")
+)
+
+// Options control the faults injected
+//
+// Errors and truncated reads are only injected into the operations
+// which rclone retries: Put, Update and Open. Latency is added to
+// every operation.
+type Options struct {
+	Seed         int64         // seed for the random faults
+	ErrorRate    float64       // probability of an operation returning a transient error
+	TruncateRate float64       // probability of a read being cut short
+	MaxLatency   time.Duration // maximum random delay added to each operation
+}
+
+// Stats are counts of the faults injected
+type Stats struct {
+	Errors    int // number of transient errors injected
+	Truncated int // number of reads cut short
+}
+
+// Fs wraps an fs.Fs injecting faults
+type Fs struct {
+	fs.Fs
+	opt      Options
+	features *fs.Features
+
+	mu    sync.Mutex // protects the following
+	rnd   *rand.Rand // source of the faults
+	stats Stats      // faults injected so far
+}
+
+// Object wraps an fs.Object injecting faults
+type Object struct {
+	fs.Object
+	f *Fs
+}
+
+// NewFs returns f wrapped so it injects the faults in opt
+//
+// The same seed gives the same faults for the same sequence of
+// operations.
+func NewFs(ctx context.Context, f fs.Fs, opt Options) *Fs {
+	cf := &Fs{
+		Fs:  f,
+		opt: opt,
+		rnd: rand.New(rand.NewSource(opt.Seed)),
+	}
+	cf.features = (&fs.Features{}).Fill(ctx, cf).Mask(ctx, f).WrapsFs(cf, f)
+	return cf
+}
+
+// Stats returns the faults injected so far
+func (f *Fs) Stats() Stats {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.stats
+}
+
+// float64 returns a random number in [0,1)
+func (f *Fs) float64() float64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.rnd.Float64()
+}
+
+// int63n returns a random number in [0,n)
+func (f *Fs) int63n(n int64) int64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.rnd.Int63n(n)
+}
+
+// delay sleeps for a random time up to MaxLatency
+func (f *Fs) delay(ctx context.Context) error {
+	if f.opt.MaxLatency <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(time.Duration(f.int63n(int64(f.opt.MaxLatency))))
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// fault adds latency and returns a transient error at ErrorRate
+func (f *Fs) fault(ctx context.Context, what string) error {
+	if err := f.delay(ctx); err != nil {
+		return err
+	}
+	if f.float64() >= f.opt.ErrorRate {
+		return nil
+	}
+	f.mu.Lock()
+	f.stats.Errors++
+	f.mu.Unlock()
+	fs.Debugf(f, "Injecting error into %s", what)
+	return fserrors.RetryError(ErrInjected)
+}
+
+// wrap returns o wrapped if it is an object
+func (f *Fs) wrap(o fs.Object) fs.Object {
+	if o == nil {
+		return nil
+	}
+	return &Object{Object: o, f: f}
+}
+
+// String returns a description of the FS
+func (f *Fs) String() string {
+	return fmt.Sprintf("chaos(%v)", f.Fs)
+}
+
+// Features returns the optional features of this Fs
+func (f *Fs) Features() *fs.Features {
+	return f.features
+}
+
+// List the objects and directories in dir into entries
+func (f *Fs) List(ctx context.Context, dir string) (entries fs.DirEntries, err error) {
+	if err := f.delay(ctx); err != nil {
+		return nil, err
+	}
+	entries, err = f.Fs.List(ctx, dir)
+	if err != nil {
+		return nil, err
+	}
+	for i, entry := range entries {
+		if o, ok := entry.(fs.Object); ok {
+			entries[i] = f.wrap(o)
+		}
+	}
+	return entries, nil
+}
+
+// NewObject finds the Object at remote
+func (f *Fs) NewObject(ctx context.Context, remote string) (fs.Object, error) {
+	if err := f.delay(ctx); err != nil {
+		return nil, err
+	}
+	o, err := f.Fs.NewObject(ctx, remote)
+	if err != nil {
+		return nil, err
+	}
+	return f.wrap(o), nil
+}
+
+// Put in to the remote path with the modTime given of the given size
+func (f *Fs) Put(ctx context.Context, in io.Reader, src fs.ObjectInfo, options ...fs.OpenOption) (fs.Object, error) {
+	if err := f.fault(ctx, "Put"); err != nil {
+		return nil, err
+	}
+	o, err := f.Fs.Put(ctx, in, src, options...)
+	return f.wrap(o), err
+}
+
+// Mkdir makes the directory
+func (f *Fs) Mkdir(ctx context.Context, dir string) error {
+	if err := f.delay(ctx); err != nil {
+		return err
+	}
+	return f.Fs.Mkdir(ctx, dir)
+}
+
+// Rmdir removes the directory if empty
+func (f *Fs) Rmdir(ctx context.Context, dir string) error {
+	if err := f.delay(ctx); err != nil {
+		return err
+	}
+	return f.Fs.Rmdir(ctx, dir)
+}
+
+// UnWrap returns the Fs that this Fs is wrapping
+func (f *Fs) UnWrap() fs.Fs {
+	return f.Fs
+}
+
+// Fs returns the chaos Fs the object is part of
+func (o *Object) Fs() fs.Info {
+	return o.f
+}
+
+// UnWrap returns the wrapped Object
+func (o *Object) UnWrap() fs.Object {
+	return o.Object
+}
+
+// SetModTime sets the modification time of the object
+func (o *Object) SetModTime(ctx context.Context, modTime time.Time) error {
+	if err := o.f.delay(ctx); err != nil {
+		return err
+	}
+	return o.Object.SetModTime(ctx, modTime)
+}
+
+// Open an object for read, cutting the read short at TruncateRate
+func (o *Object) Open(ctx context.Context, options ...fs.OpenOption) (io.ReadCloser, error) {
+	if err := o.f.fault(ctx, "Open"); err != nil {
+		return nil, err
+	}
+	in, err := o.Object.Open(ctx, options...)
+	if err != nil || o.f.float64() >= o.f.opt.TruncateRate {
+		return in, err
+	}
+	o.f.mu.Lock()
+	o.f.stats.Truncated++
+	o.f.mu.Unlock()
+	var n int64
+	if size := o.Size(); size > 0 {
+		n = o.f.int63n(size)
+	}
+	fs.Debugf(o, "Truncating read after %d bytes", n)
+	return &truncatedReader{ReadCloser: in, n: n}, nil
+}
+
+// Update the object with the contents of in
+func (o *Object) Update(ctx context.Context, in io.Reader, src fs.ObjectInfo, options ...fs.OpenOption) error {
+	if err := o.f.fault(ctx, "Update"); err != nil {
+		return err
+	}
+	return o.Object.Update(ctx, in, src, options...)
+}
+
+// Remove the object
+func (o *Object) Remove(ctx context.Context) error {
+	if err := o.f.delay(ctx); err != nil {
+		return err
+	}
+	return o.Object.Remove(ctx)
+}
+
+// truncatedReader returns ErrTruncated after n bytes as a retriable
+// error like a dropped connection would give
+type truncatedReader struct {
+	io.ReadCloser
+	n int64
+}
+
+// Read up to n bytes then return ErrTruncated
+func (r *truncatedReader) Read(p []byte) (n int, err error) {
+	if r.n <= 0 {
+		return 0, fserrors.RetryError(ErrTruncated)
+	}
+	if int64(len(p)) > r.n {
+		p = p[:r.n]
+	}
+	n, err = r.ReadCloser.Read(p)
+	r.n -= int64(n)
+	return n, err
+}
+
+// Check the interfaces are satisfied
+var (
+	_ fs.Fs              = (*Fs)(nil)
+	_ fs.UnWrapper       = (*Fs)(nil)
+	_ fs.Object          = (*Object)(nil)
+	_ fs.ObjectUnWrapper = (*Object)(nil)
+)
@@ -47,6 +47,8 @@ var (
 	SizeLimit = flag.Int64("size-limit", 0, "Limit maximum test file size")
 	// ListRetries is the number of times to retry a listing to overcome eventual consistency
 	ListRetries = flag.Int("list-retries", 3, "Number or times to retry listing")
+	// ChaosSeed is the seed for the faults injected by the chaos tests
+	ChaosSeed = flag.Int64("chaos-seed", 0, "Seed for the faults injected by the chaos tests, 0 for a random one")
 	// MatchTestRemote matches the remote names used for testing
 	MatchTestRemote = regexp.MustCompile(`^rclone-test-[abcdefghijklmnopqrstuvwxyz0123456789]{24}$`)
 )
@@ -0,0 +1,235 @@
+package fstest
+
+// Snapshots of remote trees for comparing against golden files
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/pingme998/rclone/fs"
+	"github.com/pingme998/rclone/fs/hash"
+	"github.com/pingme998/rclone/fs/walk"
+	"github.com/stretchr/testify/require"
+)
+
+// UpdateSnapshots makes CheckGolden write the golden files rather
+// than check against them
+var UpdateSnapshots = flag.Bool("update-snapshots", false, "Rewrite the snapshot golden files instead of checking against them")
+
+// SnapshotEntry is a file or empty directory in a Snapshot
+type SnapshotEntry struct {
+	Path   string            `json:"path"`             // path relative to the root of the snapshot
+	Dir    bool              `json:"dir,omitempty"`    // set if this is an empty directory
+	Size   int64             `json:"size,omitempty"`   // size of the file
+	Hashes map[string]string `json:"hashes,omitempty"` // hashes of the file by name
+}
+
+// Snapshot is a listing of a tree of a remote which can be saved as
+// a golden file and compared against later.
+//
+// It contains the files and the empty directories sorted by path.
+// Directories with something in are implied by the paths of their
+// contents so aren't recorded.
+type Snapshot struct {
+	Entries []SnapshotEntry `json:"entries"`
+}
+
+// NewSnapshot takes a snapshot of the tree at dir in f recording the
+// hashes in hashes which f supports.
+func NewSnapshot(ctx context.Context, f fs.Fs, dir string, hashes hash.Set) (*Snapshot, error) {
+	hashTypes := hashes.Overlap(f.Hashes()).Array()
+	relative := func(remote string) string {
+		if dir != "" {
+			remote = strings.TrimPrefix(remote, dir+"/")
+		}
+		return Normalize(remote)
+	}
+	var s Snapshot
+	var dirs []string
+	parents := map[string]struct{}{}
+	err := walk.ListR(ctx, f, dir, true, -1, walk.ListAll, func(entries fs.DirEntries) error {
+		for _, entry := range entries {
+			remote := relative(entry.Remote())
+			for parent := remote; parent != ""; {
+				parent = parentDir(parent)
+				parents[parent] = struct{}{}
+			}
+			switch x := entry.(type) {
+			case fs.Directory:
+				dirs = append(dirs, remote)
+			case fs.Object:
+				e := SnapshotEntry{
+					Path: remote,
+					Size: x.Size(),
+				}
+				for _, ht := range hashTypes {
+					sum, err := x.Hash(ctx, ht)
+					if err == hash.ErrUnsupported {
+						continue
+					} else if err != nil {
+						return errors.Wrapf(err, "failed to read %v hash of %q", ht, remote)
+					}
+					if sum == "" {
+						continue
+					}
+					if e.Hashes == nil {
+						e.Hashes = map[string]string{}
+					}
+					e.Hashes[ht.String()] = sum
+				}
+				s.Entries = append(s.Entries, e)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, remote := range dirs {
+		if _, found := parents[remote]; !found {
+			s.Entries = append(s.Entries, SnapshotEntry{Path: remote, Dir: true})
+		}
+	}
+	s.sort()
+	return &s, nil
+}
+
+// parentDir returns the parent directory of remote or "" if it is
+// in the root
+func parentDir(remote string) string {
+	i := strings.LastIndex(remote, "/")
+	if i < 0 {
+		return ""
+	}
+	return remote[:i]
+}
+
+// sort sorts the entries by path
+func (s *Snapshot) sort() {
+	sort.Slice(s.Entries, func(i, j int) bool {
+		return s.Entries[i].Path < s.Entries[j].Path
+	})
+}
+
+// ReadSnapshot reads a snapshot from the golden file fileName
+func ReadSnapshot(fileName string) (*Snapshot, error) {
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	var s Snapshot
+	err = json.Unmarshal(data, &s)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse snapshot %q", fileName)
+	}
+	s.sort()
+	return &s, nil
+}
+
+// Write writes the snapshot to the golden file fileName making any
+// directories needed
+func (s *Snapshot) Write(fileName string) error {
+	data, err := json.MarshalIndent(s, "", "\t")
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(filepath.Dir(fileName), 0777)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(fileName, append(data, '\n'), 0666)
+}
+
+// describe returns a short description of e for use in diffs
+func (e *SnapshotEntry) describe() string {
+	if e.Dir {
+		return fmt.Sprintf("%q (empty directory)", e.Path)
+	}
+	return fmt.Sprintf("%q (%d bytes)", e.Path, e.Size)
+}
+
+// diffEntry appends the differences between want and got, which have
+// the same path, to diffs
+func diffEntry(diffs []string, want, got *SnapshotEntry) []string {
+	if want.Dir != got.Dir {
+		return append(diffs, fmt.Sprintf("want %s got %s", want.describe(), got.describe()))
+	}
+	if want.Size != got.Size {
+		diffs = append(diffs, fmt.Sprintf("%q: want size %d got %d", want.Path, want.Size, got.Size))
+	}
+	var names []string
+	for name := range want.Hashes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		wantSum, gotSum := want.Hashes[name], got.Hashes[name]
+		if gotSum != "" && !strings.EqualFold(wantSum, gotSum) {
+			diffs = append(diffs, fmt.Sprintf("%q: want %s %s got %s", want.Path, name, wantSum, gotSum))
+		}
+	}
+	return diffs
+}
+
+// Diff returns a description of each difference between the snapshot
+// as wanted and got, or nil if they are the same.
+//
+// Hashes are only compared if they are in both snapshots so a golden
+// file made with one remote can be checked against another.
+func (s *Snapshot) Diff(got *Snapshot) (diffs []string) {
+	want := s.Entries
+	have := got.Entries
+	for len(want) > 0 || len(have) > 0 {
+		switch {
+		case len(have) == 0 || (len(want) > 0 && want[0].Path < have[0].Path):
+			diffs = append(diffs, "missing "+want[0].describe())
+			want = want[1:]
+		case len(want) == 0 || have[0].Path < want[0].Path:
+			diffs = append(diffs, "unexpected "+have[0].describe())
+			have = have[1:]
+		default:
+			diffs = diffEntry(diffs, &want[0], &have[0])
+			want, have = want[1:], have[1:]
+		}
+	}
+	return diffs
+}
+
+// AssertSnapshot checks got is the same as want reporting each
+// difference if not
+func AssertSnapshot(t *testing.T, want, got *Snapshot, what string) bool {
+	diffs := want.Diff(got)
+	if len(diffs) == 0 {
+		return true
+	}
+	t.Errorf("%s: %d differences from snapshot:\n  %s", what, len(diffs), strings.Join(diffs, "\n  "))
+	return false
+}
+
+// CheckGolden takes a snapshot of the tree at dir in f and checks it
+// against the golden file fileName, or rewrites the golden file if
+// -update-snapshots is set.
+//
+// Only the hashes in hashes are recorded - use hash.Set(hash.None)
+// to record just the names and sizes.
+func CheckGolden(t *testing.T, f fs.Fs, dir string, hashes hash.Set, fileName string) {
+	got, err := NewSnapshot(context.Background(), f, dir, hashes)
+	require.NoError(t, err)
+	if *UpdateSnapshots {
+		t.Logf("Updating golden file %q", fileName)
+		require.NoError(t, got.Write(fileName))
+		return
+	}
+	want, err := ReadSnapshot(fileName)
+	require.NoError(t, err, "run with -update-snapshots to make the golden file")
+	AssertSnapshot(t, want, got, fmt.Sprintf("%v compared with %q", f, fileName))
+}
@@ -0,0 +1,64 @@
+package fstest
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pingme998/rclone/fs/hash"
+	"github.com/pingme998/rclone/fstest/mockfs"
+	"github.com/pingme998/rclone/fstest/mockobject"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshot(t *testing.T) {
+	ctx := context.Background()
+	f := mockfs.NewFs(ctx, "mock", "root")
+	f.SetHashes(hash.NewHashSet(hash.MD5))
+	f.AddObject(mockobject.New("b.txt").WithContent([]byte("potato"), mockobject.SeekModeNone))
+	f.AddObject(mockobject.New("a.txt").WithContent([]byte("hello"), mockobject.SeekModeNone))
+
+	s, err := NewSnapshot(ctx, f, "", hash.NewHashSet(hash.MD5, hash.SHA1))
+	require.NoError(t, err)
+	assert.Equal(t, []SnapshotEntry{
+		{Path: "a.txt", Size: 5, Hashes: map[string]string{"MD5": "5d41402abc4b2a76b9719d911017c592"}},
+		{Path: "b.txt", Size: 6, Hashes: map[string]string{"MD5": "8ee2027983915ec78acc45027d874316"}},
+	}, s.Entries)
+
+	// Check it round trips through a golden file
+	tempDir, err := ioutil.TempDir("", "rclone-snapshot")
+	require.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(tempDir)
+	}()
+	fileName := filepath.Join(tempDir, "golden", "snapshot.json")
+	require.NoError(t, s.Write(fileName))
+	loaded, err := ReadSnapshot(fileName)
+	require.NoError(t, err)
+	assert.Nil(t, s.Diff(loaded))
+	CheckGolden(t, f, "", hash.NewHashSet(hash.MD5), fileName)
+
+	// Hashes missing from one side aren't compared
+	noHashes, err := NewSnapshot(ctx, f, "", hash.Set(hash.None))
+	require.NoError(t, err)
+	assert.Nil(t, s.Diff(noHashes))
+
+	// Check the differences are reported
+	got := &Snapshot{Entries: []SnapshotEntry{
+		{Path: "a.txt", Size: 6, Hashes: map[string]string{"MD5": "8EE2027983915EC78ACC45027D874316"}},
+		{Path: "b.txt", Dir: true},
+		{Path: "c.txt", Size: 1},
+	}}
+	assert.Equal(t, []string{
+		`"a.txt": want size 5 got 6`,
+		`"a.txt": want MD5 5d41402abc4b2a76b9719d911017c592 got 8EE2027983915EC78ACC45027D874316`,
+		`want "b.txt" (6 bytes) got "b.txt" (empty directory)`,
+		`unexpected "c.txt" (1 bytes)`,
+	}, s.Diff(got))
+	assert.Equal(t, []string{
+		`missing "c.txt" (1 bytes)`,
+	}, got.Diff(&Snapshot{Entries: got.Entries[:2]}))
+}
@@ -5,6 +5,7 @@
 package atexit
 
 import (
+	"context"
 	"os"
 	"os/signal"
 	"sync"
@@ -15,7 +16,10 @@ import (
 
 var (
 	fns          = make(map[FnHandle]bool)
+	gracefulFns  = make(map[GracefulHandle]bool)
 	fnsMutex     sync.Mutex
+	graceMu      sync.Mutex         // protects graceCancel
+	graceCancel  context.CancelFunc // cancels the grace period if running
 	exitChan     chan os.Signal
 	exitOnce     sync.Once
 	registerOnce sync.Once
@@ -27,37 +31,141 @@ var (
 // that can be used to unregister an at-exit function
 type FnHandle *func()
 
-// Register a function to be called on exit.
-// Returns a handle which can be used to unregister the function with `Unregister`.
-func Register(fn func()) FnHandle {
-	if running() {
-		return nil
-	}
-	fnsMutex.Lock()
-	fns[&fn] = true
-	fnsMutex.Unlock()
-
+// installSignalHandler installs the signal handler if it isn't
+// already installed
+func installSignalHandler() {
 	// Run AtExit handlers on exitSignals so everything gets tidied up properly
 	registerOnce.Do(func() {
 		exitChan = make(chan os.Signal, 1)
 		signal.Notify(exitChan, exitSignals...)
 		go func() {
-			sig := <-exitChan
+			ch := exitChan
+			sig := <-ch
 			if sig == nil {
 				return
 			}
-			signal.Stop(exitChan)
 			atomic.StoreInt32(&signalled, 1)
 			fs.Infof(nil, "Signal received: %s", sig)
+			if hasGraceful() {
+				// A second signal stops waiting for the
+				// graceful shutdown then any more get the
+				// default behaviour
+				go func() {
+					if sig := <-ch; sig != nil {
+						fs.Infof(nil, "Signal received: %s - not waiting for graceful shutdown", sig)
+						signal.Stop(ch)
+						cancelGracePeriod()
+					}
+				}()
+			} else {
+				signal.Stop(ch)
+			}
 			Run()
 			fs.Infof(nil, "Exiting...")
 			os.Exit(0)
 		}()
 	})
+}
+
+// Register a function to be called on exit.
+// Returns a handle which can be used to unregister the function with `Unregister`.
+func Register(fn func()) FnHandle {
+	if running() {
+		return nil
+	}
+	fnsMutex.Lock()
+	fns[&fn] = true
+	fnsMutex.Unlock()
+
+	installSignalHandler()
 
 	return &fn
 }
 
+// GracefulHandle is the type of the handle returned by function
+// `RegisterGraceful` that can be used to unregister the function with
+// `UnregisterGraceful`
+type GracefulHandle *func(ctx context.Context)
+
+// RegisterGraceful registers a function to be called on exit to shut
+// down gracefully, for example by waiting for uploads in progress to
+// finish.
+//
+// The graceful functions are called in parallel before the functions
+// registered with Register, but only if the exit was started by a
+// signal. The ctx passed in is cancelled when the
+// grace period set with --shutdown-grace-period expires, or a second
+// signal is received, and the functions should return promptly when
+// it is.
+func RegisterGraceful(fn func(ctx context.Context)) GracefulHandle {
+	if running() {
+		return nil
+	}
+	installSignalHandler()
+	fnsMutex.Lock()
+	gracefulFns[&fn] = true
+	fnsMutex.Unlock()
+	return &fn
+}
+
+// UnregisterGraceful a function using the handle returned by
+// `RegisterGraceful`
+func UnregisterGraceful(handle GracefulHandle) {
+	if running() {
+		return
+	}
+	fnsMutex.Lock()
+	defer fnsMutex.Unlock()
+	delete(gracefulFns, handle)
+}
+
+// hasGraceful returns true if any graceful functions are registered
+func hasGraceful() bool {
+	fnsMutex.Lock()
+	defer fnsMutex.Unlock()
+	return len(gracefulFns) > 0
+}
+
+// cancelGracePeriod stops waiting for the graceful functions if they
+// are running
+func cancelGracePeriod() {
+	graceMu.Lock()
+	if graceCancel != nil {
+		graceCancel()
+	}
+	graceMu.Unlock()
+}
+
+// runGraceful runs the graceful functions in parallel and waits for
+// them to finish - call with fnsMutex held
+//
+// They are only run if the exit was started by a signal, not on the
+// normal exit path.
+func runGraceful() {
+	if len(gracefulFns) == 0 || !Signalled() {
+		return
+	}
+	gracePeriod := fs.GetConfig(context.Background()).ShutdownGracePeriod
+	ctx, cancel := context.WithTimeout(context.Background(), gracePeriod)
+	defer cancel()
+	graceMu.Lock()
+	graceCancel = cancel
+	graceMu.Unlock()
+	fs.Infof(nil, "Shutting down gracefully - waiting up to %v", gracePeriod)
+	var wg sync.WaitGroup
+	for fnHandle := range gracefulFns {
+		wg.Add(1)
+		go func(fn func(ctx context.Context)) {
+			defer wg.Done()
+			fn(ctx)
+		}(*fnHandle)
+	}
+	wg.Wait()
+	graceMu.Lock()
+	graceCancel = nil
+	graceMu.Unlock()
+}
+
 // Signalled returns true if an exit signal has been received
 func Signalled() bool {
 	return atomic.LoadInt32(&signalled) != 0
@@ -100,6 +208,7 @@ func Run() {
 	fnsMutex.Lock()
 	defer fnsMutex.Unlock()
 	exitOnce.Do(func() {
+		runGraceful()
 		for fnHandle := range fns {
 			(*fnHandle)()
 		}
@@ -3,6 +3,7 @@
 package cache
 
 import (
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -16,6 +17,8 @@ type Cache struct {
 	expireRunning  bool
 	expireDuration time.Duration // expire the cache entry when it is older than this
 	expireInterval time.Duration // interval to run the cache expire
+	maxEntries     int           // max number of entries to keep, 0 for unlimited
+	finalize       FinalizeFunc  // called on values removed by expiry or eviction
 }
 
 // New creates a new cache with the default expire duration and interval
@@ -36,6 +39,41 @@ func (c *Cache) SetExpireDuration(d time.Duration) *Cache {
 	return c
 }
 
+// SetMaxEntries sets the maximum number of entries in the cache
+//
+// When a new entry would take the cache over this the least recently
+// used entries which aren't pinned are evicted. Set to 0 or a -ve
+// number for no limit.
+func (c *Cache) SetMaxEntries(n int) *Cache {
+	c.mu.Lock()
+	c.maxEntries = n
+	c.mu.Unlock()
+	return c
+}
+
+// FinalizeFunc is called with the values removed from the cache
+// because they expired or were evicted to keep the cache under its
+// maximum size.
+//
+// It is only called once for a value removed under more than one key
+// at the same time.
+type FinalizeFunc func(key string, value interface{})
+
+// SetFinalizer sets the function called on values which are removed
+// from the cache by expiry or eviction. It is called without the
+// cache lock held so may call cache methods.
+//
+// It isn't called for values removed explicitly with Delete,
+// DeletePrefix or Clear.
+//
+// The values in the cache must be comparable if this is set.
+func (c *Cache) SetFinalizer(finalize FinalizeFunc) *Cache {
+	c.mu.Lock()
+	c.finalize = finalize
+	c.mu.Unlock()
+	return c
+}
+
 // returns true if we aren't to cache anything
 func (c *Cache) noCache() bool {
 	return c.expireDuration <= 0
@@ -97,8 +135,10 @@ func (c *Cache) Get(key string, create CreateFunc) (value interface{}, err error
 			c.cache[key] = entry
 		}
 	}
-	defer c.mu.Unlock()
 	c.used(entry)
+	removed := c.evict(key)
+	c.mu.Unlock()
+	c.runFinalizer(removed)
 	return entry.value, entry.err
 }
 
@@ -125,8 +165,8 @@ func (c *Cache) Unpin(key string) {
 // Put puts a value named key into the cache
 func (c *Cache) Put(key string, value interface{}) {
 	c.mu.Lock()
-	defer c.mu.Unlock()
 	if c.noCache() {
+		c.mu.Unlock()
 		return
 	}
 	entry := &cacheEntry{
@@ -135,6 +175,9 @@ func (c *Cache) Put(key string, value interface{}) {
 	}
 	c.used(entry)
 	c.cache[key] = entry
+	removed := c.evict(key)
+	c.mu.Unlock()
+	c.runFinalizer(removed)
 }
 
 // GetMaybe returns the key and true if found, nil and false if not
@@ -197,14 +240,68 @@ func (c *Cache) Rename(oldKey, newKey string) (value interface{}, found bool) {
 	return value, found
 }
 
+// evict removes the least recently used entries which aren't pinned
+// until the cache is within maxEntries, never removing keep.
+//
+// It returns the removed entries and should be called with the lock
+// held.
+func (c *Cache) evict(keep string) (removed []*cacheEntry) {
+	if c.maxEntries <= 0 {
+		return nil
+	}
+	for len(c.cache) > c.maxEntries {
+		var oldest *cacheEntry
+		for key, entry := range c.cache {
+			if key == keep || entry.pinCount > 0 {
+				continue
+			}
+			if oldest == nil || entry.lastUsed.Before(oldest.lastUsed) {
+				oldest = entry
+			}
+		}
+		if oldest == nil {
+			// everything else is pinned
+			break
+		}
+		delete(c.cache, oldest.key)
+		removed = append(removed, oldest)
+	}
+	return removed
+}
+
+// runFinalizer calls the finalizer, if set, on the removed entries
+//
+// It should be called without the lock held.
+func (c *Cache) runFinalizer(removed []*cacheEntry) {
+	if len(removed) == 0 {
+		return
+	}
+	c.mu.Lock()
+	finalize := c.finalize
+	c.mu.Unlock()
+	if finalize == nil {
+		return
+	}
+outer:
+	for i, entry := range removed {
+		for _, previous := range removed[:i] {
+			if previous.value == entry.value {
+				continue outer
+			}
+		}
+		finalize(entry.key, entry.value)
+	}
+}
+
 // cacheExpire expires any entries that haven't been used recently
 func (c *Cache) cacheExpire() {
+	var removed []*cacheEntry
 	c.mu.Lock()
-	defer c.mu.Unlock()
 	now := time.Now()
 	for key, entry := range c.cache {
 		if entry.pinCount <= 0 && now.Sub(entry.lastUsed) > c.expireDuration {
 			delete(c.cache, key)
+			removed = append(removed, entry)
 		}
 	}
 	if len(c.cache) != 0 {
@@ -213,6 +310,8 @@ func (c *Cache) cacheExpire() {
 	} else {
 		c.expireRunning = false
 	}
+	c.mu.Unlock()
+	c.runFinalizer(removed)
 }
 
 // Clear removes everything from the cache
@@ -231,3 +330,33 @@ func (c *Cache) Entries() int {
 	c.mu.Unlock()
 	return entries
 }
+
+// EntryInfo describes an entry in the cache as returned by List
+type EntryInfo struct {
+	Key      string      // key
+	Value    interface{} // cached item
+	Err      error       // creation error
+	LastUsed time.Time   // time last used
+	PinCount int         // non zero if the entry is pinned
+}
+
+// List returns information about the entries in the cache sorted by
+// key
+func (c *Cache) List() (entries []EntryInfo) {
+	c.mu.Lock()
+	entries = make([]EntryInfo, 0, len(c.cache))
+	for _, entry := range c.cache {
+		entries = append(entries, EntryInfo{
+			Key:      entry.key,
+			Value:    entry.value,
+			Err:      entry.err,
+			LastUsed: entry.lastUsed,
+			PinCount: entry.pinCount,
+		})
+	}
+	c.mu.Unlock()
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Key < entries[j].Key
+	})
+	return entries
+}
@@ -333,3 +333,110 @@ func TestCacheRename(t *testing.T) {
 
 	assert.Equal(t, 1, c.Entries())
 }
+
+func TestList(t *testing.T) {
+	c, create := setup(t)
+
+	assert.Equal(t, 0, len(c.List()))
+
+	_, err := c.Get("/", create)
+	require.NoError(t, err)
+	called = 0
+	_, err = c.Get("/file.txt", create)
+	require.Equal(t, errCached, err)
+	c.Pin("/")
+
+	entries := c.List()
+	require.Equal(t, 2, len(entries))
+	assert.Equal(t, "/", entries[0].Key)
+	assert.Equal(t, "/", entries[0].Value)
+	assert.NoError(t, entries[0].Err)
+	assert.Equal(t, 1, entries[0].PinCount)
+	assert.False(t, entries[0].LastUsed.IsZero())
+	assert.Equal(t, "/file.txt", entries[1].Key)
+	assert.Equal(t, errCached, entries[1].Err)
+	assert.Equal(t, 0, entries[1].PinCount)
+}
+
+func TestMaxEntries(t *testing.T) {
+	c, _ := setup(t)
+	var finalized []string
+	c.SetMaxEntries(2)
+	c.SetFinalizer(func(key string, value interface{}) {
+		assert.Equal(t, key, value)
+		finalized = append(finalized, key)
+	})
+
+	c.Put("a", "a")
+	c.Put("b", "b")
+	assert.Equal(t, 2, c.Entries())
+	assert.Equal(t, []string(nil), finalized)
+
+	// Make "b" the least recently used but pin it so "a" is
+	// evicted instead
+	c.mu.Lock()
+	c.cache["a"].lastUsed = time.Now().Add(-time.Minute)
+	c.cache["b"].lastUsed = time.Now().Add(-2 * time.Minute)
+	c.mu.Unlock()
+	c.Pin("b")
+
+	c.Put("c", "c")
+	assert.Equal(t, []string{"a"}, finalized)
+	_, found := c.GetMaybe("a")
+	assert.False(t, found)
+
+	// "b" is still pinned and "d" is being added so "c" goes
+	called = 0
+	_, err := c.Get("d", func(key string) (interface{}, bool, error) {
+		return key, true, nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "c"}, finalized)
+	assert.Equal(t, 2, c.Entries())
+
+	// Explicit removal doesn't finalize
+	c.Delete("d")
+	c.Clear()
+	assert.Equal(t, []string{"a", "c"}, finalized)
+}
+
+func TestFinalizeOnce(t *testing.T) {
+	c, _ := setup(t)
+	var finalized []string
+	c.SetFinalizer(func(key string, value interface{}) {
+		finalized = append(finalized, value.(string))
+	})
+
+	c.Put("a", "value")
+	c.Put("b", "value")
+	c.mu.Lock()
+	for _, entry := range c.cache {
+		entry.lastUsed = time.Now().Add(-c.expireDuration - 60*time.Second)
+	}
+	c.mu.Unlock()
+
+	c.cacheExpire()
+
+	assert.Equal(t, 0, c.Entries())
+	assert.Equal(t, []string{"value"}, finalized)
+}
+
+func TestFinalizeOnExpire(t *testing.T) {
+	c, create := setup(t)
+	var finalized []string
+	c.SetFinalizer(func(key string, value interface{}) {
+		finalized = append(finalized, key)
+	})
+
+	_, err := c.Get("/", create)
+	require.NoError(t, err)
+
+	c.mu.Lock()
+	c.cache["/"].lastUsed = time.Now().Add(-c.expireDuration - 60*time.Second)
+	c.mu.Unlock()
+
+	c.cacheExpire()
+
+	assert.Equal(t, 0, c.Entries())
+	assert.Equal(t, []string{"/"}, finalized)
+}
@@ -0,0 +1,89 @@
+// Package clock provides the time to code which needs to be tested
+// without waiting for real time to pass
+package clock
+
+import (
+	"context"
+	"time"
+)
+
+// Clock tells the time and waits for it to pass
+type Clock interface {
+	// Now returns the current time
+	Now() time.Time
+	// Since returns the time elapsed since t
+	Since(t time.Time) time.Duration
+	// Until returns the duration until t
+	Until(t time.Time) time.Duration
+	// Sleep pauses for at least d
+	Sleep(d time.Duration)
+	// After returns a channel which receives the time after d
+	After(d time.Duration) <-chan time.Time
+	// AfterFunc calls f after d
+	AfterFunc(d time.Duration, f func()) Timer
+	// NewTicker returns a Ticker which ticks every d
+	NewTicker(d time.Duration) Ticker
+}
+
+// Timer is returned by AfterFunc
+type Timer interface {
+	// Stop prevents the Timer from firing, returning false if it
+	// has already fired or been stopped
+	Stop() bool
+}
+
+// Ticker sends the time on a channel at regular intervals
+type Ticker interface {
+	// Chan returns the channel the ticks are sent on
+	Chan() <-chan time.Time
+	// Stop turns off the Ticker
+	Stop()
+}
+
+// Real is the Clock which uses the system time
+var Real Clock = realClock{}
+
+// realClock implements Clock with the time package
+type realClock struct{}
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) Since(t time.Time) time.Duration        { return time.Since(t) }
+func (realClock) Until(t time.Time) time.Duration        { return time.Until(t) }
+func (realClock) Sleep(d time.Duration)                  { time.Sleep(d) }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+func (realClock) AfterFunc(d time.Duration, f func()) Timer {
+	return time.AfterFunc(d, f)
+}
+
+func (realClock) NewTicker(d time.Duration) Ticker {
+	return realTicker{time.NewTicker(d)}
+}
+
+// realTicker implements Ticker with a time.Ticker
+type realTicker struct {
+	*time.Ticker
+}
+
+func (t realTicker) Chan() <-chan time.Time { return t.C }
+
+// Type of key used to store the Clock in the context
+type clockContextKeyType struct{}
+
+// Context key for the Clock
+var clockContextKey = clockContextKeyType{}
+
+// Get returns the Clock in ctx or Real if there isn't one
+func Get(ctx context.Context) Clock {
+	if ctx != nil {
+		if c, ok := ctx.Value(clockContextKey).(Clock); ok {
+			return c
+		}
+	}
+	return Real
+}
+
+// WithClock returns a copy of ctx which makes Get return c
+func WithClock(ctx context.Context, c Clock) context.Context {
+	return context.WithValue(ctx, clockContextKey, c)
+}
@@ -0,0 +1,123 @@
+package clock
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var epoch = time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+
+func TestGet(t *testing.T) {
+	ctx := context.Background()
+	assert.Equal(t, Real, Get(ctx))
+	m := NewMock(epoch)
+	assert.Equal(t, m, Get(WithClock(ctx, m)))
+}
+
+func TestMockNow(t *testing.T) {
+	m := NewMock(epoch)
+	assert.Equal(t, epoch, m.Now())
+	m.Advance(time.Minute)
+	assert.Equal(t, epoch.Add(time.Minute), m.Now())
+	assert.Equal(t, time.Minute, m.Since(epoch))
+	assert.Equal(t, -time.Minute, m.Until(epoch))
+
+	// Doesn't go backwards
+	m.Set(epoch)
+	assert.Equal(t, epoch.Add(time.Minute), m.Now())
+}
+
+func TestMockAfter(t *testing.T) {
+	m := NewMock(epoch)
+	c := m.After(time.Second)
+	assert.Equal(t, 1, m.Waiters())
+	m.Advance(999 * time.Millisecond)
+	select {
+	case <-c:
+		t.Fatal("fired too early")
+	default:
+	}
+	m.Advance(time.Second)
+	assert.Equal(t, epoch.Add(time.Second), <-c)
+	assert.Equal(t, 0, m.Waiters())
+
+	// Fires straight away if not in the future
+	assert.Equal(t, m.Now(), <-m.After(0))
+}
+
+func TestMockSleep(t *testing.T) {
+	m := NewMock(epoch)
+	done := make(chan struct{})
+	go func() {
+		m.Sleep(time.Hour)
+		close(done)
+	}()
+	m.WaitForWaiters(1)
+	m.Advance(time.Hour)
+	<-done
+}
+
+func TestMockAfterFunc(t *testing.T) {
+	m := NewMock(epoch)
+	var calls []time.Time
+	m.AfterFunc(2*time.Second, func() {
+		calls = append(calls, m.Now())
+	})
+	m.AfterFunc(time.Second, func() {
+		calls = append(calls, m.Now())
+		// timers made while firing run in the same Advance if due
+		m.AfterFunc(500*time.Millisecond, func() {
+			calls = append(calls, m.Now())
+		})
+	})
+	stopped := m.AfterFunc(time.Second, func() {
+		t.Error("stopped timer fired")
+	})
+	assert.True(t, stopped.Stop())
+	assert.False(t, stopped.Stop())
+
+	m.Advance(time.Hour)
+	assert.Equal(t, []time.Time{
+		epoch.Add(time.Second),
+		epoch.Add(1500 * time.Millisecond),
+		epoch.Add(2 * time.Second),
+	}, calls)
+	assert.Equal(t, epoch.Add(time.Hour), m.Now())
+
+	// Runs in the background if not in the future
+	var called int32
+	timer := m.AfterFunc(0, func() {
+		atomic.StoreInt32(&called, 1)
+	})
+	assert.False(t, timer.Stop())
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&called) == 1 }, time.Second, time.Millisecond)
+}
+
+func TestMockTicker(t *testing.T) {
+	m := NewMock(epoch)
+	ticker := m.NewTicker(time.Minute)
+	m.Advance(time.Minute)
+	assert.Equal(t, epoch.Add(time.Minute), <-ticker.Chan())
+
+	// Ticks are dropped if not received
+	m.Advance(3 * time.Minute)
+	assert.Equal(t, epoch.Add(2*time.Minute), <-ticker.Chan())
+	select {
+	case <-ticker.Chan():
+		t.Fatal("unexpected tick")
+	default:
+	}
+
+	ticker.Stop()
+	assert.Equal(t, 0, m.Waiters())
+	m.Advance(time.Hour)
+	select {
+	case <-ticker.Chan():
+		t.Fatal("tick after stop")
+	default:
+	}
+}
@@ -0,0 +1,235 @@
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Mock is a Clock for tests which only moves on when Advance or Set
+// is called.
+//
+// Functions passed to AfterFunc are run by Advance and Set in the
+// caller's goroutine, so they have finished by the time it returns.
+// Timers due straight away fire when they are made.
+type Mock struct {
+	mu      sync.Mutex
+	cond    *sync.Cond    // signalled when waiters changes
+	now     time.Time     // the current time
+	waiters []*mockWaiter // pending timers, sleepers and tickers
+}
+
+// mockWaiter is a timer, sleeper or ticker waiting for the Mock to
+// reach when
+type mockWaiter struct {
+	m      *Mock
+	when   time.Time      // when this fires
+	period time.Duration  // for tickers, the time between ticks
+	c      chan time.Time // channel to send the time on, if set
+	fn     func()         // function to call, if set
+	active bool           // set if this is in m.waiters
+}
+
+// mockTicker is a Ticker for the Mock
+type mockTicker struct {
+	*mockWaiter
+}
+
+// NewMock returns a Mock with the time set to now
+func NewMock(now time.Time) *Mock {
+	m := &Mock{now: now}
+	m.cond = sync.NewCond(&m.mu)
+	return m
+}
+
+// Now returns the current time
+func (m *Mock) Now() time.Time {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.now
+}
+
+// Since returns the time elapsed since t
+func (m *Mock) Since(t time.Time) time.Duration {
+	return m.Now().Sub(t)
+}
+
+// Until returns the duration until t
+func (m *Mock) Until(t time.Time) time.Duration {
+	return t.Sub(m.Now())
+}
+
+// Sleep blocks until the Mock has been advanced by d
+func (m *Mock) Sleep(d time.Duration) {
+	<-m.After(d)
+}
+
+// After returns a channel which receives the time once the Mock has
+// been advanced by d
+func (m *Mock) After(d time.Duration) <-chan time.Time {
+	w := &mockWaiter{c: make(chan time.Time, 1)}
+	m.add(w, d)
+	return w.c
+}
+
+// AfterFunc calls f once the Mock has been advanced by d
+func (m *Mock) AfterFunc(d time.Duration, f func()) Timer {
+	w := &mockWaiter{fn: f}
+	m.add(w, d)
+	return w
+}
+
+// NewTicker returns a Ticker which ticks each time the Mock is
+// advanced by d
+func (m *Mock) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("non-positive interval for clock.Mock.NewTicker")
+	}
+	w := &mockWaiter{c: make(chan time.Time, 1), period: d}
+	m.add(w, d)
+	return mockTicker{w}
+}
+
+// add schedules w to fire after d
+//
+// If d <= 0 it fires straight away, running any function in its own
+// goroutine as the caller may hold locks the function needs.
+func (m *Mock) add(w *mockWaiter, d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	w.m = m
+	w.when = m.now.Add(d)
+	if d <= 0 {
+		if w.c != nil {
+			w.c <- m.now
+		}
+		if w.fn != nil {
+			go w.fn()
+		}
+		return
+	}
+	w.active = true
+	m.waiters = append(m.waiters, w)
+	m.cond.Broadcast()
+}
+
+// _remove takes w out of the waiters returning true if it was there
+//
+// call with lock held
+func (m *Mock) _remove(w *mockWaiter) bool {
+	if !w.active {
+		return false
+	}
+	w.active = false
+	for i, x := range m.waiters {
+		if x == w {
+			m.waiters = append(m.waiters[:i], m.waiters[i+1:]...)
+			break
+		}
+	}
+	m.cond.Broadcast()
+	return true
+}
+
+// _next returns the first waiter due to fire at or before t
+//
+// call with lock held
+func (m *Mock) _next(t time.Time) (next *mockWaiter) {
+	for _, w := range m.waiters {
+		if !w.when.After(t) && (next == nil || w.when.Before(next.when)) {
+			next = w
+		}
+	}
+	return next
+}
+
+// Advance moves the Mock on by d firing any timers, sleepers and
+// tickers which become due in order.
+func (m *Mock) Advance(d time.Duration) {
+	m.Set(m.Now().Add(d))
+}
+
+// Set moves the Mock on to t firing any timers, sleepers and tickers
+// which become due in order.
+//
+// The time is never moved backwards.
+func (m *Mock) Set(t time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for {
+		w := m._next(t)
+		if w == nil {
+			break
+		}
+		if w.when.After(m.now) {
+			m.now = w.when
+		}
+		m._remove(w)
+		if w.period > 0 {
+			w.when = w.when.Add(w.period)
+			w.active = true
+			m.waiters = append(m.waiters, w)
+		}
+		if w.c != nil {
+			// Drop the tick if the receiver isn't keeping up like time.Ticker
+			select {
+			case w.c <- m.now:
+			default:
+			}
+		}
+		if w.fn != nil {
+			m.mu.Unlock()
+			w.fn()
+			m.mu.Lock()
+		}
+	}
+	if t.After(m.now) {
+		m.now = t
+	}
+}
+
+// Waiters returns the number of timers, sleepers and tickers waiting
+// for the Mock to be advanced
+func (m *Mock) Waiters() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.waiters)
+}
+
+// WaitForWaiters blocks until there are at least n timers, sleepers
+// and tickers waiting for the Mock to be advanced.
+//
+// Use this to make sure a goroutine has started waiting before
+// calling Advance.
+func (m *Mock) WaitForWaiters(n int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for len(m.waiters) < n {
+		m.cond.Wait()
+	}
+}
+
+// Stop prevents the timer from firing, returning false if it has
+// already fired or been stopped
+func (w *mockWaiter) Stop() bool {
+	w.m.mu.Lock()
+	defer w.m.mu.Unlock()
+	return w.m._remove(w)
+}
+
+// Chan returns the channel the ticks are sent on
+func (t mockTicker) Chan() <-chan time.Time {
+	return t.c
+}
+
+// Stop turns off the ticker
+func (t mockTicker) Stop() {
+	_ = t.mockWaiter.Stop()
+}
+
+// Check the interfaces are satisfied
+var (
+	_ Clock  = realClock{}
+	_ Clock  = (*Mock)(nil)
+	_ Timer  = (*mockWaiter)(nil)
+	_ Ticker = mockTicker{}
+)
@@ -0,0 +1,83 @@
+// Package exitcode exports rclone's exit status numbers along with
+// their names and meanings so scripts and the summary file can refer
+// to them.
+package exitcode
+
+// The exit codes rclone returns. These must not be renumbered as
+// scripts depend on them.
+const (
+	// Success is returned when the command succeeded
+	Success = iota
+	// UsageError is returned for syntax or usage errors
+	UsageError
+	// UncategorizedError is returned for errors not otherwise categorised
+	UncategorizedError
+	// DirNotFound is returned when a directory wasn't found
+	DirNotFound
+	// FileNotFound is returned when a file wasn't found
+	FileNotFound
+	// RetryError is returned for temporary errors which more retries might fix
+	RetryError
+	// NoRetryError is returned for less serious errors which retrying won't fix
+	NoRetryError
+	// FatalError is returned for errors which more retries won't fix
+	FatalError
+	// TransferExceeded is returned when the --max-transfer limit was reached
+	TransferExceeded
+	// NoFilesTransferred is returned with --error-on-no-transfer if nothing was transferred
+	NoFilesTransferred
+	// PermissionDenied is returned when permission to read or write was denied
+	PermissionDenied
+	// PartialTransfer is returned when some files were transferred but there were errors
+	PartialTransfer
+	// QuotaExceeded is returned when the destination ran out of space or quota
+	QuotaExceeded
+)
+
+// info describes an exit code
+type info struct {
+	name    string
+	meaning string
+}
+
+// codes indexed by exit code
+var codes = []info{
+	Success:            {"success", "Success"},
+	UsageError:         {"usage_error", "Syntax or usage error"},
+	UncategorizedError: {"uncategorized_error", "Error not otherwise categorised"},
+	DirNotFound:        {"dir_not_found", "Directory not found"},
+	FileNotFound:       {"file_not_found", "File not found"},
+	RetryError:         {"retry_error", "Temporary error (one that more retries might fix)"},
+	NoRetryError:       {"no_retry_error", "Less serious errors (like 461 errors from dropbox)"},
+	FatalError:         {"fatal_error", "Fatal error (one that more retries won't fix, like account suspended)"},
+	TransferExceeded:   {"transfer_exceeded", "Transfer exceeded - limit set by --max-transfer reached"},
+	NoFilesTransferred: {"no_files_transferred", "Operation successful, but no files transferred"},
+	PermissionDenied:   {"permission_denied", "Permission denied"},
+	PartialTransfer:    {"partial_transfer", "Some files were transferred but there were errors"},
+	QuotaExceeded:      {"quota_exceeded", "Out of space or quota"},
+}
+
+// Codes returns all the exit codes in order
+func Codes() (out []int) {
+	for code := range codes {
+		out = append(out, code)
+	}
+	return out
+}
+
+// Name returns a short machine readable name for code, eg
+// "dir_not_found", or "unknown" if it isn't an rclone exit code.
+func Name(code int) string {
+	if code < 0 || code >= len(codes) {
+		return "unknown"
+	}
+	return codes[code].name
+}
+
+// Meaning returns a human readable description of code
+func Meaning(code int) string {
+	if code < 0 || code >= len(codes) {
+		return "Unknown exit code"
+	}
+	return codes[code].meaning
+}
@@ -0,0 +1,15 @@
+//+build !linux
+
+package file
+
+import "os"
+
+// PunchHoleImplemented is a constant indicating whether the
+// implementation of PunchHole actually does anything.
+const PunchHoleImplemented = false
+
+// PunchHole deallocates size bytes at offset in the file leaving a
+// hole which reads as zeros. The size of the file isn't changed.
+func PunchHole(out *os.File, offset, size int64) (err error) {
+	return ErrPunchHoleUnsupported
+}
@@ -0,0 +1,32 @@
+//+build linux
+
+package file
+
+import (
+	"os"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// PunchHoleImplemented is a constant indicating whether the
+// implementation of PunchHole actually does anything.
+const PunchHoleImplemented = true
+
+// PunchHole deallocates size bytes at offset in the file leaving a
+// hole which reads as zeros. The size of the file isn't changed.
+func PunchHole(out *os.File, offset, size int64) (err error) {
+	if size <= 0 {
+		return nil
+	}
+	for {
+		err = unix.Fallocate(int(out.Fd()), unix.FALLOC_FL_KEEP_SIZE|unix.FALLOC_FL_PUNCH_HOLE, offset, size)
+		if err != syscall.EINTR {
+			break
+		}
+	}
+	if err == unix.ENOTSUP {
+		return ErrPunchHoleUnsupported
+	}
+	return err
+}
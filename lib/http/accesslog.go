@@ -0,0 +1,222 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/pingme998/rclone/lib/clock"
+)
+
+// Formats for the access log
+const (
+	AccessLogCommon   = "common"   // Common Log Format
+	AccessLogCombined = "combined" // Combined Log Format - common with referer and user agent
+	AccessLogJSON     = "json"     // one JSON object per line
+)
+
+// AccessLogHelp describes the access log flags to add to the command help
+var AccessLogHelp = `
+#### Access log
+
+Use --access-log to write a line for each request served to a file,
+or "-" to write them to standard output.  Lines are appended to the
+file if it exists already.
+
+Use --access-log-format to choose the format of the lines written.
+
+- "common" writes the Common Log Format
+- "combined" writes the Combined Log Format (the default) - the Common Log Format with the referer and user agent
+- "json" writes a JSON object per line which includes the bytes received and the time taken to serve the request
+
+These can be read by standard web log analysis tools.  The user logged
+is the one given for basic authentication, or "-" if there wasn't one.
+`
+
+// accessLog writes the access log lines
+type accessLog struct {
+	mu     sync.Mutex
+	out    io.Writer
+	format string
+}
+
+// NewAccessLog returns a Middleware which writes a line in format for
+// each request to the file at path, or to standard output if path is
+// "-".
+func NewAccessLog(path, format string) (Middleware, error) {
+	if err := checkAccessLogFormat(format); err != nil {
+		return nil, err
+	}
+	var out io.Writer = os.Stdout
+	if path != "-" {
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0640)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to open access log")
+		}
+		out = f
+	}
+	return AccessLogMiddleware(out, format), nil
+}
+
+// AccessLogMiddleware returns a Middleware which writes a line in
+// format for each request to out.
+func AccessLogMiddleware(out io.Writer, format string) Middleware {
+	l := &accessLog{out: out, format: format}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			c := clock.Get(r.Context())
+			start := c.Now()
+			body := &countingReader{ReadCloser: r.Body}
+			if r.Body != nil {
+				r.Body = body
+			}
+			lw := &loggingResponseWriter{ResponseWriter: w}
+			next.ServeHTTP(lw, r)
+			l.write(r, lw, body.n, start, c.Since(start))
+		})
+	}
+}
+
+// checkAccessLogFormat returns an error if format isn't known
+func checkAccessLogFormat(format string) error {
+	switch format {
+	case AccessLogCommon, AccessLogCombined, AccessLogJSON:
+		return nil
+	}
+	return errors.Errorf("unknown access log format %q - use %q, %q or %q", format, AccessLogCommon, AccessLogCombined, AccessLogJSON)
+}
+
+// accessLogEntry is a line of the access log in JSON format
+type accessLogEntry struct {
+	Time          time.Time `json:"time"`
+	RemoteAddr    string    `json:"remote_addr"`
+	User          string    `json:"user,omitempty"`
+	Method        string    `json:"method"`
+	URI           string    `json:"uri"`
+	Proto         string    `json:"proto"`
+	Status        int       `json:"status"`
+	BytesSent     int64     `json:"bytes_sent"`
+	BytesReceived int64     `json:"bytes_received"`
+	Referer       string    `json:"referer,omitempty"`
+	UserAgent     string    `json:"user_agent,omitempty"`
+	Duration      float64   `json:"duration"` // in seconds
+}
+
+// write the line for the request r served by w
+func (l *accessLog) write(r *http.Request, w *loggingResponseWriter, received int64, start time.Time, duration time.Duration) {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	user, _, _ := r.BasicAuth()
+	status := w.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	var buf bytes.Buffer
+	if l.format == AccessLogJSON {
+		_ = json.NewEncoder(&buf).Encode(accessLogEntry{
+			Time:          start,
+			RemoteAddr:    host,
+			User:          user,
+			Method:        r.Method,
+			URI:           r.RequestURI,
+			Proto:         r.Proto,
+			Status:        status,
+			BytesSent:     w.n,
+			BytesReceived: received,
+			Referer:       r.Referer(),
+			UserAgent:     r.UserAgent(),
+			Duration:      duration.Seconds(),
+		})
+	} else {
+		sent := "-"
+		if w.n > 0 {
+			sent = strconv.FormatInt(w.n, 10)
+		}
+		fmt.Fprintf(&buf, "%s - %s [%s] %s %d %s",
+			host,
+			dashIfEmpty(user),
+			start.Format("02/Jan/2006:15:04:05 -0700"),
+			strconv.Quote(r.Method+" "+r.RequestURI+" "+r.Proto),
+			status,
+			sent,
+		)
+		if l.format == AccessLogCombined {
+			fmt.Fprintf(&buf, " %s %s", strconv.Quote(r.Referer()), strconv.Quote(r.UserAgent()))
+		}
+		buf.WriteByte('\n')
+	}
+	l.mu.Lock()
+	_, _ = l.out.Write(buf.Bytes())
+	l.mu.Unlock()
+}
+
+// dashIfEmpty returns s or "-" if it is empty as used in the Common
+// Log Format for missing values
+func dashIfEmpty(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// loggingResponseWriter records the status and the number of bytes
+// written for the access log
+type loggingResponseWriter struct {
+	http.ResponseWriter
+	status int   // status written, 0 if not written yet
+	n      int64 // bytes of body written
+}
+
+// WriteHeader records the status and writes it
+func (w *loggingResponseWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Write records the number of bytes written
+func (w *loggingResponseWriter) Write(p []byte) (n int, err error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err = w.ResponseWriter.Write(p)
+	w.n += int64(n)
+	return n, err
+}
+
+// Flush sends any buffered data to the client if supported
+func (w *loggingResponseWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// countingReader counts the bytes of the request body read
+type countingReader struct {
+	io.ReadCloser
+	n int64
+}
+
+// Read counts the bytes read
+func (r *countingReader) Read(p []byte) (n int, err error) {
+	n, err = r.ReadCloser.Read(p)
+	r.n += int64(n)
+	return n, err
+}
+
+// Check the interfaces are satisfied
+var (
+	_ http.ResponseWriter = (*loggingResponseWriter)(nil)
+	_ http.Flusher        = (*loggingResponseWriter)(nil)
+)
@@ -0,0 +1,108 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pingme998/rclone/lib/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// serveAccessLog serves a request through the access log returning
+// the line written
+func serveAccessLog(t *testing.T, format string, r *http.Request) string {
+	m := clock.NewMock(time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC))
+	var out bytes.Buffer
+	handler := AccessLogMiddleware(&out, format)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = ioutil.ReadAll(r.Body)
+		m.Advance(1500 * time.Millisecond)
+		if r.URL.Path == "/missing" {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte("hello"))
+	}))
+	r = r.WithContext(clock.WithClock(r.Context(), m))
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+	return out.String()
+}
+
+func newAccessLogRequest(method, target, body string) *http.Request {
+	r := httptest.NewRequest(method, target, strings.NewReader(body))
+	r.RemoteAddr = "1.2.3.4:5678"
+	r.Header.Set("Referer", "http://example.com/")
+	r.Header.Set("User-Agent", "test/1.0")
+	return r
+}
+
+func TestAccessLogCommon(t *testing.T) {
+	r := newAccessLogRequest("GET", "/file.txt?x=1", "")
+	assert.Equal(t, `1.2.3.4 - - [02/Jan/2020:03:04:05 +0000] "GET /file.txt?x=1 HTTP/1.1" 200 5`+"\n", serveAccessLog(t, AccessLogCommon, r))
+
+	r = newAccessLogRequest("GET", "/missing", "")
+	r.SetBasicAuth("user", "pass")
+	assert.Equal(t, `1.2.3.4 - user [02/Jan/2020:03:04:05 +0000] "GET /missing HTTP/1.1" 404 10`+"\n", serveAccessLog(t, AccessLogCommon, r))
+}
+
+func TestAccessLogCombined(t *testing.T) {
+	r := newAccessLogRequest("PUT", "/file.txt", "potato")
+	assert.Equal(t, `1.2.3.4 - - [02/Jan/2020:03:04:05 +0000] "PUT /file.txt HTTP/1.1" 200 5 "http://example.com/" "test/1.0"`+"\n", serveAccessLog(t, AccessLogCombined, r))
+}
+
+func TestAccessLogJSON(t *testing.T) {
+	r := newAccessLogRequest("PUT", "/file.txt", "potato")
+	r.SetBasicAuth("user", "pass")
+	line := serveAccessLog(t, AccessLogJSON, r)
+	assert.True(t, strings.HasSuffix(line, "\n"))
+	var entry accessLogEntry
+	require.NoError(t, json.Unmarshal([]byte(line), &entry))
+	assert.Equal(t, accessLogEntry{
+		Time:          time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC),
+		RemoteAddr:    "1.2.3.4",
+		User:          "user",
+		Method:        "PUT",
+		URI:           "/file.txt",
+		Proto:         "HTTP/1.1",
+		Status:        200,
+		BytesSent:     5,
+		BytesReceived: 6,
+		Referer:       "http://example.com/",
+		UserAgent:     "test/1.0",
+		Duration:      1.5,
+	}, entry)
+}
+
+func TestNewAccessLog(t *testing.T) {
+	_, err := NewAccessLog("-", "potato")
+	assert.Error(t, err)
+
+	dir, err := ioutil.TempDir("", "rclone-accesslog")
+	require.NoError(t, err)
+	defer func() { _ = os.RemoveAll(dir) }()
+	path := filepath.Join(dir, "access.log")
+	require.NoError(t, ioutil.WriteFile(path, []byte("existing\n"), 0600))
+
+	accessLog, err := NewAccessLog(path, AccessLogCommon)
+	require.NoError(t, err)
+	handler := accessLog(http.NotFoundHandler())
+	handler.ServeHTTP(httptest.NewRecorder(), newAccessLogRequest("GET", "/", ""))
+
+	data, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	require.Len(t, lines, 2)
+	assert.Equal(t, "existing", lines[0])
+	assert.Contains(t, lines[1], `"GET / HTTP/1.1" 404 19`)
+
+	_, err = NewAccessLog(filepath.Join(dir, "notfound", "access.log"), AccessLogCommon)
+	assert.Error(t, err)
+}
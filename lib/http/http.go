@@ -16,7 +16,9 @@ import (
 
 	"github.com/go-chi/chi/v5"
 	"github.com/pkg/errors"
+	"github.com/pingme998/rclone/fs"
 	"github.com/pingme998/rclone/fs/config/flags"
+	"github.com/pingme998/rclone/lib/atexit"
 	"github.com/spf13/pflag"
 )
 
@@ -33,6 +35,12 @@ IPs.  By default it only listens on localhost.  You can use port
 If you set --addr to listen on a public or LAN accessible IP address
 then using Authentication is advised - see the next section for info.
 
+Use --reuse-port to set SO_REUSEPORT on the listening socket (not on
+Windows) so a new copy of rclone can listen on the same port before
+the old one is stopped. If rclone is started by systemd socket
+activation then the sockets passed in by systemd are used instead of
+--addr, so rclone can be restarted without refusing connections.
+
 --server-read-timeout and --server-write-timeout can be used to
 control the timeouts on the server.  Note that this is the total time
 for a transfer.
@@ -59,7 +67,7 @@ supply --client-ca also.
 of that with the CA certificate.  --key should be the PEM encoded
 private key and --client-ca should be the PEM encoded client
 certificate authority certificate.
-`
+` + AccessLogHelp
 
 // Middleware function signature required by chi.Router.Use()
 type Middleware func(http.Handler) http.Handler
@@ -74,6 +82,9 @@ type Options struct {
 	SslCert            string        // SSL PEM key (concatenation of certificate and CA certificate)
 	SslKey             string        // SSL PEM Private key
 	ClientCA           string        // Client certificate authority to verify clients with
+	ReusePort          bool          // set SO_REUSEPORT on the listening socket
+	AccessLog          string        // file to write the access log to, "-" for stdout
+	AccessLogFormat    string        // format of the access log - common, combined or json
 }
 
 // DefaultOpt is the default values used for Options
@@ -82,6 +93,7 @@ var DefaultOpt = Options{
 	ServerReadTimeout:  1 * time.Hour,
 	ServerWriteTimeout: 1 * time.Hour,
 	MaxHeaderBytes:     4096,
+	AccessLogFormat:    AccessLogCombined,
 }
 
 // Server interface of http server
@@ -101,6 +113,8 @@ type server struct {
 	baseRouter   chi.Router
 	closing      *sync.WaitGroup
 	useSSL       bool
+	// graceful shutdown function registered for the default server
+	gracefulHandle atexit.GracefulHandle
 }
 
 var (
@@ -181,6 +195,13 @@ func NewServer(listeners, tlsListeners []net.Listener, opt Options) (Server, err
 	if opt.BaseURL != "" {
 		handler = http.StripPrefix(opt.BaseURL, handler)
 	}
+	if opt.AccessLog != "" {
+		accessLog, err := NewAccessLog(opt.AccessLog, opt.AccessLogFormat)
+		if err != nil {
+			return nil, err
+		}
+		handler = accessLog(handler)
+	}
 
 	// Serve on listeners
 	httpServer := &http.Server{
@@ -207,7 +228,7 @@ func NewServer(listeners, tlsListeners []net.Listener, opt Options) (Server, err
 		}
 	}
 
-	return &server{addrs, tlsAddrs, listeners, tlsListeners, httpServer, router, wg, useSSL}, nil
+	return &server{addrs, tlsAddrs, listeners, tlsListeners, httpServer, router, wg, useSSL, nil}, nil
 }
 
 func (s *server) Serve() {
@@ -301,24 +322,40 @@ func start() error {
 		return nil
 	}
 
-	var err error
-	var l net.Listener
-	l, err = net.Listen("tcp", defaultServerOptions.ListenAddr)
+	listeners, err := SocketActivation()
 	if err != nil {
 		return err
 	}
+	if len(listeners) > 0 {
+		fs.Infof(nil, "Using %d socket(s) passed in by systemd instead of %q", len(listeners), defaultServerOptions.ListenAddr)
+	} else {
+		l, err := Listen(defaultServerOptions.ListenAddr, defaultServerOptions.ReusePort)
+		if err != nil {
+			return err
+		}
+		listeners = []net.Listener{l}
+	}
 
 	var s Server
 	if useSSL(defaultServerOptions) {
-		s, err = NewServer([]net.Listener{}, []net.Listener{l}, defaultServerOptions)
+		s, err = NewServer([]net.Listener{}, listeners, defaultServerOptions)
 	} else {
-		s, err = NewServer([]net.Listener{l}, []net.Listener{}, defaultServerOptions)
+		s, err = NewServer(listeners, []net.Listener{}, defaultServerOptions)
 	}
 	if err != nil {
 		return err
 	}
 	defaultServer = s.(*server)
 	defaultServer.Serve()
+	// Finish the requests in progress before exiting if required
+	if fs.GetConfig(context.Background()).ShutdownGracePeriod > 0 {
+		httpServer := defaultServer.httpServer
+		defaultServer.gracefulHandle = atexit.RegisterGraceful(func(ctx context.Context) {
+			if err := httpServer.Shutdown(ctx); err != nil {
+				fs.Errorf(nil, "Error shutting down HTTP server: %v", err)
+			}
+		})
+	}
 	return nil
 }
 
@@ -329,6 +366,7 @@ func Shutdown() error {
 	if defaultServer != nil {
 		s := defaultServer
 		defaultServer = nil
+		atexit.UnregisterGraceful(s.gracefulHandle)
 		return s.Shutdown()
 	}
 	return nil
@@ -376,6 +414,9 @@ func AddFlagsPrefix(flagSet *pflag.FlagSet, prefix string, Opt *Options) {
 	flags.StringVarP(flagSet, &Opt.SslKey, prefix+"key", "", Opt.SslKey, "SSL PEM Private key")
 	flags.StringVarP(flagSet, &Opt.ClientCA, prefix+"client-ca", "", Opt.ClientCA, "Client certificate authority to verify clients with")
 	flags.StringVarP(flagSet, &Opt.BaseURL, prefix+"baseurl", "", Opt.BaseURL, "Prefix for URLs - leave blank for root.")
+	flags.BoolVarP(flagSet, &Opt.ReusePort, prefix+"reuse-port", "", Opt.ReusePort, "Set SO_REUSEPORT so several servers can listen on the same port.")
+	flags.StringVarP(flagSet, &Opt.AccessLog, prefix+"access-log", "", Opt.AccessLog, "File to write the access log to, \"-\" for stdout - leave blank for none.")
+	flags.StringVarP(flagSet, &Opt.AccessLogFormat, prefix+"access-log-format", "", Opt.AccessLogFormat, "Format of the access log: common, combined or json.")
 
 }
 
@@ -0,0 +1,72 @@
+package http
+
+import (
+	"context"
+	"net"
+	"os"
+	"strconv"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// listenFdsStart is the first file descriptor passed by systemd
+const listenFdsStart = 3
+
+var activationOnce sync.Once
+
+// SocketActivation returns the listening sockets passed in by
+// systemd socket activation, or nil if there aren't any.
+//
+// The sockets are only handed out once so only the first server to
+// ask gets them.
+func SocketActivation() (listeners []net.Listener, err error) {
+	activationOnce.Do(func() {
+		listeners, err = listenersFromEnv(listenFdsStart)
+	})
+	return listeners, err
+}
+
+// listenersFromEnv makes listeners from the file descriptors starting
+// at start described by the LISTEN_PID and LISTEN_FDS environment
+// variables as set by systemd.
+//
+// See sd_listen_fds(3) for the protocol.
+func listenersFromEnv(start int) (listeners []net.Listener, err error) {
+	pid, err := strconv.Atoi(os.Getenv("LISTEN_PID"))
+	if err != nil || pid != os.Getpid() {
+		return nil, nil
+	}
+	n, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || n <= 0 {
+		return nil, nil
+	}
+	// Don't pass the sockets on to any child processes
+	for _, name := range []string{"LISTEN_PID", "LISTEN_FDS", "LISTEN_FDNAMES"} {
+		_ = os.Unsetenv(name)
+	}
+	for fd := start; fd < start+n; fd++ {
+		f := os.NewFile(uintptr(fd), "LISTEN_FD_"+strconv.Itoa(fd))
+		// FileListener dups the file descriptor so close the original
+		l, err := net.FileListener(f)
+		_ = f.Close()
+		if err != nil {
+			for _, l := range listeners {
+				_ = l.Close()
+			}
+			return nil, errors.Wrapf(err, "socket activation: bad file descriptor %d", fd)
+		}
+		listeners = append(listeners, l)
+	}
+	return listeners, nil
+}
+
+// Listen makes a TCP listener on addr, setting SO_REUSEPORT on it if
+// reusePort is set so several servers can listen on the same port.
+func Listen(addr string, reusePort bool) (net.Listener, error) {
+	lc := net.ListenConfig{}
+	if reusePort {
+		lc.Control = setReusePort
+	}
+	return lc.Listen(context.Background(), "tcp", addr)
+}
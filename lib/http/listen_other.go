@@ -0,0 +1,14 @@
+// +build !linux,!darwin,!dragonfly,!freebsd,!netbsd,!openbsd
+
+package http
+
+import (
+	"syscall"
+
+	"github.com/pkg/errors"
+)
+
+// setReusePort returns an error as SO_REUSEPORT isn't supported on this OS
+func setReusePort(network, address string, c syscall.RawConn) error {
+	return errors.New("SO_REUSEPORT is not supported on this OS")
+}
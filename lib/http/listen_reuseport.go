@@ -0,0 +1,22 @@
+// +build linux darwin dragonfly freebsd netbsd openbsd
+
+package http
+
+import (
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// setReusePort is a net.ListenConfig Control function which sets
+// SO_REUSEPORT on the socket so several servers can listen on the
+// same port
+func setReusePort(network, address string, c syscall.RawConn) (err error) {
+	controlErr := c.Control(func(fd uintptr) {
+		err = unix.SetsockoptInt(int(fd), unix.SOL_SOCKET, unix.SO_REUSEPORT, 1)
+	})
+	if controlErr != nil {
+		return controlErr
+	}
+	return err
+}
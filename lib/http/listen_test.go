@@ -0,0 +1,69 @@
+// +build linux darwin dragonfly freebsd netbsd openbsd
+
+package http
+
+import (
+	"net"
+	"os"
+	"strconv"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListenersFromEnv(t *testing.T) {
+	// Not set
+	listeners, err := listenersFromEnv(listenFdsStart)
+	require.NoError(t, err)
+	assert.Nil(t, listeners)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer func() { _ = ln.Close() }()
+	f, err := ln.(*net.TCPListener).File()
+	require.NoError(t, err)
+	// listenersFromEnv takes ownership of fd
+	fd, err := syscall.Dup(int(f.Fd()))
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	// For a different process
+	require.NoError(t, os.Setenv("LISTEN_PID", strconv.Itoa(os.Getpid()+1)))
+	require.NoError(t, os.Setenv("LISTEN_FDS", "1"))
+	listeners, err = listenersFromEnv(fd)
+	require.NoError(t, err)
+	assert.Nil(t, listeners)
+
+	// For us
+	require.NoError(t, os.Setenv("LISTEN_PID", strconv.Itoa(os.Getpid())))
+	listeners, err = listenersFromEnv(fd)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(listeners))
+	assert.Equal(t, ln.Addr().String(), listeners[0].Addr().String())
+	require.NoError(t, listeners[0].Close())
+
+	// Check the environment was cleared
+	_, found := os.LookupEnv("LISTEN_PID")
+	assert.False(t, found)
+	_, found = os.LookupEnv("LISTEN_FDS")
+	assert.False(t, found)
+}
+
+func TestListenReusePort(t *testing.T) {
+	ln1, err := Listen("127.0.0.1:0", true)
+	require.NoError(t, err)
+	defer func() { _ = ln1.Close() }()
+	addr := ln1.Addr().String()
+
+	// A second listener can use the same port
+	ln2, err := Listen(addr, true)
+	require.NoError(t, err)
+	defer func() { _ = ln2.Close() }()
+	assert.Equal(t, addr, ln2.Addr().String())
+
+	// But not without reusePort
+	_, err = Listen(addr, false)
+	assert.Error(t, err)
+}
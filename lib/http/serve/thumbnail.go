@@ -0,0 +1,106 @@
+package serve
+
+import (
+	"image"
+	"image/color"
+	_ "image/gif" // register the decoders for thumbnails
+	"image/jpeg"
+	_ "image/png"
+	"net/http"
+	"strconv"
+
+	"github.com/pingme998/rclone/fs"
+	"github.com/pingme998/rclone/fs/accounting"
+)
+
+// Limits on the thumbnails made by Thumbnail
+const (
+	MaxThumbnailSize = 1024     // largest width or height of a thumbnail
+	maxThumbnailSrc  = 64 << 20 // largest object a thumbnail is made from
+)
+
+// Thumbnail serves a JPEG thumbnail of the image in o scaled to fit in
+// a square of size pixels. GIF, JPEG and PNG images are supported.
+func Thumbnail(w http.ResponseWriter, r *http.Request, o fs.Object, size int) {
+	if r.Method != "HEAD" && r.Method != "GET" {
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	if size <= 0 || size > MaxThumbnailSize {
+		http.Error(w, "thumbnail size must be 1-"+strconv.Itoa(MaxThumbnailSize), http.StatusBadRequest)
+		return
+	}
+	if o.Size() > maxThumbnailSrc {
+		http.Error(w, "image too large to make a thumbnail", http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "image/jpeg")
+	if r.Method == "HEAD" {
+		return
+	}
+	ctx := r.Context()
+	file, err := o.Open(ctx)
+	if err != nil {
+		fs.Debugf(o, "Thumbnail open error: %v", err)
+		http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+		return
+	}
+	tr := accounting.Stats(ctx).NewTransfer(o)
+	defer func() {
+		tr.Done(ctx, err)
+	}()
+	in := tr.Account(ctx, file) // account the transfer (no buffering)
+	img, _, err := image.Decode(in)
+	_ = in.Close()
+	if err != nil {
+		fs.Debugf(o, "Thumbnail decode error: %v", err)
+		http.Error(w, "can't decode image: "+err.Error(), http.StatusUnsupportedMediaType)
+		return
+	}
+	err = jpeg.Encode(w, scaleImage(img, size), &jpeg.Options{Quality: 85})
+	if err != nil {
+		fs.Errorf(o, "Didn't finish writing thumbnail: %v", err)
+	}
+}
+
+// scaleImage scales img down to fit in a square of size pixels by
+// averaging the source pixels under each destination pixel. Images
+// which already fit aren't scaled.
+func scaleImage(img image.Image, size int) image.Image {
+	b := img.Bounds()
+	srcW, srcH := b.Dx(), b.Dy()
+	dstW, dstH := srcW, srcH
+	if srcW > size || srcH > size {
+		if srcW >= srcH {
+			dstW, dstH = size, srcH*size/srcW
+		} else {
+			dstW, dstH = srcW*size/srcH, size
+		}
+		if dstW < 1 {
+			dstW = 1
+		}
+		if dstH < 1 {
+			dstH = 1
+		}
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dstW, dstH))
+	for y := 0; y < dstH; y++ {
+		y0, y1 := b.Min.Y+y*srcH/dstH, b.Min.Y+(y+1)*srcH/dstH
+		for x := 0; x < dstW; x++ {
+			x0, x1 := b.Min.X+x*srcW/dstW, b.Min.X+(x+1)*srcW/dstW
+			var r, g, bl, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					cr, cg, cb, ca := img.At(sx, sy).RGBA()
+					r, g, bl, a = r+uint64(cr), g+uint64(cg), bl+uint64(cb), a+uint64(ca)
+					n++
+				}
+			}
+			if n == 0 {
+				continue
+			}
+			dst.SetRGBA64(x, y, color.RGBA64{R: uint16(r / n), G: uint16(g / n), B: uint16(bl / n), A: uint16(a / n)})
+		}
+	}
+	return dst
+}
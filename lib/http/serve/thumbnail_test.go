@@ -0,0 +1,69 @@
+package serve
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pingme998/rclone/fstest/mockobject"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// makePNG makes a w x h PNG image
+func makePNG(t *testing.T, w, h int) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 0x80, A: 0xFF})
+		}
+	}
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+func TestThumbnailGET(t *testing.T) {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "http://example.com/image.png?thumbnail=32", nil)
+	o := mockobject.New("image.png").WithContent(makePNG(t, 200, 100), mockobject.SeekModeNone)
+	Thumbnail(w, r, o, 32)
+	resp := w.Result()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "image/jpeg", resp.Header.Get("Content-Type"))
+	img, err := jpeg.Decode(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 32, 16), img.Bounds())
+}
+
+func TestThumbnailBadSize(t *testing.T) {
+	for _, size := range []int{0, -1, MaxThumbnailSize + 1} {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", "http://example.com/image.png", nil)
+		o := mockobject.New("image.png").WithContent(makePNG(t, 10, 10), mockobject.SeekModeNone)
+		Thumbnail(w, r, o, size)
+		assert.Equal(t, http.StatusBadRequest, w.Result().StatusCode, size)
+	}
+}
+
+func TestThumbnailNotImage(t *testing.T) {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "http://example.com/aFile", nil)
+	o := mockobject.New("aFile").WithContent([]byte("hello"), mockobject.SeekModeNone)
+	Thumbnail(w, r, o, 32)
+	assert.Equal(t, http.StatusUnsupportedMediaType, w.Result().StatusCode)
+}
+
+func TestScaleImage(t *testing.T) {
+	small := image.NewRGBA(image.Rect(0, 0, 10, 20))
+	assert.Equal(t, image.Rect(0, 0, 10, 20), scaleImage(small, 32).Bounds())
+	tall := image.NewRGBA(image.Rect(0, 0, 100, 400))
+	assert.Equal(t, image.Rect(0, 0, 16, 64), scaleImage(tall, 64).Bounds())
+	thin := image.NewRGBA(image.Rect(0, 0, 1000, 1))
+	assert.Equal(t, image.Rect(0, 0, 10, 1), scaleImage(thin, 10).Bounds())
+}
@@ -0,0 +1,94 @@
+package serve
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/pingme998/rclone/fs"
+	"github.com/pingme998/rclone/fs/accounting"
+	"github.com/pingme998/rclone/fs/walk"
+)
+
+// Zip serves the contents of dir in f as a zip file
+//
+// The zip is streamed as it is made so errors after the first file
+// has been sent can only be signalled by truncating the response.
+func Zip(w http.ResponseWriter, r *http.Request, f fs.Fs, dir string) {
+	if r.Method != "HEAD" && r.Method != "GET" {
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	ctx := r.Context()
+	name := path.Base(dir)
+	if dir == "" {
+		name = strings.Trim(f.Name(), ":")
+		if name == "" {
+			name = "download"
+		}
+	}
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name+".zip"))
+	if r.Method == "HEAD" {
+		return
+	}
+
+	zw := zip.NewWriter(w)
+	prefix := ""
+	if dir != "" {
+		prefix = dir + "/"
+	}
+	err := walk.ListR(ctx, f, dir, false, -1, walk.ListObjects, func(entries fs.DirEntries) error {
+		for _, entry := range entries {
+			o, ok := entry.(fs.Object)
+			if !ok {
+				continue
+			}
+			err := zipObject(r, zw, o, strings.TrimPrefix(o.Remote(), prefix))
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		fs.Errorf(f, "Didn't finish writing zip of %q: %v", dir, err)
+		return
+	}
+	err = zw.Close()
+	if err != nil {
+		fs.Errorf(f, "Didn't finish writing zip of %q: %v", dir, err)
+	}
+}
+
+// zipObject adds o to the zip as name
+func zipObject(r *http.Request, zw *zip.Writer, o fs.Object, name string) (err error) {
+	ctx := r.Context()
+	header := &zip.FileHeader{
+		Name:     name,
+		Method:   zip.Deflate,
+		Modified: o.ModTime(ctx),
+	}
+	fw, err := zw.CreateHeader(header)
+	if err != nil {
+		return err
+	}
+	file, err := o.Open(ctx)
+	if err != nil {
+		return err
+	}
+	tr := accounting.Stats(ctx).NewTransfer(o)
+	defer func() {
+		tr.Done(ctx, err)
+	}()
+	in := tr.Account(ctx, file) // account the transfer (no buffering)
+	_, err = io.Copy(fw, in)
+	closeErr := in.Close()
+	if err == nil {
+		err = closeErr
+	}
+	return err
+}
@@ -0,0 +1,53 @@
+package serve
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pingme998/rclone/fstest/mockfs"
+	"github.com/pingme998/rclone/fstest/mockobject"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestZipBadMethod(t *testing.T) {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("POST", "http://example.com/dir/?download=zip", nil)
+	f := mockfs.NewFs(context.Background(), "remote", "")
+	Zip(w, r, f, "")
+	resp := w.Result()
+	assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+}
+
+func TestZipGET(t *testing.T) {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "http://example.com/?download=zip", nil)
+	f := mockfs.NewFs(context.Background(), "remote", "")
+	f.AddObject(mockobject.New("one.txt").WithContent([]byte("hello"), mockobject.SeekModeNone))
+	f.AddObject(mockobject.New("two.txt").WithContent([]byte("potato"), mockobject.SeekModeNone))
+	Zip(w, r, f, "")
+	resp := w.Result()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "application/zip", resp.Header.Get("Content-Type"))
+	assert.Equal(t, `attachment; filename="remote.zip"`, resp.Header.Get("Content-Disposition"))
+	body, err := ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	zr, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
+	require.NoError(t, err)
+	got := map[string]string{}
+	for _, file := range zr.File {
+		rc, err := file.Open()
+		require.NoError(t, err)
+		data, err := ioutil.ReadAll(rc)
+		require.NoError(t, err)
+		require.NoError(t, rc.Close())
+		got[file.Name] = string(data)
+	}
+	assert.Equal(t, map[string]string{"one.txt": "hello", "two.txt": "potato"}, got)
+}
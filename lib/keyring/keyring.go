@@ -0,0 +1,61 @@
+// Package keyring stores secrets in the OS keyring - the Keychain on
+// macOS, the Credential Manager on Windows and the Secret Service
+// (libsecret) on Linux and other unixes.
+package keyring
+
+import (
+	"bytes"
+	"os/exec"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	// ErrNotFound is returned by Get and Delete if the secret isn't
+	// in the keyring
+	ErrNotFound = errors.New("secret not found in keyring")
+
+	// ErrUnsupported is returned if there is no keyring which can
+	// be used
+	ErrUnsupported = errors.New("no keyring available")
+)
+
+// Get returns the secret stored for service and user
+func Get(service, user string) (string, error) {
+	return get(service, user)
+}
+
+// Set stores secret for service and user replacing any existing one
+func Set(service, user, secret string) error {
+	return set(service, user, secret)
+}
+
+// Delete removes the secret stored for service and user
+func Delete(service, user string) error {
+	return del(service, user)
+}
+
+// run runs the command name with args and stdin returning its
+// stdout.
+//
+// It returns ErrUnsupported if the command can't be found.
+func run(stdin string, name string, args ...string) (stdout string, err error) {
+	path, err := exec.LookPath(name)
+	if err != nil {
+		return "", errors.Wrapf(ErrUnsupported, "%s not found", name)
+	}
+	var out, stderr bytes.Buffer
+	cmd := exec.Command(path, args...)
+	cmd.Stdin = strings.NewReader(stdin)
+	cmd.Stdout = &out
+	cmd.Stderr = &stderr
+	err = cmd.Run()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return out.String(), errors.Wrapf(err, "%s failed: %s", name, msg)
+		}
+		return out.String(), errors.Wrapf(err, "%s failed", name)
+	}
+	return out.String(), nil
+}
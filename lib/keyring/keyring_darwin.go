@@ -0,0 +1,53 @@
+//+build darwin
+
+package keyring
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// exit code of security when the item isn't found
+const errSecItemNotFound = 44
+
+// isNotFound returns true if err is from security not finding the item
+func isNotFound(err error) bool {
+	if exitErr, ok := errors.Cause(err).(*exec.ExitError); ok {
+		return exitErr.ExitCode() == errSecItemNotFound
+	}
+	return false
+}
+
+// quote s for the security command line interpreter
+func quote(s string) string {
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
+
+func get(service, user string) (string, error) {
+	out, err := run("", "security", "find-generic-password", "-s", service, "-a", user, "-w")
+	if isNotFound(err) {
+		return "", ErrNotFound
+	} else if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(out, "\n"), nil
+}
+
+func set(service, user, secret string) error {
+	// Pass the secret on stdin using the interactive mode of
+	// security so it doesn't appear in the process list
+	command := fmt.Sprintf("add-generic-password -U -s %s -a %s -w %s\n", quote(service), quote(user), quote(secret))
+	_, err := run(command, "security", "-i")
+	return err
+}
+
+func del(service, user string) error {
+	_, err := run("", "security", "delete-generic-password", "-s", service, "-a", user)
+	if isNotFound(err) {
+		return ErrNotFound
+	}
+	return err
+}
@@ -0,0 +1,37 @@
+//+build !darwin,!windows,!plan9,!js
+
+package keyring
+
+import (
+	"github.com/pkg/errors"
+)
+
+// The Secret Service is used with secret-tool from libsecret
+
+func get(service, user string) (string, error) {
+	out, err := run("", "secret-tool", "lookup", "service", service, "account", user)
+	if errors.Cause(err) == ErrUnsupported {
+		return "", err
+	}
+	// secret-tool exits with an error and no output if not found
+	if out == "" {
+		return "", ErrNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	return out, nil
+}
+
+func set(service, user, secret string) error {
+	_, err := run(secret, "secret-tool", "store", "--label", "rclone: "+user, "service", service, "account", user)
+	return err
+}
+
+func del(service, user string) error {
+	if _, err := get(service, user); err != nil {
+		return err
+	}
+	_, err := run("", "secret-tool", "clear", "service", service, "account", user)
+	return err
+}
@@ -3,8 +3,10 @@ package pacer
 
 import (
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/pingme998/rclone/lib/clock"
 	"github.com/pingme998/rclone/lib/errors"
 )
 
@@ -37,12 +39,22 @@ type pacerOptions struct {
 	retries        int         // Max number of retries
 	calculator     Calculator  // switchable pacing algorithm - call with mu held
 	invoker        InvokerFunc // wrapper function used to invoke the target function
+	clock          clock.Clock // source of the time for the sleeps between calls
 }
 
 // InvokerFunc is the signature of the wrapper function used to invoke the
 // target function in Pacer.
 type InvokerFunc func(try, tries int, f Paced) (bool, error)
 
+// retries is the total number of calls retried by all the pacers
+var retries int64
+
+// Retries returns the total number of calls which have been retried
+// by all the pacers, which is a sign the remotes are overloaded.
+func Retries() int64 {
+	return atomic.LoadInt64(&retries)
+}
+
 // Option can be used in New to configure the Pacer.
 type Option func(*pacerOptions)
 
@@ -66,6 +78,11 @@ func InvokerOption(invoker InvokerFunc) Option {
 	return func(p *pacerOptions) { p.invoker = invoker }
 }
 
+// ClockOption sets the Clock the new Pacer sleeps with.
+func ClockOption(c clock.Clock) Option {
+	return func(p *pacerOptions) { p.clock = c }
+}
+
 // Paced is a function which is called by the Call and CallNoRetry
 // methods.  It should return a boolean, true if it would like to be
 // retried, and an error.  This error may be returned or returned
@@ -77,6 +94,7 @@ func New(options ...Option) *Pacer {
 	opts := pacerOptions{
 		maxConnections: 10,
 		retries:        3,
+		clock:          clock.Real,
 	}
 	for _, o := range options {
 		o(&opts)
@@ -164,7 +182,7 @@ func (p *Pacer) beginCall() {
 	p.mu.Lock()
 	// Restart the timer
 	go func(t time.Duration) {
-		time.Sleep(t)
+		p.clock.Sleep(t)
 		p.pacer <- struct{}{}
 	}(p.state.SleepTime)
 	p.mu.Unlock()
@@ -181,6 +199,7 @@ func (p *Pacer) endCall(retry bool, err error) {
 	p.mu.Lock()
 	if retry {
 		p.state.ConsecutiveRetries++
+		atomic.AddInt64(&retries, 1)
 	} else {
 		p.state.ConsecutiveRetries = 0
 	}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/pkg/errors"
+	"github.com/pingme998/rclone/lib/clock"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -144,6 +145,24 @@ func TestBeginCallZeroConnections(t *testing.T) {
 	}
 }
 
+func TestBeginCallClock(t *testing.T) {
+	m := clock.NewMock(time.Now())
+	p := New(MaxConnectionsOption(0), CalculatorOption(NewDefault(MinSleep(time.Second))), ClockOption(m))
+	p.beginCall()
+
+	// The pace token should only come back once the clock has
+	// moved on by the sleep time
+	m.WaitForWaiters(1)
+	m.Advance(time.Second - time.Millisecond)
+	if !waitForPace(p, 10*time.Millisecond).IsZero() {
+		t.Errorf("beginSleep fired too early")
+	}
+	m.Advance(time.Millisecond)
+	if waitForPace(p, 1000*time.Millisecond).IsZero() {
+		t.Errorf("beginSleep didn't fire")
+	}
+}
+
 func TestDefaultPacer(t *testing.T) {
 	c := NewDefault(MinSleep(1*time.Millisecond), MaxSleep(1*time.Second), DecayConstant(2))
 	for _, test := range []struct {
@@ -287,9 +306,11 @@ func TestEndCall(t *testing.T) {
 	p := New(MaxConnectionsOption(5))
 	emptyTokens(p)
 	p.state.ConsecutiveRetries = 1
+	before := Retries()
 	p.endCall(true, nil)
 	assert.Equal(t, 1, len(p.connTokens))
 	assert.Equal(t, 2, p.state.ConsecutiveRetries)
+	assert.Equal(t, before+1, Retries())
 }
 
 func TestEndCallZeroConnections(t *testing.T) {
@@ -245,6 +245,27 @@ func (rs Ranges) Intersection(r Range) (newRs Ranges) {
 	return newRs
 }
 
+// Remove removes r from rs so no part of it is present
+func (rs *Ranges) Remove(r Range) {
+	if r.IsEmpty() || len(*rs) == 0 {
+		return
+	}
+	var newRs Ranges
+	for _, curr := range *rs {
+		if curr.End() <= r.Pos || curr.Pos >= r.End() {
+			newRs = append(newRs, curr)
+			continue
+		}
+		if curr.Pos < r.Pos {
+			newRs = append(newRs, Range{Pos: curr.Pos, Size: r.Pos - curr.Pos})
+		}
+		if curr.End() > r.End() {
+			newRs = append(newRs, Range{Pos: r.End(), Size: curr.End() - r.End()})
+		}
+	}
+	*rs = newRs
+}
+
 // Equal returns true if rs == bs
 func (rs Ranges) Equal(bs Ranges) bool {
 	if len(rs) != len(bs) {
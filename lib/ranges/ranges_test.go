@@ -683,6 +683,51 @@ func TestRangesIntersection(t *testing.T) {
 	}
 }
 
+func TestRangesRemove(t *testing.T) {
+	for _, test := range []struct {
+		rs   Ranges
+		r    Range
+		want Ranges
+	}{
+		{
+			rs:   Ranges(nil),
+			r:    Range{Pos: 1, Size: 1},
+			want: Ranges(nil),
+		},
+		{
+			rs:   Ranges{{Pos: 1, Size: 5}},
+			r:    Range{},
+			want: Ranges{{Pos: 1, Size: 5}},
+		},
+		{
+			rs:   Ranges{{Pos: 1, Size: 5}},
+			r:    Range{Pos: 0, Size: 10},
+			want: Ranges(nil),
+		},
+		{
+			rs:   Ranges{{Pos: 1, Size: 5}},
+			r:    Range{Pos: 2, Size: 2},
+			want: Ranges{{Pos: 1, Size: 1}, {Pos: 4, Size: 2}},
+		},
+		{
+			rs:   Ranges{{Pos: 1, Size: 5}, {Pos: 10, Size: 5}},
+			r:    Range{Pos: 4, Size: 8},
+			want: Ranges{{Pos: 1, Size: 3}, {Pos: 12, Size: 3}},
+		},
+		{
+			rs:   Ranges{{Pos: 1, Size: 5}, {Pos: 10, Size: 5}},
+			r:    Range{Pos: 6, Size: 4},
+			want: Ranges{{Pos: 1, Size: 5}, {Pos: 10, Size: 5}},
+		},
+	} {
+		got := append(Ranges(nil), test.rs...)
+		got.Remove(test.r)
+		what := fmt.Sprintf("remove %+v from %+v", test.r, test.rs)
+		assert.Equal(t, test.want, got, what)
+		checkRanges(t, got, what)
+	}
+}
+
 func TestRangesEqual(t *testing.T) {
 	for _, test := range []struct {
 		rs   Ranges
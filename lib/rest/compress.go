@@ -0,0 +1,137 @@
+package rest
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/pkg/errors"
+)
+
+// acceptEncoding is the Accept-Encoding header sent when
+// decompression is enabled
+const acceptEncoding = "gzip, deflate, zstd"
+
+// SetDecompress asks the server to compress its responses and
+// decompresses them transparently if set.
+//
+// The request is sent with an Accept-Encoding header unless the caller
+// has set one already or it is a Range request.
+func (api *Client) SetDecompress(decompress bool) *Client {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	api.decompress = decompress
+	return api
+}
+
+// SetGzipRequests gzips the request bodies marshalled by CallJSON
+// and CallXML if set.
+//
+// Only enable this for servers which accept "Content-Encoding: gzip"
+// requests.
+func (api *Client) SetGzipRequests(gzipRequests bool) *Client {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	api.gzipRequests = gzipRequests
+	return api
+}
+
+// wantCompressedResponse returns true if the Accept-Encoding header
+// should be added to req
+func wantCompressedResponse(req *http.Request) bool {
+	return req.Header.Get("Accept-Encoding") == "" && req.Header.Get("Range") == ""
+}
+
+// gzipBody returns data gzipped
+func gzipBody(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	_, err := zw.Write(data)
+	if err != nil {
+		return nil, err
+	}
+	err = zw.Close()
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// decompressBody replaces resp.Body with a decompressing reader if
+// the response has a Content-Encoding we asked for.
+//
+// Like the standard library transport it removes the Content-Encoding
+// and Content-Length headers and marks the response as Uncompressed.
+func decompressBody(resp *http.Response) {
+	encoding := strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding")))
+	var open openDecompressor
+	switch encoding {
+	case "gzip", "x-gzip":
+		open = func(in io.Reader) (io.ReadCloser, error) {
+			return gzip.NewReader(in)
+		}
+	case "deflate":
+		// HTTP deflate is the zlib format - RFC 7230 section 4.2.2
+		open = zlib.NewReader
+	case "zstd":
+		open = func(in io.Reader) (io.ReadCloser, error) {
+			zr, err := zstd.NewReader(in)
+			if err != nil {
+				return nil, err
+			}
+			return zr.IOReadCloser(), nil
+		}
+	default:
+		return
+	}
+	resp.Body = &decompressReader{body: resp.Body, open: open, encoding: encoding}
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = -1
+	resp.Uncompressed = true
+}
+
+// openDecompressor returns a decompressing reader for in
+type openDecompressor func(in io.Reader) (io.ReadCloser, error)
+
+// decompressReader decompresses body, opening the decompressor on
+// the first Read so empty bodies (e.g. HEAD responses) don't error.
+type decompressReader struct {
+	body     io.ReadCloser
+	open     openDecompressor
+	encoding string
+	zr       io.ReadCloser
+	err      error
+}
+
+// Read decompressed data into p
+func (r *decompressReader) Read(p []byte) (n int, err error) {
+	if r.err != nil {
+		return 0, r.err
+	}
+	if r.zr == nil {
+		zr, err := r.open(r.body)
+		if err == io.EOF {
+			// empty body
+			r.err = io.EOF
+			return 0, r.err
+		} else if err != nil {
+			r.err = errors.Wrapf(err, "failed to decompress %s response", r.encoding)
+			return 0, r.err
+		}
+		r.zr = zr
+	}
+	return r.zr.Read(p)
+}
+
+// Close the decompressor and the body
+func (r *decompressReader) Close() error {
+	if r.zr != nil {
+		_ = r.zr.Close()
+	}
+	return r.body.Close()
+}
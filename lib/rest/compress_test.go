@@ -0,0 +1,118 @@
+package rest
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testItem struct {
+	Name string `json:"name"`
+}
+
+// compressWith compresses data with the encoding given
+func compressWith(t *testing.T, encoding string, data []byte) []byte {
+	var buf bytes.Buffer
+	var zw io.WriteCloser
+	switch encoding {
+	case "gzip":
+		zw = gzip.NewWriter(&buf)
+	case "deflate":
+		zw = zlib.NewWriter(&buf)
+	case "zstd":
+		var err error
+		zw, err = zstd.NewWriter(&buf)
+		require.NoError(t, err)
+	default:
+		t.Fatalf("unknown encoding %q", encoding)
+	}
+	_, err := zw.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+func TestDecompress(t *testing.T) {
+	const body = `{"name":"potato"}`
+	for _, encoding := range []string{"gzip", "deflate", "zstd"} {
+		t.Run(encoding, func(t *testing.T) {
+			var gotAcceptEncoding string
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotAcceptEncoding = r.Header.Get("Accept-Encoding")
+				w.Header().Set("Content-Encoding", encoding)
+				_, _ = w.Write(compressWith(t, encoding, []byte(body)))
+			}))
+			defer ts.Close()
+
+			api := NewClient(http.DefaultClient).SetRoot(ts.URL).SetDecompress(true)
+			var result testItem
+			_, err := api.CallJSON(context.Background(), &Opts{Method: "GET"}, nil, &result)
+			require.NoError(t, err)
+			assert.Equal(t, acceptEncoding, gotAcceptEncoding)
+			assert.Equal(t, "potato", result.Name)
+		})
+	}
+}
+
+func TestDecompressEmptyBody(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", "gzip")
+	}))
+	defer ts.Close()
+
+	api := NewClient(http.DefaultClient).SetRoot(ts.URL).SetDecompress(true)
+	resp, err := api.Call(context.Background(), &Opts{Method: "HEAD"})
+	require.NoError(t, err)
+	data, err := ReadBody(resp)
+	require.NoError(t, err)
+	assert.Equal(t, 0, len(data))
+}
+
+func TestDecompressRange(t *testing.T) {
+	var gotAcceptEncoding string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAcceptEncoding = r.Header.Get("Accept-Encoding")
+	}))
+	defer ts.Close()
+
+	api := NewClient(http.DefaultClient).SetRoot(ts.URL).SetDecompress(true)
+	_, err := api.Call(context.Background(), &Opts{
+		Method:       "GET",
+		ExtraHeaders: map[string]string{"Range": "bytes=0-1"},
+		NoResponse:   true,
+	})
+	require.NoError(t, err)
+	// Go's transport adds its own Accept-Encoding: gzip for non range requests
+	assert.Equal(t, "", gotAcceptEncoding)
+}
+
+func TestGzipRequests(t *testing.T) {
+	var gotEncoding string
+	var got testItem
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotEncoding = r.Header.Get("Content-Encoding")
+		zr, err := gzip.NewReader(r.Body)
+		require.NoError(t, err)
+		data, err := ioutil.ReadAll(zr)
+		require.NoError(t, err)
+		assert.Equal(t, `{"name":"potato"}`, string(data))
+		_, _ = w.Write(data)
+	}))
+	defer ts.Close()
+
+	api := NewClient(http.DefaultClient).SetRoot(ts.URL).SetGzipRequests(true)
+	_, err := api.CallJSON(context.Background(), &Opts{Method: "POST"}, &testItem{Name: "potato"}, &got)
+	require.NoError(t, err)
+	assert.Equal(t, "gzip", gotEncoding)
+	assert.Equal(t, "potato", got.Name)
+}
@@ -28,6 +28,8 @@ type Client struct {
 	errorHandler func(resp *http.Response) error
 	headers      map[string]string
 	signer       SignerFn
+	decompress   bool // ask for compressed responses and decompress them
+	gzipRequests bool // gzip marshalled request bodies
 }
 
 // NewClient takes an oauth http.Client and makes a new api instance
@@ -261,6 +263,10 @@ func (api *Client) Call(ctx context.Context, opts *Opts) (resp *http.Response, e
 	if opts.UserName != "" || opts.Password != "" {
 		req.SetBasicAuth(opts.UserName, opts.Password)
 	}
+	decompress := api.decompress && wantCompressedResponse(req)
+	if decompress {
+		req.Header.Set("Accept-Encoding", acceptEncoding)
+	}
 	var c *http.Client
 	if opts.NoRedirect {
 		c = ClientWithNoRedirects(api.c)
@@ -281,6 +287,9 @@ func (api *Client) Call(ctx context.Context, opts *Opts) (resp *http.Response, e
 	if err != nil {
 		return nil, err
 	}
+	if decompress {
+		decompressBody(resp)
+	}
 	if !opts.IgnoreStatus {
 		if resp.StatusCode < 200 || resp.StatusCode > 299 {
 			err = api.errorHandler(resp)
@@ -452,6 +461,25 @@ func (api *Client) callCodec(ctx context.Context, opts *Opts, request interface{
 			opts = opts.Copy()
 			opts.ContentType = contentType
 			opts.Body = bytes.NewBuffer(requestBody)
+			api.mu.RLock()
+			gzipRequests := api.gzipRequests
+			api.mu.RUnlock()
+			if gzipRequests && opts.MultipartParams == nil && opts.MultipartContentName == "" {
+				gzipped, err := gzipBody(requestBody)
+				if err != nil {
+					return nil, err
+				}
+				opts.Body = bytes.NewBuffer(gzipped)
+				if opts.ContentLength != nil {
+					contentLength := int64(len(gzipped))
+					opts.ContentLength = &contentLength
+				}
+				extraHeaders := map[string]string{"Content-Encoding": "gzip"}
+				for k, v := range opts.ExtraHeaders {
+					extraHeaders[k] = v
+				}
+				opts.ExtraHeaders = extraHeaders
+			}
 		}
 	}
 	if opts.MultipartParams != nil || opts.MultipartContentName != "" {
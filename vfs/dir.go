@@ -661,35 +661,103 @@ func (d *Dir) _readDirFromEntries(entries fs.DirEntries, dirTree dirtree.DirTree
 	return nil
 }
 
+// readDirPlusFiles returns the files in the directory, and in all the
+// directories below it if recurse is set, for --vfs-readdirplus to
+// prefill the modtimes of. It returns nil if it isn't enabled.
+func (d *Dir) readDirPlusFiles(recurse bool) (files []*File) {
+	if !d.vfs.Opt.ReadDirPlus || d.vfs.Opt.NoModTime {
+		return nil
+	}
+	var dirs []*Dir
+	d.mu.RLock()
+	for _, node := range d.items {
+		switch x := node.(type) {
+		case *File:
+			files = append(files, x)
+		case *Dir:
+			if recurse {
+				dirs = append(dirs, x)
+			}
+		}
+	}
+	d.mu.RUnlock()
+	for _, dir := range dirs {
+		files = append(files, dir.readDirPlusFiles(true)...)
+	}
+	return files
+}
+
+// prefillModTimes reads the modtimes of the files passed in in
+// parallel for --vfs-readdirplus
+//
+// This means a file manager opening a big directory doesn't cause a
+// transaction for each file it stats.
+//
+// It calls the backend so must be called without any Dir locks held.
+func prefillModTimes(ctx context.Context, files []*File) {
+	if len(files) == 0 {
+		return
+	}
+	checkers := fs.GetConfig(ctx).Checkers
+	if checkers < 1 {
+		checkers = 1
+	}
+	var (
+		wg sync.WaitGroup
+		in = make(chan *File, checkers)
+	)
+	for i := 0; i < checkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for file := range in {
+				file.prefillModTime(ctx)
+			}
+		}()
+	}
+	for _, file := range files {
+		in <- file
+	}
+	close(in)
+	wg.Wait()
+}
+
 // readDirTree forces a refresh of the complete directory tree
-func (d *Dir) readDirTree() error {
+func (d *Dir) readDirTree(ctx context.Context) error {
 	d.mu.RLock()
 	f, path := d.f, d.path
 	d.mu.RUnlock()
 	when := time.Now()
 	fs.Debugf(path, "Reading directory tree")
-	dt, err := walk.NewDirTree(context.TODO(), f, path, false, -1)
+	dt, err := walk.NewDirTree(ctx, f, path, false, -1)
 	if err != nil {
 		return err
 	}
 	d.mu.Lock()
-	defer d.mu.Unlock()
 	d.read = time.Time{}
 	err = d._readDirFromDirTree(dt, when)
 	if err != nil {
+		d.mu.Unlock()
 		return err
 	}
 	fs.Debugf(d.path, "Reading directory tree done in %s", time.Since(when))
 	d.read = when
+	d.mu.Unlock()
+	prefillModTimes(ctx, d.readDirPlusFiles(true))
 	return nil
 }
 
 // readDir forces a refresh of the directory
-func (d *Dir) readDir() error {
+func (d *Dir) readDir(ctx context.Context) error {
 	d.mu.Lock()
-	defer d.mu.Unlock()
 	d.read = time.Time{}
-	return d._readDir()
+	err := d._readDir()
+	d.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	prefillModTimes(ctx, d.readDirPlusFiles(false))
+	return nil
 }
 
 // stat a single item in the directory
@@ -822,6 +890,7 @@ func (d *Dir) ReadDirAll() (items Nodes, err error) {
 		items = append(items, item)
 	}
 	d.mu.Unlock()
+	prefillModTimes(context.TODO(), d.readDirPlusFiles(false))
 	sort.Sort(items)
 	// fs.Debugf(d.path, "Dir.ReadDirAll OK with %d entries", len(items))
 	return items, nil
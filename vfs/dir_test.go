@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"path"
 	"sort"
 	"testing"
 	"time"
@@ -11,6 +12,7 @@ import (
 	"github.com/pingme998/rclone/fs"
 	"github.com/pingme998/rclone/fs/operations"
 	"github.com/pingme998/rclone/fstest"
+	"github.com/pingme998/rclone/vfs/vfscommon"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -333,6 +335,61 @@ func TestDirReadDirAll(t *testing.T) {
 	})
 }
 
+func TestDirReadDirPlus(t *testing.T) {
+	opt := vfscommon.DefaultOpt
+	opt.ReadDirPlus = true
+	r, vfs, cleanup := newTestVFSOpt(t, &opt)
+	defer cleanup()
+
+	file1 := r.WriteObject(context.Background(), "dir/file1", "file1 contents", t1)
+	file2 := r.WriteObject(context.Background(), "dir/file2", "file2- contents", t2)
+	fstest.CheckItems(t, r.Fremote, file1, file2)
+
+	node, err := vfs.Stat("dir")
+	require.NoError(t, err)
+	dir := node.(*Dir)
+	checkListing(t, dir, []string{"file1,14,false", "file2,15,false"})
+
+	for _, item := range []fstest.Item{file1, file2} {
+		node, err := dir.Stat(path.Base(item.Path))
+		require.NoError(t, err)
+		file := node.(*File)
+		assert.True(t, file.AttrCached())
+		file.mu.RLock()
+		listedModTime := file.listedModTime
+		file.mu.RUnlock()
+		fstest.AssertTimeEqualWithPrecision(t, item.Path, item.ModTime, listedModTime, r.Fremote.Precision())
+		assert.Equal(t, listedModTime, file.ModTime())
+	}
+
+	// Setting the modtime should invalidate the listed modtime
+	node, err = dir.Stat("file1")
+	require.NoError(t, err)
+	file := node.(*File)
+	require.NoError(t, file.SetModTime(t2))
+	assert.False(t, file.AttrCached())
+	fstest.AssertTimeEqualWithPrecision(t, "file1", t2, file.ModTime(), r.Fremote.Precision())
+}
+
+func TestDirReadDirPlusTree(t *testing.T) {
+	opt := vfscommon.DefaultOpt
+	opt.ReadDirPlus = true
+	r, vfs, cleanup := newTestVFSOpt(t, &opt)
+	defer cleanup()
+
+	file1 := r.WriteObject(context.Background(), "dir/sub/file1", "file1 contents", t1)
+	fstest.CheckItems(t, r.Fremote, file1)
+
+	root, err := vfs.Root()
+	require.NoError(t, err)
+	require.NoError(t, root.readDirTree(context.Background()))
+
+	file, ok := root.cachedNode("dir/sub/file1").(*File)
+	require.True(t, ok)
+	assert.True(t, file.AttrCached())
+	fstest.AssertTimeEqualWithPrecision(t, file1.Path, file1.ModTime, file.ModTime(), r.Fremote.Precision())
+}
+
 func TestDirOpen(t *testing.T) {
 	_, _, dir, _, cleanup := dirCreate(t)
 	defer cleanup()
@@ -0,0 +1,8 @@
+// +build linux
+
+package vfs
+
+import "syscall"
+
+// oDirect is the open flag asking for uncached IO
+const oDirect = syscall.O_DIRECT
@@ -0,0 +1,7 @@
+// +build !linux
+
+package vfs
+
+// oDirect is the open flag asking for uncached IO - it isn't
+// available on this OS so it is never set
+const oDirect = 0
@@ -46,6 +46,7 @@ type File struct {
 	writers          []Handle                        // writers for this file
 	nwriters         int32                           // len(writers) which is read/updated with atomic
 	pendingModTime   time.Time                       // will be applied once o becomes available, i.e. after file was written
+	listedModTime    time.Time                       // modtime of o read when listing with --vfs-readdirplus
 	pendingRenameFun func(ctx context.Context) error // will be run/renamed after all writers close
 	appendMode       bool                            // file was opened with O_APPEND
 	sys              atomic.Value                    // user defined info to be attached here
@@ -303,7 +304,7 @@ func (f *File) _roundModTime(modTime time.Time) time.Time {
 // if NoModTime is set then it returns the mod time of the directory
 func (f *File) ModTime() (modTime time.Time) {
 	f.mu.RLock()
-	d, o, pendingModTime := f.d, f.o, f.pendingModTime
+	d, o, pendingModTime, listedModTime := f.d, f.o, f.pendingModTime, f.listedModTime
 	f.mu.RUnlock()
 
 	if d.vfs.Opt.NoModTime {
@@ -326,9 +327,41 @@ func (f *File) ModTime() (modTime time.Time) {
 	if o == nil {
 		return time.Now()
 	}
+	if !listedModTime.IsZero() {
+		return listedModTime
+	}
 	return o.ModTime(context.TODO())
 }
 
+// prefillModTime reads the modtime of the object into the File so
+// that ModTime doesn't need to call the backend.
+//
+// This is used by --vfs-readdirplus when the directory is listed.
+func (f *File) prefillModTime(ctx context.Context) {
+	f.mu.RLock()
+	o, done := f.o, !f.listedModTime.IsZero()
+	f.mu.RUnlock()
+	if o == nil || done {
+		return
+	}
+	modTime := o.ModTime(ctx)
+	f.mu.Lock()
+	if f.o == o {
+		f.listedModTime = modTime
+	}
+	f.mu.Unlock()
+}
+
+// AttrCached returns true if the attributes of the file were read
+// when its directory was listed with --vfs-readdirplus and the file
+// isn't being written. If so they are valid for as long as the
+// directory cache.
+func (f *File) AttrCached() bool {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return !f.listedModTime.IsZero() && !f._writingInProgress()
+}
+
 // nonNegative returns 0 if i is -ve, i otherwise
 func nonNegative(i int64) int64 {
 	if i >= 0 {
@@ -414,6 +447,7 @@ func (f *File) _applyPendingModTime() error {
 	switch err {
 	case nil:
 		fs.Debugf(f.o, "Applied pending mod time %v OK", f.pendingModTime)
+		f.listedModTime = time.Time{}
 	case fs.ErrorCantSetModTime, fs.ErrorCantSetModTimeWithoutDelete:
 		// do nothing, in order to not break "touch somefile" if it exists already
 	default:
@@ -453,6 +487,7 @@ func (f *File) setSize(n int64) {
 func (f *File) setObject(o fs.Object) {
 	f.mu.Lock()
 	f.o = o
+	f.listedModTime = time.Time{}
 	_ = f._applyPendingModTime()
 	d := f.d
 	f.mu.Unlock()
@@ -465,6 +500,9 @@ func (f *File) setObject(o fs.Object) {
 // the directory cache
 func (f *File) setObjectNoUpdate(o fs.Object) {
 	f.mu.Lock()
+	if f.o != o {
+		f.listedModTime = time.Time{}
+	}
 	f.o = o
 	f.mu.Unlock()
 }
@@ -702,7 +740,10 @@ func (f *File) Open(flags int) (fd Handle, err error) {
 	d := f.d
 	f.mu.RUnlock()
 	CacheMode := d.vfs.Opt.CacheMode
-	if CacheMode >= vfscommon.CacheModeMinimal && (d.vfs.cache.InUse(f.Path()) || d.vfs.cache.Exists(f.Path())) {
+	if f.bypassCache(flags) {
+		fs.Debugf(f.Path(), "Reading with O_DIRECT bypassing the cache")
+		fd, err = f.openRead()
+	} else if CacheMode >= vfscommon.CacheModeMinimal && (d.vfs.cache.InUse(f.Path()) || d.vfs.cache.Exists(f.Path())) {
 		fd, err = f.openRW(flags)
 	} else if read && write {
 		if CacheMode >= vfscommon.CacheModeMinimal {
@@ -737,6 +778,25 @@ func (f *File) Open(flags int) (fd Handle, err error) {
 	return fd, err
 }
 
+// bypassCache returns true if a read only open with flags should read
+// straight from the remote rather than through the VFS cache.
+//
+// This is done for O_DIRECT opens if --vfs-cache-bypass-direct is set,
+// unless the cache has changes to the file which haven't been uploaded.
+func (f *File) bypassCache(flags int) bool {
+	vfs := f.VFS()
+	if !vfs.Opt.CacheBypassDirect || oDirect == 0 || flags&oDirect == 0 {
+		return false
+	}
+	if flags&(accessModeMask|os.O_APPEND|os.O_TRUNC) != os.O_RDONLY || vfs.cache == nil {
+		return false
+	}
+	if f.getObject() == nil || vfs.cache.DirtyItem(f.Path()) != nil {
+		return false
+	}
+	return true
+}
+
 // Truncate changes the size of the named file.
 func (f *File) Truncate(size int64) (err error) {
 	// make a copy of fh.writers with the lock held then unlock so
@@ -182,6 +182,47 @@ func TestFileOpenRead(t *testing.T) {
 	fileCheckContents(t, file)
 }
 
+func TestFileOpenReadDirect(t *testing.T) {
+	if oDirect == 0 {
+		t.Skip("O_DIRECT not supported")
+	}
+	_, vfs, file, _, cleanup := fileCreate(t, vfscommon.CacheModeFull)
+	defer cleanup()
+
+	// O_DIRECT is ignored without --vfs-cache-bypass-direct
+	fd, err := file.Open(os.O_RDONLY | oDirect)
+	require.NoError(t, err)
+	assert.IsType(t, (*RWFileHandle)(nil), fd)
+	require.NoError(t, fd.Close())
+
+	// O_DIRECT reads bypass the cache
+	vfs.Opt.CacheBypassDirect = true
+	fd, err = file.Open(os.O_RDONLY | oDirect)
+	require.NoError(t, err)
+	assert.IsType(t, (*ReadFileHandle)(nil), fd)
+	contents, err := ioutil.ReadAll(fd)
+	require.NoError(t, err)
+	assert.Equal(t, "file1 contents", string(contents))
+	require.NoError(t, fd.Close())
+
+	// but not writes
+	fd, err = file.Open(os.O_RDWR | oDirect)
+	require.NoError(t, err)
+	assert.IsType(t, (*RWFileHandle)(nil), fd)
+	_, err = fd.WriteAt([]byte("FILE1"), 0)
+	require.NoError(t, err)
+	require.NoError(t, fd.Close())
+
+	// and files with changes not uploaded yet are read from the cache
+	fd, err = file.Open(os.O_RDONLY | oDirect)
+	require.NoError(t, err)
+	assert.IsType(t, (*RWFileHandle)(nil), fd)
+	contents, err = ioutil.ReadAll(fd)
+	require.NoError(t, err)
+	assert.Equal(t, "FILE1 contents", string(contents))
+	require.NoError(t, fd.Close())
+}
+
 func TestFileOpenReadUnknownSize(t *testing.T) {
 	var (
 		contents = []byte("file contents")
@@ -0,0 +1,176 @@
+package vfs
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// handleStats records the IO done through an open file handle so it
+// can be read with the vfs/handles rc call
+type handleStats struct {
+	id     int64     // unique id of the handle
+	file   *File     // file the handle is open on
+	mode   string    // type of the handle - "read", "write" or "rw"
+	flags  int       // flags the handle was opened with
+	opened time.Time // when the handle was opened
+
+	mu           sync.Mutex
+	bytesRead    int64     // bytes returned by reads
+	bytesWritten int64     // bytes written
+	reads        int64     // number of reads
+	writes       int64     // number of writes
+	seeks        int64     // number of reads and writes not where the last one finished
+	cacheHits    int64     // number of reads entirely from the cache
+	cacheMisses  int64     // number of reads which needed data from the remote
+	next         int64     // offset the next sequential IO is expected at
+	lastIO       time.Time // time of the last read or write
+}
+
+// last handle id handed out - accessed with atomic
+var lastHandleID int64
+
+// newHandleStats makes a handleStats for a handle of the type mode on
+// f opened with flags and registers it with the VFS
+func newHandleStats(f *File, mode string, flags int) *handleStats {
+	s := &handleStats{
+		id:     atomic.AddInt64(&lastHandleID, 1),
+		file:   f,
+		mode:   mode,
+		flags:  flags,
+		opened: time.Now(),
+	}
+	f.VFS().addHandle(s)
+	return s
+}
+
+// done unregisters the handle from the VFS - it is safe to call more
+// than once
+func (s *handleStats) done() {
+	s.file.VFS().delHandle(s)
+}
+
+// _io records n bytes of IO at off
+//
+// call with mu held
+func (s *handleStats) _io(off int64, n int) {
+	if off != s.next {
+		s.seeks++
+	}
+	s.next = off + int64(n)
+	s.lastIO = time.Now()
+}
+
+// read records a read of n bytes at off
+func (s *handleStats) read(off int64, n int) {
+	s.mu.Lock()
+	s._io(off, n)
+	s.reads++
+	s.bytesRead += int64(n)
+	s.mu.Unlock()
+}
+
+// write records a write of n bytes at off
+func (s *handleStats) write(off int64, n int) {
+	s.mu.Lock()
+	s._io(off, n)
+	s.writes++
+	s.bytesWritten += int64(n)
+	s.mu.Unlock()
+}
+
+// cacheRead records whether a read was satisfied from the cache
+func (s *handleStats) cacheRead(hit bool) {
+	s.mu.Lock()
+	if hit {
+		s.cacheHits++
+	} else {
+		s.cacheMisses++
+	}
+	s.mu.Unlock()
+}
+
+// handleInfo is the information about a handle returned by the
+// vfs/handles rc call
+type handleInfo struct {
+	ID            int64      `json:"id"`
+	Path          string     `json:"path"`
+	Mode          string     `json:"mode"`
+	Flags         string     `json:"flags"`
+	Opened        time.Time  `json:"opened"`
+	LastIO        *time.Time `json:"lastIO,omitempty"`
+	BytesRead     int64      `json:"bytesRead"`
+	BytesWritten  int64      `json:"bytesWritten"`
+	Reads         int64      `json:"reads"`
+	Writes        int64      `json:"writes"`
+	Seeks         int64      `json:"seeks"`
+	CacheHits     int64      `json:"cacheHits"`
+	CacheMisses   int64      `json:"cacheMisses"`
+	CacheHitRatio *float64   `json:"cacheHitRatio,omitempty"` // only set if any reads used the cache
+}
+
+// info returns a snapshot of the stats
+func (s *handleStats) info() (info handleInfo) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	info = handleInfo{
+		ID:           s.id,
+		Path:         s.file.Path(),
+		Mode:         s.mode,
+		Flags:        decodeOpenFlags(s.flags),
+		Opened:       s.opened,
+		BytesRead:    s.bytesRead,
+		BytesWritten: s.bytesWritten,
+		Reads:        s.reads,
+		Writes:       s.writes,
+		Seeks:        s.seeks,
+		CacheHits:    s.cacheHits,
+		CacheMisses:  s.cacheMisses,
+	}
+	if !s.lastIO.IsZero() {
+		lastIO := s.lastIO
+		info.LastIO = &lastIO
+	}
+	if total := s.cacheHits + s.cacheMisses; total > 0 {
+		ratio := float64(s.cacheHits) / float64(total)
+		info.CacheHitRatio = &ratio
+	}
+	return info
+}
+
+// addHandle registers the stats of an open handle
+func (vfs *VFS) addHandle(s *handleStats) {
+	vfs.handlesMu.Lock()
+	if vfs.handles == nil {
+		vfs.handles = make(map[*handleStats]struct{})
+	}
+	vfs.handles[s] = struct{}{}
+	vfs.handlesMu.Unlock()
+}
+
+// delHandle unregisters the stats of a closed handle
+func (vfs *VFS) delHandle(s *handleStats) {
+	vfs.handlesMu.Lock()
+	delete(vfs.handles, s)
+	vfs.handlesMu.Unlock()
+}
+
+// handleInfos returns the stats of the open handles in the order they
+// were opened
+func (vfs *VFS) handleInfos() []handleInfo {
+	vfs.handlesMu.Lock()
+	handles := make([]*handleStats, 0, len(vfs.handles))
+	for s := range vfs.handles {
+		handles = append(handles, s)
+	}
+	vfs.handlesMu.Unlock()
+	infos := make([]handleInfo, 0, len(handles))
+	for _, s := range handles {
+		infos = append(infos, s.info())
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].ID < infos[j].ID
+	})
+	return infos
+}
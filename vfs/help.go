@@ -105,17 +105,40 @@ second. If rclone is quit or dies with files that haven't been
 uploaded, these will be uploaded next time rclone is run with the same
 flags.
 
+If a file waiting to be uploaded is replaced, for example by an
+editor saving to a temporary file and renaming it over the original,
+the upload of the old version is cancelled, even if it has started,
+so only the final version is uploaded.
+
+The uploads of files written back from the cache can be scheduled and
+throttled so they don't compete with other traffic. If
+!--vfs-write-back-window! is set then files are only uploaded within
+that time of day, eg !23:00-07:00!. Files which are ready outside the
+window will wait for it to open. !--vfs-write-back-uploads! limits
+the number of files uploaded at once (it defaults to !--transfers!)
+and !--vfs-write-back-bwlimit! limits the total bandwidth they use.
+
+    --vfs-write-back-window TimeWindow   Only upload files from the cache within this time of day, eg 23:00-07:00.
+    --vfs-write-back-uploads int         Max number of files to upload from the cache at once. (default --transfers)
+    --vfs-write-back-bwlimit SizeSuffix  Bandwidth limit in bytes/s for uploading files from the cache. (default off)
+
 If using !--vfs-cache-max-size! note that the cache may exceed this size
 for two reasons.  Firstly because it is only checked every
 !--vfs-cache-poll-interval!.  Secondly because open files cannot be
 evicted from the cache.
 
-You **should not** run two copies of rclone using the same VFS cache
-with the same or overlapping remotes if using !--vfs-cache-mode > off!.
-This can potentially cause data corruption if you do. You can work
-around this by giving each rclone its own cache hierarchy with
-!--cache-dir!. You don't need to worry about this if the remotes in
-use don't overlap.
+Two copies of rclone on the same machine, eg an !rclone mount! and an
+!rclone serve webdav!, can share the same VFS cache for the same
+remote. The cache metadata is locked while it is updated and each
+file records which rclone processes have it open or are waiting to
+upload it. A file in use by another rclone won't be removed from the
+cache and parts of a file downloaded by one rclone are used by the
+other once they have been recorded (when the file is closed).
+Sharing the cache between different machines (eg on a network drive)
+isn't supported and nor is sharing it between overlapping remotes such
+as !remote:! and !remote:dir!. In those cases give each rclone its own
+cache hierarchy with !--cache-dir!. The cache can't be shared on
+Solaris, AIX and Plan 9 as rclone can't lock it there.
 
 #### --vfs-cache-mode off
 
@@ -179,6 +202,50 @@ whereas the --vfs-read-ahead is buffered on disk.
 When using this mode it is recommended that --buffer-size is not set
 too big and --vfs-read-ahead is set large if required.
 
+If --vfs-read-ahead-adaptive is set then rclone watches the reads on
+each open file. When they are sequential, for example when streaming
+a video, the read ahead is doubled on each read up to
+--vfs-read-ahead-max. As soon as a read is not sequential the read
+ahead drops back to --vfs-read-ahead so seeking doesn't waste
+bandwidth downloading data which won't be used.
+
+    --vfs-read-ahead-adaptive          Grow the read ahead for sequential reads when using cache-mode full.
+    --vfs-read-ahead-max SizeSuffix    Max read ahead when using --vfs-read-ahead-adaptive. (default 128M)
+
+By default the cache evicts whole files when they are older than
+--vfs-cache-max-age or the cache is bigger than --vfs-cache-max-size.
+If --vfs-cache-chunk-size is set then rclone records when each chunk
+of that size of each file was last read and evicts just the cold
+chunks of files which aren't open, so for example a large video file
+can keep only the recently watched parts in the cache. This needs an
+OS and file system which can punch holes in files (Linux only at the
+moment) otherwise whole files are evicted as usual.
+
+    --vfs-cache-chunk-size SizeSuffix   Evict cold parts of files from the cache in chunks of this size. (default off)
+
+If --vfs-cache-verify is set then rclone checks the hash of each
+complete cache file against the hash of the remote object, the first
+time the file is opened from the cache and when it has finished
+downloading. If they differ the cache file is moved out of the way to
+the "vfsCorrupt" directory in the --cache-dir, where it can be
+examined, and the file is downloaded again rather than serving the
+corrupted data. This reads the whole of each cache file, so it slows
+down opening large files, and it does nothing for remotes which don't
+support hashes.
+
+    --vfs-cache-verify   Check the hash of cached files against the remote and discard them if corrupted.
+
+If --vfs-cache-bypass-direct is set then files opened read only with
+the O_DIRECT flag are read straight from the remote as they would be
+with --vfs-cache-mode off, rather than through the cache. This stops
+applications which read everything once, eg backup programs which can
+be told to use O_DIRECT, from evicting the files other applications
+are using from the cache. Files with changes in the cache which
+haven't been uploaded yet are still read from the cache. This only
+works on Linux with !rclone mount! and !rclone mount2!.
+
+    --vfs-cache-bypass-direct   Read files opened with O_DIRECT straight from the remote, not through the cache.
+
 **IMPORTANT** not all file systems support sparse files. In particular
 FAT/exFAT do not. Rclone will perform very badly if the cache
 directory is on a filesystem which doesn't support sparse files and it
@@ -198,6 +265,17 @@ read of the modification time takes a transaction.
     --no-seek         Don't allow seeking in files.
     --read-only       Mount read-only.
 
+File managers opening a big directory will typically read the
+attributes of every file in it. On remotes where reading the
+modification time takes a transaction this can be very slow. If
+--vfs-readdirplus is set then rclone reads the modification times of
+all the files (using --checkers in parallel) when it lists the
+directory. The attributes of files which aren't being written are
+then cached by the kernel for --dir-cache-time rather than
+--attr-timeout.
+
+    --vfs-readdirplus   Read file attributes when listing directories and let the kernel cache them.
+
 When rclone reads files from a remote it reads them in chunks. This
 means that rather than requesting the whole file rclone reads the
 chunk specified. This is advantageous because some cloud providers
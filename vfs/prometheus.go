@@ -0,0 +1,103 @@
+package vfs
+
+import (
+	"github.com/pingme998/rclone/vfs/vfscache"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Collector is a Prometheus collector for the caches of the active
+// VFSes, labelled with the remote each is for
+type Collector struct {
+	files             *prometheus.Desc
+	inUse             *prometheus.Desc
+	errored           *prometheus.Desc
+	bytes             *prometheus.Desc
+	outOfSpace        *prometheus.Desc
+	uploadsInProgress *prometheus.Desc
+	uploadsQueued     *prometheus.Desc
+}
+
+// NewCollector makes a new Collector
+func NewCollector() *Collector {
+	const namespace = "rclone_vfs_cache_"
+	labels := []string{"fs"}
+	return &Collector{
+		files: prometheus.NewDesc(namespace+"files",
+			"Number of files in the VFS cache",
+			labels, nil,
+		),
+		inUse: prometheus.NewDesc(namespace+"files_in_use",
+			"Number of files in the VFS cache which are in use",
+			labels, nil,
+		),
+		errored: prometheus.NewDesc(namespace+"errored_files",
+			"Number of files in the VFS cache which failed to upload",
+			labels, nil,
+		),
+		bytes: prometheus.NewDesc(namespace+"bytes",
+			"Total size of the files in the VFS cache",
+			labels, nil,
+		),
+		outOfSpace: prometheus.NewDesc(namespace+"out_of_space",
+			"Whether the disk the VFS cache is on is full",
+			labels, nil,
+		),
+		uploadsInProgress: prometheus.NewDesc(namespace+"uploads_in_progress",
+			"Number of files being uploaded from the VFS cache",
+			labels, nil,
+		),
+		uploadsQueued: prometheus.NewDesc(namespace+"uploads_queued",
+			"Number of files waiting to be uploaded from the VFS cache",
+			labels, nil,
+		),
+	}
+}
+
+// Describe is part of the Collector interface: https://godoc.org/github.com/prometheus/client_golang/prometheus#Collector
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.files
+	ch <- c.inUse
+	ch <- c.errored
+	ch <- c.bytes
+	ch <- c.outOfSpace
+	ch <- c.uploadsInProgress
+	ch <- c.uploadsQueued
+}
+
+// Collect is part of the Collector interface: https://godoc.org/github.com/prometheus/client_golang/prometheus#Collector
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	// Read the stats with activeMu unlocked as reading them takes
+	// the cache locks
+	caches := map[string]*vfscache.Cache{}
+	activeMu.Lock()
+	for fsString, vfses := range active {
+		for _, vfs := range vfses {
+			if vfs.cache != nil {
+				caches[fsString] = vfs.cache
+				break
+			}
+		}
+	}
+	activeMu.Unlock()
+	for fsString, cache := range caches {
+		stats := cache.Stats()
+		gauge := func(desc *prometheus.Desc, value float64) {
+			ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, value, fsString)
+		}
+		gauge(c.files, float64(stats.Files))
+		gauge(c.inUse, float64(stats.InUse))
+		gauge(c.errored, float64(stats.Errored))
+		gauge(c.bytes, float64(stats.Bytes))
+		gauge(c.outOfSpace, bool2Float(stats.OutOfSpace))
+		gauge(c.uploadsInProgress, float64(stats.UploadsInProgress))
+		gauge(c.uploadsQueued, float64(stats.UploadsQueued))
+	}
+}
+
+// bool2Float converts a boolean into a float64 value for Prometheus
+func bool2Float(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
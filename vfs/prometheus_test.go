@@ -0,0 +1,21 @@
+package vfs
+
+import (
+	"testing"
+
+	"github.com/pingme998/rclone/vfs/vfscommon"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCollector(t *testing.T) {
+	opt := vfscommon.DefaultOpt
+	opt.CacheMode = vfscommon.CacheModeWrites
+	_, _, cleanup := newTestVFSOpt(t, &opt)
+	defer cleanup()
+
+	c := NewCollector()
+	caches := testutil.CollectAndCount(c, "rclone_vfs_cache_files")
+	assert.True(t, caches >= 1)
+	assert.Equal(t, 7*caches, testutil.CollectAndCount(c))
+}
@@ -124,9 +124,9 @@ func rcRefresh(ctx context.Context, in rc.Params) (out rc.Params, err error) {
 	result := map[string]string{}
 	if len(in) == 0 {
 		if recursive {
-			err = root.readDirTree()
+			err = root.readDirTree(ctx)
 		} else {
-			err = root.readDir()
+			err = root.readDir(ctx)
 		}
 		if err != nil {
 			result[""] = err.Error()
@@ -145,9 +145,9 @@ func rcRefresh(ctx context.Context, in rc.Params) (out rc.Params, err error) {
 					result[path] = err.Error()
 				} else {
 					if recursive {
-						err = dir.readDirTree()
+						err = dir.readDirTree(ctx)
 					} else {
-						err = dir.readDir()
+						err = dir.readDir(ctx)
 					}
 					if err != nil {
 						result[path] = err.Error()
@@ -389,3 +389,47 @@ func rcList(ctx context.Context, in rc.Params) (out rc.Params, err error) {
 	out["vfses"] = names
 	return out, nil
 }
+
+func init() {
+	rc.Add(rc.Call{
+		Path:  "vfs/handles",
+		Title: "List the open file handles with their IO statistics.",
+		Help: `
+This lists the file handles open on the VFS with statistics about the
+IO done through each. This is useful for finding applications which
+are doing inefficient IO on a mount, for example lots of small reads
+or reads at random offsets.
+
+    rclone rc vfs/handles
+
+It returns a list under the key "handles" in the order the handles
+were opened. Each item has
+
+- id - unique id of the handle
+- path - path of the file the handle is open on
+- mode - type of the handle - "read", "write" or "rw" for handles using the VFS cache
+- flags - flags the handle was opened with
+- opened - time the handle was opened
+- lastIO - time of the last read or write, if any
+- bytesRead - number of bytes read
+- bytesWritten - number of bytes written
+- reads - number of reads
+- writes - number of writes
+- seeks - number of reads and writes which didn't start where the previous one finished
+- cacheHits - number of reads which were satisfied from the VFS cache
+- cacheMisses - number of reads which needed data from the remote
+- cacheHitRatio - cacheHits divided by the number of reads, only present for "rw" handles which have been read
+` + getVFSHelp,
+		Fn: rcHandles,
+	})
+}
+
+func rcHandles(ctx context.Context, in rc.Params) (out rc.Params, err error) {
+	vfs, err := getVFS(in)
+	if err != nil {
+		return nil, err
+	}
+	return rc.Params{
+		"handles": vfs.handleInfos(),
+	}, nil
+}
@@ -2,6 +2,8 @@ package vfs
 
 import (
 	"context"
+	"io"
+	"os"
 	"testing"
 
 	"github.com/pingme998/rclone/fs"
@@ -119,3 +121,102 @@ func TestRcList(t *testing.T) {
 		},
 	}, out)
 }
+
+// rcGetHandles calls vfs/handles returning the handles
+func rcGetHandles(t *testing.T, call *rc.Call) []handleInfo {
+	out, err := call.Fn(context.Background(), rc.Params{})
+	require.NoError(t, err)
+	handles, ok := out["handles"].([]handleInfo)
+	require.True(t, ok)
+	return handles
+}
+
+func TestRcHandles(t *testing.T) {
+	_, vfs, cleanup, call := rcNewRun(t, "vfs/handles")
+	defer cleanup()
+
+	assert.Equal(t, []handleInfo{}, rcGetHandles(t, call))
+
+	// write a file
+	fd, err := vfs.OpenFile("file1", os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0777)
+	require.NoError(t, err)
+	_, err = fd.Write([]byte("hello "))
+	require.NoError(t, err)
+	_, err = fd.Write([]byte("world"))
+	require.NoError(t, err)
+
+	handles := rcGetHandles(t, call)
+	require.Len(t, handles, 1)
+	h := handles[0]
+	assert.Equal(t, "file1", h.Path)
+	assert.Equal(t, "write", h.Mode)
+	assert.Equal(t, "O_WRONLY|O_CREATE|O_TRUNC", h.Flags)
+	assert.NotNil(t, h.LastIO)
+	assert.Equal(t, int64(11), h.BytesWritten)
+	assert.Equal(t, int64(2), h.Writes)
+	assert.Equal(t, int64(0), h.Seeks)
+	assert.Nil(t, h.CacheHitRatio)
+
+	require.NoError(t, fd.Close())
+	assert.Equal(t, []handleInfo{}, rcGetHandles(t, call))
+
+	// read it back with a seek
+	fd, err = vfs.OpenFile("file1", os.O_RDONLY, 0)
+	require.NoError(t, err)
+	buf := make([]byte, 5)
+	_, err = fd.Read(buf)
+	require.NoError(t, err)
+	_, err = fd.Seek(6, io.SeekStart)
+	require.NoError(t, err)
+	_, err = fd.Read(buf)
+	require.NoError(t, err)
+	assert.Equal(t, "world", string(buf))
+
+	handles = rcGetHandles(t, call)
+	require.Len(t, handles, 1)
+	h = handles[0]
+	assert.Equal(t, "read", h.Mode)
+	assert.Equal(t, "O_RDONLY", h.Flags)
+	assert.Equal(t, int64(10), h.BytesRead)
+	assert.Equal(t, int64(2), h.Reads)
+	assert.Equal(t, int64(1), h.Seeks)
+	assert.Equal(t, int64(0), h.BytesWritten)
+
+	require.NoError(t, fd.Close())
+	assert.Equal(t, []handleInfo{}, rcGetHandles(t, call))
+}
+
+func TestRcHandlesCache(t *testing.T) {
+	opt := vfscommon.DefaultOpt
+	opt.CacheMode = vfscommon.CacheModeFull
+	r, vfs, cleanup := newTestVFSOpt(t, &opt)
+	defer cleanup()
+	call := rc.Calls.Get("vfs/handles")
+	require.NotNil(t, call)
+
+	file1 := r.WriteObject(context.Background(), "file1", "0123456789", t1)
+	fstest.CheckItems(t, r.Fremote, file1)
+
+	fd, err := vfs.OpenFile("file1", os.O_RDONLY, 0)
+	require.NoError(t, err)
+	buf := make([]byte, 5)
+	_, err = fd.ReadAt(buf, 0)
+	require.NoError(t, err)
+	_, err = fd.ReadAt(buf, 0)
+	require.NoError(t, err)
+
+	handles := rcGetHandles(t, call)
+	require.Len(t, handles, 1)
+	h := handles[0]
+	assert.Equal(t, "rw", h.Mode)
+	assert.Equal(t, int64(10), h.BytesRead)
+	assert.Equal(t, int64(2), h.Reads)
+	assert.Equal(t, int64(1), h.Seeks)
+	assert.Equal(t, int64(1), h.CacheHits)
+	assert.Equal(t, int64(1), h.CacheMisses)
+	require.NotNil(t, h.CacheHitRatio)
+	assert.Equal(t, 0.5, *h.CacheHitRatio)
+
+	require.NoError(t, fd.Close())
+	assert.Equal(t, []handleInfo{}, rcGetHandles(t, call))
+}
@@ -32,6 +32,7 @@ type ReadFileHandle struct {
 	hash        *hash.MultiHasher
 	opened      bool
 	remote      string
+	stats       *handleStats
 }
 
 // Check interfaces
@@ -63,6 +64,7 @@ func newReadFileHandle(f *File) (*ReadFileHandle, error) {
 		sizeUnknown: o.Size() < 0,
 	}
 	fh.cond = sync.NewCond(&fh.mu)
+	fh.stats = newHandleStats(f, "read", os.O_RDONLY)
 	return fh, nil
 }
 
@@ -209,7 +211,9 @@ func (fh *ReadFileHandle) Seek(offset int64, whence int) (n int64, err error) {
 func (fh *ReadFileHandle) ReadAt(p []byte, off int64) (n int, err error) {
 	fh.mu.Lock()
 	defer fh.mu.Unlock()
-	return fh.readAt(p, off)
+	n, err = fh.readAt(p, off)
+	fh.stats.read(off, n)
+	return n, err
 }
 
 // This waits for *poff to equal off or aborts after the timeout.
@@ -398,6 +402,7 @@ func (fh *ReadFileHandle) Read(p []byte) (n int, err error) {
 		return 0, io.EOF
 	}
 	n, err = fh.readAt(p, fh.roffset)
+	fh.stats.read(fh.roffset, n)
 	fh.roffset += int64(n)
 	return n, err
 }
@@ -411,6 +416,7 @@ func (fh *ReadFileHandle) close() error {
 		return ECLOSED
 	}
 	fh.closed = true
+	fh.stats.done()
 
 	if fh.opened {
 		var err error
@@ -465,6 +471,7 @@ func (fh *ReadFileHandle) Flush() error {
 func (fh *ReadFileHandle) Release() error {
 	fh.mu.Lock()
 	defer fh.mu.Unlock()
+	fh.stats.done()
 	if !fh.opened {
 		return nil
 	}
@@ -0,0 +1,73 @@
+package vfs
+
+const (
+	// number of consecutive sequential reads before the read ahead
+	// starts to grow
+	readAheadSequentialReads = 4
+	// the read ahead starts growing from at least this size
+	readAheadMinGrow = 1024 * 1024
+	// reads which start within this many bytes of the end of the
+	// previous read are considered sequential as the kernel may
+	// deliver reads slightly out of order
+	readAheadMaxGap = 1024 * 1024
+)
+
+// readAheadDetector works out how much to read ahead for a file
+// handle by watching whether the reads made on it are sequential.
+//
+// Sequential reads (eg streaming a video) double the read ahead each
+// time up to max, whereas a random access read resets it back to min
+// so seeking doesn't waste bandwidth.
+//
+// It isn't safe for concurrent use - it relies on the lock of the
+// handle which owns it.
+type readAheadDetector struct {
+	min    int64 // read ahead to use for random access
+	max    int64 // read ahead won't grow beyond this
+	window int64 // current read ahead
+	next   int64 // offset the next sequential read is expected at
+	seq    int   // number of consecutive sequential reads seen
+}
+
+// newReadAheadDetector makes a new readAheadDetector which will vary
+// the read ahead between min and max
+func newReadAheadDetector(min, max int64) *readAheadDetector {
+	if min < 0 {
+		min = 0
+	}
+	if max < min {
+		max = min
+	}
+	return &readAheadDetector{
+		min:    min,
+		max:    max,
+		window: min,
+	}
+}
+
+// update records a read of size bytes at off and returns the number
+// of bytes which should be read ahead for it
+func (d *readAheadDetector) update(off, size int64) int64 {
+	gap := off - d.next
+	if gap < 0 {
+		gap = -gap
+	}
+	if d.next != 0 && gap <= readAheadMaxGap {
+		d.seq++
+	} else {
+		d.seq = 0
+		d.window = d.min
+	}
+	if d.seq >= readAheadSequentialReads && d.window < d.max {
+		if d.window < readAheadMinGrow {
+			d.window = readAheadMinGrow
+		} else {
+			d.window *= 2
+		}
+		if d.window > d.max {
+			d.window = d.max
+		}
+	}
+	d.next = off + size
+	return d.window
+}
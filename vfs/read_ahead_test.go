@@ -0,0 +1,51 @@
+package vfs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadAheadDetector(t *testing.T) {
+	const (
+		min  = 64 * 1024
+		max  = 8 * 1024 * 1024
+		size = 128 * 1024
+	)
+	d := newReadAheadDetector(min, max)
+
+	// Read sequentially and check the read ahead grows up to max
+	var off int64
+	var got []int64
+	for i := 0; i < 12; i++ {
+		got = append(got, d.update(off, size))
+		off += size
+	}
+	assert.Equal(t, []int64{
+		min, min, min, min,
+		readAheadMinGrow, 2 * readAheadMinGrow, 4 * readAheadMinGrow,
+		max, max, max, max, max,
+	}, got)
+
+	// A small out of order read is still sequential
+	assert.Equal(t, int64(max), d.update(off+size, size))
+
+	// A seek resets it
+	assert.Equal(t, int64(min), d.update(100*max, size))
+	assert.Equal(t, int64(min), d.update(0, size))
+	assert.Equal(t, 0, d.seq)
+}
+
+func TestReadAheadDetectorLimits(t *testing.T) {
+	d := newReadAheadDetector(-1, -1)
+	assert.Equal(t, int64(0), d.min)
+	assert.Equal(t, int64(0), d.max)
+
+	// max less than min never grows
+	d = newReadAheadDetector(2*readAheadMinGrow, readAheadMinGrow)
+	var off int64
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, int64(2*readAheadMinGrow), d.update(off, 4096))
+		off += 4096
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/pkg/errors"
 	"github.com/pingme998/rclone/fs"
 	"github.com/pingme998/rclone/fs/log"
+	"github.com/pingme998/rclone/lib/ranges"
 	"github.com/pingme998/rclone/vfs/vfscache"
 )
 
@@ -18,10 +19,12 @@ import (
 // transferred to the remote.
 type RWFileHandle struct {
 	// read only variables
-	file  *File
-	d     *Dir
-	flags int            // open flags
-	item  *vfscache.Item // cached file item
+	file      *File
+	d         *Dir
+	flags     int                // open flags
+	item      *vfscache.Item     // cached file item
+	readAhead *readAheadDetector // set if using adaptive read ahead
+	stats     *handleStats       // IO done through the handle
 
 	// read write variables protected by mutex
 	mu          sync.Mutex
@@ -49,6 +52,9 @@ func newRWFileHandle(d *Dir, f *File, flags int) (fh *RWFileHandle, err error) {
 		flags: flags,
 		item:  item,
 	}
+	if opt := &d.vfs.Opt; opt.ReadAheadAdaptive {
+		fh.readAhead = newReadAheadDetector(int64(opt.ReadAhead), int64(opt.ReadAheadMax))
+	}
 
 	// truncate immediately if O_TRUNC is set or O_CREATE is set and file doesn't exist
 	if !fh.readOnly() && (fh.flags&os.O_TRUNC != 0 || (fh.flags&os.O_CREATE != 0 && !exists)) {
@@ -63,6 +69,7 @@ func newRWFileHandle(d *Dir, f *File, flags int) (fh *RWFileHandle, err error) {
 	if !fh.readOnly() {
 		fh.file.addWriter(fh)
 	}
+	fh.stats = newHandleStats(f, "rw", flags)
 
 	return fh, nil
 }
@@ -154,6 +161,7 @@ func (fh *RWFileHandle) close() (err error) {
 	}
 
 	fh.closed = true
+	fh.stats.done()
 	fh.updateSize()
 	if fh.opened {
 		err = fh.item.Close(fh.file.setObject)
@@ -253,22 +261,36 @@ func (fh *RWFileHandle) _readAt(b []byte, off int64, release bool) (n int, err e
 	if fh.writeOnly() {
 		return n, EBADF
 	}
-	if off >= fh._size() {
+	size := fh._size()
+	if off >= size {
 		return n, io.EOF
 	}
 	if err = fh.openPending(); err != nil {
 		return n, err
 	}
+	readAhead := int64(-1)
+	if fh.readAhead != nil {
+		readAhead = fh.readAhead.update(off, int64(len(b)))
+	}
+	// count whether the read can be satisfied from the cache
+	r := ranges.Range{Pos: off, Size: int64(len(b))}
+	r.Clip(size)
+	fh.stats.cacheRead(fh.item.HasRange(r))
 	if release {
 		// Do the writing with fh.mu unlocked
 		fh.mu.Unlock()
 	}
 
-	n, err = fh.item.ReadAt(b, off)
+	if readAhead >= 0 {
+		n, err = fh.item.ReadAtWithReadAhead(b, off, readAhead)
+	} else {
+		n, err = fh.item.ReadAt(b, off)
+	}
 
 	if release {
 		fh.mu.Lock()
 	}
+	fh.stats.read(off, n)
 	return n, err
 }
 
@@ -344,6 +366,7 @@ func (fh *RWFileHandle) _writeAt(b []byte, off int64, release bool) (n int, err
 	if release {
 		fh.mu.Lock()
 	}
+	fh.stats.write(off, n)
 	if err != nil {
 		return n, err
 	}
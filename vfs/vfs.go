@@ -27,16 +27,19 @@ import (
 	"io/ioutil"
 	"os"
 	"path"
+	"reflect"
 	"sort"
 	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 
+	"github.com/pkg/errors"
 	"github.com/pingme998/rclone/fs"
 	"github.com/pingme998/rclone/fs/cache"
 	"github.com/pingme998/rclone/fs/log"
 	"github.com/pingme998/rclone/fs/walk"
+	"github.com/pingme998/rclone/lib/atexit"
 	"github.com/pingme998/rclone/vfs/vfscache"
 	"github.com/pingme998/rclone/vfs/vfscommon"
 )
@@ -168,6 +171,8 @@ type VFS struct {
 	usage       *fs.Usage
 	pollChan    chan time.Duration
 	inUse       int32 // count of number of opens accessed with atomic
+	handlesMu   sync.Mutex
+	handles     map[*handleStats]struct{} // stats of the open file handles
 }
 
 // Keep track of active VFS keyed on fs.ConfigString(f)
@@ -176,6 +181,9 @@ var (
 	active   = map[string][]*VFS{}
 )
 
+// Flush the active VFS on exit if --shutdown-grace-period is set
+var registerFlushOnce sync.Once
+
 // New creates a new VFS and root directory.  If opt is nil, then
 // DefaultOpt will be used
 func New(f fs.Fs, opt *vfscommon.Options) *VFS {
@@ -212,6 +220,11 @@ func New(f fs.Fs, opt *vfscommon.Options) *VFS {
 	}
 	// Put the VFS into the active cache
 	active[configName] = append(active[configName], vfs)
+	registerFlushOnce.Do(func() {
+		if fs.GetConfig(context.TODO()).ShutdownGracePeriod > 0 {
+			atexit.RegisterGraceful(flushActive)
+		}
+	})
 
 	// Create root directory
 	vfs.root = newDir(vfs, f, nil, fsDir)
@@ -276,7 +289,63 @@ func (vfs *VFS) SetCacheMode(cacheMode vfscommon.CacheMode) {
 		vfs.Opt.CacheMode = cacheMode
 		vfs.cancelCache = cancel
 		vfs.cache = cache
+	} else {
+		vfs.Opt.CacheMode = vfscommon.CacheModeOff
+	}
+}
+
+// SetOptions changes the options of a running VFS.
+//
+// Only DirCacheTime, CacheMode, CacheMaxAge, CacheMaxSize and
+// WriteBackBwLimit may be changed. The cache mode may only be changed
+// when no files are open and no files are waiting to be uploaded.
+func (vfs *VFS) SetOptions(opt vfscommon.Options) error {
+	// Check nothing else has changed
+	check := opt
+	check.DirCacheTime = vfs.Opt.DirCacheTime
+	check.CacheMode = vfs.Opt.CacheMode
+	check.CacheMaxAge = vfs.Opt.CacheMaxAge
+	check.CacheMaxSize = vfs.Opt.CacheMaxSize
+	check.WriteBackBwLimit = vfs.Opt.WriteBackBwLimit
+	if check != vfs.Opt {
+		old, changed := reflect.ValueOf(vfs.Opt), reflect.ValueOf(check)
+		for i := 0; i < old.NumField(); i++ {
+			if old.Field(i).Interface() != changed.Field(i).Interface() {
+				return errors.Errorf("can't change %s while running", old.Type().Field(i).Name)
+			}
+		}
+	}
+	if opt.CacheMode != vfs.Opt.CacheMode {
+		writers := vfs.root.countActiveWriters()
+		inUse, uploads := 0, 0
+		if vfs.cache != nil {
+			inUse = vfs.cache.TotalInUse()
+			stats := vfs.cache.Stats()
+			uploads = stats.UploadsInProgress + stats.UploadsQueued
+		}
+		if writers != 0 || inUse != 0 || uploads != 0 {
+			return errors.Errorf("can't change CacheMode with %d writers active, %d cache items in use and %d uploads not finished", writers, inUse, uploads)
+		}
+	}
+
+	vfs.Opt.DirCacheTime = opt.DirCacheTime
+	if vfs.cache != nil {
+		// The cache reads these so set them with its lock held
+		vfs.cache.SetLimits(opt.CacheMaxAge, opt.CacheMaxSize)
+		vfs.cache.SetWriteBackBwLimit(opt.WriteBackBwLimit)
+	} else {
+		vfs.Opt.CacheMaxAge = opt.CacheMaxAge
+		vfs.Opt.CacheMaxSize = opt.CacheMaxSize
+		vfs.Opt.WriteBackBwLimit = opt.WriteBackBwLimit
+	}
+	if opt.CacheMode != vfs.Opt.CacheMode {
+		fs.Infof(vfs.f, "Changing cache mode from %v to %v", vfs.Opt.CacheMode, opt.CacheMode)
+		vfs.SetCacheMode(opt.CacheMode)
+		if vfs.Opt.CacheMode != opt.CacheMode {
+			return errors.Errorf("failed to change cache mode to %v", opt.CacheMode)
+		}
 	}
+	return nil
 }
 
 // shutdown the cache if it was running
@@ -287,6 +356,13 @@ func (vfs *VFS) shutdownCache() {
 	}
 }
 
+// Reuse marks the VFS as in use once more so the next Shutdown
+// doesn't shut it down. This is used when remounting a VFS to keep
+// its cache across the unmount.
+func (vfs *VFS) Reuse() {
+	atomic.AddInt32(&vfs.inUse, 1)
+}
+
 // Shutdown stops any background go-routines and removes the VFS from
 // the active ache.
 func (vfs *VFS) Shutdown() {
@@ -358,6 +434,55 @@ func (vfs *VFS) WaitForWriters(timeout time.Duration) {
 	}
 }
 
+// Flush starts uploading the files in the cache awaiting writeback
+// straight away and waits until they and any files open for writing
+// have been uploaded or ctx is cancelled.
+func (vfs *VFS) Flush(ctx context.Context) error {
+	if vfs.cache != nil {
+		vfs.cache.Flush()
+	}
+	tick := time.NewTicker(100 * time.Millisecond)
+	defer tick.Stop()
+	for {
+		writers := vfs.root.countActiveWriters()
+		uploads := 0
+		if vfs.cache != nil {
+			stats := vfs.cache.Stats()
+			uploads = stats.UploadsInProgress + stats.UploadsQueued
+		}
+		if writers == 0 && uploads == 0 {
+			return nil
+		}
+		select {
+		case <-tick.C:
+		case <-ctx.Done():
+			return errors.Wrapf(ctx.Err(), "%d writers active and %d uploads not finished", writers, uploads)
+		}
+	}
+}
+
+// flushActive flushes all the active VFS in parallel
+func flushActive(ctx context.Context) {
+	var vfses []*VFS
+	activeMu.Lock()
+	for _, activeVFSes := range active {
+		vfses = append(vfses, activeVFSes...)
+	}
+	activeMu.Unlock()
+	var wg sync.WaitGroup
+	for _, vfs := range vfses {
+		wg.Add(1)
+		go func(vfs *VFS) {
+			defer wg.Done()
+			fs.Infof(vfs.f, "vfs: flushing before exit")
+			if err := vfs.Flush(ctx); err != nil {
+				fs.Errorf(vfs.f, "vfs: failed to flush before exit: %v", err)
+			}
+		}(vfs)
+	}
+	wg.Wait()
+}
+
 // Root returns the root node
 func (vfs *VFS) Root() (*Dir, error) {
 	// fs.Debugf(vfs.f, "Root()")
@@ -446,7 +571,10 @@ func decodeOpenFlags(flags int) string {
 	if flags&os.O_TRUNC != 0 {
 		out = append(out, "O_TRUNC")
 	}
-	flags &^= accessModeMask | os.O_APPEND | os.O_CREATE | os.O_EXCL | os.O_SYNC | os.O_TRUNC
+	if flags&oDirect != 0 {
+		out = append(out, "O_DIRECT")
+	}
+	flags &^= accessModeMask | os.O_APPEND | os.O_CREATE | os.O_EXCL | os.O_SYNC | os.O_TRUNC | oDirect
 	if flags != 0 {
 		out = append(out, fmt.Sprintf("0x%X", flags))
 	}
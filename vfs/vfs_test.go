@@ -419,3 +419,80 @@ func TestFillInMissingSizes(t *testing.T) {
 		})
 	}
 }
+
+func TestVFSFlush(t *testing.T) {
+	opt := vfscommon.DefaultOpt
+	opt.CacheMode = vfscommon.CacheModeWrites
+	opt.WriteBack = time.Hour
+	r, vfs, cleanup := newTestVFSOpt(t, &opt)
+	defer cleanup()
+
+	fh, err := vfs.OpenFile("file1", os.O_WRONLY|os.O_CREATE, 0777)
+	require.NoError(t, err)
+	_, err = fh.Write([]byte("hello world"))
+	require.NoError(t, err)
+	require.NoError(t, fh.Close())
+
+	// Not uploaded yet because of the long --vfs-write-back
+	fstest.CheckListingWithPrecision(t, r.Fremote, []fstest.Item{}, []string{}, fs.ModTimeNotSupported)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	require.NoError(t, vfs.Flush(ctx))
+
+	file1 := fstest.NewItem("file1", "hello world", t1)
+	fstest.CheckListingWithPrecision(t, r.Fremote, []fstest.Item{file1}, []string{}, fs.ModTimeNotSupported)
+
+	// A cancelled context returns an error if a file is still open
+	fh, err = vfs.OpenFile("file2", os.O_WRONLY|os.O_CREATE, 0777)
+	require.NoError(t, err)
+	cancel()
+	assert.Error(t, vfs.Flush(ctx))
+	require.NoError(t, fh.Close())
+}
+
+func TestVFSSetOptions(t *testing.T) {
+	opt := vfscommon.DefaultOpt
+	opt.WriteBack = time.Hour
+	_, vfs, cleanup := newTestVFSOpt(t, &opt)
+	defer cleanup()
+
+	// Options which can't be changed
+	newOpt := vfs.Opt
+	newOpt.ReadOnly = true
+	err := vfs.SetOptions(newOpt)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "ReadOnly")
+	assert.False(t, vfs.Opt.ReadOnly)
+
+	// Options which can be changed
+	newOpt = vfs.Opt
+	newOpt.DirCacheTime = time.Hour
+	newOpt.CacheMode = vfscommon.CacheModeWrites
+	newOpt.CacheMaxAge = time.Minute
+	newOpt.CacheMaxSize = 1024
+	newOpt.WriteBackBwLimit = 2048
+	require.NoError(t, vfs.SetOptions(newOpt))
+	assert.Equal(t, newOpt, vfs.Opt)
+	require.NotNil(t, vfs.cache)
+
+	// Can't change the cache mode while a file is waiting to be uploaded
+	fh, err := vfs.OpenFile("file1", os.O_WRONLY|os.O_CREATE, 0777)
+	require.NoError(t, err)
+	_, err = fh.Write([]byte("hello world"))
+	require.NoError(t, err)
+	require.NoError(t, fh.Close())
+	newOpt.CacheMode = vfscommon.CacheModeOff
+	err = vfs.SetOptions(newOpt)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "CacheMode")
+	assert.Equal(t, vfscommon.CacheModeWrites, vfs.Opt.CacheMode)
+
+	// But can when it has been uploaded
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	require.NoError(t, vfs.Flush(ctx))
+	require.NoError(t, vfs.SetOptions(newOpt))
+	assert.Equal(t, vfscommon.CacheModeOff, vfs.Opt.CacheMode)
+	assert.Nil(t, vfs.cache)
+}
@@ -0,0 +1,71 @@
+package vfscache
+
+import (
+	"context"
+	"io"
+
+	"github.com/pingme998/rclone/fs"
+	"golang.org/x/time/rate"
+)
+
+// newWriteBackLimiter returns a rate limiter for --vfs-write-back-bwlimit.
+// The limiter is always made so the limit can be changed while running.
+func newWriteBackLimiter(bwlimit fs.SizeSuffix) *rate.Limiter {
+	limiter := rate.NewLimiter(rate.Inf, 0)
+	setWriteBackLimit(limiter, bwlimit)
+	return limiter
+}
+
+// setWriteBackLimit sets the limiter to bwlimit bytes/s or no limit if
+// bwlimit <= 0
+func setWriteBackLimit(limiter *rate.Limiter, bwlimit fs.SizeSuffix) {
+	if bwlimit <= 0 {
+		limiter.SetLimit(rate.Inf)
+		return
+	}
+	limiter.SetBurst(int(bwlimit))
+	limiter.SetLimit(rate.Limit(bwlimit))
+}
+
+// bwLimitObject wraps a cache object so reading it for upload is
+// limited by the writeback rate limiter
+type bwLimitObject struct {
+	fs.Object
+	limiter *rate.Limiter
+}
+
+// Open the object returning a rate limited reader
+func (o *bwLimitObject) Open(ctx context.Context, options ...fs.OpenOption) (io.ReadCloser, error) {
+	in, err := o.Object.Open(ctx, options...)
+	if err != nil {
+		return nil, err
+	}
+	return &bwLimitReader{ctx: ctx, in: in, limiter: o.limiter}, nil
+}
+
+// bwLimitReader limits the speed of reads from in
+type bwLimitReader struct {
+	ctx     context.Context
+	in      io.ReadCloser
+	limiter *rate.Limiter
+}
+
+// Read bytes from in waiting for the rate limiter after the read
+func (r *bwLimitReader) Read(p []byte) (n int, err error) {
+	// Can't wait for more than burst bytes at once
+	if burst := r.limiter.Burst(); burst > 0 && len(p) > burst {
+		p = p[:burst]
+	}
+	n, err = r.in.Read(p)
+	if n > 0 {
+		if waitErr := r.limiter.WaitN(r.ctx, n); waitErr != nil && err == nil {
+			err = waitErr
+		}
+	}
+	return n, err
+}
+
+// Close the underlying reader
+func (r *bwLimitReader) Close() error {
+	return r.in.Close()
+}
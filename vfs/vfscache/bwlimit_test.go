@@ -0,0 +1,67 @@
+package vfscache
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/time/rate"
+)
+
+func TestNewWriteBackLimiter(t *testing.T) {
+	assert.Equal(t, rate.Inf, newWriteBackLimiter(0).Limit())
+	assert.Equal(t, rate.Inf, newWriteBackLimiter(-1).Limit())
+	limiter := newWriteBackLimiter(1024)
+	require.NotNil(t, limiter)
+	assert.Equal(t, 1024, limiter.Burst())
+	assert.Equal(t, rate.Limit(1024), limiter.Limit())
+
+	// change the limit while running
+	setWriteBackLimit(limiter, 2048)
+	assert.Equal(t, 2048, limiter.Burst())
+	assert.Equal(t, rate.Limit(2048), limiter.Limit())
+	setWriteBackLimit(limiter, 0)
+	assert.Equal(t, rate.Inf, limiter.Limit())
+}
+
+func TestBwLimitReader(t *testing.T) {
+	const bwlimit = 64 * 1024
+	data := bytes.Repeat([]byte("x"), 2*bwlimit)
+	r := &bwLimitReader{
+		ctx:     context.Background(),
+		in:      ioutil.NopCloser(bytes.NewReader(data)),
+		limiter: newWriteBackLimiter(bwlimit),
+	}
+	start := time.Now()
+	got, err := ioutil.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, data, got)
+	// the first bwlimit bytes are free from the burst
+	assert.True(t, time.Since(start) > 500*time.Millisecond)
+	require.NoError(t, r.Close())
+
+	// check the context cancels the wait
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	r = &bwLimitReader{
+		ctx:     ctx,
+		in:      ioutil.NopCloser(bytes.NewReader(data)),
+		limiter: newWriteBackLimiter(bwlimit),
+	}
+	_, err = ioutil.ReadAll(r)
+	assert.Error(t, err)
+
+	// check an unlimited limiter reads everything at once
+	r = &bwLimitReader{
+		ctx:     context.Background(),
+		in:      ioutil.NopCloser(bytes.NewReader(data)),
+		limiter: newWriteBackLimiter(0),
+	}
+	got, err = ioutil.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, data, got)
+}
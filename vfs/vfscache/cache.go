@@ -21,9 +21,11 @@ import (
 	"github.com/pingme998/rclone/fs/fserrors"
 	"github.com/pingme998/rclone/fs/hash"
 	"github.com/pingme998/rclone/fs/operations"
+	"github.com/pingme998/rclone/lib/clock"
 	"github.com/pingme998/rclone/lib/file"
 	"github.com/pingme998/rclone/vfs/vfscache/writeback"
 	"github.com/pingme998/rclone/vfs/vfscommon"
+	"golang.org/x/time/rate"
 )
 
 // NB as Cache and Item are tightly linked it is necessary to have a
@@ -44,10 +46,15 @@ type Cache struct {
 	opt        *vfscommon.Options   // vfs Options
 	root       string               // root of the cache directory
 	metaRoot   string               // root of the cache metadata directory
+	corruptDir string               // root of the directory corrupted cache files are moved to
 	hashType   hash.Type            // hash to use locally and remotely
 	hashOption *fs.HashesOption     // corresponding OpenOption
 	writeback  *writeback.WriteBack // holds Items for writeback
+	wbLimiter  *rate.Limiter        // limits the writeback bandwidth - rate.Inf for no limit
 	avFn       AddVirtualFn         // if set, can be called to add dir entries
+	owner      string               // identifies this cache in the Item metadata
+	metaLock   *metaLock            // lock for the metadata shared with other processes
+	clock      clock.Clock          // source of the time
 
 	mu            sync.Mutex       // protects the following variables
 	cond          *sync.Cond       // cond lock for synchronous cache cleaning
@@ -58,6 +65,7 @@ type Cache struct {
 	cleanerKicked bool             // some thread kicked the cleaner upon out of space
 	kickerMu      sync.Mutex       // mutex for cleanerKicked
 	kick          chan struct{}    // channel for kicking clear to start
+	reclean       chan struct{}    // channel for running the cleaner with new limits
 
 }
 
@@ -95,6 +103,7 @@ func New(ctx context.Context, fremote fs.Fs, opt *vfscommon.Options, avFn AddVir
 	fs.Debugf(nil, "vfs cache: root is %q", root)
 	metaRoot := file.UNCPath(filepath.Join(cacheDir, "vfsMeta", fName, fRoot))
 	fs.Debugf(nil, "vfs cache: metadata root is %q", root)
+	corruptDir := file.UNCPath(filepath.Join(cacheDir, "vfsCorrupt", fName, fRoot))
 
 	fcache, err := fscache.Get(ctx, root)
 	if err != nil {
@@ -114,12 +123,16 @@ func New(ctx context.Context, fremote fs.Fs, opt *vfscommon.Options, avFn AddVir
 		opt:        opt,
 		root:       root,
 		metaRoot:   metaRoot,
+		corruptDir: corruptDir,
 		item:       make(map[string]*Item),
 		errItems:   make(map[string]error),
 		hashType:   hashType,
 		hashOption: hashOption,
 		writeback:  writeback.New(ctx, opt),
+		wbLimiter:  newWriteBackLimiter(opt.WriteBackBwLimit),
 		avFn:       avFn,
+		owner:      newOwner(),
+		clock:      clock.Get(ctx),
 	}
 
 	// Make sure cache directories exist
@@ -128,6 +141,9 @@ func New(ctx context.Context, fremote fs.Fs, opt *vfscommon.Options, avFn AddVir
 		return nil, errors.Wrap(err, "failed to make cache directory")
 	}
 
+	// Open the lock file shared with other processes using cacheDir
+	c.metaLock = newMetaLock(file.UNCPath(filepath.Join(cacheDir, metaLockName)))
+
 	// load in the cache and metadata off disk
 	err = c.reload(ctx)
 	if err != nil {
@@ -139,6 +155,7 @@ func New(ctx context.Context, fremote fs.Fs, opt *vfscommon.Options, avFn AddVir
 
 	// Create a channel for cleaner to be kicked upon out of space con
 	c.kick = make(chan struct{}, 1)
+	c.reclean = make(chan struct{}, 1)
 	c.cond = sync.NewCond(&c.mu)
 
 	go c.cleaner(ctx)
@@ -169,6 +186,12 @@ func (c *Cache) toOSPathMeta(name string) string {
 	return filepath.Join(c.metaRoot, filepath.FromSlash(name))
 }
 
+// toOSPathCorrupt turns a remote relative name into an OS path in the
+// directory corrupted cache files are moved to
+func (c *Cache) toOSPathCorrupt(name string) string {
+	return filepath.Join(c.corruptDir, filepath.FromSlash(name))
+}
+
 // mkdir makes the directory for name in the cache and returns an os
 // path for the file
 func (c *Cache) mkdir(name string) (string, error) {
@@ -671,23 +694,32 @@ func (c *Cache) clean(kicked bool) {
 
 	// loop cleaning the cache until we reach below cache quota
 	for {
+		maxAge, maxSize := c.limits()
+
 		// Remove any files that are over age
-		c.purgeOld(c.opt.CacheMaxAge)
+		c.purgeOld(maxAge)
 
-		if int64(c.opt.CacheMaxSize) <= 0 {
+		// Remove any parts of files which are over age
+		c.purgeOldChunks(maxAge)
+
+		if int64(maxSize) <= 0 {
 			break
 		}
 
+		// Remove the least recently used parts of files not in use
+		// until the cache size is below quota
+		c.purgeChunksOverQuota(int64(maxSize))
+
 		// Now remove files not in use until cache size is below quota starting from the
 		// oldest first
-		c.purgeOverQuota(int64(c.opt.CacheMaxSize))
+		c.purgeOverQuota(int64(maxSize))
 
 		// Remove cache files that are not dirty if we are still above the max cache size
-		c.purgeClean(int64(c.opt.CacheMaxSize))
+		c.purgeClean(int64(maxSize))
 		c.retryFailedResets()
 
 		used := c.updateUsed()
-		if used <= int64(c.opt.CacheMaxSize) && len(c.errItems) == 0 {
+		if used <= int64(maxSize) && len(c.errItems) == 0 {
 			break
 		}
 	}
@@ -720,6 +752,36 @@ func (c *Cache) clean(kicked bool) {
 	}
 }
 
+// limits returns the max age and size of the cache
+func (c *Cache) limits() (maxAge time.Duration, maxSize fs.SizeSuffix) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.opt.CacheMaxAge, c.opt.CacheMaxSize
+}
+
+// SetLimits changes the max age and size of the cache while it is
+// running and starts the cleaner to apply them.
+func (c *Cache) SetLimits(maxAge time.Duration, maxSize fs.SizeSuffix) {
+	c.mu.Lock()
+	c.opt.CacheMaxAge, c.opt.CacheMaxSize = maxAge, maxSize
+	c.mu.Unlock()
+	select {
+	case c.reclean <- struct{}{}:
+	default:
+		// the cleaner will run anyway
+	}
+}
+
+// SetWriteBackBwLimit changes the bandwidth limit for writebacks
+// while the cache is running. Writebacks which started without a
+// limit aren't limited.
+func (c *Cache) SetWriteBackBwLimit(bwlimit fs.SizeSuffix) {
+	c.mu.Lock()
+	c.opt.WriteBackBwLimit = bwlimit
+	c.mu.Unlock()
+	setWriteBackLimit(c.wbLimiter, bwlimit)
+}
+
 // cleaner calls clean at regular intervals and upon being kicked for out-of-space condition
 //
 // doesn't return until context is cancelled
@@ -731,13 +793,15 @@ func (c *Cache) cleaner(ctx context.Context) {
 	// Start cleaning the cache immediately
 	c.clean(false)
 	// Then every interval specified
-	timer := time.NewTicker(c.opt.CachePollInterval)
+	timer := c.clock.NewTicker(c.opt.CachePollInterval)
 	defer timer.Stop()
 	for {
 		select {
 		case <-c.kick: // a thread encountering ENOSPC kicked me
 			c.clean(true) // kicked is true
-		case <-timer.C:
+		case <-c.reclean: // the limits were changed with SetLimits
+			c.clean(false)
+		case <-timer.Chan():
 			c.clean(false) // timer driven cache poll, kicked is false
 		case <-ctx.Done():
 			fs.Debugf(nil, "vfs cache: cleaner exiting")
@@ -758,6 +822,41 @@ func (c *Cache) TotalInUse() (n int) {
 	return n
 }
 
+// Stats describes the state of the cache
+type Stats struct {
+	Files             int   // number of files in the cache
+	InUse             int   // number of files in use
+	Errored           int   // number of files which failed to upload
+	Bytes             int64 // total size of the files in the cache
+	OutOfSpace        bool  // set if the disk the cache is on is full
+	UploadsInProgress int   // number of files being uploaded
+	UploadsQueued     int   // number of files waiting to be uploaded
+}
+
+// Flush starts uploading all the files awaiting writeback straight
+// away rather than waiting for --vfs-write-back. Use Stats to see
+// when the uploads have finished.
+func (c *Cache) Flush() {
+	c.writeback.Flush()
+}
+
+// Stats returns the current state of the cache
+func (c *Cache) Stats() (stats Stats) {
+	c.mu.Lock()
+	stats.Files = len(c.item)
+	stats.Errored = len(c.errItems)
+	stats.Bytes = c.used
+	stats.OutOfSpace = c.outOfSpace
+	for _, item := range c.item {
+		if item.inUse() {
+			stats.InUse++
+		}
+	}
+	c.mu.Unlock()
+	stats.UploadsInProgress, stats.UploadsQueued = c.writeback.Stats()
+	return stats
+}
+
 // Dump the cache into a string for debugging purposes
 func (c *Cache) Dump() string {
 	if c == nil {
@@ -10,10 +10,13 @@ import (
 	"time"
 
 	_ "github.com/pingme998/rclone/backend/local" // import the local backend
+	"github.com/pingme998/rclone/fs"
 	"github.com/pingme998/rclone/fstest"
+	"github.com/pingme998/rclone/lib/clock"
 	"github.com/pingme998/rclone/vfs/vfscommon"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/time/rate"
 )
 
 // TestMain drives the tests
@@ -82,9 +85,14 @@ func addVirtual(remote string, size int64, isDir bool) error {
 }
 
 func newTestCacheOpt(t *testing.T, opt vfscommon.Options) (r *fstest.Run, c *Cache, cleanup func()) {
+	return newTestCacheClock(t, opt, clock.Real)
+}
+
+// newTestCacheClock makes a test cache which tells the time with clk
+func newTestCacheClock(t *testing.T, opt vfscommon.Options, clk clock.Clock) (r *fstest.Run, c *Cache, cleanup func()) {
 	r = fstest.NewRun(t)
 
-	ctx, cancel := context.WithCancel(context.Background())
+	ctx, cancel := context.WithCancel(clock.WithClock(context.Background(), clk))
 
 	avInfos = nil
 	c, err := New(ctx, r.Fremote, &opt, addVirtual)
@@ -611,22 +619,27 @@ func TestCacheCleaner(t *testing.T) {
 	opt := vfscommon.DefaultOpt
 	opt.CachePollInterval = 10 * time.Millisecond
 	opt.CacheMaxAge = 20 * time.Millisecond
-	_, c, cleanup := newTestCacheOpt(t, opt)
+	m := clock.NewMock(time.Now())
+	_, c, cleanup := newTestCacheClock(t, opt, m)
 	defer cleanup()
 
-	time.Sleep(2 * opt.CachePollInterval)
+	// Wait for the cleaner to start ticking
+	m.WaitForWaiters(1)
 
 	potato := c.Item("potato")
 	potato2, found := c.get("potato")
 	assert.Equal(t, fmt.Sprintf("%p", potato), fmt.Sprintf("%p", potato2))
 	assert.True(t, found)
 
+	// The cleaner runs in the background so wait for it to
+	// remove the item once it is older than the max age
+	m.Advance(opt.CacheMaxAge + opt.CachePollInterval)
 	for i := 0; i < 100; i++ {
-		time.Sleep(10 * opt.CachePollInterval)
 		potato2, found = c.get("potato")
 		if !found {
 			break
 		}
+		time.Sleep(10 * time.Millisecond)
 	}
 
 	assert.NotEqual(t, fmt.Sprintf("%p", potato), fmt.Sprintf("%p", potato2))
@@ -701,3 +714,34 @@ func TestCacheDump(t *testing.T) {
 	out = c.Dump()
 	assert.Equal(t, "Cache{\n}\n", out)
 }
+
+func TestCacheSetLimits(t *testing.T) {
+	opt := vfscommon.DefaultOpt
+	opt.CachePollInterval = time.Hour
+	_, c, cleanup := newTestCacheOpt(t, opt)
+	defer cleanup()
+
+	c.Item("potato")
+	_, found := c.get("potato")
+	require.True(t, found)
+
+	// Setting a short max age should run the cleaner straight away
+	c.SetLimits(time.Nanosecond, 1024)
+	maxAge, maxSize := c.limits()
+	assert.Equal(t, time.Nanosecond, maxAge)
+	assert.Equal(t, fs.SizeSuffix(1024), maxSize)
+	for i := 0; i < 100; i++ {
+		if _, found = c.get("potato"); !found {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.False(t, found)
+
+	// Change the writeback limit
+	assert.Equal(t, rate.Inf, c.wbLimiter.Limit())
+	c.SetWriteBackBwLimit(4096)
+	assert.Equal(t, rate.Limit(4096), c.wbLimiter.Limit())
+	c.SetWriteBackBwLimit(0)
+	assert.Equal(t, rate.Inf, c.wbLimiter.Limit())
+}
@@ -0,0 +1,182 @@
+package vfscache
+
+import (
+	"os"
+	"sort"
+	"time"
+
+	"github.com/pingme998/rclone/fs"
+	"github.com/pingme998/rclone/lib/file"
+	"github.com/pingme998/rclone/lib/ranges"
+)
+
+// This implements partial eviction of cache files.
+//
+// If --vfs-cache-chunk-size is set then each Item keeps a map of the
+// last access time of each chunk of the file in its metadata. The
+// cleaner can then evict the cold chunks of files which aren't in use
+// by punching holes in the cache file rather than removing the whole
+// file.
+
+// chunksEnabled returns true if chunks of files can be evicted
+func (c *Cache) chunksEnabled() bool {
+	return c.opt.CacheChunkSize > 0 && file.PunchHoleImplemented
+}
+
+// _touchChunks marks the chunks covering r as accessed at t
+//
+// call with lock held
+func (item *Item) _touchChunks(r ranges.Range, t time.Time) {
+	chunkSize := int64(item.c.opt.CacheChunkSize)
+	if chunkSize <= 0 || r.IsEmpty() {
+		return
+	}
+	if item.info.ChunkSize != chunkSize || item.info.Chunks == nil {
+		// chunk size changed so the old access times are no use
+		item.info.ChunkSize = chunkSize
+		item.info.Chunks = make(map[int64]time.Time)
+	}
+	for i := r.Pos / chunkSize; i*chunkSize < r.End(); i++ {
+		item.info.Chunks[i] = t
+	}
+}
+
+// _chunkATime returns the last access time of chunk i
+//
+// Chunks with no access time recorded use the access time of the item.
+//
+// call with lock held
+func (item *Item) _chunkATime(i int64) time.Time {
+	if item.info.ChunkSize == int64(item.c.opt.CacheChunkSize) {
+		if t, ok := item.info.Chunks[i]; ok {
+			return t
+		}
+	}
+	return item.info.ATime
+}
+
+// cacheChunk is a chunk of an Item which has data in the cache
+type cacheChunk struct {
+	item  *Item
+	index int64
+	atime time.Time
+}
+
+// cachedChunks returns the chunks of the item which have data in the
+// cache and were last accessed before cutoff. If cutoff is zero then
+// all the chunks are returned.
+//
+// No chunks are returned for items which are in use or dirty.
+func (item *Item) cachedChunks(cutoff time.Time) (chunks []cacheChunk) {
+	item.mu.Lock()
+	defer item.mu.Unlock()
+	chunkSize := int64(item.c.opt.CacheChunkSize)
+	if chunkSize <= 0 || item.opens != 0 || item.info.Dirty {
+		return nil
+	}
+	last := int64(-1)
+	for _, r := range item.info.Rs {
+		for i := r.Pos / chunkSize; i*chunkSize < r.End(); i++ {
+			if i == last {
+				continue
+			}
+			last = i
+			atime := item._chunkATime(i)
+			if cutoff.IsZero() || atime.Before(cutoff) {
+				chunks = append(chunks, cacheChunk{item: item, index: i, atime: atime})
+			}
+		}
+	}
+	return chunks
+}
+
+// evictChunk removes the data of chunk i from the cache file
+// returning the space freed.
+//
+// Nothing is done if the item is in use or dirty.
+func (item *Item) evictChunk(i int64) (spaceFreed int64, err error) {
+	item.mu.Lock()
+	defer item.mu.Unlock()
+	chunkSize := int64(item.c.opt.CacheChunkSize)
+	if chunkSize <= 0 || item.opens != 0 || item.info.Dirty {
+		return 0, nil
+	}
+	r := ranges.Range{Pos: i * chunkSize, Size: chunkSize}
+	present := item.info.Rs.Intersection(r).Size()
+	if present > 0 {
+		fd, err := os.OpenFile(item.c.toOSPath(item.name), os.O_WRONLY, 0600)
+		if err != nil {
+			return 0, err
+		}
+		err = file.PunchHole(fd, r.Pos, r.Size)
+		closeErr := fd.Close()
+		if err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return 0, err
+		}
+		item.info.Rs.Remove(r)
+	}
+	delete(item.info.Chunks, i)
+	return present, item._save()
+}
+
+// evictChunks evicts chunks in order until stop returns true
+//
+// call with cache mutex locked
+func (c *Cache) evictChunks(chunks []cacheChunk, stop func() bool) {
+	for _, chunk := range chunks {
+		if stop() {
+			break
+		}
+		spaceFreed, err := chunk.item.evictChunk(chunk.index)
+		c.used -= spaceFreed
+		if err != nil {
+			fs.Errorf(chunk.item.name, "vfs cache: failed to evict chunk %d: %v", chunk.index, err)
+			return
+		}
+		if spaceFreed > 0 {
+			fs.Debugf(chunk.item.name, "vfs cache: evicted chunk %d last accessed %v, freed %d bytes", chunk.index, chunk.atime, spaceFreed)
+		}
+	}
+}
+
+// purgeOldChunks evicts the chunks of files not in use which haven't
+// been accessed for maxAge
+func (c *Cache) purgeOldChunks(maxAge time.Duration) {
+	if !c.chunksEnabled() {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	cutoff := c.clock.Now().Add(-maxAge)
+	for _, item := range c.item {
+		c.evictChunks(item.cachedChunks(cutoff), func() bool { return false })
+	}
+}
+
+// purgeChunksOverQuota evicts the least recently accessed chunks of
+// files not in use until the total space is reduced below quota
+func (c *Cache) purgeChunksOverQuota(quota int64) {
+	if !c.chunksEnabled() {
+		return
+	}
+	c.updateUsed()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if quota <= 0 || c.used < quota {
+		return
+	}
+
+	var chunks []cacheChunk
+	for _, item := range c.item {
+		chunks = append(chunks, item.cachedChunks(time.Time{})...)
+	}
+	sort.Slice(chunks, func(i, j int) bool {
+		return chunks[i].atime.Before(chunks[j].atime)
+	})
+	c.evictChunks(chunks, func() bool { return c.used < quota })
+}
@@ -0,0 +1,85 @@
+package vfscache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pingme998/rclone/fs"
+	"github.com/pingme998/rclone/lib/file"
+	"github.com/pingme998/rclone/lib/ranges"
+	"github.com/pingme998/rclone/vfs/vfscommon"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCachePurgeChunks(t *testing.T) {
+	if !file.PunchHoleImplemented {
+		t.Skip("PunchHole not implemented on this OS")
+	}
+	const chunkSize = 4096
+	opt := vfscommon.DefaultOpt
+	opt.CachePollInterval = 0
+	opt.WriteBack = 0
+	opt.CacheMode = vfscommon.CacheModeFull
+	opt.CacheChunkSize = chunkSize
+	r, c, cleanup := newTestCacheOpt(t, opt)
+	defer cleanup()
+
+	contents, obj, item := newFileLength(t, r, c, "potato", 4*chunkSize)
+
+	// Read the whole file into the cache
+	require.NoError(t, item.Open(obj))
+	buf := make([]byte, len(contents))
+	n, err := item.ReadAt(buf, 0)
+	require.NoError(t, err)
+	assert.Equal(t, len(contents), n)
+	assert.Equal(t, contents, string(buf))
+
+	// No chunks can be evicted while the item is open
+	assert.Nil(t, item.cachedChunks(time.Time{}))
+	require.NoError(t, item.Close(nil))
+
+	// Make chunk 2 the oldest, then 0, then 1 then 3
+	now := time.Now()
+	item.mu.Lock()
+	require.Equal(t, int64(chunkSize), item.info.ChunkSize)
+	item.info.Chunks[2] = now.Add(-4 * time.Hour)
+	item.info.Chunks[0] = now.Add(-3 * time.Hour)
+	item.info.Chunks[1] = now.Add(-2 * time.Minute)
+	item.info.Chunks[3] = now.Add(-1 * time.Minute)
+	item.mu.Unlock()
+	assert.Len(t, item.cachedChunks(time.Time{}), 4)
+
+	// Evicting chunks to get below quota removes 2 and 0
+	c.purgeChunksOverQuota(3 * chunkSize)
+	assert.Equal(t, int64(2*chunkSize), c.used)
+	item.mu.Lock()
+	assert.Equal(t, ranges.Ranges{{Pos: chunkSize, Size: chunkSize}, {Pos: 3 * chunkSize, Size: chunkSize}}, item.info.Rs)
+
+	// Check the old chunks are evicted by age
+	item.info.Chunks[1] = now.Add(-2 * time.Hour)
+	item.mu.Unlock()
+	c.purgeOldChunks(time.Hour)
+	assert.Equal(t, int64(chunkSize), c.used)
+	item.mu.Lock()
+	assert.Equal(t, ranges.Ranges{{Pos: 3 * chunkSize, Size: chunkSize}}, item.info.Rs)
+
+	// Check the metadata was saved
+	item.info = Info{}
+	item.mu.Unlock()
+	_, err = item.load()
+	require.NoError(t, err)
+	item.mu.Lock()
+	assert.Equal(t, ranges.Ranges{{Pos: 3 * chunkSize, Size: chunkSize}}, item.info.Rs)
+	assert.Len(t, item.info.Chunks, 1)
+	item.mu.Unlock()
+
+	// Check the evicted data is read again
+	require.NoError(t, item.Open(obj))
+	n, err = item.ReadAt(buf, 0)
+	require.NoError(t, err)
+	assert.Equal(t, len(contents), n)
+	assert.Equal(t, contents, string(buf))
+	require.NoError(t, item.Close(nil))
+	assert.Equal(t, fs.SizeSuffix(4*chunkSize), fs.SizeSuffix(item.getDiskSize()))
+}
@@ -75,8 +75,9 @@ type Downloaders struct {
 // waiter is a range we are waiting for and a channel to signal when
 // the range is found
 type waiter struct {
-	r       ranges.Range
-	errChan chan<- error
+	r         ranges.Range
+	readAhead int64 // bytes to read ahead of r
+	errChan   chan<- error
 }
 
 // downloader represents a running download for part of a file.
@@ -247,17 +248,28 @@ func (dls *Downloaders) Close(inErr error) (err error) {
 // Download the range passed in returning when it has been downloaded
 // with an error from the downloading go routine.
 func (dls *Downloaders) Download(r ranges.Range) (err error) {
-	// defer log.Trace(dls.src, "r=%+v", r)("err=%v", &err)
+	return dls.DownloadWithReadAhead(r, int64(dls.opt.ReadAhead))
+}
+
+// DownloadWithReadAhead downloads the range passed in returning when
+// it has been downloaded with an error from the downloading go
+// routine.
+//
+// Any downloader started will read readAhead bytes beyond the end of
+// r, overriding --vfs-read-ahead.
+func (dls *Downloaders) DownloadWithReadAhead(r ranges.Range, readAhead int64) (err error) {
+	// defer log.Trace(dls.src, "r=%+v, readAhead=%d", r, readAhead)("err=%v", &err)
 
 	dls.mu.Lock()
 
 	errChan := make(chan error)
 	waiter := waiter{
-		r:       r,
-		errChan: errChan,
+		r:         r,
+		readAhead: readAhead,
+		errChan:   errChan,
 	}
 
-	err = dls._ensureDownloader(r)
+	err = dls._ensureDownloader(r, readAhead)
 	if err != nil {
 		dls.mu.Unlock()
 		return err
@@ -281,16 +293,18 @@ func (dls *Downloaders) _closeWaiters(err error) {
 // ensure a downloader is running for the range if required.  If one isn't found
 // then it starts it.
 //
+// The range will be extended by readAhead bytes if it is positive.
+//
 // call with lock held
-func (dls *Downloaders) _ensureDownloader(r ranges.Range) (err error) {
-	// defer log.Trace(dls.src, "r=%v", r)("err=%v", &err)
+func (dls *Downloaders) _ensureDownloader(r ranges.Range, readAhead int64) (err error) {
+	// defer log.Trace(dls.src, "r=%v, readAhead=%d", r, readAhead)("err=%v", &err)
 
 	// The window includes potentially unread data in the buffer
 	window := int64(fs.GetConfig(context.TODO()).BufferSize)
 
 	// Increase the read range by the read ahead if set
-	if dls.opt.ReadAhead > 0 {
-		r.Size += int64(dls.opt.ReadAhead)
+	if readAhead > 0 {
+		r.Size += readAhead
 	}
 
 	// We may be reopening a downloader after a failure here or
@@ -371,9 +385,15 @@ func (dls *Downloaders) _ensureDownloader(r ranges.Range) (err error) {
 //
 // It does not wait for the range to be downloaded
 func (dls *Downloaders) EnsureDownloader(r ranges.Range) (err error) {
+	return dls.EnsureDownloaderWithReadAhead(r, int64(dls.opt.ReadAhead))
+}
+
+// EnsureDownloaderWithReadAhead is like EnsureDownloader but any
+// downloader started will read readAhead bytes beyond the end of r.
+func (dls *Downloaders) EnsureDownloaderWithReadAhead(r ranges.Range, readAhead int64) (err error) {
 	dls.mu.Lock()
 	defer dls.mu.Unlock()
-	return dls._ensureDownloader(r)
+	return dls._ensureDownloader(r, readAhead)
 }
 
 // _dispatchWaiters() sends any waiters which have completed back to
@@ -413,7 +433,7 @@ func (dls *Downloaders) kickWaiters() (err error) {
 	// However the number of waiters and the number of downloaders
 	// are both expected to be small.
 	for _, waiter := range dls.waiters {
-		err = dls._ensureDownloader(waiter.r)
+		err = dls._ensureDownloader(waiter.r, waiter.readAhead)
 		if err != nil {
 			// Failures here will be retried by background kicker
 			fs.Errorf(dls.src, "vfs cache: restart download failed: %v", err)
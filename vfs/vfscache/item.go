@@ -6,17 +6,20 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
 	"sync"
 	"time"
 
 	"github.com/pkg/errors"
 	"github.com/pingme998/rclone/fs"
 	"github.com/pingme998/rclone/fs/fserrors"
+	"github.com/pingme998/rclone/fs/hash"
 	"github.com/pingme998/rclone/fs/operations"
 	"github.com/pingme998/rclone/lib/file"
 	"github.com/pingme998/rclone/lib/ranges"
 	"github.com/pingme998/rclone/vfs/vfscache/downloaders"
 	"github.com/pingme998/rclone/vfs/vfscache/writeback"
+	"golang.org/x/time/rate"
 )
 
 // NB as Cache and Item are tightly linked it is necessary to have a
@@ -68,16 +71,21 @@ type Item struct {
 	writeBackID     writeback.Handle         // id of any writebacks in progress
 	pendingAccesses int                      // number of threads - cache reset not allowed if not zero
 	beingReset      bool                     // cache cleaner is resetting the cache file, access not allowed
+	verified        bool                     // set if the cache file matched the remote hash and hasn't been written since
+	metaModTime     time.Time                // modification time of the metadata when _syncRanges last read it
 }
 
 // Info is persisted to backing store
 type Info struct {
-	ModTime     time.Time     // last time file was modified
-	ATime       time.Time     // last time file was accessed
-	Size        int64         // size of the file
-	Rs          ranges.Ranges // which parts of the file are present
-	Fingerprint string        // fingerprint of remote object
-	Dirty       bool          // set if the backing file has been modified
+	ModTime     time.Time           // last time file was modified
+	ATime       time.Time           // last time file was accessed
+	Size        int64               // size of the file
+	Rs          ranges.Ranges       // which parts of the file are present
+	Fingerprint string              // fingerprint of remote object
+	Dirty       bool                // set if the backing file has been modified
+	ChunkSize   int64               // size of the chunks in Chunks
+	Chunks      map[int64]time.Time // last access time of each chunk, indexed by offset/ChunkSize
+	Owners      []string            // caches which have the file open or dirty
 }
 
 // Items are a slice of *Item ordered by ATime
@@ -94,11 +102,12 @@ const (
 	RemovedNotInUse                         // Item not used. Remove instead of reset
 	ResetFailed                             // Reset failed with an error
 	ResetComplete                           // Reset completed successfully
+	SkippedShared                           // Item in use by another process skipped
 )
 
 func (rr ResetResult) String() string {
 	return [...]string{"Dirty item skipped", "In-access item skipped", "Empty item skipped",
-		"Not-in-use item removed", "Item reset failed", "Item reset completed"}[rr]
+		"Not-in-use item removed", "Item reset failed", "Item reset completed", "Shared item skipped"}[rr]
 }
 
 func (v Items) Len() int      { return len(v) }
@@ -117,10 +126,10 @@ func (v Items) Less(i, j int) bool {
 	return iItem.info.ATime.Before(jItem.info.ATime)
 }
 
-// clean the item after its cache file has been deleted
-func (info *Info) clean() {
+// clean the item after its cache file has been deleted at time now
+func (info *Info) clean(now time.Time) {
 	*info = Info{}
-	info.ModTime = time.Now()
+	info.ModTime = now
 	info.ATime = info.ModTime
 }
 
@@ -129,7 +138,7 @@ type StoreFn func(fs.Object)
 
 // newItem returns an item for the cache
 func newItem(c *Cache, name string) (item *Item) {
-	now := time.Now()
+	now := c.clock.Now()
 	item = &Item{
 		c:    c,
 		name: name,
@@ -193,6 +202,15 @@ func (item *Item) getDiskSize() int64 {
 func (item *Item) load() (exists bool, err error) {
 	item.mu.Lock()
 	defer item.mu.Unlock()
+	item.c.metaLock.Lock()
+	defer item.c.metaLock.Unlock()
+	return item._readMeta(&item.info)
+}
+
+// _readMeta reads the metadata from the disk into info
+//
+// call with the lock and the metaLock held
+func (item *Item) _readMeta(info *Info) (exists bool, err error) {
 	osPathMeta := item.c.toOSPathMeta(item.name) // No locking in Cache
 	in, err := os.Open(osPathMeta)
 	if err != nil {
@@ -203,7 +221,7 @@ func (item *Item) load() (exists bool, err error) {
 	}
 	defer fs.CheckClose(in, &err)
 	decoder := json.NewDecoder(in)
-	err = decoder.Decode(&item.info)
+	err = decoder.Decode(info)
 	if err != nil {
 		return true, errors.Wrap(err, "vfs cache item: corrupt metadata")
 	}
@@ -212,8 +230,19 @@ func (item *Item) load() (exists bool, err error) {
 
 // save writes an item to the disk
 //
+// The Owners are merged with those in the metadata on disk which may
+// have been written by other processes.
+//
 // call with the lock held
 func (item *Item) _save() (err error) {
+	item.c.metaLock.Lock()
+	defer item.c.metaLock.Unlock()
+	var diskInfo Info
+	_, _ = item._readMeta(&diskInfo)
+	item.info.Owners = item._otherOwners(diskInfo.Owners)
+	if item.opens != 0 || item.info.Dirty {
+		item.info.Owners = append(item.info.Owners, item.c.owner)
+	}
 	osPathMeta := item.c.toOSPathMeta(item.name) // No locking in Cache
 	out, err := os.Create(osPathMeta)
 	if err != nil {
@@ -229,6 +258,92 @@ func (item *Item) _save() (err error) {
 	return nil
 }
 
+// _otherOwners returns the owners which aren't this cache and which
+// are still alive
+//
+// call with the lock held
+func (item *Item) _otherOwners(owners []string) (others []string) {
+	for _, owner := range owners {
+		if owner != item.c.owner && ownerAlive(owner) {
+			others = append(others, owner)
+		}
+	}
+	return others
+}
+
+// _inUseElsewhere returns true if the metadata on disk shows the item
+// is open or dirty in another process
+//
+// call with the lock held
+func (item *Item) _inUseElsewhere() bool {
+	item.c.metaLock.Lock()
+	defer item.c.metaLock.Unlock()
+	var diskInfo Info
+	exists, err := item._readMeta(&diskInfo)
+	if !exists || err != nil {
+		return false
+	}
+	return len(item._otherOwners(diskInfo.Owners)) != 0
+}
+
+// _reloadMeta re-reads the metadata from disk as it may have been
+// changed by another process while the item wasn't open
+//
+// call with the lock held
+func (item *Item) _reloadMeta() {
+	item.c.metaLock.Lock()
+	defer item.c.metaLock.Unlock()
+	var diskInfo Info
+	exists, err := item._readMeta(&diskInfo)
+	if !exists {
+		if item.info.Fingerprint != "" {
+			// removed by another process
+			item.info.clean(item.c.clock.Now())
+		}
+		return
+	}
+	if err != nil {
+		fs.Errorf(item.name, "vfs cache: failed to reload metadata: %v", err)
+		return
+	}
+	item.info = diskInfo
+}
+
+// _syncRanges merges in the ranges other processes have downloaded
+// into the cache file returning true if any were found
+//
+// call with the lock held
+func (item *Item) _syncRanges() (found bool) {
+	if item.info.Dirty || item.info.Fingerprint == "" {
+		return false
+	}
+	// Only read the metadata if it has changed since last time
+	fi, err := os.Stat(item.c.toOSPathMeta(item.name)) // No locking in Cache
+	if err != nil || fi.ModTime().Equal(item.metaModTime) {
+		return false
+	}
+	item.c.metaLock.Lock()
+	defer item.c.metaLock.Unlock()
+	var diskInfo Info
+	exists, err := item._readMeta(&diskInfo)
+	if !exists || err != nil {
+		return false
+	}
+	// Any change after the Stat will give a newer time so be
+	// read next time
+	item.metaModTime = fi.ModTime()
+	if diskInfo.Dirty || diskInfo.Fingerprint != item.info.Fingerprint {
+		return false
+	}
+	for _, r := range diskInfo.Rs {
+		if !item.info.Rs.Present(r) {
+			item.info.Rs.Insert(r)
+			found = true
+		}
+	}
+	return found
+}
+
 // truncate the item to the given size, creating it if necessary
 //
 // this does not mark the object as dirty
@@ -420,7 +535,7 @@ func (item *Item) Exists() bool {
 //
 // call with lock held
 func (item *Item) _dirty() {
-	item.info.ModTime = time.Now()
+	item.info.ModTime = item.c.clock.Now()
 	item.info.ATime = item.info.ModTime
 	if !item.modified {
 		item.modified = true
@@ -509,7 +624,12 @@ func (item *Item) open(o fs.Object) (err error) {
 	item.mu.Lock()
 	defer item.mu.Unlock()
 
-	item.info.ATime = time.Now()
+	// Pick up any changes made by other processes
+	if item.opens == 0 && !item.info.Dirty {
+		item._reloadMeta()
+	}
+
+	item.info.ATime = item.c.clock.Now()
 
 	osPath, err := item.c.mkdir(item.name) // No locking in Cache
 	if err != nil {
@@ -521,6 +641,15 @@ func (item *Item) open(o fs.Object) (err error) {
 		return errors.Wrap(err, "vfs cache item: check object failed")
 	}
 
+	// Check the data in a cold cache before serving it
+	if item.opens == 0 && item._needsVerify() && !item._verify() {
+		item._quarantine()
+		err = item._checkObject(o)
+		if err != nil {
+			return errors.Wrap(err, "vfs cache item: check object failed")
+		}
+	}
+
 	item.opens++
 	if item.opens != 1 {
 		return nil
@@ -579,6 +708,9 @@ func (item *Item) _store(ctx context.Context, storeFn StoreFn) (err error) {
 
 	// Object has disappeared if cacheObj == nil
 	if cacheObj != nil {
+		if item.c.wbLimiter.Limit() != rate.Inf {
+			cacheObj = &bwLimitObject{Object: cacheObj, limiter: item.c.wbLimiter}
+		}
 		o, name := item.o, item.name
 		item.mu.Unlock()
 		o, err := operations.Copy(ctx, item.c.fremote, o, name, cacheObj)
@@ -627,7 +759,7 @@ func (item *Item) Close(storeFn StoreFn) (err error) {
 	item.mu.Lock()
 	defer item.mu.Unlock()
 
-	item.info.ATime = time.Now()
+	item.info.ATime = item.c.clock.Now()
 	item.opens--
 
 	if item.opens < 0 {
@@ -646,7 +778,7 @@ func (item *Item) Close(storeFn StoreFn) (err error) {
 	// would require keeping the downloaders alive after the item
 	// has been closed
 	if item.info.Dirty && item.o != nil {
-		err = item._ensure(0, item.info.Size)
+		err = item._ensure(0, item.info.Size, int64(item.c.opt.ReadAhead))
 		if err != nil {
 			return errors.Wrap(err, "vfs cache: failed to download missing parts of cache file")
 		}
@@ -689,6 +821,11 @@ func (item *Item) Close(storeFn StoreFn) (err error) {
 	// after the downloader
 	checkErr(item._save())
 
+	// check the data if the download has completed
+	if item._needsVerify() && !item._verify() {
+		item._quarantine()
+	}
+
 	// if the item hasn't been changed but has been completed then
 	// set the modtime from the object otherwise set it from the info
 	if item._exists() {
@@ -732,10 +869,15 @@ func (item *Item) Close(storeFn StoreFn) (err error) {
 func (item *Item) reload(ctx context.Context) error {
 	item.mu.Lock()
 	dirty := item.info.Dirty
+	inUseElsewhere := dirty && item._inUseElsewhere()
 	item.mu.Unlock()
 	if !dirty {
 		return nil
 	}
+	if inUseElsewhere {
+		fs.Infof(item.name, "vfs cache: not uploading as in use by another process")
+		return nil
+	}
 	// see if the object still exists
 	obj, _ := item.c.fremote.NewObject(ctx, item.name)
 	// open the file with the object (or nil)
@@ -813,6 +955,69 @@ func (item *Item) _checkObject(o fs.Object) error {
 	return nil
 }
 
+// _needsVerify returns true if --vfs-cache-verify is set and the cache
+// file is complete but hasn't been checked against the remote hash
+//
+// call with lock held
+func (item *Item) _needsVerify() bool {
+	return item.c.opt.CacheVerify && !item.verified && item.o != nil && !item.info.Dirty && item._present()
+}
+
+// _verify checks the hash of the cache file against the hash of the
+// remote object returning false if they differ.
+//
+// If the hashes can't be compared it returns true.
+//
+// call with lock held
+func (item *Item) _verify() bool {
+	hashType := item.c.hashType
+	if hashType == hash.None {
+		return true
+	}
+	remoteHash, err := item.o.Hash(context.TODO(), hashType)
+	if err != nil || remoteHash == "" {
+		fs.Debugf(item.name, "vfs cache: can't verify cache file as remote %v unavailable: %v", hashType, err)
+		return true
+	}
+	in, err := os.Open(item.c.toOSPath(item.name))
+	if err != nil {
+		fs.Errorf(item.name, "vfs cache: failed to open cache file to verify it: %v", err)
+		return true
+	}
+	sums, err := hash.StreamTypes(in, hash.NewHashSet(hashType))
+	_ = in.Close()
+	if err != nil {
+		fs.Errorf(item.name, "vfs cache: failed to read cache file to verify it: %v", err)
+		return true
+	}
+	if !hash.Equals(remoteHash, sums[hashType]) {
+		fs.Errorf(item.name, "vfs cache: cache file is corrupted: %v differ (remote %q != cached %q)", hashType, remoteHash, sums[hashType])
+		return false
+	}
+	fs.Debugf(item.name, "vfs cache: verified cache file %v", hashType)
+	item.verified = true
+	return true
+}
+
+// _quarantine moves the cache file into the corrupt directory, so it
+// isn't served again but can be examined, and empties the item.
+//
+// call with lock held and the file closed
+func (item *Item) _quarantine() {
+	osPath := item.c.toOSPath(item.name)             // No locking in Cache
+	corruptPath := item.c.toOSPathCorrupt(item.name) // No locking in Cache
+	err := os.MkdirAll(filepath.Dir(corruptPath), 0700)
+	if err == nil {
+		err = os.Rename(osPath, corruptPath)
+	}
+	if err != nil {
+		fs.Errorf(item.name, "vfs cache: failed to move corrupted cache file to %q: %v", corruptPath, err)
+	} else {
+		fs.Logf(item.name, "vfs cache: moved corrupted cache file to %q", corruptPath)
+	}
+	item._remove("corrupted")
+}
+
 // WrittenBack checks to see if the item has been written back or not
 func (item *Item) WrittenBack() bool {
 	item.mu.Lock()
@@ -861,7 +1066,8 @@ func (item *Item) _remove(reason string) (wasWriting bool) {
 	item.mu.Unlock()
 	wasWriting = item.c.writeback.Remove(item.writeBackID)
 	item.mu.Lock()
-	item.info.clean()
+	item.info.clean(item.c.clock.Now())
+	item.verified = false
 	item._removeFile(reason)
 	item._removeMeta(reason)
 	return wasWriting
@@ -890,12 +1096,16 @@ func (item *Item) RemoveNotInUse(maxAge time.Duration, emptyOnly bool) (removed
 		return
 	}
 
+	if item._inUseElsewhere() {
+		return
+	}
+
 	removeIt := false
 	if maxAge == 0 {
 		removeIt = true // quota-driven removal
 	}
 	if maxAge != 0 {
-		cutoff := time.Now().Add(-maxAge)
+		cutoff := item.c.clock.Now().Add(-maxAge)
 		// If not locked and access time too long ago - delete the file
 		accessTime := item.info.ATime
 		if accessTime.Sub(cutoff) <= 0 {
@@ -921,6 +1131,11 @@ func (item *Item) Reset() (rr ResetResult, spaceFreed int64, err error) {
 	item.mu.Lock()
 	defer item.mu.Unlock()
 
+	// The item can't be removed or reset if another process is using it
+	if item._inUseElsewhere() {
+		return SkippedShared, 0, nil
+	}
+
 	// The item is not being used now.  Just remove it instead of resetting it.
 	if item.opens == 0 && !item.info.Dirty {
 		spaceFreed = item.info.Rs.Size()
@@ -1104,14 +1319,20 @@ func (item *Item) FindMissing(r ranges.Range) (outr ranges.Range) {
 
 // ensure the range from offset, size is present in the backing file
 //
+// Any downloaders started will read readAhead bytes beyond the range.
+//
 // call with the item lock held
-func (item *Item) _ensure(offset, size int64) (err error) {
+func (item *Item) _ensure(offset, size, readAhead int64) (err error) {
 	// defer log.Trace(item.name, "offset=%d, size=%d", offset, size)("err=%v", &err)
 	if offset+size > item.info.Size {
 		size = item.info.Size - offset
 	}
 	r := ranges.Range{Pos: offset, Size: size}
 	present := item.info.Rs.Present(r)
+	if !present && item._syncRanges() {
+		// Another process has downloaded some of the range
+		present = item.info.Rs.Present(r)
+	}
 	/* This statement simulates a cache space error for test purpose */
 	/* if present != true && item.info.Rs.Size() > 32*1024*1024 {
 		return errors.New("no space left on device")
@@ -1125,12 +1346,12 @@ func (item *Item) _ensure(offset, size int64) (err error) {
 			return nil
 		}
 		// Otherwise start the downloader for the future if required
-		return item.downloaders.EnsureDownloader(r)
+		return item.downloaders.EnsureDownloaderWithReadAhead(r, readAhead)
 	}
 	if item.downloaders == nil {
 		return errors.New("internal error: downloaders is nil")
 	}
-	return item.downloaders.Download(r)
+	return item.downloaders.DownloadWithReadAhead(r, readAhead)
 }
 
 // _written marks the (offset, size) as present in the backing file
@@ -1145,7 +1366,10 @@ func (item *Item) _ensure(offset, size int64) (err error) {
 // call with lock held
 func (item *Item) _written(offset, size int64) {
 	// defer log.Trace(item.name, "offset=%d, size=%d", offset, size)("")
-	item.info.Rs.Insert(ranges.Range{Pos: offset, Size: size})
+	r := ranges.Range{Pos: offset, Size: size}
+	item.info.Rs.Insert(r)
+	item._touchChunks(r, item.c.clock.Now())
+	item.verified = false
 }
 
 // update the fingerprint of the object if any
@@ -1202,11 +1426,18 @@ func (item *Item) GetModTime() (modTime time.Time, err error) {
 
 // ReadAt bytes from the file at off
 func (item *Item) ReadAt(b []byte, off int64) (n int, err error) {
+	return item.ReadAtWithReadAhead(b, off, int64(item.c.opt.ReadAhead))
+}
+
+// ReadAtWithReadAhead reads bytes from the file at off, reading
+// readAhead bytes beyond the end of b from the remote if it needs to
+// download anything.
+func (item *Item) ReadAtWithReadAhead(b []byte, off int64, readAhead int64) (n int, err error) {
 	n = 0
 	var expBackOff int
 	for retries := 0; retries < fs.GetConfig(context.TODO()).LowLevelRetries; retries++ {
 		item.preAccess()
-		n, err = item.readAt(b, off)
+		n, err = item.readAt(b, off, readAhead)
 		item.postAccess()
 		if err == nil || err == io.EOF {
 			break
@@ -1229,7 +1460,7 @@ func (item *Item) ReadAt(b []byte, off int64) (n int, err error) {
 }
 
 // ReadAt bytes from the file at off
-func (item *Item) readAt(b []byte, off int64) (n int, err error) {
+func (item *Item) readAt(b []byte, off int64, readAhead int64) (n int, err error) {
 	item.mu.Lock()
 	if item.fd == nil {
 		item.mu.Unlock()
@@ -1241,12 +1472,13 @@ func (item *Item) readAt(b []byte, off int64) (n int, err error) {
 	}
 	defer item.mu.Unlock()
 
-	err = item._ensure(off, int64(len(b)))
+	err = item._ensure(off, int64(len(b)), readAhead)
 	if err != nil {
 		return 0, err
 	}
 
-	item.info.ATime = time.Now()
+	item.info.ATime = item.c.clock.Now()
+	item._touchChunks(ranges.Range{Pos: off, Size: int64(len(b))}, item.info.ATime)
 	// Do the reading with Item.mu unlocked and cache protected by preAccess
 	n, err = item.fd.ReadAt(b, off)
 	return n, err
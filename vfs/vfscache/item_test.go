@@ -450,6 +450,59 @@ func TestItemReloadCacheStale(t *testing.T) {
 	checkObject(t, r, "existing", "HELLO"+contents2[5:])
 }
 
+func TestItemVerify(t *testing.T) {
+	opt := vfscommon.DefaultOpt
+	opt.CachePollInterval = 0
+	opt.WriteBack = 0
+	opt.CacheVerify = true
+	r, c, cleanup := newTestCacheOpt(t, opt)
+	defer cleanup()
+	defer func() {
+		require.NoError(t, os.RemoveAll(c.corruptDir))
+	}()
+
+	contents, obj, item := newFile(t, r, c, "existing")
+	osPath := c.toOSPath("existing")
+	corruptPath := c.toOSPathCorrupt("existing")
+
+	// Download the whole file which verifies it on close
+	require.NoError(t, item.Open(obj))
+	buf := make([]byte, 100)
+	n, err := item.ReadAt(buf, 0)
+	require.NoError(t, err)
+	assert.Equal(t, contents, string(buf[:n]))
+	require.NoError(t, item.Close(nil))
+	assert.True(t, item.verified)
+	_, err = os.Stat(osPath)
+	require.NoError(t, err)
+	assertPathNotExist(t, corruptPath)
+
+	// Corrupt the cache file and make it look like a cold cache
+	fd, err := os.OpenFile(osPath, os.O_WRONLY, 0600)
+	require.NoError(t, err)
+	_, err = fd.WriteAt([]byte("CORRUPT"), 0)
+	require.NoError(t, err)
+	require.NoError(t, fd.Close())
+	item.verified = false
+
+	// Opening it should quarantine the corrupted file and read
+	// the good data from the remote
+	require.NoError(t, item.Open(obj))
+	corrupted, err := ioutil.ReadFile(corruptPath)
+	require.NoError(t, err)
+	assert.Equal(t, "CORRUPT"+contents[7:], string(corrupted))
+	n, err = item.ReadAt(buf, 0)
+	require.NoError(t, err)
+	assert.Equal(t, contents, string(buf[:n]))
+	require.NoError(t, item.Close(nil))
+	assert.True(t, item.verified)
+
+	// Check the good data was kept
+	cached, err := ioutil.ReadFile(osPath)
+	require.NoError(t, err)
+	assert.Equal(t, contents, string(cached))
+}
+
 func TestItemReadWrite(t *testing.T) {
 	r, c, cleanup := newItemTestCache(t)
 	defer cleanup()
@@ -0,0 +1,112 @@
+package vfscache
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/pingme998/rclone/fs"
+	"github.com/pingme998/rclone/lib/file"
+)
+
+// Sharing the cache between processes
+//
+// More than one rclone may use the same cache directory for the same
+// (or overlapping) remotes, eg an "rclone mount" and an "rclone serve
+// webdav". To stop them corrupting each other's cache files
+//
+// - the metadata files are only read and written with an advisory
+//   lock held on the lock file in the cache directory so the
+//   read-modify-write of the metadata in Item._save is atomic.
+//
+// - the metadata of each Item records the Owners which have it open
+//   or dirty. An Item which has a live owner in another process is
+//   never removed or reset by the cache cleaner or uploaded on reload.
+//
+// - when an Item is opened its metadata is re-read from disk and
+//   before downloading any data the ranges other processes have
+//   already downloaded are merged in so the data isn't stored twice.
+
+// metaLockName is the name of the lock file in the cache directory
+const metaLockName = "vfs.lock"
+
+// metaLock serializes access to the metadata between processes
+// using the cache directory and between users of the Cache in this
+// process.
+//
+// It must be taken after Item.mu and nothing else may be locked while
+// it is held.
+type metaLock struct {
+	mu sync.Mutex
+	fd *os.File // lock file - nil if it couldn't be opened
+}
+
+// newMetaLock opens the lock file at osPath
+func newMetaLock(osPath string) *metaLock {
+	l := &metaLock{}
+	fd, err := file.OpenFile(osPath, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		fs.Errorf(nil, "vfs cache: failed to open lock file so cache can't be shared with other processes: %v", err)
+	} else {
+		l.fd = fd
+	}
+	return l
+}
+
+// Lock the metadata
+func (l *metaLock) Lock() {
+	l.mu.Lock()
+	if l.fd != nil {
+		err := lockFile(l.fd)
+		if err != nil {
+			fs.Errorf(nil, "vfs cache: failed to lock metadata: %v", err)
+		}
+	}
+}
+
+// Unlock the metadata
+func (l *metaLock) Unlock() {
+	if l.fd != nil {
+		err := unlockFile(l.fd)
+		if err != nil {
+			fs.Errorf(nil, "vfs cache: failed to unlock metadata: %v", err)
+		}
+	}
+	l.mu.Unlock()
+}
+
+// number of Cache objects made by this process
+var cacheInstances int32
+
+// newOwner returns a unique identifier for a Cache to record in the
+// metadata of the Items it is using.
+//
+// It is of the form "hostname:pid:instance"
+func newOwner() string {
+	hostname, _ := os.Hostname()
+	return fmt.Sprintf("%s:%d:%d", hostname, os.Getpid(), atomic.AddInt32(&cacheInstances, 1))
+}
+
+// ownerAlive returns true if the process which made owner may still
+// be running.
+//
+// Processes on other hosts can't be checked so are assumed to be
+// running.
+func ownerAlive(owner string) bool {
+	parts := strings.Split(owner, ":")
+	if len(parts) != 3 {
+		return false
+	}
+	hostname, _ := os.Hostname()
+	if parts[0] != hostname {
+		return true
+	}
+	pid, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return false
+	}
+	return pid == os.Getpid() || processAlive(pid)
+}
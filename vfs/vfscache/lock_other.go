@@ -0,0 +1,21 @@
+//+build plan9 js solaris aix
+
+package vfscache
+
+import "os"
+
+// lockFile is a no-op on this OS so the cache can't be shared
+// between processes safely
+func lockFile(f *os.File) error {
+	return nil
+}
+
+// unlockFile is a no-op on this OS
+func unlockFile(f *os.File) error {
+	return nil
+}
+
+// processAlive can't be checked on this OS so assumes pid is running
+func processAlive(pid int) bool {
+	return true
+}
@@ -0,0 +1,147 @@
+package vfscache
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/pingme998/rclone/lib/ranges"
+	"github.com/pingme998/rclone/vfs/vfscommon"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOwnerAlive(t *testing.T) {
+	hostname, err := os.Hostname()
+	require.NoError(t, err)
+
+	owner := newOwner()
+	assert.NotEqual(t, owner, newOwner())
+	assert.True(t, ownerAlive(owner))
+	assert.True(t, ownerAlive(fmt.Sprintf("%s:%d:1", hostname, os.Getpid())))
+	assert.True(t, ownerAlive("some-other-host.example.com:1:1"))
+	assert.False(t, ownerAlive("potato"))
+	assert.False(t, ownerAlive(hostname+":potato:1"))
+}
+
+// readOwners reads the Owners of item from the metadata on disk
+func readOwners(t *testing.T, item *Item) []string {
+	item.mu.Lock()
+	defer item.mu.Unlock()
+	item.c.metaLock.Lock()
+	defer item.c.metaLock.Unlock()
+	var info Info
+	exists, err := item._readMeta(&info)
+	require.True(t, exists)
+	require.NoError(t, err)
+	return info.Owners
+}
+
+func TestCacheShared(t *testing.T) {
+	r, c, cleanup := newItemTestCache(t)
+	defer cleanup()
+
+	// Make a second cache on the same remote and cache directory
+	// as if it was another process
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	opt := vfscommon.DefaultOpt
+	opt.CachePollInterval = 0
+	opt.WriteBack = 0
+	c2, err := New(ctx, r.Fremote, &opt, addVirtual)
+	require.NoError(t, err)
+	assert.NotEqual(t, c.owner, c2.owner)
+
+	contents, obj, item := newFile(t, r, c, "existing")
+	item2, _ := c2.get("existing")
+
+	// Read some of the file into the cache with the first cache
+	require.NoError(t, item.Open(obj))
+	buf := make([]byte, 10)
+	n, err := item.ReadAt(buf, 0)
+	require.NoError(t, err)
+	assert.Equal(t, contents[:10], string(buf[:n]))
+	assert.Equal(t, []string{c.owner}, readOwners(t, item))
+
+	// The second cache can't remove or reset it
+	removed, _ := item2.RemoveNotInUse(0, false)
+	assert.False(t, removed)
+	rr, _, err := item2.Reset()
+	require.NoError(t, err)
+	assert.Equal(t, SkippedShared, rr)
+	assert.True(t, item.Exists())
+
+	// Open it with the second cache too and check it finds the
+	// data the first downloaded when it was closed
+	require.NoError(t, item.Close(nil))
+	assert.Nil(t, readOwners(t, item))
+	require.NoError(t, item2.Open(obj))
+	assert.True(t, item2.HasRange(ranges.Range{Pos: 0, Size: 10}))
+	assert.Equal(t, []string{c2.owner}, readOwners(t, item2))
+
+	// Now the first cache can't remove it
+	removed, _ = item.RemoveNotInUse(0, false)
+	assert.False(t, removed)
+	assert.True(t, item2.Exists())
+
+	n, err = item2.ReadAt(buf, 50)
+	require.NoError(t, err)
+	assert.Equal(t, contents[50:60], string(buf[:n]))
+	require.NoError(t, item2.Close(nil))
+
+	// When neither has it open it can be removed
+	removed, _ = item.RemoveNotInUse(0, false)
+	assert.True(t, removed)
+	assert.False(t, item2.Exists())
+}
+
+func TestItemSyncRanges(t *testing.T) {
+	r, c, cleanup := newItemTestCache(t)
+	defer cleanup()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	opt := vfscommon.DefaultOpt
+	opt.CachePollInterval = 0
+	opt.WriteBack = 0
+	c2, err := New(ctx, r.Fremote, &opt, addVirtual)
+	require.NoError(t, err)
+
+	_, obj, item := newFile(t, r, c, "existing")
+	item2, _ := c2.get("existing")
+	first := ranges.Range{Pos: 0, Size: 10}
+
+	// Download some of the file with the first cache and record it
+	require.NoError(t, item.Open(obj))
+	buf := make([]byte, 10)
+	_, err = item.ReadAt(buf, 0)
+	require.NoError(t, err)
+	require.NoError(t, item.Close(nil))
+
+	require.NoError(t, item2.Open(obj))
+	defer func() {
+		require.NoError(t, item2.Close(nil))
+	}()
+
+	item2.mu.Lock()
+	defer item2.mu.Unlock()
+	assert.True(t, item2.info.Rs.Present(first))
+
+	// Forget the range - it isn't found again until the metadata
+	// on disk changes
+	item2.info.Rs = nil
+	item2.metaModTime = time.Time{}
+	assert.True(t, item2._syncRanges())
+	assert.True(t, item2.info.Rs.Present(first))
+	item2.info.Rs = nil
+	assert.False(t, item2._syncRanges())
+	assert.False(t, item2.info.Rs.Present(first))
+
+	modTime := item2.metaModTime.Add(time.Second)
+	require.NoError(t, os.Chtimes(c.toOSPathMeta("existing"), modTime, modTime))
+	assert.True(t, item2._syncRanges())
+	assert.True(t, item2.info.Rs.Present(first))
+	assert.Equal(t, modTime, item2.metaModTime)
+}
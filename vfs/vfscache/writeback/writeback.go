@@ -11,6 +11,7 @@ import (
 
 	"github.com/pingme998/rclone/fs"
 	"github.com/pingme998/rclone/fs/fserrors"
+	"github.com/pingme998/rclone/lib/clock"
 	"github.com/pingme998/rclone/vfs/vfscommon"
 )
 
@@ -30,10 +31,13 @@ type WriteBack struct {
 	mu      sync.Mutex
 	items   writeBackItems            // priority queue of *writeBackItem - writeBackItems are in here while awaiting transfer only
 	lookup  map[Handle]*writeBackItem // for getting a *writeBackItem from a Handle - writeBackItems are in here until cancelled
+	names   map[string]*writeBackItem // the latest writeBackItem for each name
 	opt     *vfscommon.Options        // VFS options
-	timer   *time.Timer               // next scheduled time for the uploader
+	clock   clock.Clock               // source of the time
+	timer   clock.Timer               // next scheduled time for the uploader
 	expiry  time.Time                 // time the next item expires or IsZero
 	uploads int                       // number of uploads in progress
+	flush   bool                      // set to upload everything now, ignoring the write back delay and window
 
 	// read and written with atomic
 	id Handle // id of the last writeBackItem created
@@ -42,12 +46,16 @@ type WriteBack struct {
 // New make a new WriteBack
 //
 // cancel the context to stop the background processing
+//
+// It uses the clock in the context to tell the time.
 func New(ctx context.Context, opt *vfscommon.Options) *WriteBack {
 	wb := &WriteBack{
 		ctx:    ctx,
 		items:  writeBackItems{},
 		lookup: make(map[Handle]*writeBackItem),
+		names:  make(map[string]*writeBackItem),
 		opt:    opt,
+		clock:  clock.Get(ctx),
 	}
 	heap.Init(&wb.items)
 	return wb
@@ -124,8 +132,8 @@ func (ws *writeBackItems) _update(item *writeBackItem, expiry time.Time) {
 //
 // call with lock held
 func (wb *WriteBack) _newExpiry() time.Time {
-	expiry := time.Now()
-	if wb.opt.WriteBack > 0 {
+	expiry := wb.clock.Now()
+	if wb.opt.WriteBack > 0 && !wb.flush {
 		expiry = expiry.Add(wb.opt.WriteBack)
 	}
 	// expiry = expiry.Round(time.Millisecond)
@@ -143,6 +151,7 @@ func (wb *WriteBack) _newItem(id Handle, name string) *writeBackItem {
 		delay:  wb.opt.WriteBack,
 		id:     id,
 	}
+	wb._supersede(wbItem)
 	wb._addItem(wbItem)
 	wb._pushItem(wbItem)
 	return wbItem
@@ -160,6 +169,41 @@ func (wb *WriteBack) _addItem(wbItem *writeBackItem) {
 // call with the lock held
 func (wb *WriteBack) _delItem(wbItem *writeBackItem) {
 	delete(wb.lookup, wbItem.id)
+	if wb.names[wbItem.name] == wbItem {
+		delete(wb.names, wbItem.name)
+	}
+}
+
+// cancel a writeBackItem, stopping its upload if in progress and
+// removing it from the queue
+//
+// call with the lock held
+func (wb *WriteBack) _cancelItem(wbItem *writeBackItem) {
+	if wbItem.uploading {
+		// We are uploading already so cancel the upload
+		wb._cancelUpload(wbItem)
+	}
+	// Remove the item from the heap
+	wb._removeItem(wbItem)
+	// Remove the item from the lookup map
+	wb._delItem(wbItem)
+}
+
+// make wbItem the latest writeBackItem for its name, cancelling any
+// other one for the same name.
+//
+// This happens when a file is replaced before it was uploaded, eg by
+// an editor saving to a temporary file and renaming it over the
+// original, so the old version doesn't need uploading as it will be
+// overwritten by the new one straight away.
+//
+// call with the lock held
+func (wb *WriteBack) _supersede(wbItem *writeBackItem) {
+	if old := wb.names[wbItem.name]; old != nil && old != wbItem {
+		fs.Infof(old.name, "vfs cache: cancelling writeback of old version (uploading %v) item %d superseded by item %d", old.uploading, old.id, wbItem.id)
+		wb._cancelItem(old)
+	}
+	wb.names[wbItem.name] = wbItem
 }
 
 // pop a writeBackItem from the items heap
@@ -220,11 +264,19 @@ func (wb *WriteBack) _resetTimer() {
 	if wbItem == nil {
 		wb._stopTimer()
 	} else {
-		if wb.expiry.Equal(wbItem.expiry) {
+		// Don't start uploading until --vfs-write-back-window opens
+		expiry := wbItem.expiry
+		if now := wb.clock.Now(); expiry.Before(now) {
+			expiry = now
+		}
+		if !wb.flush {
+			expiry = wb.opt.WriteBackWindow.Next(expiry)
+		}
+		if wb.expiry.Equal(expiry) {
 			return
 		}
-		wb.expiry = wbItem.expiry
-		dt := time.Until(wbItem.expiry)
+		wb.expiry = expiry
+		dt := wb.clock.Until(expiry)
 		if dt < 0 {
 			dt = 0
 		}
@@ -232,7 +284,7 @@ func (wb *WriteBack) _resetTimer() {
 		if wb.timer != nil {
 			wb.timer.Stop()
 		}
-		wb.timer = time.AfterFunc(dt, func() {
+		wb.timer = wb.clock.AfterFunc(dt, func() {
 			wb.processItems(wb.ctx)
 		})
 	}
@@ -286,14 +338,7 @@ func (wb *WriteBack) Remove(id Handle) (found bool) {
 	wbItem, found := wb.lookup[id]
 	if found {
 		fs.Debugf(wbItem.name, "vfs cache: cancelling writeback (uploading %v) %p item %d", wbItem.uploading, wbItem, wbItem.id)
-		if wbItem.uploading {
-			// We are uploading already so cancel the upload
-			wb._cancelUpload(wbItem)
-		}
-		// Remove the item from the heap
-		wb._removeItem(wbItem)
-		// Remove the item from the lookup map
-		wb._delItem(wbItem)
+		wb._cancelItem(wbItem)
 	}
 	wb._resetTimer()
 	return found
@@ -314,7 +359,11 @@ func (wb *WriteBack) Rename(id Handle, name string) {
 		// We are uploading already so cancel the upload
 		wb._cancelUpload(wbItem)
 	}
+	if wb.names[wbItem.name] == wbItem {
+		delete(wb.names, wbItem.name)
+	}
 	wbItem.name = name
+	wb._supersede(wbItem)
 	// Kick the timer on
 	wb.items._update(wbItem, wb._newExpiry())
 
@@ -356,7 +405,7 @@ func (wb *WriteBack) upload(ctx context.Context, wbItem *writeBackItem) {
 		}
 		// push the item back on the queue for retry
 		wb._pushItem(wbItem)
-		wb.items._update(wbItem, time.Now().Add(wbItem.delay))
+		wb.items._update(wbItem, wb.clock.Now().Add(wbItem.delay))
 	} else {
 		fs.Infof(wbItem.name, "vfs cache: upload succeeded try #%d", wbItem.tries)
 		// show that we are done with the item
@@ -413,11 +462,23 @@ func (wb *WriteBack) processItems(ctx context.Context) {
 		return
 	}
 
+	if window := wb.opt.WriteBackWindow; !wb.flush && !window.Contains(wb.clock.Now()) {
+		fs.Debugf(nil, "vfs cache: delaying writeback until --vfs-write-back-window %v", window)
+		wb._stopTimer()
+		wb._resetTimer()
+		return
+	}
+
+	maxUploads := wb.opt.WriteBackUploads
+	if maxUploads <= 0 {
+		maxUploads = fs.GetConfig(context.TODO()).Transfers
+	}
+
 	resetTimer := true
-	for wbItem := wb._peekItem(); wbItem != nil && time.Until(wbItem.expiry) <= 0; wbItem = wb._peekItem() {
+	for wbItem := wb._peekItem(); wbItem != nil && wb.clock.Until(wbItem.expiry) <= 0; wbItem = wb._peekItem() {
 		// If reached transfer limit don't restart the timer
-		if wb.uploads >= fs.GetConfig(context.TODO()).Transfers {
-			fs.Debugf(wbItem.name, "vfs cache: delaying writeback as --transfers exceeded")
+		if wb.uploads >= maxUploads {
+			fs.Debugf(wbItem.name, "vfs cache: delaying writeback as max uploads %d exceeded", maxUploads)
 			resetTimer = false
 			break
 		}
@@ -439,6 +500,26 @@ func (wb *WriteBack) processItems(ctx context.Context) {
 	}
 }
 
+// Flush starts uploading all the items awaiting writeback straight
+// away ignoring --vfs-write-back and --vfs-write-back-window, and
+// carries on doing so for items added afterwards.
+//
+// It doesn't wait for the uploads to finish - use Stats to see when
+// they have.
+func (wb *WriteBack) Flush() {
+	wb.mu.Lock()
+	defer wb.mu.Unlock()
+	wb.flush = true
+	now := wb.clock.Now()
+	for _, wbItem := range wb.items {
+		if wbItem.expiry.After(now) {
+			wbItem.expiry = now
+		}
+	}
+	heap.Init(&wb.items)
+	wb._resetTimer()
+}
+
 // Stats return the number of uploads in progress and queued
 func (wb *WriteBack) Stats() (uploadsInProgress, uploadsQueued int) {
 	wb.mu.Lock()
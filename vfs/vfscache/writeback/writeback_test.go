@@ -11,8 +11,10 @@ import (
 
 	"github.com/pkg/errors"
 	"github.com/pingme998/rclone/fs"
+	"github.com/pingme998/rclone/lib/clock"
 	"github.com/pingme998/rclone/vfs/vfscommon"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func newTestWriteBack(t *testing.T) (wb *WriteBack, cancel func()) {
@@ -23,6 +25,17 @@ func newTestWriteBack(t *testing.T) (wb *WriteBack, cancel func()) {
 	return wb, cancel
 }
 
+// newMockWriteBack makes a WriteBack using a mock clock set to midday
+// so the tests can move the time on rather than sleeping
+func newMockWriteBack(t *testing.T) (wb *WriteBack, cancel func(), m *clock.Mock) {
+	m = clock.NewMock(time.Date(2020, 6, 1, 12, 0, 0, 0, time.Local))
+	ctx, cancel := context.WithCancel(clock.WithClock(context.Background(), m))
+	opt := vfscommon.DefaultOpt
+	opt.WriteBack = 100 * time.Millisecond
+	wb = New(ctx, &opt)
+	return wb, cancel, m
+}
+
 // string for debugging - make a copy and pop the items out in order
 func (wb *WriteBack) string(t *testing.T) string {
 	wb.mu.Lock()
@@ -316,7 +329,7 @@ func TestWriteBackAddOK(t *testing.T) {
 
 // Now test the upload failing and being retried
 func TestWriteBackAddFailRetry(t *testing.T) {
-	wb, cancel := newTestWriteBack(t)
+	wb, cancel, m := newMockWriteBack(t)
 	defer cancel()
 
 	pi := newPutItem(t)
@@ -327,6 +340,7 @@ func TestWriteBackAddFailRetry(t *testing.T) {
 	checkInLookup(t, wb, wbItem)
 	assert.Equal(t, "one", wb.string(t))
 
+	m.Advance(wb.opt.WriteBack)
 	<-pi.started
 	checkNotOnHeap(t, wb, wbItem)
 	checkInLookup(t, wb, wbItem)
@@ -336,7 +350,10 @@ func TestWriteBackAddFailRetry(t *testing.T) {
 	checkOnHeap(t, wb, wbItem)
 	checkInLookup(t, wb, wbItem)
 
-	// check the retry
+	// check the retry only happens after the delay has doubled
+	m.Advance(2*wb.opt.WriteBack - time.Millisecond)
+	checkOnHeap(t, wb, wbItem)
+	m.Advance(time.Millisecond)
 	<-pi.started
 	checkNotOnHeap(t, wb, wbItem)
 	checkInLookup(t, wb, wbItem)
@@ -506,7 +523,7 @@ func TestWriteBackMaxQueue(t *testing.T) {
 	for i := 0; i < toTransfer; i++ {
 		pi := newPutItem(t)
 		pis = append(pis, pi)
-		wb.Add(0, fmt.Sprintf("number%d", 1), true, pi.put)
+		wb.Add(0, fmt.Sprintf("number%d", i), true, pi.put)
 	}
 
 	inProgress, queued := wb.Stats()
@@ -542,6 +559,76 @@ func TestWriteBackMaxQueue(t *testing.T) {
 	assert.Equal(t, inProgress, 0)
 }
 
+func TestWriteBackMaxUploads(t *testing.T) {
+	wb, cancel, m := newMockWriteBack(t)
+	defer cancel()
+	wb.opt.WriteBackUploads = 1
+
+	pi1, pi2 := newPutItem(t), newPutItem(t)
+	wb.Add(0, "one", true, pi1.put)
+	wb.Add(0, "two", true, pi2.put)
+
+	// only one upload should start
+	m.Advance(2 * wb.opt.WriteBack)
+	<-pi1.started
+	inProgress, queued := wb.Stats()
+	assert.Equal(t, 1, inProgress)
+	assert.Equal(t, 1, queued)
+
+	// finishing it should start the next
+	pi1.finish(nil)
+	<-pi2.started
+	pi2.finish(nil)
+	waitUntilNoTransfers(t, wb)
+}
+
+func TestWriteBackWindow(t *testing.T) {
+	wb, cancel, m := newMockWriteBack(t)
+	defer cancel()
+
+	// Make a window which opened an hour ago
+	now := m.Now()
+	wb.opt.WriteBackWindow = vfscommon.TimeWindow{
+		Start: 11 * time.Hour,
+		End:   14 * time.Hour,
+	}
+	require.True(t, wb.opt.WriteBackWindow.Contains(now))
+	pi := newPutItem(t)
+	wb.Add(0, "one", true, pi.put)
+	m.Advance(wb.opt.WriteBack)
+	<-pi.started
+	pi.finish(nil)
+	waitUntilNoTransfers(t, wb)
+
+	// Now make a window which opens in an hour
+	wb.opt.WriteBackWindow = vfscommon.TimeWindow{
+		Start: 13 * time.Hour,
+		End:   14 * time.Hour,
+	}
+	now = m.Now()
+	require.False(t, wb.opt.WriteBackWindow.Contains(now))
+	pi = newPutItem(t)
+	id := wb.Add(0, "two", true, pi.put)
+	wbItem := wb.lookup[id]
+	m.Advance(2 * wb.opt.WriteBack)
+
+	// The upload shouldn't have started but should be scheduled
+	checkOnHeap(t, wb, wbItem)
+	assertTimerRunning(t, wb, true)
+	wb.mu.Lock()
+	assert.Equal(t, time.Date(2020, 6, 1, 13, 0, 0, 0, time.Local), wb.expiry)
+	wb.mu.Unlock()
+	pi.mu.Lock()
+	assert.False(t, pi.called)
+	pi.mu.Unlock()
+
+	// It should start when the window opens
+	m.Advance(time.Hour)
+	<-pi.started
+	pi.finish(nil)
+	waitUntilNoTransfers(t, wb)
+}
+
 func TestWriteBackRename(t *testing.T) {
 	wb, cancel := newTestWriteBack(t)
 	defer cancel()
@@ -585,6 +672,58 @@ func TestWriteBackRename(t *testing.T) {
 	assert.Equal(t, wbItem.name, "three")
 }
 
+func TestWriteBackSupersede(t *testing.T) {
+	wb, cancel := newTestWriteBack(t)
+	defer cancel()
+
+	// add item
+	pi1 := newPutItem(t)
+	id1 := wb.Add(0, "one", true, pi1.put)
+	wbItem1 := wb.lookup[id1]
+
+	// save a new version to a temporary file and rename it over
+	// the original before it is uploaded
+	pi2 := newPutItem(t)
+	id2 := wb.Add(0, "one.tmp", true, pi2.put)
+	wbItem2 := wb.lookup[id2]
+	wb.Rename(id2, "one")
+	checkNotOnHeap(t, wb, wbItem1)
+	checkNotInLookup(t, wb, wbItem1)
+	checkOnHeap(t, wb, wbItem2)
+	checkInLookup(t, wb, wbItem2)
+	assert.Equal(t, "one", wb.string(t))
+
+	// only the new version is uploaded
+	<-pi2.started
+	pi2.finish(nil)
+	waitUntilNoTransfers(t, wb)
+	assert.False(t, pi1.called)
+	checkNotInLookup(t, wb, wbItem2)
+
+	// add an item and wait for the upload to start
+	pi3 := newPutItem(t)
+	id3 := wb.Add(0, "two", true, pi3.put)
+	wbItem3 := wb.lookup[id3]
+	<-pi3.started
+
+	// a new version cancels the upload
+	pi4 := newPutItem(t)
+	id4 := wb.Add(0, "two", true, pi4.put)
+	wbItem4 := wb.lookup[id4]
+	assert.True(t, pi3.cancelled)
+	checkNotOnHeap(t, wb, wbItem3)
+	checkNotInLookup(t, wb, wbItem3)
+	checkInLookup(t, wb, wbItem4)
+
+	<-pi4.started
+	pi4.finish(nil)
+	waitUntilNoTransfers(t, wb)
+	checkNotInLookup(t, wb, wbItem4)
+	wb.mu.Lock()
+	assert.Equal(t, 0, len(wb.names))
+	wb.mu.Unlock()
+}
+
 func TestWriteBackCancelUpload(t *testing.T) {
 	wb, cancel := newTestWriteBack(t)
 	defer cancel()
@@ -615,3 +754,41 @@ func TestWriteBackCancelUpload(t *testing.T) {
 	checkInLookup(t, wb, wbItem)
 	assert.True(t, pi.cancelled)
 }
+
+func TestWriteBackFlush(t *testing.T) {
+	wb, cancel, m := newMockWriteBack(t)
+	defer cancel()
+
+	// Make a window which opens in an hour and a long delay so
+	// nothing would be uploaded without the Flush
+	wb.opt.WriteBackWindow = vfscommon.TimeWindow{
+		Start: 13 * time.Hour,
+		End:   14 * time.Hour,
+	}
+	wb.opt.WriteBack = time.Hour
+
+	pi := newPutItem(t)
+	id := wb.Add(0, "one", true, pi.put)
+	wbItem := wb.lookup[id]
+	m.Advance(30 * time.Minute)
+	checkOnHeap(t, wb, wbItem)
+	pi.mu.Lock()
+	assert.False(t, pi.called)
+	pi.mu.Unlock()
+
+	wb.Flush()
+	<-pi.started
+	pi.finish(nil)
+	waitUntilNoTransfers(t, wb)
+
+	// Items added after the Flush are uploaded straight away too
+	pi = newPutItem(t)
+	wb.Add(0, "two", true, pi.put)
+	<-pi.started
+	pi.finish(nil)
+	waitUntilNoTransfers(t, wb)
+
+	uploadsInProgress, uploadsQueued := wb.Stats()
+	assert.Equal(t, 0, uploadsInProgress)
+	assert.Equal(t, 0, uploadsQueued)
+}
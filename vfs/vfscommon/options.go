@@ -27,12 +27,21 @@ type Options struct {
 	CacheMaxAge       time.Duration
 	CacheMaxSize      fs.SizeSuffix
 	CachePollInterval time.Duration
+	CacheChunkSize    fs.SizeSuffix // if > 0 track and evict cached data in chunks of this size
+	CacheVerify       bool          // if set check complete cache files against the remote hash
+	CacheBypassDirect bool          // if set read files opened with O_DIRECT straight from the remote
 	CaseInsensitive   bool
 	WriteWait         time.Duration // time to wait for in-sequence write
 	ReadWait          time.Duration // time to wait for in-sequence read
 	WriteBack         time.Duration // time to wait before writing back dirty files
+	WriteBackWindow   TimeWindow    // only start writing back dirty files in this window each day
+	WriteBackUploads  int           // max number of concurrent writebacks - 0 to use --transfers
+	WriteBackBwLimit  fs.SizeSuffix // bandwidth limit for writebacks in bytes/s - 0 for none
 	ReadAhead         fs.SizeSuffix // bytes to read ahead in cache mode "full"
+	ReadAheadAdaptive bool          // if set grow the read ahead for sequential reads
+	ReadAheadMax      fs.SizeSuffix // max bytes to read ahead if ReadAheadAdaptive is set
 	UsedIsSize        bool          // if true, use the `rclone size` algorithm for Used size
+	ReadDirPlus       bool          // if true, read modtimes when listing directories
 }
 
 // DefaultOpt is the default values uses for Opt
@@ -54,10 +63,16 @@ var DefaultOpt = Options{
 	ChunkSize:         128 * fs.Mebi,
 	ChunkSizeLimit:    -1,
 	CacheMaxSize:      -1,
+	CacheChunkSize:    0,
+	CacheVerify:       false,
+	CacheBypassDirect: false,
 	CaseInsensitive:   runtime.GOOS == "windows" || runtime.GOOS == "darwin", // default to true on Windows and Mac, false otherwise
 	WriteWait:         1000 * time.Millisecond,
 	ReadWait:          20 * time.Millisecond,
 	WriteBack:         5 * time.Second,
 	ReadAhead:         0 * fs.Mebi,
+	ReadAheadAdaptive: false,
+	ReadAheadMax:      128 * fs.Mebi,
 	UsedIsSize:        false,
+	ReadDirPlus:       false,
 }
@@ -0,0 +1,115 @@
+package vfscommon
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pingme998/rclone/lib/errors"
+)
+
+// TimeWindow is a window of time each day, eg "23:00-07:00"
+//
+// The window may span midnight. The zero value is the whole day.
+type TimeWindow struct {
+	Start time.Duration // time after midnight the window opens
+	End   time.Duration // time after midnight the window closes
+}
+
+// IsZero returns true if the window is the whole day
+func (w TimeWindow) IsZero() bool {
+	return w.Start == w.End
+}
+
+// String turns a TimeWindow into a string
+func (w TimeWindow) String() string {
+	if w.IsZero() {
+		return ""
+	}
+	hhmm := func(d time.Duration) string {
+		return fmt.Sprintf("%02d:%02d", int(d/time.Hour), int(d%time.Hour/time.Minute))
+	}
+	return hhmm(w.Start) + "-" + hhmm(w.End)
+}
+
+// parseHHMM parses a time of day in HH:MM format
+func parseHHMM(s string) (d time.Duration, err error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, errors.Errorf("invalid time of day %q - use HH:MM", s)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// Set a TimeWindow
+func (w *TimeWindow) Set(s string) (err error) {
+	if s == "" {
+		*w = TimeWindow{}
+		return nil
+	}
+	parts := strings.Split(s, "-")
+	if len(parts) != 2 {
+		return errors.Errorf("invalid time window %q - use HH:MM-HH:MM", s)
+	}
+	var newW TimeWindow
+	if newW.Start, err = parseHHMM(parts[0]); err != nil {
+		return err
+	}
+	if newW.End, err = parseHHMM(parts[1]); err != nil {
+		return err
+	}
+	*w = newW
+	return nil
+}
+
+// Type of the value
+func (w *TimeWindow) Type() string {
+	return "TimeWindow"
+}
+
+// MarshalJSON encodes the TimeWindow as a string
+func (w TimeWindow) MarshalJSON() ([]byte, error) {
+	return json.Marshal(w.String())
+}
+
+// UnmarshalJSON decodes the TimeWindow from a string
+func (w *TimeWindow) UnmarshalJSON(in []byte) error {
+	var s string
+	err := json.Unmarshal(in, &s)
+	if err != nil {
+		return err
+	}
+	return w.Set(s)
+}
+
+// midnight returns the start of the day t is in
+func midnight(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+}
+
+// Contains returns true if t is within the window
+func (w TimeWindow) Contains(t time.Time) bool {
+	if w.IsZero() {
+		return true
+	}
+	offset := t.Sub(midnight(t))
+	if w.Start < w.End {
+		return offset >= w.Start && offset < w.End
+	}
+	return offset >= w.Start || offset < w.End
+}
+
+// Next returns t if it is within the window, otherwise the time the
+// window next opens after t.
+func (w TimeWindow) Next(t time.Time) time.Time {
+	if w.Contains(t) {
+		return t
+	}
+	start := midnight(t).Add(w.Start)
+	if start.Before(t) {
+		start = midnight(start.AddDate(0, 0, 1)).Add(w.Start)
+	}
+	return start
+}
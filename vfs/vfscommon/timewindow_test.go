@@ -0,0 +1,91 @@
+package vfscommon
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Check TimeWindow it satisfies the pflag interface
+var _ pflag.Value = (*TimeWindow)(nil)
+
+// Check TimeWindow it satisfies the json.Unmarshaller interface
+var _ json.Unmarshaler = (*TimeWindow)(nil)
+
+func TestTimeWindowSet(t *testing.T) {
+	for _, test := range []struct {
+		in   string
+		want TimeWindow
+		str  string
+		err  bool
+	}{
+		{in: "", want: TimeWindow{}, str: ""},
+		{in: "23:00-07:30", want: TimeWindow{Start: 23 * time.Hour, End: 7*time.Hour + 30*time.Minute}, str: "23:00-07:30"},
+		{in: "01:05-2:00", want: TimeWindow{Start: time.Hour + 5*time.Minute, End: 2 * time.Hour}, str: "01:05-02:00"},
+		{in: "23:00", err: true},
+		{in: "23:00-25:00", err: true},
+		{in: "potato-07:00", err: true},
+	} {
+		var w TimeWindow
+		err := w.Set(test.in)
+		if test.err {
+			assert.Error(t, err, test.in)
+			continue
+		}
+		require.NoError(t, err, test.in)
+		assert.Equal(t, test.want, w, test.in)
+		assert.Equal(t, test.str, w.String(), test.in)
+	}
+}
+
+func TestTimeWindowType(t *testing.T) {
+	var w TimeWindow
+	assert.Equal(t, "TimeWindow", w.Type())
+}
+
+func TestTimeWindowJSON(t *testing.T) {
+	w := TimeWindow{Start: 23 * time.Hour, End: 7 * time.Hour}
+	out, err := json.Marshal(w)
+	require.NoError(t, err)
+	assert.Equal(t, `"23:00-07:00"`, string(out))
+
+	var got TimeWindow
+	require.NoError(t, json.Unmarshal(out, &got))
+	assert.Equal(t, w, got)
+
+	assert.Error(t, json.Unmarshal([]byte(`"potato"`), &got))
+}
+
+func TestTimeWindowContainsNext(t *testing.T) {
+	at := func(s string) time.Time {
+		tm, err := time.ParseInLocation("2006-01-02 15:04", s, time.Local)
+		require.NoError(t, err)
+		return tm
+	}
+	night := TimeWindow{Start: 23 * time.Hour, End: 7 * time.Hour}
+	day := TimeWindow{Start: 9 * time.Hour, End: 17 * time.Hour}
+	for _, test := range []struct {
+		w        TimeWindow
+		t        string
+		contains bool
+		next     string
+	}{
+		{TimeWindow{}, "2021-06-01 12:00", true, "2021-06-01 12:00"},
+		{night, "2021-06-01 23:30", true, "2021-06-01 23:30"},
+		{night, "2021-06-01 06:59", true, "2021-06-01 06:59"},
+		{night, "2021-06-01 07:00", false, "2021-06-01 23:00"},
+		{night, "2021-06-01 12:00", false, "2021-06-01 23:00"},
+		{day, "2021-06-01 08:00", false, "2021-06-01 09:00"},
+		{day, "2021-06-01 09:00", true, "2021-06-01 09:00"},
+		{day, "2021-06-01 17:00", false, "2021-06-02 09:00"},
+		{day, "2021-06-30 20:00", false, "2021-07-01 09:00"},
+	} {
+		tm := at(test.t)
+		assert.Equal(t, test.contains, test.w.Contains(tm), test.t)
+		assert.Equal(t, at(test.next), test.w.Next(tm), test.t)
+	}
+}
@@ -28,6 +28,9 @@ func AddFlags(flagSet *pflag.FlagSet) {
 	flags.DurationVarP(flagSet, &Opt.CachePollInterval, "vfs-cache-poll-interval", "", Opt.CachePollInterval, "Interval to poll the cache for stale objects.")
 	flags.DurationVarP(flagSet, &Opt.CacheMaxAge, "vfs-cache-max-age", "", Opt.CacheMaxAge, "Max age of objects in the cache.")
 	flags.FVarP(flagSet, &Opt.CacheMaxSize, "vfs-cache-max-size", "", "Max total size of objects in the cache.")
+	flags.FVarP(flagSet, &Opt.CacheChunkSize, "vfs-cache-chunk-size", "", "Evict cold parts of files from the cache in chunks of this size when using cache-mode full. 0 to evict whole files.")
+	flags.BoolVarP(flagSet, &Opt.CacheVerify, "vfs-cache-verify", "", Opt.CacheVerify, "Check the hash of cached files against the remote and discard them if corrupted.")
+	flags.BoolVarP(flagSet, &Opt.CacheBypassDirect, "vfs-cache-bypass-direct", "", Opt.CacheBypassDirect, "Read files opened with O_DIRECT straight from the remote, not through the cache.")
 	flags.FVarP(flagSet, &Opt.ChunkSize, "vfs-read-chunk-size", "", "Read the source objects in chunks.")
 	flags.FVarP(flagSet, &Opt.ChunkSizeLimit, "vfs-read-chunk-size-limit", "", "If greater than --vfs-read-chunk-size, double the chunk size after each chunk read, until the limit is reached. 'off' is unlimited.")
 	flags.FVarP(flagSet, DirPerms, "dir-perms", "", "Directory permissions")
@@ -36,7 +39,13 @@ func AddFlags(flagSet *pflag.FlagSet) {
 	flags.DurationVarP(flagSet, &Opt.WriteWait, "vfs-write-wait", "", Opt.WriteWait, "Time to wait for in-sequence write before giving error.")
 	flags.DurationVarP(flagSet, &Opt.ReadWait, "vfs-read-wait", "", Opt.ReadWait, "Time to wait for in-sequence read before seeking.")
 	flags.DurationVarP(flagSet, &Opt.WriteBack, "vfs-write-back", "", Opt.WriteBack, "Time to writeback files after last use when using cache.")
+	flags.FVarP(flagSet, &Opt.WriteBackWindow, "vfs-write-back-window", "", "Only start writing back files in this daily time window, eg \"23:00-07:00\".")
+	flags.IntVarP(flagSet, &Opt.WriteBackUploads, "vfs-write-back-uploads", "", Opt.WriteBackUploads, "Max number of files to write back in parallel. 0 to use --transfers.")
+	flags.FVarP(flagSet, &Opt.WriteBackBwLimit, "vfs-write-back-bwlimit", "", "Bandwidth limit for writing back files in bytes/s. 0 for no limit.")
 	flags.FVarP(flagSet, &Opt.ReadAhead, "vfs-read-ahead", "", "Extra read ahead over --buffer-size when using cache-mode full.")
+	flags.BoolVarP(flagSet, &Opt.ReadAheadAdaptive, "vfs-read-ahead-adaptive", "", Opt.ReadAheadAdaptive, "Grow the read ahead for sequential reads when using cache-mode full.")
+	flags.FVarP(flagSet, &Opt.ReadAheadMax, "vfs-read-ahead-max", "", "Max read ahead when using --vfs-read-ahead-adaptive.")
 	flags.BoolVarP(flagSet, &Opt.UsedIsSize, "vfs-used-is-size", "", Opt.UsedIsSize, "Use the `rclone size` algorithm for Used size.")
+	flags.BoolVarP(flagSet, &Opt.ReadDirPlus, "vfs-readdirplus", "", Opt.ReadDirPlus, "Read file attributes when listing directories and let the kernel cache them.")
 	platformFlags(flagSet)
 }
@@ -27,6 +27,7 @@ type WriteFileHandle struct {
 	opened      bool
 	flags       int
 	truncated   bool
+	stats       *handleStats
 }
 
 // Check interfaces
@@ -45,6 +46,7 @@ func newWriteFileHandle(d *Dir, f *File, remote string, flags int) (*WriteFileHa
 	}
 	fh.cond = sync.NewCond(&fh.mu)
 	fh.file.addWriter(fh)
+	fh.stats = newHandleStats(f, "write", flags)
 	return fh, nil
 }
 
@@ -119,7 +121,9 @@ func (fh *WriteFileHandle) Node() Node {
 func (fh *WriteFileHandle) WriteAt(p []byte, off int64) (n int, err error) {
 	fh.mu.Lock()
 	defer fh.mu.Unlock()
-	return fh.writeAt(p, off)
+	n, err = fh.writeAt(p, off)
+	fh.stats.write(off, n)
+	return n, err
 }
 
 // Implementation of WriteAt - call with lock held
@@ -163,7 +167,10 @@ func (fh *WriteFileHandle) Write(p []byte) (n int, err error) {
 	fh.mu.Lock()
 	defer fh.mu.Unlock()
 	// Since we can't seek, just call WriteAt with the current offset
-	return fh.writeAt(p, fh.offset)
+	off := fh.offset
+	n, err = fh.writeAt(p, off)
+	fh.stats.write(off, n)
+	return n, err
 }
 
 // WriteString a string to the file
@@ -187,6 +194,7 @@ func (fh *WriteFileHandle) close() (err error) {
 		return ECLOSED
 	}
 	fh.closed = true
+	fh.stats.done()
 	// leave writer open until file is transferred
 	defer func() {
 		fh.file.delWriter(fh)